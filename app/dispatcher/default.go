@@ -4,6 +4,7 @@ import (
 	"context"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/xtls/xray-core/common"
@@ -98,6 +99,8 @@ type DefaultDispatcher struct {
 	policy policy.Manager
 	stats  stats.Manager
 	fdns   dns.FakeDNSEngine
+
+	validity *validityTracker
 }
 
 func init() {
@@ -121,9 +124,28 @@ func (d *DefaultDispatcher) Init(config *Config, om outbound.Manager, router rou
 	d.router = router
 	d.policy = pm
 	d.stats = sm
+	channel, _ := stats.GetOrRegisterChannel(sm, stats.UserExpiryChannel)
+	d.validity = newValidityTracker(channel)
+	return nil
+}
+
+// checkUser returns an error if the user of the inbound is not accepted now.
+func checkUser(ctx context.Context) error {
+	if inbound := session.InboundFromContext(ctx); inbound != nil && inbound.User != nil {
+		if err := inbound.User.Check(time.Now()); err != nil {
+			return errors.New("rejected user ", inbound.User.Email).Base(err)
+		}
+	}
 	return nil
 }
 
+// trackUser closes the session with close when its user expires or is disabled.
+func (d *DefaultDispatcher) trackUser(ctx context.Context, close func()) {
+	if inbound := session.InboundFromContext(ctx); inbound != nil && inbound.User != nil && d.validity != nil {
+		d.validity.track(ctx, inbound.User, close)
+	}
+}
+
 // Type implements common.HasType.
 func (*DefaultDispatcher) Type() interface{} {
 	return routing.DispatcherType()
@@ -139,6 +161,24 @@ func (*DefaultDispatcher) Close() error { return nil }
 
 func (d *DefaultDispatcher) getLink(ctx context.Context) (*transport.Link, *transport.Link) {
 	opt := pipe.OptionsFromContext(ctx)
+	sessionInbound := session.InboundFromContext(ctx)
+	var user *protocol.MemoryUser
+	if sessionInbound != nil {
+		user = sessionInbound.User
+	}
+
+	// the sessions of a Mux connection share its context, so the session is tracked until its link is done
+	trackCtx := ctx
+	if user != nil && user.Validity != nil {
+		var cancel context.CancelFunc
+		trackCtx, cancel = context.WithCancel(ctx)
+		var done atomic.Int32
+		opt = append(opt, pipe.OnDone(func() {
+			if done.Add(1) == 2 {
+				cancel()
+			}
+		}))
+	}
 	uplinkReader, uplinkWriter := pipe.New(opt...)
 	downlinkReader, downlinkWriter := pipe.New(opt...)
 
@@ -152,12 +192,6 @@ func (d *DefaultDispatcher) getLink(ctx context.Context) (*transport.Link, *tran
 		Writer: downlinkWriter,
 	}
 
-	sessionInbound := session.InboundFromContext(ctx)
-	var user *protocol.MemoryUser
-	if sessionInbound != nil {
-		user = sessionInbound.User
-	}
-
 	if user != nil && len(user.Email) > 0 {
 		p := d.policy.ForLevel(user.Level)
 		if p.Stats.UserUplink {
@@ -184,6 +218,11 @@ func (d *DefaultDispatcher) getLink(ctx context.Context) (*transport.Link, *tran
 		}
 	}
 
+	d.trackUser(trackCtx, func() {
+		common.Interrupt(uplinkReader)
+		common.Interrupt(downlinkReader)
+	})
+
 	return inboundLink, outboundLink
 }
 
@@ -268,6 +307,9 @@ func (d *DefaultDispatcher) Dispatch(ctx context.Context, destination net.Destin
 	if !destination.IsValid() {
 		panic("Dispatcher: Invalid destination.")
 	}
+	if err := checkUser(ctx); err != nil {
+		return nil, err
+	}
 	outbounds := session.OutboundsFromContext(ctx)
 	if len(outbounds) == 0 {
 		outbounds = []*session.Outbound{{}}
@@ -284,7 +326,8 @@ func (d *DefaultDispatcher) Dispatch(ctx context.Context, destination net.Destin
 
 	sniffingRequest := content.SniffingRequest
 	inbound, outbound := d.getLink(ctx)
-	if !sniffingRequest.Enabled {
+	// ICMP packets carry nothing to sniff.
+	if !sniffingRequest.Enabled || destination.Network == net.Network_ICMP {
 		go d.routedDispatch(ctx, outbound, destination)
 	} else {
 		go func() {
@@ -325,6 +368,9 @@ func (d *DefaultDispatcher) DispatchLink(ctx context.Context, destination net.De
 	if !destination.IsValid() {
 		return errors.New("Dispatcher: Invalid destination.")
 	}
+	if err := checkUser(ctx); err != nil {
+		return err
+	}
 	outbounds := session.OutboundsFromContext(ctx)
 	if len(outbounds) == 0 {
 		outbounds = []*session.Outbound{{}}
@@ -339,8 +385,20 @@ func (d *DefaultDispatcher) DispatchLink(ctx context.Context, destination net.De
 		ctx = session.ContextWithContent(ctx, content)
 	}
 	outbound = WrapLink(ctx, d.policy, d.stats, outbound)
+	if inbound := session.InboundFromContext(ctx); inbound != nil && inbound.User != nil && inbound.User.Validity != nil {
+		// the link may not be interruptible, the outbound is stopped by the context instead
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithCancel(ctx)
+		defer cancel()
+		link := outbound
+		d.trackUser(ctx, func() {
+			cancel()
+			common.Interrupt(link.Reader)
+			common.Interrupt(link.Writer)
+		})
+	}
 	sniffingRequest := content.SniffingRequest
-	if !sniffingRequest.Enabled {
+	if !sniffingRequest.Enabled || destination.Network == net.Network_ICMP {
 		d.routedDispatch(ctx, outbound, destination)
 	} else {
 		cReader := &cachedReader{
@@ -0,0 +1,145 @@
+package dispatcher
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/protocol"
+	"github.com/xtls/xray-core/features/stats"
+)
+
+// validityTracker closes the sessions of users once they expire or are disabled, and publishes their expiry.
+// The sessions watch the validity of their user themselves, the tracker only follows the users with an expiry.
+type validityTracker struct {
+	channel stats.Channel
+
+	access sync.Mutex
+	users  map[*protocol.Validity]*trackedUser
+}
+
+type trackedUser struct {
+	email string
+	// timer fires at the expiry of the user, it is kept after the sessions end so that the expiry is published
+	timer *time.Timer
+}
+
+func newValidityTracker(channel stats.Channel) *validityTracker {
+	return &validityTracker{
+		channel: channel,
+		users:   make(map[*protocol.Validity]*trackedUser),
+	}
+}
+
+// track registers a session of user until ctx is done, close is called if the user expires or is disabled before.
+// close must end ctx.
+func (t *validityTracker) track(ctx context.Context, user *protocol.MemoryUser, close func()) {
+	v := user.Validity
+	if v == nil {
+		return
+	}
+	var once sync.Once
+	stop := v.Watch(func() {
+		if v.Check(time.Now()) != nil {
+			once.Do(close)
+		} else if !v.ExpiresAt().IsZero() {
+			// extended, or given an expiry while the session runs
+			t.follow(v, user.Email)
+		}
+	})
+	// the user may expire between the check of the dispatcher and here
+	if err := v.Check(time.Now()); err != nil {
+		stop()
+		once.Do(close)
+		return
+	}
+	if !v.ExpiresAt().IsZero() {
+		t.follow(v, user.Email)
+	}
+	context.AfterFunc(ctx, func() {
+		stop()
+		if v.Removed() && !v.Watched() {
+			t.update(v)
+		}
+	})
+}
+
+// follow arms the timer of the expiry of v, if it is not followed yet.
+func (t *validityTracker) follow(v *protocol.Validity, email string) {
+	t.access.Lock()
+	defer t.access.Unlock()
+	if t.users[v] != nil {
+		return
+	}
+	u := &trackedUser{email: email}
+	t.users[v] = u
+	v.OnUpdate(func() { t.update(v) })
+	t.arm(v, u)
+}
+
+// forget stops following v, it must be called with the lock held
+func (t *validityTracker) forget(v *protocol.Validity, u *trackedUser) {
+	if u.timer != nil {
+		u.timer.Stop()
+		u.timer = nil
+	}
+	delete(t.users, v)
+}
+
+// arm sets the timer of u to the expiry of v, it must be called with the lock held
+func (t *validityTracker) arm(v *protocol.Validity, u *trackedUser) {
+	if u.timer != nil {
+		u.timer.Stop()
+		u.timer = nil
+	}
+	expiresAt := v.ExpiresAt()
+	if expiresAt.IsZero() {
+		return
+	}
+	u.timer = time.AfterFunc(time.Until(expiresAt), func() { t.expire(v) })
+}
+
+func (t *validityTracker) expire(v *protocol.Validity) {
+	t.access.Lock()
+	u := t.users[v]
+	if u == nil {
+		t.access.Unlock()
+		return
+	}
+	expiresAt := v.ExpiresAt()
+	if expiresAt.IsZero() || time.Now().Before(expiresAt) {
+		// extended after the timer fired
+		t.arm(v, u)
+		t.access.Unlock()
+		return
+	}
+	u.timer = nil
+	delete(t.users, v)
+	t.access.Unlock()
+
+	errors.LogInfo(context.Background(), "user ", u.email, " expired, closing its sessions")
+	v.Notify()
+	// the expiry of a removed user is not published
+	if t.channel != nil && !v.Removed() {
+		t.channel.Publish(context.Background(), stats.UserExpiry{
+			Email:     u.email,
+			ExpiresAt: expiresAt,
+		})
+	}
+}
+
+// update follows the new expiry of v, the sessions check the new validity themselves
+func (t *validityTracker) update(v *protocol.Validity) {
+	t.access.Lock()
+	defer t.access.Unlock()
+	u := t.users[v]
+	if u == nil {
+		return
+	}
+	if v.ExpiresAt().IsZero() || v.Removed() && !v.Watched() {
+		t.forget(v, u)
+		return
+	}
+	t.arm(v, u)
+}
@@ -0,0 +1,164 @@
+package dispatcher
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/xtls/xray-core/app/stats"
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/buf"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/protocol"
+	"github.com/xtls/xray-core/common/session"
+	"github.com/xtls/xray-core/features/policy"
+	feature_stats "github.com/xtls/xray-core/features/stats"
+)
+
+// waitFor polls cond until it holds, for up to 5 seconds, and returns whether it does.
+func waitFor(cond func() bool) bool {
+	for deadline := time.Now().Add(5 * time.Second); !cond(); time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestValidityTracker(t *testing.T) {
+	channel := stats.NewChannel(&stats.ChannelConfig{BufferSize: 16})
+	subscriber, err := feature_stats.SubscribeRunnableChannel(channel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer feature_stats.UnsubscribeClosableChannel(channel, subscriber)
+	tracker := newValidityTracker(channel)
+
+	expiresAt := time.Now().Add(time.Second).Truncate(time.Second).Add(time.Second)
+	user := &protocol.MemoryUser{
+		Email:    "love@example.com",
+		Validity: protocol.NewValidity(expiresAt.Unix(), 0, true),
+	}
+	closed := make(chan string, 4)
+	// track starts a session, which ends once closed as the links of the dispatcher do
+	track := func(name string) context.CancelFunc {
+		ctx, cancel := context.WithCancel(context.Background())
+		tracker.track(ctx, user, func() {
+			closed <- name
+			cancel()
+		})
+		return cancel
+	}
+	cancel := track("first")
+	track("second")
+	// a session which ended before the expiry is not closed
+	cancel()
+
+	select {
+	case value := <-subscriber:
+		if expiry := value.(feature_stats.UserExpiry); expiry.Email != user.Email || !expiry.ExpiresAt.Equal(expiresAt) {
+			t.Error("unexpected expiry ", expiry)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the expiry to be published")
+	}
+	if len(closed) != 1 || <-closed != "second" {
+		t.Error("expected the session to be closed")
+	}
+
+	// a session of an expired user is closed at once
+	track("expired")
+	if len(closed) != 1 || <-closed != "expired" {
+		t.Error("expected the session of the expired user to be closed")
+	}
+
+	user.Validity.Update(time.Now().Add(time.Hour).Unix(), 0, true)
+	track("extended")
+	if len(closed) != 0 {
+		t.Fatal("unexpected closed session of the extended user")
+	}
+	user.Validity.Update(time.Now().Add(time.Hour).Unix(), 0, false)
+	if len(closed) != 1 || <-closed != "extended" {
+		t.Error("expected the session of the disabled user to be closed")
+	}
+	if len(tracker.users) != 1 {
+		t.Error("expected the expiry of the disabled user to be kept")
+	}
+
+	// a removed user is followed until its sessions end
+	user.Validity.Update(time.Now().Add(time.Hour).Unix(), 0, true)
+	cancel = track("removed")
+	user.Validity.Remove()
+	if len(tracker.users) != 1 {
+		t.Error("expected the removed user with a session to be kept")
+	}
+	cancel()
+	// the session is untracked asynchronously
+	if !waitFor(func() bool {
+		tracker.access.Lock()
+		defer tracker.access.Unlock()
+		return len(tracker.users) == 0
+	}) {
+		t.Fatal("expected the removed user to be forgotten")
+	}
+	if len(closed) != 0 {
+		t.Error("unexpected closed session of the removed user")
+	}
+}
+
+func TestValidityWithoutExpiry(t *testing.T) {
+	tracker := newValidityTracker(nil)
+	user := &protocol.MemoryUser{
+		Email:    "love@example.com",
+		Validity: protocol.NewValidity(0, 0, true),
+	}
+	closed := make(chan struct{}, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tracker.track(ctx, user, func() { closed <- struct{}{} })
+	if len(tracker.users) != 0 {
+		t.Error("unexpected tracked user without expiry")
+	}
+
+	// it is still closed once disabled
+	user.Validity.Update(0, 0, false)
+	if len(closed) != 1 {
+		t.Error("expected the session of the disabled user to be closed")
+	}
+}
+
+func TestValidityOfMuxSessions(t *testing.T) {
+	d := &DefaultDispatcher{
+		policy:   policy.DefaultManager{},
+		validity: newValidityTracker(nil),
+	}
+	user := &protocol.MemoryUser{
+		Email:    "love@example.com",
+		Validity: protocol.NewValidity(time.Now().Add(time.Hour).Unix(), 0, true),
+	}
+	// the sessions of a Mux connection share its context, which stays alive
+	connCtx := session.ContextWithInbound(context.Background(), &session.Inbound{
+		Source: net.TCPDestination(net.LocalHostIP, 10000),
+		User:   user,
+	})
+
+	for i := 0; i < 1000; i++ {
+		inbound, outbound := d.getLink(session.SubContextFromMuxInbound(connCtx))
+		common.Must(inbound.Writer.WriteMultiBuffer(buf.MultiBuffer{buf.FromBytes([]byte("hello"))}))
+		common.Close(inbound.Writer)
+		common.Interrupt(outbound.Reader)
+		common.Close(outbound.Writer)
+	}
+	if !waitFor(func() bool { return !user.Validity.Watched() }) {
+		t.Error("expected the ended sessions of the Mux connection to be untracked")
+	}
+
+	inbound, _ := d.getLink(session.SubContextFromMuxInbound(connCtx))
+	user.Validity.Update(0, 0, false)
+	if _, err := inbound.Reader.ReadMultiBuffer(); err == nil {
+		t.Error("expected the running session of the disabled user to be closed")
+	}
+	if !waitFor(func() bool { return !user.Validity.Watched() }) {
+		t.Error("expected the closed session to be untracked")
+	}
+}
@@ -3,7 +3,9 @@ package dns
 import (
 	"bytes"
 	"context"
+	gotls "crypto/tls"
 	"encoding/binary"
+	"math/rand"
 	"net/url"
 	"sync"
 	"time"
@@ -16,6 +18,8 @@ import (
 	"github.com/xtls/xray-core/common/protocol/dns"
 	"github.com/xtls/xray-core/common/session"
 	dns_feature "github.com/xtls/xray-core/features/dns"
+	"github.com/xtls/xray-core/transport/internet"
+	"github.com/xtls/xray-core/transport/internet/hysteria/udphop"
 	"github.com/xtls/xray-core/transport/internet/tls"
 	"golang.org/x/net/http2"
 )
@@ -33,10 +37,14 @@ type QUICNameServer struct {
 	destination     *net.Destination
 	connection      *quic.Conn
 	clientIP        net.IP
+
+	hopPorts       []uint32
+	hopIntervalMin time.Duration
+	hopIntervalMax time.Duration
 }
 
-// NewQUICNameServer creates DNS-over-QUIC client object for local resolving
-func NewQUICNameServer(url *url.URL, disableCache bool, serveStale bool, serveExpiredTTL uint32, clientIP net.IP) (*QUICNameServer, error) {
+// NewQUICNameServer creates DNS-over-QUIC client object for local resolving, hopping the ports of udpHop if any
+func NewQUICNameServer(url *url.URL, udpHop *internet.UdpHop, disableCache bool, serveStale bool, serveExpiredTTL uint32, clientIP net.IP) (*QUICNameServer, error) {
 	var err error
 	port := net.Port(853)
 	if url.Port() != "" {
@@ -53,6 +61,12 @@ func NewQUICNameServer(url *url.URL, disableCache bool, serveStale bool, serveEx
 		clientIP:        clientIP,
 	}
 
+	if udpHop != nil && len(udpHop.Ports) > 0 {
+		s.hopPorts = udpHop.Ports
+		s.hopIntervalMin = time.Duration(udpHop.IntervalMin) * time.Second
+		s.hopIntervalMax = time.Duration(udpHop.IntervalMax) * time.Second
+	}
+
 	errors.LogInfo(context.Background(), "DNS: created Local DNS-over-QUIC client for ", url.String())
 	return s, nil
 }
@@ -263,7 +277,13 @@ func (s *QUICNameServer) openConnection() (*quic.Conn, error) {
 		HandshakeIdleTimeout: handshakeTimeout,
 	}
 	tlsConfig.ServerName = s.destination.Address.String()
-	conn, err := quic.DialAddr(context.Background(), s.destination.NetAddr(), tlsConfig.GetTLSConfig(tls.WithNextProto("http/1.1", http2.NextProtoTLS, NextProtoDQ)), quicConfig)
+	var conn *quic.Conn
+	var err error
+	if len(s.hopPorts) > 0 {
+		conn, err = s.dialHop(tlsConfig.GetTLSConfig(tls.WithNextProto("http/1.1", http2.NextProtoTLS, NextProtoDQ)), quicConfig)
+	} else {
+		conn, err = quic.DialAddr(context.Background(), s.destination.NetAddr(), tlsConfig.GetTLSConfig(tls.WithNextProto("http/1.1", http2.NextProtoTLS, NextProtoDQ)), quicConfig)
+	}
 	log.Record(&log.AccessMessage{
 		From:   "DNS",
 		To:     s.destination,
@@ -277,6 +297,33 @@ func (s *QUICNameServer) openConnection() (*quic.Conn, error) {
 	return conn, nil
 }
 
+// dialHop dials the server on a random hop port, and keeps switching ports while the connection lives.
+func (s *QUICNameServer) dialHop(tlsConfig *gotls.Config, quicConfig *quic.Config) (*quic.Conn, error) {
+	udpAddr, err := net.ResolveUDPAddr("udp", s.destination.NetAddr())
+	if err != nil {
+		return nil, err
+	}
+	index := rand.Intn(len(s.hopPorts))
+	udpAddr.Port = int(s.hopPorts[index])
+
+	listenUDP := func(*net.UDPAddr) (net.PacketConn, error) {
+		return net.ListenUDP("udp", nil)
+	}
+	pktConn, err := listenUDP(udpAddr)
+	if err != nil {
+		return nil, err
+	}
+	hopConn := udphop.NewUDPHopPacketConn(udphop.ToAddrs(udpAddr.IP, s.hopPorts), s.hopIntervalMin, s.hopIntervalMax, listenUDP, pktConn, index)
+
+	conn, err := quic.Dial(context.Background(), hopConn, udpAddr, tlsConfig, quicConfig)
+	if err != nil {
+		hopConn.Close()
+		return nil, err
+	}
+	context.AfterFunc(conn.Context(), func() { hopConn.Close() })
+	return conn, nil
+}
+
 func (s *QUICNameServer) openStream(ctx context.Context) (*quic.Stream, error) {
 	conn, err := s.getConnection()
 	if err != nil {
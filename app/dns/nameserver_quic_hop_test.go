@@ -0,0 +1,97 @@
+package dns
+
+import (
+	"context"
+	gotls "crypto/tls"
+	"io"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/apernet/quic-go"
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/protocol/tls/cert"
+	"github.com/xtls/xray-core/transport/internet"
+	"github.com/xtls/xray-core/transport/internet/hysteria/udphop"
+)
+
+func TestQUICNameServerHop(t *testing.T) {
+	listen := func(addr *net.UDPAddr) (net.PacketConn, error) {
+		return net.ListenUDP("udp", addr)
+	}
+	mainConn := common.Must2(listen(&net.UDPAddr{IP: net.IP{127, 0, 0, 1}}))
+	var hopPorts []uint32
+	for i := 0; i < 3; i++ {
+		conn := common.Must2(listen(&net.UDPAddr{IP: net.IP{127, 0, 0, 1}}))
+		hopPorts = append(hopPorts, uint32(conn.LocalAddr().(*net.UDPAddr).Port))
+		conn.Close()
+	}
+	hopConn := common.Must2(udphop.ListenUDPHop(mainConn, hopPorts, listen))
+	defer hopConn.Close()
+
+	certificate := common.Must2(cert.Generate(nil, cert.DNSNames("127.0.0.1")))
+	certPEM, keyPEM := certificate.ToPEM()
+	keyPair := common.Must2(gotls.X509KeyPair(certPEM, keyPEM))
+	listener := common.Must2(quic.Listen(hopConn, &gotls.Config{
+		Certificates: []gotls.Certificate{keyPair},
+		NextProtos:   []string{NextProtoDQ},
+	}, nil))
+	defer listener.Close()
+
+	// the server echoes the first DoQ message of each stream
+	go func() {
+		for {
+			conn, err := listener.Accept(context.Background())
+			if err != nil {
+				return
+			}
+			go func() {
+				stream, err := conn.AcceptStream(context.Background())
+				if err != nil {
+					return
+				}
+				defer stream.Close()
+				b := make([]byte, 2)
+				if _, err := io.ReadFull(stream, b); err != nil {
+					return
+				}
+				msg := make([]byte, int(b[0])<<8|int(b[1]))
+				if _, err := io.ReadFull(stream, msg); err != nil {
+					return
+				}
+				stream.Write(append(b, msg...))
+			}()
+		}
+	}()
+
+	u := common.Must2(url.Parse("quic+local://127.0.0.1:" + strconv.Itoa(mainConn.LocalAddr().(*net.UDPAddr).Port)))
+	s := common.Must2(NewQUICNameServer(u, &internet.UdpHop{Ports: hopPorts, IntervalMin: 5}, false, false, 0, nil))
+	conn, err := s.dialHop(&gotls.Config{
+		InsecureSkipVerify: true,
+		NextProtos:         []string{NextProtoDQ},
+	}, &quic.Config{HandshakeIdleTimeout: handshakeTimeout})
+	common.Must(err)
+	defer conn.CloseWithError(0, "")
+
+	port := uint32(conn.RemoteAddr().(*net.UDPAddr).Port)
+	found := false
+	for _, p := range hopPorts {
+		found = found || p == port
+	}
+	if !found {
+		t.Error("dialed port ", port, ", expected one of ", hopPorts)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stream := common.Must2(conn.OpenStreamSync(ctx))
+	stream.SetDeadline(time.Now().Add(5 * time.Second))
+	common.Must2(stream.Write([]byte{0, 4, 'p', 'i', 'n', 'g'}))
+	b := make([]byte, 6)
+	common.Must2(io.ReadFull(stream, b))
+	if string(b[2:]) != "ping" {
+		t.Error("unexpected response: ", string(b[2:]))
+	}
+}
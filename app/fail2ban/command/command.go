@@ -0,0 +1,67 @@
+package command
+
+import (
+	"context"
+
+	"github.com/xtls/xray-core/app/fail2ban"
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/core"
+	"github.com/xtls/xray-core/features/extension"
+	grpc "google.golang.org/grpc"
+)
+
+type fail2banServer struct {
+	UnimplementedFail2BanServiceServer
+	fail2ban *fail2ban.Fail2ban
+}
+
+func (s *fail2banServer) ListBans(ctx context.Context, request *ListBansRequest) (*ListBansResponse, error) {
+	bans := s.fail2ban.Bans(request.Jail)
+	response := &ListBansResponse{
+		Bans: make([]*Ban, 0, len(bans)),
+	}
+	for _, b := range bans {
+		response.Bans = append(response.Bans, &Ban{
+			Jail:     b.Jail,
+			Ip:       b.IP.String(),
+			Failures: uint32(b.Failures),
+			Since:    b.Since.Unix(),
+			Until:    b.Until.Unix(),
+		})
+	}
+	return response, nil
+}
+
+func (s *fail2banServer) Unban(ctx context.Context, request *UnbanRequest) (*UnbanResponse, error) {
+	ip := net.ParseAddress(request.Ip)
+	if !ip.Family().IsIP() {
+		return nil, errors.New("invalid IP: ", request.Ip)
+	}
+	count, err := s.fail2ban.Unban(request.Jail, ip)
+	if err != nil {
+		return nil, err
+	}
+	return &UnbanResponse{Count: uint32(count)}, nil
+}
+
+func (s *fail2banServer) Register(server *grpc.Server) {
+	RegisterFail2BanServiceServer(server, s)
+}
+
+func init() {
+	common.Must(common.RegisterConfig((*Config)(nil), func(ctx context.Context, cfg interface{}) (interface{}, error) {
+		s := &fail2banServer{}
+		if err := core.RequireFeatures(ctx, func(f extension.Fail2ban) error {
+			var ok bool
+			if s.fail2ban, ok = f.(*fail2ban.Fail2ban); !ok {
+				return errors.New("fail2ban command requires the fail2ban app")
+			}
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+		return s, nil
+	}))
+}
@@ -0,0 +1,407 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        v6.33.5
+// source: app/fail2ban/command/command.proto
+
+package command
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Ban struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Jail  string                 `protobuf:"bytes,1,opt,name=jail,proto3" json:"jail,omitempty"`
+	Ip    string                 `protobuf:"bytes,2,opt,name=ip,proto3" json:"ip,omitempty"`
+	// Number of failures which got the IP banned.
+	Failures uint32 `protobuf:"varint,3,opt,name=failures,proto3" json:"failures,omitempty"`
+	// Unix time of the ban and of its end.
+	Since         int64 `protobuf:"varint,4,opt,name=since,proto3" json:"since,omitempty"`
+	Until         int64 `protobuf:"varint,5,opt,name=until,proto3" json:"until,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Ban) Reset() {
+	*x = Ban{}
+	mi := &file_app_fail2ban_command_command_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Ban) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Ban) ProtoMessage() {}
+
+func (x *Ban) ProtoReflect() protoreflect.Message {
+	mi := &file_app_fail2ban_command_command_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Ban.ProtoReflect.Descriptor instead.
+func (*Ban) Descriptor() ([]byte, []int) {
+	return file_app_fail2ban_command_command_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Ban) GetJail() string {
+	if x != nil {
+		return x.Jail
+	}
+	return ""
+}
+
+func (x *Ban) GetIp() string {
+	if x != nil {
+		return x.Ip
+	}
+	return ""
+}
+
+func (x *Ban) GetFailures() uint32 {
+	if x != nil {
+		return x.Failures
+	}
+	return 0
+}
+
+func (x *Ban) GetSince() int64 {
+	if x != nil {
+		return x.Since
+	}
+	return 0
+}
+
+func (x *Ban) GetUntil() int64 {
+	if x != nil {
+		return x.Until
+	}
+	return 0
+}
+
+type ListBansRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Lists the bans of all the jails if empty.
+	Jail          string `protobuf:"bytes,1,opt,name=jail,proto3" json:"jail,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListBansRequest) Reset() {
+	*x = ListBansRequest{}
+	mi := &file_app_fail2ban_command_command_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListBansRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListBansRequest) ProtoMessage() {}
+
+func (x *ListBansRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_app_fail2ban_command_command_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListBansRequest.ProtoReflect.Descriptor instead.
+func (*ListBansRequest) Descriptor() ([]byte, []int) {
+	return file_app_fail2ban_command_command_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ListBansRequest) GetJail() string {
+	if x != nil {
+		return x.Jail
+	}
+	return ""
+}
+
+type ListBansResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Bans          []*Ban                 `protobuf:"bytes,1,rep,name=bans,proto3" json:"bans,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListBansResponse) Reset() {
+	*x = ListBansResponse{}
+	mi := &file_app_fail2ban_command_command_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListBansResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListBansResponse) ProtoMessage() {}
+
+func (x *ListBansResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_app_fail2ban_command_command_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListBansResponse.ProtoReflect.Descriptor instead.
+func (*ListBansResponse) Descriptor() ([]byte, []int) {
+	return file_app_fail2ban_command_command_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ListBansResponse) GetBans() []*Ban {
+	if x != nil {
+		return x.Bans
+	}
+	return nil
+}
+
+type UnbanRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Lifts the bans of the IP in all the jails if empty.
+	Jail          string `protobuf:"bytes,1,opt,name=jail,proto3" json:"jail,omitempty"`
+	Ip            string `protobuf:"bytes,2,opt,name=ip,proto3" json:"ip,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UnbanRequest) Reset() {
+	*x = UnbanRequest{}
+	mi := &file_app_fail2ban_command_command_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UnbanRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UnbanRequest) ProtoMessage() {}
+
+func (x *UnbanRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_app_fail2ban_command_command_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UnbanRequest.ProtoReflect.Descriptor instead.
+func (*UnbanRequest) Descriptor() ([]byte, []int) {
+	return file_app_fail2ban_command_command_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *UnbanRequest) GetJail() string {
+	if x != nil {
+		return x.Jail
+	}
+	return ""
+}
+
+func (x *UnbanRequest) GetIp() string {
+	if x != nil {
+		return x.Ip
+	}
+	return ""
+}
+
+type UnbanResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Number of bans lifted.
+	Count         uint32 `protobuf:"varint,1,opt,name=count,proto3" json:"count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UnbanResponse) Reset() {
+	*x = UnbanResponse{}
+	mi := &file_app_fail2ban_command_command_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UnbanResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UnbanResponse) ProtoMessage() {}
+
+func (x *UnbanResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_app_fail2ban_command_command_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UnbanResponse.ProtoReflect.Descriptor instead.
+func (*UnbanResponse) Descriptor() ([]byte, []int) {
+	return file_app_fail2ban_command_command_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *UnbanResponse) GetCount() uint32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+type Config struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Config) Reset() {
+	*x = Config{}
+	mi := &file_app_fail2ban_command_command_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Config) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Config) ProtoMessage() {}
+
+func (x *Config) ProtoReflect() protoreflect.Message {
+	mi := &file_app_fail2ban_command_command_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Config.ProtoReflect.Descriptor instead.
+func (*Config) Descriptor() ([]byte, []int) {
+	return file_app_fail2ban_command_command_proto_rawDescGZIP(), []int{5}
+}
+
+var File_app_fail2ban_command_command_proto protoreflect.FileDescriptor
+
+const file_app_fail2ban_command_command_proto_rawDesc = "" +
+	"\n" +
+	"\"app/fail2ban/command/command.proto\x12\x19xray.app.fail2ban.command\"q\n" +
+	"\x03Ban\x12\x12\n" +
+	"\x04jail\x18\x01 \x01(\tR\x04jail\x12\x0e\n" +
+	"\x02ip\x18\x02 \x01(\tR\x02ip\x12\x1a\n" +
+	"\bfailures\x18\x03 \x01(\rR\bfailures\x12\x14\n" +
+	"\x05since\x18\x04 \x01(\x03R\x05since\x12\x14\n" +
+	"\x05until\x18\x05 \x01(\x03R\x05until\"%\n" +
+	"\x0fListBansRequest\x12\x12\n" +
+	"\x04jail\x18\x01 \x01(\tR\x04jail\"F\n" +
+	"\x10ListBansResponse\x122\n" +
+	"\x04bans\x18\x01 \x03(\v2\x1e.xray.app.fail2ban.command.BanR\x04bans\"2\n" +
+	"\fUnbanRequest\x12\x12\n" +
+	"\x04jail\x18\x01 \x01(\tR\x04jail\x12\x0e\n" +
+	"\x02ip\x18\x02 \x01(\tR\x02ip\"%\n" +
+	"\rUnbanResponse\x12\x14\n" +
+	"\x05count\x18\x01 \x01(\rR\x05count\"\b\n" +
+	"\x06Config2\xd6\x01\n" +
+	"\x0fFail2banService\x12e\n" +
+	"\bListBans\x12*.xray.app.fail2ban.command.ListBansRequest\x1a+.xray.app.fail2ban.command.ListBansResponse\"\x00\x12\\\n" +
+	"\x05Unban\x12'.xray.app.fail2ban.command.UnbanRequest\x1a(.xray.app.fail2ban.command.UnbanResponse\"\x00Bm\n" +
+	"\x1dcom.xray.app.fail2ban.commandP\x01Z.github.com/xtls/xray-core/app/fail2ban/command\xaa\x02\x19Xray.App.Fail2ban.Commandb\x06proto3"
+
+var (
+	file_app_fail2ban_command_command_proto_rawDescOnce sync.Once
+	file_app_fail2ban_command_command_proto_rawDescData []byte
+)
+
+func file_app_fail2ban_command_command_proto_rawDescGZIP() []byte {
+	file_app_fail2ban_command_command_proto_rawDescOnce.Do(func() {
+		file_app_fail2ban_command_command_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_app_fail2ban_command_command_proto_rawDesc), len(file_app_fail2ban_command_command_proto_rawDesc)))
+	})
+	return file_app_fail2ban_command_command_proto_rawDescData
+}
+
+var file_app_fail2ban_command_command_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_app_fail2ban_command_command_proto_goTypes = []any{
+	(*Ban)(nil),              // 0: xray.app.fail2ban.command.Ban
+	(*ListBansRequest)(nil),  // 1: xray.app.fail2ban.command.ListBansRequest
+	(*ListBansResponse)(nil), // 2: xray.app.fail2ban.command.ListBansResponse
+	(*UnbanRequest)(nil),     // 3: xray.app.fail2ban.command.UnbanRequest
+	(*UnbanResponse)(nil),    // 4: xray.app.fail2ban.command.UnbanResponse
+	(*Config)(nil),           // 5: xray.app.fail2ban.command.Config
+}
+var file_app_fail2ban_command_command_proto_depIdxs = []int32{
+	0, // 0: xray.app.fail2ban.command.ListBansResponse.bans:type_name -> xray.app.fail2ban.command.Ban
+	1, // 1: xray.app.fail2ban.command.Fail2banService.ListBans:input_type -> xray.app.fail2ban.command.ListBansRequest
+	3, // 2: xray.app.fail2ban.command.Fail2banService.Unban:input_type -> xray.app.fail2ban.command.UnbanRequest
+	2, // 3: xray.app.fail2ban.command.Fail2banService.ListBans:output_type -> xray.app.fail2ban.command.ListBansResponse
+	4, // 4: xray.app.fail2ban.command.Fail2banService.Unban:output_type -> xray.app.fail2ban.command.UnbanResponse
+	3, // [3:5] is the sub-list for method output_type
+	1, // [1:3] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_app_fail2ban_command_command_proto_init() }
+func file_app_fail2ban_command_command_proto_init() {
+	if File_app_fail2ban_command_command_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_app_fail2ban_command_command_proto_rawDesc), len(file_app_fail2ban_command_command_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   6,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_app_fail2ban_command_command_proto_goTypes,
+		DependencyIndexes: file_app_fail2ban_command_command_proto_depIdxs,
+		MessageInfos:      file_app_fail2ban_command_command_proto_msgTypes,
+	}.Build()
+	File_app_fail2ban_command_command_proto = out.File
+	file_app_fail2ban_command_command_proto_goTypes = nil
+	file_app_fail2ban_command_command_proto_depIdxs = nil
+}
@@ -0,0 +1,159 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.0
+// - protoc             v6.33.5
+// source: app/fail2ban/command/command.proto
+
+package command
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Fail2BanService_ListBans_FullMethodName = "/xray.app.fail2ban.command.Fail2banService/ListBans"
+	Fail2BanService_Unban_FullMethodName    = "/xray.app.fail2ban.command.Fail2banService/Unban"
+)
+
+// Fail2BanServiceClient is the client API for Fail2BanService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type Fail2BanServiceClient interface {
+	ListBans(ctx context.Context, in *ListBansRequest, opts ...grpc.CallOption) (*ListBansResponse, error)
+	Unban(ctx context.Context, in *UnbanRequest, opts ...grpc.CallOption) (*UnbanResponse, error)
+}
+
+type fail2BanServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewFail2BanServiceClient(cc grpc.ClientConnInterface) Fail2BanServiceClient {
+	return &fail2BanServiceClient{cc}
+}
+
+func (c *fail2BanServiceClient) ListBans(ctx context.Context, in *ListBansRequest, opts ...grpc.CallOption) (*ListBansResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListBansResponse)
+	err := c.cc.Invoke(ctx, Fail2BanService_ListBans_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fail2BanServiceClient) Unban(ctx context.Context, in *UnbanRequest, opts ...grpc.CallOption) (*UnbanResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UnbanResponse)
+	err := c.cc.Invoke(ctx, Fail2BanService_Unban_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Fail2BanServiceServer is the server API for Fail2BanService service.
+// All implementations must embed UnimplementedFail2BanServiceServer
+// for forward compatibility.
+type Fail2BanServiceServer interface {
+	ListBans(context.Context, *ListBansRequest) (*ListBansResponse, error)
+	Unban(context.Context, *UnbanRequest) (*UnbanResponse, error)
+	mustEmbedUnimplementedFail2BanServiceServer()
+}
+
+// UnimplementedFail2BanServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedFail2BanServiceServer struct{}
+
+func (UnimplementedFail2BanServiceServer) ListBans(context.Context, *ListBansRequest) (*ListBansResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListBans not implemented")
+}
+func (UnimplementedFail2BanServiceServer) Unban(context.Context, *UnbanRequest) (*UnbanResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Unban not implemented")
+}
+func (UnimplementedFail2BanServiceServer) mustEmbedUnimplementedFail2BanServiceServer() {}
+func (UnimplementedFail2BanServiceServer) testEmbeddedByValue()                         {}
+
+// UnsafeFail2BanServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to Fail2BanServiceServer will
+// result in compilation errors.
+type UnsafeFail2BanServiceServer interface {
+	mustEmbedUnimplementedFail2BanServiceServer()
+}
+
+func RegisterFail2BanServiceServer(s grpc.ServiceRegistrar, srv Fail2BanServiceServer) {
+	// If the following call panics, it indicates UnimplementedFail2BanServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Fail2BanService_ServiceDesc, srv)
+}
+
+func _Fail2BanService_ListBans_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListBansRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(Fail2BanServiceServer).ListBans(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Fail2BanService_ListBans_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(Fail2BanServiceServer).ListBans(ctx, req.(*ListBansRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Fail2BanService_Unban_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UnbanRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(Fail2BanServiceServer).Unban(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Fail2BanService_Unban_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(Fail2BanServiceServer).Unban(ctx, req.(*UnbanRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Fail2BanService_ServiceDesc is the grpc.ServiceDesc for Fail2BanService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Fail2BanService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "xray.app.fail2ban.command.Fail2banService",
+	HandlerType: (*Fail2BanServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListBans",
+			Handler:    _Fail2BanService_ListBans_Handler,
+		},
+		{
+			MethodName: "Unban",
+			Handler:    _Fail2BanService_Unban_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "app/fail2ban/command/command.proto",
+}
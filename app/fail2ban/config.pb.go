@@ -0,0 +1,246 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        v6.33.5
+// source: app/fail2ban/config.proto
+
+package fail2ban
+
+import (
+	geodata "github.com/xtls/xray-core/common/geodata"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Jail bans the source IPs which keep failing the authentication of some inbounds.
+type Jail struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Name  string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// Inbounds whose failures are counted and which the bans apply to, all of them if empty.
+	InboundTag []string `protobuf:"bytes,2,rep,name=inbound_tag,json=inboundTag,proto3" json:"inbound_tag,omitempty"`
+	// Protocols whose failures are counted, such as "vless" or "reality", all of them but "reality" if empty.
+	Protocol []string `protobuf:"bytes,3,rep,name=protocol,proto3" json:"protocol,omitempty"`
+	// Number of failures of a source IP within find_time to ban it, 5 if unset.
+	MaxRetry uint32 `protobuf:"varint,4,opt,name=max_retry,json=maxRetry,proto3" json:"max_retry,omitempty"`
+	// Seconds in which the failures are counted, 600 if unset.
+	FindTime uint32 `protobuf:"varint,5,opt,name=find_time,json=findTime,proto3" json:"find_time,omitempty"`
+	// Seconds a source IP is banned for, 3600 if unset.
+	BanTime       uint32 `protobuf:"varint,6,opt,name=ban_time,json=banTime,proto3" json:"ban_time,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Jail) Reset() {
+	*x = Jail{}
+	mi := &file_app_fail2ban_config_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Jail) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Jail) ProtoMessage() {}
+
+func (x *Jail) ProtoReflect() protoreflect.Message {
+	mi := &file_app_fail2ban_config_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Jail.ProtoReflect.Descriptor instead.
+func (*Jail) Descriptor() ([]byte, []int) {
+	return file_app_fail2ban_config_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Jail) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Jail) GetInboundTag() []string {
+	if x != nil {
+		return x.InboundTag
+	}
+	return nil
+}
+
+func (x *Jail) GetProtocol() []string {
+	if x != nil {
+		return x.Protocol
+	}
+	return nil
+}
+
+func (x *Jail) GetMaxRetry() uint32 {
+	if x != nil {
+		return x.MaxRetry
+	}
+	return 0
+}
+
+func (x *Jail) GetFindTime() uint32 {
+	if x != nil {
+		return x.FindTime
+	}
+	return 0
+}
+
+func (x *Jail) GetBanTime() uint32 {
+	if x != nil {
+		return x.BanTime
+	}
+	return 0
+}
+
+type Config struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Jail  []*Jail                `protobuf:"bytes,1,rep,name=jail,proto3" json:"jail,omitempty"`
+	// Outbound which the requests from banned source IPs are routed to.
+	OutboundTag string `protobuf:"bytes,2,opt,name=outbound_tag,json=outboundTag,proto3" json:"outbound_tag,omitempty"`
+	// Source IPs which are never banned.
+	IgnoreIp      []*geodata.IPRule `protobuf:"bytes,3,rep,name=ignore_ip,json=ignoreIp,proto3" json:"ignore_ip,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Config) Reset() {
+	*x = Config{}
+	mi := &file_app_fail2ban_config_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Config) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Config) ProtoMessage() {}
+
+func (x *Config) ProtoReflect() protoreflect.Message {
+	mi := &file_app_fail2ban_config_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Config.ProtoReflect.Descriptor instead.
+func (*Config) Descriptor() ([]byte, []int) {
+	return file_app_fail2ban_config_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Config) GetJail() []*Jail {
+	if x != nil {
+		return x.Jail
+	}
+	return nil
+}
+
+func (x *Config) GetOutboundTag() string {
+	if x != nil {
+		return x.OutboundTag
+	}
+	return ""
+}
+
+func (x *Config) GetIgnoreIp() []*geodata.IPRule {
+	if x != nil {
+		return x.IgnoreIp
+	}
+	return nil
+}
+
+var File_app_fail2ban_config_proto protoreflect.FileDescriptor
+
+const file_app_fail2ban_config_proto_rawDesc = "" +
+	"\n" +
+	"\x19app/fail2ban/config.proto\x12\x11xray.app.fail2ban\x1a\x1bcommon/geodata/geodat.proto\"\xac\x01\n" +
+	"\x04Jail\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1f\n" +
+	"\vinbound_tag\x18\x02 \x03(\tR\n" +
+	"inboundTag\x12\x1a\n" +
+	"\bprotocol\x18\x03 \x03(\tR\bprotocol\x12\x1b\n" +
+	"\tmax_retry\x18\x04 \x01(\rR\bmaxRetry\x12\x1b\n" +
+	"\tfind_time\x18\x05 \x01(\rR\bfindTime\x12\x19\n" +
+	"\bban_time\x18\x06 \x01(\rR\abanTime\"\x92\x01\n" +
+	"\x06Config\x12+\n" +
+	"\x04jail\x18\x01 \x03(\v2\x17.xray.app.fail2ban.JailR\x04jail\x12!\n" +
+	"\foutbound_tag\x18\x02 \x01(\tR\voutboundTag\x128\n" +
+	"\tignore_ip\x18\x03 \x03(\v2\x1b.xray.common.geodata.IPRuleR\bignoreIpBU\n" +
+	"\x15com.xray.app.fail2banP\x01Z&github.com/xtls/xray-core/app/fail2ban\xaa\x02\x11Xray.App.Fail2banb\x06proto3"
+
+var (
+	file_app_fail2ban_config_proto_rawDescOnce sync.Once
+	file_app_fail2ban_config_proto_rawDescData []byte
+)
+
+func file_app_fail2ban_config_proto_rawDescGZIP() []byte {
+	file_app_fail2ban_config_proto_rawDescOnce.Do(func() {
+		file_app_fail2ban_config_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_app_fail2ban_config_proto_rawDesc), len(file_app_fail2ban_config_proto_rawDesc)))
+	})
+	return file_app_fail2ban_config_proto_rawDescData
+}
+
+var file_app_fail2ban_config_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_app_fail2ban_config_proto_goTypes = []any{
+	(*Jail)(nil),           // 0: xray.app.fail2ban.Jail
+	(*Config)(nil),         // 1: xray.app.fail2ban.Config
+	(*geodata.IPRule)(nil), // 2: xray.common.geodata.IPRule
+}
+var file_app_fail2ban_config_proto_depIdxs = []int32{
+	0, // 0: xray.app.fail2ban.Config.jail:type_name -> xray.app.fail2ban.Jail
+	2, // 1: xray.app.fail2ban.Config.ignore_ip:type_name -> xray.common.geodata.IPRule
+	2, // [2:2] is the sub-list for method output_type
+	2, // [2:2] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
+}
+
+func init() { file_app_fail2ban_config_proto_init() }
+func file_app_fail2ban_config_proto_init() {
+	if File_app_fail2ban_config_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_app_fail2ban_config_proto_rawDesc), len(file_app_fail2ban_config_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_app_fail2ban_config_proto_goTypes,
+		DependencyIndexes: file_app_fail2ban_config_proto_depIdxs,
+		MessageInfos:      file_app_fail2ban_config_proto_msgTypes,
+	}.Build()
+	File_app_fail2ban_config_proto = out.File
+	file_app_fail2ban_config_proto_goTypes = nil
+	file_app_fail2ban_config_proto_depIdxs = nil
+}
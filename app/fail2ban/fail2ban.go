@@ -0,0 +1,378 @@
+// Package fail2ban bans the source IPs which keep failing the authentication of inbounds for a while,
+// by rejecting their connections before the handshakes of the inbounds and routing their requests to an outbound.
+package fail2ban
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/xtls/xray-core/app/router"
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/geodata"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/serial"
+	"github.com/xtls/xray-core/common/signal/done"
+	"github.com/xtls/xray-core/core"
+	"github.com/xtls/xray-core/features/extension"
+	"github.com/xtls/xray-core/features/routing"
+	"github.com/xtls/xray-core/features/stats"
+)
+
+// Ban is a source IP banned by a jail.
+type Ban struct {
+	Jail     string
+	IP       net.Address
+	Failures int
+	Since    time.Time
+	Until    time.Time
+
+	timer *time.Timer
+}
+
+type jail struct {
+	name        string
+	inboundTags []string
+	protocols   []string
+	maxRetry    int
+	findTime    time.Duration
+	banTime     time.Duration
+
+	failures map[string][]time.Time
+	bans     map[string]*Ban
+}
+
+func newJail(config *Jail) *jail {
+	j := &jail{
+		name:        config.Name,
+		inboundTags: config.InboundTag,
+		protocols:   config.Protocol,
+		maxRetry:    int(config.MaxRetry),
+		findTime:    time.Duration(config.FindTime) * time.Second,
+		banTime:     time.Duration(config.BanTime) * time.Second,
+		failures:    make(map[string][]time.Time),
+		bans:        make(map[string]*Ban),
+	}
+	if j.maxRetry == 0 {
+		j.maxRetry = 5
+	}
+	if j.findTime == 0 {
+		j.findTime = 600 * time.Second
+	}
+	if j.banTime == 0 {
+		j.banTime = 3600 * time.Second
+	}
+	return j
+}
+
+// explicitProtocols are the protocols whose failures are only counted by the jails naming them. A REALITY
+// fallback is as well a visitor of the target site, and banning it would tell the server from the site.
+var explicitProtocols = []string{"reality"}
+
+func (j *jail) watches(failure *stats.AuthFailure) bool {
+	if len(j.inboundTags) > 0 && !slices.Contains(j.inboundTags, failure.InboundTag) {
+		return false
+	}
+	if len(j.protocols) == 0 {
+		return !slices.Contains(explicitProtocols, failure.Protocol)
+	}
+	return slices.Contains(j.protocols, failure.Protocol)
+}
+
+// fail records the failure, and returns the number of failures in find time if they are enough for a ban.
+func (j *jail) fail(ip string, now time.Time) int {
+	failures := j.failures[ip][:0]
+	for _, t := range j.failures[ip] {
+		if now.Sub(t) < j.findTime {
+			failures = append(failures, t)
+		}
+	}
+	failures = append(failures, now)
+	if len(failures) < j.maxRetry {
+		j.failures[ip] = failures
+		j.removeExpired(now)
+		return 0
+	}
+	delete(j.failures, ip)
+	return len(failures)
+}
+
+// removeExpired drops the failures out of find time, once there are many source IPs.
+func (j *jail) removeExpired(now time.Time) {
+	if len(j.failures) < 1024 {
+		return
+	}
+	for ip, failures := range j.failures {
+		if now.Sub(failures[len(failures)-1]) >= j.findTime {
+			delete(j.failures, ip)
+		}
+	}
+}
+
+func ruleTag(jail string, ip string) string {
+	return "fail2ban>>>" + jail + ">>>" + ip
+}
+
+// Fail2ban subscribes to the authentication failures published by inbounds, and bans the source IPs
+// which fail too often. The inbounds reject the connections from banned IPs when accepting them, and
+// before the REALITY handshake, see Banned. The routing rules added to the outbound are secondary,
+// they catch the requests of the connections accepted before the ban. As the rules are appended, they
+// don't apply to the requests matched by the configured rules before.
+type Fail2ban struct {
+	outboundTag string
+	ignore      geodata.IPMatcher // or nil
+	router      routing.Router
+	channel     stats.Channel
+
+	access sync.Mutex
+	jails  []*jail
+	sub    chan interface{}
+	done   *done.Instance
+}
+
+// Init initializes the Fail2ban with the router to add the rules to and the stats manager for the failures.
+func (f *Fail2ban) Init(config *Config, r routing.Router, sm stats.Manager) error {
+	if config.OutboundTag == "" {
+		return errors.New("fail2ban outbound tag is not specified")
+	}
+	channel, err := stats.GetOrRegisterChannel(sm, stats.AuthFailureChannel)
+	if err != nil {
+		return errors.New("fail2ban requires stats to be enabled").Base(err)
+	}
+	f.channel = channel
+	return f.init(config, r)
+}
+
+// New creates a Fail2ban which isn't subscribed to the authentication failures, they are reported to it
+// with Fail. It is used by the inbounds banning the sources failing their own authentication.
+func New(config *Config, r routing.Router) (*Fail2ban, error) {
+	if config.OutboundTag == "" {
+		return nil, errors.New("fail2ban outbound tag is not specified")
+	}
+	f := new(Fail2ban)
+	if err := f.init(config, r); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *Fail2ban) init(config *Config, r routing.Router) error {
+	f.outboundTag = config.OutboundTag
+	f.router = r
+	f.done = done.New()
+	if len(config.IgnoreIp) > 0 {
+		var err error
+		if f.ignore, err = geodata.IPReg.BuildIPMatcher(config.IgnoreIp); err != nil {
+			return errors.New("failed to build ignored IPs").Base(err)
+		}
+	}
+	names := make(map[string]bool)
+	for _, jc := range config.Jail {
+		if jc.Name == "" {
+			return errors.New("fail2ban jail name is not specified")
+		}
+		if names[jc.Name] {
+			return errors.New("duplicate fail2ban jail ", jc.Name)
+		}
+		names[jc.Name] = true
+		f.jails = append(f.jails, newJail(jc))
+	}
+	return nil
+}
+
+// Type implements common.HasType.
+func (*Fail2ban) Type() interface{} {
+	return extension.Fail2banType()
+}
+
+// Banned implements extension.Fail2ban. An IP is banned from the inbound if a jail watching it bans the IP.
+func (f *Fail2ban) Banned(inboundTag string, ip net.Address) bool {
+	f.access.Lock()
+	defer f.access.Unlock()
+	for _, j := range f.jails {
+		if (len(j.inboundTags) == 0 || slices.Contains(j.inboundTags, inboundTag)) && j.bans[ip.String()] != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Start implements common.Runnable.
+func (f *Fail2ban) Start() error {
+	sub, err := stats.SubscribeRunnableChannel(f.channel)
+	if err != nil {
+		return errors.New("failed to subscribe to authentication failures").Base(err)
+	}
+	f.access.Lock()
+	f.sub = sub
+	f.access.Unlock()
+	go f.run(sub)
+	return nil
+}
+
+func (f *Fail2ban) run(sub chan interface{}) {
+	for {
+		select {
+		case msg, ok := <-sub:
+			if !ok {
+				return
+			}
+			if failure, ok := msg.(stats.AuthFailure); ok {
+				f.Fail(&failure)
+			}
+		case <-f.done.Wait():
+			return
+		}
+	}
+}
+
+// Fail records the authentication failure in the jails watching it, and bans its source IP in those
+// it has failed often enough.
+func (f *Fail2ban) Fail(failure *stats.AuthFailure) {
+	if failure.Source == nil || !failure.Source.Family().IsIP() {
+		return
+	}
+	if f.ignore != nil && f.ignore.Match(failure.Source.IP()) {
+		return
+	}
+	ip := failure.Source.String()
+	now := failure.Time
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	f.access.Lock()
+	defer f.access.Unlock()
+	if f.done.Done() {
+		return
+	}
+	for _, j := range f.jails {
+		if !j.watches(failure) || j.bans[ip] != nil {
+			continue
+		}
+		if failures := j.fail(ip, now); failures > 0 {
+			f.ban(j, failure.Source, failures)
+		}
+	}
+}
+
+// ban adds the rule of the ban, it must be called with the lock held.
+func (f *Fail2ban) ban(j *jail, source net.Address, failures int) {
+	ip := source.String()
+	prefix := uint32(32)
+	if source.Family().IsIPv6() {
+		prefix = 128
+	}
+	config := &router.Config{
+		Rule: []*router.RoutingRule{{
+			RuleTag:    ruleTag(j.name, ip),
+			TargetTag:  &router.RoutingRule_Tag{Tag: f.outboundTag},
+			InboundTag: j.inboundTags,
+			SourceIp: []*geodata.IPRule{{Value: &geodata.IPRule_Custom{Custom: &geodata.CIDRRule{
+				Cidr: &geodata.CIDR{Ip: source.IP(), Prefix: prefix},
+			}}}},
+		}},
+	}
+	if err := f.router.AddRule(serial.ToTypedMessage(config), true); err != nil {
+		errors.LogWarningInner(context.Background(), err, "failed to ban ", ip, " in ", j.name)
+		return
+	}
+	now := time.Now()
+	b := &Ban{
+		Jail:     j.name,
+		IP:       source,
+		Failures: failures,
+		Since:    now,
+		Until:    now.Add(j.banTime),
+	}
+	b.timer = time.AfterFunc(j.banTime, func() {
+		f.access.Lock()
+		defer f.access.Unlock()
+		if j.bans[ip] == b {
+			f.unban(j, ip)
+		}
+	})
+	j.bans[ip] = b
+	errors.LogWarning(context.Background(), "banned ", ip, " in ", j.name, " for ", j.banTime, " after ", failures, " authentication failures")
+}
+
+// unban lifts the ban of the IP in the jail, it must be called with the lock held.
+func (f *Fail2ban) unban(j *jail, ip string) {
+	j.bans[ip].timer.Stop()
+	delete(j.bans, ip)
+	if err := f.router.RemoveRule(ruleTag(j.name, ip)); err != nil {
+		errors.LogWarningInner(context.Background(), err, "failed to unban ", ip, " in ", j.name)
+		return
+	}
+	errors.LogInfo(context.Background(), "unbanned ", ip, " in ", j.name)
+}
+
+// Bans returns the current bans of the named jail, or of all the jails if the name is empty.
+func (f *Fail2ban) Bans(name string) []*Ban {
+	f.access.Lock()
+	defer f.access.Unlock()
+	var bans []*Ban
+	for _, j := range f.jails {
+		if name != "" && j.name != name {
+			continue
+		}
+		for _, b := range j.bans {
+			bans = append(bans, b)
+		}
+	}
+	slices.SortFunc(bans, func(a, b *Ban) int {
+		return a.Since.Compare(b.Since)
+	})
+	return bans
+}
+
+// Unban lifts the ban of the IP in the named jail, or in all the jails if the name is empty, and returns
+// the number of bans lifted.
+func (f *Fail2ban) Unban(name string, ip net.Address) (int, error) {
+	if name != "" && !slices.ContainsFunc(f.jails, func(j *jail) bool { return j.name == name }) {
+		return 0, errors.New("fail2ban jail not found: ", name)
+	}
+	f.access.Lock()
+	defer f.access.Unlock()
+	count := 0
+	for _, j := range f.jails {
+		if (name == "" || j.name == name) && j.bans[ip.String()] != nil {
+			f.unban(j, ip.String())
+			count++
+		}
+	}
+	return count, nil
+}
+
+// Close implements common.Closable. It lifts all the bans.
+func (f *Fail2ban) Close() error {
+	f.access.Lock()
+	defer f.access.Unlock()
+	if f.done.Done() {
+		return nil
+	}
+	f.done.Close()
+	for _, j := range f.jails {
+		for ip := range j.bans {
+			f.unban(j, ip)
+		}
+	}
+	if f.sub != nil {
+		return stats.UnsubscribeClosableChannel(f.channel, f.sub)
+	}
+	return nil
+}
+
+func init() {
+	common.Must(common.RegisterConfig((*Config)(nil), func(ctx context.Context, config interface{}) (interface{}, error) {
+		f := new(Fail2ban)
+		if err := core.RequireFeatures(ctx, func(r routing.Router, sm stats.Manager) error {
+			return f.Init(config.(*Config), r, sm)
+		}); err != nil {
+			return nil, err
+		}
+		return f, nil
+	}))
+}
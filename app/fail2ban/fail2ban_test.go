@@ -0,0 +1,125 @@
+package fail2ban_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/xtls/xray-core/app/fail2ban"
+	"github.com/xtls/xray-core/app/router"
+	"github.com/xtls/xray-core/app/stats"
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/geodata"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/session"
+	routing_session "github.com/xtls/xray-core/features/routing/session"
+	feature_stats "github.com/xtls/xray-core/features/stats"
+)
+
+func TestFail2ban(t *testing.T) {
+	sm, err := stats.NewManager(context.Background(), &stats.Config{})
+	common.Must(err)
+	common.Must(sm.Start())
+	defer sm.Close()
+	r := new(router.Router)
+
+	f := new(Fail2ban)
+	common.Must(f.Init(&Config{
+		OutboundTag: "blocked",
+		IgnoreIp:    common.Must2(geodata.ParseIPRules([]string{"192.0.2.3"})),
+		Jail: []*Jail{
+			{Name: "vless", InboundTag: []string{"in"}, Protocol: []string{"vless"}, MaxRetry: 2},
+			{Name: "all", MaxRetry: 3, BanTime: 1},
+		},
+	}, r, sm))
+	common.Must(f.Start())
+	defer f.Close()
+
+	pick := func(source string) string {
+		ctx := session.ContextWithInbound(context.Background(), &session.Inbound{
+			Source: net.TCPDestination(net.ParseAddress(source), 1234),
+			Tag:    "in",
+		})
+		ctx = session.ContextWithOutbounds(ctx, []*session.Outbound{{Target: net.TCPDestination(net.LocalHostIP, 80)}})
+		route, err := r.PickRoute(routing_session.AsRoutingContext(ctx))
+		if err != nil {
+			return ""
+		}
+		return route.GetOutboundTag()
+	}
+	fail := func(source string, protocol string) {
+		feature_stats.PublishAuthFailure(sm, feature_stats.AuthFailure{
+			InboundTag: "in",
+			Protocol:   protocol,
+			Source:     net.ParseAddress(source),
+			Reason:     "invalid_user",
+		})
+	}
+	waitBans := func(n int) []*Ban {
+		for i := 0; i < 100; i++ {
+			if bans := f.Bans(""); len(bans) == n {
+				return bans
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		t.Fatal("unexpected bans: ", f.Bans(""))
+		return nil
+	}
+
+	fail("192.0.2.1", "trojan")
+	fail("192.0.2.1", "trojan")
+	fail("192.0.2.2", "vless")
+	fail("192.0.2.3", "vless")
+	fail("192.0.2.3", "vless")
+	for i := 0; i < 3; i++ {
+		fail("192.0.2.4", "reality")
+	}
+	waitBans(0)
+	if tag := pick("192.0.2.1"); tag != "" {
+		t.Fatal("banned before max retry: ", tag)
+	}
+
+	fail("192.0.2.2", "vless")
+	fail("192.0.2.1", "trojan")
+	bans := waitBans(2)
+	if tag := pick("192.0.2.1"); tag != "blocked" {
+		t.Error("not banned in all: ", tag)
+	}
+	if tag := pick("192.0.2.2"); tag != "blocked" {
+		t.Error("not banned in vless: ", tag)
+	}
+	if tag := pick("192.0.2.3"); tag != "" {
+		t.Error("ignored IP banned: ", tag)
+	}
+	if !f.Banned("in", net.ParseAddress("192.0.2.2")) || !f.Banned("other", net.ParseAddress("192.0.2.1")) {
+		t.Error("banned IPs not rejected")
+	}
+	if f.Banned("other", net.ParseAddress("192.0.2.2")) || f.Banned("in", net.ParseAddress("192.0.2.3")) {
+		t.Error("IPs rejected out of the jails")
+	}
+	for _, b := range bans {
+		if b.IP.String() == "192.0.2.2" && b.Jail != "vless" || b.IP.String() == "192.0.2.1" && b.Jail != "all" {
+			t.Error("unexpected ban: ", b)
+		}
+	}
+
+	count, err := f.Unban("", net.ParseAddress("192.0.2.2"))
+	common.Must(err)
+	if count != 1 {
+		t.Error("unbanned: ", count)
+	}
+	if tag := pick("192.0.2.2"); tag != "" {
+		t.Error("not unbanned: ", tag)
+	}
+	if _, err := f.Unban("none", net.ParseAddress("192.0.2.2")); err == nil {
+		t.Error("unbanned in unknown jail")
+	}
+
+	time.Sleep(1500 * time.Millisecond)
+	if tag := pick("192.0.2.1"); tag != "" {
+		t.Error("ban not expired: ", tag)
+	}
+	if rules := r.ListRule(); len(rules) != 0 {
+		t.Error("rules left: ", rules)
+	}
+}
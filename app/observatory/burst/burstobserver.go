@@ -56,6 +56,11 @@ func (o *Observer) createResult() []*observatory.OutboundStatus {
 				Min:       int64(value.getStatistics().Min),
 			},
 		}
+		upstream, alive := observatory.ReportedHealth(o.ohm, name)
+		status.Upstream = upstream
+		if !alive {
+			status.Alive = false
+		}
 		result = append(result, &status)
 	}
 	return result
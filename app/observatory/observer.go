@@ -128,6 +128,12 @@ func (o *Observer) clearRemovedOutbounds(outbounds []string) {
 }
 
 func (o *Observer) probe(outbound string) ProbeResult {
+	if upstream, alive := ReportedHealth(o.ohm, outbound); !alive {
+		errorMessage := "the outbound " + outbound + " is dead: its upstream " + upstream + " is down"
+		errors.LogInfo(o.ctx, errorMessage)
+		return ProbeResult{Alive: false, LastErrorReason: errorMessage}
+	}
+
 	errorCollectorForRequest := newErrorCollector()
 
 	httpTransport := http.Transport{
@@ -207,6 +213,7 @@ func (o *Observer) updateStatusForResult(outbound string, result *ProbeResult) {
 
 	status.LastTryTime = time.Now().Unix()
 	status.OutboundTag = outbound
+	status.Upstream, _ = ReportedHealth(o.ohm, outbound)
 	status.Alive = result.Alive
 	if result.Alive {
 		status.Delay = result.Delay
@@ -0,0 +1,20 @@
+package observatory
+
+import (
+	"github.com/xtls/xray-core/features/outbound"
+	"github.com/xtls/xray-core/proxy"
+)
+
+// ReportedHealth returns what the outbound of the tag reports about its upstream, see proxy.HealthReporter.
+// Outbounds which do not report are considered alive.
+func ReportedHealth(ohm outbound.Manager, tag string) (upstream string, alive bool) {
+	gi, ok := ohm.GetHandler(tag).(proxy.GetOutbound)
+	if !ok {
+		return "", true
+	}
+	reporter, ok := gi.GetOutbound().(proxy.HealthReporter)
+	if !ok {
+		return "", true
+	}
+	return reporter.Health()
+}
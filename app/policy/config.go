@@ -58,6 +58,14 @@ func (p *Policy) overrideWith(another *Policy) {
 			Connection: another.Buffer.Connection,
 		}
 	}
+	if another.Mux != nil {
+		p.Mux = &Policy_Mux{
+			MaxSessions:    another.Mux.MaxSessions,
+			MaxConnections: another.Mux.MaxConnections,
+			SessionIdle:    another.Mux.SessionIdle,
+			XudpGrace:      another.Mux.XudpGrace,
+		}
+	}
 }
 
 // ToCorePolicy converts this Policy to policy.Session.
@@ -74,10 +82,17 @@ func (p *Policy) ToCorePolicy() policy.Session {
 		cp.Stats.UserUplink = p.Stats.UserUplink
 		cp.Stats.UserDownlink = p.Stats.UserDownlink
 		cp.Stats.UserOnline = p.Stats.UserOnline
+		cp.Stats.UserMux = p.Stats.UserMux
 	}
 	if p.Buffer != nil {
 		cp.Buffer.PerConnection = p.Buffer.Connection
 	}
+	if p.Mux != nil {
+		cp.Mux.MaxSessions = p.Mux.MaxSessions
+		cp.Mux.MaxConnections = p.Mux.MaxConnections
+		cp.Mux.SessionIdle = p.Mux.SessionIdle.Duration()
+		cp.Mux.XUDPGrace = p.Mux.XudpGrace.Duration()
+	}
 	return cp
 }
 
@@ -61,7 +61,40 @@ func (op *RemoveUserOperation) ApplyInbound(ctx context.Context, handler inbound
 	if !ok {
 		return errors.New("proxy is not a UserManager")
 	}
-	return um.RemoveUser(ctx, op.Email)
+	user := um.GetUser(ctx, op.Email)
+	if err := um.RemoveUser(ctx, op.Email); err != nil {
+		return err
+	}
+	if user != nil && user.Validity != nil {
+		user.Validity.Remove()
+	}
+	return nil
+}
+
+// ApplyInbound implements InboundOperation.
+func (op *UpdateUserValidityOperation) ApplyInbound(ctx context.Context, handler inbound.Handler) error {
+	p, err := getInbound(handler)
+	if err != nil {
+		return err
+	}
+	um, ok := p.(proxy.UserManager)
+	if !ok {
+		return errors.New("proxy is not a UserManager")
+	}
+	user := um.GetUser(ctx, op.Email)
+	if user == nil {
+		return errors.New("user ", op.Email, " not found")
+	}
+	if user.Validity == nil {
+		// the user is shared by its sessions, so a validity can't be given to it here
+		return errors.New("user ", op.Email, " is not added with a validity")
+	}
+	_, _, enabled := user.Validity.Get()
+	if op.Enabled != nil {
+		enabled = *op.Enabled
+	}
+	user.Validity.Update(op.ExpiresAt, op.NotBefore, enabled)
+	return nil
 }
 
 type handlerServer struct {
@@ -0,0 +1,107 @@
+package command_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/xtls/xray-core/app/proxyman/command"
+	"github.com/xtls/xray-core/common/protocol"
+	"github.com/xtls/xray-core/features/inbound"
+	"github.com/xtls/xray-core/proxy"
+	"google.golang.org/protobuf/proto"
+)
+
+type testInbound struct {
+	proxy.Inbound
+	users map[string]*protocol.MemoryUser
+}
+
+func (p *testInbound) AddUser(ctx context.Context, u *protocol.MemoryUser) error {
+	p.users[u.Email] = u
+	return nil
+}
+
+func (p *testInbound) RemoveUser(ctx context.Context, email string) error {
+	delete(p.users, email)
+	return nil
+}
+
+func (p *testInbound) GetUser(ctx context.Context, email string) *protocol.MemoryUser {
+	return p.users[email]
+}
+
+func (p *testInbound) GetUsers(ctx context.Context) []*protocol.MemoryUser {
+	users := make([]*protocol.MemoryUser, 0, len(p.users))
+	for _, u := range p.users {
+		users = append(users, u)
+	}
+	return users
+}
+
+func (p *testInbound) GetUsersCount(context.Context) int64 {
+	return int64(len(p.users))
+}
+
+type testHandler struct {
+	inbound.Handler
+	proxy *testInbound
+}
+
+func (h *testHandler) GetInbound() proxy.Inbound {
+	return h.proxy
+}
+
+func TestUpdateUserValidity(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	limited := &protocol.MemoryUser{Email: "limited@example.com", Validity: protocol.NewValidity(0, 0, false)}
+	always := &protocol.MemoryUser{Email: "always@example.com", Validity: protocol.NewValidity(0, 0, true)}
+	fixed := &protocol.MemoryUser{Email: "fixed@example.com"}
+	handler := &testHandler{proxy: &testInbound{users: map[string]*protocol.MemoryUser{
+		limited.Email: limited,
+		always.Email:  always,
+		fixed.Email:   fixed,
+	}}}
+
+	// enabled is kept unless it is set
+	op := &command.UpdateUserValidityOperation{Email: limited.Email, ExpiresAt: now.Add(time.Hour).Unix()}
+	if err := op.ApplyInbound(ctx, handler); err != nil {
+		t.Fatal(err)
+	}
+	if err := limited.Check(now); err == nil {
+		t.Error("expected the user to stay disabled")
+	}
+	op = &command.UpdateUserValidityOperation{Email: limited.Email, ExpiresAt: now.Add(time.Hour).Unix(), Enabled: proto.Bool(true)}
+	if err := op.ApplyInbound(ctx, handler); err != nil {
+		t.Fatal(err)
+	}
+	if err := limited.Check(now); err != nil {
+		t.Error("expected the user to be enabled, but got ", err)
+	}
+
+	// a user added as always valid can expire
+	op = &command.UpdateUserValidityOperation{Email: always.Email, ExpiresAt: now.Add(-time.Minute).Unix()}
+	if err := op.ApplyInbound(ctx, handler); err != nil {
+		t.Fatal(err)
+	}
+	if err := always.Check(now); err == nil {
+		t.Error("expected the user to expire")
+	}
+	if _, _, enabled := always.Validity.Get(); !enabled {
+		t.Error("expected the user to be enabled")
+	}
+
+	op = &command.UpdateUserValidityOperation{Email: fixed.Email, ExpiresAt: now.Add(-time.Minute).Unix()}
+	if err := op.ApplyInbound(ctx, handler); err == nil {
+		t.Error("expected a user without validity to fail")
+	}
+	if fixed.Validity != nil {
+		t.Error("expected the user without validity to be kept")
+	}
+
+	op = &command.UpdateUserValidityOperation{Email: "unknown@example.com"}
+	if err := op.ApplyInbound(ctx, handler); err == nil {
+		t.Error("expected an unknown user to fail")
+	}
+}
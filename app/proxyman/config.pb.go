@@ -25,6 +25,58 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+type SourcePool_Strategy int32
+
+const (
+	// A random address for each connection.
+	SourcePool_Random SourcePool_Strategy = 0
+	// The same address for the same user email, or client IP without user.
+	SourcePool_User SourcePool_Strategy = 1
+	// The same address for the same destination domain or IP.
+	SourcePool_Destination SourcePool_Strategy = 2
+)
+
+// Enum value maps for SourcePool_Strategy.
+var (
+	SourcePool_Strategy_name = map[int32]string{
+		0: "Random",
+		1: "User",
+		2: "Destination",
+	}
+	SourcePool_Strategy_value = map[string]int32{
+		"Random":      0,
+		"User":        1,
+		"Destination": 2,
+	}
+)
+
+func (x SourcePool_Strategy) Enum() *SourcePool_Strategy {
+	p := new(SourcePool_Strategy)
+	*p = x
+	return p
+}
+
+func (x SourcePool_Strategy) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (SourcePool_Strategy) Descriptor() protoreflect.EnumDescriptor {
+	return file_app_proxyman_config_proto_enumTypes[0].Descriptor()
+}
+
+func (SourcePool_Strategy) Type() protoreflect.EnumType {
+	return &file_app_proxyman_config_proto_enumTypes[0]
+}
+
+func (x SourcePool_Strategy) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use SourcePool_Strategy.Descriptor instead.
+func (SourcePool_Strategy) EnumDescriptor() ([]byte, []int) {
+	return file_app_proxyman_config_proto_rawDescGZIP(), []int{6, 0}
+}
+
 type InboundConfig struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	unknownFields protoimpl.UnknownFields
@@ -334,8 +386,10 @@ type SenderConfig struct {
 	MultiplexSettings *MultiplexingConfig     `protobuf:"bytes,4,opt,name=multiplex_settings,json=multiplexSettings,proto3" json:"multiplex_settings,omitempty"`
 	ViaCidr           string                  `protobuf:"bytes,5,opt,name=via_cidr,json=viaCidr,proto3" json:"via_cidr,omitempty"`
 	TargetStrategy    internet.DomainStrategy `protobuf:"varint,6,opt,name=target_strategy,json=targetStrategy,proto3,enum=xray.transport.internet.DomainStrategy" json:"target_strategy,omitempty"`
-	unknownFields     protoimpl.UnknownFields
-	sizeCache         protoimpl.SizeCache
+	// Send traffic through an address of the pool, instead of via.
+	ViaPool       *SourcePool `protobuf:"bytes,7,opt,name=via_pool,json=viaPool,proto3" json:"via_pool,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
 func (x *SenderConfig) Reset() {
@@ -410,6 +464,93 @@ func (x *SenderConfig) GetTargetStrategy() internet.DomainStrategy {
 	return internet.DomainStrategy(0)
 }
 
+func (x *SenderConfig) GetViaPool() *SourcePool {
+	if x != nil {
+		return x.ViaPool
+	}
+	return nil
+}
+
+type SourcePool struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// IPs or CIDRs, an address of a CIDR is selected by the strategy.
+	Addresses []string            `protobuf:"bytes,1,rep,name=addresses,proto3" json:"addresses,omitempty"`
+	Strategy  SourcePool_Strategy `protobuf:"varint,2,opt,name=strategy,proto3,enum=xray.app.proxyman.SourcePool_Strategy" json:"strategy,omitempty"`
+	// Seconds between checks of whether the addresses can route, 60 if 0.
+	CheckInterval uint32 `protobuf:"varint,3,opt,name=check_interval,json=checkInterval,proto3" json:"check_interval,omitempty"`
+	// The IP:port routes are looked up to from the IPv4 and IPv6 addresses when checking them,
+	// 1.1.1.1:53 and [2606:4700:4700::1111]:53 if empty. No packet is sent.
+	CheckIpv4     string `protobuf:"bytes,4,opt,name=check_ipv4,json=checkIpv4,proto3" json:"check_ipv4,omitempty"`
+	CheckIpv6     string `protobuf:"bytes,5,opt,name=check_ipv6,json=checkIpv6,proto3" json:"check_ipv6,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SourcePool) Reset() {
+	*x = SourcePool{}
+	mi := &file_app_proxyman_config_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SourcePool) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SourcePool) ProtoMessage() {}
+
+func (x *SourcePool) ProtoReflect() protoreflect.Message {
+	mi := &file_app_proxyman_config_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SourcePool.ProtoReflect.Descriptor instead.
+func (*SourcePool) Descriptor() ([]byte, []int) {
+	return file_app_proxyman_config_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *SourcePool) GetAddresses() []string {
+	if x != nil {
+		return x.Addresses
+	}
+	return nil
+}
+
+func (x *SourcePool) GetStrategy() SourcePool_Strategy {
+	if x != nil {
+		return x.Strategy
+	}
+	return SourcePool_Random
+}
+
+func (x *SourcePool) GetCheckInterval() uint32 {
+	if x != nil {
+		return x.CheckInterval
+	}
+	return 0
+}
+
+func (x *SourcePool) GetCheckIpv4() string {
+	if x != nil {
+		return x.CheckIpv4
+	}
+	return ""
+}
+
+func (x *SourcePool) GetCheckIpv6() string {
+	if x != nil {
+		return x.CheckIpv6
+	}
+	return ""
+}
+
 type MultiplexingConfig struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
 	// Whether or not Mux is enabled.
@@ -420,13 +561,15 @@ type MultiplexingConfig struct {
 	XudpConcurrency int32 `protobuf:"varint,3,opt,name=xudpConcurrency,proto3" json:"xudpConcurrency,omitempty"`
 	// "reject" (default), "allow" or "skip".
 	XudpProxyUDP443 string `protobuf:"bytes,4,opt,name=xudpProxyUDP443,proto3" json:"xudpProxyUDP443,omitempty"`
-	unknownFields   protoimpl.UnknownFields
-	sizeCache       protoimpl.SizeCache
+	// Whether the sessions are flow controlled, if the server supports it.
+	FlowControl   bool `protobuf:"varint,5,opt,name=flow_control,json=flowControl,proto3" json:"flow_control,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
 func (x *MultiplexingConfig) Reset() {
 	*x = MultiplexingConfig{}
-	mi := &file_app_proxyman_config_proto_msgTypes[6]
+	mi := &file_app_proxyman_config_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -438,7 +581,7 @@ func (x *MultiplexingConfig) String() string {
 func (*MultiplexingConfig) ProtoMessage() {}
 
 func (x *MultiplexingConfig) ProtoReflect() protoreflect.Message {
-	mi := &file_app_proxyman_config_proto_msgTypes[6]
+	mi := &file_app_proxyman_config_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -451,7 +594,7 @@ func (x *MultiplexingConfig) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use MultiplexingConfig.ProtoReflect.Descriptor instead.
 func (*MultiplexingConfig) Descriptor() ([]byte, []int) {
-	return file_app_proxyman_config_proto_rawDescGZIP(), []int{6}
+	return file_app_proxyman_config_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *MultiplexingConfig) GetEnabled() bool {
@@ -482,6 +625,13 @@ func (x *MultiplexingConfig) GetXudpProxyUDP443() string {
 	return ""
 }
 
+func (x *MultiplexingConfig) GetFlowControl() bool {
+	if x != nil {
+		return x.FlowControl
+	}
+	return false
+}
+
 var File_app_proxyman_config_proto protoreflect.FileDescriptor
 
 const file_app_proxyman_config_proto_rawDesc = "" +
@@ -506,19 +656,35 @@ const file_app_proxyman_config_proto_rawDesc = "" +
 	"\x03tag\x18\x01 \x01(\tR\x03tag\x12M\n" +
 	"\x11receiver_settings\x18\x02 \x01(\v2 .xray.common.serial.TypedMessageR\x10receiverSettings\x12G\n" +
 	"\x0eproxy_settings\x18\x03 \x01(\v2 .xray.common.serial.TypedMessageR\rproxySettings\"\x10\n" +
-	"\x0eOutboundConfig\"\x9d\x03\n" +
+	"\x0eOutboundConfig\"\xd7\x03\n" +
 	"\fSenderConfig\x12-\n" +
 	"\x03via\x18\x01 \x01(\v2\x1b.xray.common.net.IPOrDomainR\x03via\x12N\n" +
 	"\x0fstream_settings\x18\x02 \x01(\v2%.xray.transport.internet.StreamConfigR\x0estreamSettings\x12K\n" +
 	"\x0eproxy_settings\x18\x03 \x01(\v2$.xray.transport.internet.ProxyConfigR\rproxySettings\x12T\n" +
 	"\x12multiplex_settings\x18\x04 \x01(\v2%.xray.app.proxyman.MultiplexingConfigR\x11multiplexSettings\x12\x19\n" +
 	"\bvia_cidr\x18\x05 \x01(\tR\aviaCidr\x12P\n" +
-	"\x0ftarget_strategy\x18\x06 \x01(\x0e2'.xray.transport.internet.DomainStrategyR\x0etargetStrategy\"\xa4\x01\n" +
+	"\x0ftarget_strategy\x18\x06 \x01(\x0e2'.xray.transport.internet.DomainStrategyR\x0etargetStrategy\x128\n" +
+	"\bvia_pool\x18\a \x01(\v2\x1d.xray.app.proxyman.SourcePoolR\aviaPool\"\x86\x02\n" +
+	"\n" +
+	"SourcePool\x12\x1c\n" +
+	"\taddresses\x18\x01 \x03(\tR\taddresses\x12B\n" +
+	"\bstrategy\x18\x02 \x01(\x0e2&.xray.app.proxyman.SourcePool.StrategyR\bstrategy\x12%\n" +
+	"\x0echeck_interval\x18\x03 \x01(\rR\rcheckInterval\x12\x1d\n" +
+	"\n" +
+	"check_ipv4\x18\x04 \x01(\tR\tcheckIpv4\x12\x1d\n" +
+	"\n" +
+	"check_ipv6\x18\x05 \x01(\tR\tcheckIpv6\"1\n" +
+	"\bStrategy\x12\n" +
+	"\n" +
+	"\x06Random\x10\x00\x12\b\n" +
+	"\x04User\x10\x01\x12\x0f\n" +
+	"\vDestination\x10\x02\"\xc7\x01\n" +
 	"\x12MultiplexingConfig\x12\x18\n" +
 	"\aenabled\x18\x01 \x01(\bR\aenabled\x12 \n" +
 	"\vconcurrency\x18\x02 \x01(\x05R\vconcurrency\x12(\n" +
 	"\x0fxudpConcurrency\x18\x03 \x01(\x05R\x0fxudpConcurrency\x12(\n" +
-	"\x0fxudpProxyUDP443\x18\x04 \x01(\tR\x0fxudpProxyUDP443BU\n" +
+	"\x0fxudpProxyUDP443\x18\x04 \x01(\tR\x0fxudpProxyUDP443\x12!\n" +
+	"\fflow_control\x18\x05 \x01(\bR\vflowControlBU\n" +
 	"\x15com.xray.app.proxymanP\x01Z&github.com/xtls/xray-core/app/proxyman\xaa\x02\x11Xray.App.Proxymanb\x06proto3"
 
 var (
@@ -533,43 +699,48 @@ func file_app_proxyman_config_proto_rawDescGZIP() []byte {
 	return file_app_proxyman_config_proto_rawDescData
 }
 
-var file_app_proxyman_config_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_app_proxyman_config_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_app_proxyman_config_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
 var file_app_proxyman_config_proto_goTypes = []any{
-	(*InboundConfig)(nil),         // 0: xray.app.proxyman.InboundConfig
-	(*SniffingConfig)(nil),        // 1: xray.app.proxyman.SniffingConfig
-	(*ReceiverConfig)(nil),        // 2: xray.app.proxyman.ReceiverConfig
-	(*InboundHandlerConfig)(nil),  // 3: xray.app.proxyman.InboundHandlerConfig
-	(*OutboundConfig)(nil),        // 4: xray.app.proxyman.OutboundConfig
-	(*SenderConfig)(nil),          // 5: xray.app.proxyman.SenderConfig
-	(*MultiplexingConfig)(nil),    // 6: xray.app.proxyman.MultiplexingConfig
-	(*geodata.DomainRule)(nil),    // 7: xray.common.geodata.DomainRule
-	(*geodata.IPRule)(nil),        // 8: xray.common.geodata.IPRule
-	(*net.PortList)(nil),          // 9: xray.common.net.PortList
-	(*net.IPOrDomain)(nil),        // 10: xray.common.net.IPOrDomain
-	(*internet.StreamConfig)(nil), // 11: xray.transport.internet.StreamConfig
-	(*serial.TypedMessage)(nil),   // 12: xray.common.serial.TypedMessage
-	(*internet.ProxyConfig)(nil),  // 13: xray.transport.internet.ProxyConfig
-	(internet.DomainStrategy)(0),  // 14: xray.transport.internet.DomainStrategy
+	(SourcePool_Strategy)(0),      // 0: xray.app.proxyman.SourcePool.Strategy
+	(*InboundConfig)(nil),         // 1: xray.app.proxyman.InboundConfig
+	(*SniffingConfig)(nil),        // 2: xray.app.proxyman.SniffingConfig
+	(*ReceiverConfig)(nil),        // 3: xray.app.proxyman.ReceiverConfig
+	(*InboundHandlerConfig)(nil),  // 4: xray.app.proxyman.InboundHandlerConfig
+	(*OutboundConfig)(nil),        // 5: xray.app.proxyman.OutboundConfig
+	(*SenderConfig)(nil),          // 6: xray.app.proxyman.SenderConfig
+	(*SourcePool)(nil),            // 7: xray.app.proxyman.SourcePool
+	(*MultiplexingConfig)(nil),    // 8: xray.app.proxyman.MultiplexingConfig
+	(*geodata.DomainRule)(nil),    // 9: xray.common.geodata.DomainRule
+	(*geodata.IPRule)(nil),        // 10: xray.common.geodata.IPRule
+	(*net.PortList)(nil),          // 11: xray.common.net.PortList
+	(*net.IPOrDomain)(nil),        // 12: xray.common.net.IPOrDomain
+	(*internet.StreamConfig)(nil), // 13: xray.transport.internet.StreamConfig
+	(*serial.TypedMessage)(nil),   // 14: xray.common.serial.TypedMessage
+	(*internet.ProxyConfig)(nil),  // 15: xray.transport.internet.ProxyConfig
+	(internet.DomainStrategy)(0),  // 16: xray.transport.internet.DomainStrategy
 }
 var file_app_proxyman_config_proto_depIdxs = []int32{
-	7,  // 0: xray.app.proxyman.SniffingConfig.domains_excluded:type_name -> xray.common.geodata.DomainRule
-	8,  // 1: xray.app.proxyman.SniffingConfig.ips_excluded:type_name -> xray.common.geodata.IPRule
-	9,  // 2: xray.app.proxyman.ReceiverConfig.port_list:type_name -> xray.common.net.PortList
-	10, // 3: xray.app.proxyman.ReceiverConfig.listen:type_name -> xray.common.net.IPOrDomain
-	11, // 4: xray.app.proxyman.ReceiverConfig.stream_settings:type_name -> xray.transport.internet.StreamConfig
-	1,  // 5: xray.app.proxyman.ReceiverConfig.sniffing_settings:type_name -> xray.app.proxyman.SniffingConfig
-	12, // 6: xray.app.proxyman.InboundHandlerConfig.receiver_settings:type_name -> xray.common.serial.TypedMessage
-	12, // 7: xray.app.proxyman.InboundHandlerConfig.proxy_settings:type_name -> xray.common.serial.TypedMessage
-	10, // 8: xray.app.proxyman.SenderConfig.via:type_name -> xray.common.net.IPOrDomain
-	11, // 9: xray.app.proxyman.SenderConfig.stream_settings:type_name -> xray.transport.internet.StreamConfig
-	13, // 10: xray.app.proxyman.SenderConfig.proxy_settings:type_name -> xray.transport.internet.ProxyConfig
-	6,  // 11: xray.app.proxyman.SenderConfig.multiplex_settings:type_name -> xray.app.proxyman.MultiplexingConfig
-	14, // 12: xray.app.proxyman.SenderConfig.target_strategy:type_name -> xray.transport.internet.DomainStrategy
-	13, // [13:13] is the sub-list for method output_type
-	13, // [13:13] is the sub-list for method input_type
-	13, // [13:13] is the sub-list for extension type_name
-	13, // [13:13] is the sub-list for extension extendee
-	0,  // [0:13] is the sub-list for field type_name
+	9,  // 0: xray.app.proxyman.SniffingConfig.domains_excluded:type_name -> xray.common.geodata.DomainRule
+	10, // 1: xray.app.proxyman.SniffingConfig.ips_excluded:type_name -> xray.common.geodata.IPRule
+	11, // 2: xray.app.proxyman.ReceiverConfig.port_list:type_name -> xray.common.net.PortList
+	12, // 3: xray.app.proxyman.ReceiverConfig.listen:type_name -> xray.common.net.IPOrDomain
+	13, // 4: xray.app.proxyman.ReceiverConfig.stream_settings:type_name -> xray.transport.internet.StreamConfig
+	2,  // 5: xray.app.proxyman.ReceiverConfig.sniffing_settings:type_name -> xray.app.proxyman.SniffingConfig
+	14, // 6: xray.app.proxyman.InboundHandlerConfig.receiver_settings:type_name -> xray.common.serial.TypedMessage
+	14, // 7: xray.app.proxyman.InboundHandlerConfig.proxy_settings:type_name -> xray.common.serial.TypedMessage
+	12, // 8: xray.app.proxyman.SenderConfig.via:type_name -> xray.common.net.IPOrDomain
+	13, // 9: xray.app.proxyman.SenderConfig.stream_settings:type_name -> xray.transport.internet.StreamConfig
+	15, // 10: xray.app.proxyman.SenderConfig.proxy_settings:type_name -> xray.transport.internet.ProxyConfig
+	8,  // 11: xray.app.proxyman.SenderConfig.multiplex_settings:type_name -> xray.app.proxyman.MultiplexingConfig
+	16, // 12: xray.app.proxyman.SenderConfig.target_strategy:type_name -> xray.transport.internet.DomainStrategy
+	7,  // 13: xray.app.proxyman.SenderConfig.via_pool:type_name -> xray.app.proxyman.SourcePool
+	0,  // 14: xray.app.proxyman.SourcePool.strategy:type_name -> xray.app.proxyman.SourcePool.Strategy
+	15, // [15:15] is the sub-list for method output_type
+	15, // [15:15] is the sub-list for method input_type
+	15, // [15:15] is the sub-list for extension type_name
+	15, // [15:15] is the sub-list for extension extendee
+	0,  // [0:15] is the sub-list for field type_name
 }
 
 func init() { file_app_proxyman_config_proto_init() }
@@ -582,13 +753,14 @@ func file_app_proxyman_config_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_app_proxyman_config_proto_rawDesc), len(file_app_proxyman_config_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   7,
+			NumEnums:      1,
+			NumMessages:   8,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
 		GoTypes:           file_app_proxyman_config_proto_goTypes,
 		DependencyIndexes: file_app_proxyman_config_proto_depIdxs,
+		EnumInfos:         file_app_proxyman_config_proto_enumTypes,
 		MessageInfos:      file_app_proxyman_config_proto_msgTypes,
 	}.Build()
 	File_app_proxyman_config_proto = out.File
@@ -15,6 +15,8 @@ import (
 	"github.com/xtls/xray-core/common/session"
 	"github.com/xtls/xray-core/common/signal/done"
 	"github.com/xtls/xray-core/common/task"
+	"github.com/xtls/xray-core/core"
+	"github.com/xtls/xray-core/features/extension"
 	"github.com/xtls/xray-core/features/routing"
 	"github.com/xtls/xray-core/features/stats"
 	"github.com/xtls/xray-core/proxy"
@@ -63,6 +65,13 @@ func (w *tcpWorker) callback(conn stat.Connection) {
 	sid := session.NewID()
 	ctx = c.ContextWithID(ctx, sid)
 
+	if w.banned(conn.RemoteAddr()) {
+		cancel()
+		conn.Close()
+		errors.LogInfo(ctx, "rejected banned source ", conn.RemoteAddr())
+		return
+	}
+
 	outbounds := []*session.Outbound{{}}
 	if w.recvOrigDest {
 		var dest net.Destination
@@ -127,12 +136,25 @@ func (w *tcpWorker) callback(conn stat.Connection) {
 	conn.Close()
 }
 
+// banned returns whether the source of a connection is banned from the inbound by fail2ban.
+func (w *tcpWorker) banned(source net.Addr) bool {
+	if len(w.tag) == 0 {
+		return false
+	}
+	v := core.FromContext(w.ctx)
+	if v == nil {
+		return false
+	}
+	f, _ := v.GetFeature(extension.Fail2banType()).(extension.Fail2ban)
+	return f != nil && f.Banned(w.tag, net.DestinationFromAddr(source).Address)
+}
+
 func (w *tcpWorker) Proxy() proxy.Inbound {
 	return w.proxy
 }
 
 func (w *tcpWorker) Start() error {
-	ctx := context.Background()
+	ctx := w.listenContext()
 
 	if v, ok := w.proxy.(*hysteria_proxy.Server); ok {
 		ctx = hysteria.ContextWithValidator(ctx, v.HysteriaInboundValidator())
@@ -148,6 +170,20 @@ func (w *tcpWorker) Start() error {
 	return nil
 }
 
+// listenContext returns the context of the listener, with the instance and the inbound tag for the transports
+// counting their own stats. It is detached from the worker, as the connections accepted get the context of the
+// worker in callback.
+func (w *tcpWorker) listenContext() context.Context {
+	if core.FromContext(w.ctx) == nil {
+		return context.Background()
+	}
+	ctx := core.ToBackgroundDetachedContext(w.ctx)
+	if len(w.tag) > 0 {
+		ctx = session.ContextWithInbound(ctx, &session.Inbound{Tag: w.tag})
+	}
+	return ctx
+}
+
 func (w *tcpWorker) Close() error {
 	var errs []interface{}
 	if w.hub != nil {
@@ -0,0 +1,51 @@
+package inbound
+
+import (
+	"context"
+	"testing"
+
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/session"
+	"github.com/xtls/xray-core/core"
+	"github.com/xtls/xray-core/transport/internet"
+)
+
+const xrayKey core.XrayKey = 1
+
+func TestListenContext(t *testing.T) {
+	v, err := core.New(&core.Config{})
+	common.Must(err)
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), xrayKey, v))
+	defer cancel()
+
+	for _, test := range []struct {
+		tag    string
+		stream *internet.MemoryStreamConfig
+	}{
+		{tag: "in", stream: &internet.MemoryStreamConfig{ProtocolName: "tcp"}},
+		{tag: "in", stream: &internet.MemoryStreamConfig{ProtocolName: "grpc"}},
+		{tag: "in", stream: &internet.MemoryStreamConfig{ProtocolName: "websocket"}},
+		{stream: &internet.MemoryStreamConfig{ProtocolName: "grpc"}},
+	} {
+		w := &tcpWorker{ctx: ctx, tag: test.tag, stream: test.stream}
+		listenCtx := w.listenContext()
+		if core.FromContext(listenCtx) != v {
+			t.Error("expected the instance for ", test.stream.ProtocolName)
+		}
+		inbound := session.InboundFromContext(listenCtx)
+		if len(test.tag) > 0 {
+			if inbound == nil || inbound.Tag != test.tag {
+				t.Error("expected the inbound tag for ", test.stream.ProtocolName)
+			}
+		} else if inbound != nil {
+			t.Error("unexpected inbound of ", test.stream.ProtocolName, " without tag")
+		}
+	}
+
+	// the listener outlives a cancelled worker context
+	cancel()
+	w := &tcpWorker{ctx: ctx, tag: "in", stream: &internet.MemoryStreamConfig{ProtocolName: "grpc"}}
+	if err := w.listenContext().Err(); err != nil {
+		t.Error("listen context is done: ", err)
+	}
+}
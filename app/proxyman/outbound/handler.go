@@ -67,6 +67,7 @@ type Handler struct {
 	mux             *mux.ClientManager
 	xudp            *mux.ClientManager
 	udp443          string
+	sourcePool      *sourcePool
 	uplinkCounter   stats.Counter
 	downlinkCounter stats.Counter
 }
@@ -95,6 +96,11 @@ func NewHandler(ctx context.Context, config *core.OutboundHandlerConfig) (outbou
 				return nil, errors.New("failed to parse stream settings").Base(err).AtWarning()
 			}
 			h.streamSettings = mss
+			if s.ViaPool != nil {
+				if h.sourcePool, err = newSourcePool(s.ViaPool); err != nil {
+					return nil, err
+				}
+			}
 		default:
 			return nil, errors.New("settings is not SenderConfig")
 		}
@@ -138,6 +144,7 @@ func NewHandler(ctx context.Context, config *core.OutboundHandlerConfig) (outbou
 							Strategy: mux.ClientStrategy{
 								MaxConcurrency: uint32(config.Concurrency),
 								MaxConnection:  128,
+								FlowControl:    config.FlowControl,
 							},
 						},
 					},
@@ -159,6 +166,7 @@ func NewHandler(ctx context.Context, config *core.OutboundHandlerConfig) (outbou
 							Strategy: mux.ClientStrategy{
 								MaxConcurrency: uint32(config.XudpConcurrency),
 								MaxConnection:  128,
+								FlowControl:    config.FlowControl,
 							},
 						},
 					},
@@ -208,6 +216,28 @@ func (h *Handler) Dispatch(ctx context.Context, link *transport.Link) {
 		link.Reader = &buf.EndpointOverrideReader{Reader: link.Reader, Dest: ob.Target.Address, OriginalDest: ob.OriginalTarget.Address}
 		link.Writer = &buf.EndpointOverrideWriter{Writer: link.Writer, Dest: ob.Target.Address, OriginalDest: ob.OriginalTarget.Address}
 	}
+	if ob.Target.Network == net.Network_ICMP {
+		if f, ok := h.proxy.(proxy.ICMPForwarder); !ok || !f.CanForwardICMP() {
+			err := errors.New("outbound ", h.tag, " does not support ICMP").AtInfo()
+			session.SubmitOutboundErrorToOriginator(ctx, err)
+			errors.LogInfo(ctx, err.Error())
+			common.Interrupt(link.Writer)
+			common.Interrupt(link.Reader)
+			return
+		}
+		goto out
+	}
+	if session.BindFromContext(ctx) != nil {
+		if b, ok := h.proxy.(proxy.Binder); !ok || !b.CanBind() {
+			err := errors.New("outbound ", h.tag, " does not support BIND").AtInfo()
+			session.SubmitOutboundErrorToOriginator(ctx, err)
+			errors.LogInfo(ctx, err.Error())
+			common.Interrupt(link.Writer)
+			common.Interrupt(link.Reader)
+			return
+		}
+		goto out
+	}
 	if h.mux != nil {
 		test := func(err error) {
 			if err != nil {
@@ -302,7 +332,7 @@ func (h *Handler) Dial(ctx context.Context, dest net.Destination) (stat.Connecti
 			return nil, errors.New("failed to get outbound handler with tag: " + tag)
 		}
 
-		if h.senderSettings.Via != nil {
+		if h.senderSettings.Via != nil || h.sourcePool != nil {
 			outbounds := session.OutboundsFromContext(ctx)
 			ob := outbounds[len(outbounds)-1]
 			h.SetOutboundGateway(ctx, ob)
@@ -322,7 +352,13 @@ func (h *Handler) Dial(ctx context.Context, dest net.Destination) (stat.Connecti
 }
 
 func (h *Handler) SetOutboundGateway(ctx context.Context, ob *session.Outbound) {
-	if ob.Gateway == nil && h.senderSettings != nil && h.senderSettings.Via != nil && !h.senderSettings.ProxySettings.HasTag() && (h.streamSettings.SocketSettings == nil || len(h.streamSettings.SocketSettings.DialerProxy) == 0) {
+	if ob.Gateway == nil && h.senderSettings != nil && (h.senderSettings.Via != nil || h.sourcePool != nil) && !h.senderSettings.ProxySettings.HasTag() && (h.streamSettings.SocketSettings == nil || len(h.streamSettings.SocketSettings.DialerProxy) == 0) {
+		if h.sourcePool != nil {
+			if ob.Gateway = h.sourcePool.Select(ctx, ob); ob.Gateway != nil {
+				errors.LogDebug(ctx, "use pool ip as sendthrough: ", ob.Gateway.String())
+			}
+			return
+		}
 		var domain string
 		addr := h.senderSettings.Via.AsAddress()
 		domain = h.senderSettings.Via.GetDomain()
@@ -371,11 +407,17 @@ func (h *Handler) GetOutbound() proxy.Outbound {
 
 // Start implements common.Runnable.
 func (h *Handler) Start() error {
+	if h.sourcePool != nil {
+		return h.sourcePool.Start()
+	}
 	return nil
 }
 
 // Close implements common.Closable.
 func (h *Handler) Close() error {
+	if h.sourcePool != nil {
+		h.sourcePool.Close()
+	}
 	common.Close(h.mux)
 	common.Close(h.proxy)
 	return nil
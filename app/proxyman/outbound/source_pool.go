@@ -0,0 +1,269 @@
+package outbound
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	gonet "net"
+	"net/netip"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/xtls/xray-core/app/proxyman"
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/session"
+	"github.com/xtls/xray-core/common/task"
+	"github.com/xtls/xray-core/transport/internet"
+)
+
+const defaultSourceCheckInterval = 60 * time.Second
+
+var (
+	defaultSourceCheckIPv4 = netip.MustParseAddrPort("1.1.1.1:53")
+	defaultSourceCheckIPv6 = netip.MustParseAddrPort("[2606:4700:4700::1111]:53")
+)
+
+type sourceEntry struct {
+	prefix netip.Prefix
+	alive  atomic.Bool
+}
+
+// sourcePool selects the source address of connections among addresses and ranges. Entries which
+// cannot route are skipped, and a sticky selection only moves the keys of the entries skipped.
+type sourcePool struct {
+	entries   []*sourceEntry
+	strategy  proxyman.SourcePool_Strategy
+	checkIPv4 netip.AddrPort
+	checkIPv6 netip.AddrPort
+	monitor   *task.Periodic
+	// lookup resolves the domain targets when the pool has addresses of both families
+	lookup func(domain string) ([]net.IP, error)
+}
+
+func newSourcePool(config *proxyman.SourcePool) (*sourcePool, error) {
+	if len(config.Addresses) == 0 {
+		return nil, errors.New("empty source address pool")
+	}
+	p := &sourcePool{
+		strategy:  config.Strategy,
+		checkIPv4: defaultSourceCheckIPv4,
+		checkIPv6: defaultSourceCheckIPv6,
+		lookup: func(domain string) ([]net.IP, error) {
+			return internet.LookupForIP(domain, internet.DomainStrategy_USE_IP, nil)
+		},
+	}
+	if config.CheckIpv4 != "" {
+		addr, err := netip.ParseAddrPort(config.CheckIpv4)
+		if err != nil || !addr.Addr().Is4() {
+			return nil, errors.New("invalid IPv4 check address ", config.CheckIpv4).Base(err)
+		}
+		p.checkIPv4 = addr
+	}
+	if config.CheckIpv6 != "" {
+		addr, err := netip.ParseAddrPort(config.CheckIpv6)
+		if err != nil || !addr.Addr().Is6() || addr.Addr().Is4In6() {
+			return nil, errors.New("invalid IPv6 check address ", config.CheckIpv6).Base(err)
+		}
+		p.checkIPv6 = addr
+	}
+	for _, address := range config.Addresses {
+		var prefix netip.Prefix
+		var err error
+		if strings.Contains(address, "/") {
+			prefix, err = netip.ParsePrefix(address)
+		} else {
+			var addr netip.Addr
+			if addr, err = netip.ParseAddr(address); err == nil {
+				prefix = netip.PrefixFrom(addr, addr.BitLen())
+			}
+		}
+		if err != nil {
+			return nil, errors.New("invalid source address ", address).Base(err)
+		}
+		entry := &sourceEntry{prefix: prefix.Masked()}
+		entry.alive.Store(true)
+		p.entries = append(p.entries, entry)
+	}
+	interval := defaultSourceCheckInterval
+	if config.CheckInterval > 0 {
+		interval = time.Duration(config.CheckInterval) * time.Second
+	}
+	p.monitor = &task.Periodic{
+		Interval: interval,
+		Execute:  p.check,
+	}
+	return p, nil
+}
+
+func (p *sourcePool) Start() error {
+	return p.monitor.Start()
+}
+
+func (p *sourcePool) Close() error {
+	return p.monitor.Close()
+}
+
+// check marks the entries which can route, it logs the changes instead of returning an error,
+// which would end the periodic checks.
+func (p *sourcePool) check() error {
+	for _, entry := range p.entries {
+		remote := p.checkIPv6
+		if entry.prefix.Addr().Is4() {
+			remote = p.checkIPv4
+		}
+		err := probeSource(randomAddr(entry.prefix), remote)
+		if alive := err == nil; entry.alive.Swap(alive) != alive {
+			if alive {
+				errors.LogWarning(context.Background(), "source address ", entry.prefix, " is usable again")
+			} else {
+				errors.LogWarningInner(context.Background(), err, "source address ", entry.prefix, " is not usable")
+			}
+		}
+	}
+	return nil
+}
+
+// probeSource binds the address and looks up a route from it to remote, without sending any packet
+func probeSource(addr netip.Addr, remote netip.AddrPort) error {
+	conn, err := gonet.DialUDP("udp", gonet.UDPAddrFromAddrPort(netip.AddrPortFrom(addr, 0)), gonet.UDPAddrFromAddrPort(remote))
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// Select returns the source address of the connection of ob, nil if there is none of the family of its target.
+// The entries of each family are selected from separately. For a domain target, the family is the one of
+// its IPs if they are all of the same family, so the outbound resolves the domain to IPs it can reach.
+func (p *sourcePool) Select(ctx context.Context, ob *session.Outbound) net.Address {
+	v4, v6 := true, true
+	if target := ob.Target.Address; target != nil && target.Family().IsIP() {
+		v4, v6 = target.Family().IsIPv4(), target.Family().IsIPv6()
+	}
+	key := p.key(ctx, ob)
+	entry4, weight4 := p.selectEntry(key, v4, true)
+	entry6, weight6 := p.selectEntry(key, v6, false)
+	if entry4 != nil && entry6 != nil && ob.Target.Address != nil && ob.Target.Address.Family().IsDomain() {
+		if ips, err := p.lookup(ob.Target.Address.Domain()); err == nil {
+			has4 := slices.ContainsFunc(ips, func(ip net.IP) bool { return ip.To4() != nil })
+			has6 := slices.ContainsFunc(ips, func(ip net.IP) bool { return ip.To4() == nil })
+			switch {
+			case has4 && !has6:
+				entry6 = nil
+			case has6 && !has4:
+				entry4 = nil
+			}
+		}
+	}
+	selected := entry4
+	switch {
+	case entry6 == nil:
+	case entry4 == nil, weight6 > weight4:
+		selected = entry6
+	}
+	if selected == nil {
+		return nil
+	}
+	if key == "" {
+		return net.IPAddress(randomAddr(selected.prefix).AsSlice())
+	}
+	sum := sha256.Sum256([]byte(key))
+	return net.IPAddress(addrInPrefix(selected.prefix, sum[:]).AsSlice())
+}
+
+// selectEntry selects an entry of the family if enabled, among the alive ones if any, and returns its weight.
+// With a key, the entry is selected by rendezvous hashing, so that the key stays on it while it is alive.
+func (p *sourcePool) selectEntry(key string, enabled bool, v4 bool) (*sourceEntry, uint64) {
+	if !enabled {
+		return nil, 0
+	}
+	var entries, alive []*sourceEntry
+	for _, entry := range p.entries {
+		if entry.prefix.Addr().Is4() != v4 {
+			continue
+		}
+		entries = append(entries, entry)
+		if entry.alive.Load() {
+			alive = append(alive, entry)
+		}
+	}
+	if len(alive) > 0 {
+		entries = alive
+	}
+	if len(entries) == 0 {
+		return nil, 0
+	}
+
+	var selected *sourceEntry
+	var highest uint64
+	for _, entry := range entries {
+		var weight uint64
+		if key == "" {
+			var b [8]byte
+			rand.Read(b[:])
+			weight = binary.BigEndian.Uint64(b[:])
+		} else {
+			sum := sha256.Sum256([]byte(entry.prefix.String() + "\x00" + key))
+			weight = binary.BigEndian.Uint64(sum[:8])
+		}
+		if selected == nil || weight > highest {
+			selected, highest = entry, weight
+		}
+	}
+	return selected, highest
+}
+
+func (p *sourcePool) key(ctx context.Context, ob *session.Outbound) string {
+	switch p.strategy {
+	case proxyman.SourcePool_User:
+		if inbound := session.InboundFromContext(ctx); inbound != nil {
+			if inbound.User != nil && inbound.User.Email != "" {
+				return "user:" + inbound.User.Email
+			}
+			if inbound.Source.IsValid() {
+				return "source:" + inbound.Source.Address.String()
+			}
+		}
+	case proxyman.SourcePool_Destination:
+		target := ob.OriginalTarget.Address
+		if target == nil {
+			target = ob.Target.Address
+		}
+		if target != nil {
+			return "destination:" + target.String()
+		}
+	}
+	return ""
+}
+
+func randomAddr(prefix netip.Prefix) netip.Addr {
+	if prefix.IsSingleIP() {
+		return prefix.Addr()
+	}
+	b := make([]byte, 16)
+	rand.Read(b)
+	return addrInPrefix(prefix, b)
+}
+
+// addrInPrefix returns the address of prefix whose host bits are taken from b
+func addrInPrefix(prefix netip.Prefix, b []byte) netip.Addr {
+	addr := prefix.Addr().AsSlice()
+	for i := range addr {
+		bits := prefix.Bits() - i*8
+		switch {
+		case bits >= 8:
+			continue
+		case bits <= 0:
+			addr[i] = b[i]
+		default:
+			mask := byte(0xff) >> bits
+			addr[i] = addr[i]&^mask | b[i]&mask
+		}
+	}
+	result, _ := netip.AddrFromSlice(addr)
+	return result
+}
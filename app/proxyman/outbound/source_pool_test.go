@@ -0,0 +1,160 @@
+package outbound
+
+import (
+	"context"
+	"net/netip"
+	"testing"
+
+	"github.com/xtls/xray-core/app/proxyman"
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/protocol"
+	"github.com/xtls/xray-core/common/session"
+)
+
+func TestSourcePool(t *testing.T) {
+	pool, err := newSourcePool(&proxyman.SourcePool{
+		Addresses: []string{"192.0.2.1", "192.0.2.2", "198.51.100.7", "2001:db8::/64"},
+		Strategy:  proxyman.SourcePool_User,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	selectFor := func(email string, target net.Address) net.Address {
+		ctx := session.ContextWithInbound(context.Background(), &session.Inbound{
+			User: &protocol.MemoryUser{Email: email},
+		})
+		return pool.Select(ctx, &session.Outbound{Target: net.TCPDestination(target, 443)})
+	}
+
+	v4 := net.ParseAddress("203.0.113.1")
+	v6 := net.ParseAddress("2001:db8:1::1")
+	first := selectFor("a@example.com", v4)
+	if !first.Family().IsIPv4() {
+		t.Fatal("expected an IPv4 address for an IPv4 target, but got ", first)
+	}
+	for range 10 {
+		if addr := selectFor("a@example.com", v4); addr.String() != first.String() {
+			t.Fatal("selection is not sticky: ", first, " then ", addr)
+		}
+	}
+	addr := selectFor("a@example.com", v6)
+	if ip, _ := netip.AddrFromSlice(addr.IP()); !netip.MustParsePrefix("2001:db8::/64").Contains(ip) {
+		t.Fatal("expected an address of the IPv6 range, but got ", addr)
+	}
+	if other := selectFor("b@example.com", v6); other.String() == addr.String() {
+		t.Error("expected different addresses in the range for different users")
+	}
+
+	// only the keys of a dead entry move
+	moved := 0
+	before := make(map[string]string)
+	for _, email := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+		before[email] = selectFor(email, v4).String()
+	}
+	for _, entry := range pool.entries {
+		if entry.prefix.Addr().String() == first.String() {
+			entry.alive.Store(false)
+		}
+	}
+	for email, source := range before {
+		addr := selectFor(email, v4).String()
+		if addr == first.String() {
+			t.Error("selected the dead address for ", email)
+		}
+		if addr != source {
+			if source != first.String() {
+				t.Error("selection of ", email, " moved from the alive address ", source)
+			}
+			moved++
+		}
+	}
+	if moved == 0 {
+		t.Error("expected some users on the dead address")
+	}
+}
+
+func TestSourcePoolDomain(t *testing.T) {
+	pool, err := newSourcePool(&proxyman.SourcePool{
+		Addresses: []string{"192.0.2.1", "192.0.2.2", "198.51.100.7", "2001:db8::/64"},
+		Strategy:  proxyman.SourcePool_User,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool.lookup = func(domain string) ([]net.IP, error) {
+		switch domain {
+		case "v4.example.com":
+			return []net.IP{net.ParseIP("203.0.113.1")}, nil
+		case "v6.example.com":
+			return []net.IP{net.ParseIP("2001:db8:1::1")}, nil
+		case "dual.example.com":
+			return []net.IP{net.ParseIP("203.0.113.1"), net.ParseIP("2001:db8:1::1")}, nil
+		}
+		return nil, errors.New("not found")
+	}
+
+	selectFor := func(email string, domain string) net.Address {
+		ctx := session.ContextWithInbound(context.Background(), &session.Inbound{
+			User: &protocol.MemoryUser{Email: email},
+		})
+		return pool.Select(ctx, &session.Outbound{Target: net.TCPDestination(net.DomainAddress(domain), 443)})
+	}
+
+	families := make(map[bool]bool)
+	for _, email := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+		if addr := selectFor(email, "v4.example.com"); !addr.Family().IsIPv4() {
+			t.Error("expected an IPv4 address for an IPv4-only domain, but got ", addr, " for ", email)
+		}
+		if addr := selectFor(email, "v6.example.com"); !addr.Family().IsIPv6() {
+			t.Error("expected an IPv6 address for an IPv6-only domain, but got ", addr, " for ", email)
+		}
+		dual := selectFor(email, "dual.example.com")
+		for range 3 {
+			if addr := selectFor(email, "dual.example.com"); addr.String() != dual.String() {
+				t.Fatal("selection is not sticky: ", dual, " then ", addr)
+			}
+		}
+		if unknown := selectFor(email, "unknown.example.com"); unknown.String() != dual.String() {
+			t.Error("expected the same selection without the IPs of the domain: ", dual, " and ", unknown)
+		}
+		families[dual.Family().IsIPv4()] = true
+	}
+	if len(families) != 2 {
+		t.Error("expected users on both families for a dual-stack domain")
+	}
+}
+
+func TestSourcePoolCheckAddress(t *testing.T) {
+	pool, err := newSourcePool(&proxyman.SourcePool{
+		Addresses: []string{"192.0.2.1"},
+		CheckIpv4: "192.0.2.53:53",
+		CheckIpv6: "[2001:db8::53]:53",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pool.checkIPv4.String() != "192.0.2.53:53" || pool.checkIPv6.String() != "[2001:db8::53]:53" {
+		t.Error("unexpected check addresses ", pool.checkIPv4, " ", pool.checkIPv6)
+	}
+	for _, config := range []*proxyman.SourcePool{
+		{Addresses: []string{"192.0.2.1"}, CheckIpv4: "2001:db8::53"},
+		{Addresses: []string{"192.0.2.1"}, CheckIpv4: "[2001:db8::53]:53"},
+		{Addresses: []string{"192.0.2.1"}, CheckIpv6: "192.0.2.53:53"},
+	} {
+		if _, err := newSourcePool(config); err == nil {
+			t.Error("expected error for ", config)
+		}
+	}
+}
+
+func TestAddrInPrefix(t *testing.T) {
+	b := []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}
+	if addr := addrInPrefix(netip.MustParsePrefix("10.1.0.0/20"), b); addr != netip.MustParseAddr("10.1.15.255") {
+		t.Error("unexpected address ", addr)
+	}
+	if addr := addrInPrefix(netip.MustParsePrefix("2001:db8::/64"), b); addr != netip.MustParseAddr("2001:db8::ffff:ffff:ffff:ffff") {
+		t.Error("unexpected address ", addr)
+	}
+}
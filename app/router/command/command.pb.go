@@ -43,6 +43,8 @@ type RoutingContext struct {
 	LocalIPs          [][]byte               `protobuf:"bytes,13,rep,name=LocalIPs,proto3" json:"LocalIPs,omitempty"`
 	LocalPort         uint32                 `protobuf:"varint,14,opt,name=LocalPort,proto3" json:"LocalPort,omitempty"`
 	VlessRoute        uint32                 `protobuf:"varint,15,opt,name=VlessRoute,proto3" json:"VlessRoute,omitempty"`
+	UserGroup         string                 `protobuf:"bytes,16,opt,name=UserGroup,proto3" json:"UserGroup,omitempty"`
+	UserOutboundTag   string                 `protobuf:"bytes,17,opt,name=UserOutboundTag,proto3" json:"UserOutboundTag,omitempty"`
 	unknownFields     protoimpl.UnknownFields
 	sizeCache         protoimpl.SizeCache
 }
@@ -182,6 +184,20 @@ func (x *RoutingContext) GetVlessRoute() uint32 {
 	return 0
 }
 
+func (x *RoutingContext) GetUserGroup() string {
+	if x != nil {
+		return x.UserGroup
+	}
+	return ""
+}
+
+func (x *RoutingContext) GetUserOutboundTag() string {
+	if x != nil {
+		return x.UserOutboundTag
+	}
+	return ""
+}
+
 // SubscribeRoutingStatsRequest subscribes to routing statistics channel if
 // opened by xray-core.
 // * FieldSelectors selects a subset of fields in routing statistics to return.
@@ -195,6 +211,8 @@ func (x *RoutingContext) GetVlessRoute() uint32 {
 //   - domain: Selects target domain.
 //   - protocol: Select connection's protocol.
 //   - user: Select connection's inbound user email.
+//   - user_group: Select the group of connection's inbound user.
+//   - user_outbound: Select the outbound the inbound user is bound to.
 //   - attributes: Select connection's additional attributes.
 //   - outbound: Equivalent as "outbound" and "outbound_group", select both
 //     outbound tag and outbound group tags.
@@ -967,7 +985,7 @@ var File_app_router_command_command_proto protoreflect.FileDescriptor
 
 const file_app_router_command_command_proto_rawDesc = "" +
 	"\n" +
-	" app/router/command/command.proto\x12\x17xray.app.router.command\x1a\x18common/net/network.proto\x1a!common/serial/typed_message.proto\"\xf6\x04\n" +
+	" app/router/command/command.proto\x12\x17xray.app.router.command\x1a\x18common/net/network.proto\x1a!common/serial/typed_message.proto\"\xbe\x05\n" +
 	"\x0eRoutingContext\x12\x1e\n" +
 	"\n" +
 	"InboundTag\x18\x01 \x01(\tR\n" +
@@ -994,7 +1012,9 @@ const file_app_router_command_command_proto_rawDesc = "" +
 	"\tLocalPort\x18\x0e \x01(\rR\tLocalPort\x12\x1e\n" +
 	"\n" +
 	"VlessRoute\x18\x0f \x01(\rR\n" +
-	"VlessRoute\x1a=\n" +
+	"VlessRoute\x12\x1c\n" +
+	"\tUserGroup\x18\x10 \x01(\tR\tUserGroup\x12(\n" +
+	"\x0fUserOutboundTag\x18\x11 \x01(\tR\x0fUserOutboundTag\x1a=\n" +
 	"\x0fAttributesEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
 	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"F\n" +
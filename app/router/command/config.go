@@ -73,6 +73,8 @@ var fieldMap = map[string]func(*RoutingContext, routing.Route){
 	"domain":         func(s *RoutingContext, r routing.Route) { s.TargetDomain = r.GetTargetDomain() },
 	"protocol":       func(s *RoutingContext, r routing.Route) { s.Protocol = r.GetProtocol() },
 	"user":           func(s *RoutingContext, r routing.Route) { s.User = r.GetUser() },
+	"user_group":     func(s *RoutingContext, r routing.Route) { s.UserGroup = r.GetUserGroup() },
+	"user_outbound":  func(s *RoutingContext, r routing.Route) { s.UserOutboundTag = r.GetUserOutboundTag() },
 	"attributes":     func(s *RoutingContext, r routing.Route) { s.Attributes = r.GetAttributes() },
 	"outbound_group": func(s *RoutingContext, r routing.Route) { s.OutboundGroupTags = r.GetOutboundGroupTags() },
 	"outbound":       func(s *RoutingContext, r routing.Route) { s.OutboundTag = r.GetOutboundTag() },
@@ -3,6 +3,7 @@ package router
 import (
 	"context"
 	"os"
+	"path"
 	"path/filepath"
 	"regexp"
 	"slices"
@@ -180,6 +181,28 @@ func NewUserMatcher(users []string) *UserMatcher {
 	}
 }
 
+type UserGroupMatcher struct {
+	groups map[string]bool
+}
+
+func NewUserGroupMatcher(groups []string) *UserGroupMatcher {
+	matcher := &UserGroupMatcher{
+		groups: make(map[string]bool, len(groups)),
+	}
+	for _, group := range groups {
+		if len(group) > 0 {
+			matcher.groups[group] = true
+		}
+	}
+	return matcher
+}
+
+// Apply implements Condition.
+func (v *UserGroupMatcher) Apply(ctx routing.Context) bool {
+	group := ctx.GetUserGroup()
+	return len(group) > 0 && v.groups[group]
+}
+
 // Apply implements Condition.
 func (v *UserMatcher) Apply(ctx routing.Context) bool {
 	user := ctx.GetUser()
@@ -337,38 +360,43 @@ func NewProcessNameMatcher(names []string) *ProcessNameMatcher {
 	}
 }
 
-func (m *ProcessNameMatcher) Apply(ctx routing.Context) bool {
+// localFlow returns the flow of a connection as seen by the local socket which opened it
+func localFlow(ctx routing.Context) (network string, srcIP string, srcPort uint16, dstIP string, dstPort uint16, ok bool) {
 	if len(ctx.GetSourceIPs()) == 0 {
-		return false
+		return
 	}
 
-	srcPort := uint16(ctx.GetSourcePort())
-	srcIP := ctx.GetSourceIPs()[0].String()
+	srcPort = uint16(ctx.GetSourcePort())
+	srcIP = ctx.GetSourceIPs()[0].String()
 
-	var network string
 	switch ctx.GetNetwork() {
 	case net.Network_TCP:
 		network = "tcp"
 	case net.Network_UDP:
 		network = "udp"
 	default:
-		return false
+		return
 	}
 
-	var dstIP string
-	var dstPort uint16 = 0
-
 	// do not use resolved IP because Android process lookup needs original dst ip
-	resolvableContext, ok := ctx.(*dns.ResolvableContext)
-	if ok && len(resolvableContext.Context.GetTargetIPs()) > 0 {
+	resolvableContext, isResolvable := ctx.(*dns.ResolvableContext)
+	if isResolvable && len(resolvableContext.Context.GetTargetIPs()) > 0 {
 		dstIP = resolvableContext.Context.GetTargetIPs()[0].String()
 		dstPort = uint16(resolvableContext.Context.GetTargetPort())
 	} else if len(ctx.GetTargetIPs()) > 0 {
 		dstIP = ctx.GetTargetIPs()[0].String()
 		dstPort = uint16(ctx.GetTargetPort())
 	}
+	return network, srcIP, srcPort, dstIP, dstPort, true
+}
+
+func (m *ProcessNameMatcher) Apply(ctx routing.Context) bool {
+	network, srcIP, srcPort, dstIP, dstPort, ok := localFlow(ctx)
+	if !ok {
+		return false
+	}
 
-	pid, name, absPath, err := net.FindProcess(network, srcIP, uint16(srcPort), dstIP, uint16(dstPort))
+	pid, name, absPath, err := net.FindProcess(network, srcIP, srcPort, dstIP, dstPort)
 	if err != nil {
 		if err != net.ErrNotLocal {
 			errors.LogError(context.Background(), "Unables to find local process name: ", err)
@@ -393,3 +421,65 @@ func (m *ProcessNameMatcher) Apply(ctx routing.Context) bool {
 	}
 	return false
 }
+
+// SocketOwnerMatcher matches connections opened by local sockets of the given users, groups or cgroups.
+// All non-empty lists must match.
+type SocketOwnerMatcher struct {
+	UIDs    []uint32
+	GIDs    []uint32
+	Cgroups []string
+}
+
+func NewSocketOwnerMatcher(uids []uint32, gids []uint32, cgroups []string) *SocketOwnerMatcher {
+	m := &SocketOwnerMatcher{
+		UIDs: uids,
+		GIDs: gids,
+	}
+	for _, cgroup := range cgroups {
+		cgroup = path.Clean("/" + cgroup)
+		m.Cgroups = append(m.Cgroups, cgroup)
+	}
+	return m
+}
+
+func (m *SocketOwnerMatcher) Apply(ctx routing.Context) bool {
+	network, srcIP, srcPort, dstIP, dstPort, ok := localFlow(ctx)
+	if !ok {
+		return false
+	}
+
+	owner, err := net.FindSocketOwner(network, srcIP, srcPort, dstIP, dstPort)
+	if err != nil {
+		if err != net.ErrNotLocal {
+			errors.LogError(context.Background(), "Unables to find local socket owner: ", err)
+		}
+		return false
+	}
+	return m.Match(owner)
+}
+
+// Match reports whether the socket owner matches
+func (m *SocketOwnerMatcher) Match(owner *net.SocketOwner) bool {
+	if len(m.UIDs) > 0 && !slices.Contains(m.UIDs, owner.UID) {
+		return false
+	}
+	if len(m.GIDs) > 0 && (owner.GID == net.UnknownID || !slices.Contains(m.GIDs, owner.GID)) {
+		return false
+	}
+	if len(m.Cgroups) > 0 {
+		if owner.Cgroup == "" {
+			return false
+		}
+		matched := false
+		for _, cgroup := range m.Cgroups {
+			if owner.Cgroup == cgroup || cgroup == "/" || strings.HasPrefix(owner.Cgroup, cgroup+"/") {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
@@ -402,3 +402,58 @@ func BenchmarkMultiGeoIPMatcher(b *testing.B) {
 		_ = matcher.Apply(ctx)
 	}
 }
+
+func TestSocketOwnerMatcher(t *testing.T) {
+	cases := []struct {
+		matcher *SocketOwnerMatcher
+		owner   net.SocketOwner
+		output  bool
+	}{
+		{
+			matcher: NewSocketOwnerMatcher([]uint32{1000}, nil, nil),
+			owner:   net.SocketOwner{UID: 1000, GID: 100},
+			output:  true,
+		},
+		{
+			matcher: NewSocketOwnerMatcher([]uint32{1000}, nil, nil),
+			owner:   net.SocketOwner{UID: 1001, GID: 100},
+			output:  false,
+		},
+		{
+			matcher: NewSocketOwnerMatcher([]uint32{1000}, []uint32{100}, nil),
+			owner:   net.SocketOwner{UID: 1000, GID: 1000},
+			output:  false,
+		},
+		{
+			matcher: NewSocketOwnerMatcher(nil, []uint32{net.UnknownID}, nil),
+			owner:   net.SocketOwner{UID: 1000, GID: net.UnknownID},
+			output:  false,
+		},
+		{
+			matcher: NewSocketOwnerMatcher(nil, nil, []string{"system.slice/docker.service"}),
+			owner:   net.SocketOwner{Cgroup: "/system.slice/docker.service"},
+			output:  true,
+		},
+		{
+			matcher: NewSocketOwnerMatcher(nil, nil, []string{"/user.slice/"}),
+			owner:   net.SocketOwner{Cgroup: "/user.slice/user-1000.slice/session-2.scope"},
+			output:  true,
+		},
+		{
+			matcher: NewSocketOwnerMatcher(nil, nil, []string{"/user.slice"}),
+			owner:   net.SocketOwner{Cgroup: "/user.slices"},
+			output:  false,
+		},
+		{
+			matcher: NewSocketOwnerMatcher(nil, nil, []string{"/"}),
+			owner:   net.SocketOwner{},
+			output:  false,
+		},
+	}
+
+	for i, c := range cases {
+		if actual := c.matcher.Match(&c.owner); actual != c.output {
+			t.Error("case ", i, " expected ", c.output, " but got ", actual)
+		}
+	}
+}
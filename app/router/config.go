@@ -65,6 +65,10 @@ func (rr *RoutingRule) BuildCondition() (Condition, error) {
 		conds.Add(NewUserMatcher(rr.UserEmail))
 	}
 
+	if len(rr.UserGroup) > 0 {
+		conds.Add(NewUserGroupMatcher(rr.UserGroup))
+	}
+
 	if len(rr.Attributes) > 0 {
 		configuredKeys := make(map[string]*regexp.Regexp)
 		for key, value := range rr.Attributes {
@@ -110,6 +114,10 @@ func (rr *RoutingRule) BuildCondition() (Condition, error) {
 		conds.Add(NewProcessNameMatcher(rr.Process))
 	}
 
+	if len(rr.Uid) > 0 || len(rr.Gid) > 0 || len(rr.Cgroup) > 0 {
+		conds.Add(NewSocketOwnerMatcher(rr.Uid, rr.Gid, rr.Cgroup))
+	}
+
 	if conds.Len() == 0 {
 		return nil, errors.New("this rule has no effective fields").AtWarning()
 	}
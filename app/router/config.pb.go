@@ -107,8 +107,15 @@ type RoutingRule struct {
 	VlessRouteList *net.PortList  `protobuf:"bytes,20,opt,name=vless_route_list,json=vlessRouteList,proto3" json:"vless_route_list,omitempty"`
 	Process        []string       `protobuf:"bytes,21,rep,name=process,proto3" json:"process,omitempty"`
 	Webhook        *WebhookConfig `protobuf:"bytes,22,opt,name=webhook,proto3" json:"webhook,omitempty"`
-	unknownFields  protoimpl.UnknownFields
-	sizeCache      protoimpl.SizeCache
+	// Owner of the local socket of the connection, Linux only.
+	Uid []uint32 `protobuf:"varint,23,rep,packed,name=uid,proto3" json:"uid,omitempty"`
+	Gid []uint32 `protobuf:"varint,24,rep,packed,name=gid,proto3" json:"gid,omitempty"`
+	// cgroup v2 paths, matching their descendants too.
+	Cgroup []string `protobuf:"bytes,25,rep,name=cgroup,proto3" json:"cgroup,omitempty"`
+	// Groups of the inbound users, see the accounts of VLESS.
+	UserGroup     []string `protobuf:"bytes,26,rep,name=user_group,json=userGroup,proto3" json:"user_group,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
 func (x *RoutingRule) Reset() {
@@ -278,6 +285,34 @@ func (x *RoutingRule) GetWebhook() *WebhookConfig {
 	return nil
 }
 
+func (x *RoutingRule) GetUid() []uint32 {
+	if x != nil {
+		return x.Uid
+	}
+	return nil
+}
+
+func (x *RoutingRule) GetGid() []uint32 {
+	if x != nil {
+		return x.Gid
+	}
+	return nil
+}
+
+func (x *RoutingRule) GetCgroup() []string {
+	if x != nil {
+		return x.Cgroup
+	}
+	return nil
+}
+
+func (x *RoutingRule) GetUserGroup() []string {
+	if x != nil {
+		return x.UserGroup
+	}
+	return nil
+}
+
 type isRoutingRule_TargetTag interface {
 	isRoutingRule_TargetTag()
 }
@@ -637,7 +672,7 @@ var File_app_router_config_proto protoreflect.FileDescriptor
 
 const file_app_router_config_proto_rawDesc = "" +
 	"\n" +
-	"\x17app/router/config.proto\x12\x0fxray.app.router\x1a!common/serial/typed_message.proto\x1a\x15common/net/port.proto\x1a\x18common/net/network.proto\x1a\x1bcommon/geodata/geodat.proto\"\xc1\a\n" +
+	"\x17app/router/config.proto\x12\x0fxray.app.router\x1a!common/serial/typed_message.proto\x1a\x15common/net/port.proto\x1a\x18common/net/network.proto\x1a\x1bcommon/geodata/geodat.proto\"\x9c\b\n" +
 	"\vRoutingRule\x12\x12\n" +
 	"\x03tag\x18\x01 \x01(\tH\x00R\x03tag\x12%\n" +
 	"\rbalancing_tag\x18\f \x01(\tH\x00R\fbalancingTag\x12\x19\n" +
@@ -661,7 +696,12 @@ const file_app_router_config_proto_rawDesc = "" +
 	"\x0flocal_port_list\x18\x12 \x01(\v2\x19.xray.common.net.PortListR\rlocalPortList\x12C\n" +
 	"\x10vless_route_list\x18\x14 \x01(\v2\x19.xray.common.net.PortListR\x0evlessRouteList\x12\x18\n" +
 	"\aprocess\x18\x15 \x03(\tR\aprocess\x128\n" +
-	"\awebhook\x18\x16 \x01(\v2\x1e.xray.app.router.WebhookConfigR\awebhook\x1a=\n" +
+	"\awebhook\x18\x16 \x01(\v2\x1e.xray.app.router.WebhookConfigR\awebhook\x12\x10\n" +
+	"\x03uid\x18\x17 \x03(\rR\x03uid\x12\x10\n" +
+	"\x03gid\x18\x18 \x03(\rR\x03gid\x12\x16\n" +
+	"\x06cgroup\x18\x19 \x03(\tR\x06cgroup\x12\x1d\n" +
+	"\n" +
+	"user_group\x18\x1a \x03(\tR\tuserGroup\x1a=\n" +
 	"\x0fAttributesEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
 	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01B\f\n" +
@@ -2,7 +2,9 @@ package router
 
 import (
 	"context"
+	"slices"
 	"sync"
+	"sync/atomic"
 
 	"github.com/xtls/xray-core/common"
 	"github.com/xtls/xray-core/common/errors"
@@ -17,14 +19,15 @@ import (
 // Router is an implementation of routing.Router.
 type Router struct {
 	domainStrategy Config_DomainStrategy
-	rules          []*Rule
-	balancers      map[string]*Balancer
-	dns            dns.Client
+	// rules are read without the lock when routing, so they are never changed but replaced as a whole.
+	rules     atomic.Pointer[[]*Rule]
+	balancers map[string]*Balancer
+	dns       dns.Client
 
 	ctx        context.Context
 	ohm        outbound.Manager
 	dispatcher routing.Dispatcher
-	mu         sync.Mutex
+	mu         sync.RWMutex
 }
 
 // Route is an implementation of routing.Route.
@@ -53,11 +56,11 @@ func (r *Router) Init(ctx context.Context, config *Config, d dns.Client, ohm out
 		r.balancers[rule.Tag] = balancer
 	}
 
-	r.rules = make([]*Rule, 0, len(config.Rule))
+	rules := make([]*Rule, 0, len(config.Rule))
 	for _, rule := range config.Rule {
 		cond, err := rule.BuildCondition()
 		if err != nil {
-			r.closeWebhooks()
+			closeWebhooks(rules)
 			return err
 		}
 		rr := &Rule{
@@ -68,7 +71,7 @@ func (r *Router) Init(ctx context.Context, config *Config, d dns.Client, ohm out
 		if wh := rule.GetWebhook(); wh != nil {
 			notifier, err := NewWebhookNotifier(wh)
 			if err != nil {
-				r.closeWebhooks()
+				closeWebhooks(rules)
 				return err
 			}
 			rr.Webhook = notifier
@@ -80,21 +83,35 @@ func (r *Router) Init(ctx context.Context, config *Config, d dns.Client, ohm out
 				if rr.Webhook != nil {
 					rr.Webhook.Close()
 				}
-				r.closeWebhooks()
+				closeWebhooks(rules)
 				return errors.New("balancer ", btag, " not found")
 			}
 			rr.Balancer = brule
 		}
-		r.rules = append(r.rules, rr)
+		rules = append(rules, rr)
 	}
+	r.rules.Store(&rules)
 
 	return nil
 }
 
+// loadRules returns the current rules, which must not be changed.
+func (r *Router) loadRules() []*Rule {
+	if rules := r.rules.Load(); rules != nil {
+		return *rules
+	}
+	return nil
+}
+
 // PickRoute implements routing.Router.
 func (r *Router) PickRoute(ctx routing.Context) (routing.Route, error) {
 	originalCtx := ctx
 	rule, ctx, err := r.pickRouteInternal(ctx)
+	if err == common.ErrNoClue {
+		if tag := ctx.GetUserOutboundTag(); tag != "" {
+			return r.pickUserRoute(ctx, tag)
+		}
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -108,6 +125,21 @@ func (r *Router) PickRoute(ctx routing.Context) (routing.Route, error) {
 	return &Route{Context: ctx, outboundTag: tag, ruleTag: rule.RuleTag}, nil
 }
 
+// pickUserRoute routes to the outbound or balancer the user is bound to.
+func (r *Router) pickUserRoute(ctx routing.Context, tag string) (routing.Route, error) {
+	r.mu.RLock()
+	balancer, found := r.balancers[tag]
+	r.mu.RUnlock()
+	if found {
+		outboundTag, err := balancer.PickOutbound()
+		if err != nil {
+			return nil, err
+		}
+		return &Route{Context: ctx, outboundTag: outboundTag}, nil
+	}
+	return &Route{Context: ctx, outboundTag: tag}, nil
+}
+
 // AddRule implements routing.Router.
 func (r *Router) AddRule(config *serial.TypedMessage, shouldAppend bool) error {
 	inst, err := config.GetInstance()
@@ -124,14 +156,14 @@ func (r *Router) ReloadRules(config *Config, shouldAppend bool) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if !shouldAppend {
-		for _, rule := range r.rules {
-			if rule.Webhook != nil {
-				rule.Webhook.Close()
-			}
-		}
+	var rules []*Rule
+	if shouldAppend {
+		rules = slices.Clone(r.loadRules())
+	} else {
+		closeWebhooks(r.loadRules())
+		r.rules.Store(new([]*Rule))
 		r.balancers = make(map[string]*Balancer, len(config.BalancingRule))
-		r.rules = make([]*Rule, 0, len(config.Rule))
+		rules = make([]*Rule, 0, len(config.Rule))
 	}
 	for _, rule := range config.BalancingRule {
 		_, found := r.balancers[rule.Tag]
@@ -146,18 +178,13 @@ func (r *Router) ReloadRules(config *Config, shouldAppend bool) error {
 		r.balancers[rule.Tag] = balancer
 	}
 
-	startIdx := len(r.rules)
+	startIdx := len(rules)
 	closeNewWebhooks := func() {
-		for i := startIdx; i < len(r.rules); i++ {
-			if r.rules[i].Webhook != nil {
-				r.rules[i].Webhook.Close()
-			}
-		}
-		r.rules = r.rules[:startIdx]
+		closeWebhooks(rules[startIdx:])
 	}
 
 	for _, rule := range config.Rule {
-		if r.RuleExists(rule.GetRuleTag()) {
+		if ruleExists(rules, rule.GetRuleTag()) {
 			closeNewWebhooks()
 			return errors.New("duplicate ruleTag ", rule.GetRuleTag())
 		}
@@ -191,15 +218,20 @@ func (r *Router) ReloadRules(config *Config, shouldAppend bool) error {
 			}
 			rr.Balancer = brule
 		}
-		r.rules = append(r.rules, rr)
+		rules = append(rules, rr)
 	}
+	r.rules.Store(&rules)
 
 	return nil
 }
 
 func (r *Router) RuleExists(tag string) bool {
+	return ruleExists(r.loadRules(), tag)
+}
+
+func ruleExists(rules []*Rule, tag string) bool {
 	if tag != "" {
-		for _, rule := range r.rules {
+		for _, rule := range rules {
 			if rule.RuleTag == tag {
 				return true
 			}
@@ -215,14 +247,14 @@ func (r *Router) RemoveRule(tag string) error {
 
 	newRules := []*Rule{}
 	if tag != "" {
-		for _, rule := range r.rules {
+		for _, rule := range r.loadRules() {
 			if rule.RuleTag != tag {
 				newRules = append(newRules, rule)
 			} else if rule.Webhook != nil {
 				rule.Webhook.Close()
 			}
 		}
-		r.rules = newRules
+		r.rules.Store(&newRules)
 		return nil
 	}
 	return errors.New("empty tag name!")
@@ -230,10 +262,8 @@ func (r *Router) RemoveRule(tag string) error {
 
 // ListRule implements routing.Router
 func (r *Router) ListRule() []routing.Route {
-	r.mu.Lock()
-	defer r.mu.Unlock()
 	ruleList := make([]routing.Route, 0)
-	for _, rule := range r.rules {
+	for _, rule := range r.loadRules() {
 		ruleList = append(ruleList, &Route{
 			outboundTag: rule.Tag,
 			ruleTag:     rule.RuleTag,
@@ -252,7 +282,9 @@ func (r *Router) pickRouteInternal(ctx routing.Context) (*Rule, routing.Context,
 		ctx = routing_dns.ContextWithDNSClient(ctx, r.dns)
 	}
 
-	for _, rule := range r.rules {
+	rules := r.loadRules()
+
+	for _, rule := range rules {
 		if rule.Apply(ctx) {
 			return rule, ctx, nil
 		}
@@ -265,7 +297,7 @@ func (r *Router) pickRouteInternal(ctx routing.Context) (*Rule, routing.Context,
 	ctx = routing_dns.ContextWithDNSClient(ctx, r.dns)
 
 	// Try applying rules again if we have IPs.
-	for _, rule := range r.rules {
+	for _, rule := range rules {
 		if rule.Apply(ctx) {
 			return rule, ctx, nil
 		}
@@ -279,9 +311,9 @@ func (r *Router) Start() error {
 	return nil
 }
 
-// closeWebhooks closes all webhook notifiers in the current rule set.
-func (r *Router) closeWebhooks() {
-	for _, rule := range r.rules {
+// closeWebhooks closes the webhook notifiers of the rules.
+func closeWebhooks(rules []*Rule) {
+	for _, rule := range rules {
 		if rule.Webhook != nil {
 			rule.Webhook.Close()
 		}
@@ -292,7 +324,7 @@ func (r *Router) closeWebhooks() {
 func (r *Router) Close() error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	r.closeWebhooks()
+	closeWebhooks(r.loadRules())
 	return nil
 }
 
@@ -9,10 +9,12 @@ import (
 	"github.com/xtls/xray-core/common"
 	"github.com/xtls/xray-core/common/geodata"
 	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/protocol"
 	"github.com/xtls/xray-core/common/session"
 	"github.com/xtls/xray-core/features/dns"
 	"github.com/xtls/xray-core/features/outbound"
 	routing_session "github.com/xtls/xray-core/features/routing/session"
+	"github.com/xtls/xray-core/proxy/vless"
 	"github.com/xtls/xray-core/testing/mocks"
 )
 
@@ -99,6 +101,69 @@ func TestSimpleBalancer(t *testing.T) {
 	}
 }
 
+func TestUserRouting(t *testing.T) {
+	config := &Config{
+		Rule: []*RoutingRule{
+			{
+				TargetTag: &RoutingRule_Tag{
+					Tag: "vip",
+				},
+				UserGroup: []string{"vip"},
+			},
+		},
+		BalancingRule: []*BalancingRule{
+			{
+				Tag:              "balance",
+				OutboundSelector: []string{"test-"},
+			},
+		},
+	}
+
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+
+	mockDNS := mocks.NewDNSClient(mockCtl)
+	mockOhm := mocks.NewOutboundManager(mockCtl)
+	mockHs := mocks.NewOutboundHandlerSelector(mockCtl)
+
+	mockHs.EXPECT().Select(gomock.Eq([]string{"test-"})).Return([]string{"test"})
+
+	r := new(Router)
+	common.Must(r.Init(context.TODO(), config, mockDNS, &mockOutboundManager{
+		Manager:         mockOhm,
+		HandlerSelector: mockHs,
+	}, nil))
+
+	cases := []struct {
+		account *vless.MemoryAccount
+		tag     string
+	}{
+		{account: &vless.MemoryAccount{Group: "vip", OutboundTag: "exit"}, tag: "vip"},
+		{account: &vless.MemoryAccount{OutboundTag: "exit"}, tag: "exit"},
+		{account: &vless.MemoryAccount{OutboundTag: "balance"}, tag: "test"},
+		{account: &vless.MemoryAccount{Group: "other"}},
+	}
+	for _, c := range cases {
+		ctx := session.ContextWithOutbounds(context.Background(), []*session.Outbound{{
+			Target: net.TCPDestination(net.DomainAddress("example.com"), 80),
+		}})
+		ctx = session.ContextWithInbound(ctx, &session.Inbound{
+			User: &protocol.MemoryUser{Account: c.account},
+		})
+		route, err := r.PickRoute(routing_session.AsRoutingContext(ctx))
+		if c.tag == "" {
+			if err == nil {
+				t.Error("expect no route for ", c.account, ", but actually ", route.GetOutboundTag())
+			}
+			continue
+		}
+		common.Must(err)
+		if tag := route.GetOutboundTag(); tag != c.tag {
+			t.Error("expect tag '", c.tag, "', but actually ", tag)
+		}
+	}
+}
+
 /*
 
 Do not work right now: need a full client setup
@@ -205,6 +205,38 @@ func (s *statsServer) GetSysStats(ctx context.Context, request *SysStatsRequest)
 	return response, nil
 }
 
+func (s *statsServer) SubscribeUserExpiry(request *SubscribeUserExpiryRequest, stream StatsService_SubscribeUserExpiryServer) error {
+	channel, err := feature_stats.GetOrRegisterChannel(s.stats, feature_stats.UserExpiryChannel)
+	if err != nil {
+		return status.Error(codes.Unavailable, "user expiry is not available: "+err.Error())
+	}
+	subscriber, err := feature_stats.SubscribeRunnableChannel(channel)
+	if err != nil {
+		return err
+	}
+	defer feature_stats.UnsubscribeClosableChannel(channel, subscriber)
+	for {
+		select {
+		case value, ok := <-subscriber:
+			if !ok {
+				return status.Error(codes.Unavailable, "upstream closed the subscriber channel")
+			}
+			expiry, ok := value.(feature_stats.UserExpiry)
+			if !ok {
+				continue
+			}
+			if err := stream.Send(&UserExpiry{
+				Email:     expiry.Email,
+				ExpiresAt: expiry.ExpiresAt.Unix(),
+			}); err != nil {
+				return err
+			}
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		}
+	}
+}
+
 func (s *statsServer) mustEmbedUnimplementedStatsServiceServer() {}
 
 type service struct {
@@ -26,6 +26,7 @@ const (
 	StatsService_GetStatsOnlineIpList_FullMethodName = "/xray.app.stats.command.StatsService/GetStatsOnlineIpList"
 	StatsService_GetAllOnlineUsers_FullMethodName    = "/xray.app.stats.command.StatsService/GetAllOnlineUsers"
 	StatsService_GetUsersStats_FullMethodName        = "/xray.app.stats.command.StatsService/GetUsersStats"
+	StatsService_SubscribeUserExpiry_FullMethodName  = "/xray.app.stats.command.StatsService/SubscribeUserExpiry"
 )
 
 // StatsServiceClient is the client API for StatsService service.
@@ -39,6 +40,7 @@ type StatsServiceClient interface {
 	GetStatsOnlineIpList(ctx context.Context, in *GetStatsRequest, opts ...grpc.CallOption) (*GetStatsOnlineIpListResponse, error)
 	GetAllOnlineUsers(ctx context.Context, in *GetAllOnlineUsersRequest, opts ...grpc.CallOption) (*GetAllOnlineUsersResponse, error)
 	GetUsersStats(ctx context.Context, in *GetUsersStatsRequest, opts ...grpc.CallOption) (*GetUsersStatsResponse, error)
+	SubscribeUserExpiry(ctx context.Context, in *SubscribeUserExpiryRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[UserExpiry], error)
 }
 
 type statsServiceClient struct {
@@ -119,6 +121,25 @@ func (c *statsServiceClient) GetUsersStats(ctx context.Context, in *GetUsersStat
 	return out, nil
 }
 
+func (c *statsServiceClient) SubscribeUserExpiry(ctx context.Context, in *SubscribeUserExpiryRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[UserExpiry], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &StatsService_ServiceDesc.Streams[0], StatsService_SubscribeUserExpiry_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[SubscribeUserExpiryRequest, UserExpiry]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type StatsService_SubscribeUserExpiryClient = grpc.ServerStreamingClient[UserExpiry]
+
 // StatsServiceServer is the server API for StatsService service.
 // All implementations must embed UnimplementedStatsServiceServer
 // for forward compatibility.
@@ -130,6 +151,7 @@ type StatsServiceServer interface {
 	GetStatsOnlineIpList(context.Context, *GetStatsRequest) (*GetStatsOnlineIpListResponse, error)
 	GetAllOnlineUsers(context.Context, *GetAllOnlineUsersRequest) (*GetAllOnlineUsersResponse, error)
 	GetUsersStats(context.Context, *GetUsersStatsRequest) (*GetUsersStatsResponse, error)
+	SubscribeUserExpiry(*SubscribeUserExpiryRequest, grpc.ServerStreamingServer[UserExpiry]) error
 	mustEmbedUnimplementedStatsServiceServer()
 }
 
@@ -161,6 +183,9 @@ func (UnimplementedStatsServiceServer) GetAllOnlineUsers(context.Context, *GetAl
 func (UnimplementedStatsServiceServer) GetUsersStats(context.Context, *GetUsersStatsRequest) (*GetUsersStatsResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method GetUsersStats not implemented")
 }
+func (UnimplementedStatsServiceServer) SubscribeUserExpiry(*SubscribeUserExpiryRequest, grpc.ServerStreamingServer[UserExpiry]) error {
+	return status.Error(codes.Unimplemented, "method SubscribeUserExpiry not implemented")
+}
 func (UnimplementedStatsServiceServer) mustEmbedUnimplementedStatsServiceServer() {}
 func (UnimplementedStatsServiceServer) testEmbeddedByValue()                      {}
 
@@ -308,6 +333,17 @@ func _StatsService_GetUsersStats_Handler(srv interface{}, ctx context.Context, d
 	return interceptor(ctx, in, info, handler)
 }
 
+func _StatsService_SubscribeUserExpiry_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeUserExpiryRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(StatsServiceServer).SubscribeUserExpiry(m, &grpc.GenericServerStream[SubscribeUserExpiryRequest, UserExpiry]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type StatsService_SubscribeUserExpiryServer = grpc.ServerStreamingServer[UserExpiry]
+
 // StatsService_ServiceDesc is the grpc.ServiceDesc for StatsService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -344,6 +380,12 @@ var StatsService_ServiceDesc = grpc.ServiceDesc{
 			Handler:    _StatsService_GetUsersStats_Handler,
 		},
 	},
-	Streams:  []grpc.StreamDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "SubscribeUserExpiry",
+			Handler:       _StatsService_SubscribeUserExpiry_Handler,
+			ServerStreams: true,
+		},
+	},
 	Metadata: "app/stats/command/command.proto",
 }
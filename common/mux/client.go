@@ -171,6 +171,8 @@ func (f *DialingWorkerFactory) Create() (*ClientWorker, error) {
 type ClientStrategy struct {
 	MaxConcurrency uint32
 	MaxConnection  uint32
+	// FlowControl requests the flow control of the sessions, see flow.go
+	FlowControl bool
 }
 
 type ClientWorker struct {
@@ -179,6 +181,7 @@ type ClientWorker struct {
 	done           *done.Instance
 	timer          *time.Ticker
 	strategy       ClientStrategy
+	scheduler      *frameScheduler
 }
 
 var (
@@ -195,6 +198,9 @@ func NewClientWorker(stream transport.Link, s ClientStrategy) (*ClientWorker, er
 		timer:          time.NewTicker(time.Second * 16),
 		strategy:       s,
 	}
+	if s.FlowControl {
+		c.scheduler = newFrameScheduler(stream.Writer)
+	}
 
 	go c.fetchOutput()
 	go c.monitor()
@@ -232,6 +238,9 @@ func (m *ClientWorker) monitor() {
 		select {
 		case <-m.done.Wait():
 			m.sessionManager.Close()
+			if m.scheduler != nil {
+				m.scheduler.Close()
+			}
 			common.Interrupt(m.link.Writer)
 			common.Interrupt(m.link.Reader)
 			return
@@ -268,7 +277,11 @@ func fetchInput(ctx context.Context, s *Session, output buf.Writer) {
 	if session.IsReverseMuxFromContext(ctx) {
 		inbound = session.InboundFromContext(ctx)
 	}
+	if s.window != nil {
+		output = &sessionWriter{scheduler: s.scheduler, session: s}
+	}
 	writer := NewWriter(s.ID, ob.Target, output, transferType, xudp.GetGlobalID(ctx), inbound)
+	writer.window = s.window
 	defer s.Close(false)
 	defer writer.Close()
 
@@ -320,6 +333,9 @@ func (m *ClientWorker) Dispatch(ctx context.Context, link *transport.Link) bool
 	}
 	s.input = link.Reader
 	s.output = link.Writer
+	if m.scheduler != nil && xudp.GetGlobalID(ctx) == [8]byte{} {
+		s.enableFlowControl(m.scheduler, false)
+	}
 	go fetchInput(ctx, s, m.link.Writer)
 	if _, ok := link.Reader.(*pipe.Reader); !ok {
 		select {
@@ -345,6 +361,11 @@ func (m *ClientWorker) handleStatusNew(meta *FrameMetadata, reader *buf.Buffered
 }
 
 func (m *ClientWorker) handleStatusKeep(meta *FrameMetadata, reader *buf.BufferedReader) error {
+	if meta.Option.Has(OptionFlowControl) {
+		if s, found := m.sessionManager.Get(meta.SessionID); found && s.window != nil {
+			s.acknowledgeFlowControl()
+		}
+	}
 	if !meta.Option.Has(OptionData) {
 		return nil
 	}
@@ -359,7 +380,7 @@ func (m *ClientWorker) handleStatusKeep(meta *FrameMetadata, reader *buf.Buffere
 	}
 
 	rr := s.NewReader(reader, &meta.Target)
-	err := buf.Copy(rr, s.output)
+	err := s.receive(rr, meta)
 	if err != nil && buf.IsWriteError(err) {
 		errors.LogInfoInner(context.Background(), err, "failed to write to downstream. closing session ", s.ID)
 		s.Close(false)
@@ -405,6 +426,8 @@ func (m *ClientWorker) fetchOutput() {
 			err = m.handleStatusNew(&meta, reader)
 		case SessionStatusKeep:
 			err = m.handleStatusKeep(&meta, reader)
+		case SessionStatusCredit:
+			grantCredit(m.sessionManager, &meta)
 		default:
 			status := meta.SessionStatus
 			errors.LogError(context.Background(), "unknown status: ", status)
@@ -0,0 +1,366 @@
+package mux
+
+import (
+	"context"
+	"io"
+	"sync"
+	"sync/atomic"
+
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/buf"
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/protocol"
+	"github.com/xtls/xray-core/common/signal/done"
+	"github.com/xtls/xray-core/transport/pipe"
+)
+
+/*
+Flow control
+
+The client sets OptionFlowControl on the New frame of a session. A server supporting it replies with a Keep frame
+without data and with OptionFlowControl before any other frame of the session, peers not supporting it ignore the
+option. From then on, each side sends at most initialWindow bytes of data in the session more than the credits
+received from the other side, in Credit frames of 4 bytes of big endian credit after the common metadata. The
+receiver buffers the data of each session up to initialWindow, so that the reader of the Mux connection never
+waits for a slow session, grants the data delivered to the session as credits, and resets the session if the
+peer sends more than its credits. The client doesn't buffer before the acknowledgement, and doesn't request the
+flow control of XUDP sessions, which outlive their Mux connection.
+
+The client doesn't know if the server supports the flow control before the acknowledgement, so it doesn't wait
+for credits before it either, and sets OptionFlowControl on the data frames it sends meanwhile. The server
+doesn't hold them to the window, but waits for room in the buffer of the session, as without flow control.
+*/
+
+const (
+	initialWindow = 256 * 1024
+	// credits are granted in batches, not for each frame
+	creditThreshold = initialWindow / 4
+	// stream sessions which sent less than this are served before bulk ones
+	smallFlowSize = 64 * 1024
+)
+
+// Priorities of the frames in a frameScheduler, lower first.
+const (
+	priorityControl = iota
+	priorityPacket
+	prioritySmallFlow
+	priorityBulk
+	priorityCount
+)
+
+type pendingFrame struct {
+	mb     buf.MultiBuffer
+	result chan error
+}
+
+// frameScheduler writes the frames of the sessions of a Mux connection by their priorities.
+type frameScheduler struct {
+	writer buf.Writer
+	start  sync.Once
+	signal chan struct{}
+	done   *done.Instance
+
+	access sync.Mutex
+	queues [priorityCount][]*pendingFrame
+}
+
+func newFrameScheduler(writer buf.Writer) *frameScheduler {
+	return &frameScheduler{
+		writer: writer,
+		signal: make(chan struct{}, 1),
+		done:   done.New(),
+	}
+}
+
+// write writes a whole frame, and returns after it is written.
+func (s *frameScheduler) write(priority int, mb buf.MultiBuffer) error {
+	s.start.Do(func() { go s.run() })
+	f := &pendingFrame{
+		mb:     mb,
+		result: make(chan error, 1),
+	}
+	s.access.Lock()
+	if s.done.Done() {
+		s.access.Unlock()
+		buf.ReleaseMulti(mb)
+		return io.ErrClosedPipe
+	}
+	s.queues[priority] = append(s.queues[priority], f)
+	s.access.Unlock()
+	select {
+	case s.signal <- struct{}{}:
+	default:
+	}
+	return <-f.result
+}
+
+func (s *frameScheduler) next() *pendingFrame {
+	s.access.Lock()
+	defer s.access.Unlock()
+	for i, queue := range s.queues {
+		if len(queue) > 0 {
+			f := queue[0]
+			queue[0] = nil
+			s.queues[i] = queue[1:]
+			return f
+		}
+	}
+	return nil
+}
+
+func (s *frameScheduler) run() {
+	for {
+		f := s.next()
+		if f == nil {
+			select {
+			case <-s.signal:
+				continue
+			case <-s.done.Wait():
+				s.Close()
+				return
+			}
+		}
+		err := s.writer.WriteMultiBuffer(f.mb)
+		f.result <- err
+		if err != nil {
+			s.Close()
+			return
+		}
+	}
+}
+
+// Close fails the pending frames and the ones to come.
+func (s *frameScheduler) Close() error {
+	s.access.Lock()
+	defer s.access.Unlock()
+	s.done.Close()
+	for i, queue := range s.queues {
+		for _, f := range queue {
+			buf.ReleaseMulti(f.mb)
+			f.result <- io.ErrClosedPipe
+		}
+		s.queues[i] = nil
+	}
+	return nil
+}
+
+// sessionWriter writes the frames of a session to a frameScheduler, by the priority of the session.
+type sessionWriter struct {
+	scheduler *frameScheduler
+	session   *Session
+	sent      int64
+}
+
+// WriteMultiBuffer implements buf.Writer, mb is a whole frame.
+func (w *sessionWriter) WriteMultiBuffer(mb buf.MultiBuffer) error {
+	priority := priorityBulk
+	switch {
+	case w.session.transferType == protocol.TransferTypePacket:
+		priority = priorityPacket
+	case w.sent < smallFlowSize:
+		priority = prioritySmallFlow
+	}
+	w.sent += int64(mb.Len())
+	return w.scheduler.write(priority, mb)
+}
+
+// sendWindow limits the data sent in a session by the credits of the peer.
+type sendWindow struct {
+	access sync.Mutex
+	window int64
+	// acked is whether the peer supports the flow control
+	acked  bool
+	update chan struct{}
+	done   *done.Instance
+}
+
+func newSendWindow(acked bool) *sendWindow {
+	return &sendWindow{
+		window: initialWindow,
+		acked:  acked,
+		update: make(chan struct{}, 1),
+		done:   done.New(),
+	}
+}
+
+// acquire waits for the window to send n bytes, and returns whether they are sent before the peer acknowledges
+// the flow control. The window is not enforced until then, but is counted from the start.
+func (w *sendWindow) acquire(n int32) (bool, error) {
+	for {
+		w.access.Lock()
+		if !w.acked || w.window >= int64(n) {
+			early := !w.acked
+			w.window -= int64(n)
+			w.access.Unlock()
+			return early, nil
+		}
+		w.access.Unlock()
+		select {
+		case <-w.update:
+		case <-w.done.Wait():
+			return false, io.ErrClosedPipe
+		}
+	}
+}
+
+func (w *sendWindow) grant(credit uint32) {
+	w.access.Lock()
+	w.window += int64(credit)
+	w.access.Unlock()
+	w.notify()
+}
+
+// acknowledge returns whether the window was not acknowledged before.
+func (w *sendWindow) acknowledge() bool {
+	w.access.Lock()
+	acked := w.acked
+	w.acked = true
+	w.access.Unlock()
+	w.notify()
+	return !acked
+}
+
+func (w *sendWindow) notify() {
+	select {
+	case w.update <- struct{}{}:
+	default:
+	}
+}
+
+// Close releases the writers waiting for the window.
+func (w *sendWindow) Close() error {
+	return w.done.Close()
+}
+
+// writeCredit sends credit of session id to the peer.
+func writeCredit(scheduler *frameScheduler, id uint16, credit uint32) error {
+	meta := FrameMetadata{
+		SessionID:     id,
+		SessionStatus: SessionStatusCredit,
+		Credit:        credit,
+	}
+	frame := buf.New()
+	common.Must(meta.WriteTo(frame))
+	return scheduler.write(priorityControl, buf.MultiBuffer{frame})
+}
+
+// writeFlowControlAck acknowledges the flow control of session id to the client.
+func writeFlowControlAck(scheduler *frameScheduler, id uint16) error {
+	meta := FrameMetadata{
+		SessionID:     id,
+		SessionStatus: SessionStatusKeep,
+	}
+	meta.Option.Set(OptionFlowControl)
+	frame := buf.New()
+	common.Must(meta.WriteTo(frame))
+	return scheduler.write(priorityControl, buf.MultiBuffer{frame})
+}
+
+// grantCredit grants the credit in meta to its session.
+func grantCredit(m *SessionManager, meta *FrameMetadata) {
+	if s, found := m.Get(meta.SessionID); found && s.window != nil {
+		s.window.grant(meta.Credit)
+	}
+}
+
+// enableFlowControl makes s limit the data it sends by the credits of the peer. acked is whether the peer is
+// known to support the flow control, as on the server. Otherwise the received data keeps going to the bounded
+// output of s until the peer acknowledges the flow control, so peers not supporting it still get backpressure.
+func (s *Session) enableFlowControl(scheduler *frameScheduler, acked bool) {
+	s.scheduler = scheduler
+	s.window = newSendWindow(acked)
+	if acked {
+		s.bufferOutput()
+	}
+}
+
+// acknowledgeFlowControl handles the acknowledgement of the flow control of s by the peer.
+func (s *Session) acknowledgeFlowControl() {
+	if s.window.acknowledge() {
+		s.bufferOutput()
+	}
+}
+
+var errWindowExceeded = errors.New("peer exceeded the flow control window")
+
+// receiveBuffer buffers the received data of a session.
+type receiveBuffer struct {
+	writer *pipe.Writer
+	// outstanding is the data received and not granted back as credits yet
+	outstanding atomic.Int64
+}
+
+// receiveWriter writes the data of a frame to a receiveBuffer.
+type receiveWriter struct {
+	buffer *receiveBuffer
+	// early is whether the data is sent before the flow control is acknowledged
+	early bool
+}
+
+// WriteMultiBuffer implements buf.Writer. It interrupts the buffer if the peer exceeds the window.
+func (w receiveWriter) WriteMultiBuffer(mb buf.MultiBuffer) error {
+	if w.buffer.outstanding.Add(int64(mb.Len())) > initialWindow && !w.early {
+		buf.ReleaseMulti(mb)
+		common.Interrupt(w.buffer.writer)
+		return errWindowExceeded
+	}
+	return w.buffer.writer.WriteMultiBuffer(mb)
+}
+
+// receive copies the data of the frame of meta to the output of s.
+func (s *Session) receive(reader buf.Reader, meta *FrameMetadata) error {
+	if s.received == nil {
+		return buf.Copy(reader, s.output)
+	}
+	return buf.Copy(reader, receiveWriter{
+		buffer: s.received,
+		early:  meta.Option.Has(OptionFlowControl),
+	})
+}
+
+// bufferOutput makes s buffer its received data, and grant the data delivered to its output as credits to the peer.
+func (s *Session) bufferOutput() {
+	s.parent.Lock()
+	defer s.parent.Unlock()
+	if s.closed {
+		return
+	}
+	output := s.output
+	// the data within the window never waits for room
+	reader, writer := pipe.New(pipe.WithSizeLimit(initialWindow))
+	s.received = &receiveBuffer{writer: writer}
+	s.output = writer
+	go s.deliver(reader, output)
+}
+
+func (s *Session) deliver(reader *pipe.Reader, output buf.Writer) {
+	var pending uint32
+	for {
+		mb, err := reader.ReadMultiBuffer()
+		if err != nil {
+			if errors.Cause(err) == io.EOF {
+				common.Close(output)
+			} else {
+				common.Interrupt(output)
+			}
+			return
+		}
+		n := mb.Len()
+		if err := output.WriteMultiBuffer(mb); err != nil {
+			errors.LogInfoInner(context.Background(), err, "failed to write to downstream. closing session ", s.ID)
+			common.Interrupt(reader)
+			s.Close(false)
+			return
+		}
+		pending += uint32(n)
+		if pending >= creditThreshold {
+			// the peer can't use the credit before it is written
+			s.received.outstanding.Add(-int64(pending))
+			if writeCredit(s.scheduler, s.ID, pending) != nil {
+				common.Interrupt(reader)
+				return
+			}
+			pending = 0
+		}
+	}
+}
@@ -21,11 +21,14 @@ const (
 	SessionStatusKeep      SessionStatus = 0x02
 	SessionStatusEnd       SessionStatus = 0x03
 	SessionStatusKeepAlive SessionStatus = 0x04
+	// SessionStatusCredit is only sent to the peers acknowledging the flow control, see flow.go.
+	SessionStatusCredit SessionStatus = 0x05
 )
 
 const (
-	OptionData  bitmask.Byte = 0x01
-	OptionError bitmask.Byte = 0x02
+	OptionData        bitmask.Byte = 0x01
+	OptionError       bitmask.Byte = 0x02
+	OptionFlowControl bitmask.Byte = 0x04
 )
 
 type TargetNetwork byte
@@ -62,6 +65,7 @@ type FrameMetadata struct {
 	SessionStatus SessionStatus
 	GlobalID      [8]byte
 	Inbound       *session.Inbound
+	Credit        uint32
 }
 
 func (f FrameMetadata) WriteTo(b *buf.Buffer) error {
@@ -74,7 +78,9 @@ func (f FrameMetadata) WriteTo(b *buf.Buffer) error {
 	common.Must(b.WriteByte(byte(f.SessionStatus)))
 	common.Must(b.WriteByte(byte(f.Option)))
 
-	if f.SessionStatus == SessionStatusNew {
+	if f.SessionStatus == SessionStatusCredit {
+		binary.BigEndian.PutUint32(b.Extend(4), f.Credit)
+	} else if f.SessionStatus == SessionStatusNew {
 		switch f.Target.Network {
 		case net.Network_TCP:
 			common.Must(b.WriteByte(byte(TargetNetworkTCP)))
@@ -141,6 +147,14 @@ func (f *FrameMetadata) UnmarshalFromBuffer(b *buf.Buffer, readSourceAndLocal bo
 	f.Option = bitmask.Byte(b.Byte(3))
 	f.Target.Network = net.Network_Unknown
 
+	if f.SessionStatus == SessionStatusCredit {
+		if b.Len() < 8 {
+			return errors.New("insufficient buffer: ", b.Len())
+		}
+		f.Credit = binary.BigEndian.Uint32(b.BytesRange(4, 8))
+		return nil
+	}
+
 	if f.SessionStatus == SessionStatusNew || (f.SessionStatus == SessionStatusKeep && b.Len() > 4 &&
 		TargetNetwork(b.Byte(4)) == TargetNetworkUDP) { // MUST check the flag first
 		if b.Len() < 8 {
@@ -23,3 +23,20 @@ func BenchmarkFrameWrite(b *testing.B) {
 		writer.Clear()
 	}
 }
+
+func TestFrameCredit(t *testing.T) {
+	frame := mux.FrameMetadata{
+		SessionID:     3,
+		SessionStatus: mux.SessionStatusCredit,
+		Credit:        65536,
+	}
+	b := buf.New()
+	defer b.Release()
+	common.Must(frame.WriteTo(b))
+
+	var meta mux.FrameMetadata
+	common.Must(meta.Unmarshal(b, false))
+	if meta.SessionID != 3 || meta.SessionStatus != mux.SessionStatusCredit || meta.Credit != 65536 {
+		t.Error("unexpected frame: ", meta)
+	}
+}
@@ -3,6 +3,7 @@ package mux
 import (
 	"context"
 	"io"
+	"sync"
 	"time"
 
 	"github.com/xtls/xray-core/common"
@@ -12,26 +13,87 @@ import (
 	"github.com/xtls/xray-core/common/net"
 	"github.com/xtls/xray-core/common/protocol"
 	"github.com/xtls/xray-core/common/session"
+	"github.com/xtls/xray-core/common/signal"
 	"github.com/xtls/xray-core/common/signal/done"
 	"github.com/xtls/xray-core/core"
+	"github.com/xtls/xray-core/features/policy"
 	"github.com/xtls/xray-core/features/routing"
+	"github.com/xtls/xray-core/features/stats"
 	"github.com/xtls/xray-core/transport"
 	"github.com/xtls/xray-core/transport/pipe"
 )
 
 type Server struct {
-	dispatcher routing.Dispatcher
+	dispatcher    routing.Dispatcher
+	policyManager policy.Manager
+	stats         stats.Manager
 }
 
 // NewServer creates a new mux.Server.
 func NewServer(ctx context.Context) *Server {
 	s := &Server{}
-	core.RequireFeatures(ctx, func(d routing.Dispatcher) {
+	core.RequireFeatures(ctx, func(d routing.Dispatcher, pm policy.Manager, sm stats.Manager) {
 		s.dispatcher = d
+		s.policyManager = pm
+		s.stats = sm
 	})
 	return s
 }
 
+// userWorkers counts the Mux connections of each user on the server side, across the inbounds.
+var userWorkers struct {
+	sync.Mutex
+	Map map[string]uint32
+}
+
+func init() {
+	userWorkers.Map = make(map[string]uint32)
+}
+
+func acquireUserWorker(email string, max uint32) bool {
+	userWorkers.Lock()
+	defer userWorkers.Unlock()
+	if max > 0 && userWorkers.Map[email] >= max {
+		return false
+	}
+	userWorkers.Map[email]++
+	return true
+}
+
+func releaseUserWorker(email string) {
+	userWorkers.Lock()
+	defer userWorkers.Unlock()
+	if userWorkers.Map[email] <= 1 {
+		delete(userWorkers.Map, email)
+	} else {
+		userWorkers.Map[email]--
+	}
+}
+
+// newWorker creates a ServerWorker limited by the policy of the user in ctx.
+func (s *Server) newWorker(ctx context.Context, link *transport.Link) (*ServerWorker, error) {
+	worker := newServerWorker(s.dispatcher, link)
+	if inbound := session.InboundFromContext(ctx); inbound != nil && inbound.User != nil && s.policyManager != nil {
+		p := s.policyManager.ForLevel(inbound.User.Level)
+		worker.limits = p.Mux
+		if email := inbound.User.Email; email != "" {
+			if !acquireUserWorker(email, p.Mux.MaxConnections) {
+				return nil, errors.New("too many Mux connections of user ", email).AtWarning()
+			}
+			worker.email = email
+			if p.Stats.UserMux && s.stats != nil {
+				worker.connections, _ = stats.GetOrRegisterCounter(s.stats, "user>>>"+email+">>>mux>>>connections")
+				worker.sessions, _ = stats.GetOrRegisterCounter(s.stats, "user>>>"+email+">>>mux>>>sessions")
+				if worker.connections != nil {
+					worker.connections.Add(1)
+				}
+			}
+		}
+	}
+	worker.start(ctx)
+	return worker, nil
+}
+
 // Type implements common.HasType.
 func (s *Server) Type() interface{} {
 	return s.dispatcher.Type()
@@ -47,7 +109,7 @@ func (s *Server) Dispatch(ctx context.Context, dest net.Destination) (*transport
 	uplinkReader, uplinkWriter := pipe.New(opts...)
 	downlinkReader, downlinkWriter := pipe.New(opts...)
 
-	_, err := NewServerWorker(ctx, s.dispatcher, &transport.Link{
+	_, err := s.newWorker(ctx, &transport.Link{
 		Reader: uplinkReader,
 		Writer: downlinkWriter,
 	})
@@ -63,7 +125,7 @@ func (s *Server) DispatchLink(ctx context.Context, dest net.Destination, link *t
 	if dest.Address != muxCoolAddress {
 		return s.dispatcher.DispatchLink(ctx, dest, link)
 	}
-	worker, err := NewServerWorker(ctx, s.dispatcher, link)
+	worker, err := s.newWorker(ctx, link)
 	if err != nil {
 		return err
 	}
@@ -90,33 +152,73 @@ type ServerWorker struct {
 	sessionManager *SessionManager
 	done           *done.Instance
 	timer          *time.Ticker
+	scheduler      *frameScheduler
+	limits         policy.Mux
+	// email is set if the Mux connection is counted for its user
+	email       string
+	connections stats.Counter
+	sessions    stats.Counter
 }
 
 func NewServerWorker(ctx context.Context, d routing.Dispatcher, link *transport.Link) (*ServerWorker, error) {
-	worker := &ServerWorker{
+	worker := newServerWorker(d, link)
+	worker.start(ctx)
+	return worker, nil
+}
+
+func newServerWorker(d routing.Dispatcher, link *transport.Link) *ServerWorker {
+	return &ServerWorker{
 		dispatcher:     d,
 		link:           link,
 		sessionManager: NewSessionManager(),
 		done:           done.New(),
 		timer:          time.NewTicker(60 * time.Second),
+		scheduler:      newFrameScheduler(link.Writer),
 	}
+}
+
+func (w *ServerWorker) start(ctx context.Context) {
 	if inbound := session.InboundFromContext(ctx); inbound != nil {
 		inbound.CanSpliceCopy = 3
 	}
-	go worker.run(ctx)
-	go worker.monitor()
-	return worker, nil
+	go w.run(ctx)
+	go w.monitor()
+}
+
+// handle starts handling the session. It is counted in the stats of the user until it ends, from before
+// any of its data is forwarded.
+func (w *ServerWorker) handle(ctx context.Context, s *Session) {
+	if w.sessions != nil {
+		w.sessions.Add(1)
+	}
+	go func() {
+		if w.sessions != nil {
+			defer w.sessions.Add(-1)
+		}
+		handle(ctx, s, w.link.Writer)
+	}()
 }
 
 func handle(ctx context.Context, s *Session, output buf.Writer) {
+	if s.window != nil {
+		output = &sessionWriter{scheduler: s.scheduler, session: s}
+	}
 	writer := NewResponseWriter(s.ID, output, s.transferType)
-	if err := buf.Copy(s.input, writer); err != nil {
+	writer.window = s.window
+	var opts []buf.CopyOption
+	if s.activity != nil {
+		opts = append(opts, buf.UpdateActivity(s.activity))
+	}
+	if err := buf.Copy(s.input, writer, opts...); err != nil {
 		errors.LogInfoInner(ctx, err, "session ", s.ID, " ends.")
 		writer.hasError = true
 	}
 
 	writer.Close()
 	s.Close(false)
+	if s.activity != nil {
+		s.activity.SetTimeout(0)
+	}
 }
 
 func (w *ServerWorker) monitor() {
@@ -128,6 +230,13 @@ func (w *ServerWorker) monitor() {
 		select {
 		case <-w.done.Wait():
 			w.sessionManager.Close()
+			w.scheduler.Close()
+			if w.email != "" {
+				releaseUserWorker(w.email)
+			}
+			if w.connections != nil {
+				w.connections.Add(-1)
+			}
 			common.Interrupt(w.link.Writer)
 			common.Interrupt(w.link.Reader)
 			return
@@ -192,6 +301,18 @@ func (w *ServerWorker) handleStatusNew(ctx context.Context, meta *FrameMetadata,
 		}
 	}
 
+	if max := w.limits.MaxSessions; max > 0 && w.sessionManager.Size() >= int(max) {
+		errors.LogWarning(ctx, "rejected session ", meta.SessionID, " beyond the limit of ", max, " sessions")
+		// Notify remote peer to close this session.
+		closingWriter := NewResponseWriter(meta.SessionID, w.link.Writer, protocol.TransferTypeStream)
+		closingWriter.hasError = true
+		closingWriter.Close()
+		if meta.Option.Has(OptionData) {
+			return buf.Copy(NewStreamReader(reader), buf.Discard)
+		}
+		return nil
+	}
+
 	if meta.GlobalID != [8]byte{} { // MUST ignore empty Global ID
 		mb, err := NewPacketReader(reader, &meta.Target).ReadMultiBuffer()
 		if err != nil {
@@ -252,12 +373,13 @@ func (w *ServerWorker) handleStatusNew(ctx context.Context, meta *FrameMetadata,
 			transferType: protocol.TransferTypePacket,
 			XUDP:         x,
 		}
+		x.Grace = w.limits.XUDPGrace
 		x.Status = Active
 		if !w.sessionManager.Add(x.Mux) {
 			x.Mux.Close(false)
 			return errors.New("failed to add new session")
 		}
-		go handle(ctx, x.Mux, w.link.Writer)
+		w.handle(ctx, x.Mux)
 		return nil
 	}
 
@@ -278,17 +400,42 @@ func (w *ServerWorker) handleStatusNew(ctx context.Context, meta *FrameMetadata,
 	if meta.Target.Network == net.Network_UDP {
 		s.transferType = protocol.TransferTypePacket
 	}
+	if idle := w.limits.SessionIdle; idle > 0 {
+		s.activity = signal.CancelAfterInactivity(ctx, func() {
+			s.parent.Lock()
+			closed := s.closed
+			s.parent.Unlock()
+			if !closed {
+				errors.LogInfo(ctx, "closing session ", s.ID, " idle for ", idle)
+				s.Close(false)
+			}
+		}, idle)
+	}
+	flowControl := meta.Option.Has(OptionFlowControl)
+	if flowControl {
+		s.enableFlowControl(w.scheduler, true)
+	}
 	if !w.sessionManager.Add(s) {
 		s.Close(false)
 		return errors.New("failed to add new session")
 	}
-	go handle(ctx, s, w.link.Writer)
+	if flowControl {
+		// the acknowledgement must precede the response
+		if err := writeFlowControlAck(w.scheduler, s.ID); err != nil {
+			s.Close(false)
+			return errors.New("failed to acknowledge flow control").Base(err)
+		}
+	}
+	w.handle(ctx, s)
 	if !meta.Option.Has(OptionData) {
 		return nil
 	}
 
+	if s.activity != nil {
+		s.activity.Update()
+	}
 	rr := s.NewReader(reader, &meta.Target)
-	err = buf.Copy(rr, s.output)
+	err = s.receive(rr, meta)
 
 	if err != nil && buf.IsWriteError(err) {
 		s.Close(false)
@@ -311,8 +458,11 @@ func (w *ServerWorker) handleStatusKeep(meta *FrameMetadata, reader *buf.Buffere
 		return buf.Copy(NewStreamReader(reader), buf.Discard)
 	}
 
+	if s.activity != nil {
+		s.activity.Update()
+	}
 	rr := s.NewReader(reader, &meta.Target)
-	err := buf.Copy(rr, s.output)
+	err := s.receive(rr, meta)
 
 	if err != nil && buf.IsWriteError(err) {
 		errors.LogInfoInner(context.Background(), err, "failed to write to downstream writer. closing session ", s.ID)
@@ -349,6 +499,8 @@ func (w *ServerWorker) handleFrame(ctx context.Context, reader *buf.BufferedRead
 		err = w.handleStatusNew(session.ContextWithIsReverseMux(ctx, false), &meta, reader)
 	case SessionStatusKeep:
 		err = w.handleStatusKeep(&meta, reader)
+	case SessionStatusCredit:
+		grantCredit(w.sessionManager, &meta)
 	default:
 		status := meta.SessionStatus
 		return errors.New("unknown status: ", status).AtError()
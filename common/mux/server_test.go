@@ -1,15 +1,23 @@
 package mux_test
 
 import (
+	"bytes"
 	"context"
 	"testing"
+	"time"
 
+	"github.com/xtls/xray-core/app/policy"
+	"github.com/xtls/xray-core/app/stats"
 	"github.com/xtls/xray-core/common"
 	"github.com/xtls/xray-core/common/buf"
 	"github.com/xtls/xray-core/common/mux"
 	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/protocol"
+	"github.com/xtls/xray-core/common/serial"
 	"github.com/xtls/xray-core/common/session"
+	"github.com/xtls/xray-core/core"
 	"github.com/xtls/xray-core/features/routing"
+	feature_stats "github.com/xtls/xray-core/features/stats"
 	"github.com/xtls/xray-core/transport"
 	"github.com/xtls/xray-core/transport/pipe"
 )
@@ -56,6 +64,8 @@ func (*TestDispatcher) Type() interface{} {
 	return routing.DispatcherType()
 }
 
+const xrayKey core.XrayKey = 1
+
 func TestRegressionOutboundLeak(t *testing.T) {
 	originalOutbounds := []*session.Outbound{{}}
 	serverCtx := session.ContextWithOutbounds(context.Background(), originalOutbounds)
@@ -122,3 +132,399 @@ func TestRegressionOutboundLeak(t *testing.T) {
 		t.Error("outbound target got leaked: ", outbounds[0].Target.String())
 	}
 }
+
+func TestFlowControl(t *testing.T) {
+	websiteUplinks := make(map[net.Port]*transport.Link)
+	websiteDownlinks := make(map[net.Port]*transport.Link)
+	for _, port := range []net.Port{80, 443} {
+		websiteUplinks[port], websiteDownlinks[port] = newLinkPair()
+	}
+	dispatcher := TestDispatcher{
+		OnDispatch: func(ctx context.Context, dest net.Destination) (*transport.Link, error) {
+			return websiteDownlinks[dest.Port], nil
+		},
+	}
+
+	muxServerUplink, muxServerDownlink := newLinkPair()
+	_, err := mux.NewServerWorker(context.Background(), &dispatcher, muxServerUplink)
+	common.Must(err)
+
+	client, err := mux.NewClientWorker(*muxServerDownlink, mux.ClientStrategy{FlowControl: true})
+	common.Must(err)
+
+	dispatch := func(port net.Port, downlinkOpt pipe.Option) buf.Reader {
+		ctx := session.ContextWithOutbounds(context.Background(), []*session.Outbound{{
+			Target: net.TCPDestination(net.DomainAddress("www.example.com"), port),
+		}})
+		uplinkReader, uplinkWriter := pipe.New(pipe.WithoutSizeLimit())
+		downlinkReader, downlinkWriter := pipe.New(downlinkOpt)
+		if !client.Dispatch(ctx, &transport.Link{Reader: uplinkReader, Writer: downlinkWriter}) {
+			t.Fatal("failed to dispatch")
+		}
+		common.Must(uplinkWriter.WriteMultiBuffer(buf.MultiBuffer{buf.FromBytes([]byte("hello"))}))
+		return downlinkReader
+	}
+
+	// the first session is not read, and must not block the second one
+	const size = 1024 * 1024
+	slow := dispatch(80, pipe.WithSizeLimit(16*1024))
+	payload := make([]byte, size)
+	for i := range payload {
+		payload[i] = byte(i)
+	}
+	common.Must(websiteUplinks[80].Writer.WriteMultiBuffer(buf.MergeBytes(nil, payload)))
+
+	fast := dispatch(443, pipe.WithoutSizeLimit())
+	if mb, err := websiteUplinks[443].Reader.ReadMultiBuffer(); err != nil || mb.String() != "hello" {
+		t.Fatal("upload: ", mb.String(), err)
+	}
+	common.Must(websiteUplinks[443].Writer.WriteMultiBuffer(buf.MultiBuffer{buf.FromBytes([]byte("world"))}))
+	result := make(chan string, 1)
+	go func() {
+		mb, _ := fast.ReadMultiBuffer()
+		result <- mb.String()
+	}()
+	select {
+	case res := <-result:
+		if res != "world" {
+			t.Error("download: ", res)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the second session is blocked by the first one")
+	}
+
+	var received []byte
+	for len(received) < size {
+		mb, err := slow.ReadMultiBuffer()
+		common.Must(err)
+		for _, b := range mb {
+			received = append(received, b.Bytes()...)
+		}
+		buf.ReleaseMulti(mb)
+	}
+	if !bytes.Equal(received, payload) {
+		t.Error("unexpected data of the first session")
+	}
+}
+
+func TestFlowControlWithoutAck(t *testing.T) {
+	// a server not supporting the flow control never acknowledges it
+	serverReader, clientWriter := pipe.New(pipe.WithoutSizeLimit())
+	clientReader, serverWriter := pipe.New(pipe.WithSizeLimit(16 * 1024))
+	client, err := mux.NewClientWorker(transport.Link{Reader: clientReader, Writer: clientWriter}, mux.ClientStrategy{FlowControl: true})
+	common.Must(err)
+
+	ctx := session.ContextWithOutbounds(context.Background(), []*session.Outbound{{
+		Target: net.TCPDestination(net.DomainAddress("www.example.com"), 80),
+	}})
+	uplinkReader, uplinkWriter := pipe.New(pipe.WithoutSizeLimit())
+	_, downlinkWriter := pipe.New(pipe.WithSizeLimit(16 * 1024))
+	if !client.Dispatch(ctx, &transport.Link{Reader: uplinkReader, Writer: downlinkWriter}) {
+		t.Fatal("failed to dispatch")
+	}
+	common.Must(uplinkWriter.WriteMultiBuffer(buf.MultiBuffer{buf.FromBytes([]byte("hello"))}))
+	if _, err := serverReader.ReadMultiBuffer(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the session is not read, so the client must not take all the data
+	written := make(chan struct{})
+	go func() {
+		writer := mux.NewResponseWriter(1, serverWriter, protocol.TransferTypeStream)
+		writer.WriteMultiBuffer(buf.MergeBytes(nil, make([]byte, 1024*1024)))
+		close(written)
+	}()
+	select {
+	case <-written:
+		t.Error("the client buffers the data without limit before the acknowledgement")
+	case <-time.After(time.Second):
+	}
+}
+
+func TestFlowControlWindowExceeded(t *testing.T) {
+	for _, early := range []bool{false, true} {
+		// the session is not read, so all the data but 16 KiB stays in its buffer
+		_, websiteDownlinkWriter := pipe.New(pipe.WithSizeLimit(16 * 1024))
+		websiteUplinkReader, _ := pipe.New(pipe.WithoutSizeLimit())
+		dispatcher := TestDispatcher{
+			OnDispatch: func(ctx context.Context, dest net.Destination) (*transport.Link, error) {
+				return &transport.Link{Reader: websiteUplinkReader, Writer: websiteDownlinkWriter}, nil
+			},
+		}
+		muxServerUplink, muxServerDownlink := newLinkPair()
+		_, err := mux.NewServerWorker(context.Background(), &dispatcher, muxServerUplink)
+		common.Must(err)
+
+		reset := make(chan struct{})
+		go func() {
+			reader := &buf.BufferedReader{Reader: muxServerDownlink.Reader}
+			for {
+				var meta mux.FrameMetadata
+				if err := meta.Unmarshal(reader, false); err != nil {
+					return
+				}
+				if meta.Option.Has(mux.OptionData) {
+					common.Must(buf.Copy(mux.NewStreamReader(reader), buf.Discard))
+				}
+				if meta.SessionStatus == mux.SessionStatusEnd && meta.Option.Has(mux.OptionError) {
+					close(reset)
+					return
+				}
+			}
+		}()
+
+		newFrame := buf.New()
+		common.Must((&mux.FrameMetadata{
+			SessionID:     1,
+			SessionStatus: mux.SessionStatusNew,
+			Target:        net.TCPDestination(net.DomainAddress("www.example.com"), 80),
+			Option:        mux.OptionFlowControl,
+		}).WriteTo(newFrame))
+		common.Must(muxServerDownlink.Writer.WriteMultiBuffer(buf.MultiBuffer{newFrame}))
+		// the client sends 512 KiB without any credit
+		go func() {
+			for i := 0; i < 128; i++ {
+				meta := mux.FrameMetadata{
+					SessionID:     1,
+					SessionStatus: mux.SessionStatusKeep,
+					Option:        mux.OptionData,
+				}
+				if early {
+					meta.Option.Set(mux.OptionFlowControl)
+				}
+				frame := buf.New()
+				common.Must(meta.WriteTo(frame))
+				common.Must2(serial.WriteUint16(frame, 4096))
+				if muxServerDownlink.Writer.WriteMultiBuffer(buf.MergeBytes(buf.MultiBuffer{frame}, make([]byte, 4096))) != nil {
+					return
+				}
+			}
+		}()
+
+		select {
+		case <-reset:
+			if early {
+				t.Error("the session is reset for the data sent before the acknowledgement")
+			}
+		case <-time.After(time.Second):
+			if !early {
+				t.Error("the session is not reset when the client exceeds the window")
+			}
+		}
+		common.Close(muxServerDownlink.Writer)
+	}
+}
+
+func TestServerLimits(t *testing.T) {
+	config := &core.Config{
+		App: []*serial.TypedMessage{
+			serial.ToTypedMessage(&stats.Config{}),
+			serial.ToTypedMessage(&policy.Config{
+				Level: map[uint32]*policy.Policy{
+					0: {
+						Stats: &policy.Policy_Stats{UserMux: true},
+						Mux: &policy.Policy_Mux{
+							MaxSessions:    1,
+							MaxConnections: 1,
+							SessionIdle:    &policy.Second{Value: 1},
+						},
+					},
+				},
+			}),
+		},
+	}
+	v, err := core.New(config)
+	common.Must(err)
+	websites := make(chan *transport.Link, 2)
+	common.Must(v.AddFeature(&TestDispatcher{
+		OnDispatch: func(ctx context.Context, dest net.Destination) (*transport.Link, error) {
+			uplink, downlink := newLinkPair()
+			websites <- uplink
+			return downlink, nil
+		},
+	}))
+	ctx := context.WithValue(context.Background(), xrayKey, v)
+	ctx = session.ContextWithInbound(ctx, &session.Inbound{
+		User: &protocol.MemoryUser{Email: "love@example.com"},
+	})
+	server := mux.NewServer(ctx)
+	muxCool := net.TCPDestination(net.DomainAddress("v1.mux.cool"), 9527)
+
+	link, err := server.Dispatch(ctx, muxCool)
+	common.Must(err)
+	if _, err := server.Dispatch(ctx, muxCool); err == nil {
+		t.Error("expected the second Mux connection of the user to be rejected")
+	}
+	client, err := mux.NewClientWorker(*link, mux.ClientStrategy{})
+	common.Must(err)
+
+	statsManager := v.GetFeature(feature_stats.ManagerType()).(feature_stats.Manager)
+	sessions := statsManager.GetCounter("user>>>love@example.com>>>mux>>>sessions")
+	if c := statsManager.GetCounter("user>>>love@example.com>>>mux>>>connections"); c == nil || c.Value() != 1 {
+		t.Error("expected 1 Mux connection of the user")
+	}
+
+	dispatch := func() buf.Reader {
+		ctx := session.ContextWithOutbounds(context.Background(), []*session.Outbound{{
+			Target: net.TCPDestination(net.DomainAddress("www.example.com"), 80),
+		}})
+		uplink, downlink := newLinkPair()
+		if !client.Dispatch(ctx, uplink) {
+			t.Fatal("failed to dispatch")
+		}
+		common.Must(downlink.Writer.WriteMultiBuffer(buf.MultiBuffer{buf.FromBytes([]byte("hello"))}))
+		return downlink.Reader
+	}
+
+	first := dispatch()
+	website := <-websites
+	if mb, err := website.Reader.ReadMultiBuffer(); err != nil || mb.String() != "hello" {
+		t.Fatal("upload: ", mb.String(), err)
+	}
+	if sessions == nil || sessions.Value() != 1 {
+		t.Error("expected 1 Mux session of the user")
+	}
+
+	// the second session is beyond the limit
+	second := dispatch()
+	if _, err := second.ReadMultiBuffer(); err == nil {
+		t.Error("expected the second session to be rejected")
+	}
+
+	// the first session is closed once idle
+	if _, err := first.ReadMultiBuffer(); err == nil {
+		t.Error("expected the idle session to be closed")
+	}
+	if _, err := website.Reader.ReadMultiBuffer(); err == nil {
+		t.Error("expected the idle session to be closed on the server")
+	}
+	if !waitFor(func() bool { return sessions.Value() == 0 }) {
+		t.Error("expected no Mux session of the user, but got ", sessions.Value())
+	}
+
+	common.Must(client.Close())
+	if !waitFor(func() bool {
+		link, err = server.Dispatch(ctx, muxCool)
+		return err == nil
+	}) {
+		t.Fatal("expected a new Mux connection after the first one is closed: ", err)
+	}
+	common.Close(link.Writer)
+	connections := statsManager.GetCounter("user>>>love@example.com>>>mux>>>connections")
+	if !waitFor(func() bool { return connections.Value() == 0 }) {
+		t.Error("expected the Mux connection of the user to be released")
+	}
+	if link, err = server.Dispatch(ctx, muxCool); err != nil {
+		t.Error("expected a new Mux connection after the second one is closed: ", err)
+	} else {
+		common.Close(link.Writer)
+	}
+}
+
+// waitFor polls cond until it holds, for up to a second, and returns whether it does.
+func waitFor(cond func() bool) bool {
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return true
+}
+
+func TestXUDPGrace(t *testing.T) {
+	config := &core.Config{
+		App: []*serial.TypedMessage{
+			serial.ToTypedMessage(&policy.Config{
+				Level: map[uint32]*policy.Policy{
+					0: {
+						Mux: &policy.Policy_Mux{
+							XudpGrace: &policy.Second{Value: 1},
+						},
+					},
+				},
+			}),
+		},
+	}
+	v, err := core.New(config)
+	common.Must(err)
+	websites := make(chan *transport.Link, 2)
+	common.Must(v.AddFeature(&TestDispatcher{
+		OnDispatch: func(ctx context.Context, dest net.Destination) (*transport.Link, error) {
+			uplink, downlink := newLinkPair()
+			websites <- uplink
+			return downlink, nil
+		},
+	}))
+	ctx := context.WithValue(context.Background(), xrayKey, v)
+	ctx = session.ContextWithInbound(ctx, &session.Inbound{
+		User: &protocol.MemoryUser{},
+	})
+	server := mux.NewServer(ctx)
+	muxCool := net.TCPDestination(net.DomainAddress("v1.mux.cool"), 9527)
+
+	// the client sends packets of the same source, so they have the same GlobalID
+	clientCtx := context.WithValue(context.Background(), "cone", true)
+	clientCtx = session.ContextWithInbound(clientCtx, &session.Inbound{
+		Name:   "socks",
+		Source: net.UDPDestination(net.ParseAddress("192.0.2.1"), 5000),
+	})
+	clientCtx = session.ContextWithOutbounds(clientCtx, []*session.Outbound{{
+		Target: net.UDPDestination(net.ParseAddress("192.0.2.2"), 53),
+	}})
+	connect := func(payload string) (*mux.ClientWorker, *transport.Link) {
+		link, err := server.Dispatch(ctx, muxCool)
+		common.Must(err)
+		client, err := mux.NewClientWorker(*link, mux.ClientStrategy{})
+		common.Must(err)
+		uplink, downlink := newLinkPair()
+		if !client.Dispatch(clientCtx, uplink) {
+			t.Fatal("failed to dispatch")
+		}
+		b := buf.FromBytes([]byte(payload))
+		b.UDP = &net.Destination{Address: net.ParseAddress("192.0.2.2"), Port: 53, Network: net.Network_UDP}
+		common.Must(downlink.Writer.WriteMultiBuffer(buf.MultiBuffer{b}))
+		return client, downlink
+	}
+
+	client, _ := connect("hello")
+	website := <-websites
+	if mb, err := website.Reader.ReadMultiBuffer(); err != nil || mb.String() != "hello" {
+		t.Fatal("upload: ", mb.String(), err)
+	}
+	common.Must(client.Close())
+	time.Sleep(100 * time.Millisecond)
+
+	// the reconnecting client reattaches to the same outbound
+	client, downlink := connect("again")
+	if mb, err := website.Reader.ReadMultiBuffer(); err != nil || mb.String() != "again" {
+		t.Fatal("upload after reconnecting: ", mb.String(), err)
+	}
+	select {
+	case <-websites:
+		t.Fatal("dispatched again after reconnecting")
+	default:
+	}
+	common.Must(website.Writer.WriteMultiBuffer(buf.MultiBuffer{buf.FromBytes([]byte("world"))}))
+	if mb, err := downlink.Reader.ReadMultiBuffer(); err != nil || mb.String() != "world" {
+		t.Fatal("download after reconnecting: ", mb.String(), err)
+	}
+	common.Must(client.Close())
+
+	// the outbound is closed after the grace period
+	time.Sleep(1500 * time.Millisecond)
+	if _, err := website.Reader.(*pipe.Reader).ReadMultiBufferTimeout(time.Second); err == nil || err == buf.ErrReadTimeout {
+		t.Error("expected the outbound to be closed after the grace period")
+	}
+	client, _ = connect("new")
+	select {
+	case website = <-websites:
+	case <-time.After(time.Second):
+		t.Fatal("expected a new outbound")
+	}
+	if mb, err := website.Reader.ReadMultiBuffer(); err != nil || mb.String() != "new" {
+		t.Error("upload to the new outbound: ", mb.String(), err)
+	}
+	common.Must(client.Close())
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/xtls/xray-core/common/errors"
 	"github.com/xtls/xray-core/common/net"
 	"github.com/xtls/xray-core/common/protocol"
+	"github.com/xtls/xray-core/common/signal"
 	"github.com/xtls/xray-core/common/signal/done"
 	"github.com/xtls/xray-core/transport/pipe"
 )
@@ -163,6 +164,12 @@ type Session struct {
 	closed       bool
 	done         *done.Instance
 	XUDP         *XUDP
+	// scheduler and window are set if the session is flow controlled, received once it buffers its received data
+	scheduler *frameScheduler
+	window    *sendWindow
+	received  *receiveBuffer
+	// activity is set if the session is closed once idle
+	activity *signal.ActivityTimer
 }
 
 // Close closes all resources associated with this session.
@@ -179,6 +186,9 @@ func (s *Session) Close(locked bool) error {
 	if s.done != nil {
 		s.done.Close()
 	}
+	if s.window != nil {
+		s.window.Close()
+	}
 	if s.XUDP == nil {
 		common.Interrupt(s.input)
 		common.Close(s.output)
@@ -191,9 +201,14 @@ func (s *Session) Close(locked bool) error {
 		s.input.(*pipe.Reader).Recover()
 		XUDPManager.Lock()
 		if s.XUDP.Status == Active {
-			s.XUDP.Expire = time.Now().Add(time.Minute)
+			grace := s.XUDP.Grace
+			if grace <= 0 {
+				grace = defaultXUDPGrace
+			}
+			s.XUDP.Expire = time.Now().Add(grace)
 			s.XUDP.Status = Expiring
-			errors.LogDebug(context.Background(), "XUDP put ", s.XUDP.GlobalID)
+			s.XUDP.expireAfter(grace)
+			errors.LogDebug(context.Background(), "XUDP put ", s.XUDP.GlobalID, " for ", grace)
 		}
 		XUDPManager.Unlock()
 	}
@@ -215,11 +230,19 @@ const (
 	Expiring     = 2
 )
 
+// defaultXUDPGrace is the time an XUDP session is kept after its Mux connection closes, if the policy doesn't set it.
+const defaultXUDPGrace = time.Minute
+
+// XUDP is a UDP session of a client, identified by its GlobalID, which outlives the Mux connection carrying it
+// for a grace period, so that the client reconnecting in time reattaches to the same outbound UDP socket.
 type XUDP struct {
 	GlobalID [8]byte
 	Status   uint64
 	Expire   time.Time
+	Grace    time.Duration
 	Mux      *Session
+
+	timer *time.Timer
 }
 
 func (x *XUDP) Interrupt() {
@@ -227,6 +250,22 @@ func (x *XUDP) Interrupt() {
 	common.Close(x.Mux.output)
 }
 
+// expireAfter removes the XUDP if it is still expiring after d, it must be called with XUDPManager locked.
+func (x *XUDP) expireAfter(d time.Duration) {
+	if x.timer != nil {
+		x.timer.Stop()
+	}
+	x.timer = time.AfterFunc(d, func() {
+		XUDPManager.Lock()
+		defer XUDPManager.Unlock()
+		if x.Status == Expiring && !time.Now().Before(x.Expire) && XUDPManager.Map[x.GlobalID] == x {
+			x.Interrupt()
+			delete(XUDPManager.Map, x.GlobalID)
+			errors.LogDebug(context.Background(), "XUDP del ", x.GlobalID)
+		}
+	})
+}
+
 var XUDPManager struct {
 	sync.Mutex
 	Map map[[8]byte]*XUDP
@@ -234,19 +273,4 @@ var XUDPManager struct {
 
 func init() {
 	XUDPManager.Map = make(map[[8]byte]*XUDP)
-	go func() {
-		for {
-			time.Sleep(time.Minute)
-			now := time.Now()
-			XUDPManager.Lock()
-			for id, x := range XUDPManager.Map {
-				if x.Status == Expiring && now.After(x.Expire) {
-					x.Interrupt()
-					delete(XUDPManager.Map, id)
-					errors.LogDebug(context.Background(), "XUDP del ", id)
-				}
-			}
-			XUDPManager.Unlock()
-		}
-	}()
 }
@@ -18,6 +18,8 @@ type Writer struct {
 	transferType protocol.TransferType
 	globalID     [8]byte
 	inbound      *session.Inbound
+	// window is set if the session is flow controlled
+	window *sendWindow
 }
 
 func NewWriter(id uint16, dest net.Destination, writer buf.Writer, transferType protocol.TransferType, globalID [8]byte, inbound *session.Inbound) *Writer {
@@ -54,6 +56,9 @@ func (w *Writer) getNextFrameMeta() FrameMetadata {
 	} else {
 		w.followup = true
 		meta.SessionStatus = SessionStatusNew
+		if w.window != nil {
+			meta.Option.Set(OptionFlowControl)
+		}
 	}
 
 	return meta
@@ -86,9 +91,13 @@ func writeMetaWithFrame(writer buf.Writer, meta FrameMetadata, data buf.MultiBuf
 	return writer.WriteMultiBuffer(mb2)
 }
 
-func (w *Writer) writeData(mb buf.MultiBuffer) error {
+// writeData writes mb in a frame, early is whether it is sent before the flow control is acknowledged.
+func (w *Writer) writeData(mb buf.MultiBuffer, early bool) error {
 	meta := w.getNextFrameMeta()
 	meta.Option.Set(OptionData)
+	if early {
+		meta.Option.Set(OptionFlowControl)
+	}
 
 	return writeMetaWithFrame(w.writer, meta, mb)
 }
@@ -110,7 +119,15 @@ func (w *Writer) WriteMultiBuffer(mb buf.MultiBuffer) error {
 			mb = mb2
 			chunk = buf.MultiBuffer{b}
 		}
-		if err := w.writeData(chunk); err != nil {
+		early := false
+		if w.window != nil {
+			var err error
+			if early, err = w.window.acquire(chunk.Len()); err != nil {
+				buf.ReleaseMulti(chunk)
+				return err
+			}
+		}
+		if err := w.writeData(chunk, early); err != nil {
 			return err
 		}
 	}
@@ -21,6 +21,8 @@ func DestinationFromAddr(addr net.Addr) Destination {
 		return UDPDestination(IPAddress(addr.IP), Port(addr.Port))
 	case *net.UnixAddr:
 		return UnixDestination(DomainAddress(addr.Name))
+	case *net.IPAddr:
+		return ICMPDestination(IPAddress(addr.IP))
 	default:
 		panic("Net: Unknown address type.")
 	}
@@ -86,12 +88,20 @@ func UnixDestination(address Address) Destination {
 	}
 }
 
+// ICMPDestination creates an ICMP destination with given address
+func ICMPDestination(address Address) Destination {
+	return Destination{
+		Network: Network_ICMP,
+		Address: address,
+	}
+}
+
 // NetAddr returns the network address in this Destination in string form.
 func (d Destination) NetAddr() string {
 	addr := ""
 	if d.Network == Network_TCP || d.Network == Network_UDP {
 		addr = d.Address.String() + ":" + d.Port.String()
-	} else if d.Network == Network_UNIX {
+	} else if d.Network == Network_UNIX || d.Network == Network_ICMP {
 		addr = d.Address.String()
 	}
 	return addr
@@ -122,6 +132,12 @@ func (d Destination) RawNetAddr() net.Addr {
 				Net:  d.Network.SystemString(),
 			}
 		}
+	case Network_ICMP:
+		if d.Address.Family().IsIP() {
+			addr = &net.IPAddr{
+				IP: d.Address.IP(),
+			}
+		}
 	}
 	return addr
 }
@@ -136,6 +152,8 @@ func (d Destination) String() string {
 		prefix = "udp:"
 	case Network_UNIX:
 		prefix = "unix:"
+	case Network_ICMP:
+		prefix = "icmp:"
 	}
 	return prefix + d.NetAddr()
 }
@@ -32,6 +32,12 @@ func TestDestinationProperty(t *testing.T) {
 			String:    "unix:/tmp/test.sock",
 			NetString: "/tmp/test.sock",
 		},
+		{
+			Input:     ICMPDestination(IPAddress([]byte{1, 1, 1, 1})),
+			Network:   Network_ICMP,
+			String:    "icmp:1.1.1.1",
+			NetString: "1.1.1.1",
+		},
 	}
 
 	for _, testCase := range testCases {
@@ -0,0 +1,67 @@
+package net
+
+import (
+	"strconv"
+	"sync"
+	"time"
+)
+
+// UnknownID is the UID or GID of a SocketOwner that could not be determined.
+const UnknownID = ^uint32(0)
+
+// SocketOwner identifies who owns a local socket.
+type SocketOwner struct {
+	UID uint32
+	// GID is the primary group of UID.
+	GID uint32
+	// Cgroup is the cgroup v2 path of the socket, e.g. /system.slice/docker-<id>.scope. Empty if unknown.
+	Cgroup string
+}
+
+type socketOwnerCacheEntry struct {
+	owner  *SocketOwner
+	err    error
+	expire time.Time
+}
+
+const (
+	socketOwnerCacheTTL  = 10 * time.Second
+	socketOwnerCacheSize = 4096
+)
+
+var socketOwnerCache = struct {
+	sync.Mutex
+	entries map[string]socketOwnerCacheEntry
+}{entries: make(map[string]socketOwnerCacheEntry)}
+
+// FindSocketOwner finds the owner of the local socket of a connection, from its source to its destination.
+// Results are cached shortly, so that a connection is only looked up once however many rules match it.
+func FindSocketOwner(network, srcIP string, srcPort uint16, destIP string, destPort uint16) (*SocketOwner, error) {
+	key := network + "|" + srcIP + "|" + strconv.Itoa(int(srcPort)) + "|" + destIP + "|" + strconv.Itoa(int(destPort))
+	now := time.Now()
+
+	socketOwnerCache.Lock()
+	entry, found := socketOwnerCache.entries[key]
+	socketOwnerCache.Unlock()
+	if found && now.Before(entry.expire) {
+		return entry.owner, entry.err
+	}
+
+	owner, err := findSocketOwner(network, srcIP, srcPort, destIP, destPort)
+
+	socketOwnerCache.Lock()
+	if len(socketOwnerCache.entries) >= socketOwnerCacheSize {
+		for k, e := range socketOwnerCache.entries {
+			if now.After(e.expire) {
+				delete(socketOwnerCache.entries, k)
+			}
+		}
+		if len(socketOwnerCache.entries) >= socketOwnerCacheSize {
+			clear(socketOwnerCache.entries)
+		}
+	}
+	socketOwnerCache.entries[key] = socketOwnerCacheEntry{owner: owner, err: err, expire: now.Add(socketOwnerCacheTTL)}
+	socketOwnerCache.Unlock()
+
+	return owner, err
+}
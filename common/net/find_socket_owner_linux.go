@@ -0,0 +1,313 @@
+//go:build linux && !android
+
+package net
+
+import (
+	"encoding/binary"
+	"io/fs"
+	"net"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/xtls/xray-core/common/errors"
+	"golang.org/x/sys/unix"
+)
+
+// inet_diag definitions from linux/inet_diag.h, which golang.org/x/sys/unix does not provide
+const (
+	sizeofInetDiagReqV2 = 56
+	sizeofInetDiagMsg   = 72
+	inetDiagNoCookie    = 0xffffffff
+	inetDiagCgroupID    = 21
+)
+
+func findSocketOwner(network, srcIP string, srcPort uint16, destIP string, destPort uint16) (*SocketOwner, error) {
+	src := net.ParseIP(srcIP)
+	isLocal, err := IsLocal(src)
+	if err != nil {
+		return nil, errors.New("failed to determine if address is local: ", err)
+	}
+	if !isLocal {
+		return nil, ErrNotLocal
+	}
+
+	var protocol uint8
+	switch network {
+	case "tcp":
+		protocol = unix.IPPROTO_TCP
+	case "udp":
+		protocol = unix.IPPROTO_UDP
+	default:
+		return nil, errors.New("unsupported network for socket owner lookup: ", network)
+	}
+
+	uid, cgroupID, err := querySocketDiag(protocol, src, srcPort, net.ParseIP(destIP), destPort)
+	if err != nil {
+		return nil, errors.New("failed to find the socket of ", srcIP, ":", srcPort).Base(err)
+	}
+	owner := &SocketOwner{
+		UID: uid,
+		GID: primaryGroupOf(uid),
+	}
+	if cgroupID != 0 {
+		owner.Cgroup = cgroupPathOf(cgroupID)
+	}
+	return owner, nil
+}
+
+// querySocketDiag asks sock_diag for the socket bound to src:srcPort. Connected TCP sockets are looked up directly,
+// the rest are dumped and matched here, as UDP sockets are mostly unconnected and may be bound to a wildcard address.
+func querySocketDiag(protocol uint8, src net.IP, srcPort uint16, dst net.IP, dstPort uint16) (uid uint32, cgroupID uint64, err error) {
+	family := uint8(unix.AF_INET)
+	if src.To4() == nil {
+		family = unix.AF_INET6
+	}
+
+	fd, err := unix.Socket(unix.AF_NETLINK, unix.SOCK_DGRAM|unix.SOCK_CLOEXEC, unix.NETLINK_SOCK_DIAG)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer unix.Close(fd)
+	tv := unix.NsecToTimeval(int64(time.Second))
+	if err := unix.SetsockoptTimeval(fd, unix.SOL_SOCKET, unix.SO_RCVTIMEO, &tv); err != nil {
+		return 0, 0, err
+	}
+
+	if protocol == unix.IPPROTO_TCP && dst != nil && dstPort != 0 {
+		uid, cgroupID, err = socketDiagRoundTrip(fd, newSocketDiagRequest(family, protocol, src, srcPort, dst, dstPort, false), src, srcPort, dst, dstPort)
+		if err == nil {
+			return uid, cgroupID, nil
+		}
+	}
+	uid, cgroupID, err = socketDiagRoundTrip(fd, newSocketDiagRequest(family, protocol, nil, 0, nil, 0, true), src, srcPort, dst, dstPort)
+	if err != nil && family == unix.AF_INET {
+		// dual-stack sockets carry IPv4 traffic with v4-mapped addresses
+		return socketDiagRoundTrip(fd, newSocketDiagRequest(unix.AF_INET6, protocol, nil, 0, nil, 0, true), src, srcPort, dst, dstPort)
+	}
+	return uid, cgroupID, err
+}
+
+func newSocketDiagRequest(family, protocol uint8, src net.IP, srcPort uint16, dst net.IP, dstPort uint16, dump bool) []byte {
+	flags := uint16(unix.NLM_F_REQUEST)
+	if dump {
+		flags |= unix.NLM_F_DUMP
+	}
+	b := make([]byte, unix.SizeofNlMsghdr+sizeofInetDiagReqV2)
+	binary.NativeEndian.PutUint32(b[0:], uint32(len(b)))
+	binary.NativeEndian.PutUint16(b[4:], unix.SOCK_DIAG_BY_FAMILY)
+	binary.NativeEndian.PutUint16(b[6:], flags)
+	binary.NativeEndian.PutUint32(b[8:], 1)
+
+	req := b[unix.SizeofNlMsghdr:]
+	req[0] = family
+	req[1] = protocol
+	binary.NativeEndian.PutUint32(req[4:], 0xffffffff) // all states
+	binary.BigEndian.PutUint16(req[8:], srcPort)
+	binary.BigEndian.PutUint16(req[10:], dstPort)
+	putSocketDiagAddress(req[12:28], family, src)
+	putSocketDiagAddress(req[28:44], family, dst)
+	binary.NativeEndian.PutUint32(req[48:], inetDiagNoCookie)
+	binary.NativeEndian.PutUint32(req[52:], inetDiagNoCookie)
+	return b
+}
+
+func putSocketDiagAddress(b []byte, family uint8, ip net.IP) {
+	if ip == nil {
+		return
+	}
+	if family == unix.AF_INET {
+		copy(b, ip.To4())
+	} else {
+		copy(b, ip.To16())
+	}
+}
+
+func socketDiagRoundTrip(fd int, request []byte, src net.IP, srcPort uint16, dst net.IP, dstPort uint16) (uint32, uint64, error) {
+	if err := unix.Sendto(fd, request, 0, &unix.SockaddrNetlink{Family: unix.AF_NETLINK}); err != nil {
+		return 0, 0, err
+	}
+
+	buf := make([]byte, 32*1024)
+	var best *socketDiagMessage
+	bestScore := 0
+	for {
+		n, _, err := unix.Recvfrom(fd, buf, 0)
+		if err != nil {
+			return 0, 0, err
+		}
+		messages, err := syscall.ParseNetlinkMessage(buf[:n])
+		if err != nil {
+			return 0, 0, err
+		}
+		for _, m := range messages {
+			switch m.Header.Type {
+			case unix.NLMSG_DONE:
+				if best == nil {
+					return 0, 0, errors.New("socket not found")
+				}
+				return best.uid, best.cgroupID, nil
+			case unix.NLMSG_ERROR:
+				if len(m.Data) >= 4 {
+					if errno := -int32(binary.NativeEndian.Uint32(m.Data)); errno != 0 {
+						return 0, 0, syscall.Errno(errno)
+					}
+				}
+				return 0, 0, errors.New("unexpected netlink acknowledgement")
+			case unix.SOCK_DIAG_BY_FAMILY:
+				msg, err := parseSocketDiagMessage(m.Data)
+				if err != nil {
+					return 0, 0, err
+				}
+				if m.Header.Flags&unix.NLM_F_MULTI == 0 {
+					// answer of a direct lookup
+					return msg.uid, msg.cgroupID, nil
+				}
+				if score := msg.match(src, srcPort, dst, dstPort); score > bestScore {
+					best, bestScore = msg, score
+				}
+			}
+		}
+	}
+}
+
+type socketDiagMessage struct {
+	srcPort  uint16
+	dstPort  uint16
+	src      net.IP
+	dst      net.IP
+	uid      uint32
+	cgroupID uint64
+}
+
+// parseSocketDiagMessage parses an inet_diag_msg and the attributes following it
+func parseSocketDiagMessage(b []byte) (*socketDiagMessage, error) {
+	if len(b) < sizeofInetDiagMsg {
+		return nil, errors.New("short inet_diag_msg")
+	}
+	msg := &socketDiagMessage{
+		srcPort: binary.BigEndian.Uint16(b[4:]),
+		dstPort: binary.BigEndian.Uint16(b[6:]),
+		uid:     binary.NativeEndian.Uint32(b[64:]),
+	}
+	if b[0] == unix.AF_INET {
+		msg.src = net.IP(append([]byte(nil), b[8:12]...))
+		msg.dst = net.IP(append([]byte(nil), b[24:28]...))
+	} else {
+		msg.src = net.IP(append([]byte(nil), b[8:24]...))
+		msg.dst = net.IP(append([]byte(nil), b[24:40]...))
+	}
+
+	attrs := b[sizeofInetDiagMsg:]
+	for len(attrs) >= unix.SizeofRtAttr {
+		length := int(binary.NativeEndian.Uint16(attrs))
+		if length < unix.SizeofRtAttr || length > len(attrs) {
+			break
+		}
+		if binary.NativeEndian.Uint16(attrs[2:]) == inetDiagCgroupID && length >= unix.SizeofRtAttr+8 {
+			msg.cgroupID = binary.NativeEndian.Uint64(attrs[unix.SizeofRtAttr:])
+		}
+		length = (length + unix.RTA_ALIGNTO - 1) &^ (unix.RTA_ALIGNTO - 1)
+		if length > len(attrs) {
+			break
+		}
+		attrs = attrs[length:]
+	}
+	return msg, nil
+}
+
+// match scores how well the socket matches a connection from src:srcPort to dst:dstPort, 0 if it does not at all
+func (m *socketDiagMessage) match(src net.IP, srcPort uint16, dst net.IP, dstPort uint16) int {
+	if m.srcPort != srcPort {
+		return 0
+	}
+	score := 0
+	switch {
+	case m.src.Equal(src):
+		score = 2
+	case m.src.IsUnspecified():
+		score = 1
+	default:
+		return 0
+	}
+	if m.dstPort != 0 || !m.dst.IsUnspecified() {
+		if dst == nil || m.dstPort != dstPort || !m.dst.Equal(dst) {
+			return 0
+		}
+		score += 2
+	}
+	return score
+}
+
+var primaryGroups sync.Map // uint32 -> uint32
+
+func primaryGroupOf(uid uint32) uint32 {
+	if gid, ok := primaryGroups.Load(uid); ok {
+		return gid.(uint32)
+	}
+	gid := UnknownID
+	if u, err := user.LookupId(strconv.FormatUint(uint64(uid), 10)); err == nil {
+		if g, err := strconv.ParseUint(u.Gid, 10, 32); err == nil {
+			gid = uint32(g)
+		}
+	}
+	primaryGroups.Store(uid, gid)
+	return gid
+}
+
+const cgroupRescanInterval = 5 * time.Second
+
+// cgroupPaths maps the ids of cgroup v2 directories, which are their inode numbers, to their paths
+var cgroupPaths = struct {
+	sync.Mutex
+	paths    map[uint64]string
+	lastScan time.Time
+}{paths: make(map[uint64]string)}
+
+func cgroupPathOf(id uint64) string {
+	cgroupPaths.Lock()
+	defer cgroupPaths.Unlock()
+
+	if path, found := cgroupPaths.paths[id]; found {
+		return path
+	}
+	if time.Since(cgroupPaths.lastScan) < cgroupRescanInterval {
+		return ""
+	}
+	cgroupPaths.lastScan = time.Now()
+
+	root := cgroup2Root()
+	if root == "" {
+		return ""
+	}
+	paths := make(map[uint64]string, len(cgroupPaths.paths))
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+			rel, _ := filepath.Rel(root, path)
+			paths[stat.Ino] = filepath.Clean("/" + rel)
+		}
+		return nil
+	})
+	cgroupPaths.paths = paths
+	return paths[id]
+}
+
+func cgroup2Root() string {
+	for _, root := range []string{"/sys/fs/cgroup", "/sys/fs/cgroup/unified"} {
+		var st unix.Statfs_t
+		if unix.Statfs(root, &st) == nil && st.Type == unix.CGROUP2_SUPER_MAGIC {
+			return root
+		}
+	}
+	return ""
+}
@@ -0,0 +1,45 @@
+//go:build linux && !android
+
+package net_test
+
+import (
+	"net"
+	"os"
+	"testing"
+
+	"github.com/xtls/xray-core/common"
+	. "github.com/xtls/xray-core/common/net"
+)
+
+func TestFindSocketOwner(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	common.Must(err)
+	defer listener.Close()
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	common.Must(err)
+	defer conn.Close()
+
+	local := conn.LocalAddr().(*net.TCPAddr)
+	remote := conn.RemoteAddr().(*net.TCPAddr)
+	owner, err := FindSocketOwner("tcp", local.IP.String(), uint16(local.Port), remote.IP.String(), uint16(remote.Port))
+	if err != nil {
+		t.Skip("sock_diag unavailable: ", err)
+	}
+	if owner.UID != uint32(os.Getuid()) {
+		t.Error("expected uid ", os.Getuid(), " but got ", owner.UID)
+	}
+
+	packetConn, err := net.ListenPacket("udp", ":0")
+	common.Must(err)
+	defer packetConn.Close()
+	port := uint16(packetConn.LocalAddr().(*net.UDPAddr).Port)
+	owner, err = FindSocketOwner("udp", "127.0.0.1", port, "1.1.1.1", 53)
+	common.Must(err)
+	if owner.UID != uint32(os.Getuid()) {
+		t.Error("expected uid ", os.Getuid(), " but got ", owner.UID)
+	}
+
+	if _, err := FindSocketOwner("udp", "8.8.8.8", port, "1.1.1.1", 53); err != ErrNotLocal {
+		t.Error("expected ErrNotLocal but got ", err)
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !linux || android
+
+package net
+
+import (
+	"github.com/xtls/xray-core/common/errors"
+)
+
+func findSocketOwner(network, srcIP string, srcPort uint16, destIP string, destPort uint16) (*SocketOwner, error) {
+	return nil, errors.New("socket owner lookup is not supported on this platform")
+}
@@ -25,6 +25,8 @@ var LookupIP = net.LookupIP
 
 var FileConn = net.FileConn
 
+var FilePacketConn = net.FilePacketConn
+
 // ParseIP is an alias of net.ParseIP
 var ParseIP = net.ParseIP
 
@@ -8,6 +8,16 @@ type Account interface {
 	ToProto() proto.Message
 }
 
+// RoutingAccount is an Account carrying routing hints of its user.
+type RoutingAccount interface {
+	Account
+	// GetOutboundTag returns the tag of the outbound or balancer for the connections of the user not matching
+	// any routing rule, or "".
+	GetOutboundTag() string
+	// GetGroup returns the group of the user to match in routing rules, or "".
+	GetGroup() string
+}
+
 // AsAccount is an object can be converted into account.
 type AsAccount interface {
 	AsAccount() (Account, error)
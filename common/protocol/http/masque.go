@@ -0,0 +1,130 @@
+package http
+
+import (
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/apernet/quic-go/quicvarint"
+	"github.com/xtls/xray-core/common/buf"
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/net"
+)
+
+const (
+	// ProtocolConnectUDP is the :protocol of extended CONNECT requests for proxying UDP (RFC 9298).
+	ProtocolConnectUDP = "connect-udp"
+	// ProtocolConnectIP is the :protocol of extended CONNECT requests for proxying IP (RFC 9484).
+	ProtocolConnectIP = "connect-ip"
+
+	// CapsuleTypeDatagram is the type of DATAGRAM capsules (RFC 9297).
+	CapsuleTypeDatagram = 0x00
+
+	// DefaultConnectUDPTemplate is the default URI template of CONNECT-UDP requests, without scheme and authority.
+	DefaultConnectUDPTemplate = connectUDPPathPrefix + "{target_host}/{target_port}/"
+
+	connectUDPPathPrefix = "/.well-known/masque/udp/"
+)
+
+// ExpandConnectUDPTemplate expands the URI template of CONNECT-UDP requests to dest.
+// Variables target_host and target_port are expanded as simple strings (RFC 6570),
+// so that e.g. colons of IPv6 addresses are percent-encoded.
+func ExpandConnectUDPTemplate(template string, dest net.Destination) string {
+	host := dest.Address.String()
+	if dest.Address.Family().IsIP() {
+		host = dest.Address.IP().String() // without brackets
+	}
+	return strings.NewReplacer(
+		"{target_host}", escapeTemplateValue(host),
+		"{target_port}", dest.Port.String(),
+	).Replace(template)
+}
+
+// ConnectUDPPath returns the path of a CONNECT-UDP request to dest, following the default URI template.
+func ConnectUDPPath(dest net.Destination) string {
+	return ExpandConnectUDPTemplate(DefaultConnectUDPTemplate, dest)
+}
+
+func escapeTemplateValue(s string) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '-' || c == '.' || c == '_' || c == '~' {
+			sb.WriteByte(c)
+		} else {
+			sb.WriteString("%" + strings.ToUpper(strconv.FormatUint(uint64(c)|0x100, 16)[1:]))
+		}
+	}
+	return sb.String()
+}
+
+// ParseConnectUDPPath parses the target of a CONNECT-UDP request from its escaped path,
+// following the default URI template "/.well-known/masque/udp/{target_host}/{target_port}/".
+func ParseConnectUDPPath(path string) (net.Destination, error) {
+	if !strings.HasPrefix(path, connectUDPPathPrefix) {
+		return net.Destination{}, errors.New("unexpected CONNECT-UDP path: ", path)
+	}
+	parts := strings.Split(strings.TrimSuffix(path[len(connectUDPPathPrefix):], "/"), "/")
+	if len(parts) != 2 {
+		return net.Destination{}, errors.New("unexpected CONNECT-UDP path: ", path)
+	}
+	host, err := url.PathUnescape(parts[0])
+	if err != nil || host == "" {
+		return net.Destination{}, errors.New("invalid CONNECT-UDP target host: ", parts[0])
+	}
+	port, err := strconv.ParseUint(parts[1], 10, 16)
+	if err != nil || port == 0 {
+		return net.Destination{}, errors.New("invalid CONNECT-UDP target port: ", parts[1])
+	}
+	return net.UDPDestination(net.ParseAddress(host), net.Port(port)), nil
+}
+
+// ReadDatagramCapsule reads capsules from r until a DATAGRAM capsule, skipping the ones of other types,
+// and returns its payload. Payloads larger than a buffer are dropped.
+func ReadDatagramCapsule(r quicvarint.Reader) (*buf.Buffer, error) {
+	for {
+		typ, err := quicvarint.Read(r)
+		if err != nil {
+			return nil, err
+		}
+		length, err := quicvarint.Read(r)
+		if err != nil {
+			return nil, err
+		}
+		if typ != CapsuleTypeDatagram || length > buf.Size {
+			if _, err := io.CopyN(io.Discard, r, int64(length)); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		b := buf.New()
+		if _, err := b.ReadFullFrom(r, int32(length)); err != nil {
+			b.Release()
+			return nil, err
+		}
+		return b, nil
+	}
+}
+
+// WriteUDPDatagramCapsule writes UDP payload of CONNECT-UDP to w in a DATAGRAM capsule with context ID 0.
+func WriteUDPDatagramCapsule(w io.Writer, payload []byte) error {
+	b := make([]byte, 0, 16+len(payload))
+	b = quicvarint.Append(b, CapsuleTypeDatagram)
+	b = quicvarint.Append(b, uint64(1+len(payload)))
+	b = append(b, 0) // context ID
+	b = append(b, payload...)
+	_, err := w.Write(b)
+	return err
+}
+
+// ReadContextID strips the context ID of an HTTP Datagram of CONNECT-UDP from b.
+// It returns false if b is not UDP payload, i.e. the context ID is not 0.
+func ReadContextID(b *buf.Buffer) bool {
+	id, n, err := quicvarint.Parse(b.Bytes())
+	if err != nil || id != 0 {
+		return false
+	}
+	b.Advance(int32(n))
+	return true
+}
@@ -0,0 +1,75 @@
+package http_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/buf"
+	"github.com/xtls/xray-core/common/net"
+	. "github.com/xtls/xray-core/common/protocol/http"
+)
+
+func TestConnectUDPPath(t *testing.T) {
+	cases := []struct {
+		dest net.Destination
+		path string
+	}{
+		{
+			dest: net.UDPDestination(net.ParseAddress("192.0.2.6"), 443),
+			path: "/.well-known/masque/udp/192.0.2.6/443/",
+		},
+		{
+			dest: net.UDPDestination(net.ParseAddress("2001:db8::42"), 53),
+			path: "/.well-known/masque/udp/2001%3Adb8%3A%3A42/53/",
+		},
+		{
+			dest: net.UDPDestination(net.ParseAddress("example.com"), 8443),
+			path: "/.well-known/masque/udp/example.com/8443/",
+		},
+	}
+	for _, c := range cases {
+		path := ConnectUDPPath(c.dest)
+		if path != c.path {
+			t.Error("path of ", c.dest, ": ", path, ", expected ", c.path)
+		}
+		dest, err := ParseConnectUDPPath(path)
+		common.Must(err)
+		if dest != c.dest {
+			t.Error("dest of ", path, ": ", dest, ", expected ", c.dest)
+		}
+	}
+
+	for _, path := range []string{
+		"/.well-known/masque/udp/example.com/",
+		"/.well-known/masque/udp/example.com/0/",
+		"/.well-known/masque/ip/example.com/443/",
+	} {
+		if _, err := ParseConnectUDPPath(path); err == nil {
+			t.Error("expected error for ", path)
+		}
+	}
+}
+
+func TestDatagramCapsule(t *testing.T) {
+	var b bytes.Buffer
+	b.Write([]byte{0x2d, 0x02, 0xff, 0xff}) // unknown capsule
+	common.Must(WriteUDPDatagramCapsule(&b, []byte("payload")))
+
+	payload, err := ReadDatagramCapsule(&b)
+	common.Must(err)
+	defer payload.Release()
+	if !ReadContextID(payload) {
+		t.Fatal("unexpected context ID")
+	}
+	if string(payload.Bytes()) != "payload" {
+		t.Error("payload: ", payload.String())
+	}
+
+	ip := buf.New()
+	defer ip.Release()
+	ip.Write([]byte{0x01, 0x45})
+	if ReadContextID(ip) {
+		t.Error("unexpected context ID 1")
+	}
+}
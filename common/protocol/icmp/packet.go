@@ -0,0 +1,227 @@
+// Package icmp implements the packets carried by links of net.Network_ICMP.
+//
+// Every buffer on such a link holds one IPv4 or IPv6 packet with an ICMP message,
+// so the TTL of requests and the source of replies survive the way through
+// inbounds and outbounds, which is what traceroute relies on. Inbounds send Echo
+// requests, and outbounds send back Echo replies and the errors quoting them.
+package icmp
+
+import (
+	"encoding/binary"
+
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/net"
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/checksum"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+)
+
+const (
+	// headerSize is the size of the ICMP header, including the identifier and sequence number of Echo.
+	headerSize = 8
+
+	// Errors quote as much of the original packet as fits into the minimum MTU.
+	maxQuotedIPv4 = 576 - header.IPv4MinimumSize - headerSize
+	maxQuotedIPv6 = 1280 - header.IPv6MinimumSize - headerSize
+)
+
+// Packet is an ICMP message together with the fields of its IP header.
+type Packet struct {
+	Source      net.IP
+	Destination net.IP
+	TTL         uint8
+	// Message is the ICMP header and body.
+	Message []byte
+}
+
+// ParsePacket parses an IPv4 or IPv6 packet carrying an ICMP message.
+// The message of the returned packet shares memory with b.
+// Truncated packets, as quoted by errors, are accepted.
+func ParsePacket(b []byte) (*Packet, error) {
+	if len(b) == 0 {
+		return nil, errors.New("empty packet")
+	}
+	switch header.IPVersion(b) {
+	case header.IPv4Version:
+		if len(b) < header.IPv4MinimumSize {
+			return nil, errors.New("invalid IPv4 packet")
+		}
+		ip := header.IPv4(b)
+		headerLength := int(ip.HeaderLength())
+		if headerLength < header.IPv4MinimumSize || len(b) < headerLength+headerSize {
+			return nil, errors.New("invalid IPv4 packet")
+		}
+		if ip.Protocol() != uint8(header.ICMPv4ProtocolNumber) {
+			return nil, errors.New("not an ICMP packet")
+		}
+		end := int(ip.TotalLength())
+		if end < headerLength+headerSize || end > len(b) {
+			end = len(b)
+		}
+		src, dst := ip.SourceAddress(), ip.DestinationAddress()
+		return &Packet{
+			Source:      net.IP(src.AsSlice()),
+			Destination: net.IP(dst.AsSlice()),
+			TTL:         ip.TTL(),
+			Message:     b[headerLength:end],
+		}, nil
+	case header.IPv6Version:
+		if len(b) < header.IPv6MinimumSize+headerSize {
+			return nil, errors.New("invalid IPv6 packet")
+		}
+		ip := header.IPv6(b)
+		if ip.NextHeader() != uint8(header.ICMPv6ProtocolNumber) {
+			return nil, errors.New("not an ICMPv6 packet")
+		}
+		end := header.IPv6MinimumSize + int(ip.PayloadLength())
+		if end < header.IPv6MinimumSize+headerSize || end > len(b) {
+			end = len(b)
+		}
+		src, dst := ip.SourceAddress(), ip.DestinationAddress()
+		return &Packet{
+			Source:      net.IP(src.AsSlice()),
+			Destination: net.IP(dst.AsSlice()),
+			TTL:         ip.HopLimit(),
+			Message:     b[header.IPv6MinimumSize:end],
+		}, nil
+	default:
+		return nil, errors.New("unknown IP version")
+	}
+}
+
+// IsIPv6 returns true if the packet is ICMPv6.
+func (p *Packet) IsIPv6() bool {
+	return p.Destination.To4() == nil
+}
+
+// Type returns the ICMP type of the message.
+func (p *Packet) Type() uint8 {
+	return p.Message[0]
+}
+
+// IsEchoRequest returns true if the message is an Echo request.
+func (p *Packet) IsEchoRequest() bool {
+	if p.IsIPv6() {
+		return header.ICMPv6Type(p.Type()) == header.ICMPv6EchoRequest
+	}
+	return header.ICMPv4Type(p.Type()) == header.ICMPv4Echo
+}
+
+// IsEchoReply returns true if the message is an Echo reply.
+func (p *Packet) IsEchoReply() bool {
+	if p.IsIPv6() {
+		return header.ICMPv6Type(p.Type()) == header.ICMPv6EchoReply
+	}
+	return header.ICMPv4Type(p.Type()) == header.ICMPv4EchoReply
+}
+
+// IsError returns true if the message is an error that quotes the packet causing it.
+func (p *Packet) IsError() bool {
+	if p.IsIPv6() {
+		switch header.ICMPv6Type(p.Type()) {
+		case header.ICMPv6DstUnreachable, header.ICMPv6PacketTooBig, header.ICMPv6TimeExceeded, header.ICMPv6ParamProblem:
+			return true
+		}
+		return false
+	}
+	switch header.ICMPv4Type(p.Type()) {
+	case header.ICMPv4DstUnreachable, header.ICMPv4TimeExceeded, header.ICMPv4ParamProblem:
+		return true
+	}
+	return false
+}
+
+// Ident returns the identifier of an Echo message.
+func (p *Packet) Ident() uint16 {
+	return binary.BigEndian.Uint16(p.Message[4:])
+}
+
+// SetIdent sets the identifier of an Echo message.
+func (p *Packet) SetIdent(ident uint16) {
+	binary.BigEndian.PutUint16(p.Message[4:], ident)
+}
+
+// Quoted parses the packet quoted by an error.
+func (p *Packet) Quoted() (*Packet, error) {
+	if !p.IsError() {
+		return nil, errors.New("not an ICMP error")
+	}
+	return ParsePacket(p.Message[headerSize:])
+}
+
+// SetQuoted replaces the packet quoted by an error.
+func (p *Packet) SetQuoted(quoted *Packet) {
+	p.Message = append(p.Message[:headerSize:headerSize], quoted.marshal(false)...)
+}
+
+// Marshal encodes the packet, computing its checksums.
+func (p *Packet) Marshal() []byte {
+	return p.marshal(true)
+}
+
+func (p *Packet) marshal(computeChecksum bool) []byte {
+	if p.IsIPv6() {
+		b := make([]byte, header.IPv6MinimumSize+len(p.Message))
+		src := tcpip.AddrFrom16Slice(p.Source.To16())
+		dst := tcpip.AddrFrom16Slice(p.Destination.To16())
+		header.IPv6(b).Encode(&header.IPv6Fields{
+			PayloadLength:     uint16(len(p.Message)),
+			TransportProtocol: header.ICMPv6ProtocolNumber,
+			HopLimit:          p.TTL,
+			SrcAddr:           src,
+			DstAddr:           dst,
+		})
+		message := header.ICMPv6(b[header.IPv6MinimumSize:])
+		copy(message, p.Message)
+		if computeChecksum {
+			message.SetChecksum(0)
+			message.SetChecksum(header.ICMPv6Checksum(header.ICMPv6ChecksumParams{
+				Header:      message[:header.ICMPv6MinimumSize],
+				Src:         src,
+				Dst:         dst,
+				PayloadCsum: checksum.Checksum(message.Payload(), 0),
+				PayloadLen:  len(message.Payload()),
+			}))
+		}
+		return b
+	}
+
+	b := make([]byte, header.IPv4MinimumSize+len(p.Message))
+	ip := header.IPv4(b)
+	ip.Encode(&header.IPv4Fields{
+		TotalLength: uint16(len(b)),
+		TTL:         p.TTL,
+		Protocol:    uint8(header.ICMPv4ProtocolNumber),
+		SrcAddr:     tcpip.AddrFrom4Slice(p.Source.To4()),
+		DstAddr:     tcpip.AddrFrom4Slice(p.Destination.To4()),
+	})
+	ip.SetChecksum(^ip.CalculateChecksum())
+	message := header.ICMPv4(b[header.IPv4MinimumSize:])
+	copy(message, p.Message)
+	if computeChecksum {
+		message.SetChecksum(0)
+		message.SetChecksum(header.ICMPv4Checksum(message[:header.ICMPv4MinimumSize], checksum.Checksum(message.Payload(), 0)))
+	}
+	return b
+}
+
+// NewError creates an error sent by from to to, quoting the packet causing it.
+func NewError(from, to net.IP, icmpType, icmpCode uint8, quoted *Packet) *Packet {
+	b := quoted.marshal(false)
+	limit := maxQuotedIPv4
+	if quoted.IsIPv6() {
+		limit = maxQuotedIPv6
+	}
+	if len(b) > limit {
+		b = b[:limit]
+	}
+	message := make([]byte, headerSize, headerSize+len(b))
+	message[0] = icmpType
+	message[1] = icmpCode
+	return &Packet{
+		Source:      from,
+		Destination: to,
+		TTL:         64,
+		Message:     append(message, b...),
+	}
+}
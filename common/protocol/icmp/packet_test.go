@@ -0,0 +1,129 @@
+package icmp_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/net"
+	. "github.com/xtls/xray-core/common/protocol/icmp"
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/checksum"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+)
+
+func echoRequest(src, dst net.IP, ident uint16) *Packet {
+	message := []byte{8, 0, 0, 0, 0, 0, 0, 1, 'p', 'i', 'n', 'g'}
+	if dst.To4() == nil {
+		message[0] = 128
+	}
+	p := &Packet{
+		Source:      src,
+		Destination: dst,
+		TTL:         3,
+		Message:     message,
+	}
+	p.SetIdent(ident)
+	return p
+}
+
+func TestPacketIPv4(t *testing.T) {
+	src := net.IP{10, 0, 0, 2}
+	dst := net.IP{1, 1, 1, 1}
+	b := echoRequest(src, dst, 0x1234).Marshal()
+
+	ip := header.IPv4(b)
+	if !ip.IsChecksumValid() {
+		t.Error("invalid IPv4 checksum")
+	}
+	if checksum.Checksum(b[header.IPv4MinimumSize:], 0) != 0xffff {
+		t.Error("invalid ICMP checksum")
+	}
+
+	p, err := ParsePacket(b)
+	common.Must(err)
+	if !p.Source.Equal(src) || !p.Destination.Equal(dst) || p.TTL != 3 {
+		t.Error("unexpected header ", p.Source, " ", p.Destination, " ", p.TTL)
+	}
+	if p.IsIPv6() || !p.IsEchoRequest() || p.IsEchoReply() || p.IsError() {
+		t.Error("unexpected type ", p.Type())
+	}
+	if p.Ident() != 0x1234 {
+		t.Error("unexpected ident ", p.Ident())
+	}
+	if !bytes.Equal(p.Message[8:], []byte("ping")) {
+		t.Error("unexpected payload ", p.Message[8:])
+	}
+}
+
+func TestPacketIPv6(t *testing.T) {
+	src := net.ParseIP("fd00::2")
+	dst := net.ParseIP("2001:db8::1")
+	b := echoRequest(src, dst, 7).Marshal()
+
+	xsum := header.PseudoHeaderChecksum(header.ICMPv6ProtocolNumber, tcpip.AddrFrom16Slice(src), tcpip.AddrFrom16Slice(dst), uint16(len(b)-header.IPv6MinimumSize))
+	if checksum.Checksum(b[header.IPv6MinimumSize:], xsum) != 0xffff {
+		t.Error("invalid ICMPv6 checksum")
+	}
+
+	p, err := ParsePacket(b)
+	common.Must(err)
+	if !p.IsIPv6() || !p.IsEchoRequest() || p.Ident() != 7 || p.TTL != 3 {
+		t.Error("unexpected packet ", p)
+	}
+}
+
+func TestPacketError(t *testing.T) {
+	local := net.IP{10, 0, 0, 2}
+	router := net.IP{192, 168, 1, 1}
+	request := echoRequest(net.IP{172, 16, 0, 1}, net.IP{1, 1, 1, 1}, 42)
+
+	// Time Exceeded
+	p := NewError(router, local, 11, 0, request)
+	if !p.IsError() {
+		t.Fatal("not an error")
+	}
+	quoted, err := p.Quoted()
+	common.Must(err)
+	if quoted.Ident() != 42 || !quoted.Destination.Equal(net.IP{1, 1, 1, 1}) {
+		t.Error("unexpected quoted packet ", quoted)
+	}
+
+	quoted.Source = local
+	quoted.SetIdent(43)
+	p.SetQuoted(quoted)
+
+	p, err = ParsePacket(p.Marshal())
+	common.Must(err)
+	if !p.Source.Equal(router) || !p.Destination.Equal(local) {
+		t.Error("unexpected header ", p.Source, " ", p.Destination)
+	}
+	quoted, err = p.Quoted()
+	common.Must(err)
+	if quoted.Ident() != 43 || !quoted.Source.Equal(local) || !quoted.IsEchoRequest() {
+		t.Error("unexpected quoted packet ", quoted)
+	}
+
+	if _, err := request.Quoted(); err == nil {
+		t.Error("expected error for Echo request")
+	}
+}
+
+func TestParseInvalidPacket(t *testing.T) {
+	for _, b := range [][]byte{
+		nil,
+		{0x45, 0, 0},
+		{0x60},
+		{0x15, 0, 0, 0},
+	} {
+		if _, err := ParsePacket(b); err == nil {
+			t.Error("expected error for ", b)
+		}
+	}
+
+	udp := echoRequest(net.IP{10, 0, 0, 2}, net.IP{1, 1, 1, 1}, 1).Marshal()
+	udp[9] = uint8(header.UDPProtocolNumber)
+	if _, err := ParsePacket(udp); err == nil {
+		t.Error("expected error for UDP")
+	}
+}
@@ -1,6 +1,8 @@
 package protocol
 
 import (
+	"time"
+
 	"github.com/xtls/xray-core/common/errors"
 	"github.com/xtls/xray-core/common/serial"
 )
@@ -28,22 +30,37 @@ func (u *User) ToMemoryUser() (*MemoryUser, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &MemoryUser{
+	mUser := &MemoryUser{
 		Account: account,
 		Email:   u.Email,
 		Level:   u.Level,
-	}, nil
+	}
+	// users without any validity set are always valid, but get one so that they can be limited while in use
+	enabled := true
+	if u.Enabled != nil {
+		enabled = *u.Enabled
+	}
+	mUser.Validity = NewValidity(u.ExpiresAt, u.NotBefore, enabled)
+	return mUser, nil
 }
 
 func ToProtoUser(mu *MemoryUser) *User {
 	if mu == nil {
 		return nil
 	}
-	return &User{
+	u := &User{
 		Account: serial.ToTypedMessage(mu.Account.ToProto()),
 		Email:   mu.Email,
 		Level:   mu.Level,
 	}
+	if mu.Validity != nil {
+		var enabled bool
+		u.ExpiresAt, u.NotBefore, enabled = mu.Validity.Get()
+		if !enabled {
+			u.Enabled = &enabled
+		}
+	}
+	return u
 }
 
 // MemoryUser is a parsed form of User, to reduce number of parsing of Account proto.
@@ -52,4 +69,14 @@ type MemoryUser struct {
 	Account Account
 	Email   string
 	Level   uint32
+	// Validity is nil for the users not built from a User, which are always valid.
+	Validity *Validity
+}
+
+// Check returns an error if the user is not accepted at now.
+func (u *MemoryUser) Check(now time.Time) error {
+	if u == nil || u.Validity == nil {
+		return nil
+	}
+	return u.Validity.Check(now)
 }
@@ -0,0 +1,72 @@
+package protocol_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/xtls/xray-core/common/protocol"
+	"github.com/xtls/xray-core/common/serial"
+	"github.com/xtls/xray-core/proxy/vless"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestUserValidity(t *testing.T) {
+	now := time.Now()
+	user := &protocol.User{
+		Email:     "love@example.com",
+		Account:   serial.ToTypedMessage(&vless.Account{Id: "27848739-7e62-4138-9fd3-098a63964b6b"}),
+		ExpiresAt: now.Add(time.Hour).Unix(),
+		NotBefore: now.Add(-time.Hour).Unix(),
+	}
+	mUser, err := user.ToMemoryUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mUser.Check(now); err != nil {
+		t.Error("expected the user to be valid, but got ", err)
+	}
+	if err := mUser.Check(now.Add(2 * time.Hour)); err == nil {
+		t.Error("expected the user to expire")
+	}
+	if err := mUser.Check(now.Add(-2 * time.Hour)); err == nil {
+		t.Error("expected the user not to be valid yet")
+	}
+
+	// the copies of the user share the validity
+	copied := *mUser
+	updated := false
+	mUser.Validity.OnUpdate(func() { updated = true })
+	mUser.Validity.Update(now.Add(-time.Minute).Unix(), 0, true)
+	if !updated {
+		t.Error("expected OnUpdate to be called")
+	}
+	if err := copied.Check(now); err == nil {
+		t.Error("expected the copy of the user to expire")
+	}
+
+	mUser.Validity.Update(0, 0, false)
+	if err := mUser.Check(now); err == nil {
+		t.Error("expected the user to be disabled")
+	}
+	if !proto.Equal(protocol.ToProtoUser(mUser), &protocol.User{
+		Email:   user.Email,
+		Account: user.Account,
+		Enabled: proto.Bool(false),
+	}) {
+		t.Error("unexpected proto user ", protocol.ToProtoUser(mUser))
+	}
+
+	always, err := (&protocol.User{Email: "always@example.com", Account: user.Account}).ToMemoryUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if always.Validity == nil {
+		t.Fatal("expected a user without expiresAt, notBefore or enabled to have a validity to be limited later")
+	}
+	if err := always.Check(now); err != nil {
+		t.Error("expected a user without expiresAt, notBefore or enabled to be valid, but got ", err)
+	}
+	if !proto.Equal(protocol.ToProtoUser(always), &protocol.User{Email: "always@example.com", Account: user.Account}) {
+		t.Error("unexpected proto user ", protocol.ToProtoUser(always))
+	}
+}
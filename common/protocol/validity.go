@@ -0,0 +1,139 @@
+package protocol
+
+import (
+	"sync"
+	"time"
+
+	"github.com/xtls/xray-core/common/errors"
+)
+
+// Validity is the period in which a user is accepted, and whether it is enabled. It is shared by the copies
+// of a MemoryUser, so that a user can be extended or disabled while in use.
+type Validity struct {
+	access    sync.RWMutex
+	expiresAt int64
+	notBefore int64
+	enabled   bool
+	removed   bool
+	onUpdate  func()
+	watchers  map[*validityWatcher]struct{}
+}
+
+type validityWatcher struct {
+	f func()
+}
+
+// NewValidity creates a Validity. expiresAt and notBefore are unix times in seconds, 0 for no limit.
+func NewValidity(expiresAt, notBefore int64, enabled bool) *Validity {
+	return &Validity{
+		expiresAt: expiresAt,
+		notBefore: notBefore,
+		enabled:   enabled,
+	}
+}
+
+// Get returns the current validity.
+func (v *Validity) Get() (expiresAt, notBefore int64, enabled bool) {
+	v.access.RLock()
+	defer v.access.RUnlock()
+	return v.expiresAt, v.notBefore, v.enabled
+}
+
+// ExpiresAt returns the time of expiry, or the zero time if the user never expires.
+func (v *Validity) ExpiresAt() time.Time {
+	expiresAt, _, _ := v.Get()
+	if expiresAt == 0 {
+		return time.Time{}
+	}
+	return time.Unix(expiresAt, 0)
+}
+
+// Update replaces the validity, and calls the function set by OnUpdate and then the watchers.
+func (v *Validity) Update(expiresAt, notBefore int64, enabled bool) {
+	v.access.Lock()
+	v.expiresAt, v.notBefore, v.enabled = expiresAt, notBefore, enabled
+	onUpdate := v.onUpdate
+	v.access.Unlock()
+	if onUpdate != nil {
+		onUpdate()
+	}
+	v.Notify()
+}
+
+// Remove marks the user as removed from its inbound, and calls the function set by OnUpdate and then the
+// watchers. The sessions of the user are still checked, but the validity is not followed after they end.
+func (v *Validity) Remove() {
+	v.access.Lock()
+	v.removed = true
+	onUpdate := v.onUpdate
+	v.access.Unlock()
+	if onUpdate != nil {
+		onUpdate()
+	}
+	v.Notify()
+}
+
+// Removed returns whether the user is removed from its inbound.
+func (v *Validity) Removed() bool {
+	v.access.RLock()
+	defer v.access.RUnlock()
+	return v.removed
+}
+
+// OnUpdate sets f to be called after each Update, replacing the previous one.
+func (v *Validity) OnUpdate(f func()) {
+	v.access.Lock()
+	defer v.access.Unlock()
+	v.onUpdate = f
+}
+
+// Watch calls f after each Update, Remove and Notify until the returned stop is called, as the sessions of the
+// user do to close themselves once it is no longer valid.
+func (v *Validity) Watch(f func()) (stop func()) {
+	w := &validityWatcher{f: f}
+	v.access.Lock()
+	if v.watchers == nil {
+		v.watchers = make(map[*validityWatcher]struct{})
+	}
+	v.watchers[w] = struct{}{}
+	v.access.Unlock()
+	return func() {
+		v.access.Lock()
+		delete(v.watchers, w)
+		v.access.Unlock()
+	}
+}
+
+// Watched returns whether any watcher is set.
+func (v *Validity) Watched() bool {
+	v.access.RLock()
+	defer v.access.RUnlock()
+	return len(v.watchers) > 0
+}
+
+// Notify calls the watchers, for the changes of the validity over time such as its expiry.
+func (v *Validity) Notify() {
+	v.access.RLock()
+	watchers := make([]*validityWatcher, 0, len(v.watchers))
+	for w := range v.watchers {
+		watchers = append(watchers, w)
+	}
+	v.access.RUnlock()
+	for _, w := range watchers {
+		w.f()
+	}
+}
+
+// Check returns an error if the user is not accepted at now.
+func (v *Validity) Check(now time.Time) error {
+	expiresAt, notBefore, enabled := v.Get()
+	switch {
+	case !enabled:
+		return errors.New("user is disabled")
+	case notBefore != 0 && now.Unix() < notBefore:
+		return errors.New("user is not valid before ", time.Unix(notBefore, 0).Format(time.RFC3339))
+	case expiresAt != 0 && now.Unix() >= expiresAt:
+		return errors.New("user expired at ", time.Unix(expiresAt, 0).Format(time.RFC3339))
+	}
+	return nil
+}
@@ -28,6 +28,7 @@ const (
 	mitmServerNameKey         ctx.SessionKey = 12 // used by TLS dialer
 
 	streamSettingsKey ctx.SessionKey = 13
+	bindKey           ctx.SessionKey = 14 // used by freedom to serve BIND requests
 )
 
 func ContextWithInbound(ctx context.Context, inbound *Inbound) context.Context {
@@ -202,3 +203,14 @@ func ContextWithStreamSettings(ctx context.Context, streamSettings any) context.
 func StreamSettingsFromContext(ctx context.Context) any {
 	return ctx.Value(streamSettingsKey)
 }
+
+func ContextWithBind(ctx context.Context, bind *Bind) context.Context {
+	return context.WithValue(ctx, bindKey, bind)
+}
+
+func BindFromContext(ctx context.Context) *Bind {
+	if bind, ok := ctx.Value(bindKey).(*Bind); ok {
+		return bind
+	}
+	return nil
+}
@@ -107,6 +107,17 @@ type Sockopt struct {
 	Mark int32
 }
 
+// Bind is a BIND request of an inbound, such as SOCKS 5. The outbound serving it accepts a connection
+// from the target instead of dialing it, and relays the link with that connection.
+type Bind struct {
+	// Address is the address to listen on if the outbound doesn't send through another one. Nil for any.
+	Address net.Address
+	// Bound is called with the address listened on, before accepting.
+	Bound func(net.Destination) error
+	// Accepted is called with the address of the accepted peer, before relaying.
+	Accepted func(net.Destination) error
+}
+
 // SetAttribute attaches additional string attributes to content.
 func (c *Content) SetAttribute(name string, value string) {
 	if c.Attributes == nil {
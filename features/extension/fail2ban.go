@@ -0,0 +1,19 @@
+package extension
+
+import (
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/features"
+)
+
+// Fail2ban bans the source IPs which keep failing the authentication of inbounds. Inbounds check it
+// when accepting connections, before any handshake.
+type Fail2ban interface {
+	features.Feature
+
+	// Banned returns whether the IP is banned from the inbound of the tag.
+	Banned(inboundTag string, ip net.Address) bool
+}
+
+func Fail2banType() interface{} {
+	return (*Fail2ban)(nil)
+}
@@ -29,6 +29,8 @@ type Stats struct {
 	UserDownlink bool
 	// Whether or not to enable online map for user.
 	UserOnline bool
+	// Whether or not to enable stat counters for the active Mux connections and sessions of user.
+	UserMux bool
 }
 
 // Buffer contains settings for internal buffer.
@@ -37,6 +39,18 @@ type Buffer struct {
 	PerConnection int32
 }
 
+// Mux contains limits for the Mux connections of users on the server side, 0 for no limit.
+type Mux struct {
+	// Max number of concurrent sessions in a Mux connection.
+	MaxSessions uint32
+	// Max number of concurrent Mux connections of a user.
+	MaxConnections uint32
+	// Timeout for a session in a Mux connection being idle.
+	SessionIdle time.Duration
+	// Time an XUDP session is kept for its client to reattach after its Mux connection closes, a minute if 0.
+	XUDPGrace time.Duration
+}
+
 // SystemStats contains stat policy settings on system level.
 type SystemStats struct {
 	// Whether or not to enable stat counter for uplink traffic in inbound handlers.
@@ -60,6 +74,7 @@ type Session struct {
 	Timeouts Timeout // Timeout settings
 	Stats    Stats
 	Buffer   Buffer
+	Mux      Mux
 }
 
 // Manager is a feature that provides Policy for the given user by its id or level.
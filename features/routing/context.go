@@ -41,6 +41,12 @@ type Context interface {
 	// GetUser returns the user email from the connection content, if exists.
 	GetUser() string
 
+	// GetUserGroup returns the group of the user from the connection content, if exists.
+	GetUserGroup() string
+
+	// GetUserOutboundTag returns the tag of the outbound or balancer the user is bound to, if exists.
+	GetUserOutboundTag() string
+
 	// GetVlessRoute returns the user-sent VLESS UUID's 7th<<8 | 8th bytes, if exists.
 	GetVlessRoute() net.Port
 
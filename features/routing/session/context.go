@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/protocol"
 	"github.com/xtls/xray-core/common/session"
 	"github.com/xtls/xray-core/features/routing"
 )
@@ -127,6 +128,30 @@ func (ctx *Context) GetUser() string {
 	return ctx.Inbound.User.Email
 }
 
+// GetUserGroup implements routing.Context.
+func (ctx *Context) GetUserGroup() string {
+	if account := ctx.routingAccount(); account != nil {
+		return account.GetGroup()
+	}
+	return ""
+}
+
+// GetUserOutboundTag implements routing.Context.
+func (ctx *Context) GetUserOutboundTag() string {
+	if account := ctx.routingAccount(); account != nil {
+		return account.GetOutboundTag()
+	}
+	return ""
+}
+
+func (ctx *Context) routingAccount() protocol.RoutingAccount {
+	if ctx.Inbound == nil || ctx.Inbound.User == nil {
+		return nil
+	}
+	account, _ := ctx.Inbound.User.Account.(protocol.RoutingAccount)
+	return account
+}
+
 // GetVlessRoute implements routing.Context.
 func (ctx *Context) GetVlessRoute() net.Port {
 	if ctx.Inbound == nil {
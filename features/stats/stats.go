@@ -2,9 +2,11 @@ package stats
 
 import (
 	"context"
+	"time"
 
 	"github.com/xtls/xray-core/common"
 	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/net"
 	"github.com/xtls/xray-core/features"
 )
 
@@ -52,6 +54,45 @@ type Channel interface {
 	Unsubscribe(chan interface{}) error
 }
 
+// UserExpiryChannel is the name of the channel on which a UserExpiry is published when a user expires.
+const UserExpiryChannel = "user>>>expiry"
+
+// UserExpiry is the event of a user reaching its expiry time.
+type UserExpiry struct {
+	Email     string
+	ExpiresAt time.Time
+}
+
+// AuthFailureChannel is the name of the channel on which an AuthFailure is published when a client fails authentication.
+const AuthFailureChannel = "auth>>>failure"
+
+// AuthFailure is the event of a client failing the authentication of an inbound.
+type AuthFailure struct {
+	InboundTag string
+	Protocol   string
+	Source     net.Address
+	Reason     string
+	Time       time.Time
+}
+
+// PublishAuthFailure publishes the failure on AuthFailureChannel if it is registered.
+// Failures may come in floods, so they are dropped instead of queued when the channel is congested.
+func PublishAuthFailure(m Manager, failure AuthFailure) {
+	if m == nil || failure.Source == nil {
+		return
+	}
+	channel := m.GetChannel(AuthFailureChannel)
+	if channel == nil {
+		return
+	}
+	if failure.Time.IsZero() {
+		failure.Time = time.Now()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	channel.Publish(ctx, failure)
+	cancel()
+}
+
 // SubscribeRunnableChannel subscribes the channel and starts it if there is first subscriber coming.
 func SubscribeRunnableChannel(c Channel) (chan interface{}, error) {
 	if len(c.Subscribers()) == 0 {
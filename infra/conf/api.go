@@ -4,6 +4,7 @@ import (
 	"strings"
 
 	"github.com/xtls/xray-core/app/commander"
+	fail2banservice "github.com/xtls/xray-core/app/fail2ban/command"
 	loggerservice "github.com/xtls/xray-core/app/log/command"
 	observatoryservice "github.com/xtls/xray-core/app/observatory/command"
 	handlerservice "github.com/xtls/xray-core/app/proxyman/command"
@@ -11,6 +12,7 @@ import (
 	statsservice "github.com/xtls/xray-core/app/stats/command"
 	"github.com/xtls/xray-core/common/errors"
 	"github.com/xtls/xray-core/common/serial"
+	wireguardservice "github.com/xtls/xray-core/proxy/wireguard/command"
 )
 
 type APIConfig struct {
@@ -39,6 +41,10 @@ func (c *APIConfig) Build() (*commander.Config, error) {
 			services = append(services, serial.ToTypedMessage(&observatoryservice.Config{}))
 		case "routingservice":
 			services = append(services, serial.ToTypedMessage(&routerservice.Config{}))
+		case "wireguardservice":
+			services = append(services, serial.ToTypedMessage(&wireguardservice.Config{}))
+		case "fail2banservice":
+			services = append(services, serial.ToTypedMessage(&fail2banservice.Config{}))
 		}
 	}
 
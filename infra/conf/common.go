@@ -6,6 +6,7 @@ import (
 	"math"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/xtls/xray-core/common/errors"
 	"github.com/xtls/xray-core/common/net"
@@ -78,6 +79,8 @@ func (v Network) Build() net.Network {
 		return net.Network_UDP
 	case "unix":
 		return net.Network_UNIX
+	case "icmp":
+		return net.Network_ICMP
 	default:
 		return net.Network_Unknown
 	}
@@ -286,6 +289,49 @@ func (v *User) Build() *protocol.User {
 	}
 }
 
+// UnixTime deserializes from a RFC 3339 time string or unix seconds.
+type UnixTime int64
+
+// UnmarshalJSON implements encoding/json.Unmarshaler.UnmarshalJSON
+func (v *UnixTime) UnmarshalJSON(data []byte) error {
+	var seconds int64
+	if err := json.Unmarshal(data, &seconds); err == nil {
+		*v = UnixTime(seconds)
+		return nil
+	}
+	var str string
+	if err := json.Unmarshal(data, &str); err != nil {
+		return errors.New("invalid time, expected either RFC 3339 time string or unix seconds")
+	}
+	t, err := time.Parse(time.RFC3339, str)
+	if err != nil {
+		return errors.New("invalid time: ", str).Base(err)
+	}
+	*v = UnixTime(t.Unix())
+	return nil
+}
+
+// UserValidity is the validity of a user of an inbound, accepted in the objects of users.
+type UserValidity struct {
+	ExpiresAt UnixTime `json:"expiresAt"`
+	NotBefore UnixTime `json:"notBefore"`
+	Enabled   *bool    `json:"enabled"`
+}
+
+// Apply sets the validity to user.
+func (v *UserValidity) Apply(user *protocol.User) error {
+	if v.ExpiresAt < 0 || v.NotBefore < 0 {
+		return errors.New("user ", user.Email, ": invalid expiresAt or notBefore")
+	}
+	if v.ExpiresAt != 0 && v.NotBefore != 0 && v.ExpiresAt <= v.NotBefore {
+		return errors.New("user ", user.Email, ": expiresAt must be after notBefore")
+	}
+	user.ExpiresAt = int64(v.ExpiresAt)
+	user.NotBefore = int64(v.NotBefore)
+	user.Enabled = v.Enabled
+	return nil
+}
+
 // Int32Range deserializes from "1-2" or 1, so can deserialize from both int and number.
 // Negative integers can be passed as sentinel values, but do not parse as ranges.
 // Value will be exchanged if From > To, use .Left and .Right to get original value if need.
@@ -14,6 +14,7 @@ import (
 	"github.com/xtls/xray-core/common/errors"
 	"github.com/xtls/xray-core/common/geodata"
 	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/transport/internet"
 )
 
 type NameServerConfig struct {
@@ -32,6 +33,7 @@ type NameServerConfig struct {
 	ServeExpiredTTL *uint32    `json:"serveExpiredTTL"`
 	FinalQuery      bool       `json:"finalQuery"`
 	UnexpectedIPs   StringList `json:"unexpectedIPs"`
+	UdpHop          *UdpHop    `json:"udpHop"`
 }
 
 // UnmarshalJSON implements encoding/json.Unmarshaler.UnmarshalJSON
@@ -58,6 +60,7 @@ func (c *NameServerConfig) UnmarshalJSON(data []byte) error {
 		ServeExpiredTTL *uint32    `json:"serveExpiredTTL"`
 		FinalQuery      bool       `json:"finalQuery"`
 		UnexpectedIPs   StringList `json:"unexpectedIPs"`
+		UdpHop          *UdpHop    `json:"udpHop"`
 	}
 	if err := json.Unmarshal(data, &advanced); err == nil {
 		c.Address = advanced.Address
@@ -75,6 +78,7 @@ func (c *NameServerConfig) UnmarshalJSON(data []byte) error {
 		c.ServeExpiredTTL = advanced.ServeExpiredTTL
 		c.FinalQuery = advanced.FinalQuery
 		c.UnexpectedIPs = advanced.UnexpectedIPs
+		c.UdpHop = advanced.UdpHop
 		return nil
 	}
 
@@ -133,6 +137,24 @@ func (c *NameServerConfig) Build() (*dns.NameServer, error) {
 		myClientIP = []byte(c.ClientIP.IP())
 	}
 
+	var udpHop *internet.UdpHop
+	if c.UdpHop != nil {
+		if !strings.HasPrefix(strings.ToLower(c.Address.String()), "quic+local://") {
+			return nil, errors.New("udpHop is only supported by quic+local:// name servers")
+		}
+		if c.UdpHop.PortList.Range == nil {
+			return nil, errors.New("udpHop requires ports")
+		}
+		if (c.UdpHop.Interval.From != 0 && c.UdpHop.Interval.From < 5) || (c.UdpHop.Interval.To != 0 && c.UdpHop.Interval.To < 5) {
+			return nil, errors.New("Interval must be at least 5")
+		}
+		udpHop = &internet.UdpHop{
+			Ports:       c.UdpHop.PortList.Build().Ports(),
+			IntervalMin: int64(c.UdpHop.Interval.From),
+			IntervalMax: int64(c.UdpHop.Interval.To),
+		}
+	}
+
 	return &dns.NameServer{
 		Address: &net.Endpoint{
 			Network: net.Network_UDP,
@@ -153,6 +175,7 @@ func (c *NameServerConfig) Build() (*dns.NameServer, error) {
 		FinalQuery:      c.FinalQuery,
 		UnexpectedIp:    unexpectedIPRules,
 		ActUnprior:      actUnprior,
+		UdpHop:          udpHop,
 	}, nil
 }
 
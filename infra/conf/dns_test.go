@@ -9,6 +9,7 @@ import (
 	"github.com/xtls/xray-core/common/geodata"
 	"github.com/xtls/xray-core/common/net"
 	. "github.com/xtls/xray-core/infra/conf"
+	"github.com/xtls/xray-core/transport/internet"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/testing/protocmp"
 )
@@ -104,6 +105,38 @@ func TestDNSConfigParsing(t *testing.T) {
 				DisableFallback: true,
 			},
 		},
+		{
+			Input: `{
+				"servers": [{
+					"address": "quic+local://dns.example.com",
+					"udpHop": {
+						"ports": "853,20000-20002",
+						"interval": "10-30"
+					}
+				}]
+			}`,
+			Parser: parserCreator(),
+			Output: &dns.Config{
+				NameServer: []*dns.NameServer{
+					{
+						Address: &net.Endpoint{
+							Address: &net.IPOrDomain{
+								Address: &net.IPOrDomain_Domain{
+									Domain: "quic+local://dns.example.com",
+								},
+							},
+							Network: net.Network_UDP,
+						},
+						UdpHop: &internet.UdpHop{
+							Ports:       []uint32{853, 20000, 20001, 20002},
+							IntervalMin: 10,
+							IntervalMax: 30,
+						},
+						PolicyID: 1,
+					},
+				},
+			},
+		},
 	}
 
 	for _, testCase := range testCases {
@@ -122,3 +155,19 @@ func TestDNSConfigParsing(t *testing.T) {
 		}
 	}
 }
+
+func TestNameServerConfigUdpHop(t *testing.T) {
+	for _, input := range []string{
+		`{"address": "https://dns.example.com/dns-query", "udpHop": {"ports": "20000-20002"}}`,
+		`{"address": "quic+local://dns.example.com", "udpHop": {"interval": 10}}`,
+		`{"address": "quic+local://dns.example.com", "udpHop": {"ports": "20000-20002", "interval": 3}}`,
+	} {
+		config := new(NameServerConfig)
+		if err := json.Unmarshal([]byte(input), config); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := config.Build(); err == nil {
+			t.Error("built an invalid udpHop: ", input)
+		}
+	}
+}
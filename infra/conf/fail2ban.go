@@ -0,0 +1,80 @@
+package conf
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/xtls/xray-core/app/fail2ban"
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/geodata"
+	"google.golang.org/protobuf/proto"
+)
+
+// fail2banProtocols are the protocols which publish authentication failures.
+var fail2banProtocols = []string{"vless", "vmess", "trojan", "reality", "socks", "http", "masque"}
+
+type Fail2banJailConfig struct {
+	Name       string      `json:"name"`
+	InboundTag *StringList `json:"inboundTag"`
+	Protocol   *StringList `json:"protocol"`
+	MaxRetry   uint32      `json:"maxRetry"`
+	FindTime   uint32      `json:"findTime"`
+	BanTime    uint32      `json:"banTime"`
+}
+
+// Build implements Buildable.
+func (c *Fail2banJailConfig) Build() (*fail2ban.Jail, error) {
+	if c.Name == "" {
+		return nil, errors.New(`fail2ban jail "name" is not specified`)
+	}
+	jail := &fail2ban.Jail{
+		Name:     c.Name,
+		MaxRetry: c.MaxRetry,
+		FindTime: c.FindTime,
+		BanTime:  c.BanTime,
+	}
+	if c.InboundTag != nil {
+		jail.InboundTag = *c.InboundTag
+	}
+	if c.Protocol != nil {
+		for _, p := range *c.Protocol {
+			p = strings.ToLower(p)
+			if !slices.Contains(fail2banProtocols, p) {
+				return nil, errors.New("fail2ban jail ", c.Name, ": unknown protocol ", p)
+			}
+			jail.Protocol = append(jail.Protocol, p)
+		}
+	}
+	return jail, nil
+}
+
+type Fail2banConfig struct {
+	OutboundTag string                `json:"outboundTag"`
+	IgnoreIP    *StringList           `json:"ignoreIP"`
+	Jails       []*Fail2banJailConfig `json:"jails"`
+}
+
+// Build implements Buildable.
+func (c *Fail2banConfig) Build() (proto.Message, error) {
+	if c.OutboundTag == "" {
+		return nil, errors.New(`fail2ban "outboundTag" is not specified`)
+	}
+	config := &fail2ban.Config{
+		OutboundTag: c.OutboundTag,
+	}
+	if c.IgnoreIP != nil {
+		rules, err := geodata.ParseIPRules(*c.IgnoreIP)
+		if err != nil {
+			return nil, errors.New("invalid fail2ban ignoreIP").Base(err)
+		}
+		config.IgnoreIp = rules
+	}
+	for _, jc := range c.Jails {
+		jail, err := jc.Build()
+		if err != nil {
+			return nil, err
+		}
+		config.Jail = append(config.Jail, jail)
+	}
+	return config, nil
+}
@@ -0,0 +1,70 @@
+package conf_test
+
+import (
+	"testing"
+
+	"github.com/xtls/xray-core/app/fail2ban"
+	"github.com/xtls/xray-core/common/geodata"
+	"github.com/xtls/xray-core/common/net"
+	. "github.com/xtls/xray-core/infra/conf"
+)
+
+func TestFail2banConfig(t *testing.T) {
+	creator := func() Buildable {
+		return new(Fail2banConfig)
+	}
+
+	runMultiTestCase(t, []TestCase{
+		{
+			Input: `{
+				"outboundTag": "blocked",
+				"ignoreIP": ["10.0.0.0/8"],
+				"jails": [
+					{
+						"name": "proxy",
+						"inboundTag": ["vless-in", "trojan-in"],
+						"protocol": ["VLESS", "trojan", "reality"],
+						"maxRetry": 3,
+						"findTime": 60,
+						"banTime": 86400
+					},
+					{
+						"name": "socks",
+						"protocol": "socks"
+					}
+				]
+			}`,
+			Parser: loadJSON(creator),
+			Output: &fail2ban.Config{
+				OutboundTag: "blocked",
+				IgnoreIp: []*geodata.IPRule{{Value: &geodata.IPRule_Custom{Custom: &geodata.CIDRRule{
+					Cidr: &geodata.CIDR{Ip: net.ParseAddress("10.0.0.0").IP(), Prefix: 8},
+				}}}},
+				Jail: []*fail2ban.Jail{
+					{
+						Name:       "proxy",
+						InboundTag: []string{"vless-in", "trojan-in"},
+						Protocol:   []string{"vless", "trojan", "reality"},
+						MaxRetry:   3,
+						FindTime:   60,
+						BanTime:    86400,
+					},
+					{
+						Name:     "socks",
+						Protocol: []string{"socks"},
+					},
+				},
+			},
+		},
+	})
+
+	for _, input := range []string{
+		`{"jails": [{"name": "proxy"}]}`,
+		`{"outboundTag": "blocked", "jails": [{"protocol": "vless"}]}`,
+		`{"outboundTag": "blocked", "jails": [{"name": "proxy", "protocol": "ssh"}]}`,
+	} {
+		if _, err := loadJSON(creator)(input); err == nil {
+			t.Error("expected error: ", input)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package conf
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/net"
+)
+
+// FallbackDestConfig is one of the dests of a VLESS or Trojan fallback, chosen by weight.
+type FallbackDestConfig struct {
+	Type   string          `json:"type"`
+	Dest   json.RawMessage `json:"dest"`
+	Weight uint32          `json:"weight"`
+}
+
+// parseFallbackDest returns the dest of a fallback, which is a port or an address in JSON.
+func parseFallbackDest(dest json.RawMessage) string {
+	var i uint16
+	var s string
+	if err := json.Unmarshal(dest, &i); err == nil {
+		s = strconv.Itoa(int(i))
+	} else {
+		_ = json.Unmarshal(dest, &s)
+	}
+	return s
+}
+
+// fallbackDestType infers the type of the dest of a fallback if it is empty, and returns it with the dest to dial.
+// The type stays empty if the dest is not valid.
+func fallbackDestType(typ string, dest string) (string, string) {
+	if typ != "" || dest == "" {
+		return typ, dest
+	}
+	if dest == "serve-ws-none" {
+		typ = "serve"
+	} else if filepath.IsAbs(dest) || dest[0] == '@' {
+		typ = "unix"
+		if strings.HasPrefix(dest, "@@") && (runtime.GOOS == "linux" || runtime.GOOS == "android") {
+			fullAddr := make([]byte, len(syscall.RawSockaddrUnix{}.Path)) // may need padding to work with haproxy
+			copy(fullAddr, dest[1:])
+			dest = string(fullAddr)
+		}
+	} else {
+		if _, err := strconv.Atoi(dest); err == nil {
+			dest = "localhost:" + dest
+		}
+		if _, _, err := net.SplitHostPort(dest); err == nil {
+			typ = "tcp"
+		}
+	}
+	return typ, dest
+}
+
+// checkFallbackRule checks the host and the regexps of the headers of a fallback. They are matched with the
+// HTTP/1 request only, so a fallback of "alpn" "h2" can't have them.
+func checkFallbackRule(alpn string, host string, headers map[string]string) error {
+	if strings.EqualFold(alpn, "h2") && (host != "" || len(headers) > 0) {
+		return errors.New(`"alpn":"h2" doesn't support "host" and "headers", which are matched with HTTP/1 requests only`)
+	}
+	for k, v := range headers {
+		if _, err := regexp.Compile(v); err != nil {
+			return errors.New(`invalid regexp of "headers" `, k).Base(err)
+		}
+	}
+	return nil
+}
@@ -14,6 +14,13 @@ type GRPCConfig struct {
 	PermitWithoutStream bool   `json:"permit_without_stream"`
 	InitialWindowsSize  int32  `json:"initial_windows_size"`
 	UserAgent           string `json:"user_agent"`
+
+	MaxConcurrentStreams         uint32 `json:"max_concurrent_streams"`
+	MaxConnectionIdle            int32  `json:"max_connection_idle"`
+	MaxConnectionAge             int32  `json:"max_connection_age"`
+	MaxConnectionAgeGrace        int32  `json:"max_connection_age_grace"`
+	KeepaliveMinTime             int32  `json:"keepalive_min_time"`
+	KeepalivePermitWithoutStream bool   `json:"keepalive_permit_without_stream"`
 }
 
 func (g *GRPCConfig) Build() (proto.Message, error) {
@@ -27,6 +34,18 @@ func (g *GRPCConfig) Build() (proto.Message, error) {
 		// default window size of gRPC-go
 		g.InitialWindowsSize = 0
 	}
+	if g.MaxConnectionIdle < 0 {
+		g.MaxConnectionIdle = 0
+	}
+	if g.MaxConnectionAge < 0 {
+		g.MaxConnectionAge = 0
+	}
+	if g.MaxConnectionAgeGrace < 0 {
+		g.MaxConnectionAgeGrace = 0
+	}
+	if g.KeepaliveMinTime < 0 {
+		g.KeepaliveMinTime = 0
+	}
 
 	return &grpc.Config{
 		Authority:           g.Authority,
@@ -37,5 +56,12 @@ func (g *GRPCConfig) Build() (proto.Message, error) {
 		PermitWithoutStream: g.PermitWithoutStream,
 		InitialWindowsSize:  g.InitialWindowsSize,
 		UserAgent:           g.UserAgent,
+
+		MaxConcurrentStreams:         g.MaxConcurrentStreams,
+		MaxConnectionIdle:            g.MaxConnectionIdle,
+		MaxConnectionAge:             g.MaxConnectionAge,
+		MaxConnectionAgeGrace:        g.MaxConnectionAgeGrace,
+		KeepaliveMinTime:             g.KeepaliveMinTime,
+		KeepalivePermitWithoutStream: g.KeepalivePermitWithoutStream,
 	}, nil
 }
@@ -23,16 +23,18 @@ func (v *HTTPAccount) Build() *http.Account {
 }
 
 type HTTPServerConfig struct {
-	Users       []*HTTPAccount `json:"users"`
-	Accounts    []*HTTPAccount `json:"accounts"`
-	Transparent bool           `json:"allowTransparent"`
-	UserLevel   uint32         `json:"userLevel"`
+	Users           []*HTTPAccount `json:"users"`
+	Accounts        []*HTTPAccount `json:"accounts"`
+	Transparent     bool           `json:"allowTransparent"`
+	UserLevel       uint32         `json:"userLevel"`
+	ExtendedConnect bool           `json:"extendedConnect"`
 }
 
 func (c *HTTPServerConfig) Build() (proto.Message, error) {
 	config := &http.ServerConfig{
 		AllowTransparent: c.Transparent,
 		UserLevel:        c.UserLevel,
+		ExtendedConnect:  c.ExtendedConnect,
 	}
 
 	if c.Accounts != nil {
@@ -22,7 +22,8 @@ func TestHTTPServerConfig(t *testing.T) {
 					}
 				],
 				"allowTransparent": true,
-				"userLevel": 1
+				"userLevel": 1,
+				"extendedConnect": true
 			}`,
 			Parser: loadJSON(creator),
 			Output: &http.ServerConfig{
@@ -31,6 +32,7 @@ func TestHTTPServerConfig(t *testing.T) {
 				},
 				AllowTransparent: true,
 				UserLevel:        1,
+				ExtendedConnect:  true,
 			},
 		},
 	})
@@ -34,6 +34,7 @@ type HysteriaUserConfig struct {
 	Auth  string `json:"auth"`
 	Level uint32 `json:"level"`
 	Email string `json:"email"`
+	UserValidity
 }
 
 type HysteriaServerConfig struct {
@@ -64,7 +65,7 @@ func (c *HysteriaServerConfig) Build() (proto.Message, error) {
 				Level:   user.Level,
 				Account: serial.ToTypedMessage(acc),
 			}
-			return nil
+			return user.Apply(config.Users[idx])
 		}
 		if err := task.ParallelForN(len(c.Users), processUser); err != nil {
 			return nil, err
@@ -0,0 +1,86 @@
+package conf
+
+import (
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/protocol"
+	"github.com/xtls/xray-core/common/serial"
+	"github.com/xtls/xray-core/proxy/masque"
+	"google.golang.org/protobuf/proto"
+)
+
+type MasqueClientConfig struct {
+	Address     *Address `json:"address"`
+	Port        uint16   `json:"port"`
+	Level       uint32   `json:"level"`
+	Email       string   `json:"email"`
+	Username    string   `json:"user"`
+	Password    string   `json:"pass"`
+	UdpTemplate string   `json:"udpTemplate"`
+}
+
+func (c *MasqueClientConfig) Build() (proto.Message, error) {
+	if c.Address == nil {
+		return nil, errors.New("MASQUE server address is not set")
+	}
+	if c.Port == 0 {
+		return nil, errors.New("Invalid MASQUE port")
+	}
+
+	config := &masque.ClientConfig{
+		Server: &protocol.ServerEndpoint{
+			Address: c.Address.Build(),
+			Port:    uint32(c.Port),
+			User: &protocol.User{
+				Level: c.Level,
+				Email: c.Email,
+				Account: serial.ToTypedMessage(&masque.Account{
+					Username: c.Username,
+					Password: c.Password,
+				}),
+			},
+		},
+		UdpTemplate: c.UdpTemplate,
+	}
+
+	return config, nil
+}
+
+type MasqueUserConfig struct {
+	Username string `json:"user"`
+	Password string `json:"pass"`
+	Level    uint32 `json:"level"`
+	Email    string `json:"email"`
+	UserValidity
+}
+
+type MasqueServerConfig struct {
+	Users   []*MasqueUserConfig `json:"users"`
+	Clients []*MasqueUserConfig `json:"clients"`
+}
+
+func (c *MasqueServerConfig) Build() (proto.Message, error) {
+	config := new(masque.ServerConfig)
+
+	if c.Clients != nil {
+		c.Users = c.Clients
+	}
+	for _, user := range c.Users {
+		if user.Username == "" {
+			return nil, errors.New("MASQUE user is not set")
+		}
+		u := &protocol.User{
+			Email: user.Email,
+			Level: user.Level,
+			Account: serial.ToTypedMessage(&masque.Account{
+				Username: user.Username,
+				Password: user.Password,
+			}),
+		}
+		if err := user.Apply(u); err != nil {
+			return nil, err
+		}
+		config.Users = append(config.Users, u)
+	}
+
+	return config, nil
+}
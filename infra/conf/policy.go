@@ -12,7 +12,12 @@ type Policy struct {
 	StatsUserUplink   bool    `json:"statsUserUplink"`
 	StatsUserDownlink bool    `json:"statsUserDownlink"`
 	StatsUserOnline   bool    `json:"statsUserOnline"`
+	StatsUserMux      bool    `json:"statsUserMux"`
 	BufferSize        *int32  `json:"bufferSize"`
+	MuxMaxSessions    uint32  `json:"muxMaxSessions"`
+	MuxMaxConnections uint32  `json:"muxMaxConnections"`
+	MuxSessionIdle    *uint32 `json:"muxSessionIdle"`
+	MuxXUDPGrace      uint32  `json:"muxXudpGrace"`
 }
 
 func (t *Policy) Build() (*policy.Policy, error) {
@@ -36,9 +41,23 @@ func (t *Policy) Build() (*policy.Policy, error) {
 			UserUplink:   t.StatsUserUplink,
 			UserDownlink: t.StatsUserDownlink,
 			UserOnline:   t.StatsUserOnline,
+			UserMux:      t.StatsUserMux,
 		},
 	}
 
+	if t.MuxMaxSessions != 0 || t.MuxMaxConnections != 0 || t.MuxSessionIdle != nil || t.MuxXUDPGrace != 0 {
+		p.Mux = &policy.Policy_Mux{
+			MaxSessions:    t.MuxMaxSessions,
+			MaxConnections: t.MuxMaxConnections,
+		}
+		if t.MuxSessionIdle != nil {
+			p.Mux.SessionIdle = &policy.Second{Value: *t.MuxSessionIdle}
+		}
+		if t.MuxXUDPGrace != 0 {
+			p.Mux.XudpGrace = &policy.Second{Value: t.MuxXUDPGrace}
+		}
+	}
+
 	if t.BufferSize != nil {
 		bs := int32(-1)
 		if *t.BufferSize >= 0 {
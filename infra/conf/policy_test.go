@@ -38,3 +38,25 @@ func TestBufferSize(t *testing.T) {
 		}
 	}
 }
+
+func TestMuxPolicy(t *testing.T) {
+	idle := uint32(30)
+	pConf := Policy{
+		StatsUserMux:      true,
+		MuxMaxSessions:    8,
+		MuxMaxConnections: 2,
+		MuxSessionIdle:    &idle,
+		MuxXUDPGrace:      300,
+	}
+	p, err := pConf.Build()
+	common.Must(err)
+	if !p.Stats.UserMux || p.Mux.MaxSessions != 8 || p.Mux.MaxConnections != 2 || p.Mux.SessionIdle.Value != 30 || p.Mux.XudpGrace.Value != 300 {
+		t.Error("unexpected mux policy ", p.Mux)
+	}
+
+	p, err = (&Policy{}).Build()
+	common.Must(err)
+	if p.Mux != nil {
+		t.Error("expected no mux policy, but got ", p.Mux)
+	}
+}
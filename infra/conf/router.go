@@ -2,6 +2,8 @@ package conf
 
 import (
 	"encoding/json"
+	"os/user"
+	"strconv"
 	"strings"
 
 	"github.com/xtls/xray-core/app/router"
@@ -141,6 +143,7 @@ func parseFieldRule(msg json.RawMessage) (*router.RoutingRule, error) {
 		Source     *StringList        `json:"source"`
 		SourcePort *PortList          `json:"sourcePort"`
 		User       *StringList        `json:"user"`
+		UserGroup  *StringList        `json:"userGroup"`
 		VlessRoute *PortList          `json:"vlessRoute"`
 		InboundTag *StringList        `json:"inboundTag"`
 		Protocols  *StringList        `json:"protocol"`
@@ -148,6 +151,9 @@ func parseFieldRule(msg json.RawMessage) (*router.RoutingRule, error) {
 		LocalIP    *StringList        `json:"localIP"`
 		LocalPort  *PortList          `json:"localPort"`
 		Process    *StringList        `json:"process"`
+		UID        *OwnerIDList       `json:"uid"`
+		GID        *OwnerIDList       `json:"gid"`
+		Cgroup     *StringList        `json:"cgroup"`
 		Webhook    *WebhookRuleConfig `json:"webhook"`
 	}
 	rawFieldRule := new(RawFieldRule)
@@ -237,6 +243,10 @@ func parseFieldRule(msg json.RawMessage) (*router.RoutingRule, error) {
 		}
 	}
 
+	if rawFieldRule.UserGroup != nil {
+		rule.UserGroup = *rawFieldRule.UserGroup
+	}
+
 	if rawFieldRule.VlessRoute != nil {
 		rule.VlessRouteList = rawFieldRule.VlessRoute.Build()
 	}
@@ -261,6 +271,24 @@ func parseFieldRule(msg json.RawMessage) (*router.RoutingRule, error) {
 		rule.Process = *rawFieldRule.Process
 	}
 
+	if rawFieldRule.UID != nil {
+		rule.Uid, err = rawFieldRule.UID.Build(lookupUserID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if rawFieldRule.GID != nil {
+		rule.Gid, err = rawFieldRule.GID.Build(lookupGroupID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if rawFieldRule.Cgroup != nil && len(*rawFieldRule.Cgroup) > 0 {
+		rule.Cgroup = *rawFieldRule.Cgroup
+	}
+
 	if rawFieldRule.Webhook != nil && rawFieldRule.Webhook.URL != "" {
 		rule.Webhook = &router.WebhookConfig{
 			Url:           rawFieldRule.Webhook.URL,
@@ -272,6 +300,63 @@ func parseFieldRule(msg json.RawMessage) (*router.RoutingRule, error) {
 	return rule, nil
 }
 
+// OwnerIDList is a list of user or group ids, given as numbers or names
+type OwnerIDList []string
+
+func (v *OwnerIDList) UnmarshalJSON(data []byte) error {
+	var items []json.RawMessage
+	if err := json.Unmarshal(data, &items); err != nil {
+		items = []json.RawMessage{data}
+	}
+	for _, item := range items {
+		var id uint32
+		if err := json.Unmarshal(item, &id); err == nil {
+			*v = append(*v, strconv.FormatUint(uint64(id), 10))
+			continue
+		}
+		var name string
+		if err := json.Unmarshal(item, &name); err != nil || name == "" {
+			return errors.New("invalid user or group: ", string(item))
+		}
+		*v = append(*v, name)
+	}
+	return nil
+}
+
+// Build resolves the names of the list with lookup
+func (v *OwnerIDList) Build(lookup func(name string) (string, error)) ([]uint32, error) {
+	ids := make([]uint32, 0, len(*v))
+	for _, s := range *v {
+		if _, err := strconv.ParseUint(s, 10, 32); err != nil {
+			if s, err = lookup(s); err != nil {
+				return nil, err
+			}
+		}
+		id, err := strconv.ParseUint(s, 10, 32)
+		if err != nil {
+			return nil, errors.New("invalid id ", s).Base(err)
+		}
+		ids = append(ids, uint32(id))
+	}
+	return ids, nil
+}
+
+func lookupUserID(name string) (string, error) {
+	u, err := user.Lookup(name)
+	if err != nil {
+		return "", errors.New("unknown user ", name).Base(err)
+	}
+	return u.Uid, nil
+}
+
+func lookupGroupID(name string) (string, error) {
+	g, err := user.LookupGroup(name)
+	if err != nil {
+		return "", errors.New("unknown group ", name).Base(err)
+	}
+	return g.Gid, nil
+}
+
 func parseRule(msg json.RawMessage) (*router.RoutingRule, error) {
 	rawRule := new(RouterRule)
 	err := json.Unmarshal(msg, rawRule)
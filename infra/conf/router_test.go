@@ -7,6 +7,7 @@ import (
 	_ "unsafe"
 
 	"github.com/xtls/xray-core/app/router"
+	"github.com/xtls/xray-core/common"
 	"github.com/xtls/xray-core/common/geodata"
 	"github.com/xtls/xray-core/common/net"
 	"github.com/xtls/xray-core/common/serial"
@@ -236,3 +237,96 @@ func TestRouterConfig(t *testing.T) {
 		},
 	})
 }
+
+func TestRouterConfigSocketOwner(t *testing.T) {
+	createParser := func() func(string) (proto.Message, error) {
+		return func(s string) (proto.Message, error) {
+			config := new(RouterConfig)
+			if err := json.Unmarshal([]byte(s), config); err != nil {
+				return nil, err
+			}
+			return config.Build()
+		}
+	}
+
+	runMultiTestCase(t, []TestCase{
+		{
+			Input: `{
+				"rules": [
+					{
+						"uid": [1000, "root"],
+						"gid": 100,
+						"cgroup": ["/system.slice/docker.service", "/user.slice"],
+						"outboundTag": "direct"
+					}
+				]
+			}`,
+			Parser: createParser(),
+			Output: &router.Config{
+				DomainStrategy: router.Config_AsIs,
+				Rule: []*router.RoutingRule{
+					{
+						Uid:    []uint32{1000, 0},
+						Gid:    []uint32{100},
+						Cgroup: []string{"/system.slice/docker.service", "/user.slice"},
+						TargetTag: &router.RoutingRule_Tag{
+							Tag: "direct",
+						},
+					},
+				},
+			},
+		},
+	})
+
+	config := new(RouterConfig)
+	common.Must(json.Unmarshal([]byte(`{"rules": [{"uid": "no-such-user-xray", "outboundTag": "direct"}]}`), config))
+	if _, err := config.Build(); err == nil {
+		t.Error("expected error for unknown user")
+	}
+}
+
+func TestRouterConfigUserGroup(t *testing.T) {
+	runMultiTestCase(t, []TestCase{
+		{
+			Input: `{
+				"rules": [
+					{
+						"userGroup": ["vip", "staff"],
+						"balancerTag": "b1"
+					}
+				],
+				"balancers": [
+					{
+						"tag": "b1",
+						"selector": ["exit-"]
+					}
+				]
+			}`,
+			Parser: func(s string) (proto.Message, error) {
+				config := new(RouterConfig)
+				if err := json.Unmarshal([]byte(s), config); err != nil {
+					return nil, err
+				}
+				return config.Build()
+			},
+			Output: &router.Config{
+				DomainStrategy: router.Config_AsIs,
+				Rule: []*router.RoutingRule{
+					{
+						UserGroup: []string{"vip", "staff"},
+						TargetTag: &router.RoutingRule_BalancingTag{
+							BalancingTag: "b1",
+						},
+					},
+				},
+				BalancingRule: []*router.BalancingRule{
+					{
+						Tag:              "b1",
+						OutboundSelector: []string{"exit-"},
+						Strategy:         "random",
+					},
+				},
+			},
+		},
+	})
+}
@@ -15,29 +15,18 @@ import (
 )
 
 func cipherFromString(c string) shadowsocks.CipherType {
-	switch strings.ToLower(c) {
-	case "aes-128-gcm", "aead_aes_128_gcm":
-		return shadowsocks.CipherType_AES_128_GCM
-	case "aes-256-gcm", "aead_aes_256_gcm":
-		return shadowsocks.CipherType_AES_256_GCM
-	case "chacha20-poly1305", "aead_chacha20_poly1305", "chacha20-ietf-poly1305":
-		return shadowsocks.CipherType_CHACHA20_POLY1305
-	case "xchacha20-poly1305", "aead_xchacha20_poly1305", "xchacha20-ietf-poly1305":
-		return shadowsocks.CipherType_XCHACHA20_POLY1305
-	case "none", "plain":
-		return shadowsocks.CipherType_NONE
-	default:
-		return shadowsocks.CipherType_UNKNOWN
-	}
+	return shadowsocks.ParseCipherType(c)
 }
 
 type ShadowsocksUserConfig struct {
-	Cipher   string   `json:"method"`
-	Password string   `json:"password"`
-	Level    byte     `json:"level"`
-	Email    string   `json:"email"`
-	Address  *Address `json:"address"`
-	Port     uint16   `json:"port"`
+	Cipher      string   `json:"method"`
+	Password    string   `json:"password"`
+	Level       byte     `json:"level"`
+	Email       string   `json:"email"`
+	Address     *Address `json:"address"`
+	Port        uint16   `json:"port"`
+	OutboundTag string   `json:"outboundTag"`
+	UserValidity
 }
 
 type ShadowsocksServerConfig struct {
@@ -48,6 +37,7 @@ type ShadowsocksServerConfig struct {
 	Users       []*ShadowsocksUserConfig `json:"users"`
 	Clients     []*ShadowsocksUserConfig `json:"clients"`
 	NetworkList *NetworkList             `json:"network"`
+	UserSource  *UserSourceConfig        `json:"userSource"`
 }
 
 func (v *ShadowsocksServerConfig) Build() (proto.Message, error) {
@@ -58,12 +48,30 @@ func (v *ShadowsocksServerConfig) Build() (proto.Message, error) {
 	}
 
 	if C.Contains(shadowaead_2022.List, v.Cipher) {
+		if v.UserSource != nil {
+			return nil, errors.New(`Shadowsocks 2022 doesn't support "userSource"`)
+		}
 		return buildShadowsocks2022(v)
 	}
 
 	config := new(shadowsocks.ServerConfig)
 	config.Network = v.NetworkList.Build()
 
+	if v.UserSource != nil {
+		if v.UserSource.Socket != "" {
+			return nil, errors.New(`Shadowsocks "userSource" doesn't support "socket"`)
+		}
+		source, err := v.UserSource.Build()
+		if err != nil {
+			return nil, err
+		}
+		config.UserSource = source
+		if v.Users == nil && v.Password == "" {
+			// all users are from the source
+			v.Users = []*ShadowsocksUserConfig{}
+		}
+	}
+
 	if v.Users != nil {
 		if len(v.Users) > 0 {
 			config.Users = make([]*protocol.User, len(v.Users))
@@ -85,7 +93,7 @@ func (v *ShadowsocksServerConfig) Build() (proto.Message, error) {
 					Level:   uint32(user.Level),
 					Account: serial.ToTypedMessage(account),
 				}
-				return nil
+				return user.Apply(config.Users[idx])
 			}
 			if err := task.ParallelForN(len(v.Users), processUser); err != nil {
 				return nil, err
@@ -141,6 +149,9 @@ func buildShadowsocks2022(v *ShadowsocksServerConfig) (proto.Message, error) {
 			if user.Cipher != "" {
 				return errors.New("shadowsocks 2022 (multi-user): users must have empty method")
 			}
+			if user.OutboundTag != "" {
+				return errors.New("shadowsocks 2022 (multi-user): outboundTag is only supported by relay users")
+			}
 			account := &shadowsocks_2022.Account{
 				Key: user.Password,
 			}
@@ -149,7 +160,7 @@ func buildShadowsocks2022(v *ShadowsocksServerConfig) (proto.Message, error) {
 				Level:   uint32(user.Level),
 				Account: serial.ToTypedMessage(account),
 			}
-			return nil
+			return user.Apply(config.Users[idx])
 		}
 		if err := task.ParallelForN(len(v.Users), processUser); err != nil {
 			return nil, err
@@ -169,32 +180,37 @@ func buildShadowsocks2022(v *ShadowsocksServerConfig) (proto.Message, error) {
 			return nil, errors.New("shadowsocks 2022 (relay): all users must have relay address")
 		}
 		config.Destinations = append(config.Destinations, &shadowsocks_2022.RelayDestination{
-			Key:     user.Password,
-			Email:   user.Email,
-			Address: user.Address.Build(),
-			Port:    uint32(user.Port),
+			Key:         user.Password,
+			Email:       user.Email,
+			Address:     user.Address.Build(),
+			Port:        uint32(user.Port),
+			OutboundTag: user.OutboundTag,
 		})
 	}
 	return config, nil
 }
 
 type ShadowsocksServerTarget struct {
-	Address  *Address `json:"address"`
-	Port     uint16   `json:"port"`
-	Level    byte     `json:"level"`
-	Email    string   `json:"email"`
-	Cipher   string   `json:"method"`
-	Password string   `json:"password"`
+	Address    *Address `json:"address"`
+	Port       uint16   `json:"port"`
+	Level      byte     `json:"level"`
+	Email      string   `json:"email"`
+	Cipher     string   `json:"method"`
+	Password   string   `json:"password"`
+	UoT        bool     `json:"uot"`
+	UoTVersion uint32   `json:"uotVersion"`
 }
 
 type ShadowsocksClientConfig struct {
-	Address  *Address                   `json:"address"`
-	Port     uint16                     `json:"port"`
-	Level    byte                       `json:"level"`
-	Email    string                     `json:"email"`
-	Cipher   string                     `json:"method"`
-	Password string                     `json:"password"`
-	Servers  []*ShadowsocksServerTarget `json:"servers"`
+	Address    *Address                   `json:"address"`
+	Port       uint16                     `json:"port"`
+	Level      byte                       `json:"level"`
+	Email      string                     `json:"email"`
+	Cipher     string                     `json:"method"`
+	Password   string                     `json:"password"`
+	UoT        bool                       `json:"uot"`
+	UoTVersion uint32                     `json:"uotVersion"`
+	Servers    []*ShadowsocksServerTarget `json:"servers"`
 }
 
 func (v *ShadowsocksClientConfig) Build() (proto.Message, error) {
@@ -203,12 +219,14 @@ func (v *ShadowsocksClientConfig) Build() (proto.Message, error) {
 	if v.Address != nil {
 		v.Servers = []*ShadowsocksServerTarget{
 			{
-				Address:  v.Address,
-				Port:     v.Port,
-				Level:    v.Level,
-				Email:    v.Email,
-				Cipher:   v.Cipher,
-				Password: v.Password,
+				Address:    v.Address,
+				Port:       v.Port,
+				Level:      v.Level,
+				Email:      v.Email,
+				Cipher:     v.Cipher,
+				Password:   v.Password,
+				UoT:        v.UoT,
+				UoTVersion: v.UoTVersion,
 			},
 		}
 	}
@@ -234,6 +252,14 @@ func (v *ShadowsocksClientConfig) Build() (proto.Message, error) {
 			config.Port = uint32(server.Port)
 			config.Method = server.Cipher
 			config.Key = server.Password
+			if server.UoT {
+				// version 1 is the legacy protocol without a request, which the inbounds don't accept
+				if server.UoTVersion != 0 && server.UoTVersion != 2 {
+					return nil, errors.New("unsupported UDP-over-TCP version: ", server.UoTVersion, ", only 2 is supported")
+				}
+				config.Uot = true
+				config.UotVersion = server.UoTVersion
+			}
 			return config, nil
 		}
 	}
@@ -243,6 +269,9 @@ func (v *ShadowsocksClientConfig) Build() (proto.Message, error) {
 		if C.Contains(shadowaead_2022.List, server.Cipher) {
 			return nil, errors.New("Shadowsocks 2022 accept no multi servers")
 		}
+		if server.UoT {
+			return nil, errors.New("UDP-over-TCP is only supported by Shadowsocks 2022")
+		}
 		if server.Address == nil {
 			return nil, errors.New("Shadowsocks server address is not set.")
 		}
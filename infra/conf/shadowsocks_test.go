@@ -1,13 +1,16 @@
 package conf_test
 
 import (
+	"encoding/json"
 	"testing"
 
+	"github.com/xtls/xray-core/common"
 	"github.com/xtls/xray-core/common/net"
 	"github.com/xtls/xray-core/common/protocol"
 	"github.com/xtls/xray-core/common/serial"
 	. "github.com/xtls/xray-core/infra/conf"
 	"github.com/xtls/xray-core/proxy/shadowsocks"
+	"github.com/xtls/xray-core/proxy/shadowsocks_2022"
 )
 
 func TestShadowsocksServerConfigParsing(t *testing.T) {
@@ -34,3 +37,74 @@ func TestShadowsocksServerConfigParsing(t *testing.T) {
 		},
 	})
 }
+
+func TestShadowsocks2022ConfigParsing(t *testing.T) {
+	runMultiTestCase(t, []TestCase{
+		{
+			Input: `{
+				"method": "2022-blake3-aes-128-gcm",
+				"password": "ZGVmYXVsdC1rZXktMTIzNA==",
+				"users": [{
+					"password": "dXNlci1rZXktMTIzNDU2Nw==",
+					"email": "relay@example.com",
+					"address": "192.0.2.1",
+					"port": 8388,
+					"outboundTag": "chain"
+				}]
+			}`,
+			Parser: loadJSON(func() Buildable {
+				return new(ShadowsocksServerConfig)
+			}),
+			Output: &shadowsocks_2022.RelayServerConfig{
+				Method: "2022-blake3-aes-128-gcm",
+				Key:    "ZGVmYXVsdC1rZXktMTIzNA==",
+				Destinations: []*shadowsocks_2022.RelayDestination{{
+					Key:         "dXNlci1rZXktMTIzNDU2Nw==",
+					Email:       "relay@example.com",
+					Address:     net.NewIPOrDomain(net.ParseAddress("192.0.2.1")),
+					Port:        8388,
+					OutboundTag: "chain",
+				}},
+				Network: []net.Network{net.Network_TCP},
+			},
+		},
+		{
+			Input: `{
+				"address": "example.com",
+				"port": 8388,
+				"method": "2022-blake3-aes-128-gcm",
+				"password": "ZGVmYXVsdC1rZXktMTIzNA==",
+				"uot": true,
+				"uotVersion": 2
+			}`,
+			Parser: loadJSON(func() Buildable {
+				return new(ShadowsocksClientConfig)
+			}),
+			Output: &shadowsocks_2022.ClientConfig{
+				Address:    net.NewIPOrDomain(net.ParseAddress("example.com")),
+				Port:       8388,
+				Method:     "2022-blake3-aes-128-gcm",
+				Key:        "ZGVmYXVsdC1rZXktMTIzNA==",
+				Uot:        true,
+				UotVersion: 2,
+			},
+		},
+	})
+}
+
+func TestShadowsocksClientConfigUoTVersion(t *testing.T) {
+	for _, version := range []string{"1", "3"} {
+		config := new(ShadowsocksClientConfig)
+		common.Must(json.Unmarshal([]byte(`{
+			"address": "example.com",
+			"port": 8388,
+			"method": "2022-blake3-aes-128-gcm",
+			"password": "ZGVmYXVsdC1rZXktMTIzNA==",
+			"uot": true,
+			"uotVersion": `+version+`
+		}`), config))
+		if _, err := config.Build(); err == nil {
+			t.Error("built UDP-over-TCP version ", version)
+		}
+	}
+}
@@ -32,6 +32,8 @@ type SocksServerConfig struct {
 	Users      []*SocksAccount `json:"users"`
 	Accounts   []*SocksAccount `json:"accounts"`
 	UDP        bool            `json:"udp"`
+	UDPStrict  bool            `json:"udpStrict"`
+	Bind       bool            `json:"bind"`
 	Host       *Address        `json:"ip"`
 	UserLevel  uint32          `json:"userLevel"`
 }
@@ -60,6 +62,8 @@ func (v *SocksServerConfig) Build() (proto.Message, error) {
 	}
 
 	config.UdpEnabled = v.UDP
+	config.UdpStrict = v.UDPStrict
+	config.BindEnabled = v.Bind
 	if v.Host != nil {
 		config.Address = v.Host.Build()
 	}
@@ -12,6 +12,7 @@ import (
 	"os"
 	"regexp"
 	"runtime"
+	"slices"
 	"strconv"
 	"strings"
 	"syscall"
@@ -57,6 +58,8 @@ type KCPConfig struct {
 	DownCap          *uint32 `json:"downlinkCapacity"`
 	CwndMultiplier   *uint32 `json:"cwndMultiplier"`
 	MaxSendingWindow *uint32 `json:"maxSendingWindow"`
+	DataShards       *uint32 `json:"dataShards"`
+	ParityShards     *uint32 `json:"parityShards"`
 
 	HeaderConfig json.RawMessage `json:"header"`
 	Seed         *string         `json:"seed"`
@@ -88,6 +91,12 @@ func (c *KCPConfig) Build() (proto.Message, error) {
 	if c.MaxSendingWindow != nil {
 		config.MaxSendingWindow = *c.MaxSendingWindow
 	}
+	if c.DataShards != nil {
+		config.DataShards = *c.DataShards
+	}
+	if c.ParityShards != nil {
+		config.ParityShards = *c.ParityShards
+	}
 
 	if config.Mtu < 21 {
 		return nil, errors.New("Mtu must be at least 21").AtError()
@@ -101,6 +110,16 @@ func (c *KCPConfig) Build() (proto.Message, error) {
 	if config.GetSendingBufferSize() == 0 {
 		return nil, errors.New("MaxSendingWindow must be >= Mtu").AtError()
 	}
+	if config.FECEnabled() {
+		if config.DataShards+config.ParityShards > 255 {
+			return nil, errors.New("dataShards + parityShards must not exceed 255").AtError()
+		}
+		if config.Mtu < 21+kcp.FECSegmentOverhead {
+			return nil, errors.New("Mtu must be at least ", 21+kcp.FECSegmentOverhead, " with FEC").AtError()
+		}
+	} else if config.DataShards > 0 || config.ParityShards > 0 {
+		return nil, errors.New("both dataShards and parityShards must be set to enable FEC").AtError()
+	}
 
 	return config, nil
 }
@@ -666,6 +685,7 @@ type QuicParamsConfig struct {
 	KeepAlivePeriod             int64     `json:"keepAlivePeriod"`
 	DisablePathMTUDiscovery     bool      `json:"disablePathMTUDiscovery"`
 	MaxIncomingStreams          int64     `json:"maxIncomingStreams"`
+	HopListenPorts              *PortList `json:"hopListenPorts"`
 }
 
 type TLSConfig struct {
@@ -802,6 +822,9 @@ type REALITYConfig struct {
 	LimitFallbackUpload   LimitFallback `json:"limitFallbackUpload"`
 	LimitFallbackDownload LimitFallback `json:"limitFallbackDownload"`
 
+	ServerNameTargets   map[string]json.RawMessage `json:"serverNameTargets"`
+	HealthCheckInterval uint32                     `json:"healthCheckInterval"`
+
 	Fingerprint   string `json:"fingerprint"`
 	ServerName    string `json:"serverName"`
 	Password      string `json:"password"`
@@ -811,6 +834,59 @@ type REALITYConfig struct {
 	SpiderX       string `json:"spiderX"`
 }
 
+// buildREALITYTargets parses a "target" value, which is a dest or a list of dests tried in order.
+func buildREALITYTargets(raw json.RawMessage, network string) ([]*reality.Target, error) {
+	var list []json.RawMessage
+	if err := json.Unmarshal(raw, &list); err != nil {
+		list = []json.RawMessage{raw}
+	}
+	if len(list) == 0 {
+		return nil, errors.New(`please fill in a valid value for "target"`)
+	}
+	targets := make([]*reality.Target, 0, len(list))
+	for _, raw := range list {
+		target, err := buildREALITYTarget(raw, network)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, target)
+	}
+	return targets, nil
+}
+
+func buildREALITYTarget(raw json.RawMessage, network string) (*reality.Target, error) {
+	var i uint16
+	var s string
+	var err error
+	if err = json.Unmarshal(raw, &i); err == nil {
+		s = strconv.Itoa(int(i))
+	} else {
+		_ = json.Unmarshal(raw, &s)
+	}
+	if network == "" && s != "" {
+		switch s[0] {
+		case '@', '/':
+			network = "unix"
+			if s[0] == '@' && len(s) > 1 && s[1] == '@' && (runtime.GOOS == "linux" || runtime.GOOS == "android") {
+				fullAddr := make([]byte, len(syscall.RawSockaddrUnix{}.Path)) // may need padding to work with haproxy
+				copy(fullAddr, s[1:])
+				s = string(fullAddr)
+			}
+		default:
+			if _, err = strconv.Atoi(s); err == nil {
+				s = "localhost:" + s
+			}
+			if _, _, err = net.SplitHostPort(s); err == nil {
+				network = "tcp"
+			}
+		}
+	}
+	if network == "" {
+		return nil, errors.New(`please fill in a valid value for "target"`)
+	}
+	return &reality.Target{Type: network, Dest: s}, nil
+}
+
 func (c *REALITYConfig) Build() (proto.Message, error) {
 	config := new(reality.Config)
 	config.MasterKeyLog = c.MasterKeyLog
@@ -820,34 +896,30 @@ func (c *REALITYConfig) Build() (proto.Message, error) {
 		c.Dest = c.Target
 	}
 	if c.Dest != nil {
-		var i uint16
-		var s string
-		if err = json.Unmarshal(c.Dest, &i); err == nil {
-			s = strconv.Itoa(int(i))
-		} else {
-			_ = json.Unmarshal(c.Dest, &s)
-		}
-		if c.Type == "" && s != "" {
-			switch s[0] {
-			case '@', '/':
-				c.Type = "unix"
-				if s[0] == '@' && len(s) > 1 && s[1] == '@' && (runtime.GOOS == "linux" || runtime.GOOS == "android") {
-					fullAddr := make([]byte, len(syscall.RawSockaddrUnix{}.Path)) // may need padding to work with haproxy
-					copy(fullAddr, s[1:])
-					s = string(fullAddr)
+		targets, err := buildREALITYTargets(c.Dest, c.Type)
+		if err != nil {
+			return nil, err
+		}
+		config.Dest = targets[0].Dest
+		config.Type = targets[0].Type
+		config.Targets = targets[1:]
+		if len(c.ServerNameTargets) > 0 {
+			config.ServerNameTargets = make(map[string]*reality.TargetList, len(c.ServerNameTargets))
+			for serverName, raw := range c.ServerNameTargets {
+				if serverName == "" {
+					return nil, errors.New(`empty server name in "serverNameTargets"`)
 				}
-			default:
-				if _, err = strconv.Atoi(s); err == nil {
-					s = "localhost:" + s
+				targets, err := buildREALITYTargets(raw, c.Type)
+				if err != nil {
+					return nil, errors.New(`invalid "serverNameTargets[`, serverName, `]"`).Base(err)
 				}
-				if _, _, err = net.SplitHostPort(s); err == nil {
-					c.Type = "tcp"
+				config.ServerNameTargets[serverName] = &reality.TargetList{Targets: targets}
+				if !slices.Contains(c.ServerNames, serverName) {
+					c.ServerNames = append(c.ServerNames, serverName)
 				}
 			}
 		}
-		if c.Type == "" {
-			return nil, errors.New(`please fill in a valid value for "target"`)
-		}
+		config.HealthCheckInterval = c.HealthCheckInterval
 		if c.Xver > 2 {
 			return nil, errors.New(`invalid PROXY protocol version, "xver" only accepts 0, 1, 2`)
 		}
@@ -901,8 +973,6 @@ func (c *REALITYConfig) Build() (proto.Message, error) {
 				return nil, errors.New(`invalid "shortIds[`, i, `]": `, s)
 			}
 		}
-		config.Dest = s
-		config.Type = c.Type
 		config.Xver = c.Xver
 		config.ServerNames = c.ServerNames
 		config.MaxTimeDiff = c.MaxTimeDiff
@@ -2233,13 +2303,13 @@ func (c *StreamConfig) Build() (*internet.StreamConfig, error) {
 
 			c.FinalMask.QuicParams.Congestion = strings.ToLower(c.FinalMask.QuicParams.Congestion)
 			switch c.FinalMask.QuicParams.Congestion {
-			case "", "brutal", "reno", "bbr":
+			case "", "brutal", "reno", "bbr", "adaptive-brutal":
 			case "force-brutal":
 				if up == 0 {
 					return nil, errors.New("force-brutal requires up")
 				}
 			default:
-				return nil, errors.New("unknown congestion control: ", c.FinalMask.QuicParams.Congestion, ", valid values: reno, bbr, brutal, force-brutal")
+				return nil, errors.New("unknown congestion control: ", c.FinalMask.QuicParams.Congestion, ", valid values: reno, bbr, brutal, force-brutal, adaptive-brutal")
 			}
 
 			if (c.FinalMask.QuicParams.UdpHop.Interval.From != 0 && c.FinalMask.QuicParams.UdpHop.Interval.From < 5) || (c.FinalMask.QuicParams.UdpHop.Interval.To != 0 && c.FinalMask.QuicParams.UdpHop.Interval.To < 5) {
@@ -2292,6 +2362,9 @@ func (c *StreamConfig) Build() (*internet.StreamConfig, error) {
 				DisablePathMtuDiscovery: c.FinalMask.QuicParams.DisablePathMTUDiscovery,
 				MaxIncomingStreams:      c.FinalMask.QuicParams.MaxIncomingStreams,
 			}
+			if c.FinalMask.QuicParams.HopListenPorts != nil {
+				config.QuicParams.HopListenPorts = c.FinalMask.QuicParams.HopListenPorts.Build().Ports()
+			}
 		}
 	}
 
@@ -291,3 +291,27 @@ func TestHeaderCustomUDPBuildRejectsExprWithoutArgs(t *testing.T) {
 		t.Fatalf("expected transform arg rejection, got %v", err)
 	}
 }
+
+func TestQuicParamsHopListenPorts(t *testing.T) {
+	build := func(s string) *internet.QuicParams {
+		config := new(StreamConfig)
+		if err := json.Unmarshal([]byte(s), config); err != nil {
+			t.Fatal(err)
+		}
+		c, err := config.Build()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c.QuicParams
+	}
+
+	// the hop ports of clients are not listened on
+	params := build(`{"finalmask": {"quicParams": {"udpHop": {"ports": "20000-20002"}}}}`)
+	if len(params.UdpHop.Ports) != 3 || len(params.HopListenPorts) != 0 {
+		t.Error("unexpected quicParams ", params)
+	}
+	params = build(`{"finalmask": {"quicParams": {"hopListenPorts": "20000-20002,20005"}}}`)
+	if len(params.UdpHop.Ports) != 0 || len(params.HopListenPorts) != 4 {
+		t.Error("unexpected quicParams ", params)
+	}
+}
@@ -2,17 +2,12 @@ package conf
 
 import (
 	"encoding/json"
-	"path/filepath"
-	"runtime"
-	"strconv"
-	"strings"
-	"syscall"
 
 	"github.com/xtls/xray-core/common/errors"
-	"github.com/xtls/xray-core/common/net"
 	"github.com/xtls/xray-core/common/protocol"
 	"github.com/xtls/xray-core/common/serial"
 	"github.com/xtls/xray-core/common/task"
+	"github.com/xtls/xray-core/proxy/shadowsocks"
 	"github.com/xtls/xray-core/proxy/trojan"
 	"google.golang.org/protobuf/proto"
 )
@@ -27,15 +22,49 @@ type TrojanServerTarget struct {
 	Flow     string   `json:"flow"`
 }
 
+// TrojanShadowsocksConfig is configuration of the shadowsocks layer of Trojan-Go
+type TrojanShadowsocksConfig struct {
+	Enabled  bool   `json:"enabled"`
+	Method   string `json:"method"`
+	Password string `json:"password"`
+}
+
+// Build returns nil if the layer is not enabled
+func (c *TrojanShadowsocksConfig) Build() (*trojan.Shadowsocks, error) {
+	if c == nil || !c.Enabled {
+		return nil, nil
+	}
+	cipherType := cipherFromString(c.Method)
+	if cipherType < shadowsocks.CipherType_AES_128_GCM || cipherType > shadowsocks.CipherType_XCHACHA20_POLY1305 {
+		return nil, errors.New("Trojan shadowsocks: unsupported method ", c.Method, ", an AEAD method is required")
+	}
+	if c.Password == "" {
+		return nil, errors.New("Trojan shadowsocks: password is not specified")
+	}
+	return &trojan.Shadowsocks{
+		CipherType: cipherType,
+		Password:   c.Password,
+	}, nil
+}
+
+// TrojanMuxConfig is configuration of the mux of Trojan-Go
+type TrojanMuxConfig struct {
+	Enabled     bool   `json:"enabled"`
+	Concurrency uint32 `json:"concurrency"`
+	IdleTimeout uint32 `json:"idleTimeout"`
+}
+
 // TrojanClientConfig is configuration of trojan servers
 type TrojanClientConfig struct {
-	Address  *Address              `json:"address"`
-	Port     uint16                `json:"port"`
-	Level    byte                  `json:"level"`
-	Email    string                `json:"email"`
-	Password string                `json:"password"`
-	Flow     string                `json:"flow"`
-	Servers  []*TrojanServerTarget `json:"servers"`
+	Address     *Address                 `json:"address"`
+	Port        uint16                   `json:"port"`
+	Level       byte                     `json:"level"`
+	Email       string                   `json:"email"`
+	Password    string                   `json:"password"`
+	Flow        string                   `json:"flow"`
+	Servers     []*TrojanServerTarget    `json:"servers"`
+	Shadowsocks *TrojanShadowsocksConfig `json:"shadowsocks"`
+	Mux         *TrojanMuxConfig         `json:"mux"`
 }
 
 // Build implements Buildable
@@ -89,6 +118,19 @@ func (c *TrojanClientConfig) Build() (proto.Message, error) {
 		break
 	}
 
+	ss, err := c.Shadowsocks.Build()
+	if err != nil {
+		return nil, err
+	}
+	config.Shadowsocks = ss
+
+	if c.Mux != nil && c.Mux.Enabled {
+		config.Mux = &trojan.Mux{
+			Concurrency: c.Mux.Concurrency,
+			IdleTimeout: c.Mux.IdleTimeout,
+		}
+	}
+
 	return config, nil
 }
 
@@ -100,6 +142,11 @@ type TrojanInboundFallback struct {
 	Type string          `json:"type"`
 	Dest json.RawMessage `json:"dest"`
 	Xver uint64          `json:"xver"`
+
+	Host    string                `json:"host"`
+	Headers map[string]string     `json:"headers"`
+	Dests   []*FallbackDestConfig `json:"dests"`
+	Tlv     bool                  `json:"tlv"`
 }
 
 // TrojanUserConfig is user configuration
@@ -108,13 +155,16 @@ type TrojanUserConfig struct {
 	Level    byte   `json:"level"`
 	Email    string `json:"email"`
 	Flow     string `json:"flow"`
+	UserValidity
 }
 
 // TrojanServerConfig is Inbound configuration
 type TrojanServerConfig struct {
-	Users     []*TrojanUserConfig      `json:"users"`
-	Clients   []*TrojanUserConfig      `json:"clients"`
-	Fallbacks []*TrojanInboundFallback `json:"fallbacks"`
+	Users       []*TrojanUserConfig      `json:"users"`
+	Clients     []*TrojanUserConfig      `json:"clients"`
+	Fallbacks   []*TrojanInboundFallback `json:"fallbacks"`
+	UserSource  *UserSourceConfig        `json:"userSource"`
+	Shadowsocks *TrojanShadowsocksConfig `json:"shadowsocks"`
 }
 
 // Build implements Buildable
@@ -129,6 +179,20 @@ func (c *TrojanServerConfig) Build() (proto.Message, error) {
 		Users: make([]*protocol.User, len(c.Users)),
 	}
 
+	if c.UserSource != nil {
+		source, err := c.UserSource.Build()
+		if err != nil {
+			return nil, err
+		}
+		config.UserSource = source
+	}
+
+	ss, err := c.Shadowsocks.Build()
+	if err != nil {
+		return nil, err
+	}
+	config.Shadowsocks = ss
+
 	processClient := func(idx int) error {
 		rawUser := c.Users[idx]
 		if rawUser.Flow != "" {
@@ -142,28 +206,32 @@ func (c *TrojanServerConfig) Build() (proto.Message, error) {
 				Password: rawUser.Password,
 			}),
 		}
-		return nil
+		return rawUser.Apply(config.Users[idx])
 	}
 	if err := task.ParallelForN(len(c.Users), processClient); err != nil {
 		return nil, err
 	}
 
 	for _, fb := range c.Fallbacks {
-		var i uint16
-		var s string
-		if err := json.Unmarshal(fb.Dest, &i); err == nil {
-			s = strconv.Itoa(int(i))
-		} else {
-			_ = json.Unmarshal(fb.Dest, &s)
-		}
-		config.Fallbacks = append(config.Fallbacks, &trojan.Fallback{
-			Name: fb.Name,
-			Alpn: fb.Alpn,
-			Path: fb.Path,
-			Type: fb.Type,
-			Dest: s,
-			Xver: fb.Xver,
-		})
+		f := &trojan.Fallback{
+			Name:    fb.Name,
+			Alpn:    fb.Alpn,
+			Path:    fb.Path,
+			Type:    fb.Type,
+			Dest:    parseFallbackDest(fb.Dest),
+			Xver:    fb.Xver,
+			Host:    fb.Host,
+			Headers: fb.Headers,
+			Tlv:     fb.Tlv,
+		}
+		for _, d := range fb.Dests {
+			f.Dests = append(f.Dests, &trojan.Fallback_Dest{
+				Type:   d.Type,
+				Dest:   parseFallbackDest(d.Dest),
+				Weight: d.Weight,
+			})
+		}
+		config.Fallbacks = append(config.Fallbacks, f)
 	}
 	for _, fb := range config.Fallbacks {
 		/*
@@ -174,31 +242,24 @@ func (c *TrojanServerConfig) Build() (proto.Message, error) {
 		if fb.Path != "" && fb.Path[0] != '/' {
 			return nil, errors.New(`Trojan fallbacks: "path" must be empty or start with "/"`)
 		}
-		if fb.Type == "" && fb.Dest != "" {
-			if fb.Dest == "serve-ws-none" {
-				fb.Type = "serve"
-			} else if filepath.IsAbs(fb.Dest) || fb.Dest[0] == '@' {
-				fb.Type = "unix"
-				if strings.HasPrefix(fb.Dest, "@@") && (runtime.GOOS == "linux" || runtime.GOOS == "android") {
-					fullAddr := make([]byte, len(syscall.RawSockaddrUnix{}.Path)) // may need padding to work with haproxy
-					copy(fullAddr, fb.Dest[1:])
-					fb.Dest = string(fullAddr)
-				}
-			} else {
-				if _, err := strconv.Atoi(fb.Dest); err == nil {
-					fb.Dest = "localhost:" + fb.Dest
-				}
-				if _, _, err := net.SplitHostPort(fb.Dest); err == nil {
-					fb.Type = "tcp"
-				}
+		if err := checkFallbackRule(fb.Alpn, fb.Host, fb.Headers); err != nil {
+			return nil, errors.New(`Trojan fallbacks: invalid "host" or "headers"`).Base(err)
+		}
+		fb.Type, fb.Dest = fallbackDestType(fb.Type, fb.Dest)
+		for _, d := range fb.Dests {
+			if d.Type, d.Dest = fallbackDestType(d.Type, d.Dest); d.Type == "" {
+				return nil, errors.New(`Trojan fallbacks: please fill in a valid value for every "dest" of "dests"`)
 			}
 		}
-		if fb.Type == "" {
+		if fb.Type == "" && len(fb.Dests) == 0 {
 			return nil, errors.New(`Trojan fallbacks: please fill in a valid value for every "dest"`)
 		}
 		if fb.Xver > 2 {
 			return nil, errors.New(`Trojan fallbacks: invalid PROXY protocol version, "xver" only accepts 0, 1, 2`)
 		}
+		if fb.Tlv && fb.Xver != 2 {
+			return nil, errors.New(`Trojan fallbacks: "tlv" requires "xver" 2`)
+		}
 	}
 
 	return config, nil
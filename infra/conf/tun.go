@@ -1,10 +1,51 @@
 package conf
 
 import (
+	"encoding/json"
+
 	"github.com/xtls/xray-core/proxy/tun"
 	"google.golang.org/protobuf/proto"
 )
 
+type TunDNSHijackConfig struct {
+	Addresses *StringList              `json:"addresses"`
+	BlockDoT  bool                     `json:"blockDoT"`
+	Rules     []*DNSOutboundRuleConfig `json:"rules"`
+
+	disabled bool
+}
+
+// UnmarshalJSON implements encoding/json.Unmarshaler.UnmarshalJSON, accepting true for the defaults.
+func (c *TunDNSHijackConfig) UnmarshalJSON(data []byte) error {
+	var enabled bool
+	if err := json.Unmarshal(data, &enabled); err == nil {
+		c.disabled = !enabled
+		return nil
+	}
+	type config TunDNSHijackConfig
+	return json.Unmarshal(data, (*config)(c))
+}
+
+func (c *TunDNSHijackConfig) Build() (*tun.DnsHijack, error) {
+	if c == nil || c.disabled {
+		return nil, nil
+	}
+	config := &tun.DnsHijack{
+		BlockDot: c.BlockDoT,
+	}
+	if c.Addresses != nil {
+		config.Address = *c.Addresses
+	}
+	for _, r := range c.Rules {
+		rule, err := r.Build()
+		if err != nil {
+			return nil, err
+		}
+		config.Rule = append(config.Rule, rule)
+	}
+	return config, nil
+}
+
 type TunConfig struct {
 	Name                   string   `json:"name"`
 	MTU                    uint32   `json:"mtu"`
@@ -13,6 +54,9 @@ type TunConfig struct {
 	UserLevel              uint32   `json:"userLevel"`
 	AutoSystemRoutingTable []string `json:"autoSystemRoutingTable"`
 	AutoOutboundsInterface *string  `json:"autoOutboundsInterface"`
+	ForwardIcmp            bool     `json:"forwardIcmp"`
+
+	DNSHijack *TunDNSHijackConfig `json:"dnsHijack"`
 }
 
 func (v *TunConfig) Build() (proto.Message, error) {
@@ -23,10 +67,16 @@ func (v *TunConfig) Build() (proto.Message, error) {
 		DNS:                    v.DNS,
 		UserLevel:              v.UserLevel,
 		AutoSystemRoutingTable: v.AutoSystemRoutingTable,
+		ForwardIcmp:            v.ForwardIcmp,
 	}
 	if v.AutoOutboundsInterface != nil {
 		config.AutoOutboundsInterface = *v.AutoOutboundsInterface
 	}
+	dnsHijack, err := v.DNSHijack.Build()
+	if err != nil {
+		return nil, err
+	}
+	config.DnsHijack = dnsHijack
 	if len(v.AutoSystemRoutingTable) > 0 && v.AutoOutboundsInterface == nil {
 		config.AutoOutboundsInterface = "auto"
 	}
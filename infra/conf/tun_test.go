@@ -0,0 +1,83 @@
+package conf_test
+
+import (
+	"testing"
+
+	. "github.com/xtls/xray-core/infra/conf"
+	"github.com/xtls/xray-core/proxy/dns"
+	"github.com/xtls/xray-core/proxy/tun"
+)
+
+func TestTunConfig(t *testing.T) {
+	creator := func() Buildable {
+		return new(TunConfig)
+	}
+
+	runMultiTestCase(t, []TestCase{
+		{
+			Input: `{
+				"forwardIcmp": true
+			}`,
+			Parser: loadJSON(creator),
+			Output: &tun.Config{
+				Name:        "xray0",
+				MTU:         1500,
+				ForwardIcmp: true,
+			},
+		},
+		{
+			Input: `{
+				"name": "tun0",
+				"dnsHijack": true
+			}`,
+			Parser: loadJSON(creator),
+			Output: &tun.Config{
+				Name:      "tun0",
+				MTU:       1500,
+				DnsHijack: &tun.DnsHijack{},
+			},
+		},
+		{
+			Input: `{
+				"dnsHijack": false
+			}`,
+			Parser: loadJSON(creator),
+			Output: &tun.Config{
+				Name: "xray0",
+				MTU:  1500,
+			},
+		},
+		{
+			Input: `{
+				"dnsHijack": {
+					"addresses": ["198.18.0.2", ":5353"],
+					"blockDoT": true,
+					"rules": [{
+						"action": "hijack",
+						"qType": "1,28"
+					}, {
+						"action": "drop"
+					}]
+				}
+			}`,
+			Parser: loadJSON(creator),
+			Output: &tun.Config{
+				Name: "xray0",
+				MTU:  1500,
+				DnsHijack: &tun.DnsHijack{
+					Address:  []string{"198.18.0.2", ":5353"},
+					BlockDot: true,
+					Rule: []*dns.DNSRuleConfig{
+						{
+							Action: dns.RuleAction_Hijack,
+							QType:  []int32{1, 28},
+						},
+						{
+							Action: dns.RuleAction_Drop,
+						},
+					},
+				},
+			},
+		},
+	})
+}
@@ -0,0 +1,39 @@
+package conf
+
+import (
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/proxy/usersource"
+)
+
+type UserSourceConfig struct {
+	File        string `json:"file"`
+	URL         string `json:"url"`
+	Socket      string `json:"socket"`
+	Interval    uint32 `json:"interval"`
+	CacheTTL    uint32 `json:"cacheTtl"`
+	NegativeTTL uint32 `json:"negativeTtl"`
+}
+
+// Build implements Buildable.
+func (c *UserSourceConfig) Build() (*usersource.Config, error) {
+	count := 0
+	for _, s := range []string{c.File, c.URL, c.Socket} {
+		if s != "" {
+			count++
+		}
+	}
+	if count != 1 {
+		return nil, errors.New(`userSource: exactly one of "file", "url" and "socket" must be set`)
+	}
+	if c.Socket == "" && (c.CacheTTL > 0 || c.NegativeTTL > 0) {
+		return nil, errors.New(`userSource: "cacheTtl" and "negativeTtl" are only for "socket"`)
+	}
+	return &usersource.Config{
+		File:        c.File,
+		Url:         c.URL,
+		Socket:      c.Socket,
+		Interval:    c.Interval,
+		CacheTtl:    c.CacheTTL,
+		NegativeTtl: c.NegativeTTL,
+	}, nil
+}
@@ -3,14 +3,10 @@ package conf
 import (
 	"encoding/base64"
 	"encoding/json"
-	"path/filepath"
-	"runtime"
 	"strconv"
 	"strings"
-	"syscall"
 
 	"github.com/xtls/xray-core/common/errors"
-	"github.com/xtls/xray-core/common/net"
 	"github.com/xtls/xray-core/common/protocol"
 	"github.com/xtls/xray-core/common/serial"
 	"github.com/xtls/xray-core/common/task"
@@ -28,6 +24,11 @@ type VLessInboundFallback struct {
 	Type string          `json:"type"`
 	Dest json.RawMessage `json:"dest"`
 	Xver uint64          `json:"xver"`
+
+	Host    string                `json:"host"`
+	Headers map[string]string     `json:"headers"`
+	Dests   []*FallbackDestConfig `json:"dests"`
+	Tlv     bool                  `json:"tlv"`
 }
 
 type VLessInboundConfig struct {
@@ -37,6 +38,7 @@ type VLessInboundConfig struct {
 	Fallbacks  []*VLessInboundFallback `json:"fallbacks"`
 	Flow       string                  `json:"flow"`
 	Testseed   []uint32                `json:"testseed"`
+	UserSource *UserSourceConfig       `json:"userSource"`
 }
 
 // Build implements Buildable
@@ -94,6 +96,14 @@ func (c *VLessInboundConfig) Build() (proto.Message, error) {
 			}
 		}
 
+		validity := new(UserValidity)
+		if err := json.Unmarshal(rawUser, validity); err != nil {
+			return errors.New(`VLESS users: invalid user`).Base(err)
+		}
+		if err := validity.Apply(user); err != nil {
+			return err
+		}
+
 		user.Account = serial.ToTypedMessage(account)
 		config.Users[idx] = user
 		return nil
@@ -103,6 +113,14 @@ func (c *VLessInboundConfig) Build() (proto.Message, error) {
 		return nil, err
 	}
 
+	if c.UserSource != nil {
+		source, err := c.UserSource.Build()
+		if err != nil {
+			return nil, err
+		}
+		config.UserSource = source
+	}
+
 	config.Decryption = c.Decryption
 	if !func() bool {
 		s := strings.Split(config.Decryption, ".")
@@ -159,21 +177,25 @@ func (c *VLessInboundConfig) Build() (proto.Message, error) {
 	}
 
 	for _, fb := range c.Fallbacks {
-		var i uint16
-		var s string
-		if err := json.Unmarshal(fb.Dest, &i); err == nil {
-			s = strconv.Itoa(int(i))
-		} else {
-			_ = json.Unmarshal(fb.Dest, &s)
+		f := &inbound.Fallback{
+			Name:    fb.Name,
+			Alpn:    fb.Alpn,
+			Path:    fb.Path,
+			Type:    fb.Type,
+			Dest:    parseFallbackDest(fb.Dest),
+			Xver:    fb.Xver,
+			Host:    fb.Host,
+			Headers: fb.Headers,
+			Tlv:     fb.Tlv,
+		}
+		for _, d := range fb.Dests {
+			f.Dests = append(f.Dests, &inbound.Fallback_Dest{
+				Type:   d.Type,
+				Dest:   parseFallbackDest(d.Dest),
+				Weight: d.Weight,
+			})
 		}
-		config.Fallbacks = append(config.Fallbacks, &inbound.Fallback{
-			Name: fb.Name,
-			Alpn: fb.Alpn,
-			Path: fb.Path,
-			Type: fb.Type,
-			Dest: s,
-			Xver: fb.Xver,
-		})
+		config.Fallbacks = append(config.Fallbacks, f)
 	}
 	for _, fb := range config.Fallbacks {
 		/*
@@ -184,31 +206,24 @@ func (c *VLessInboundConfig) Build() (proto.Message, error) {
 		if fb.Path != "" && fb.Path[0] != '/' {
 			return nil, errors.New(`VLESS fallbacks: "path" must be empty or start with "/"`)
 		}
-		if fb.Type == "" && fb.Dest != "" {
-			if fb.Dest == "serve-ws-none" {
-				fb.Type = "serve"
-			} else if filepath.IsAbs(fb.Dest) || fb.Dest[0] == '@' {
-				fb.Type = "unix"
-				if strings.HasPrefix(fb.Dest, "@@") && (runtime.GOOS == "linux" || runtime.GOOS == "android") {
-					fullAddr := make([]byte, len(syscall.RawSockaddrUnix{}.Path)) // may need padding to work with haproxy
-					copy(fullAddr, fb.Dest[1:])
-					fb.Dest = string(fullAddr)
-				}
-			} else {
-				if _, err := strconv.Atoi(fb.Dest); err == nil {
-					fb.Dest = "localhost:" + fb.Dest
-				}
-				if _, _, err := net.SplitHostPort(fb.Dest); err == nil {
-					fb.Type = "tcp"
-				}
+		if err := checkFallbackRule(fb.Alpn, fb.Host, fb.Headers); err != nil {
+			return nil, errors.New(`VLESS fallbacks: invalid "host" or "headers"`).Base(err)
+		}
+		fb.Type, fb.Dest = fallbackDestType(fb.Type, fb.Dest)
+		for _, d := range fb.Dests {
+			if d.Type, d.Dest = fallbackDestType(d.Type, d.Dest); d.Type == "" {
+				return nil, errors.New(`VLESS fallbacks: please fill in a valid value for every "dest" of "dests"`)
 			}
 		}
-		if fb.Type == "" {
+		if fb.Type == "" && len(fb.Dests) == 0 {
 			return nil, errors.New(`VLESS fallbacks: please fill in a valid value for every "dest"`)
 		}
 		if fb.Xver > 2 {
 			return nil, errors.New(`VLESS fallbacks: invalid PROXY protocol version, "xver" only accepts 0, 1, 2`)
 		}
+		if fb.Tlv && fb.Xver != 2 {
+			return nil, errors.New(`VLESS fallbacks: "tlv" requires "xver" 2`)
+		}
 	}
 
 	return config, nil
@@ -7,9 +7,11 @@ import (
 	"github.com/xtls/xray-core/common/protocol"
 	"github.com/xtls/xray-core/common/serial"
 	. "github.com/xtls/xray-core/infra/conf"
+	"github.com/xtls/xray-core/proxy/usersource"
 	"github.com/xtls/xray-core/proxy/vless"
 	"github.com/xtls/xray-core/proxy/vless/inbound"
 	"github.com/xtls/xray-core/proxy/vless/outbound"
+	"google.golang.org/protobuf/proto"
 )
 
 func TestVLessOutbound(t *testing.T) {
@@ -85,6 +87,18 @@ func TestVLessOutbound(t *testing.T) {
 	})
 }
 
+func TestVLessInboundFallbackH2(t *testing.T) {
+	parse := loadJSON(func() Buildable {
+		return new(VLessInboundConfig)
+	})
+	if _, err := parse(`{
+		"decryption": "none",
+		"fallbacks": [{"alpn": "h2", "host": "example.com", "dest": 80}]
+	}`); err == nil {
+		t.Error("expected a host of an h2 fallback to be rejected")
+	}
+}
+
 func TestVLessInbound(t *testing.T) {
 	creator := func() Buildable {
 		return new(VLessInboundConfig)
@@ -155,5 +169,140 @@ func TestVLessInbound(t *testing.T) {
 				},
 			},
 		},
+		{
+			Input: `{
+				"clients": [],
+				"decryption": "none",
+				"fallbacks": [
+					{
+						"dest": 80
+					},
+					{
+						"name": "example.com",
+						"host": "admin.example.com",
+						"headers": {
+							"User-Agent": "^Mozilla/"
+						},
+						"dests": [
+							{
+								"dest": 8001,
+								"weight": 3
+							},
+							{
+								"dest": "/dev/shm/web.socket"
+							}
+						],
+						"xver": 2,
+						"tlv": true
+					}
+				]
+			}`,
+			Parser: loadJSON(creator),
+			Output: &inbound.Config{
+				Users:      []*protocol.User{},
+				Decryption: "none",
+				Fallbacks: []*inbound.Fallback{
+					{
+						Type: "tcp",
+						Dest: "localhost:80",
+					},
+					{
+						Name: "example.com",
+						Host: "admin.example.com",
+						Headers: map[string]string{
+							"User-Agent": "^Mozilla/",
+						},
+						Dests: []*inbound.Fallback_Dest{
+							{
+								Type:   "tcp",
+								Dest:   "localhost:8001",
+								Weight: 3,
+							},
+							{
+								Type: "unix",
+								Dest: "/dev/shm/web.socket",
+							},
+						},
+						Xver: 2,
+						Tlv:  true,
+					},
+				},
+			},
+		},
+		{
+			Input: `{
+				"clients": [],
+				"decryption": "none",
+				"userSource": {
+					"socket": "/run/users.sock",
+					"cacheTtl": 300
+				}
+			}`,
+			Parser: loadJSON(creator),
+			Output: &inbound.Config{
+				Users:      []*protocol.User{},
+				Decryption: "none",
+				UserSource: &usersource.Config{
+					Socket:   "/run/users.sock",
+					CacheTtl: 300,
+				},
+			},
+		},
+		{
+			Input: `{
+				"clients": [
+					{
+						"id": "27848739-7e62-4138-9fd3-098a63964b6b",
+						"email": "love@example.com",
+						"expiresAt": "2030-01-01T00:00:00Z",
+						"notBefore": 1700000000,
+						"enabled": false
+					}
+				],
+				"decryption": "none"
+			}`,
+			Parser: loadJSON(creator),
+			Output: &inbound.Config{
+				Users: []*protocol.User{
+					{
+						Account: serial.ToTypedMessage(&vless.Account{
+							Id: "27848739-7e62-4138-9fd3-098a63964b6b",
+						}),
+						Email:     "love@example.com",
+						ExpiresAt: 1893456000,
+						NotBefore: 1700000000,
+						Enabled:   proto.Bool(false),
+					},
+				},
+				Decryption: "none",
+			},
+		},
+		{
+			Input: `{
+				"clients": [
+					{
+						"id": "27848739-7e62-4138-9fd3-098a63964b6b",
+						"email": "love@example.com",
+						"outboundTag": "exit-jp",
+						"group": "vip"
+					}
+				],
+				"decryption": "none"
+			}`,
+			Parser: loadJSON(creator),
+			Output: &inbound.Config{
+				Users: []*protocol.User{
+					{
+						Account: serial.ToTypedMessage(&vless.Account{
+							Id:          "27848739-7e62-4138-9fd3-098a63964b6b",
+							OutboundTag: "exit-jp",
+							Group:       "vip",
+						}),
+						Email: "love@example.com",
+					},
+				},
+				Decryption: "none",
+			},
+		},
 	})
 }
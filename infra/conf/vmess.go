@@ -58,10 +58,32 @@ func (c *VMessDefaultConfig) Build() *inbound.DefaultConfig {
 	return config
 }
 
+type VMessAuthFailureBanConfig struct {
+	MaxRetry    uint32 `json:"maxRetry"`
+	FindTime    uint32 `json:"findTime"`
+	BanTime     uint32 `json:"banTime"`
+	OutboundTag string `json:"outboundTag"`
+}
+
+// Build implements Buildable
+func (c *VMessAuthFailureBanConfig) Build() (*inbound.AuthFailureBan, error) {
+	if c.OutboundTag == "" {
+		return nil, errors.New(`VMess "ban" has no "outboundTag"`)
+	}
+	return &inbound.AuthFailureBan{
+		MaxRetry:    c.MaxRetry,
+		FindTime:    c.FindTime,
+		BanTime:     c.BanTime,
+		OutboundTag: c.OutboundTag,
+	}, nil
+}
+
 type VMessInboundConfig struct {
-	Users    []json.RawMessage   `json:"users"`
-	Clients  []json.RawMessage   `json:"clients"`
-	Defaults *VMessDefaultConfig `json:"default"`
+	Users      []json.RawMessage          `json:"users"`
+	Clients    []json.RawMessage          `json:"clients"`
+	Defaults   *VMessDefaultConfig        `json:"default"`
+	UserSource *UserSourceConfig          `json:"userSource"`
+	Ban        *VMessAuthFailureBanConfig `json:"ban"`
 }
 
 // Build implements Buildable
@@ -74,6 +96,25 @@ func (c *VMessInboundConfig) Build() (proto.Message, error) {
 		config.Default = c.Defaults.Build()
 	}
 
+	if c.UserSource != nil {
+		if c.UserSource.Socket != "" {
+			return nil, errors.New(`VMess "userSource" doesn't support "socket"`)
+		}
+		source, err := c.UserSource.Build()
+		if err != nil {
+			return nil, err
+		}
+		config.UserSource = source
+	}
+
+	if c.Ban != nil {
+		ban, err := c.Ban.Build()
+		if err != nil {
+			return nil, err
+		}
+		config.Ban = ban
+	}
+
 	if c.Clients != nil {
 		c.Users = c.Clients
 	}
@@ -95,6 +136,14 @@ func (c *VMessInboundConfig) Build() (proto.Message, error) {
 		}
 		account.ID = u.String()
 
+		validity := new(UserValidity)
+		if err := json.Unmarshal(rawData, validity); err != nil {
+			return errors.New("invalid VMess user").Base(err)
+		}
+		if err := validity.Apply(user); err != nil {
+			return err
+		}
+
 		user.Account = serial.ToTypedMessage(account.Build())
 		config.User[idx] = user
 		return nil
@@ -126,5 +126,32 @@ func TestVMessInbound(t *testing.T) {
 				},
 			},
 		},
+		{
+			Input: `{
+				"clients": [],
+				"ban": {
+					"maxRetry": 3,
+					"banTime": 60,
+					"outboundTag": "blocked"
+				}
+			}`,
+			Parser: loadJSON(creator),
+			Output: &inbound.Config{
+				User: []*protocol.User{},
+				Ban: &inbound.AuthFailureBan{
+					MaxRetry:    3,
+					BanTime:     60,
+					OutboundTag: "blocked",
+				},
+			},
+		},
 	})
 }
+
+func TestVMessInboundBanWithoutOutboundTag(t *testing.T) {
+	if _, err := loadJSON(func() Buildable {
+		return new(VMessInboundConfig)
+	})(`{"clients": [], "ban": {"maxRetry": 3}}`); err == nil {
+		t.Error("expected an error for a ban without outboundTag")
+	}
+}
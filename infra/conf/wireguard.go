@@ -23,6 +23,8 @@ type WireGuardPeerConfig struct {
 
 	Level uint32 `json:"level"`
 	Email string `json:"email"`
+	// UserValidity is only for the peers of the inbound.
+	UserValidity
 }
 
 func (c *WireGuardPeerConfig) Build() (*wireguard.PeerConfig, error) {
@@ -56,16 +58,23 @@ func (c *WireGuardPeerConfig) Build() (*wireguard.PeerConfig, error) {
 	return config, nil
 }
 
+type WireGuardFailoverConfig struct {
+	HandshakeTimeout uint32 `json:"handshakeTimeout"`
+	CheckInterval    uint32 `json:"checkInterval"`
+	KeepAlive        uint32 `json:"keepAlive"`
+}
+
 type WireGuardConfig struct {
 	IsClient bool `json:""`
 
-	NoKernelTun    bool                   `json:"noKernelTun"`
-	SecretKey      string                 `json:"secretKey"`
-	Address        []string               `json:"address"`
-	Peers          []*WireGuardPeerConfig `json:"peers"`
-	MTU            int32                  `json:"mtu"`
-	Reserved       []byte                 `json:"reserved"`
-	DomainStrategy string                 `json:"domainStrategy"`
+	NoKernelTun    bool                     `json:"noKernelTun"`
+	SecretKey      string                   `json:"secretKey"`
+	Address        []string                 `json:"address"`
+	Peers          []*WireGuardPeerConfig   `json:"peers"`
+	MTU            int32                    `json:"mtu"`
+	Reserved       []byte                   `json:"reserved"`
+	DomainStrategy string                   `json:"domainStrategy"`
+	Failover       *WireGuardFailoverConfig `json:"failover"`
 }
 
 func (c *WireGuardConfig) Build() (proto.Message, error) {
@@ -106,7 +115,7 @@ func (c *WireGuardConfig) Build() (proto.Message, error) {
 				Level:   p.Level,
 				Account: serial.ToTypedMessage(m),
 			}
-			return nil
+			return p.Apply(config.Users[idx])
 		}
 		if err := task.ParallelForN(len(c.Peers), processUser); err != nil {
 			return nil, err
@@ -139,6 +148,21 @@ func (c *WireGuardConfig) Build() (proto.Message, error) {
 		return nil, errors.New("unsupported domain strategy: ", c.DomainStrategy)
 	}
 
+	if c.Failover != nil {
+		if !c.IsClient {
+			return nil, errors.New(`"failover" is only supported by the WireGuard outbound`)
+		}
+		// peers handshake again every 2 minutes, after RekeyAfterTime and RekeyTimeout
+		if c.Failover.HandshakeTimeout != 0 && c.Failover.HandshakeTimeout < 135 {
+			return nil, errors.New(`"handshakeTimeout" must be at least 135 seconds`)
+		}
+		config.Failover = &wireguard.FailoverConfig{
+			HandshakeTimeout: c.Failover.HandshakeTimeout,
+			CheckInterval:    c.Failover.CheckInterval,
+			KeepAlive:        c.Failover.KeepAlive,
+		}
+	}
+
 	config.IsClient = c.IsClient
 	config.NoKernelTun = c.NoKernelTun
 
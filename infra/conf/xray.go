@@ -3,6 +3,7 @@ package conf
 import (
 	"context"
 	"encoding/json"
+	"net/netip"
 	"path/filepath"
 	"strings"
 
@@ -30,6 +31,7 @@ var (
 		"trojan":        func() interface{} { return new(TrojanServerConfig) },
 		"wireguard":     func() interface{} { return &WireGuardConfig{IsClient: false} },
 		"hysteria":      func() interface{} { return new(HysteriaServerConfig) },
+		"masque":        func() interface{} { return new(MasqueServerConfig) },
 		"tun":           func() interface{} { return new(TunConfig) },
 	}, "protocol", "settings")
 
@@ -46,6 +48,7 @@ var (
 		"vmess":       func() interface{} { return new(VMessOutboundConfig) },
 		"trojan":      func() interface{} { return new(TrojanClientConfig) },
 		"hysteria":    func() interface{} { return new(HysteriaClientConfig) },
+		"masque":      func() interface{} { return new(MasqueClientConfig) },
 		"dns":         func() interface{} { return new(DNSOutboundConfig) },
 		"wireguard":   func() interface{} { return &WireGuardConfig{IsClient: true} },
 	}, "protocol", "settings")
@@ -103,6 +106,7 @@ type MuxConfig struct {
 	Concurrency     int16  `json:"concurrency"`
 	XudpConcurrency int16  `json:"xudpConcurrency"`
 	XudpProxyUDP443 string `json:"xudpProxyUDP443"`
+	FlowControl     bool   `json:"flowControl"`
 }
 
 // Build creates MultiplexingConfig, Concurrency < 0 completely disables mux.
@@ -119,6 +123,7 @@ func (m *MuxConfig) Build() (*proxyman.MultiplexingConfig, error) {
 		Concurrency:     int32(m.Concurrency),
 		XudpConcurrency: int32(m.XudpConcurrency),
 		XudpProxyUDP443: m.XudpProxyUDP443,
+		FlowControl:     m.FlowControl,
 	}, nil
 }
 
@@ -210,14 +215,64 @@ func (c *InboundDetourConfig) Build() (*core.InboundHandlerConfig, error) {
 }
 
 type OutboundDetourConfig struct {
-	Protocol       string           `json:"protocol"`
-	SendThrough    *string          `json:"sendThrough"`
-	Tag            string           `json:"tag"`
-	Settings       *json.RawMessage `json:"settings"`
-	StreamSetting  *StreamConfig    `json:"streamSettings"`
-	ProxySettings  *ProxyConfig     `json:"proxySettings"`
-	MuxSettings    *MuxConfig       `json:"mux"`
-	TargetStrategy string           `json:"targetStrategy"`
+	Protocol        string                 `json:"protocol"`
+	SendThrough     *string                `json:"sendThrough"`
+	Tag             string                 `json:"tag"`
+	Settings        *json.RawMessage       `json:"settings"`
+	StreamSetting   *StreamConfig          `json:"streamSettings"`
+	ProxySettings   *ProxyConfig           `json:"proxySettings"`
+	MuxSettings     *MuxConfig             `json:"mux"`
+	TargetStrategy  string                 `json:"targetStrategy"`
+	SendThroughPool *SendThroughPoolConfig `json:"sendThroughPool"`
+}
+
+type SendThroughPoolConfig struct {
+	Addresses     []string `json:"addresses"`
+	Strategy      string   `json:"strategy"`
+	CheckInterval uint32   `json:"checkInterval"`
+	CheckIPv4     string   `json:"checkIPv4"`
+	CheckIPv6     string   `json:"checkIPv6"`
+}
+
+// Build implements Buildable.
+func (c *SendThroughPoolConfig) Build() (*proxyman.SourcePool, error) {
+	if len(c.Addresses) == 0 {
+		return nil, errors.New("sendThroughPool: empty addresses")
+	}
+	config := &proxyman.SourcePool{
+		CheckInterval: c.CheckInterval,
+		CheckIpv4:     c.CheckIPv4,
+		CheckIpv6:     c.CheckIPv6,
+	}
+	if addr, err := netip.ParseAddrPort(c.CheckIPv4); c.CheckIPv4 != "" && (err != nil || !addr.Addr().Is4()) {
+		return nil, errors.New("sendThroughPool: invalid checkIPv4 ", c.CheckIPv4, ", expected IPv4:port")
+	}
+	if addr, err := netip.ParseAddrPort(c.CheckIPv6); c.CheckIPv6 != "" && (err != nil || !addr.Addr().Is6() || addr.Addr().Is4In6()) {
+		return nil, errors.New("sendThroughPool: invalid checkIPv6 ", c.CheckIPv6, ", expected [IPv6]:port")
+	}
+	for _, address := range c.Addresses {
+		var err error
+		if strings.Contains(address, "/") {
+			_, err = netip.ParsePrefix(address)
+		} else {
+			_, err = netip.ParseAddr(address)
+		}
+		if err != nil {
+			return nil, errors.New("sendThroughPool: invalid address ", address).Base(err)
+		}
+		config.Addresses = append(config.Addresses, address)
+	}
+	switch strings.ToLower(c.Strategy) {
+	case "", "random":
+		config.Strategy = proxyman.SourcePool_Random
+	case "user":
+		config.Strategy = proxyman.SourcePool_User
+	case "destination":
+		config.Strategy = proxyman.SourcePool_Destination
+	default:
+		return nil, errors.New("sendThroughPool: unknown strategy ", c.Strategy)
+	}
+	return config, nil
 }
 
 func (c *OutboundDetourConfig) checkChainProxyConfig() error {
@@ -279,6 +334,17 @@ func (c *OutboundDetourConfig) Build() (*core.OutboundHandlerConfig, error) {
 		senderSettings.Via = address.Build()
 	}
 
+	if c.SendThroughPool != nil {
+		if c.SendThrough != nil {
+			return nil, errors.New("sendThroughPool is conflicted with sendThrough")
+		}
+		pool, err := c.SendThroughPool.Build()
+		if err != nil {
+			return nil, err
+		}
+		senderSettings.ViaPool = pool
+	}
+
 	if c.StreamSetting != nil {
 		ss, err := c.StreamSetting.Build()
 		if err != nil {
@@ -362,6 +428,7 @@ type Config struct {
 	BurstObservatory *BurstObservatoryConfig `json:"burstObservatory"`
 	Version          *VersionConfig          `json:"version"`
 	Geodata          *GeodataConfig          `json:"geodata"`
+	Fail2ban         *Fail2banConfig         `json:"fail2ban"`
 }
 
 func (c *Config) findInboundTag(tag string) int {
@@ -438,6 +505,10 @@ func (c *Config) Override(o *Config, fn string) {
 		c.Geodata = o.Geodata
 	}
 
+	if o.Fail2ban != nil {
+		c.Fail2ban = o.Fail2ban
+	}
+
 	// update the Inbound in slice if the only one in override config has same tag
 	if len(o.InboundConfigs) > 0 {
 		for i := range o.InboundConfigs {
@@ -594,6 +665,14 @@ func (c *Config) Build() (*core.Config, error) {
 		config.App = append(config.App, serial.ToTypedMessage(r))
 	}
 
+	if c.Fail2ban != nil {
+		r, err := c.Fail2ban.Build()
+		if err != nil {
+			return nil, errors.New("failed to build fail2ban configuration").Base(err)
+		}
+		config.App = append(config.App, serial.ToTypedMessage(r))
+	}
+
 	var inbounds []InboundDetourConfig
 
 	if len(c.InboundConfigs) > 0 {
@@ -255,6 +255,11 @@ func TestMuxConfig_Build(t *testing.T) {
 			XudpConcurrency: 0,
 			XudpProxyUDP443: "reject",
 		}},
+		{"flow control", `{"enabled": true, "flowControl": true}`, &proxyman.MultiplexingConfig{
+			Enabled:         true,
+			XudpProxyUDP443: "reject",
+			FlowControl:     true,
+		}},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -25,6 +25,7 @@ var CmdAPI = &base.Command{
 		cmdListOutbounds,
 		cmdAddInboundUsers,
 		cmdRemoveInboundUsers,
+		cmdUpdateInboundUsers,
 		cmdInboundUser,
 		cmdInboundUserCount,
 		cmdAddRules,
@@ -34,5 +35,7 @@ var CmdAPI = &base.Command{
 		cmdOnlineStats,
 		cmdOnlineStatsIpList,
 		cmdGetAllOnlineUsers,
+		cmdWireGuard,
+		cmdFail2ban,
 	},
 }
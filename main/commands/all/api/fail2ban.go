@@ -0,0 +1,108 @@
+package api
+
+import (
+	fail2banService "github.com/xtls/xray-core/app/fail2ban/command"
+	"github.com/xtls/xray-core/main/commands/base"
+)
+
+var cmdFail2ban = &base.Command{
+	UsageLine: "{{.Exec}} api f2b",
+	Short:     "Manage source IPs banned by fail2ban",
+	Long: `{{.Exec}} {{.LongName}} lists and lifts the bans of source IPs which failed authentication too often.
+"Fail2banService" must be enabled in the api services.
+`,
+	Commands: []*base.Command{
+		cmdFail2banBans,
+		cmdFail2banUnban,
+	},
+}
+
+var cmdFail2banBans = &base.Command{
+	CustomFlags: true,
+	UsageLine:   "{{.Exec}} api f2b bans [--server=127.0.0.1:8080] [-jail=name]",
+	Short:       "List banned source IPs",
+	Long: `
+List banned source IPs, with their jail, number of failures, and the unix time of the ban and of its end.
+
+Arguments:
+
+	-s, -server <server:port>
+		The API server address. Default 127.0.0.1:8080
+
+	-t, -timeout <seconds>
+		Timeout in seconds for calling API. Default 3
+
+	-jail
+		Name of the jail. Default all jails
+
+Example:
+
+	{{.Exec}} {{.LongName}} --server=127.0.0.1:8080 -jail="proxy"
+`,
+	Run: executeFail2banBans,
+}
+
+func executeFail2banBans(cmd *base.Command, args []string) {
+	setSharedFlags(cmd)
+	var jail string
+	cmd.Flag.StringVar(&jail, "jail", "", "")
+	cmd.Flag.Parse(args)
+
+	conn, ctx, close := dialAPIServer()
+	defer close()
+
+	client := fail2banService.NewFail2BanServiceClient(conn)
+	resp, err := client.ListBans(ctx, &fail2banService.ListBansRequest{Jail: jail})
+	if err != nil {
+		base.Fatalf("failed to list bans: %s", err)
+	}
+	showJSONResponse(resp)
+}
+
+var cmdFail2banUnban = &base.Command{
+	CustomFlags: true,
+	UsageLine:   "{{.Exec}} api f2b unban [--server=127.0.0.1:8080] [-jail=name] <ip>",
+	Short:       "Lift the ban of a source IP",
+	Long: `
+Lift the ban of a source IP.
+
+Arguments:
+
+	-s, -server <server:port>
+		The API server address. Default 127.0.0.1:8080
+
+	-t, -timeout <seconds>
+		Timeout in seconds for calling API. Default 3
+
+	-jail
+		Name of the jail. Default all jails
+
+Example:
+
+	{{.Exec}} {{.LongName}} --server=127.0.0.1:8080 1.2.3.4
+`,
+	Run: executeFail2banUnban,
+}
+
+func executeFail2banUnban(cmd *base.Command, args []string) {
+	setSharedFlags(cmd)
+	var jail string
+	cmd.Flag.StringVar(&jail, "jail", "", "")
+	cmd.Flag.Parse(args)
+	if cmd.Flag.NArg() != 1 {
+		base.Fatalf("an IP must be specified")
+	}
+
+	conn, ctx, close := dialAPIServer()
+	defer close()
+
+	client := fail2banService.NewFail2BanServiceClient(conn)
+	resp, err := client.Unban(ctx, &fail2banService.UnbanRequest{
+		Jail: jail,
+		Ip:   cmd.Flag.Arg(0),
+	})
+	if err != nil {
+		base.Fatalf("failed to unban: %s", err)
+	}
+	showJSONResponse(resp)
+}
@@ -0,0 +1,146 @@
+package api
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	handlerService "github.com/xtls/xray-core/app/proxyman/command"
+	cserial "github.com/xtls/xray-core/common/serial"
+
+	"github.com/xtls/xray-core/main/commands/base"
+)
+
+var cmdUpdateInboundUsers = &base.Command{
+	CustomFlags: true,
+	UsageLine:   "{{.Exec}} api upu [--server=127.0.0.1:8080] -tag=tag [-expires=time] [-notbefore=time] [-enable|-disable] <email1> [email2]...",
+	Short:       "Update the validity of inbound users",
+	Long: `
+Update the expiry, the start and the state of users of an inbound, without
+removing them. The settings not given are kept.
+
+Arguments:
+
+	-s, -server <server:port>
+		The API server address. Default 127.0.0.1:8080
+
+	-t, -timeout <seconds>
+		Timeout in seconds for calling API. Default 3
+
+	-tag
+		Inbound tag
+
+	-expires
+		The expiry of the users, as RFC 3339 time or unix seconds, "never",
+		or "+duration" to extend the current expiry, from now if the users
+		never expire or have expired.
+
+	-notbefore
+		The time before which the users are rejected, as RFC 3339 time or
+		unix seconds, or "never" to remove it.
+
+	-enable, -disable
+		Enable or disable the users.
+
+Example:
+
+	{{.Exec}} {{.LongName}} --server=127.0.0.1:8080 -tag="vless-in" -expires=+720h "xray@love.com" ...
+	{{.Exec}} {{.LongName}} --server=127.0.0.1:8080 -tag="vless-in" -disable "xray@love.com"
+`,
+	Run: executeUpdateUsers,
+}
+
+func executeUpdateUsers(cmd *base.Command, args []string) {
+	setSharedFlags(cmd)
+	var tag, expires, notBefore string
+	var enable, disable bool
+	cmd.Flag.StringVar(&tag, "tag", "", "")
+	cmd.Flag.StringVar(&expires, "expires", "", "")
+	cmd.Flag.StringVar(&notBefore, "notbefore", "", "")
+	cmd.Flag.BoolVar(&enable, "enable", false, "")
+	cmd.Flag.BoolVar(&disable, "disable", false, "")
+	cmd.Flag.Parse(args)
+	emails := cmd.Flag.Args()
+	if len(tag) < 1 {
+		base.Fatalf("inbound tag not specified")
+	}
+	if enable && disable {
+		base.Fatalf("-enable and -disable are exclusive")
+	}
+
+	conn, ctx, close := dialAPIServer()
+	defer close()
+	client := handlerService.NewHandlerServiceClient(conn)
+
+	success := 0
+	for _, email := range emails {
+		fmt.Println("update user:", email)
+		resp, err := client.GetInboundUsers(ctx, &handlerService.GetInboundUserRequest{
+			Tag:   tag,
+			Email: email,
+		})
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+		if len(resp.Users) == 0 || resp.Users[0] == nil {
+			fmt.Println("user not found")
+			continue
+		}
+		op := &handlerService.UpdateUserValidityOperation{
+			Email:     email,
+			ExpiresAt: resp.Users[0].ExpiresAt,
+			NotBefore: resp.Users[0].NotBefore,
+		}
+		if expires != "" {
+			if op.ExpiresAt, err = parseUserTime(expires, op.ExpiresAt); err != nil {
+				base.Fatalf("invalid -expires: %s", err)
+			}
+		}
+		if notBefore != "" {
+			if op.NotBefore, err = parseUserTime(notBefore, 0); err != nil {
+				base.Fatalf("invalid -notbefore: %s", err)
+			}
+		}
+		if enable || disable {
+			op.Enabled = &enable
+		}
+		_, err = client.AlterInbound(ctx, &handlerService.AlterInboundRequest{
+			Tag:       tag,
+			Operation: cserial.ToTypedMessage(op),
+		})
+		if err == nil {
+			success += 1
+		} else {
+			fmt.Println(err)
+		}
+	}
+	fmt.Println("Updated", success, "user(s) in total.")
+}
+
+// parseUserTime parses a time in unix seconds. "+duration" is added to current, or to now if current has passed.
+func parseUserTime(value string, current int64) (int64, error) {
+	switch {
+	case value == "never" || value == "0":
+		return 0, nil
+	case strings.HasPrefix(value, "+"):
+		d, err := time.ParseDuration(value[1:])
+		if err != nil {
+			return 0, err
+		}
+		from := time.Now()
+		if current > from.Unix() {
+			from = time.Unix(current, 0)
+		}
+		return from.Add(d).Unix(), nil
+	}
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return seconds, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return 0, err
+	}
+	return t.Unix(), nil
+}
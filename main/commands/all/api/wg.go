@@ -0,0 +1,227 @@
+package api
+
+import (
+	"strings"
+
+	"github.com/xtls/xray-core/infra/conf"
+	"github.com/xtls/xray-core/main/commands/base"
+	wireguardService "github.com/xtls/xray-core/proxy/wireguard/command"
+)
+
+var cmdWireGuard = &base.Command{
+	UsageLine: "{{.Exec}} api wg",
+	Short:     "Manage peers of WireGuard inbounds",
+	Long: `{{.Exec}} {{.LongName}} manages peers of WireGuard inbounds at runtime.
+"WireGuardService" must be enabled in the api services.
+`,
+	Commands: []*base.Command{
+		cmdWireGuardPeers,
+		cmdWireGuardAddPeer,
+		cmdWireGuardRemovePeer,
+	},
+}
+
+var cmdWireGuardPeers = &base.Command{
+	CustomFlags: true,
+	UsageLine:   "{{.Exec}} api wg peers [--server=127.0.0.1:8080] -tag=tag",
+	Short:       "List peers of a WireGuard inbound",
+	Long: `
+List peers of a WireGuard inbound, with their endpoint, last handshake time (unix seconds),
+received and sent bytes and allowed IPs.
+
+Arguments:
+
+	-s, -server <server:port>
+		The API server address. Default 127.0.0.1:8080
+
+	-t, -timeout <seconds>
+		Timeout in seconds for calling API. Default 3
+
+	-tag
+		Inbound tag
+
+Example:
+
+	{{.Exec}} {{.LongName}} --server=127.0.0.1:8080 -tag="wg-in"
+`,
+	Run: executeWireGuardPeers,
+}
+
+func executeWireGuardPeers(cmd *base.Command, args []string) {
+	setSharedFlags(cmd)
+	var tag string
+	cmd.Flag.StringVar(&tag, "tag", "", "")
+	cmd.Flag.Parse(args)
+	if len(tag) < 1 {
+		base.Fatalf("inbound tag not specified")
+	}
+
+	conn, ctx, close := dialAPIServer()
+	defer close()
+
+	client := wireguardService.NewWireGuardServiceClient(conn)
+	resp, err := client.ListPeers(ctx, &wireguardService.ListPeersRequest{Tag: tag})
+	if err != nil {
+		base.Fatalf("failed to list peers: %s", err)
+	}
+	showJSONResponse(resp)
+}
+
+var cmdWireGuardAddPeer = &base.Command{
+	CustomFlags: true,
+	UsageLine:   "{{.Exec}} api wg add [--server=127.0.0.1:8080] -tag=tag -key=publicKey [-allowed=ip1,ip2]",
+	Short:       "Add a peer to a WireGuard inbound",
+	Long: `
+Add a peer to a WireGuard inbound. A peer of the same public key is updated.
+
+Arguments:
+
+	-s, -server <server:port>
+		The API server address. Default 127.0.0.1:8080
+
+	-t, -timeout <seconds>
+		Timeout in seconds for calling API. Default 3
+
+	-tag
+		Inbound tag
+
+	-key
+		Public key of the peer, in base64 or hex
+
+	-psk
+		Pre-shared key, in base64 or hex
+
+	-allowed
+		Comma separated allowed IPs. Default 0.0.0.0/0,::/0
+
+	-keepalive
+		Persistent keepalive interval in seconds
+
+	-email
+		Email of the peer
+
+	-level
+		User level of the peer
+
+Example:
+
+	{{.Exec}} {{.LongName}} --server=127.0.0.1:8080 -tag="wg-in" -key="<public key>" -allowed=10.0.0.2/32 -email="phone@love.com"
+`,
+	Run: executeWireGuardAddPeer,
+}
+
+func executeWireGuardAddPeer(cmd *base.Command, args []string) {
+	setSharedFlags(cmd)
+	var (
+		tag       string
+		peer      conf.WireGuardPeerConfig
+		allowedIP string
+		keepAlive uint
+		level     uint
+	)
+	cmd.Flag.StringVar(&tag, "tag", "", "")
+	cmd.Flag.StringVar(&peer.PublicKey, "key", "", "")
+	cmd.Flag.StringVar(&peer.PreSharedKey, "psk", "", "")
+	cmd.Flag.StringVar(&allowedIP, "allowed", "", "")
+	cmd.Flag.UintVar(&keepAlive, "keepalive", 0, "")
+	cmd.Flag.StringVar(&peer.Email, "email", "", "")
+	cmd.Flag.UintVar(&level, "level", 0, "")
+	cmd.Flag.Parse(args)
+	if len(tag) < 1 {
+		base.Fatalf("inbound tag not specified")
+	}
+	if len(peer.PublicKey) < 1 {
+		base.Fatalf("public key not specified")
+	}
+	if allowedIP != "" {
+		peer.AllowedIPs = strings.Split(allowedIP, ",")
+	}
+	peer.KeepAlive = uint32(keepAlive)
+	peer.Level = uint32(level)
+
+	config, err := peer.Build()
+	if err != nil {
+		base.Fatalf("invalid peer: %s", err)
+	}
+
+	conn, ctx, close := dialAPIServer()
+	defer close()
+
+	client := wireguardService.NewWireGuardServiceClient(conn)
+	resp, err := client.AddPeer(ctx, &wireguardService.AddPeerRequest{
+		Tag:   tag,
+		Email: peer.Email,
+		Level: peer.Level,
+		Peer:  config,
+	})
+	if err != nil {
+		base.Fatalf("failed to add peer: %s", err)
+	}
+	showJSONResponse(resp)
+}
+
+var cmdWireGuardRemovePeer = &base.Command{
+	CustomFlags: true,
+	UsageLine:   "{{.Exec}} api wg rm [--server=127.0.0.1:8080] -tag=tag [-key=publicKey] [-email=email]",
+	Short:       "Remove a peer from a WireGuard inbound",
+	Long: `
+Remove a peer from a WireGuard inbound, by its public key or email.
+
+Arguments:
+
+	-s, -server <server:port>
+		The API server address. Default 127.0.0.1:8080
+
+	-t, -timeout <seconds>
+		Timeout in seconds for calling API. Default 3
+
+	-tag
+		Inbound tag
+
+	-key
+		Public key of the peer, in base64 or hex
+
+	-email
+		Email of the peer
+
+Example:
+
+	{{.Exec}} {{.LongName}} --server=127.0.0.1:8080 -tag="wg-in" -email="phone@love.com"
+`,
+	Run: executeWireGuardRemovePeer,
+}
+
+func executeWireGuardRemovePeer(cmd *base.Command, args []string) {
+	setSharedFlags(cmd)
+	var tag, key, email string
+	cmd.Flag.StringVar(&tag, "tag", "", "")
+	cmd.Flag.StringVar(&key, "key", "", "")
+	cmd.Flag.StringVar(&email, "email", "", "")
+	cmd.Flag.Parse(args)
+	if len(tag) < 1 {
+		base.Fatalf("inbound tag not specified")
+	}
+	if key == "" && email == "" {
+		base.Fatalf("either public key or email must be specified")
+	}
+	if key != "" {
+		var err error
+		if key, err = conf.ParseWireGuardKey(key); err != nil {
+			base.Fatalf("invalid public key: %s", err)
+		}
+	}
+
+	conn, ctx, close := dialAPIServer()
+	defer close()
+
+	client := wireguardService.NewWireGuardServiceClient(conn)
+	resp, err := client.RemovePeer(ctx, &wireguardService.RemovePeerRequest{
+		Tag:       tag,
+		PublicKey: key,
+		Email:     email,
+	})
+	if err != nil {
+		base.Fatalf("failed to remove peer: %s", err)
+	}
+	showJSONResponse(resp)
+}
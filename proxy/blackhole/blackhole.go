@@ -37,15 +37,18 @@ func (h *Handler) Process(ctx context.Context, link *transport.Link, dialer inte
 	ob := outbounds[len(outbounds)-1]
 	ob.Name = "blackhole"
 
-	nBytes := h.response.WriteTo(link.Writer)
-	if nBytes > 0 {
-		// Sleep a little here to make sure the response is sent to client.
-		time.Sleep(time.Second)
+	// Responses are not ICMP packets, pings just time out.
+	if ob.Target.Network != net.Network_ICMP {
+		nBytes := h.response.WriteTo(link.Writer)
+		if nBytes > 0 {
+			// Sleep a little here to make sure the response is sent to client.
+			time.Sleep(time.Second)
+		}
 	}
 	defer common.Interrupt(link.Writer)
 	defer common.Interrupt(link.Reader)
 	// wait to drain all the possible incoming UDP data
-	if ob.Target.Network == net.Network_UDP {
+	if ob.Target.Network == net.Network_UDP || ob.Target.Network == net.Network_ICMP {
 		ctx, cancel := context.WithCancel(ctx)
 		timer := signal.CancelAfterInactivity(ctx, func() {
 			cancel()
@@ -56,6 +59,11 @@ func (h *Handler) Process(ctx context.Context, link *transport.Link, dialer inte
 	return nil
 }
 
+// CanForwardICMP implements proxy.ICMPForwarder.
+func (h *Handler) CanForwardICMP() bool {
+	return true
+}
+
 func init() {
 	common.Must(common.RegisterConfig((*Config)(nil), func(ctx context.Context, config interface{}) (interface{}, error) {
 		return New(ctx, config.(*Config))
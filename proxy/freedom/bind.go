@@ -0,0 +1,112 @@
+package freedom
+
+import (
+	"context"
+	"time"
+
+	"github.com/xtls/xray-core/common/buf"
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/session"
+	"github.com/xtls/xray-core/common/signal"
+	"github.com/xtls/xray-core/common/task"
+	"github.com/xtls/xray-core/transport"
+	"github.com/xtls/xray-core/transport/internet"
+)
+
+// CanBind implements proxy.Binder.
+func (h *Handler) CanBind() bool {
+	return true
+}
+
+// processBind serves the BIND request for target. It listens on the gateway of the outbound if any, so the
+// target sees the same address as the connections it receives, accepts the connection of the target, and
+// relays the link with it. Connections from other IPs, or blocked by the final rules, are rejected.
+func (h *Handler) processBind(ctx context.Context, link *transport.Link, bind *session.Bind, target net.Destination, gateway net.Address, defaultRule *FinalRule) error {
+	address := bind.Address
+	if gateway != nil {
+		address = gateway
+	}
+	var ip net.IP
+	if address != nil && address.Family().IsIP() {
+		ip = address.IP()
+	}
+	listener, err := internet.ListenSystem(ctx, &net.TCPAddr{IP: ip}, nil)
+	if err != nil {
+		return errors.New("failed to listen for BIND request").Base(err)
+	}
+	defer listener.Close()
+
+	bound := net.DestinationFromAddr(listener.Addr())
+	errors.LogInfo(ctx, "listening on ", bound, " for BIND request of ", target)
+	if err := bind.Bound(bound); err != nil {
+		return err
+	}
+
+	plcy := h.policy()
+	// The target is expected to connect within the idle timeout.
+	acceptTimer := time.AfterFunc(plcy.Timeouts.ConnectionIdle, func() {
+		listener.Close()
+	})
+	stop := context.AfterFunc(ctx, func() {
+		listener.Close()
+	})
+	var peer net.Conn
+	var from net.Destination
+	for {
+		peer, err = listener.Accept()
+		if err != nil {
+			break
+		}
+		from = net.DestinationFromAddr(peer.RemoteAddr())
+		if target.Address.Family().IsIP() && !target.Address.IP().IsUnspecified() && !target.Address.IP().Equal(from.Address.IP()) {
+			errors.LogInfo(ctx, "rejecting connection from ", from, " to BIND port ", bound)
+			peer.Close()
+			continue
+		}
+		if rule := h.matchFinalRule(net.Network_TCP, from.Address, from.Port, defaultRule); rule != nil && rule.action == RuleAction_Block {
+			errors.LogInfo(ctx, "blocked connection from ", from, " to BIND port ", bound)
+			peer.Close()
+			continue
+		}
+		break
+	}
+	acceptTimer.Stop()
+	stop()
+	listener.Close()
+	if err != nil {
+		return errors.New("failed to accept connection for BIND request").Base(err)
+	}
+	defer peer.Close()
+
+	errors.LogInfo(ctx, "accepted connection from ", from, " on BIND port ", bound)
+	if err := bind.Accepted(from); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	timer := signal.CancelAfterInactivity(ctx, cancel, plcy.Timeouts.ConnectionIdle)
+
+	requestDone := func() error {
+		defer timer.SetTimeout(plcy.Timeouts.DownlinkOnly)
+		if err := buf.Copy(link.Reader, buf.NewWriter(peer), buf.UpdateActivity(timer)); err != nil {
+			return errors.New("failed to process request").Base(err)
+		}
+		if cw, ok := peer.(interface{ CloseWrite() error }); ok {
+			cw.CloseWrite()
+		}
+		return nil
+	}
+	responseDone := func() error {
+		defer timer.SetTimeout(plcy.Timeouts.UplinkOnly)
+		if err := buf.Copy(buf.NewReader(peer), link.Writer, buf.UpdateActivity(timer)); err != nil {
+			return errors.New("failed to process response").Base(err)
+		}
+		return nil
+	}
+
+	if err := task.Run(ctx, requestDone, task.OnSuccess(responseDone, task.Close(link.Writer))); err != nil {
+		return errors.New("connection ends").Base(err)
+	}
+	return nil
+}
@@ -248,6 +248,11 @@ func (h *Handler) blockDelay(rule *FinalRule) time.Duration {
 	return time.Duration(min+uint64(dice.Roll(int(span+1)))) * time.Second
 }
 
+// CanForwardICMP implements proxy.ICMPForwarder.
+func (h *Handler) CanForwardICMP() bool {
+	return true
+}
+
 func isValidAddress(addr *net.IPOrDomain) bool {
 	if addr == nil {
 		return false
@@ -276,6 +281,9 @@ func (h *Handler) Process(ctx context.Context, link *transport.Link, dialer inte
 	}
 	dialer.SetOutboundGateway(ctx, ob)
 	outGateway := ob.Gateway
+	if bind := session.BindFromContext(ctx); bind != nil {
+		return h.processBind(ctx, link, bind, ob.Target, outGateway, defaultRule)
+	}
 	UDPOverride := net.UDPDestination(nil, 0)
 	if h.config.DestinationOverride != nil {
 		server := h.config.DestinationOverride.Server
@@ -375,7 +383,7 @@ func (h *Handler) Process(ctx context.Context, link *transport.Link, dialer inte
 		}
 		return nil
 	}
-	if h.config.ProxyProtocol > 0 && h.config.ProxyProtocol <= 2 {
+	if h.config.ProxyProtocol > 0 && h.config.ProxyProtocol <= 2 && destination.Network != net.Network_ICMP {
 		version := byte(h.config.ProxyProtocol)
 		srcAddr := inbound.Source.RawNetAddr()
 		dstAddr := conn.RemoteAddr()
@@ -407,7 +415,9 @@ func (h *Handler) Process(ctx context.Context, link *transport.Link, dialer inte
 		defer timer.SetTimeout(plcy.Timeouts.DownlinkOnly)
 
 		var writer buf.Writer
-		if destination.Network == net.Network_TCP {
+		if destination.Network == net.Network_ICMP {
+			writer = &buf.SequentialWriter{Writer: conn}
+		} else if destination.Network == net.Network_TCP {
 			if h.config.Fragment != nil {
 				errors.LogDebug(ctx, "FRAGMENT", h.config.Fragment.PacketsFrom, h.config.Fragment.PacketsTo, h.config.Fragment.LengthMin, h.config.Fragment.LengthMax,
 					h.config.Fragment.IntervalMin, h.config.Fragment.IntervalMax, h.config.Fragment.MaxSplitMin, h.config.Fragment.MaxSplitMax)
@@ -451,7 +461,9 @@ func (h *Handler) Process(ctx context.Context, link *transport.Link, dialer inte
 			return proxy.CopyRawConnIfExist(ctx, conn, writeConn, link.Writer, timer, inTimer)
 		}
 		var reader buf.Reader
-		if destination.Network == net.Network_TCP {
+		if destination.Network == net.Network_ICMP {
+			reader = &buf.PacketReader{Reader: conn}
+		} else if destination.Network == net.Network_TCP {
 			reader = buf.NewReader(conn)
 		} else {
 			reader = NewPacketReader(conn, h, defaultRule, UDPOverride, destination)
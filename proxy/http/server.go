@@ -22,6 +22,7 @@ import (
 	"github.com/xtls/xray-core/core"
 	"github.com/xtls/xray-core/features/policy"
 	"github.com/xtls/xray-core/features/routing"
+	"github.com/xtls/xray-core/features/stats"
 	"github.com/xtls/xray-core/proxy"
 	"github.com/xtls/xray-core/transport"
 	"github.com/xtls/xray-core/transport/internet/stat"
@@ -31,6 +32,7 @@ import (
 type Server struct {
 	config        *ServerConfig
 	policyManager policy.Manager
+	stats         stats.Manager
 }
 
 // NewServer creates a new HTTP inbound handler.
@@ -39,11 +41,33 @@ func NewServer(ctx context.Context, config *ServerConfig) (*Server, error) {
 	s := &Server{
 		config:        config,
 		policyManager: v.GetFeature(policy.ManagerType()).(policy.Manager),
+		stats:         v.GetFeature(stats.ManagerType()).(stats.Manager),
+	}
+	if config.ExtendedConnect {
+		if err := enableExtendedConnect(); err != nil {
+			return nil, err
+		}
 	}
 
 	return s, nil
 }
 
+// authFailed publishes the authentication failure of a request with wrong credentials. Requests without
+// any are not counted, as clients usually send one first to be challenged for them.
+func (s *Server) authFailed(ctx context.Context, authorization string) {
+	if authorization == "" {
+		return
+	}
+	if inbound := session.InboundFromContext(ctx); inbound != nil {
+		stats.PublishAuthFailure(s.stats, stats.AuthFailure{
+			InboundTag: inbound.Tag,
+			Protocol:   "http",
+			Source:     inbound.Source.Address,
+			Reason:     "invalid_password",
+		})
+	}
+}
+
 func (s *Server) policy() policy.Session {
 	config := s.config
 	p := s.policyManager.ForLevel(config.UserLevel)
@@ -108,6 +132,13 @@ func (s *Server) ProcessWithFirstbyte(ctx context.Context, network net.Network,
 		reader = bufio.NewReaderSize(readerOnly{conn}, buf.Size)
 	}
 
+	if err := conn.SetReadDeadline(time.Now().Add(s.policy().Timeouts.Handshake)); err != nil {
+		errors.LogInfoInner(ctx, err, "failed to set read deadline")
+	}
+	if isHTTP2(reader) {
+		return s.serveHTTP2(ctx, conn, reader, dispatcher)
+	}
+
 Start:
 	if err := conn.SetReadDeadline(time.Now().Add(s.policy().Timeouts.Handshake)); err != nil {
 		errors.LogInfoInner(ctx, err, "failed to set read deadline")
@@ -123,8 +154,10 @@ Start:
 	}
 
 	if len(s.config.Accounts) > 0 {
-		user, pass, ok := parseBasicAuth(request.Header.Get("Proxy-Authorization"))
+		authorization := request.Header.Get("Proxy-Authorization")
+		user, pass, ok := parseBasicAuth(authorization)
 		if !ok || !s.config.HasAccount(user, pass) {
+			s.authFailed(ctx, authorization)
 			return common.Error2(conn.Write([]byte("HTTP/1.1 407 Proxy Authentication Required\r\nProxy-Authenticate: Basic realm=\"proxy\"\r\n\r\n")))
 		}
 		if inbound != nil {
@@ -0,0 +1,274 @@
+package http
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/apernet/quic-go/quicvarint"
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/buf"
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/log"
+	"github.com/xtls/xray-core/common/net"
+	http_proto "github.com/xtls/xray-core/common/protocol/http"
+	"github.com/xtls/xray-core/common/session"
+	"github.com/xtls/xray-core/common/task"
+	"github.com/xtls/xray-core/features/routing"
+	"github.com/xtls/xray-core/transport"
+	"github.com/xtls/xray-core/transport/internet/stat"
+	"golang.org/x/net/http2"
+)
+
+// isHTTP2 reports whether the connection starts with the HTTP/2 client preface,
+// i.e. h2 negotiated by ALPN over TLS, or h2c with prior knowledge.
+func isHTTP2(reader *bufio.Reader) bool {
+	// Peek byte by byte, so short HTTP/1 requests are not blocked waiting for more data
+	for n := 1; n <= len(http2.ClientPreface); n++ {
+		b, err := reader.Peek(n)
+		if err != nil || b[n-1] != http2.ClientPreface[n-1] {
+			return false
+		}
+	}
+	return true
+}
+
+// bufferedConn reads from the reader that peeked the client preface.
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.reader.Read(b)
+}
+
+// serveHTTP2 serves an HTTP/2 connection. Every stream is dispatched on its own. CONNECT-UDP streams need
+// extended CONNECT, which is only accepted if the config enables it.
+func (s *Server) serveHTTP2(ctx context.Context, conn stat.Connection, reader *bufio.Reader, dispatcher routing.Dispatcher) error {
+	if err := conn.SetReadDeadline(time.Time{}); err != nil {
+		errors.LogDebugInner(ctx, err, "failed to clear read deadline")
+	}
+	errors.LogDebug(ctx, "serving HTTP/2")
+
+	server := &http2.Server{
+		IdleTimeout: s.policy().Timeouts.ConnectionIdle,
+	}
+	server.ServeConn(&bufferedConn{Conn: conn, reader: reader}, &http2.ServeConnOpts{
+		Context: ctx,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			s.handleHTTP2Stream(w, r, conn, dispatcher)
+		}),
+	})
+	return nil
+}
+
+func (s *Server) handleHTTP2Stream(w http.ResponseWriter, r *http.Request, conn stat.Connection, dispatcher routing.Dispatcher) {
+	ctx := session.SubContextFromMuxInbound(r.Context())
+	if inbound := session.InboundFromContext(ctx); inbound != nil {
+		newInbound := *inbound
+		if inbound.User != nil {
+			user := *inbound.User
+			newInbound.User = &user
+		}
+		newInbound.CanSpliceCopy = 3
+		ctx = session.ContextWithInbound(ctx, &newInbound)
+	}
+	inbound := session.InboundFromContext(ctx)
+
+	if len(s.config.Accounts) > 0 {
+		authorization := r.Header.Get("Proxy-Authorization")
+		user, pass, ok := parseBasicAuth(authorization)
+		if !ok || !s.config.HasAccount(user, pass) {
+			s.authFailed(ctx, authorization)
+			w.Header().Set("Proxy-Authenticate", `Basic realm="proxy"`)
+			w.WriteHeader(http.StatusProxyAuthRequired)
+			return
+		}
+		if inbound != nil && inbound.User != nil {
+			inbound.User.Email = user
+		}
+	}
+
+	protocol := r.Header.Get(":protocol")
+	errors.LogInfo(ctx, "HTTP/2 request to Method [", r.Method, "] Protocol [", protocol, "] Host [", r.Host, "] with URL [", r.URL, "]")
+
+	var dest net.Destination
+	var err error
+	switch {
+	case r.Method != http.MethodConnect:
+		dest, err = http_proto.ParseHost(r.Host, net.Port(80))
+	case protocol == "":
+		dest, err = http_proto.ParseHost(r.Host, net.Port(443))
+	case protocol == http_proto.ProtocolConnectUDP && s.config.ExtendedConnect:
+		dest, err = http_proto.ParseConnectUDPPath(r.URL.EscapedPath())
+	default:
+		w.WriteHeader(http.StatusNotImplemented)
+		return
+	}
+	if err != nil {
+		errors.LogInfoInner(ctx, err, "malformed proxy request")
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	msg := &log.AccessMessage{
+		From:   conn.RemoteAddr(),
+		To:     dest,
+		Status: log.AccessAccepted,
+		Reason: "",
+	}
+	if inbound != nil && inbound.User != nil {
+		msg.Email = inbound.User.Email
+	}
+	ctx = log.ContextWithAccessMessage(ctx, msg)
+
+	if r.Method != http.MethodConnect {
+		err = s.handlePlainHTTP2(ctx, w, r, dest, dispatcher)
+	} else {
+		if protocol == http_proto.ProtocolConnectUDP {
+			w.Header().Set("Capsule-Protocol", "?1")
+		}
+		w.WriteHeader(http.StatusOK)
+		writer := &flushWriter{ResponseWriter: w, controller: http.NewResponseController(w)}
+		if err = writer.controller.Flush(); err != nil {
+			errors.LogInfoInner(ctx, err, "failed to write back OK response")
+			return
+		}
+		link := &transport.Link{
+			Reader: buf.NewReader(r.Body),
+			Writer: buf.NewWriter(writer),
+		}
+		if protocol == http_proto.ProtocolConnectUDP {
+			link = &transport.Link{
+				Reader: &capsuleReader{reader: quicvarint.NewReader(r.Body)},
+				Writer: &capsuleWriter{writer: writer},
+			}
+		}
+		err = dispatcher.DispatchLink(ctx, dest, link)
+	}
+	if err != nil {
+		errors.LogInfoInner(ctx, err, "failed to process HTTP/2 request")
+	}
+}
+
+func (s *Server) handlePlainHTTP2(ctx context.Context, w http.ResponseWriter, r *http.Request, dest net.Destination, dispatcher routing.Dispatcher) error {
+	content := session.ContentFromContext(ctx)
+	content.SetAttribute(":method", strings.ToUpper(r.Method))
+	content.SetAttribute(":path", r.URL.Path)
+	for key := range r.Header {
+		content.SetAttribute(strings.ToLower(key), r.Header.Get(key))
+	}
+
+	link, err := dispatcher.Dispatch(ctx, dest)
+	if err != nil {
+		w.WriteHeader(http.StatusBadGateway)
+		return err
+	}
+
+	request := &http.Request{
+		Method:        r.Method,
+		URL:           &url.URL{Path: r.URL.Path, RawPath: r.URL.RawPath, RawQuery: r.URL.RawQuery},
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        r.Header.Clone(),
+		Body:          r.Body,
+		ContentLength: r.ContentLength,
+		Host:          r.Host,
+	}
+	http_proto.RemoveHopByHopHeaders(request.Header)
+	request.Header.Del("Proxy-Authorization")
+	request.Header.Set("Connection", "close")
+	// Prevent UA from being set to golang's default ones
+	if request.Header.Get("User-Agent") == "" {
+		request.Header.Set("User-Agent", "")
+	}
+
+	requestDone := func() error {
+		defer common.Close(link.Writer)
+		requestWriter := buf.NewBufferedWriter(link.Writer)
+		common.Must(requestWriter.SetBuffered(false))
+		if err := request.Write(requestWriter); err != nil {
+			return errors.New("failed to write whole request").Base(err).AtWarning()
+		}
+		return nil
+	}
+
+	responseDone := func() error {
+		response, err := http.ReadResponse(bufio.NewReaderSize(&buf.BufferedReader{Reader: link.Reader}, buf.Size), request)
+		if err != nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return errors.New("failed to read response from ", r.Host).Base(err).AtWarning()
+		}
+		defer response.Body.Close()
+		http_proto.RemoveHopByHopHeaders(response.Header)
+		for key, values := range response.Header {
+			w.Header()[key] = values
+		}
+		w.WriteHeader(response.StatusCode)
+		if _, err := io.Copy(w, response.Body); err != nil {
+			return errors.New("failed to write response").Base(err).AtWarning()
+		}
+		return nil
+	}
+
+	if err := task.Run(ctx, requestDone, responseDone); err != nil {
+		common.Interrupt(link.Reader)
+		common.Interrupt(link.Writer)
+		return errors.New("connection ends").Base(err)
+	}
+	return nil
+}
+
+// flushWriter flushes every write to the HTTP/2 stream, so tunneled data is not delayed.
+type flushWriter struct {
+	http.ResponseWriter
+	controller *http.ResponseController
+}
+
+func (w *flushWriter) Write(b []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(b)
+	if err == nil {
+		err = w.controller.Flush()
+	}
+	return n, err
+}
+
+// capsuleReader reads UDP payload of CONNECT-UDP from DATAGRAM capsules.
+type capsuleReader struct {
+	reader quicvarint.Reader
+}
+
+func (r *capsuleReader) ReadMultiBuffer() (buf.MultiBuffer, error) {
+	for {
+		b, err := http_proto.ReadDatagramCapsule(r.reader)
+		if err != nil {
+			return nil, err
+		}
+		if !http_proto.ReadContextID(b) {
+			b.Release()
+			continue
+		}
+		return buf.MultiBuffer{b}, nil
+	}
+}
+
+// capsuleWriter writes UDP payload of CONNECT-UDP in DATAGRAM capsules.
+type capsuleWriter struct {
+	writer io.Writer
+}
+
+func (w *capsuleWriter) WriteMultiBuffer(mb buf.MultiBuffer) error {
+	defer buf.ReleaseMulti(mb)
+	for _, b := range mb {
+		if err := http_proto.WriteUDPDatagramCapsule(w.writer, b.Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
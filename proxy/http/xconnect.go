@@ -0,0 +1,27 @@
+//go:build !go1.27 || http2legacy
+
+package http
+
+import (
+	"sync"
+	_ "unsafe" // required to use go:linkname
+)
+
+// disableExtendedConnectProtocol is set by golang.org/x/net/http2 from GODEBUG=http2xconnect=1 when it is
+// initialized, it is the only switch of extended CONNECT.
+//
+//go:linkname disableExtendedConnectProtocol golang.org/x/net/http2.disableExtendedConnectProtocol
+var disableExtendedConnectProtocol bool
+
+var extendedConnectOnce sync.Once
+
+// enableExtendedConnect enables extended CONNECT of the servers of golang.org/x/net/http2, without changing
+// the environment of the process. Only the HTTP inbound serves HTTP/2 with them, the other transports use
+// the HTTP/2 of net/http, which keeps it disabled. The inbounds not enabling it still reject the requests
+// with a :protocol they get then.
+func enableExtendedConnect() error {
+	extendedConnectOnce.Do(func() {
+		disableExtendedConnectProtocol = false
+	})
+	return nil
+}
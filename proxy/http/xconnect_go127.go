@@ -0,0 +1,20 @@
+//go:build go1.27 && !http2legacy
+
+package http
+
+import (
+	"os"
+	"strings"
+
+	"github.com/xtls/xray-core/common/errors"
+)
+
+// enableExtendedConnect checks that extended CONNECT is enabled. From Go 1.27 the servers of
+// golang.org/x/net/http2 are those of net/http, shared with the other transports, which only enable it by
+// GODEBUG=http2xconnect=1 when initialized. So it is up to the operator to set it for the process.
+func enableExtendedConnect() error {
+	if !strings.Contains(os.Getenv("GODEBUG"), "http2xconnect=1") {
+		return errors.New("extended CONNECT needs GODEBUG=http2xconnect=1 when built with Go 1.27 or later")
+	}
+	return nil
+}
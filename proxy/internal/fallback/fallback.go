@@ -0,0 +1,244 @@
+// Package fallback contains the parts of the fallbacks of VLESS and Trojan inbounds which they share,
+// matching by the HTTP request, choosing weighted dests and writing PROXY protocol headers.
+package fallback
+
+import (
+	"bytes"
+	"net/textproto"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/xtls/xray-core/common/buf"
+	"github.com/xtls/xray-core/common/dice"
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/net"
+)
+
+// Request is the HTTP/1 request at the beginning of a connection falling back, as far as its first read contains.
+type Request struct {
+	Path   string
+	Host   string
+	Header textproto.MIMEHeader
+}
+
+// ParseRequest parses the request line and the complete header lines in b. It returns nil if b doesn't start
+// with an HTTP/1 request line, so the rules with a host or headers never match HTTP/2 connections, such as
+// those which negotiated "h2".
+func ParseRequest(b []byte) *Request {
+	line, b, ok := bytes.Cut(b, []byte("\n"))
+	if !ok {
+		return nil
+	}
+	s := strings.Split(string(bytes.TrimSuffix(line, []byte("\r"))), " ")
+	if len(s) != 3 || !strings.HasPrefix(s[2], "HTTP/1.") {
+		return nil
+	}
+	path, _, _ := strings.Cut(s[1], "?")
+	request := &Request{
+		Path:   path,
+		Header: make(textproto.MIMEHeader),
+	}
+	for {
+		if line, b, ok = bytes.Cut(b, []byte("\n")); !ok {
+			break // the rest is not complete
+		}
+		line = bytes.TrimSuffix(line, []byte("\r"))
+		if len(line) == 0 {
+			break
+		}
+		name, value, ok := bytes.Cut(line, []byte(":"))
+		if !ok {
+			break
+		}
+		request.Header.Add(textproto.CanonicalMIMEHeaderKey(string(name)), string(bytes.TrimSpace(value)))
+	}
+	request.Host = strings.ToLower(request.Header.Get("Host"))
+	if host, _, err := net.SplitHostPort(request.Host); err == nil {
+		request.Host = host
+	}
+	return request
+}
+
+// Rule matches a fallback by the SNI and ALPN of the connection, and the HTTP/1 request in it.
+type Rule struct {
+	Name    string
+	Alpn    string
+	Path    string
+	Host    string
+	Headers map[string]*regexp.Regexp
+}
+
+// NewRule creates a Rule, with the headers mapping header names to the regexps their values must match.
+func NewRule(name, alpn, path, host string, headers map[string]string) (*Rule, error) {
+	r := &Rule{
+		Name:    strings.ToLower(name),
+		Alpn:    strings.ToLower(alpn),
+		Path:    path,
+		Host:    strings.ToLower(host),
+		Headers: make(map[string]*regexp.Regexp, len(headers)),
+	}
+	for k, v := range headers {
+		re, err := regexp.Compile(v)
+		if err != nil {
+			return nil, errors.New("invalid regexp of header ", k).Base(err)
+		}
+		r.Headers[textproto.CanonicalMIMEHeaderKey(k)] = re
+	}
+	return r, nil
+}
+
+// Match returns whether the connection of the name and alpn, with the request or nil, matches the rule.
+// Like the fallbacks without a host or headers, the rule matches the names containing its name.
+func (r *Rule) Match(name, alpn string, request *Request) bool {
+	if request == nil {
+		return false
+	}
+	if r.Name != "" && !strings.Contains(name, r.Name) {
+		return false
+	}
+	if r.Alpn != "" && alpn != r.Alpn {
+		return false
+	}
+	if r.Path != "" && request.Path != r.Path {
+		return false
+	}
+	if r.Host != "" && request.Host != r.Host {
+		return false
+	}
+	for k, re := range r.Headers {
+		if !matchAny(re, request.Header.Values(k)) {
+			return false
+		}
+	}
+	return true
+}
+
+func matchAny(re *regexp.Regexp, values []string) bool {
+	for _, v := range values {
+		if re.MatchString(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// Dest is a weighted dest of a fallback.
+type Dest interface {
+	GetType() string
+	GetDest() string
+	GetWeight() uint32
+}
+
+// Pick returns one of the dests, at random by their weights, which are 1 if unset.
+func Pick[D Dest](dests []D) D {
+	weight := func(d D) int64 {
+		if w := d.GetWeight(); w > 0 {
+			return int64(w)
+		}
+		return 1
+	}
+	var total int64
+	for _, d := range dests {
+		total += weight(d)
+	}
+	n := dice.RollInt63n(total)
+	for _, d := range dests {
+		if n -= weight(d); n < 0 {
+			return d
+		}
+	}
+	return dests[len(dests)-1]
+}
+
+// Types of the TLVs of PROXY protocol v2.
+const (
+	TLVTypeALPN      = 0x01
+	TLVTypeAuthority = 0x02
+	// TLVTypeUser is of the custom range, and carries the email of the user the connection was
+	// authenticated as before falling back, such as an expired one.
+	TLVTypeUser = 0xE0
+)
+
+// TLV is a type-length-value of PROXY protocol v2.
+type TLV struct {
+	Type  byte
+	Value string
+}
+
+// TLVs returns the TLVs of the SNI, ALPN and user email which are not empty.
+func TLVs(name, alpn, user string) []TLV {
+	var tlvs []TLV
+	if alpn != "" {
+		tlvs = append(tlvs, TLV{Type: TLVTypeALPN, Value: alpn})
+	}
+	if name != "" {
+		tlvs = append(tlvs, TLV{Type: TLVTypeAuthority, Value: name})
+	}
+	if user != "" {
+		tlvs = append(tlvs, TLV{Type: TLVTypeUser, Value: user})
+	}
+	return tlvs
+}
+
+// WriteProxyHeader writes the PROXY protocol header of version xver for the connection from remote to local.
+// The TLVs are only written in version 2.
+func WriteProxyHeader(b *buf.Buffer, xver uint64, remote net.Addr, local net.Addr, tlvs []TLV) error {
+	ipType := 4
+	remoteAddr, remotePort, err := net.SplitHostPort(remote.String())
+	if err != nil {
+		ipType = 0
+	}
+	localAddr, localPort, err := net.SplitHostPort(local.String())
+	if err != nil {
+		ipType = 0
+	}
+	if ipType == 4 && strings.Contains(remoteAddr, ":") {
+		ipType = 6
+	}
+	switch xver {
+	case 1:
+		if ipType == 0 {
+			_, err = b.WriteString("PROXY UNKNOWN\r\n")
+			return err
+		}
+		if ipType == 4 {
+			_, err = b.WriteString("PROXY TCP4 " + remoteAddr + " " + localAddr + " " + remotePort + " " + localPort + "\r\n")
+		} else {
+			_, err = b.WriteString("PROXY TCP6 " + remoteAddr + " " + localAddr + " " + remotePort + " " + localPort + "\r\n")
+		}
+		return err
+	case 2:
+		b.Write([]byte("\x0D\x0A\x0D\x0A\x00\x0D\x0A\x51\x55\x49\x54\x0A")) // signature
+		// addresses and TLVs
+		var value []byte
+		switch ipType {
+		case 0:
+			b.Write([]byte{0x20, 0x00}) // v2 + LOCAL + UNSPEC + UNSPEC
+		case 4:
+			b.Write([]byte{0x21, 0x11}) // v2 + PROXY + AF_INET + STREAM
+			value = append(value, net.ParseIP(remoteAddr).To4()...)
+			value = append(value, net.ParseIP(localAddr).To4()...)
+		default:
+			b.Write([]byte{0x21, 0x21}) // v2 + PROXY + AF_INET6 + STREAM
+			value = append(value, net.ParseIP(remoteAddr).To16()...)
+			value = append(value, net.ParseIP(localAddr).To16()...)
+		}
+		if ipType != 0 {
+			p1, _ := strconv.ParseUint(remotePort, 10, 16)
+			p2, _ := strconv.ParseUint(localPort, 10, 16)
+			value = append(value, byte(p1>>8), byte(p1), byte(p2>>8), byte(p2))
+		}
+		for _, tlv := range tlvs {
+			value = append(value, tlv.Type, byte(len(tlv.Value)>>8), byte(len(tlv.Value)))
+			value = append(value, tlv.Value...)
+		}
+		if len(value) > 65535 {
+			return errors.New("PROXY protocol header is too long: ", len(value), " bytes")
+		}
+		b.Write([]byte{byte(len(value) >> 8), byte(len(value))})
+		_, err = b.Write(value)
+		return err
+	}
+	return errors.New("invalid PROXY protocol version ", xver)
+}
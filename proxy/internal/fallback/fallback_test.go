@@ -0,0 +1,178 @@
+package fallback_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/buf"
+	"github.com/xtls/xray-core/common/net"
+	. "github.com/xtls/xray-core/proxy/internal/fallback"
+)
+
+func TestRule(t *testing.T) {
+	request := ParseRequest([]byte("GET /api/v1?q=1 HTTP/1.1\r\nHost: Example.COM:8443\r\nUser-Agent: curl/8.0\r\nx-token: abc\r\nAccept: text/"))
+	if request == nil || request.Path != "/api/v1" || request.Host != "example.com" {
+		t.Fatal("unexpected request: ", request)
+	}
+	if request.Header.Get("Accept") != "" {
+		t.Error("incomplete header line parsed")
+	}
+	if ParseRequest([]byte("PRI * HTTP/2.0\r\n\r\nSM\r\n\r\n")) != nil || ParseRequest([]byte{0, 1, 2}) != nil {
+		t.Error("parsed non HTTP/1 request")
+	}
+
+	cases := []struct {
+		name    string
+		alpn    string
+		path    string
+		host    string
+		headers map[string]string
+		match   bool
+	}{
+		{host: "example.com", match: true},
+		{host: "example.org", match: false},
+		{name: "example", alpn: "http/1.1", host: "example.com", match: true},
+		{name: "other", host: "example.com", match: false},
+		{alpn: "h2", host: "example.com", match: false},
+		{path: "/api/v1", headers: map[string]string{"user-agent": "^curl/"}, match: true},
+		{path: "/api/v2", headers: map[string]string{"user-agent": "^curl/"}, match: false},
+		{headers: map[string]string{"X-Token": "^abc$", "User-Agent": "curl"}, match: true},
+		{headers: map[string]string{"X-Token": "^abd$"}, match: false},
+		{headers: map[string]string{"X-Missing": ".*"}, match: false},
+	}
+	for i, c := range cases {
+		rule := common.Must2(NewRule(c.name, c.alpn, c.path, c.host, c.headers))
+		if rule.Match("www.example.com", "http/1.1", request) != c.match {
+			t.Error("case ", i, " does not match ", c.match)
+		}
+		if rule.Match("www.example.com", "http/1.1", nil) {
+			t.Error("case ", i, " matches no request")
+		}
+	}
+	if _, err := NewRule("", "", "", "", map[string]string{"X-Token": "("}); err == nil {
+		t.Error("invalid regexp accepted")
+	}
+}
+
+type fallback struct {
+	name, alpn, path, host string
+	headers                map[string]string
+}
+
+func (f *fallback) GetName() string               { return f.name }
+func (f *fallback) GetAlpn() string               { return f.alpn }
+func (f *fallback) GetPath() string               { return f.path }
+func (f *fallback) GetHost() string               { return f.host }
+func (f *fallback) GetHeaders() map[string]string { return f.headers }
+
+func TestMatcher(t *testing.T) {
+	fallbacks := []*fallback{
+		{},
+		{path: "/ws"},
+		{alpn: "h2"},
+		{name: "example.com", path: "/ws"},
+		{host: "admin.example.com"},
+	}
+	m := common.Must2(NewMatcher(fallbacks))
+
+	cases := []struct {
+		name, alpn, request string
+		fallback            *fallback
+	}{
+		{request: "GET / HTTP/1.1\r\nHost: www.example.com\r\n\r\n", fallback: fallbacks[0]},
+		{request: "GET /ws?ed=2048 HTTP/1.1\r\nHost: www.example.com\r\n\r\n", fallback: fallbacks[1]},
+		{alpn: "h2", request: "PRI * HTTP/2.0\r\n\r\nSM\r\n\r\n", fallback: fallbacks[2]},
+		{name: "www.example.com", alpn: "http/1.1", request: "GET /ws HTTP/1.1\r\n\r\n", fallback: fallbacks[3]},
+		{name: "www.example.org", request: "GET /ws HTTP/1.1\r\n\r\n", fallback: fallbacks[1]},
+		{name: "www.example.com", request: "GET /ws HTTP/1.1\r\nHost: admin.example.com\r\n\r\n", fallback: fallbacks[4]},
+	}
+	for i, c := range cases {
+		first := buf.New()
+		first.WriteString(c.request)
+		fb, err := m.Find(context.Background(), c.name, c.alpn, first, int64(first.Len()))
+		first.Release()
+		if err != nil || fb != c.fallback {
+			t.Error("case ", i, " found ", fb, " ", err)
+		}
+	}
+
+	if _, err := common.Must2(NewMatcher([]*fallback{{path: "/ws"}})).Find(context.Background(), "", "", buf.New(), 0); err == nil {
+		t.Error("found a fallback without a default")
+	}
+}
+
+type dest struct {
+	dest   string
+	weight uint32
+}
+
+func (d *dest) GetType() string   { return "tcp" }
+func (d *dest) GetDest() string   { return d.dest }
+func (d *dest) GetWeight() uint32 { return d.weight }
+
+func TestPick(t *testing.T) {
+	dests := []*dest{{dest: "a", weight: 3}, {dest: "b"}, {dest: "c", weight: 0}}
+	count := make(map[string]int)
+	for i := 0; i < 5000; i++ {
+		count[Pick(dests).GetDest()]++
+	}
+	if count["a"] < 2500 || count["b"] < 700 || count["c"] < 700 {
+		t.Error("unexpected picks: ", count)
+	}
+}
+
+func TestWriteProxyHeader(t *testing.T) {
+	remote := &net.TCPAddr{IP: net.ParseIP("192.0.2.1"), Port: 1234}
+	local := &net.TCPAddr{IP: net.ParseIP("192.0.2.2"), Port: 443}
+	signature := []byte("\x0D\x0A\x0D\x0A\x00\x0D\x0A\x51\x55\x49\x54\x0A")
+
+	b := buf.New()
+	defer b.Release()
+	common.Must(WriteProxyHeader(b, 1, remote, local, TLVs("example.com", "h2", "")))
+	if b.String() != "PROXY TCP4 192.0.2.1 192.0.2.2 1234 443\r\n" {
+		t.Error("unexpected v1 header: ", b.String())
+	}
+
+	b.Clear()
+	common.Must(WriteProxyHeader(b, 2, remote, local, nil))
+	expected := append(append([]byte{}, signature...), 0x21, 0x11, 0x00, 0x0C, 192, 0, 2, 1, 192, 0, 2, 2, 0x04, 0xD2, 0x01, 0xBB)
+	if !bytes.Equal(b.Bytes(), expected) {
+		t.Errorf("unexpected v2 header: %x", b.Bytes())
+	}
+
+	b.Clear()
+	common.Must(WriteProxyHeader(b, 2, remote, local, TLVs("example.com", "h2", "love@example.com")))
+	expected = append(append([]byte{}, signature...), 0x21, 0x11, 0x00, 12+5+14+19, 192, 0, 2, 1, 192, 0, 2, 2, 0x04, 0xD2, 0x01, 0xBB)
+	expected = append(expected, TLVTypeALPN, 0, 2, 'h', '2')
+	expected = append(expected, TLVTypeAuthority, 0, 11)
+	expected = append(expected, "example.com"...)
+	expected = append(expected, TLVTypeUser, 0, 16)
+	expected = append(expected, "love@example.com"...)
+	if !bytes.Equal(b.Bytes(), expected) {
+		t.Errorf("unexpected v2 header with TLVs: %x", b.Bytes())
+	}
+
+	b.Clear()
+	user := strings.Repeat("u", 300) + "@example.com"
+	common.Must(WriteProxyHeader(b, 2, remote, local, TLVs("", "", user)))
+	expected = append(append([]byte{}, signature...), 0x21, 0x11, byte((12+3+len(user))>>8), byte(12+3+len(user)), 192, 0, 2, 1, 192, 0, 2, 2, 0x04, 0xD2, 0x01, 0xBB)
+	expected = append(expected, TLVTypeUser, byte(len(user)>>8), byte(len(user)))
+	expected = append(expected, user...)
+	if !bytes.Equal(b.Bytes(), expected) {
+		t.Errorf("unexpected v2 header with a long TLV: %x", b.Bytes())
+	}
+
+	b.Clear()
+	if err := WriteProxyHeader(b, 2, remote, local, TLVs("", "", strings.Repeat("u", 65536))); err == nil {
+		t.Error("expected a header longer than 65535 bytes to fail")
+	}
+
+	b.Clear()
+	common.Must(WriteProxyHeader(b, 2, &net.UnixAddr{Name: "@"}, &net.UnixAddr{Name: "@"}, nil))
+	if !bytes.Equal(b.Bytes(), append(append([]byte{}, signature...), 0x20, 0x00, 0x00, 0x00)) {
+		t.Errorf("unexpected v2 LOCAL header: %x", b.Bytes())
+	}
+}
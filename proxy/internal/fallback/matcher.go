@@ -0,0 +1,190 @@
+package fallback
+
+import (
+	"context"
+	"strings"
+
+	"github.com/xtls/xray-core/common/buf"
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/retry"
+)
+
+// Fallback is the config of a fallback of VLESS or Trojan.
+type Fallback interface {
+	GetName() string
+	GetAlpn() string
+	GetPath() string
+	GetHost() string
+	GetHeaders() map[string]string
+}
+
+type rule[F Fallback] struct {
+	*Rule
+	fb F
+}
+
+// Matcher finds the fallback of a connection. The fallbacks with a host or headers are matched first, in order,
+// then the others by the name, alpn and path.
+type Matcher[F Fallback] struct {
+	rules []rule[F]
+	napfb map[string]map[string]map[string]F
+}
+
+// NewMatcher creates a Matcher of the fallbacks.
+func NewMatcher[F Fallback](fallbacks []F) (*Matcher[F], error) {
+	m := &Matcher[F]{
+		napfb: make(map[string]map[string]map[string]F),
+	}
+	for _, fb := range fallbacks {
+		if fb.GetHost() != "" || len(fb.GetHeaders()) > 0 {
+			r, err := NewRule(fb.GetName(), fb.GetAlpn(), fb.GetPath(), fb.GetHost(), fb.GetHeaders())
+			if err != nil {
+				return nil, err
+			}
+			m.rules = append(m.rules, rule[F]{Rule: r, fb: fb})
+			continue
+		}
+		if m.napfb[fb.GetName()] == nil {
+			m.napfb[fb.GetName()] = make(map[string]map[string]F)
+		}
+		if m.napfb[fb.GetName()][fb.GetAlpn()] == nil {
+			m.napfb[fb.GetName()][fb.GetAlpn()] = make(map[string]F)
+		}
+		m.napfb[fb.GetName()][fb.GetAlpn()][fb.GetPath()] = fb
+	}
+	if m.napfb[""] != nil {
+		for name, apfb := range m.napfb {
+			if name != "" {
+				for alpn := range m.napfb[""] {
+					if apfb[alpn] == nil {
+						apfb[alpn] = make(map[string]F)
+					}
+				}
+			}
+		}
+	}
+	for _, apfb := range m.napfb {
+		if apfb[""] != nil {
+			for alpn, pfb := range apfb {
+				if alpn != "" { // && alpn != "h2" {
+					for path, fb := range apfb[""] {
+						if _, found := pfb[path]; !found {
+							pfb[path] = fb
+						}
+					}
+				}
+			}
+		}
+	}
+	if m.napfb[""] != nil {
+		for name, apfb := range m.napfb {
+			if name != "" {
+				for alpn, pfb := range m.napfb[""] {
+					for path, fb := range pfb {
+						if _, found := apfb[alpn][path]; !found {
+							apfb[alpn][path] = fb
+						}
+					}
+				}
+			}
+		}
+	}
+	return m, nil
+}
+
+// Find returns the fallback of the connection of the name and alpn, whose first read is first.
+func (m *Matcher[F]) Find(ctx context.Context, name string, alpn string, first *buf.Buffer, firstLen int64) (F, error) {
+	if len(m.rules) > 0 {
+		request := ParseRequest(first.Bytes())
+		for _, r := range m.rules {
+			if r.Match(name, alpn, request) {
+				return r.fb, nil
+			}
+		}
+	}
+
+	var none F
+	napfb := m.napfb
+	if len(napfb) > 1 || napfb[""] == nil {
+		if name != "" && napfb[name] == nil {
+			match := ""
+			for n := range napfb {
+				if n != "" && strings.Contains(name, n) && len(n) > len(match) {
+					match = n
+				}
+			}
+			name = match
+		}
+	}
+
+	if napfb[name] == nil {
+		name = ""
+	}
+	apfb := napfb[name]
+	if apfb == nil {
+		return none, errors.New(`failed to find the default "name" config`).AtWarning()
+	}
+
+	if apfb[alpn] == nil {
+		alpn = ""
+	}
+	pfb := apfb[alpn]
+	if pfb == nil {
+		return none, errors.New(`failed to find the default "alpn" config`).AtWarning()
+	}
+
+	path := ""
+	if _, found := pfb[""]; len(pfb) > 1 || !found {
+		if firstLen >= 18 && first.Byte(4) != '*' { // not h2c
+			firstBytes := first.Bytes()
+			for i := 4; i <= 8; i++ { // 5 -> 9
+				if firstBytes[i] == '/' && firstBytes[i-1] == ' ' {
+					search := len(firstBytes)
+					if search > 64 {
+						search = 64 // up to about 60
+					}
+					for j := i + 1; j < search; j++ {
+						k := firstBytes[j]
+						if k == '\r' || k == '\n' { // avoid logging \r or \n
+							break
+						}
+						if k == '?' || k == ' ' {
+							path = string(firstBytes[i:j])
+							errors.LogInfo(ctx, "realPath = "+path)
+							if _, found := pfb[path]; !found {
+								path = ""
+							}
+							break
+						}
+					}
+					break
+				}
+			}
+		}
+	}
+	fb, found := pfb[path]
+	if !found {
+		return none, errors.New(`failed to find the default "path" config`).AtWarning()
+	}
+	return fb, nil
+}
+
+// Dial dials the dest of the network, or one of the dests picked by weight if there are any, retrying with
+// another pick on failure.
+func Dial[D Dest](ctx context.Context, network string, dest string, dests []D) (net.Conn, error) {
+	var conn net.Conn
+	if err := retry.ExponentialBackoff(5, 100).On(func() error {
+		if len(dests) > 0 {
+			d := Pick(dests)
+			network, dest = d.GetType(), d.GetDest()
+		}
+		var dialer net.Dialer
+		var err error
+		conn, err = dialer.DialContext(ctx, network, dest)
+		return err
+	}); err != nil {
+		return nil, errors.New("failed to dial to " + dest).Base(err).AtWarning()
+	}
+	return conn, nil
+}
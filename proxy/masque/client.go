@@ -0,0 +1,391 @@
+package masque
+
+import (
+	"context"
+	gotls "crypto/tls"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/apernet/quic-go"
+	"github.com/apernet/quic-go/http3"
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/buf"
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/net/cnc"
+	"github.com/xtls/xray-core/common/protocol"
+	http_proto "github.com/xtls/xray-core/common/protocol/http"
+	"github.com/xtls/xray-core/common/session"
+	"github.com/xtls/xray-core/common/signal"
+	"github.com/xtls/xray-core/common/task"
+	"github.com/xtls/xray-core/core"
+	"github.com/xtls/xray-core/features/policy"
+	"github.com/xtls/xray-core/transport"
+	"github.com/xtls/xray-core/transport/internet"
+	"github.com/xtls/xray-core/transport/internet/hysteria/congestion"
+	"github.com/xtls/xray-core/transport/internet/stat"
+	"github.com/xtls/xray-core/transport/internet/tls"
+)
+
+// Client is a MASQUE client. All requests share one QUIC connection to the server.
+type Client struct {
+	server         *protocol.ServerSpec
+	udpTemplate    string
+	policyManager  policy.Manager
+	streamSettings *internet.MemoryStreamConfig
+	tlsConfig      *gotls.Config
+
+	mu      sync.Mutex
+	conn    *quic.Conn
+	cc      *http3.ClientConn
+	tr      *quic.Transport
+	pktConn net.PacketConn
+}
+
+func NewClient(ctx context.Context, config *ClientConfig) (*Client, error) {
+	v := core.MustFromContext(ctx)
+	p := v.GetFeature(policy.ManagerType()).(policy.Manager)
+
+	streamSettings := session.StreamSettingsFromContext(ctx).(*internet.MemoryStreamConfig)
+	tlsConfig := tls.ConfigFromStreamSettings(streamSettings)
+	if tlsConfig == nil {
+		return nil, errors.New("MASQUE requires TLS")
+	}
+	if config.Server == nil {
+		return nil, errors.New(`no target server found`)
+	}
+	server, err := protocol.NewServerSpecFromPB(config.Server)
+	if err != nil {
+		return nil, errors.New("failed to get server spec").Base(err)
+	}
+
+	udpTemplate, err := connectUDPTemplate(config.UdpTemplate, server.Destination)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		server:         server,
+		udpTemplate:    udpTemplate,
+		policyManager:  p,
+		streamSettings: streamSettings,
+		tlsConfig:      tlsConfig.GetTLSConfig(tls.WithDestination(server.Destination), tls.WithNextProto("h3")),
+	}, nil
+}
+
+// clientConn returns the HTTP/3 connection to the server, dialing a new one with the dialer if there is none alive.
+// The connection outlives the request of ctx, so it is dialed in a context detached from it.
+func (c *Client) clientConn(ctx context.Context, dialer internet.Dialer) (*http3.ClientConn, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.conn != nil {
+		select {
+		case <-c.conn.Context().Done():
+			c.closeConn()
+		default:
+			return c.cc, nil
+		}
+	}
+
+	dest := c.server.Destination
+	dest.Network = net.Network_UDP
+	dialCtx := session.ContextWithOutbounds(core.ToBackgroundDetachedContext(ctx), []*session.Outbound{{
+		Target: dest,
+		Name:   "masque",
+	}})
+	raw, err := dialer.Dial(dialCtx, dest)
+	if err != nil {
+		return nil, errors.New("failed to dial to ", dest).Base(err)
+	}
+	var pktConn net.PacketConn
+	var udpAddr *net.UDPAddr
+	switch conn := stat.TryUnwrapStatsConn(raw).(type) {
+	case *internet.PacketConnWrapper:
+		pktConn = conn.PacketConn
+		udpAddr = raw.RemoteAddr().(*net.UDPAddr)
+	case *cnc.Connection:
+		pktConn = &internet.FakePacketConn{Conn: conn}
+		udpAddr = &net.UDPAddr{IP: conn.RemoteAddr().(*net.TCPAddr).IP, Port: conn.RemoteAddr().(*net.TCPAddr).Port}
+	default:
+		raw.Close()
+		return nil, errors.New("unexpected connection ", reflect.TypeOf(raw))
+	}
+
+	tr := &quic.Transport{Conn: pktConn}
+	conn, err := tr.Dial(ctx, udpAddr, c.tlsConfig, newQUICConfig(c.streamSettings.QuicParams))
+	if err != nil {
+		tr.Close()
+		pktConn.Close()
+		return nil, errors.New("failed to dial QUIC to ", dest).Base(err)
+	}
+	useCongestion(conn, c.streamSettings.QuicParams, congestion.NewTargetRateStats(ctx))
+
+	c.conn = conn
+	c.cc = (&http3.Transport{EnableDatagrams: true}).NewClientConn(conn)
+	c.tr = tr
+	c.pktConn = pktConn
+	return c.cc, nil
+}
+
+func (c *Client) closeConn() {
+	c.conn.CloseWithError(quic.ApplicationErrorCode(http3.ErrCodeNoError), "")
+	c.tr.Close()
+	c.pktConn.Close()
+	c.conn = nil
+	c.cc = nil
+	c.tr = nil
+	c.pktConn = nil
+}
+
+// Close implements common.Closable.Close.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn != nil {
+		c.closeConn()
+	}
+	return nil
+}
+
+// connect sends a CONNECT request, and returns the stream once the server accepts it.
+func (c *Client) connect(ctx context.Context, cc *http3.ClientConn, req *http.Request) (*http3.RequestStream, error) {
+	if user := c.server.User; user != nil {
+		if account, ok := user.Account.(*Account); ok {
+			req.Header.Set("Proxy-Authorization", account.ProxyAuthorization())
+		}
+	}
+	if req.Proto != "" {
+		// Extended CONNECT can only be sent once the server enables it in SETTINGS.
+		select {
+		case <-cc.ReceivedSettings():
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if settings := cc.Settings(); !settings.EnableExtendedConnect || !settings.EnableDatagrams {
+			return nil, errors.New("server does not support ", req.Proto)
+		}
+	}
+	str, err := cc.OpenRequestStream(ctx)
+	if err != nil {
+		return nil, errors.New("failed to open request stream").Base(err)
+	}
+	if err := str.SendRequestHeader(req.WithContext(ctx)); err != nil {
+		str.CancelRead(quic.StreamErrorCode(http3.ErrCodeRequestCanceled))
+		str.CancelWrite(quic.StreamErrorCode(http3.ErrCodeRequestCanceled))
+		return nil, errors.New("failed to send request").Base(err)
+	}
+	resp, err := str.ReadResponse()
+	if err != nil {
+		return nil, errors.New("failed to read response").Base(err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		str.CancelRead(quic.StreamErrorCode(http3.ErrCodeRequestCanceled))
+		str.CancelWrite(quic.StreamErrorCode(http3.ErrCodeRequestCanceled))
+		return nil, errors.New("server responded ", resp.Status)
+	}
+	return str, nil
+}
+
+// connectUDPTemplate returns the URI template of CONNECT-UDP requests to server, the default one if template is empty.
+func connectUDPTemplate(template string, server net.Destination) (string, error) {
+	if template == "" {
+		return "https://" + server.NetAddr() + http_proto.DefaultConnectUDPTemplate, nil
+	}
+	if u, err := url.Parse(strings.NewReplacer("{target_host}", "h", "{target_port}", "1").Replace(template)); err != nil || u.Scheme != "https" || u.Host == "" {
+		return "", errors.New("invalid CONNECT-UDP template: ", template)
+	}
+	return template, nil
+}
+
+func (c *Client) connectUDP(ctx context.Context, cc *http3.ClientConn, dest net.Destination) (*http3.RequestStream, error) {
+	u, err := url.Parse(http_proto.ExpandConnectUDPTemplate(c.udpTemplate, dest))
+	if err != nil {
+		return nil, err
+	}
+	return c.connect(ctx, cc, &http.Request{
+		Method: http.MethodConnect,
+		Proto:  http_proto.ProtocolConnectUDP,
+		Host:   u.Host,
+		URL:    u,
+		Header: http.Header{"Capsule-Protocol": []string{"?1"}},
+	})
+}
+
+// Process implements proxy.Outbound.Process.
+func (c *Client) Process(ctx context.Context, link *transport.Link, dialer internet.Dialer) error {
+	outbounds := session.OutboundsFromContext(ctx)
+	ob := outbounds[len(outbounds)-1]
+	if !ob.Target.IsValid() {
+		return errors.New("target not specified")
+	}
+	ob.Name = "masque"
+	ob.CanSpliceCopy = 3
+	target := ob.Target
+
+	cc, err := c.clientConn(ctx, dialer)
+	if err != nil {
+		return errors.New("failed to find an available destination").AtWarning().Base(err)
+	}
+	errors.LogInfo(ctx, "tunneling request to ", target, " via ", c.server.Destination.NetAddr())
+
+	var level uint32
+	if c.server.User != nil {
+		level = c.server.User.Level
+	}
+	sessionPolicy := c.policyManager.ForLevel(level)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	timer := signal.CancelAfterInactivity(ctx, cancel, sessionPolicy.Timeouts.ConnectionIdle)
+
+	if target.Network == net.Network_TCP {
+		str, err := c.connect(ctx, cc, &http.Request{
+			Method: http.MethodConnect,
+			Host:   target.NetAddr(),
+			URL:    &url.URL{Host: target.NetAddr()},
+			Header: http.Header{},
+		})
+		if err != nil {
+			return errors.New("failed to connect to ", target).Base(err)
+		}
+		defer str.CancelRead(quic.StreamErrorCode(http3.ErrCodeNoError))
+
+		requestDone := func() error {
+			defer timer.SetTimeout(sessionPolicy.Timeouts.DownlinkOnly)
+			if err := buf.Copy(link.Reader, buf.NewWriter(str), buf.UpdateActivity(timer)); err != nil {
+				return err
+			}
+			return str.Close()
+		}
+		responseDone := func() error {
+			defer timer.SetTimeout(sessionPolicy.Timeouts.UplinkOnly)
+			return buf.Copy(buf.NewReader(str), link.Writer, buf.UpdateActivity(timer))
+		}
+		responseDoneAndCloseWriter := task.OnSuccess(responseDone, task.Close(link.Writer))
+		if err := task.Run(ctx, requestDone, responseDoneAndCloseWriter); err != nil {
+			return errors.New("connection ends").Base(err)
+		}
+		return nil
+	}
+
+	// Every destination of the UDP session has its own CONNECT-UDP stream,
+	// and responses from all of them are merged.
+	session := &udpSession{
+		ctx:      ctx,
+		client:   c,
+		cc:       cc,
+		target:   target,
+		streams:  make(map[net.Destination]*http3.RequestStream),
+		received: make(chan *buf.Buffer, 64),
+	}
+	defer session.close()
+
+	requestDone := func() error {
+		defer timer.SetTimeout(sessionPolicy.Timeouts.DownlinkOnly)
+		return buf.Copy(link.Reader, session, buf.UpdateActivity(timer))
+	}
+	responseDone := func() error {
+		defer timer.SetTimeout(sessionPolicy.Timeouts.UplinkOnly)
+		return buf.Copy(session, link.Writer, buf.UpdateActivity(timer))
+	}
+	responseDoneAndCloseWriter := task.OnSuccess(responseDone, task.Close(link.Writer))
+	if err := task.Run(ctx, requestDone, responseDoneAndCloseWriter); err != nil {
+		return errors.New("connection ends").Base(err)
+	}
+	return nil
+}
+
+type udpSession struct {
+	ctx    context.Context
+	client *Client
+	cc     *http3.ClientConn
+	target net.Destination
+
+	mu       sync.Mutex
+	streams  map[net.Destination]*http3.RequestStream
+	received chan *buf.Buffer
+}
+
+func (s *udpSession) stream(dest net.Destination) (*http3.RequestStream, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if str, found := s.streams[dest]; found {
+		return str, nil
+	}
+	str, err := s.client.connectUDP(s.ctx, s.cc, dest)
+	if err != nil {
+		return nil, errors.New("failed to connect to ", dest).Base(err)
+	}
+	s.streams[dest] = str
+	go s.receive(str, dest)
+	return str, nil
+}
+
+func (s *udpSession) receive(str *http3.RequestStream, dest net.Destination) {
+	// The stream only carries capsules, and the server ends the session by closing it.
+	go func() {
+		io.Copy(io.Discard, str)
+		str.CancelRead(quic.StreamErrorCode(http3.ErrCodeNoError))
+	}()
+	for {
+		b, err := receiveUDP(str.Context(), str)
+		if err != nil {
+			return
+		}
+		b.UDP = &dest
+		select {
+		case s.received <- b:
+		case <-s.ctx.Done():
+			b.Release()
+			return
+		}
+	}
+}
+
+// WriteMultiBuffer implements buf.Writer.
+func (s *udpSession) WriteMultiBuffer(mb buf.MultiBuffer) error {
+	defer buf.ReleaseMulti(mb)
+	for _, b := range mb {
+		dest := s.target
+		if b.UDP != nil {
+			dest = *b.UDP
+		}
+		str, err := s.stream(dest)
+		if err != nil {
+			return err
+		}
+		if err := sendUDP(s.ctx, str, b.Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadMultiBuffer implements buf.Reader.
+func (s *udpSession) ReadMultiBuffer() (buf.MultiBuffer, error) {
+	select {
+	case b := <-s.received:
+		return buf.MultiBuffer{b}, nil
+	case <-s.ctx.Done():
+		return nil, s.ctx.Err()
+	}
+}
+
+func (s *udpSession) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, str := range s.streams {
+		str.CancelRead(quic.StreamErrorCode(http3.ErrCodeNoError))
+		str.Close()
+	}
+}
+
+func init() {
+	common.Must(common.RegisterConfig((*ClientConfig)(nil), func(ctx context.Context, config interface{}) (interface{}, error) {
+		return NewClient(ctx, config.(*ClientConfig))
+	}))
+}
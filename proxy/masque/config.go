@@ -0,0 +1,28 @@
+package masque
+
+import (
+	"encoding/base64"
+
+	"github.com/xtls/xray-core/common/protocol"
+	"google.golang.org/protobuf/proto"
+)
+
+func (a *Account) Equals(another protocol.Account) bool {
+	if account, ok := another.(*Account); ok {
+		return a.Username == account.Username
+	}
+	return false
+}
+
+func (a *Account) ToProto() proto.Message {
+	return a
+}
+
+func (a *Account) AsAccount() (protocol.Account, error) {
+	return a, nil
+}
+
+// ProxyAuthorization returns the value of Proxy-Authorization of the account.
+func (a *Account) ProxyAuthorization() string {
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(a.Username+":"+a.Password))
+}
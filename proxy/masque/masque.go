@@ -0,0 +1,160 @@
+// Package masque implements MASQUE proxying (RFC 9298) over HTTP/3.
+//
+// UDP is proxied by CONNECT-UDP with HTTP Datagrams (RFC 9297), and TCP by the classic
+// HTTP/3 CONNECT method, so both the client and the server interoperate with standard relays.
+package masque
+
+import (
+	"context"
+	"encoding/base64"
+	go_errors "errors"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/apernet/quic-go"
+	"github.com/apernet/quic-go/http3"
+	"github.com/xtls/xray-core/common/buf"
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/net"
+	http_proto "github.com/xtls/xray-core/common/protocol/http"
+	"github.com/xtls/xray-core/features/stats"
+	"github.com/xtls/xray-core/transport/internet"
+	"github.com/xtls/xray-core/transport/internet/hysteria/congestion"
+)
+
+func newQUICConfig(quicParams *internet.QuicParams) *quic.Config {
+	if quicParams == nil {
+		quicParams = &internet.QuicParams{}
+	}
+	quicConfig := &quic.Config{
+		InitialStreamReceiveWindow:     quicParams.InitStreamReceiveWindow,
+		MaxStreamReceiveWindow:         quicParams.MaxStreamReceiveWindow,
+		InitialConnectionReceiveWindow: quicParams.InitConnReceiveWindow,
+		MaxConnectionReceiveWindow:     quicParams.MaxConnReceiveWindow,
+		MaxIdleTimeout:                 time.Duration(quicParams.MaxIdleTimeout) * time.Second,
+		KeepAlivePeriod:                time.Duration(quicParams.KeepAlivePeriod) * time.Second,
+		MaxIncomingStreams:             quicParams.MaxIncomingStreams,
+		DisablePathMTUDiscovery:        quicParams.DisablePathMtuDiscovery || (runtime.GOOS != "linux" && runtime.GOOS != "windows" && runtime.GOOS != "darwin"),
+		EnableDatagrams:                true,
+	}
+	if quicParams.InitStreamReceiveWindow == 0 {
+		quicConfig.InitialStreamReceiveWindow = 8388608
+	}
+	if quicParams.MaxStreamReceiveWindow == 0 {
+		quicConfig.MaxStreamReceiveWindow = 8388608
+	}
+	if quicParams.InitConnReceiveWindow == 0 {
+		quicConfig.InitialConnectionReceiveWindow = 8388608 * 5 / 2
+	}
+	if quicParams.MaxConnReceiveWindow == 0 {
+		quicConfig.MaxConnectionReceiveWindow = 8388608 * 5 / 2
+	}
+	if quicParams.MaxIdleTimeout == 0 {
+		quicConfig.MaxIdleTimeout = 30 * time.Second
+	}
+	if quicParams.MaxIncomingStreams == 0 {
+		quicConfig.MaxIncomingStreams = 1024
+	}
+	return quicConfig
+}
+
+// useCongestion sets the congestion control of conn. Brutal has no negotiation with
+// standard relays, so it is only used with a configured send rate.
+func useCongestion(conn *quic.Conn, quicParams *internet.QuicParams, targetRates *congestion.TargetRateStats) {
+	if quicParams == nil {
+		congestion.UseConfigured(conn, congestion.TypeBBR, "")
+		return
+	}
+	switch quicParams.Congestion {
+	case "brutal", "force-brutal":
+		if quicParams.BrutalUp > 0 {
+			congestion.UseBrutal(conn, quicParams.BrutalUp)
+			return
+		}
+	case "adaptive-brutal":
+		congestion.UseAdaptiveBrutal(conn, quicParams.BrutalUp, targetRates)
+		return
+	}
+	congestion.UseConfigured(conn, quicParams.Congestion, quicParams.BbrProfile)
+}
+
+func parseProxyAuthorization(auth string) (username, password string, ok bool) {
+	const prefix = "Basic "
+	if len(auth) < len(prefix) || !strings.EqualFold(auth[:len(prefix)], prefix) {
+		return
+	}
+	c, err := base64.StdEncoding.DecodeString(auth[len(prefix):])
+	if err != nil {
+		return
+	}
+	username, password, ok = strings.Cut(string(c), ":")
+	return
+}
+
+// datagramStream is the part of http3.Stream and http3.RequestStream carrying HTTP Datagrams.
+type datagramStream interface {
+	SendDatagram(b []byte) error
+	ReceiveDatagram(ctx context.Context) ([]byte, error)
+}
+
+var (
+	_ datagramStream = (*http3.Stream)(nil)
+	_ datagramStream = (*http3.RequestStream)(nil)
+)
+
+// receiveUDP receives UDP payload of CONNECT-UDP from str. Datagrams of other contexts are dropped.
+func receiveUDP(ctx context.Context, str datagramStream) (*buf.Buffer, error) {
+	for {
+		d, err := str.ReceiveDatagram(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if len(d) == 0 || len(d) > buf.Size {
+			continue
+		}
+		b := buf.New()
+		b.Write(d)
+		if !http_proto.ReadContextID(b) {
+			b.Release()
+			continue
+		}
+		return b, nil
+	}
+}
+
+// sendUDP sends UDP payload of CONNECT-UDP to str. Payload too large for a datagram is dropped,
+// as it would be on a path with a smaller MTU.
+func sendUDP(ctx context.Context, str datagramStream, payload []byte) error {
+	d := make([]byte, 1+len(payload))
+	copy(d[1:], payload) // context ID 0
+	err := str.SendDatagram(d)
+	var tooLarge *quic.DatagramTooLargeError
+	if go_errors.As(err, &tooLarge) {
+		errors.LogDebug(ctx, "dropping UDP packet of ", len(payload), " bytes, larger than ", tooLarge.MaxDatagramPayloadSize-1)
+		return nil
+	}
+	return err
+}
+
+type packetCounterConn struct {
+	net.PacketConn
+	ReadCounter  stats.Counter
+	WriteCounter stats.Counter
+}
+
+func (c *packetCounterConn) ReadFrom(p []byte) (n int, addr net.Addr, err error) {
+	n, addr, err = c.PacketConn.ReadFrom(p)
+	if err == nil && c.ReadCounter != nil {
+		c.ReadCounter.Add(int64(n))
+	}
+	return
+}
+
+func (c *packetCounterConn) WriteTo(p []byte, addr net.Addr) (n int, err error) {
+	n, err = c.PacketConn.WriteTo(p, addr)
+	if err == nil && c.WriteCounter != nil {
+		c.WriteCounter.Add(int64(n))
+	}
+	return
+}
@@ -0,0 +1,207 @@
+package masque
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/apernet/quic-go"
+	"github.com/apernet/quic-go/http3"
+	"github.com/google/go-cmp/cmp"
+	"github.com/xtls/xray-core/app/stats"
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/protocol"
+	http_proto "github.com/xtls/xray-core/common/protocol/http"
+	feature_stats "github.com/xtls/xray-core/features/stats"
+)
+
+func TestParseProxyAuthorization(t *testing.T) {
+	account := &Account{Username: "love", Password: "pass:word"}
+	username, password, ok := parseProxyAuthorization(account.ProxyAuthorization())
+	if !ok || username != "love" || password != "pass:word" {
+		t.Error("unexpected credentials ", username, " ", password, " ", ok)
+	}
+	for _, auth := range []string{"", "Bearer token", "Basic !!!", "Basic bG92ZQ=="} {
+		if _, _, ok := parseProxyAuthorization(auth); ok {
+			t.Error("unexpected credentials from ", auth)
+		}
+	}
+}
+
+func TestConnectUDPTemplate(t *testing.T) {
+	server := net.TCPDestination(net.DomainAddress("proxy.example.com"), 443)
+	cases := []struct {
+		template string
+		dest     net.Destination
+		url      string
+	}{
+		{
+			dest: net.UDPDestination(net.ParseAddress("192.0.2.1"), 53),
+			url:  "https://proxy.example.com:443/.well-known/masque/udp/192.0.2.1/53/",
+		},
+		{
+			template: "https://relay.example.com:8443/masque?h={target_host}&p={target_port}",
+			dest:     net.UDPDestination(net.ParseAddress("2001:db8::1"), 443),
+			url:      "https://relay.example.com:8443/masque?h=2001%3Adb8%3A%3A1&p=443",
+		},
+		{
+			template: "https://relay.example.com/udp/{target_host}/{target_port}/",
+			dest:     net.UDPDestination(net.DomainAddress("dns.example.com"), 853),
+			url:      "https://relay.example.com/udp/dns.example.com/853/",
+		},
+	}
+	for _, c := range cases {
+		template, err := connectUDPTemplate(c.template, server)
+		common.Must(err)
+		u, err := url.Parse(http_proto.ExpandConnectUDPTemplate(template, c.dest))
+		common.Must(err)
+		if u.String() != c.url {
+			t.Error("unexpected URL ", u, ", expected ", c.url)
+		}
+	}
+
+	for _, template := range []string{
+		"http://relay.example.com/udp/{target_host}/{target_port}/",
+		"/udp/{target_host}/{target_port}/",
+		"https://relay.example.com:port/{target_host}/{target_port}/",
+	} {
+		if _, err := connectUDPTemplate(template, server); err == nil {
+			t.Error("expected an error for template ", template)
+		}
+	}
+}
+
+// fakeDatagramStream receives the queued datagrams, and records the ones sent.
+type fakeDatagramStream struct {
+	received [][]byte
+	sent     [][]byte
+	maxSize  int
+}
+
+func (s *fakeDatagramStream) SendDatagram(b []byte) error {
+	if s.maxSize > 0 && len(b) > s.maxSize {
+		return &quic.DatagramTooLargeError{MaxDatagramPayloadSize: int64(s.maxSize)}
+	}
+	s.sent = append(s.sent, b)
+	return nil
+}
+
+func (s *fakeDatagramStream) ReceiveDatagram(ctx context.Context) ([]byte, error) {
+	if len(s.received) == 0 {
+		return nil, io.EOF
+	}
+	d := s.received[0]
+	s.received = s.received[1:]
+	return d, nil
+}
+
+func TestDatagramContextID(t *testing.T) {
+	str := &fakeDatagramStream{
+		received: [][]byte{
+			{0x00, 'a'},
+			{},                 // without context ID
+			{0x02, 'b'},        // of another context
+			{0x40, 0x02, 'c'},  // of another context, in two bytes
+			{0x40, 0x00, 'd'},  // of context 0, in two bytes
+			{0x00},             // empty payload
+			{0x80, 0x00, 0x00}, // truncated context ID
+		},
+		maxSize: 4,
+	}
+	var payloads []string
+	for {
+		b, err := receiveUDP(context.Background(), str)
+		if err != nil {
+			break
+		}
+		payloads = append(payloads, b.String())
+		b.Release()
+	}
+	if r := cmp.Diff(payloads, []string{"a", "d", ""}); r != "" {
+		t.Error(r)
+	}
+
+	common.Must(sendUDP(context.Background(), str, []byte("udp")))
+	// too large for a datagram, it is dropped
+	common.Must(sendUDP(context.Background(), str, []byte("large")))
+	if r := cmp.Diff(str.sent, [][]byte{{0x00, 'u', 'd', 'p'}}); r != "" {
+		t.Error(r)
+	}
+}
+
+func TestServerAuthentication(t *testing.T) {
+	sm := common.Must2(stats.NewManager(context.Background(), &stats.Config{}))
+	channel := common.Must2(feature_stats.GetOrRegisterChannel(sm, feature_stats.AuthFailureChannel))
+	common.Must(sm.Start())
+	defer sm.Close()
+	failures := common.Must2(feature_stats.SubscribeRunnableChannel(channel))
+	defer feature_stats.UnsubscribeClosableChannel(channel, failures)
+
+	s := &Server{ctx: context.Background(), tag: "masque", stats: sm}
+	account := &Account{Username: "love", Password: "password"}
+	common.Must(s.AddUser(context.Background(), &protocol.MemoryUser{Email: "love@example.com", Account: account}))
+
+	source := &net.UDPAddr{IP: net.ParseIP("192.0.2.1"), Port: 1234}
+	serve := func(method string, proto string, authorization string) int {
+		r := httptest.NewRequest(method, "https://proxy.example.com/", nil)
+		r = r.WithContext(context.WithValue(r.Context(), http3.RemoteAddrContextKey, source))
+		r.Proto = proto
+		if authorization != "" {
+			r.Header.Set("Proxy-Authorization", authorization)
+		}
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, r)
+		if w.Code == http.StatusProxyAuthRequired && w.Header().Get("Proxy-Authenticate") == "" {
+			t.Error("expected the client to be challenged")
+		}
+		return w.Code
+	}
+	failed := func() *feature_stats.AuthFailure {
+		select {
+		case msg := <-failures:
+			failure := msg.(feature_stats.AuthFailure)
+			return &failure
+		case <-time.After(100 * time.Millisecond):
+			return nil
+		}
+	}
+
+	if code := serve(http.MethodGet, "HTTP/3.0", ""); code != http.StatusNotFound {
+		t.Error("unexpected status ", code)
+	}
+	// a request without credentials is challenged, and is not a failure
+	if code := serve(http.MethodConnect, "HTTP/3.0", ""); code != http.StatusProxyAuthRequired {
+		t.Error("unexpected status ", code)
+	}
+	if failure := failed(); failure != nil {
+		t.Error("unexpected failure ", failure)
+	}
+
+	wrong := &Account{Username: "love", Password: "wrong"}
+	unknown := &Account{Username: "hate", Password: "password"}
+	for _, a := range []*Account{wrong, unknown} {
+		if code := serve(http.MethodConnect, "HTTP/3.0", a.ProxyAuthorization()); code != http.StatusProxyAuthRequired {
+			t.Error("unexpected status ", code)
+		}
+		failure := failed()
+		if failure == nil {
+			t.Fatal("expected the failure to be published")
+		}
+		if failure.InboundTag != "masque" || failure.Protocol != "masque" || failure.Source.String() != "192.0.2.1" {
+			t.Error("unexpected failure ", failure)
+		}
+	}
+
+	// authenticated, but neither CONNECT nor CONNECT-UDP
+	if code := serve(http.MethodConnect, http_proto.ProtocolConnectIP, account.ProxyAuthorization()); code != http.StatusNotImplemented {
+		t.Error("unexpected status ", code)
+	}
+	if failure := failed(); failure != nil {
+		t.Error("unexpected failure ", failure)
+	}
+}
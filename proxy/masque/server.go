@@ -0,0 +1,418 @@
+package masque
+
+import (
+	"context"
+	"crypto/subtle"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/apernet/quic-go"
+	"github.com/apernet/quic-go/http3"
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/buf"
+	c "github.com/xtls/xray-core/common/ctx"
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/log"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/protocol"
+	http_proto "github.com/xtls/xray-core/common/protocol/http"
+	"github.com/xtls/xray-core/common/session"
+	"github.com/xtls/xray-core/core"
+	"github.com/xtls/xray-core/features/extension"
+	"github.com/xtls/xray-core/features/policy"
+	"github.com/xtls/xray-core/features/routing"
+	"github.com/xtls/xray-core/features/stats"
+	"github.com/xtls/xray-core/transport"
+	"github.com/xtls/xray-core/transport/internet"
+	"github.com/xtls/xray-core/transport/internet/hysteria/congestion"
+	"github.com/xtls/xray-core/transport/internet/stat"
+	"github.com/xtls/xray-core/transport/internet/tls"
+)
+
+// Server is a MASQUE relay. Like WireGuard and TUN, it listens by itself rather than by workers of the inbound.
+type Server struct {
+	ctx             context.Context
+	policyManager   policy.Manager
+	dispatcher      routing.Dispatcher
+	tag             string
+	src             net.Destination
+	sniffingRequest session.SniffingRequest
+	streamSettings  *internet.MemoryStreamConfig
+	tlsConfig       *tls.Config
+	uplinkCounter   stats.Counter
+	downlinkCounter stats.Counter
+	stats           stats.Manager
+
+	users sync.Map // username -> *protocol.MemoryUser
+
+	mu       sync.Mutex
+	pktConn  net.PacketConn
+	tr       *quic.Transport
+	listener *quic.Listener
+	h3       *http3.Server
+}
+
+func NewServer(ctx context.Context, config *ServerConfig) (*Server, error) {
+	v := core.MustFromContext(ctx)
+	p := v.GetFeature(policy.ManagerType()).(policy.Manager)
+	d := v.GetFeature(routing.DispatcherType()).(routing.Dispatcher)
+
+	inbound := session.InboundFromContext(ctx)
+	content := session.ContentFromContext(ctx)
+	streamSettings := session.StreamSettingsFromContext(ctx).(*internet.MemoryStreamConfig)
+	tlsConfig := tls.ConfigFromStreamSettings(streamSettings)
+	if tlsConfig == nil {
+		return nil, errors.New("MASQUE requires TLS")
+	}
+
+	tag := inbound.Tag
+	statsManager := v.GetFeature(stats.ManagerType()).(stats.Manager)
+	var uplinkCounter stats.Counter
+	var downlinkCounter stats.Counter
+	if len(tag) > 0 && p.ForSystem().Stats.InboundUplink {
+		name := "inbound>>>" + tag + ">>>traffic>>>uplink"
+		c, _ := stats.GetOrRegisterCounter(statsManager, name)
+		if c != nil {
+			uplinkCounter = c
+		}
+	}
+	if len(tag) > 0 && p.ForSystem().Stats.InboundDownlink {
+		name := "inbound>>>" + tag + ">>>traffic>>>downlink"
+		c, _ := stats.GetOrRegisterCounter(statsManager, name)
+		if c != nil {
+			downlinkCounter = c
+		}
+	}
+
+	s := &Server{
+		ctx:             core.ToBackgroundDetachedContext(ctx),
+		policyManager:   p,
+		dispatcher:      d,
+		tag:             tag,
+		src:             inbound.Source,
+		sniffingRequest: content.SniffingRequest,
+		streamSettings:  streamSettings,
+		tlsConfig:       tlsConfig,
+		uplinkCounter:   uplinkCounter,
+		downlinkCounter: downlinkCounter,
+		stats:           statsManager,
+	}
+	for _, u := range config.Users {
+		user, err := u.ToMemoryUser()
+		if err != nil {
+			return nil, errors.New("failed to get MASQUE user").Base(err).AtError()
+		}
+		if err := s.AddUser(ctx, user); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func (s *Server) AddUser(ctx context.Context, user *protocol.MemoryUser) error {
+	account, ok := user.Account.(*Account)
+	if !ok {
+		return errors.New("not a MASQUE account")
+	}
+	s.users.Store(account.Username, user)
+	return nil
+}
+
+func (s *Server) RemoveUser(ctx context.Context, email string) error {
+	if user := s.GetUser(ctx, email); user != nil {
+		s.users.Delete(user.Account.(*Account).Username)
+	}
+	return nil
+}
+
+func (s *Server) GetUser(ctx context.Context, email string) (user *protocol.MemoryUser) {
+	s.users.Range(func(key, value any) bool {
+		if value.(*protocol.MemoryUser).Email == email {
+			user = value.(*protocol.MemoryUser)
+			return false
+		}
+		return true
+	})
+	return
+}
+
+func (s *Server) GetUsers(ctx context.Context) (users []*protocol.MemoryUser) {
+	s.users.Range(func(key, value any) bool {
+		users = append(users, value.(*protocol.MemoryUser))
+		return true
+	})
+	return
+}
+
+func (s *Server) GetUsersCount(context.Context) (count int64) {
+	s.users.Range(func(key, value any) bool {
+		count++
+		return true
+	})
+	return
+}
+
+// Network implements proxy.Inbound.Network.
+func (*Server) Network() []net.Network {
+	return []net.Network{}
+}
+
+// Process implements proxy.Inbound.Process.
+func (s *Server) Process(ctx context.Context, network net.Network, conn stat.Connection, dispatcher routing.Dispatcher) error {
+	return nil
+}
+
+// Start implements common.Runnable.Start.
+func (s *Server) Start() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.listener != nil {
+		return nil
+	}
+	if s.src.Address.Family().IsDomain() {
+		return errors.New("address is domain")
+	}
+
+	pktConn, err := internet.ListenSystemPacket(context.Background(), &net.UDPAddr{IP: s.src.Address.IP(), Port: int(s.src.Port)}, s.streamSettings.SocketSettings)
+	if err != nil {
+		return err
+	}
+	if s.streamSettings.UdpmaskManager != nil {
+		newConn, err := s.streamSettings.UdpmaskManager.WrapPacketConnServer(pktConn)
+		if err != nil {
+			pktConn.Close()
+			return errors.New("mask err").Base(err)
+		}
+		pktConn = newConn
+	}
+	if s.uplinkCounter != nil || s.downlinkCounter != nil {
+		pktConn = &packetCounterConn{
+			PacketConn:   pktConn,
+			ReadCounter:  s.uplinkCounter,
+			WriteCounter: s.downlinkCounter,
+		}
+	}
+
+	tr := &quic.Transport{Conn: pktConn}
+	listener, err := tr.Listen(s.tlsConfig.GetTLSConfig(tls.WithNextProto("h3")), newQUICConfig(s.streamSettings.QuicParams))
+	if err != nil {
+		tr.Close()
+		pktConn.Close()
+		return err
+	}
+	s.pktConn = pktConn
+	s.tr = tr
+	s.listener = listener
+	s.h3 = &http3.Server{
+		Handler:         s,
+		EnableDatagrams: true,
+	}
+	go s.keepAccepting(listener)
+	return nil
+}
+
+func (s *Server) keepAccepting(listener *quic.Listener) {
+	for {
+		conn, err := listener.Accept(context.Background())
+		if err != nil {
+			if err != quic.ErrServerClosed {
+				errors.LogErrorInner(s.ctx, err, "failed to serve MASQUE")
+			}
+			return
+		}
+		if s.banned(conn.RemoteAddr()) {
+			conn.CloseWithError(quic.ApplicationErrorCode(http3.ErrCodeNoError), "")
+			errors.LogInfo(s.ctx, "rejected banned source ", conn.RemoteAddr())
+			continue
+		}
+		go func() {
+			useCongestion(conn, s.streamSettings.QuicParams, congestion.NewTargetRateStats(s.ctx))
+			s.h3.ServeQUICConn(conn)
+			conn.CloseWithError(quic.ApplicationErrorCode(http3.ErrCodeNoError), "")
+		}()
+	}
+}
+
+// banned returns whether the source of a connection is banned from the inbound by fail2ban.
+func (s *Server) banned(source net.Addr) bool {
+	if len(s.tag) == 0 {
+		return false
+	}
+	v := core.FromContext(s.ctx)
+	if v == nil {
+		return false
+	}
+	f, _ := v.GetFeature(extension.Fail2banType()).(extension.Fail2ban)
+	return f != nil && f.Banned(s.tag, net.DestinationFromAddr(source).Address)
+}
+
+// authFailed publishes the authentication failure of a request with wrong credentials. Requests without
+// any are not counted, as clients usually send one first to be challenged for them.
+func (s *Server) authFailed(source net.Destination, authorization string) {
+	if authorization == "" || !source.IsValid() {
+		return
+	}
+	stats.PublishAuthFailure(s.stats, stats.AuthFailure{
+		InboundTag: s.tag,
+		Protocol:   "masque",
+		Source:     source.Address,
+		Reason:     "invalid_password",
+	})
+}
+
+// Close implements common.Closable.Close.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.listener == nil {
+		return nil
+	}
+	err := errors.Combine(s.h3.Close(), s.listener.Close(), s.tr.Close(), s.pktConn.Close())
+	s.listener = nil
+	return err
+}
+
+// ServeHTTP implements http.Handler.ServeHTTP.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodConnect {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	var source net.Destination
+	if remote, ok := r.Context().Value(http3.RemoteAddrContextKey).(net.Addr); ok {
+		source = net.DestinationFromAddr(remote)
+	}
+
+	authorization := r.Header.Get("Proxy-Authorization")
+	username, password, _ := parseProxyAuthorization(authorization)
+	value, found := s.users.Load(username)
+	if !found || subtle.ConstantTimeCompare([]byte(value.(*protocol.MemoryUser).Account.(*Account).Password), []byte(password)) != 1 {
+		s.authFailed(source, authorization)
+		w.Header().Set("Proxy-Authenticate", `Basic realm="proxy"`)
+		w.WriteHeader(http.StatusProxyAuthRequired)
+		return
+	}
+	user := value.(*protocol.MemoryUser)
+
+	var dest net.Destination
+	var err error
+	switch r.Proto {
+	case http_proto.ProtocolConnectUDP:
+		dest, err = http_proto.ParseConnectUDPPath(r.URL.EscapedPath())
+	case "HTTP/3.0":
+		dest, err = http_proto.ParseHost(r.Host, net.Port(443))
+	default:
+		w.WriteHeader(http.StatusNotImplemented)
+		return
+	}
+	if err != nil {
+		errors.LogInfoInner(s.ctx, err, "malformed MASQUE request")
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(s.ctx)
+	defer cancel()
+	ctx = c.ContextWithID(ctx, session.NewID())
+
+	inbound := &session.Inbound{
+		Name:          "masque",
+		Tag:           s.tag,
+		CanSpliceCopy: 3,
+		Source:        source,
+		User:          user,
+	}
+	ctx = session.ContextWithInbound(ctx, inbound)
+	ctx = session.ContextWithContent(ctx, &session.Content{
+		SniffingRequest: s.sniffingRequest,
+	})
+	ctx = session.SubContextFromMuxInbound(ctx)
+	ctx = log.ContextWithAccessMessage(ctx, &log.AccessMessage{
+		From:   source,
+		To:     dest,
+		Status: log.AccessAccepted,
+		Reason: "",
+		Email:  user.Email,
+	})
+	errors.LogInfo(ctx, "processing ", r.Proto, " from ", source, " to ", dest)
+
+	if dest.Network == net.Network_UDP {
+		w.Header().Set("Capsule-Protocol", "?1")
+	}
+	w.WriteHeader(http.StatusOK)
+	w.(http.Flusher).Flush()
+
+	var link *transport.Link
+	if dest.Network == net.Network_UDP {
+		str := w.(http3.HTTPStreamer).HTTPStream()
+		// The request stream only carries capsules, and the session ends with it.
+		go func() {
+			io.Copy(io.Discard, str)
+			cancel()
+		}()
+		link = &transport.Link{
+			Reader: &datagramReader{ctx: ctx, str: str},
+			Writer: &datagramWriter{ctx: ctx, str: str},
+		}
+	} else {
+		link = &transport.Link{
+			Reader: buf.NewReader(r.Body),
+			Writer: buf.NewWriter(&flushWriter{w: w}),
+		}
+	}
+	if err := s.dispatcher.DispatchLink(ctx, dest, link); err != nil {
+		errors.LogInfoInner(ctx, err, "connection ends")
+	}
+}
+
+type flushWriter struct {
+	w http.ResponseWriter
+}
+
+func (w *flushWriter) Write(b []byte) (int, error) {
+	n, err := w.w.Write(b)
+	if err == nil {
+		w.w.(http.Flusher).Flush()
+	}
+	return n, err
+}
+
+// datagramReader reads UDP payload of CONNECT-UDP from HTTP Datagrams.
+type datagramReader struct {
+	ctx  context.Context
+	str  datagramStream
+	dest *net.Destination
+}
+
+func (r *datagramReader) ReadMultiBuffer() (buf.MultiBuffer, error) {
+	b, err := receiveUDP(r.ctx, r.str)
+	if err != nil {
+		return nil, err
+	}
+	b.UDP = r.dest
+	return buf.MultiBuffer{b}, nil
+}
+
+// datagramWriter writes UDP payload of CONNECT-UDP in HTTP Datagrams.
+type datagramWriter struct {
+	ctx context.Context
+	str datagramStream
+}
+
+func (w *datagramWriter) WriteMultiBuffer(mb buf.MultiBuffer) error {
+	defer buf.ReleaseMulti(mb)
+	for _, b := range mb {
+		if err := sendUDP(w.ctx, w.str, b.Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func init() {
+	common.Must(common.RegisterConfig((*ServerConfig)(nil), func(ctx context.Context, config interface{}) (interface{}, error) {
+		return NewServer(ctx, config.(*ServerConfig))
+	}))
+}
@@ -91,6 +91,25 @@ type UserManager interface {
 	GetUsersCount(context.Context) int64
 }
 
+// ICMPForwarder is implemented by Outbounds that are able to process links of net.Network_ICMP.
+// Links of other Outbounds are rejected.
+type ICMPForwarder interface {
+	CanForwardICMP() bool
+}
+
+// Binder is implemented by Outbounds that are able to serve the BIND requests of session.Bind.
+// BIND requests routed to other Outbounds are rejected.
+type Binder interface {
+	CanBind() bool
+}
+
+// HealthReporter is implemented by Outbounds which monitor their upstream themselves.
+// The observatory reports it, and marks the Outbound dead without probing when the upstream is down.
+type HealthReporter interface {
+	// Health returns the upstream in use and whether it is usable. An empty upstream means it is not known.
+	Health() (upstream string, alive bool)
+}
+
 type GetInbound interface {
 	GetInbound() Inbound
 }
@@ -694,6 +713,9 @@ func UnwrapRawConn(conn net.Conn) (net.Conn, stats.Counter, stats.Counter) {
 				conn = utlsConn.NetConn()
 			} else if realityConn, ok := conn.(*reality.Conn); ok {
 				conn = realityConn.NetConn()
+				if helloConn, ok := conn.(*reality.HelloConn); ok {
+					conn = helloConn.Conn // the ClientHello has been consumed by the handshake
+				}
 			} else if realityUConn, ok := conn.(*reality.UConn); ok {
 				conn = realityUConn.NetConn()
 			}
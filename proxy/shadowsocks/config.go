@@ -6,6 +6,7 @@ import (
 	"crypto/md5"
 	"crypto/sha1"
 	"io"
+	"strings"
 
 	"google.golang.org/protobuf/proto"
 
@@ -28,6 +29,24 @@ type MemoryAccount struct {
 
 var ErrIVNotUnique = errors.New("IV is not unique")
 
+// ParseCipherType returns the cipher of the name of the method, CipherType_UNKNOWN if it is not supported.
+func ParseCipherType(method string) CipherType {
+	switch strings.ToLower(method) {
+	case "aes-128-gcm", "aead_aes_128_gcm":
+		return CipherType_AES_128_GCM
+	case "aes-256-gcm", "aead_aes_256_gcm":
+		return CipherType_AES_256_GCM
+	case "chacha20-poly1305", "aead_chacha20_poly1305", "chacha20-ietf-poly1305":
+		return CipherType_CHACHA20_POLY1305
+	case "xchacha20-poly1305", "aead_xchacha20_poly1305", "xchacha20-ietf-poly1305":
+		return CipherType_XCHACHA20_POLY1305
+	case "none", "plain":
+		return CipherType_NONE
+	default:
+		return CipherType_UNKNOWN
+	}
+}
+
 // Equals implements protocol.Account.Equals().
 func (a *MemoryAccount) Equals(another protocol.Account) bool {
 	if account, ok := another.(*MemoryAccount); ok {
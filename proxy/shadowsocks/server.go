@@ -17,6 +17,7 @@ import (
 	"github.com/xtls/xray-core/core"
 	"github.com/xtls/xray-core/features/policy"
 	"github.com/xtls/xray-core/features/routing"
+	"github.com/xtls/xray-core/proxy/usersource"
 	"github.com/xtls/xray-core/transport/internet/stat"
 	"github.com/xtls/xray-core/transport/internet/udp"
 )
@@ -26,6 +27,7 @@ type Server struct {
 	validator     *Validator
 	policyManager policy.Manager
 	cone          bool
+	userSource    *usersource.Source
 }
 
 // NewServer create a new Shadowsocks server.
@@ -50,9 +52,61 @@ func NewServer(ctx context.Context, config *ServerConfig) (*Server, error) {
 		cone:          ctx.Value("cone").(bool),
 	}
 
+	if config.UserSource != nil {
+		if config.UserSource.Socket != "" {
+			return nil, errors.New("Shadowsocks users can't be queried from a socket, as they are identified by trying all of them")
+		}
+		source, err := usersource.New(ctx, config.UserSource, "shadowsocks", s, s.toUser)
+		if err != nil {
+			return nil, errors.New("failed to create user source").Base(err).AtError()
+		}
+		s.userSource = source
+	}
+
 	return s, nil
 }
 
+// toUser converts a record to a user, whose method defaults to the one of the first user of the config.
+func (s *Server) toUser(record *usersource.Record) (*protocol.MemoryUser, error) {
+	account := &Account{
+		Password:   record.Password,
+		CipherType: ParseCipherType(record.Method),
+	}
+	if record.Method == "" && len(s.config.Users) > 0 {
+		if first, err := s.config.Users[0].Account.GetInstance(); err == nil {
+			account.CipherType = first.(*Account).CipherType
+		}
+	}
+	if account.CipherType == CipherType_UNKNOWN {
+		return nil, errors.New("unknown cipher method: ", record.Method)
+	}
+	memoryAccount, err := account.AsAccount()
+	if err != nil {
+		return nil, err
+	}
+	return &protocol.MemoryUser{
+		Email:   record.Email,
+		Level:   record.Level,
+		Account: memoryAccount,
+	}, nil
+}
+
+// Start implements common.Runnable.
+func (s *Server) Start() error {
+	if s.userSource != nil {
+		return s.userSource.Start()
+	}
+	return nil
+}
+
+// Close implements common.Closable.
+func (s *Server) Close() error {
+	if s.userSource != nil {
+		return s.userSource.Close()
+	}
+	return nil
+}
+
 // AddUser implements proxy.UserManager.AddUser().
 func (s *Server) AddUser(ctx context.Context, u *protocol.MemoryUser) error {
 	return s.validator.Add(u)
@@ -7,6 +7,7 @@ import (
 	"hash/crc64"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/xtls/xray-core/common/dice"
 	"github.com/xtls/xray-core/common/errors"
@@ -113,7 +114,11 @@ func (v *Validator) Get(bs []byte, command protocol.RequestCommand) (u *protocol
 	v.RLock()
 	defer v.RUnlock()
 
+	now := time.Now()
 	for _, user := range v.users {
+		if user.Check(now) != nil {
+			continue
+		}
 		if account := user.Account.(*MemoryAccount); account.Cipher.IsAEAD() {
 			// AEAD payload decoding requires the payload to be over 32 bytes
 			if len(bs) < 32 {
@@ -104,6 +104,13 @@ func (i *Inbound) Process(ctx context.Context, network net.Network, connection s
 }
 
 func (i *Inbound) NewConnection(ctx context.Context, conn net.Conn, metadata M.Metadata) error {
+	if isUoT(metadata.Destination) {
+		packetConn, metadata, err := newUoTConn(conn, metadata)
+		if err != nil {
+			return err
+		}
+		return i.NewPacketConnection(ctx, packetConn, metadata)
+	}
 	inbound := session.InboundFromContext(ctx)
 	inbound.User = &protocol.MemoryUser{
 		Email: i.email,
@@ -227,6 +227,13 @@ func (i *MultiUserInbound) Process(ctx context.Context, network net.Network, con
 }
 
 func (i *MultiUserInbound) NewConnection(ctx context.Context, conn net.Conn, metadata M.Metadata) error {
+	if isUoT(metadata.Destination) {
+		packetConn, metadata, err := newUoTConn(conn, metadata)
+		if err != nil {
+			return err
+		}
+		return i.NewPacketConnection(ctx, packetConn, metadata)
+	}
 	inbound := session.InboundFromContext(ctx)
 	userInt, _ := A.UserFromContext[int](ctx)
 	user := i.users[userInt]
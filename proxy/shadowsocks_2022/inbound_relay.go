@@ -144,6 +144,9 @@ func (i *RelayInbound) NewConnection(ctx context.Context, conn net.Conn, metadat
 	if err != nil {
 		return err
 	}
+	if user.OutboundTag != "" {
+		ctx = session.SetForcedOutboundTagToContext(ctx, user.OutboundTag)
+	}
 	link, err := dispatcher.Dispatch(ctx, destination)
 	if err != nil {
 		return err
@@ -171,6 +174,9 @@ func (i *RelayInbound) NewPacketConnection(ctx context.Context, conn N.PacketCon
 	if err != nil {
 		return err
 	}
+	if user.OutboundTag != "" {
+		ctx = session.SetForcedOutboundTagToContext(ctx, user.OutboundTag)
+	}
 	link, err := dispatcher.Dispatch(ctx, destination)
 	if err != nil {
 		return err
@@ -10,6 +10,7 @@ import (
 	B "github.com/sagernet/sing/common/buf"
 	"github.com/sagernet/sing/common/bufio"
 	N "github.com/sagernet/sing/common/network"
+	"github.com/sagernet/sing/common/uot"
 	"github.com/xtls/xray-core/common"
 	"github.com/xtls/xray-core/common/buf"
 	"github.com/xtls/xray-core/common/errors"
@@ -31,6 +32,7 @@ type Outbound struct {
 	ctx    context.Context
 	server net.Destination
 	method shadowsocks.Method
+	uot    *uot.Client
 }
 
 func NewClient(ctx context.Context, config *ClientConfig) (*Outbound, error) {
@@ -54,6 +56,12 @@ func NewClient(ctx context.Context, config *ClientConfig) (*Outbound, error) {
 	} else {
 		return nil, errors.New("unknown method ", config.Method)
 	}
+	if config.Uot {
+		if config.UotVersion != 0 && config.UotVersion != uot.Version {
+			return nil, errors.New("unsupported UDP-over-TCP version ", config.UotVersion)
+		}
+		o.uot = &uot.Client{Version: uint8(config.UotVersion)}
+	}
 	return o, nil
 }
 
@@ -78,6 +86,9 @@ func (o *Outbound) Process(ctx context.Context, link *transport.Link, dialer int
 
 	serverDestination := o.server
 	serverDestination.Network = network
+	if network == net.Network_UDP && o.uot != nil {
+		serverDestination.Network = net.Network_TCP
+	}
 	connection, err := dialer.Dial(ctx, serverDestination)
 	if err != nil {
 		return errors.New("failed to connect to server").Base(err)
@@ -140,6 +151,15 @@ func (o *Outbound) Process(ctx context.Context, link *transport.Link, dialer int
 			}
 		}
 
+		if o.uot != nil {
+			serverConn := o.method.DialEarlyConn(connection, uot.RequestDestination(o.uot.Version))
+			uotConn, err := o.uot.DialEarlyConn(serverConn, false, singbridge.ToSocksaddr(destination))
+			if err != nil {
+				return errors.New("failed to create UDP-over-TCP connection").Base(err)
+			}
+			return singbridge.ReturnError(bufio.CopyPacketConn(ctx, packetConn, uotConn))
+		}
+
 		serverConn := o.method.DialPacketConn(connection)
 		return singbridge.ReturnError(bufio.CopyPacketConn(ctx, packetConn, serverConn))
 	}
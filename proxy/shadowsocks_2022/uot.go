@@ -0,0 +1,27 @@
+package shadowsocks_2022
+
+import (
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+	"github.com/sagernet/sing/common/uot"
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/net"
+)
+
+func isUoT(destination M.Socksaddr) bool {
+	return destination.Fqdn == uot.MagicAddress || destination.Fqdn == uot.LegacyMagicAddress
+}
+
+// newUoTConn reads the request of a UDP-over-TCP connection, and returns it as a packet connection
+// along with the metadata of its destination
+func newUoTConn(conn net.Conn, metadata M.Metadata) (N.PacketConn, M.Metadata, error) {
+	if metadata.Destination.Fqdn == uot.LegacyMagicAddress {
+		return nil, metadata, errors.New("UDP-over-TCP version ", uot.LegacyVersion, " is not supported")
+	}
+	request, err := uot.ReadRequest(conn)
+	if err != nil {
+		return nil, metadata, errors.New("failed to read UDP-over-TCP request").Base(err)
+	}
+	metadata.Destination = request.Destination
+	return uot.NewConn(conn, *request), metadata, nil
+}
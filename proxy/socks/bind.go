@@ -0,0 +1,64 @@
+package socks
+
+import (
+	"context"
+
+	"github.com/xtls/xray-core/common/buf"
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/log"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/protocol"
+	"github.com/xtls/xray-core/common/session"
+	"github.com/xtls/xray-core/features/routing"
+	"github.com/xtls/xray-core/transport"
+	"github.com/xtls/xray-core/transport/internet/stat"
+)
+
+// processBind handles a SOCKS 5 BIND request. It is dispatched like a CONNECT request, and the outbound it is
+// routed to opens the port for the peer at the destination of the request, see session.Bind. The first reply
+// carries the port once it is opened, the second one the address of the peer once it connects, and then the
+// outbound relays between the client and the peer. Outbounds which can't open ports reject the request.
+func (s *Server) processBind(ctx context.Context, conn stat.Connection, reader buf.Reader, request *protocol.RequestHeader, defaultAddress net.Address, dispatcher routing.Dispatcher) error {
+	dest := request.Destination()
+	inbound := session.InboundFromContext(ctx)
+	errors.LogInfo(ctx, "TCP Bind request for ", dest)
+
+	bound, accepted := false, false
+	ctx = session.ContextWithBind(ctx, &session.Bind{
+		Address: defaultAddress,
+		Bound: func(addr net.Destination) error {
+			bound = true
+			return writeSocks5Response(conn, statusSuccess, addr.Address, addr.Port)
+		},
+		Accepted: func(from net.Destination) error {
+			accepted = true
+			if inbound != nil && inbound.Source.IsValid() {
+				log.Record(&log.AccessMessage{
+					From:   from,
+					To:     inbound.Source,
+					Status: log.AccessAccepted,
+					Reason: "",
+					Email:  inbound.User.Email,
+				})
+			}
+			return writeSocks5Response(conn, statusSuccess, from.Address, from.Port)
+		},
+	})
+	err := dispatcher.DispatchLink(ctx, dest, &transport.Link{
+		Reader: reader,
+		Writer: buf.NewWriter(conn),
+	})
+	switch {
+	case !bound:
+		writeSocks5Response(conn, statusNotAllowed, net.AnyIP, net.Port(0))
+		if err == nil {
+			err = errors.New("BIND request for ", dest, " is rejected by the outbound")
+		}
+	case !accepted:
+		writeSocks5Response(conn, statusGeneralFailure, net.AnyIP, net.Port(0))
+	}
+	if err != nil {
+		return errors.New("failed to dispatch BIND request").Base(err)
+	}
+	return nil
+}
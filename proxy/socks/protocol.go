@@ -32,10 +32,15 @@ const (
 	authPassword         = 0x02
 	authNoMatchingMethod = 0xFF
 
-	statusSuccess       = 0x00
-	statusCmdNotSupport = 0x07
+	statusSuccess        = 0x00
+	statusGeneralFailure = 0x01
+	statusNotAllowed     = 0x02
+	statusCmdNotSupport  = 0x07
 )
 
+// errInvalidAccount is the error of a client with wrong username or password.
+var errInvalidAccount = errors.New("invalid username or password")
+
 var addrParser = protocol.NewAddressParser(
 	protocol.AddressFamilyByte(0x01, net.AddressFamilyIPv4),
 	protocol.AddressFamilyByte(0x04, net.AddressFamilyIPv6),
@@ -47,6 +52,9 @@ type ServerSession struct {
 	address      net.Address
 	port         net.Port
 	localAddress net.Address
+
+	// bind is set by a BIND request, whose replies are sent once the port is opened.
+	bind bool
 }
 
 func (s *ServerSession) handshake4(cmd byte, reader io.Reader, writer io.Writer) (*protocol.RequestHeader, error) {
@@ -128,7 +136,7 @@ func (s *ServerSession) auth5(nMethod byte, reader io.Reader, writer io.Writer)
 
 		if !s.config.HasAccount(username, password) {
 			writeSocks5AuthenticationResponse(writer, 0x01, 0xFF)
-			return "", errors.New("invalid username or password")
+			return "", errInvalidAccount
 		}
 
 		if err := writeSocks5AuthenticationResponse(writer, 0x01, 0x00); err != nil {
@@ -175,8 +183,12 @@ func (s *ServerSession) handshake5(nMethod byte, reader io.Reader, writer net.Co
 		}
 		request.Command = protocol.RequestCommandUDP
 	case cmdTCPBind:
-		writeSocks5Response(writer, statusCmdNotSupport, net.AnyIP, net.Port(0))
-		return nil, nil, errors.New("TCP bind is not supported.")
+		if !s.config.BindEnabled {
+			writeSocks5Response(writer, statusCmdNotSupport, net.AnyIP, net.Port(0))
+			return nil, nil, errors.New("TCP bind is not enabled.")
+		}
+		request.Command = protocol.RequestCommandTCP
+		s.bind = true
 	default:
 		writeSocks5Response(writer, statusCmdNotSupport, net.AnyIP, net.Port(0))
 		return nil, nil, errors.New("unknown command ", cmd)
@@ -191,17 +203,23 @@ func (s *ServerSession) handshake5(nMethod byte, reader io.Reader, writer net.Co
 	request.Address = addr
 	request.Port = port
 
+	if s.bind {
+		return request, nil, nil
+	}
+
 	responseAddress := s.address
 	responsePort := s.port
 	var tempUDPConn *TempUDPConn
 	//nolint:gocritic // Use if else chain for clarity
 	if request.Command == protocol.RequestCommandUDP {
-		if s.config.Address != nil {
-			// Use configured IP as remote address in the response to UDP Associate
-			responseAddress = s.config.Address.AsAddress()
-		} else {
-			// Use conn.LocalAddr() IP as remote address in the response by default
-			responseAddress = s.localAddress
+		responseAddress = s.listenAddress()
+		var clientIP gonet.IP
+		if addr, ok := writer.RemoteAddr().(*net.TCPAddr); ok { // unix?
+			clientIP = addr.IP
+		}
+		if s.config.UdpStrict && !request.Address.Family().IsDomain() && !request.Address.IP().IsUnspecified() && !request.Address.IP().Equal(clientIP) {
+			writeSocks5Response(writer, statusNotAllowed, net.AnyIP, net.Port(0))
+			return nil, nil, errors.New("UDP associate for ", request.Address, " is not allowed from ", clientIP)
 		}
 		udpHub, err := internet.ListenSystemPacket(context.Background(), &net.UDPAddr{IP: responseAddress.IP(), Port: 0}, nil)
 		if err != nil {
@@ -211,7 +229,7 @@ func (s *ServerSession) handshake5(nMethod byte, reader io.Reader, writer net.Co
 		expectedRemote := &gonet.UDPAddr{}
 		// UDP Associate should not specify a domain as source IP
 		if request.Address.Family().IsDomain() || request.Address.IP().IsUnspecified() {
-			expectedRemote.IP = writer.RemoteAddr().(*net.TCPAddr).IP // unix?
+			expectedRemote.IP = clientIP
 		} else {
 			expectedRemote.IP = request.Address.IP()
 			expectedRemote.Port = int(request.Port) // 0 is allowed
@@ -226,6 +244,16 @@ func (s *ServerSession) handshake5(nMethod byte, reader io.Reader, writer net.Co
 	return request, tempUDPConn, nil
 }
 
+// listenAddress returns the address of the ports opened for UDP ASSOCIATE and BIND requests.
+func (s *ServerSession) listenAddress() net.Address {
+	if s.config.Address != nil {
+		// Use configured IP as remote address in the response
+		return s.config.Address.AsAddress()
+	}
+	// Use conn.LocalAddr() IP as remote address in the response by default
+	return s.localAddress
+}
+
 // Handshake performs a Socks4/4a/5 handshake.
 func (s *ServerSession) Handshake(reader io.Reader, writer net.Conn) (*protocol.RequestHeader, *TempUDPConn, error) {
 	buffer := buf.StackNew()
@@ -18,6 +18,7 @@ import (
 	"github.com/xtls/xray-core/core"
 	"github.com/xtls/xray-core/features/policy"
 	"github.com/xtls/xray-core/features/routing"
+	"github.com/xtls/xray-core/features/stats"
 	"github.com/xtls/xray-core/proxy"
 	"github.com/xtls/xray-core/proxy/http"
 	"github.com/xtls/xray-core/transport"
@@ -29,6 +30,7 @@ import (
 type Server struct {
 	config        *ServerConfig
 	policyManager policy.Manager
+	stats         stats.Manager
 	cone          bool
 	httpServer    *http.Server
 }
@@ -39,6 +41,7 @@ func NewServer(ctx context.Context, config *ServerConfig) (*Server, error) {
 	s := &Server{
 		config:        config,
 		policyManager: v.GetFeature(policy.ManagerType()).(policy.Manager),
+		stats:         v.GetFeature(stats.ManagerType()).(stats.Manager),
 		cone:          ctx.Value("cone").(bool),
 	}
 	httpConfig := &http.ServerConfig{
@@ -130,6 +133,14 @@ func (s *Server) processTCP(ctx context.Context, conn stat.Connection, dispatche
 				Reason: err,
 			})
 		}
+		if errors.Cause(err) == errInvalidAccount {
+			stats.PublishAuthFailure(s.stats, stats.AuthFailure{
+				InboundTag: inbound.Tag,
+				Protocol:   "socks",
+				Source:     inbound.Source.Address,
+				Reason:     "invalid_password",
+			})
+		}
 		return errors.New("failed to read request").Base(err)
 	}
 	if request.User != nil {
@@ -140,6 +151,10 @@ func (s *Server) processTCP(ctx context.Context, conn stat.Connection, dispatche
 		errors.LogInfoInner(ctx, err, "failed to clear deadline")
 	}
 
+	if svrSession.bind {
+		return s.processBind(ctx, conn, reader, request, svrSession.listenAddress(), dispatcher)
+	}
+
 	if request.Command == protocol.RequestCommandTCP {
 		dest := request.Destination()
 		errors.LogInfo(ctx, "TCP Connect request to ", dest)
@@ -169,7 +184,12 @@ func (s *Server) processTCP(ctx context.Context, conn stat.Connection, dispatche
 		if tempUDPConn == nil {
 			return errors.New("UDP associate with listen port failed")
 		}
-		tempUDPConn.SetTimeout(plcy.Timeouts.ConnectionIdle)
+		if s.config.UdpStrict {
+			// An association not used within the handshake timeout is ended
+			tempUDPConn.SetStrictTimeout(plcy.Timeouts.Handshake, plcy.Timeouts.ConnectionIdle)
+		} else {
+			tempUDPConn.SetTimeout(plcy.Timeouts.ConnectionIdle)
+		}
 		errCh := make(chan error, 1)
 		go func() {
 			errCh <- s.handleUDPPayload(ctx, tempUDPConn, dispatcher)
@@ -177,7 +197,15 @@ func (s *Server) processTCP(ctx context.Context, conn stat.Connection, dispatche
 		// Associated TCP keeps the UDP alive
 		// Close UDP if TCP connection is closed
 		// Or Close TCP if UDP is idle timeout
-		io.Copy(buf.DiscardBytes, conn)
+		if s.config.UdpStrict {
+			// Nothing but the end of the association is expected on the TCP connection
+			if mb, err := reader.ReadMultiBuffer(); err == nil {
+				buf.ReleaseMulti(mb)
+				errors.LogInfo(ctx, "unexpected data on the TCP connection of UDP associate, closing")
+			}
+		} else {
+			io.Copy(buf.DiscardBytes, conn)
+		}
 		tempUDPConn.Close()
 		return <-errCh
 	}
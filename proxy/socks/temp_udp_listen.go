@@ -25,6 +25,7 @@ type TempUDPConn struct {
 	AssociatedTCPConn net.Conn
 	ExpectedRemote    atomic.Pointer[net.UDPAddr]
 	Timer             *signal.ActivityTimer
+	idle              time.Duration
 }
 
 func (c *TempUDPConn) Read(b []byte) (n int, err error) {
@@ -38,12 +39,12 @@ func (c *TempUDPConn) Read(b []byte) (n int, err error) {
 		expected := c.ExpectedRemote.Load()
 		if remote.IP.Equal(expected.IP) {
 			if remote.Port == expected.Port {
-				c.Timer.Update()
+				c.update()
 				return
 			}
 			if expected.Port == 0 {
 				c.ExpectedRemote.Store(remote)
-				c.Timer.Update()
+				c.update()
 				return
 			}
 		}
@@ -65,6 +66,21 @@ func (c *TempUDPConn) SetTimeout(d time.Duration) {
 	}, d)
 }
 
+// SetStrictTimeout is SetTimeout for strict mode, the client must send its first packet within the first
+// timeout, then the conn is closed after idle timeout.
+func (c *TempUDPConn) SetStrictTimeout(first time.Duration, idle time.Duration) {
+	c.SetTimeout(first)
+	c.idle = idle
+}
+
+func (c *TempUDPConn) update() {
+	if c.idle > 0 {
+		c.Timer.SetTimeout(c.idle)
+		c.idle = 0
+	}
+	c.Timer.Update()
+}
+
 func (c *TempUDPConn) Close() error {
 	c.Timer.SetTimeout(0)
 	c.AssociatedTCPConn.Close()
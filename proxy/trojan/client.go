@@ -2,6 +2,7 @@ package trojan
 
 import (
 	"context"
+	"io"
 	"time"
 
 	"github.com/xtls/xray-core/common"
@@ -17,13 +18,14 @@ import (
 	"github.com/xtls/xray-core/features/policy"
 	"github.com/xtls/xray-core/transport"
 	"github.com/xtls/xray-core/transport/internet"
-	"github.com/xtls/xray-core/transport/internet/stat"
 )
 
 // Client is a inbound handler for trojan protocol
 type Client struct {
 	server        *protocol.ServerSpec
 	policyManager policy.Manager
+	shadowsocks   *protocol.MemoryUser // or nil
+	mux           *muxPool             // or nil
 }
 
 // NewClient create a new trojan client.
@@ -41,9 +43,45 @@ func NewClient(ctx context.Context, config *ClientConfig) (*Client, error) {
 		server:        server,
 		policyManager: v.GetFeature(policy.ManagerType()).(policy.Manager),
 	}
+
+	if config.Shadowsocks != nil {
+		client.shadowsocks, err = config.Shadowsocks.toShadowsocksUser()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if config.Mux != nil {
+		client.mux = &muxPool{
+			concurrency: int(config.Mux.Concurrency),
+			idleTimeout: time.Duration(config.Mux.IdleTimeout) * time.Second,
+		}
+		if client.mux.concurrency == 0 {
+			client.mux.concurrency = 8
+		}
+		if client.mux.idleTimeout == 0 {
+			client.mux.idleTimeout = 60 * time.Second
+		}
+	}
 	return client, nil
 }
 
+// newWriter returns the writer of the Trojan request on writer, which is encrypted by the shadowsocks layer if set.
+func (c *Client) newWriter(writer io.Writer) (io.Writer, error) {
+	if c.shadowsocks == nil {
+		return writer, nil
+	}
+	return newShadowsocksWriter(c.shadowsocks, writer)
+}
+
+// newReader returns the reader of the response on reader, which is decrypted by the shadowsocks layer if set.
+func (c *Client) newReader(reader io.Reader) io.Reader {
+	if c.shadowsocks == nil {
+		return reader
+	}
+	return &shadowsocksReader{Reader: reader, user: c.shadowsocks}
+}
+
 // Process implements OutboundHandler.Process().
 func (c *Client) Process(ctx context.Context, link *transport.Link, dialer internet.Dialer) error {
 	outbounds := session.OutboundsFromContext(ctx)
@@ -57,30 +95,38 @@ func (c *Client) Process(ctx context.Context, link *transport.Link, dialer inter
 	network := destination.Network
 
 	server := c.server
-	var conn stat.Connection
+	user := server.User
+	account, ok := user.Account.(*MemoryAccount)
+	if !ok {
+		return errors.New("user account is not valid")
+	}
 
-	err := retry.ExponentialBackoff(5, 100).On(func() error {
-		rawConn, err := dialer.Dial(ctx, server.Destination)
+	// conn is the connection of the request, which is a stream of a mux connection if mux is enabled
+	var conn io.ReadWriteCloser
+	var err error
+	if c.mux != nil {
+		conn, err = c.openMuxStream(ctx, dialer)
 		if err != nil {
 			return err
 		}
+	} else {
+		err = retry.ExponentialBackoff(5, 100).On(func() error {
+			rawConn, err := dialer.Dial(ctx, server.Destination)
+			if err != nil {
+				return err
+			}
 
-		conn = rawConn
-		return nil
-	})
-	if err != nil {
-		return errors.New("failed to find an available destination").AtWarning().Base(err)
+			conn = rawConn
+			return nil
+		})
+		if err != nil {
+			return errors.New("failed to find an available destination").AtWarning().Base(err)
+		}
 	}
 	errors.LogInfo(ctx, "tunneling request to ", destination, " via ", server.Destination.NetAddr())
 
 	defer conn.Close()
 
-	user := server.User
-	account, ok := user.Account.(*MemoryAccount)
-	if !ok {
-		return errors.New("user account is not valid")
-	}
-
 	var newCtx context.Context
 	var newCancel context.CancelFunc
 	if session.TimeoutOnlyFromContext(ctx) {
@@ -101,17 +147,34 @@ func (c *Client) Process(ctx context.Context, link *transport.Link, dialer inter
 
 		bufferWriter := buf.NewBufferedWriter(buf.NewWriter(conn))
 
-		connWriter := &ConnWriter{
-			Writer:  bufferWriter,
-			Target:  destination,
-			Account: account,
+		var connWriter *ConnWriter
+		var requestWriter interface {
+			io.Writer
+			buf.Writer
+		}
+		if c.mux != nil {
+			if err := writeMuxRequest(bufferWriter, destination); err != nil {
+				return errors.New("failed to write mux request").Base(err).AtWarning()
+			}
+			requestWriter = bufferWriter
+		} else {
+			writer, err := c.newWriter(bufferWriter)
+			if err != nil {
+				return errors.New("failed to write shadowsocks IV").Base(err).AtWarning()
+			}
+			connWriter = &ConnWriter{
+				Writer:  writer,
+				Target:  destination,
+				Account: account,
+			}
+			requestWriter = connWriter
 		}
 
 		var bodyWriter buf.Writer
 		if destination.Network == net.Network_UDP {
-			bodyWriter = &PacketWriter{Writer: connWriter, Target: destination}
+			bodyWriter = &PacketWriter{Writer: requestWriter, Target: destination}
 		} else {
-			bodyWriter = connWriter
+			bodyWriter = requestWriter
 		}
 
 		// write some request payload to buffer
@@ -125,8 +188,10 @@ func (c *Client) Process(ctx context.Context, link *transport.Link, dialer inter
 		}
 
 		// Send header if not sent yet
-		if _, err = connWriter.Write([]byte{}); err != nil {
-			return err.(*errors.Error).AtWarning()
+		if connWriter != nil {
+			if _, err = connWriter.Write([]byte{}); err != nil {
+				return err.(*errors.Error).AtWarning()
+			}
 		}
 
 		if err = buf.Copy(link.Reader, bodyWriter, buf.UpdateActivity(timer)); err != nil {
@@ -139,13 +204,18 @@ func (c *Client) Process(ctx context.Context, link *transport.Link, dialer inter
 	getResponse := func() error {
 		defer timer.SetTimeout(sessionPolicy.Timeouts.UplinkOnly)
 
+		var connReader io.Reader = conn
+		if c.mux == nil {
+			connReader = c.newReader(conn)
+		}
+
 		var reader buf.Reader
 		if network == net.Network_UDP {
 			reader = &PacketReader{
-				Reader: conn,
+				Reader: connReader,
 			}
 		} else {
-			reader = buf.NewReader(conn)
+			reader = buf.NewReader(connReader)
 		}
 		return buf.Copy(reader, link.Writer, buf.UpdateActivity(timer))
 	}
@@ -8,6 +8,8 @@ package trojan
 
 import (
 	protocol "github.com/xtls/xray-core/common/protocol"
+	shadowsocks "github.com/xtls/xray-core/proxy/shadowsocks"
+	usersource "github.com/xtls/xray-core/proxy/usersource"
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
 	reflect "reflect"
@@ -67,13 +69,21 @@ func (x *Account) GetPassword() string {
 }
 
 type Fallback struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
-	Alpn          string                 `protobuf:"bytes,2,opt,name=alpn,proto3" json:"alpn,omitempty"`
-	Path          string                 `protobuf:"bytes,3,opt,name=path,proto3" json:"path,omitempty"`
-	Type          string                 `protobuf:"bytes,4,opt,name=type,proto3" json:"type,omitempty"`
-	Dest          string                 `protobuf:"bytes,5,opt,name=dest,proto3" json:"dest,omitempty"`
-	Xver          uint64                 `protobuf:"varint,6,opt,name=xver,proto3" json:"xver,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Name  string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Alpn  string                 `protobuf:"bytes,2,opt,name=alpn,proto3" json:"alpn,omitempty"`
+	Path  string                 `protobuf:"bytes,3,opt,name=path,proto3" json:"path,omitempty"`
+	Type  string                 `protobuf:"bytes,4,opt,name=type,proto3" json:"type,omitempty"`
+	Dest  string                 `protobuf:"bytes,5,opt,name=dest,proto3" json:"dest,omitempty"`
+	Xver  uint64                 `protobuf:"varint,6,opt,name=xver,proto3" json:"xver,omitempty"`
+	// HTTP Host header to match, without the port.
+	Host string `protobuf:"bytes,7,opt,name=host,proto3" json:"host,omitempty"`
+	// HTTP header names to the regexps their values must match.
+	Headers map[string]string `protobuf:"bytes,8,rep,name=headers,proto3" json:"headers,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// Weighted dests to choose from instead of type and dest.
+	Dests []*Fallback_Dest `protobuf:"bytes,9,rep,name=dests,proto3" json:"dests,omitempty"`
+	// Whether to send the SNI, ALPN and the user as TLVs of PROXY protocol v2.
+	Tlv           bool `protobuf:"varint,10,opt,name=tlv,proto3" json:"tlv,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -150,16 +160,154 @@ func (x *Fallback) GetXver() uint64 {
 	return 0
 }
 
+func (x *Fallback) GetHost() string {
+	if x != nil {
+		return x.Host
+	}
+	return ""
+}
+
+func (x *Fallback) GetHeaders() map[string]string {
+	if x != nil {
+		return x.Headers
+	}
+	return nil
+}
+
+func (x *Fallback) GetDests() []*Fallback_Dest {
+	if x != nil {
+		return x.Dests
+	}
+	return nil
+}
+
+func (x *Fallback) GetTlv() bool {
+	if x != nil {
+		return x.Tlv
+	}
+	return false
+}
+
+// Shadowsocks is the AEAD layer of Trojan-Go below the Trojan protocol.
+type Shadowsocks struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	CipherType    shadowsocks.CipherType `protobuf:"varint,1,opt,name=cipher_type,json=cipherType,proto3,enum=xray.proxy.shadowsocks.CipherType" json:"cipher_type,omitempty"`
+	Password      string                 `protobuf:"bytes,2,opt,name=password,proto3" json:"password,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Shadowsocks) Reset() {
+	*x = Shadowsocks{}
+	mi := &file_proxy_trojan_config_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Shadowsocks) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Shadowsocks) ProtoMessage() {}
+
+func (x *Shadowsocks) ProtoReflect() protoreflect.Message {
+	mi := &file_proxy_trojan_config_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Shadowsocks.ProtoReflect.Descriptor instead.
+func (*Shadowsocks) Descriptor() ([]byte, []int) {
+	return file_proxy_trojan_config_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Shadowsocks) GetCipherType() shadowsocks.CipherType {
+	if x != nil {
+		return x.CipherType
+	}
+	return shadowsocks.CipherType(0)
+}
+
+func (x *Shadowsocks) GetPassword() string {
+	if x != nil {
+		return x.Password
+	}
+	return ""
+}
+
+// Mux is the smux multiplexing of Trojan-Go.
+type Mux struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Max number of streams of a session, 8 if unset.
+	Concurrency uint32 `protobuf:"varint,1,opt,name=concurrency,proto3" json:"concurrency,omitempty"`
+	// Seconds before a session without streams is closed, 60 if unset.
+	IdleTimeout   uint32 `protobuf:"varint,2,opt,name=idle_timeout,json=idleTimeout,proto3" json:"idle_timeout,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Mux) Reset() {
+	*x = Mux{}
+	mi := &file_proxy_trojan_config_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Mux) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Mux) ProtoMessage() {}
+
+func (x *Mux) ProtoReflect() protoreflect.Message {
+	mi := &file_proxy_trojan_config_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Mux.ProtoReflect.Descriptor instead.
+func (*Mux) Descriptor() ([]byte, []int) {
+	return file_proxy_trojan_config_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Mux) GetConcurrency() uint32 {
+	if x != nil {
+		return x.Concurrency
+	}
+	return 0
+}
+
+func (x *Mux) GetIdleTimeout() uint32 {
+	if x != nil {
+		return x.IdleTimeout
+	}
+	return 0
+}
+
 type ClientConfig struct {
 	state         protoimpl.MessageState   `protogen:"open.v1"`
 	Server        *protocol.ServerEndpoint `protobuf:"bytes,1,opt,name=server,proto3" json:"server,omitempty"`
+	Shadowsocks   *Shadowsocks             `protobuf:"bytes,2,opt,name=shadowsocks,proto3" json:"shadowsocks,omitempty"`
+	Mux           *Mux                     `protobuf:"bytes,3,opt,name=mux,proto3" json:"mux,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *ClientConfig) Reset() {
 	*x = ClientConfig{}
-	mi := &file_proxy_trojan_config_proto_msgTypes[2]
+	mi := &file_proxy_trojan_config_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -171,7 +319,7 @@ func (x *ClientConfig) String() string {
 func (*ClientConfig) ProtoMessage() {}
 
 func (x *ClientConfig) ProtoReflect() protoreflect.Message {
-	mi := &file_proxy_trojan_config_proto_msgTypes[2]
+	mi := &file_proxy_trojan_config_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -184,7 +332,7 @@ func (x *ClientConfig) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ClientConfig.ProtoReflect.Descriptor instead.
 func (*ClientConfig) Descriptor() ([]byte, []int) {
-	return file_proxy_trojan_config_proto_rawDescGZIP(), []int{2}
+	return file_proxy_trojan_config_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *ClientConfig) GetServer() *protocol.ServerEndpoint {
@@ -194,17 +342,33 @@ func (x *ClientConfig) GetServer() *protocol.ServerEndpoint {
 	return nil
 }
 
+func (x *ClientConfig) GetShadowsocks() *Shadowsocks {
+	if x != nil {
+		return x.Shadowsocks
+	}
+	return nil
+}
+
+func (x *ClientConfig) GetMux() *Mux {
+	if x != nil {
+		return x.Mux
+	}
+	return nil
+}
+
 type ServerConfig struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Users         []*protocol.User       `protobuf:"bytes,1,rep,name=users,proto3" json:"users,omitempty"`
 	Fallbacks     []*Fallback            `protobuf:"bytes,2,rep,name=fallbacks,proto3" json:"fallbacks,omitempty"`
+	UserSource    *usersource.Config     `protobuf:"bytes,3,opt,name=user_source,json=userSource,proto3" json:"user_source,omitempty"`
+	Shadowsocks   *Shadowsocks           `protobuf:"bytes,4,opt,name=shadowsocks,proto3" json:"shadowsocks,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *ServerConfig) Reset() {
 	*x = ServerConfig{}
-	mi := &file_proxy_trojan_config_proto_msgTypes[3]
+	mi := &file_proxy_trojan_config_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -216,7 +380,7 @@ func (x *ServerConfig) String() string {
 func (*ServerConfig) ProtoMessage() {}
 
 func (x *ServerConfig) ProtoReflect() protoreflect.Message {
-	mi := &file_proxy_trojan_config_proto_msgTypes[3]
+	mi := &file_proxy_trojan_config_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -229,7 +393,7 @@ func (x *ServerConfig) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ServerConfig.ProtoReflect.Descriptor instead.
 func (*ServerConfig) Descriptor() ([]byte, []int) {
-	return file_proxy_trojan_config_proto_rawDescGZIP(), []int{3}
+	return file_proxy_trojan_config_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *ServerConfig) GetUsers() []*protocol.User {
@@ -246,25 +410,124 @@ func (x *ServerConfig) GetFallbacks() []*Fallback {
 	return nil
 }
 
+func (x *ServerConfig) GetUserSource() *usersource.Config {
+	if x != nil {
+		return x.UserSource
+	}
+	return nil
+}
+
+func (x *ServerConfig) GetShadowsocks() *Shadowsocks {
+	if x != nil {
+		return x.Shadowsocks
+	}
+	return nil
+}
+
+type Fallback_Dest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Type  string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	Dest  string                 `protobuf:"bytes,2,opt,name=dest,proto3" json:"dest,omitempty"`
+	// 1 if unset.
+	Weight        uint32 `protobuf:"varint,3,opt,name=weight,proto3" json:"weight,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Fallback_Dest) Reset() {
+	*x = Fallback_Dest{}
+	mi := &file_proxy_trojan_config_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Fallback_Dest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Fallback_Dest) ProtoMessage() {}
+
+func (x *Fallback_Dest) ProtoReflect() protoreflect.Message {
+	mi := &file_proxy_trojan_config_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Fallback_Dest.ProtoReflect.Descriptor instead.
+func (*Fallback_Dest) Descriptor() ([]byte, []int) {
+	return file_proxy_trojan_config_proto_rawDescGZIP(), []int{1, 1}
+}
+
+func (x *Fallback_Dest) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Fallback_Dest) GetDest() string {
+	if x != nil {
+		return x.Dest
+	}
+	return ""
+}
+
+func (x *Fallback_Dest) GetWeight() uint32 {
+	if x != nil {
+		return x.Weight
+	}
+	return 0
+}
+
 var File_proxy_trojan_config_proto protoreflect.FileDescriptor
 
 const file_proxy_trojan_config_proto_rawDesc = "" +
 	"\n" +
-	"\x19proxy/trojan/config.proto\x12\x11xray.proxy.trojan\x1a\x1acommon/protocol/user.proto\x1a!common/protocol/server_spec.proto\"%\n" +
+	"\x19proxy/trojan/config.proto\x12\x11xray.proxy.trojan\x1a\x1acommon/protocol/user.proto\x1a\x1dproxy/usersource/config.proto\x1a!common/protocol/server_spec.proto\x1a\x1eproxy/shadowsocks/config.proto\"%\n" +
 	"\aAccount\x12\x1a\n" +
-	"\bpassword\x18\x01 \x01(\tR\bpassword\"\x82\x01\n" +
+	"\bpassword\x18\x01 \x01(\tR\bpassword\"\xa8\x03\n" +
 	"\bFallback\x12\x12\n" +
 	"\x04name\x18\x01 \x01(\tR\x04name\x12\x12\n" +
 	"\x04alpn\x18\x02 \x01(\tR\x04alpn\x12\x12\n" +
 	"\x04path\x18\x03 \x01(\tR\x04path\x12\x12\n" +
 	"\x04type\x18\x04 \x01(\tR\x04type\x12\x12\n" +
 	"\x04dest\x18\x05 \x01(\tR\x04dest\x12\x12\n" +
-	"\x04xver\x18\x06 \x01(\x04R\x04xver\"L\n" +
+	"\x04xver\x18\x06 \x01(\x04R\x04xver\x12\x12\n" +
+	"\x04host\x18\a \x01(\tR\x04host\x12B\n" +
+	"\aheaders\x18\b \x03(\v2(.xray.proxy.trojan.Fallback.HeadersEntryR\aheaders\x126\n" +
+	"\x05dests\x18\t \x03(\v2 .xray.proxy.trojan.Fallback.DestR\x05dests\x12\x10\n" +
+	"\x03tlv\x18\n" +
+	" \x01(\bR\x03tlv\x1a:\n" +
+	"\fHeadersEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\x1aF\n" +
+	"\x04Dest\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12\x12\n" +
+	"\x04dest\x18\x02 \x01(\tR\x04dest\x12\x16\n" +
+	"\x06weight\x18\x03 \x01(\rR\x06weight\"n\n" +
+	"\vShadowsocks\x12C\n" +
+	"\vcipher_type\x18\x01 \x01(\x0e2\".xray.proxy.shadowsocks.CipherTypeR\n" +
+	"cipherType\x12\x1a\n" +
+	"\bpassword\x18\x02 \x01(\tR\bpassword\"J\n" +
+	"\x03Mux\x12 \n" +
+	"\vconcurrency\x18\x01 \x01(\rR\vconcurrency\x12!\n" +
+	"\fidle_timeout\x18\x02 \x01(\rR\vidleTimeout\"\xb8\x01\n" +
 	"\fClientConfig\x12<\n" +
-	"\x06server\x18\x01 \x01(\v2$.xray.common.protocol.ServerEndpointR\x06server\"{\n" +
+	"\x06server\x18\x01 \x01(\v2$.xray.common.protocol.ServerEndpointR\x06server\x12@\n" +
+	"\vshadowsocks\x18\x02 \x01(\v2\x1e.xray.proxy.trojan.ShadowsocksR\vshadowsocks\x12(\n" +
+	"\x03mux\x18\x03 \x01(\v2\x16.xray.proxy.trojan.MuxR\x03mux\"\xfd\x01\n" +
 	"\fServerConfig\x120\n" +
 	"\x05users\x18\x01 \x03(\v2\x1a.xray.common.protocol.UserR\x05users\x129\n" +
-	"\tfallbacks\x18\x02 \x03(\v2\x1b.xray.proxy.trojan.FallbackR\tfallbacksBU\n" +
+	"\tfallbacks\x18\x02 \x03(\v2\x1b.xray.proxy.trojan.FallbackR\tfallbacks\x12>\n" +
+	"\vuser_source\x18\x03 \x01(\v2\x1d.xray.proxy.usersource.ConfigR\n" +
+	"userSource\x12@\n" +
+	"\vshadowsocks\x18\x04 \x01(\v2\x1e.xray.proxy.trojan.ShadowsocksR\vshadowsocksBU\n" +
 	"\x15com.xray.proxy.trojanP\x01Z&github.com/xtls/xray-core/proxy/trojan\xaa\x02\x11Xray.Proxy.Trojanb\x06proto3"
 
 var (
@@ -279,24 +542,37 @@ func file_proxy_trojan_config_proto_rawDescGZIP() []byte {
 	return file_proxy_trojan_config_proto_rawDescData
 }
 
-var file_proxy_trojan_config_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_proxy_trojan_config_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
 var file_proxy_trojan_config_proto_goTypes = []any{
 	(*Account)(nil),                 // 0: xray.proxy.trojan.Account
 	(*Fallback)(nil),                // 1: xray.proxy.trojan.Fallback
-	(*ClientConfig)(nil),            // 2: xray.proxy.trojan.ClientConfig
-	(*ServerConfig)(nil),            // 3: xray.proxy.trojan.ServerConfig
-	(*protocol.ServerEndpoint)(nil), // 4: xray.common.protocol.ServerEndpoint
-	(*protocol.User)(nil),           // 5: xray.common.protocol.User
+	(*Shadowsocks)(nil),             // 2: xray.proxy.trojan.Shadowsocks
+	(*Mux)(nil),                     // 3: xray.proxy.trojan.Mux
+	(*ClientConfig)(nil),            // 4: xray.proxy.trojan.ClientConfig
+	(*ServerConfig)(nil),            // 5: xray.proxy.trojan.ServerConfig
+	nil,                             // 6: xray.proxy.trojan.Fallback.HeadersEntry
+	(*Fallback_Dest)(nil),           // 7: xray.proxy.trojan.Fallback.Dest
+	(shadowsocks.CipherType)(0),     // 8: xray.proxy.shadowsocks.CipherType
+	(*protocol.ServerEndpoint)(nil), // 9: xray.common.protocol.ServerEndpoint
+	(*protocol.User)(nil),           // 10: xray.common.protocol.User
+	(*usersource.Config)(nil),       // 11: xray.proxy.usersource.Config
 }
 var file_proxy_trojan_config_proto_depIdxs = []int32{
-	4, // 0: xray.proxy.trojan.ClientConfig.server:type_name -> xray.common.protocol.ServerEndpoint
-	5, // 1: xray.proxy.trojan.ServerConfig.users:type_name -> xray.common.protocol.User
-	1, // 2: xray.proxy.trojan.ServerConfig.fallbacks:type_name -> xray.proxy.trojan.Fallback
-	3, // [3:3] is the sub-list for method output_type
-	3, // [3:3] is the sub-list for method input_type
-	3, // [3:3] is the sub-list for extension type_name
-	3, // [3:3] is the sub-list for extension extendee
-	0, // [0:3] is the sub-list for field type_name
+	6,  // 0: xray.proxy.trojan.Fallback.headers:type_name -> xray.proxy.trojan.Fallback.HeadersEntry
+	7,  // 1: xray.proxy.trojan.Fallback.dests:type_name -> xray.proxy.trojan.Fallback.Dest
+	8,  // 2: xray.proxy.trojan.Shadowsocks.cipher_type:type_name -> xray.proxy.shadowsocks.CipherType
+	9,  // 3: xray.proxy.trojan.ClientConfig.server:type_name -> xray.common.protocol.ServerEndpoint
+	2,  // 4: xray.proxy.trojan.ClientConfig.shadowsocks:type_name -> xray.proxy.trojan.Shadowsocks
+	3,  // 5: xray.proxy.trojan.ClientConfig.mux:type_name -> xray.proxy.trojan.Mux
+	10, // 6: xray.proxy.trojan.ServerConfig.users:type_name -> xray.common.protocol.User
+	1,  // 7: xray.proxy.trojan.ServerConfig.fallbacks:type_name -> xray.proxy.trojan.Fallback
+	11, // 8: xray.proxy.trojan.ServerConfig.user_source:type_name -> xray.proxy.usersource.Config
+	2,  // 9: xray.proxy.trojan.ServerConfig.shadowsocks:type_name -> xray.proxy.trojan.Shadowsocks
+	10, // [10:10] is the sub-list for method output_type
+	10, // [10:10] is the sub-list for method input_type
+	10, // [10:10] is the sub-list for extension type_name
+	10, // [10:10] is the sub-list for extension extendee
+	0,  // [0:10] is the sub-list for field type_name
 }
 
 func init() { file_proxy_trojan_config_proto_init() }
@@ -310,7 +586,7 @@ func file_proxy_trojan_config_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proxy_trojan_config_proto_rawDesc), len(file_proxy_trojan_config_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   4,
+			NumMessages:   8,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
@@ -0,0 +1,195 @@
+package trojan
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/xtls/xray-core/common/buf"
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/log"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/retry"
+	"github.com/xtls/xray-core/common/session"
+	"github.com/xtls/xray-core/features/policy"
+	"github.com/xtls/xray-core/features/routing"
+	"github.com/xtls/xray-core/transport/internet"
+)
+
+// muxTarget is the target in the Trojan request of a Trojan-Go mux connection.
+var muxTarget = net.Destination{Network: net.Network_TCP, Address: net.DomainAddress("MUX_CONN")}
+
+// writeMuxRequest writes the simplesocks request, which starts every stream of a Trojan-Go mux connection.
+func writeMuxRequest(writer io.Writer, target net.Destination) error {
+	buffer := buf.StackNew()
+	defer buffer.Release()
+
+	command := commandTCP
+	if target.Network == net.Network_UDP {
+		command = commandUDP
+	}
+	if err := buffer.WriteByte(command); err != nil {
+		return err
+	}
+	if err := addrParser.WriteAddressPort(&buffer, target.Address, target.Port); err != nil {
+		return err
+	}
+	_, err := writer.Write(buffer.Bytes())
+	return err
+}
+
+// readMuxRequest reads the simplesocks request of a stream.
+func readMuxRequest(reader io.Reader) (net.Destination, error) {
+	var command [1]byte
+	if _, err := io.ReadFull(reader, command[:]); err != nil {
+		return net.Destination{}, errors.New("failed to read command").Base(err)
+	}
+
+	network := net.Network_TCP
+	switch command[0] {
+	case commandTCP:
+	case commandUDP:
+		network = net.Network_UDP
+	default:
+		return net.Destination{}, errors.New("unknown command ", command[0])
+	}
+
+	addr, port, err := addrParser.ReadAddressPort(nil, reader)
+	if err != nil {
+		return net.Destination{}, errors.New("failed to read address and port").Base(err)
+	}
+	return net.Destination{Network: network, Address: addr, Port: port}, nil
+}
+
+// handleMux serves the streams of a Trojan-Go mux connection until it is closed.
+func (s *Server) handleMux(ctx context.Context, sessionPolicy policy.Session, clientReader io.Reader, clientWriter buf.Writer, dispatcher routing.Dispatcher) error {
+	mux := newSmuxSession(ctx, clientReader, clientWriter, nil, false)
+	defer mux.Close()
+
+	errors.LogInfo(ctx, "received mux connection")
+	for {
+		stream, err := mux.Accept()
+		if err != nil {
+			return nil
+		}
+		go s.handleMuxStream(session.SubContextFromMuxInbound(ctx), sessionPolicy, stream, dispatcher)
+	}
+}
+
+func (s *Server) handleMuxStream(ctx context.Context, sessionPolicy policy.Session, stream *smuxStream, dispatcher routing.Dispatcher) {
+	defer stream.Close()
+
+	destination, err := readMuxRequest(stream)
+	if err != nil {
+		errors.LogInfoInner(ctx, err, "failed to read mux request")
+		return
+	}
+
+	inbound := session.InboundFromContext(ctx)
+	if destination.Network == net.Network_UDP {
+		err = s.handleUDPPayload(ctx, sessionPolicy, &PacketReader{Reader: stream}, &PacketWriter{Writer: stream}, dispatcher)
+	} else {
+		ctx = log.ContextWithAccessMessage(ctx, &log.AccessMessage{
+			From:   inbound.Source,
+			To:     destination,
+			Status: log.AccessAccepted,
+			Reason: "",
+			Email:  inbound.User.Email,
+		})
+		errors.LogInfo(ctx, "received mux request for ", destination)
+		err = s.handleConnection(ctx, sessionPolicy, destination, stream, stream, dispatcher)
+	}
+	if err != nil {
+		errors.LogInfoInner(ctx, err, "mux stream ends")
+	}
+}
+
+// muxPool keeps the Trojan-Go mux connections of a client.
+type muxPool struct {
+	access      sync.Mutex
+	sessions    []*smuxSession
+	concurrency int
+	idleTimeout time.Duration
+}
+
+// openStream opens a stream on a mux connection which has room for it, if any.
+func (p *muxPool) openStream() *smuxStream {
+	p.access.Lock()
+	defer p.access.Unlock()
+
+	sessions := p.sessions[:0]
+	for _, s := range p.sessions {
+		if !s.IsClosed() {
+			sessions = append(sessions, s)
+		}
+	}
+	p.sessions = sessions
+
+	for _, s := range p.sessions {
+		if s.NumStreams() < p.concurrency {
+			if stream, err := s.Open(); err == nil {
+				return stream
+			}
+		}
+	}
+	return nil
+}
+
+// openMuxStream opens a stream on a mux connection which has room for it, dialing a new one if there is none.
+func (c *Client) openMuxStream(ctx context.Context, dialer internet.Dialer) (*smuxStream, error) {
+	p := c.mux
+	if stream := p.openStream(); stream != nil {
+		return stream, nil
+	}
+
+	// the connection outlives the request which dials it
+	ctx = context.WithoutCancel(ctx)
+	var conn net.Conn
+	err := retry.ExponentialBackoff(5, 100).On(func() error {
+		rawConn, err := dialer.Dial(ctx, c.server.Destination)
+		if err != nil {
+			return err
+		}
+		conn = rawConn
+		return nil
+	})
+	if err != nil {
+		return nil, errors.New("failed to find an available destination").AtWarning().Base(err)
+	}
+	// the streams of other requests may have ended while dialing
+	if stream := p.openStream(); stream != nil {
+		conn.Close()
+		return stream, nil
+	}
+	errors.LogInfo(ctx, "created mux connection to ", c.server.Destination.NetAddr())
+
+	// the IV, the request and the first frame are sent together
+	bufferWriter := buf.NewBufferedWriter(buf.NewWriter(conn))
+	writer, err := c.newWriter(bufferWriter)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	connWriter := &ConnWriter{
+		Writer:  writer,
+		Target:  muxTarget,
+		Account: c.server.User.Account.(*MemoryAccount),
+		Mux:     true,
+	}
+	// the session outlives the request of ctx
+	s := newSmuxSession(context.Background(), c.newReader(conn), connWriter, conn, true)
+	s.idleTimeout = p.idleTimeout
+	stream, err := s.Open()
+	if err == nil {
+		err = bufferWriter.SetBuffered(false)
+	}
+	if err != nil {
+		s.Close()
+		return nil, errors.New("failed to open mux stream").Base(err).AtWarning()
+	}
+	p.access.Lock()
+	p.sessions = append(p.sessions, s)
+	p.access.Unlock()
+	return stream, nil
+}
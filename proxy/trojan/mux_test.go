@@ -0,0 +1,210 @@
+package trojan
+
+import (
+	"bytes"
+	"context"
+	"io"
+	gonet "net"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/buf"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/protocol"
+	"github.com/xtls/xray-core/proxy/shadowsocks"
+	"github.com/xtls/xray-core/transport/internet"
+	"github.com/xtls/xray-core/transport/internet/stat"
+)
+
+func TestMuxStreams(t *testing.T) {
+	clientConn, serverConn := gonet.Pipe()
+	client := newSmuxSession(context.Background(), clientConn, buf.NewWriter(clientConn), clientConn, true)
+	server := newSmuxSession(context.Background(), serverConn, buf.NewWriter(serverConn), serverConn, false)
+	defer client.Close()
+	defer server.Close()
+
+	go func() {
+		for {
+			stream, err := server.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer stream.Close()
+				dest, err := readMuxRequest(stream)
+				if err != nil {
+					return
+				}
+				common.Must2(stream.Write([]byte(dest.NetAddr())))
+				buf.Copy(stream, stream)
+			}()
+		}
+	}()
+
+	destinations := []net.Destination{
+		net.TCPDestination(net.DomainAddress("example.com"), 443),
+		net.UDPDestination(net.LocalHostIP, 53),
+	}
+	for _, dest := range destinations {
+		stream, err := client.Open()
+		common.Must(err)
+		common.Must(writeMuxRequest(stream, dest))
+
+		payload := make([]byte, 3*buf.Size)
+		for i := range payload {
+			payload[i] = byte(i)
+		}
+		common.Must2(stream.Write(payload))
+
+		response := make([]byte, len(dest.NetAddr())+len(payload))
+		common.Must2(io.ReadFull(stream, response))
+		if r := cmp.Diff(response[:len(dest.NetAddr())], []byte(dest.NetAddr())); r != "" {
+			t.Error("destination: ", r)
+		}
+		if r := cmp.Diff(response[len(dest.NetAddr()):], payload); r != "" {
+			t.Error("data: ", r)
+		}
+
+		common.Must(stream.Close())
+	}
+	if n := client.NumStreams(); n != 0 {
+		t.Error("streams: ", n)
+	}
+}
+
+func TestMuxStreamLimit(t *testing.T) {
+	clientConn, serverConn := gonet.Pipe()
+	client := newSmuxSession(context.Background(), clientConn, buf.NewWriter(clientConn), clientConn, true)
+	server := newSmuxSession(context.Background(), serverConn, buf.NewWriter(serverConn), serverConn, false)
+	defer client.Close()
+	defer server.Close()
+
+	accepted := make(chan struct{}, smuxMaxStreams+1)
+	go func() {
+		for {
+			if _, err := server.Accept(); err != nil {
+				return
+			}
+			accepted <- struct{}{}
+		}
+	}()
+	for i := 0; i < smuxMaxStreams; i++ {
+		common.Must2(client.Open())
+		<-accepted
+	}
+
+	stream, err := client.Open()
+	common.Must(err)
+	if _, err := stream.Read(make([]byte, 1)); err != io.EOF {
+		t.Error("expected the stream beyond the limit to be closed, but got ", err)
+	}
+	if n := server.NumStreams(); n != smuxMaxStreams {
+		t.Error("streams: ", n)
+	}
+}
+
+// blockingDialer fails to dial, after release for the first dial.
+type blockingDialer struct {
+	internet.Dialer
+	dialing chan struct{}
+	release chan struct{}
+}
+
+func (d *blockingDialer) Dial(ctx context.Context, dest net.Destination) (stat.Connection, error) {
+	select {
+	case d.dialing <- struct{}{}:
+		<-d.release
+	default:
+	}
+	return nil, io.ErrClosedPipe
+}
+
+func TestMuxPoolDialing(t *testing.T) {
+	clientConn, serverConn := gonet.Pipe()
+	session := newSmuxSession(context.Background(), clientConn, buf.NewWriter(clientConn), clientConn, true)
+	server := newSmuxSession(context.Background(), serverConn, buf.NewWriter(serverConn), serverConn, false)
+	defer session.Close()
+	defer server.Close()
+	go func() {
+		for {
+			if _, err := server.Accept(); err != nil {
+				return
+			}
+		}
+	}()
+
+	client := &Client{
+		server: &protocol.ServerSpec{Destination: net.TCPDestination(net.LocalHostIP, 443)},
+		mux:    &muxPool{sessions: []*smuxSession{session}, concurrency: 1},
+	}
+	first, err := client.openMuxStream(context.Background(), nil)
+	common.Must(err)
+
+	// the session is full, so the second request dials
+	dialer := &blockingDialer{dialing: make(chan struct{}, 1), release: make(chan struct{})}
+	defer close(dialer.release)
+	go client.openMuxStream(context.Background(), dialer)
+	<-dialer.dialing
+
+	// the third request must not wait for the dialing once the session has room
+	common.Must(first.Close())
+	opened := make(chan error, 1)
+	go func() {
+		_, err := client.openMuxStream(context.Background(), dialer)
+		opened <- err
+	}()
+	select {
+	case err := <-opened:
+		common.Must(err)
+	case <-time.After(time.Second):
+		t.Fatal("the mux pool is locked while dialing")
+	}
+}
+
+func TestShadowsocksLayer(t *testing.T) {
+	ss, err := (&Shadowsocks{CipherType: shadowsocks.CipherType_AES_256_GCM, Password: "ss"}).toShadowsocksUser()
+	common.Must(err)
+	account, err := (&Account{Password: "password"}).AsAccount()
+	common.Must(err)
+
+	encrypted := new(bytes.Buffer)
+	writer, err := newShadowsocksWriter(ss, encrypted)
+	common.Must(err)
+	destination := net.TCPDestination(net.DomainAddress("example.com"), 80)
+	connWriter := &ConnWriter{Writer: writer, Target: destination, Account: account.(*MemoryAccount)}
+	payload := []byte("GET / HTTP/1.1\r\n\r\n")
+	common.Must2(connWriter.Write(payload))
+
+	first := buf.New()
+	common.Must2(first.Write(encrypted.Bytes()))
+	server := &Server{shadowsocks: ss}
+	decrypted, reader, _, err := server.decryptFirst(bytes.NewReader(nil), first)
+	common.Must(err)
+	if r := cmp.Diff(decrypted.BytesTo(56), account.(*MemoryAccount).Key); r != "" {
+		t.Error("key: ", r)
+	}
+
+	connReader := &ConnReader{Reader: reader}
+	common.Must(connReader.ParseHeader())
+	if r := cmp.Diff(connReader.Target, destination); r != "" {
+		t.Error("destination: ", r)
+	}
+	data, err := buf.ReadAllToBytes(connReader)
+	common.Must(err)
+	if r := cmp.Diff(data, payload); r != "" {
+		t.Error("data: ", r)
+	}
+
+	other, err := (&Shadowsocks{CipherType: shadowsocks.CipherType_AES_256_GCM, Password: "other"}).toShadowsocksUser()
+	common.Must(err)
+	server.shadowsocks = other
+	_, _, raw, err := server.decryptFirst(bytes.NewReader(nil), first)
+	if err == nil {
+		t.Fatal("decrypted with a wrong password")
+	}
+	if r := cmp.Diff(raw.String(), string(encrypted.Bytes())); r != "" {
+		t.Error("raw: ", r)
+	}
+}
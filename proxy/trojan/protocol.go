@@ -25,6 +25,7 @@ const (
 
 	commandTCP byte = 1
 	commandUDP byte = 3
+	commandMux byte = 0x7f
 )
 
 // ConnWriter is TCP Connection Writer Wrapper for trojan protocol
@@ -32,6 +33,7 @@ type ConnWriter struct {
 	io.Writer
 	Target     net.Destination
 	Account    *MemoryAccount
+	Mux        bool
 	headerSent bool
 }
 
@@ -66,7 +68,9 @@ func (c *ConnWriter) writeHeader() error {
 	defer buffer.Release()
 
 	command := commandTCP
-	if c.Target.Network == net.Network_UDP {
+	if c.Mux {
+		command = commandMux
+	} else if c.Target.Network == net.Network_UDP {
 		command = commandUDP
 	}
 
@@ -154,6 +158,7 @@ type ConnReader struct {
 	io.Reader
 	Target       net.Destination
 	Flow         string
+	Mux          bool
 	headerParsed bool
 }
 
@@ -175,8 +180,11 @@ func (c *ConnReader) ParseHeader() error {
 	}
 
 	network := net.Network_TCP
-	if command[0] == commandUDP {
+	switch command[0] {
+	case commandUDP:
 		network = net.Network_UDP
+	case commandMux:
+		c.Mux = true
 	}
 
 	addr, port, err := addrParser.ReadAddressPort(nil, c.Reader)
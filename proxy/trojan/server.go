@@ -2,8 +2,8 @@ package trojan
 
 import (
 	"context"
+	"encoding/hex"
 	"io"
-	"strconv"
 	"strings"
 	"time"
 
@@ -14,13 +14,15 @@ import (
 	"github.com/xtls/xray-core/common/net"
 	"github.com/xtls/xray-core/common/protocol"
 	udp_proto "github.com/xtls/xray-core/common/protocol/udp"
-	"github.com/xtls/xray-core/common/retry"
 	"github.com/xtls/xray-core/common/session"
 	"github.com/xtls/xray-core/common/signal"
 	"github.com/xtls/xray-core/common/task"
 	"github.com/xtls/xray-core/core"
 	"github.com/xtls/xray-core/features/policy"
 	"github.com/xtls/xray-core/features/routing"
+	"github.com/xtls/xray-core/features/stats"
+	"github.com/xtls/xray-core/proxy/internal/fallback"
+	"github.com/xtls/xray-core/proxy/usersource"
 	"github.com/xtls/xray-core/transport/internet/reality"
 	"github.com/xtls/xray-core/transport/internet/stat"
 	"github.com/xtls/xray-core/transport/internet/tls"
@@ -36,9 +38,12 @@ func init() {
 // Server is an inbound connection handler that handles messages in trojan protocol.
 type Server struct {
 	policyManager policy.Manager
+	stats         stats.Manager
 	validator     *Validator
-	fallbacks     map[string]map[string]map[string]*Fallback // or nil
+	fallbacks     *fallback.Matcher[*Fallback] // or nil
 	cone          bool
+	userSource    *usersource.Source
+	shadowsocks   *protocol.MemoryUser // or nil
 }
 
 // NewServer creates a new trojan inbound handler.
@@ -58,63 +63,96 @@ func NewServer(ctx context.Context, config *ServerConfig) (*Server, error) {
 	v := core.MustFromContext(ctx)
 	server := &Server{
 		policyManager: v.GetFeature(policy.ManagerType()).(policy.Manager),
+		stats:         v.GetFeature(stats.ManagerType()).(stats.Manager),
 		validator:     validator,
 		cone:          ctx.Value("cone").(bool),
 	}
 
-	if config.Fallbacks != nil {
-		server.fallbacks = make(map[string]map[string]map[string]*Fallback)
-		for _, fb := range config.Fallbacks {
-			if server.fallbacks[fb.Name] == nil {
-				server.fallbacks[fb.Name] = make(map[string]map[string]*Fallback)
-			}
-			if server.fallbacks[fb.Name][fb.Alpn] == nil {
-				server.fallbacks[fb.Name][fb.Alpn] = make(map[string]*Fallback)
-			}
-			server.fallbacks[fb.Name][fb.Alpn][fb.Path] = fb
-		}
-		if server.fallbacks[""] != nil {
-			for name, apfb := range server.fallbacks {
-				if name != "" {
-					for alpn := range server.fallbacks[""] {
-						if apfb[alpn] == nil {
-							apfb[alpn] = make(map[string]*Fallback)
-						}
-					}
-				}
-			}
+	if config.Shadowsocks != nil {
+		var err error
+		server.shadowsocks, err = config.Shadowsocks.toShadowsocksUser()
+		if err != nil {
+			return nil, err
 		}
-		for _, apfb := range server.fallbacks {
-			if apfb[""] != nil {
-				for alpn, pfb := range apfb {
-					if alpn != "" { // && alpn != "h2" {
-						for path, fb := range apfb[""] {
-							if pfb[path] == nil {
-								pfb[path] = fb
-							}
-						}
-					}
-				}
-			}
+	}
+
+	if config.Fallbacks != nil {
+		fallbacks, err := fallback.NewMatcher(config.Fallbacks)
+		if err != nil {
+			return nil, errors.New("invalid fallback").Base(err).AtError()
 		}
-		if server.fallbacks[""] != nil {
-			for name, apfb := range server.fallbacks {
-				if name != "" {
-					for alpn, pfb := range server.fallbacks[""] {
-						for path, fb := range pfb {
-							if apfb[alpn][path] == nil {
-								apfb[alpn][path] = fb
-							}
-						}
-					}
-				}
-			}
+		server.fallbacks = fallbacks
+	}
+
+	if config.UserSource != nil {
+		source, err := usersource.New(ctx, config.UserSource, "trojan", server, toUser)
+		if err != nil {
+			return nil, errors.New("failed to create user source").Base(err).AtError()
 		}
+		server.userSource = source
 	}
 
 	return server, nil
 }
 
+func toUser(record *usersource.Record) (*protocol.MemoryUser, error) {
+	if record.Password == "" {
+		return nil, errors.New("empty password")
+	}
+	account, err := (&Account{Password: record.Password}).AsAccount()
+	if err != nil {
+		return nil, err
+	}
+	return &protocol.MemoryUser{
+		Email:   record.Email,
+		Level:   record.Level,
+		Account: account,
+	}, nil
+}
+
+// getUser returns the user of the hex SHA-224 hash of the password, querying the user source if it is not known
+func (s *Server) getUser(hash []byte) *protocol.MemoryUser {
+	key := hexString(hash)
+	if user := s.validator.Get(key); user != nil {
+		return user
+	}
+	if s.userSource == nil || !s.userSource.IsLookup() {
+		return nil
+	}
+	if _, err := hex.DecodeString(string(hash)); err == nil && s.userSource.Lookup(string(hash)) {
+		return s.validator.Get(key)
+	}
+	return nil
+}
+
+// authFailed publishes the authentication failure of a client with a wrong password.
+func (s *Server) authFailed(ctx context.Context) {
+	if inbound := session.InboundFromContext(ctx); inbound != nil {
+		stats.PublishAuthFailure(s.stats, stats.AuthFailure{
+			InboundTag: inbound.Tag,
+			Protocol:   "trojan",
+			Source:     inbound.Source.Address,
+			Reason:     "invalid_password",
+		})
+	}
+}
+
+// Start implements common.Runnable.
+func (s *Server) Start() error {
+	if s.userSource != nil {
+		return s.userSource.Start()
+	}
+	return nil
+}
+
+// Close implements common.Closable.
+func (s *Server) Close() error {
+	if s.userSource != nil {
+		return s.userSource.Close()
+	}
+	return nil
+}
+
 // AddUser implements proxy.UserManager.AddUser().
 func (s *Server) AddUser(ctx context.Context, u *protocol.MemoryUser) error {
 	return s.validator.Add(u)
@@ -168,12 +206,39 @@ func (s *Server) Process(ctx context.Context, network net.Network, conn stat.Con
 	}
 
 	var user *protocol.MemoryUser
+	var rejected *protocol.MemoryUser // for the fallback
+
+	isfb := s.fallbacks != nil
 
-	napfb := s.fallbacks
-	isfb := napfb != nil
+	// the Trojan request is below the shadowsocks layer of Trojan-Go if it is set
+	var clientWriter io.Writer = conn
+	trojanFirst := first
+	trojanReader := bufferedReader
+	if s.shadowsocks != nil {
+		decrypted, reader, raw, decryptErr := s.decryptFirst(conn, first)
+		if decryptErr != nil {
+			log.Record(&log.AccessMessage{
+				From:   conn.RemoteAddr(),
+				To:     "",
+				Status: log.AccessRejected,
+				Reason: decryptErr,
+			})
+			s.authFailed(ctx)
+			if isfb {
+				bufferedReader.Buffer = raw
+				return s.fallback(ctx, decryptErr, sessionPolicy, conn, iConn, first, firstLen, bufferedReader, nil)
+			}
+			buf.ReleaseMulti(raw)
+			return decryptErr
+		}
+		trojanFirst = decrypted
+		trojanReader = reader
+		// the shadowsocks password is right, so it is not a probe to fallback
+		isfb = false
+	}
 
 	shouldFallback := false
-	if firstLen < 58 || first.Byte(56) != '\r' {
+	if trojanFirst.Len() < 58 || trojanFirst.Byte(56) != '\r' {
 		// invalid protocol
 		err = errors.New("not trojan protocol")
 		log.Record(&log.AccessMessage{
@@ -185,10 +250,17 @@ func (s *Server) Process(ctx context.Context, network net.Network, conn stat.Con
 
 		shouldFallback = true
 	} else {
-		user = s.validator.Get(hexString(first.BytesTo(56)))
+		user = s.getUser(trojanFirst.BytesTo(56))
 		if user == nil {
 			// invalid user, let's fallback
 			err = errors.New("not a valid user")
+			s.authFailed(ctx)
+		} else if checkErr := user.Check(time.Now()); checkErr != nil {
+			err = errors.New("rejected user ", user.Email).Base(checkErr)
+			rejected = user
+			user = nil
+		}
+		if user == nil {
 			log.Record(&log.AccessMessage{
 				From:   conn.RemoteAddr(),
 				To:     "",
@@ -201,12 +273,18 @@ func (s *Server) Process(ctx context.Context, network net.Network, conn stat.Con
 	}
 
 	if isfb && shouldFallback {
-		return s.fallback(ctx, err, sessionPolicy, conn, iConn, napfb, first, firstLen, bufferedReader)
+		return s.fallback(ctx, err, sessionPolicy, conn, iConn, first, firstLen, bufferedReader, rejected)
 	} else if shouldFallback {
 		return errors.New("invalid protocol or invalid user")
 	}
 
-	clientReader := &ConnReader{Reader: bufferedReader}
+	if s.shadowsocks != nil {
+		if clientWriter, err = newShadowsocksWriter(s.shadowsocks, conn); err != nil {
+			return errors.New("failed to write shadowsocks IV").Base(err)
+		}
+	}
+
+	clientReader := &ConnReader{Reader: trojanReader}
 	if err := clientReader.ParseHeader(); err != nil {
 		log.Record(&log.AccessMessage{
 			From:   conn.RemoteAddr(),
@@ -228,8 +306,12 @@ func (s *Server) Process(ctx context.Context, network net.Network, conn stat.Con
 	inbound.User = user
 	sessionPolicy = s.policyManager.ForLevel(user.Level)
 
+	if clientReader.Mux {
+		return s.handleMux(ctx, sessionPolicy, clientReader, buf.NewWriter(clientWriter), dispatcher)
+	}
+
 	if destination.Network == net.Network_UDP { // handle udp request
-		return s.handleUDPPayload(ctx, sessionPolicy, &PacketReader{Reader: clientReader}, &PacketWriter{Writer: conn}, dispatcher)
+		return s.handleUDPPayload(ctx, sessionPolicy, &PacketReader{Reader: clientReader}, &PacketWriter{Writer: clientWriter}, dispatcher)
 	}
 
 	ctx = log.ContextWithAccessMessage(ctx, &log.AccessMessage{
@@ -241,7 +323,7 @@ func (s *Server) Process(ctx context.Context, network net.Network, conn stat.Con
 	})
 
 	errors.LogInfo(ctx, "received request for ", destination)
-	return s.handleConnection(ctx, sessionPolicy, destination, clientReader, buf.NewWriter(conn), dispatcher)
+	return s.handleConnection(ctx, sessionPolicy, destination, clientReader, buf.NewWriter(clientWriter), dispatcher)
 }
 
 func (s *Server) handleUDPPayload(ctx context.Context, sessionPolicy policy.Session, clientReader *PacketReader, clientWriter *PacketWriter, dispatcher routing.Dispatcher) error {
@@ -361,7 +443,8 @@ func (s *Server) handleConnection(ctx context.Context, sessionPolicy policy.Sess
 	return nil
 }
 
-func (s *Server) fallback(ctx context.Context, err error, sessionPolicy policy.Session, connection stat.Connection, iConn stat.Connection, napfb map[string]map[string]map[string]*Fallback, first *buf.Buffer, firstLen int64, reader buf.Reader) error {
+// fallback forwards the connection to the fallback matching it, with the user which was rejected if any.
+func (s *Server) fallback(ctx context.Context, err error, sessionPolicy policy.Session, connection stat.Connection, iConn stat.Connection, first *buf.Buffer, firstLen int64, reader buf.Reader, user *protocol.MemoryUser) error {
 	if err := connection.SetReadDeadline(time.Time{}); err != nil {
 		errors.LogWarningInner(ctx, err, "unable to set back read deadline")
 	}
@@ -385,82 +468,24 @@ func (s *Server) fallback(ctx context.Context, err error, sessionPolicy policy.S
 	name = strings.ToLower(name)
 	alpn = strings.ToLower(alpn)
 
-	if len(napfb) > 1 || napfb[""] == nil {
-		if name != "" && napfb[name] == nil {
-			match := ""
-			for n := range napfb {
-				if n != "" && strings.Contains(name, n) && len(n) > len(match) {
-					match = n
-				}
-			}
-			name = match
-		}
-	}
-
-	if napfb[name] == nil {
-		name = ""
-	}
-	apfb := napfb[name]
-	if apfb == nil {
-		return errors.New(`failed to find the default "name" config`).AtWarning()
-	}
-
-	if apfb[alpn] == nil {
-		alpn = ""
-	}
-	pfb := apfb[alpn]
-	if pfb == nil {
-		return errors.New(`failed to find the default "alpn" config`).AtWarning()
+	email := ""
+	if user != nil {
+		email = user.Email
 	}
+	tlvs := fallback.TLVs(name, alpn, email)
 
-	path := ""
-	if len(pfb) > 1 || pfb[""] == nil {
-		if firstLen >= 18 && first.Byte(4) != '*' { // not h2c
-			firstBytes := first.Bytes()
-			for i := 4; i <= 8; i++ { // 5 -> 9
-				if firstBytes[i] == '/' && firstBytes[i-1] == ' ' {
-					search := len(firstBytes)
-					if search > 64 {
-						search = 64 // up to about 60
-					}
-					for j := i + 1; j < search; j++ {
-						k := firstBytes[j]
-						if k == '\r' || k == '\n' { // avoid logging \r or \n
-							break
-						}
-						if k == '?' || k == ' ' {
-							path = string(firstBytes[i:j])
-							errors.LogInfo(ctx, "realPath = "+path)
-							if pfb[path] == nil {
-								path = ""
-							}
-							break
-						}
-					}
-					break
-				}
-			}
-		}
-	}
-	fb := pfb[path]
-	if fb == nil {
-		return errors.New(`failed to find the default "path" config`).AtWarning()
+	fb, err := s.fallbacks.Find(ctx, name, alpn, first, firstLen)
+	if err != nil {
+		return err
 	}
 
 	ctx, cancel := context.WithCancel(ctx)
 	timer := signal.CancelAfterInactivity(ctx, cancel, sessionPolicy.Timeouts.ConnectionIdle)
 	ctx = policy.ContextWithBufferPolicy(ctx, sessionPolicy.Buffer)
 
-	var conn net.Conn
-	if err := retry.ExponentialBackoff(5, 100).On(func() error {
-		var dialer net.Dialer
-		conn, err = dialer.DialContext(ctx, fb.Type, fb.Dest)
-		if err != nil {
-			return err
-		}
-		return nil
-	}); err != nil {
-		return errors.New("failed to dial to " + fb.Dest).Base(err).AtWarning()
+	conn, err := fallback.Dial(ctx, fb.Type, fb.Dest, fb.Dests)
+	if err != nil {
+		return err
 	}
 	defer conn.Close()
 
@@ -470,54 +495,13 @@ func (s *Server) fallback(ctx context.Context, err error, sessionPolicy policy.S
 	postRequest := func() error {
 		defer timer.SetTimeout(sessionPolicy.Timeouts.DownlinkOnly)
 		if fb.Xver != 0 {
-			ipType := 4
-			remoteAddr, remotePort, err := net.SplitHostPort(connection.RemoteAddr().String())
-			if err != nil {
-				ipType = 0
-			}
-			localAddr, localPort, err := net.SplitHostPort(connection.LocalAddr().String())
-			if err != nil {
-				ipType = 0
-			}
-			if ipType == 4 {
-				for i := 0; i < len(remoteAddr); i++ {
-					if remoteAddr[i] == ':' {
-						ipType = 6
-						break
-					}
-				}
+			if !fb.Tlv {
+				tlvs = nil
 			}
 			pro := buf.New()
 			defer pro.Release()
-			switch fb.Xver {
-			case 1:
-				if ipType == 0 {
-					common.Must2(pro.Write([]byte("PROXY UNKNOWN\r\n")))
-					break
-				}
-				if ipType == 4 {
-					common.Must2(pro.Write([]byte("PROXY TCP4 " + remoteAddr + " " + localAddr + " " + remotePort + " " + localPort + "\r\n")))
-				} else {
-					common.Must2(pro.Write([]byte("PROXY TCP6 " + remoteAddr + " " + localAddr + " " + remotePort + " " + localPort + "\r\n")))
-				}
-			case 2:
-				common.Must2(pro.Write([]byte("\x0D\x0A\x0D\x0A\x00\x0D\x0A\x51\x55\x49\x54\x0A"))) // signature
-				if ipType == 0 {
-					common.Must2(pro.Write([]byte("\x20\x00\x00\x00"))) // v2 + LOCAL + UNSPEC + UNSPEC + 0 bytes
-					break
-				}
-				if ipType == 4 {
-					common.Must2(pro.Write([]byte("\x21\x11\x00\x0C"))) // v2 + PROXY + AF_INET + STREAM + 12 bytes
-					common.Must2(pro.Write(net.ParseIP(remoteAddr).To4()))
-					common.Must2(pro.Write(net.ParseIP(localAddr).To4()))
-				} else {
-					common.Must2(pro.Write([]byte("\x21\x21\x00\x24"))) // v2 + PROXY + AF_INET6 + STREAM + 36 bytes
-					common.Must2(pro.Write(net.ParseIP(remoteAddr).To16()))
-					common.Must2(pro.Write(net.ParseIP(localAddr).To16()))
-				}
-				p1, _ := strconv.ParseUint(remotePort, 10, 16)
-				p2, _ := strconv.ParseUint(localPort, 10, 16)
-				common.Must2(pro.Write([]byte{byte(p1 >> 8), byte(p1), byte(p2 >> 8), byte(p2)}))
+			if err := fallback.WriteProxyHeader(pro, fb.Xver, connection.RemoteAddr(), connection.LocalAddr(), tlvs); err != nil {
+				return errors.New("failed to set PROXY protocol v", fb.Xver).Base(err).AtWarning()
 			}
 			if err := serverWriter.WriteMultiBuffer(buf.MultiBuffer{pro}); err != nil {
 				return errors.New("failed to set PROXY protocol v", fb.Xver).Base(err).AtWarning()
@@ -0,0 +1,92 @@
+package trojan
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/buf"
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/protocol"
+	"github.com/xtls/xray-core/proxy/shadowsocks"
+)
+
+// toShadowsocksUser returns the user which holds the cipher of the Trojan-Go shadowsocks layer.
+func (c *Shadowsocks) toShadowsocksUser() (*protocol.MemoryUser, error) {
+	account, err := (&shadowsocks.Account{
+		Password:   c.Password,
+		CipherType: c.CipherType,
+	}).AsAccount()
+	if err != nil {
+		return nil, errors.New("failed to create shadowsocks account").Base(err)
+	}
+	if !account.(*shadowsocks.MemoryAccount).Cipher.IsAEAD() {
+		return nil, errors.New("shadowsocks layer requires an AEAD cipher")
+	}
+	return &protocol.MemoryUser{Account: account}, nil
+}
+
+// newShadowsocksWriter writes the IV to writer, and returns a writer which encrypts to it.
+func newShadowsocksWriter(user *protocol.MemoryUser, writer io.Writer) (*buf.BufferedWriter, error) {
+	w, err := shadowsocks.WriteTCPResponse(&protocol.RequestHeader{User: user}, writer)
+	if err != nil {
+		return nil, err
+	}
+	bufferedWriter := buf.NewBufferedWriter(w)
+	common.Must(bufferedWriter.SetBuffered(false))
+	return bufferedWriter, nil
+}
+
+// shadowsocksReader decrypts from the underlying reader, reading the IV on the first read.
+type shadowsocksReader struct {
+	io.Reader
+	user   *protocol.MemoryUser
+	reader *buf.BufferedReader
+}
+
+func (r *shadowsocksReader) Read(b []byte) (int, error) {
+	if r.reader == nil {
+		reader, err := shadowsocks.ReadTCPResponse(r.user, r.Reader)
+		if err != nil {
+			return 0, err
+		}
+		r.reader = &buf.BufferedReader{Reader: reader}
+	}
+	return r.reader.Read(b)
+}
+
+// recordReader records what is read, so that it can be replayed.
+type recordReader struct {
+	io.Reader
+	record    buf.MultiBuffer
+	recording bool
+}
+
+func (r *recordReader) Read(b []byte) (int, error) {
+	n, err := r.Reader.Read(b)
+	if r.recording && n > 0 {
+		r.record = buf.MergeBytes(r.record, b[:n])
+	}
+	return n, err
+}
+
+// decryptFirst decrypts the first chunk of the shadowsocks layer, which starts with first and continues on conn.
+// It returns the decrypted first chunk and the reader of the rest, or the raw bytes read if decryption fails.
+func (s *Server) decryptFirst(conn io.Reader, first *buf.Buffer) (*buf.Buffer, *buf.BufferedReader, buf.MultiBuffer, error) {
+	recorder := &recordReader{Reader: conn, recording: true}
+	reader, err := shadowsocks.ReadTCPResponse(s.shadowsocks, io.MultiReader(bytes.NewReader(first.Bytes()), recorder))
+	var mb buf.MultiBuffer
+	if err == nil {
+		mb, err = reader.ReadMultiBuffer()
+	}
+	recorder.recording = false
+	if err != nil {
+		return nil, nil, append(buf.MultiBuffer{first}, recorder.record...), errors.New("failed to decrypt shadowsocks layer").Base(err)
+	}
+	buf.ReleaseMulti(recorder.record)
+
+	decrypted := buf.New()
+	mb, n := buf.SplitBytes(mb, decrypted.Extend(buf.Size))
+	decrypted.Resize(0, int32(n))
+	return decrypted, &buf.BufferedReader{Reader: reader, Buffer: append(buf.MultiBuffer{decrypted}, mb...)}, nil, nil
+}
@@ -0,0 +1,345 @@
+package trojan
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/buf"
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/signal/done"
+	"github.com/xtls/xray-core/transport/pipe"
+)
+
+/*
+smux v1 (github.com/xtaci/smux) frame format, as used by the mux of Trojan-Go
+1 byte  - version
+1 byte  - command
+2 bytes - length, little endian
+4 bytes - stream id, little endian
+n bytes - data
+*/
+
+const (
+	smuxVersion = 1
+
+	smuxSYN byte = 0
+	smuxFIN byte = 1
+	smuxPSH byte = 2
+	smuxNOP byte = 3
+
+	smuxHeaderSize        = 8
+	smuxKeepAliveInterval = 10 * time.Second
+	smuxKeepAliveTimeout  = 30 * time.Second
+	// smux v1 has no flow control per stream, so a stream whose buffer is full stops the reading of the
+	// session, and so the other streams, until it is read. The streams of a session buffer at most 32 MiB.
+	smuxStreamBuffer = 256 * 1024
+	// the SYNs of the client beyond it are answered with FIN
+	smuxMaxStreams = 128
+)
+
+// smuxSession is a smux session over a Trojan connection.
+type smuxSession struct {
+	ctx    context.Context
+	reader io.Reader
+	closer io.Closer
+	client bool
+
+	writeAccess sync.Mutex
+	writer      buf.Writer
+
+	access  sync.Mutex
+	streams map[uint32]*smuxStream
+	nextID  uint32
+	// idleSince is when the session had no streams since, it is closed after idleTimeout if set
+	idleSince   time.Time
+	idleTimeout time.Duration
+
+	accept   chan *smuxStream
+	received atomic.Bool
+	done     *done.Instance
+}
+
+func newSmuxSession(ctx context.Context, reader io.Reader, writer buf.Writer, closer io.Closer, client bool) *smuxSession {
+	s := &smuxSession{
+		ctx:       ctx,
+		reader:    reader,
+		writer:    writer,
+		closer:    closer,
+		client:    client,
+		streams:   make(map[uint32]*smuxStream),
+		nextID:    1,
+		idleSince: time.Now(),
+		accept:    make(chan *smuxStream, 16),
+		done:      done.New(),
+	}
+	go s.recvLoop()
+	go s.keepAlive()
+	return s
+}
+
+// writeFrame writes a frame in a single buffer, data must fit in it with the header.
+func (s *smuxSession) writeFrame(command byte, id uint32, data buf.MultiBuffer) error {
+	frame := buf.New()
+	b := frame.Extend(smuxHeaderSize)
+	b[0] = smuxVersion
+	b[1] = command
+	binary.LittleEndian.PutUint16(b[2:], uint16(data.Len()))
+	binary.LittleEndian.PutUint32(b[4:], id)
+	data.Copy(frame.Extend(data.Len()))
+	buf.ReleaseMulti(data)
+
+	s.writeAccess.Lock()
+	defer s.writeAccess.Unlock()
+	if s.done.Done() {
+		frame.Release()
+		return io.ErrClosedPipe
+	}
+	return s.writer.WriteMultiBuffer(buf.MultiBuffer{frame})
+}
+
+func (s *smuxSession) recvLoop() {
+	defer s.Close()
+
+	var header [smuxHeaderSize]byte
+	for {
+		if _, err := io.ReadFull(s.reader, header[:]); err != nil {
+			if errors.Cause(err) != io.EOF {
+				errors.LogDebugInner(s.ctx, err, "failed to read smux frame")
+			}
+			return
+		}
+		s.received.Store(true)
+		if header[0] != smuxVersion {
+			errors.LogInfo(s.ctx, "unsupported smux version ", header[0])
+			return
+		}
+		length := int32(binary.LittleEndian.Uint16(header[2:]))
+		id := binary.LittleEndian.Uint32(header[4:])
+
+		switch header[1] {
+		case smuxNOP:
+		case smuxSYN:
+			if !s.client {
+				s.access.Lock()
+				switch {
+				case s.streams[id] != nil:
+					s.access.Unlock()
+				case len(s.streams) >= smuxMaxStreams:
+					s.access.Unlock()
+					errors.LogInfo(s.ctx, "rejected smux stream ", id, " beyond ", smuxMaxStreams, " streams")
+					if s.writeFrame(smuxFIN, id, nil) != nil {
+						return
+					}
+				default:
+					stream := s.newStream(id)
+					s.access.Unlock()
+					select {
+					case s.accept <- stream:
+					case <-s.done.Wait():
+						return
+					}
+				}
+			}
+		case smuxFIN:
+			s.access.Lock()
+			stream := s.streams[id]
+			s.access.Unlock()
+			if stream != nil {
+				common.Close(stream.input)
+			}
+		case smuxPSH:
+			var mb buf.MultiBuffer
+			for length > 0 {
+				b := buf.New()
+				n, err := b.ReadFullFrom(s.reader, min(length, buf.Size))
+				mb = append(mb, b)
+				if err != nil {
+					buf.ReleaseMulti(mb)
+					return
+				}
+				length -= int32(n)
+			}
+			s.access.Lock()
+			stream := s.streams[id]
+			s.access.Unlock()
+			if stream == nil || stream.input.WriteMultiBuffer(mb) != nil {
+				buf.ReleaseMulti(mb)
+			}
+		default:
+			errors.LogInfo(s.ctx, "unknown smux command ", header[1])
+			return
+		}
+	}
+}
+
+// keepAlive pings the peer, and closes the session if nothing is received from the peer for
+// smuxKeepAliveTimeout or it has been idle for idleTimeout.
+func (s *smuxSession) keepAlive() {
+	ping := time.NewTicker(smuxKeepAliveInterval)
+	defer ping.Stop()
+	timeout := time.NewTicker(smuxKeepAliveTimeout)
+	defer timeout.Stop()
+	for {
+		select {
+		case <-s.done.Wait():
+			return
+		case <-ping.C:
+			if s.writeFrame(smuxNOP, 0, nil) != nil {
+				s.Close()
+				return
+			}
+			s.access.Lock()
+			idle := s.idleTimeout > 0 && len(s.streams) == 0 && time.Since(s.idleSince) >= s.idleTimeout
+			s.access.Unlock()
+			if idle {
+				s.Close()
+				return
+			}
+		case <-timeout.C:
+			if !s.received.Swap(false) {
+				errors.LogInfo(s.ctx, "smux session timed out")
+				s.Close()
+				return
+			}
+		}
+	}
+}
+
+// newStream registers a stream, it must be called with the lock held.
+func (s *smuxSession) newStream(id uint32) *smuxStream {
+	reader, writer := pipe.New(pipe.WithSizeLimit(smuxStreamBuffer))
+	stream := &smuxStream{
+		id:      id,
+		session: s,
+		input:   writer,
+		reader:  &buf.BufferedReader{Reader: reader},
+	}
+	s.streams[id] = stream
+	return stream
+}
+
+func (s *smuxSession) removeStream(id uint32) {
+	s.access.Lock()
+	defer s.access.Unlock()
+	delete(s.streams, id)
+	if len(s.streams) == 0 {
+		s.idleSince = time.Now()
+	}
+}
+
+// Open opens a stream of the client.
+func (s *smuxSession) Open() (*smuxStream, error) {
+	s.access.Lock()
+	if s.done.Done() {
+		s.access.Unlock()
+		return nil, io.ErrClosedPipe
+	}
+	s.nextID += 2
+	stream := s.newStream(s.nextID)
+	s.access.Unlock()
+	if err := s.writeFrame(smuxSYN, stream.id, nil); err != nil {
+		s.removeStream(stream.id)
+		return nil, err
+	}
+	return stream, nil
+}
+
+// Accept returns the next stream opened by the client.
+func (s *smuxSession) Accept() (*smuxStream, error) {
+	select {
+	case stream := <-s.accept:
+		return stream, nil
+	case <-s.done.Wait():
+		return nil, io.EOF
+	}
+}
+
+// NumStreams returns the number of the active streams.
+func (s *smuxSession) NumStreams() int {
+	s.access.Lock()
+	defer s.access.Unlock()
+	return len(s.streams)
+}
+
+// IsClosed returns true if the session is closed.
+func (s *smuxSession) IsClosed() bool {
+	return s.done.Done()
+}
+
+// Close closes the session and its streams.
+func (s *smuxSession) Close() error {
+	s.writeAccess.Lock()
+	if s.done.Done() {
+		s.writeAccess.Unlock()
+		return nil
+	}
+	s.done.Close()
+	s.writeAccess.Unlock()
+
+	s.access.Lock()
+	streams := s.streams
+	s.streams = make(map[uint32]*smuxStream)
+	s.access.Unlock()
+	for _, stream := range streams {
+		common.Interrupt(stream.input)
+	}
+	if s.closer != nil {
+		return s.closer.Close()
+	}
+	return nil
+}
+
+// smuxStream is a stream of a smuxSession, it implements buf.Reader, buf.Writer, io.Reader and io.Writer.
+type smuxStream struct {
+	id      uint32
+	session *smuxSession
+	input   *pipe.Writer
+	reader  *buf.BufferedReader
+	closed  atomic.Bool
+}
+
+// ReadMultiBuffer implements buf.Reader.
+func (s *smuxStream) ReadMultiBuffer() (buf.MultiBuffer, error) {
+	return s.reader.ReadMultiBuffer()
+}
+
+// Read implements io.Reader.
+func (s *smuxStream) Read(b []byte) (int, error) {
+	return s.reader.Read(b)
+}
+
+// WriteMultiBuffer implements buf.Writer.
+func (s *smuxStream) WriteMultiBuffer(mb buf.MultiBuffer) error {
+	for !mb.IsEmpty() {
+		var frame buf.MultiBuffer
+		mb, frame = buf.SplitSize(mb, buf.Size-smuxHeaderSize)
+		if err := s.session.writeFrame(smuxPSH, s.id, frame); err != nil {
+			buf.ReleaseMulti(mb)
+			return err
+		}
+	}
+	return nil
+}
+
+// Write implements io.Writer, b is written in a frame if it fits.
+func (s *smuxStream) Write(b []byte) (int, error) {
+	if err := s.WriteMultiBuffer(buf.MergeBytes(nil, b)); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// Close sends FIN to the peer and releases the stream.
+func (s *smuxStream) Close() error {
+	if s.closed.Swap(true) {
+		return nil
+	}
+	s.session.removeStream(s.id)
+	common.Interrupt(s.input)
+	return s.session.writeFrame(smuxFIN, s.id, nil)
+}
@@ -7,6 +7,7 @@
 package tun
 
 import (
+	dns "github.com/xtls/xray-core/proxy/dns"
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
 	reflect "reflect"
@@ -30,6 +31,8 @@ type Config struct {
 	UserLevel              uint32                 `protobuf:"varint,5,opt,name=user_level,json=userLevel,proto3" json:"user_level,omitempty"`
 	AutoSystemRoutingTable []string               `protobuf:"bytes,6,rep,name=auto_system_routing_table,json=autoSystemRoutingTable,proto3" json:"auto_system_routing_table,omitempty"`
 	AutoOutboundsInterface string                 `protobuf:"bytes,7,opt,name=auto_outbounds_interface,json=autoOutboundsInterface,proto3" json:"auto_outbounds_interface,omitempty"`
+	ForwardIcmp            bool                   `protobuf:"varint,8,opt,name=forward_icmp,json=forwardIcmp,proto3" json:"forward_icmp,omitempty"`
+	DnsHijack              *DnsHijack             `protobuf:"bytes,9,opt,name=dns_hijack,json=dnsHijack,proto3" json:"dns_hijack,omitempty"`
 	unknownFields          protoimpl.UnknownFields
 	sizeCache              protoimpl.SizeCache
 }
@@ -113,11 +116,88 @@ func (x *Config) GetAutoOutboundsInterface() string {
 	return ""
 }
 
+func (x *Config) GetForwardIcmp() bool {
+	if x != nil {
+		return x.ForwardIcmp
+	}
+	return false
+}
+
+func (x *Config) GetDnsHijack() *DnsHijack {
+	if x != nil {
+		return x.DnsHijack
+	}
+	return nil
+}
+
+type DnsHijack struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// "ip:port", "ip" for port 53, or ":port" for any address. Empty means any address on port 53.
+	Address []string `protobuf:"bytes,1,rep,name=address,proto3" json:"address,omitempty"`
+	// Closes DNS over TLS connections to the addresses on port 853, so that clients fall back to plain DNS.
+	BlockDot bool `protobuf:"varint,2,opt,name=block_dot,json=blockDot,proto3" json:"block_dot,omitempty"`
+	// Rules of the dns outbound. Empty means A and AAAA are answered, others are forwarded.
+	Rule          []*dns.DNSRuleConfig `protobuf:"bytes,3,rep,name=rule,proto3" json:"rule,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DnsHijack) Reset() {
+	*x = DnsHijack{}
+	mi := &file_proxy_tun_config_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DnsHijack) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DnsHijack) ProtoMessage() {}
+
+func (x *DnsHijack) ProtoReflect() protoreflect.Message {
+	mi := &file_proxy_tun_config_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DnsHijack.ProtoReflect.Descriptor instead.
+func (*DnsHijack) Descriptor() ([]byte, []int) {
+	return file_proxy_tun_config_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *DnsHijack) GetAddress() []string {
+	if x != nil {
+		return x.Address
+	}
+	return nil
+}
+
+func (x *DnsHijack) GetBlockDot() bool {
+	if x != nil {
+		return x.BlockDot
+	}
+	return false
+}
+
+func (x *DnsHijack) GetRule() []*dns.DNSRuleConfig {
+	if x != nil {
+		return x.Rule
+	}
+	return nil
+}
+
 var File_proxy_tun_config_proto protoreflect.FileDescriptor
 
 const file_proxy_tun_config_proto_rawDesc = "" +
 	"\n" +
-	"\x16proxy/tun/config.proto\x12\x0exray.proxy.tun\"\xee\x01\n" +
+	"\x16proxy/tun/config.proto\x12\x0exray.proxy.tun\x1a\x16proxy/dns/config.proto\"\xcb\x02\n" +
 	"\x06Config\x12\x12\n" +
 	"\x04name\x18\x01 \x01(\tR\x04name\x12\x10\n" +
 	"\x03MTU\x18\x02 \x01(\rR\x03MTU\x12\x18\n" +
@@ -126,7 +206,14 @@ const file_proxy_tun_config_proto_rawDesc = "" +
 	"\n" +
 	"user_level\x18\x05 \x01(\rR\tuserLevel\x129\n" +
 	"\x19auto_system_routing_table\x18\x06 \x03(\tR\x16autoSystemRoutingTable\x128\n" +
-	"\x18auto_outbounds_interface\x18\a \x01(\tR\x16autoOutboundsInterfaceBL\n" +
+	"\x18auto_outbounds_interface\x18\a \x01(\tR\x16autoOutboundsInterface\x12!\n" +
+	"\fforward_icmp\x18\b \x01(\bR\vforwardIcmp\x128\n" +
+	"\n" +
+	"dns_hijack\x18\t \x01(\v2\x19.xray.proxy.tun.DnsHijackR\tdnsHijack\"u\n" +
+	"\tDnsHijack\x12\x18\n" +
+	"\aaddress\x18\x01 \x03(\tR\aaddress\x12\x1b\n" +
+	"\tblock_dot\x18\x02 \x01(\bR\bblockDot\x121\n" +
+	"\x04rule\x18\x03 \x03(\v2\x1d.xray.proxy.dns.DNSRuleConfigR\x04ruleBL\n" +
 	"\x12com.xray.proxy.tunP\x01Z#github.com/xtls/xray-core/proxy/tun\xaa\x02\x0eXray.Proxy.Tunb\x06proto3"
 
 var (
@@ -141,16 +228,20 @@ func file_proxy_tun_config_proto_rawDescGZIP() []byte {
 	return file_proxy_tun_config_proto_rawDescData
 }
 
-var file_proxy_tun_config_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_proxy_tun_config_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
 var file_proxy_tun_config_proto_goTypes = []any{
-	(*Config)(nil), // 0: xray.proxy.tun.Config
+	(*Config)(nil),            // 0: xray.proxy.tun.Config
+	(*DnsHijack)(nil),         // 1: xray.proxy.tun.DnsHijack
+	(*dns.DNSRuleConfig)(nil), // 2: xray.proxy.dns.DNSRuleConfig
 }
 var file_proxy_tun_config_proto_depIdxs = []int32{
-	0, // [0:0] is the sub-list for method output_type
-	0, // [0:0] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	1, // 0: xray.proxy.tun.Config.dns_hijack:type_name -> xray.proxy.tun.DnsHijack
+	2, // 1: xray.proxy.tun.DnsHijack.rule:type_name -> xray.proxy.dns.DNSRuleConfig
+	2, // [2:2] is the sub-list for method output_type
+	2, // [2:2] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
 }
 
 func init() { file_proxy_tun_config_proto_init() }
@@ -164,7 +255,7 @@ func file_proxy_tun_config_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proxy_tun_config_proto_rawDesc), len(file_proxy_tun_config_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   1,
+			NumMessages:   2,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
@@ -0,0 +1,153 @@
+package tun
+
+import (
+	"context"
+
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/log"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/net/cnc"
+	"github.com/xtls/xray-core/common/session"
+	"github.com/xtls/xray-core/features/routing"
+	"github.com/xtls/xray-core/proxy"
+	"github.com/xtls/xray-core/proxy/dns"
+	"github.com/xtls/xray-core/transport"
+	"github.com/xtls/xray-core/transport/internet/stat"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+const dotPort = net.Port(853)
+
+type dnsHijackAddress struct {
+	ip   net.Address // nil matches any address
+	port net.Port
+}
+
+// dnsHijacker answers DNS queries sent to the configured addresses from app/dns, using a dns outbound inside the stack,
+// instead of passing them to the routing like any other connection
+type dnsHijacker struct {
+	addresses  []dnsHijackAddress
+	blockDoT   bool
+	handler    proxy.Outbound
+	dispatcher routing.Dispatcher
+}
+
+func newDNSHijacker(ctx context.Context, config *DnsHijack, userLevel uint32, dispatcher routing.Dispatcher) (*dnsHijacker, error) {
+	h := &dnsHijacker{
+		blockDoT:   config.BlockDot,
+		dispatcher: dispatcher,
+	}
+
+	addresses, err := parseDNSHijackAddresses(config.Address)
+	if err != nil {
+		return nil, err
+	}
+	h.addresses = addresses
+
+	rules := config.Rule
+	if len(rules) == 0 {
+		rules = []*dns.DNSRuleConfig{
+			{Action: dns.RuleAction_Hijack, QType: []int32{int32(dnsmessage.TypeA), int32(dnsmessage.TypeAAAA)}},
+			{Action: dns.RuleAction_Direct},
+		}
+	}
+	handler, err := common.CreateObject(ctx, &dns.Config{
+		UserLevel: userLevel,
+		Rule:      rules,
+	})
+	if err != nil {
+		return nil, errors.New("[tun] failed to create dns hijack handler").Base(err)
+	}
+	h.handler = handler.(proxy.Outbound)
+
+	return h, nil
+}
+
+func parseDNSHijackAddresses(addresses []string) ([]dnsHijackAddress, error) {
+	if len(addresses) == 0 {
+		return []dnsHijackAddress{{port: 53}}, nil
+	}
+	parsed := make([]dnsHijackAddress, 0, len(addresses))
+	for _, address := range addresses {
+		host, port, err := net.SplitHostPort(address)
+		if err != nil {
+			host, port = address, "53"
+		}
+		a := dnsHijackAddress{}
+		if host != "" {
+			a.ip = net.ParseAddress(host)
+			if !a.ip.Family().IsIP() {
+				return nil, errors.New("[tun] dns hijack address must be an IP: ", address)
+			}
+		}
+		a.port, err = net.PortFromString(port)
+		if err != nil {
+			return nil, errors.New("[tun] invalid dns hijack address: ", address).Base(err)
+		}
+		parsed = append(parsed, a)
+	}
+	return parsed, nil
+}
+
+// Match reports whether a connection to the destination is to be hijacked, or blocked as DNS over TLS
+func (h *dnsHijacker) Match(destination net.Destination) (hijack bool, block bool) {
+	if destination.Network != net.Network_TCP && destination.Network != net.Network_UDP {
+		return false, false
+	}
+	for _, a := range h.addresses {
+		if a.ip != nil && !a.ip.IP().Equal(destination.Address.IP()) {
+			continue
+		}
+		if destination.Port == a.port {
+			return true, false
+		}
+		if h.blockDoT && destination.Network == net.Network_TCP && destination.Port == dotPort {
+			return false, true
+		}
+	}
+	return false, false
+}
+
+// Handle answers the queries of the connection, the ones which are not answered by app/dns are routed to the destination
+func (h *dnsHijacker) Handle(ctx context.Context, destination net.Destination, link *transport.Link) error {
+	ctx = session.ContextWithOutbounds(ctx, []*session.Outbound{{
+		OriginalTarget: destination,
+		Target:         destination,
+	}})
+	return h.handler.Process(ctx, link, &dnsHijackDialer{dispatcher: h.dispatcher})
+}
+
+// dnsHijackDialer dispatches the forwarded queries from the inbound of the connection they came from,
+// so that the routing is able to match them by protocol "dns"
+type dnsHijackDialer struct {
+	dispatcher routing.Dispatcher
+}
+
+func (d *dnsHijackDialer) Dial(ctx context.Context, destination net.Destination) (stat.Connection, error) {
+	content := &session.Content{Protocol: "dns", SkipDNSResolve: true}
+	ctx = session.ContextWithContent(ctx, content)
+	ctx = session.ContextWithOutbounds(ctx, []*session.Outbound{{}})
+	if accessMessage := log.AccessMessageFromContext(ctx); accessMessage != nil {
+		forwarded := *accessMessage
+		ctx = log.ContextWithAccessMessage(ctx, &forwarded)
+	}
+
+	link, err := d.dispatcher.Dispatch(ctx, destination)
+	if err != nil {
+		return nil, err
+	}
+	var readerOpt cnc.ConnectionOption
+	if destination.Network == net.Network_TCP {
+		readerOpt = cnc.ConnectionOutputMulti(link.Reader)
+	} else {
+		readerOpt = cnc.ConnectionOutputMultiUDP(link.Reader)
+	}
+	return cnc.NewConnection(cnc.ConnectionInputMulti(link.Writer), readerOpt), nil
+}
+
+func (d *dnsHijackDialer) DestIpAddress() net.IP {
+	return nil
+}
+
+func (d *dnsHijackDialer) SetOutboundGateway(ctx context.Context, ob *session.Outbound) {}
@@ -0,0 +1,91 @@
+package tun
+
+import (
+	"context"
+	"testing"
+
+	"github.com/xtls/xray-core/common"
+	xnet "github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/session"
+	"github.com/xtls/xray-core/transport"
+	"github.com/xtls/xray-core/transport/pipe"
+)
+
+func TestDNSHijackMatch(t *testing.T) {
+	addresses, err := parseDNSHijackAddresses([]string{"198.18.0.2", "[fd00::2]:5353", ":54"})
+	common.Must(err)
+	h := &dnsHijacker{addresses: addresses, blockDoT: true}
+
+	cases := []struct {
+		dest   xnet.Destination
+		hijack bool
+		block  bool
+	}{
+		{dest: xnet.UDPDestination(xnet.ParseAddress("198.18.0.2"), 53), hijack: true},
+		{dest: xnet.TCPDestination(xnet.ParseAddress("198.18.0.2"), 53), hijack: true},
+		{dest: xnet.TCPDestination(xnet.ParseAddress("198.18.0.2"), 853), block: true},
+		{dest: xnet.UDPDestination(xnet.ParseAddress("198.18.0.2"), 853)},
+		{dest: xnet.UDPDestination(xnet.ParseAddress("198.18.0.3"), 53)},
+		{dest: xnet.UDPDestination(xnet.ParseAddress("fd00::2"), 5353), hijack: true},
+		{dest: xnet.UDPDestination(xnet.ParseAddress("fd00::2"), 53)},
+		{dest: xnet.UDPDestination(xnet.ParseAddress("8.8.8.8"), 54), hijack: true},
+		{dest: xnet.TCPDestination(xnet.ParseAddress("8.8.8.8"), 853), block: true},
+		{dest: xnet.ICMPDestination(xnet.ParseAddress("198.18.0.2"))},
+	}
+	for _, c := range cases {
+		hijack, block := h.Match(c.dest)
+		if hijack != c.hijack || block != c.block {
+			t.Errorf("%v: got hijack=%v block=%v, want hijack=%v block=%v", c.dest, hijack, block, c.hijack, c.block)
+		}
+	}
+
+	for _, address := range []string{"example.com", "1.1.1.1:dns"} {
+		if _, err := parseDNSHijackAddresses([]string{address}); err == nil {
+			t.Errorf("expected error for %s", address)
+		}
+	}
+
+	addresses, err = parseDNSHijackAddresses(nil)
+	common.Must(err)
+	h = &dnsHijacker{addresses: addresses}
+	if hijack, _ := h.Match(xnet.UDPDestination(xnet.ParseAddress("1.1.1.1"), 53)); !hijack {
+		t.Error("port 53 of any address must be hijacked by default")
+	}
+}
+
+type recordingDispatcher struct {
+	testDispatcher
+	ctx  context.Context
+	dest xnet.Destination
+}
+
+func (d *recordingDispatcher) Dispatch(ctx context.Context, dest xnet.Destination) (*transport.Link, error) {
+	d.ctx = ctx
+	d.dest = dest
+	r, w := pipe.New()
+	return &transport.Link{Reader: r, Writer: w}, nil
+}
+
+func TestDNSHijackDialerRoutesAsDNS(t *testing.T) {
+	dispatcher := &recordingDispatcher{}
+	ctx := session.ContextWithInbound(context.Background(), &session.Inbound{Tag: "tun-in"})
+	ctx = session.ContextWithOutbounds(ctx, []*session.Outbound{{Name: "dns"}})
+
+	dest := xnet.UDPDestination(xnet.ParseAddress("8.8.8.8"), 53)
+	conn, err := (&dnsHijackDialer{dispatcher: dispatcher}).Dial(ctx, dest)
+	common.Must(err)
+	defer conn.Close()
+
+	if dispatcher.dest != dest {
+		t.Errorf("unexpected destination: %v", dispatcher.dest)
+	}
+	if content := session.ContentFromContext(dispatcher.ctx); content == nil || content.Protocol != "dns" {
+		t.Error("forwarded query not marked as dns")
+	}
+	if inbound := session.InboundFromContext(dispatcher.ctx); inbound == nil || inbound.Tag != "tun-in" {
+		t.Error("forwarded query lost its inbound")
+	}
+	if outbounds := session.OutboundsFromContext(dispatcher.ctx); len(outbounds) != 1 || outbounds[0].Name != "" {
+		t.Error("forwarded query must not reuse the outbound of the dns handler")
+	}
+}
@@ -35,6 +35,7 @@ type Handler struct {
 	sniffingRequest session.SniffingRequest
 	uplinkCounter   stats.Counter
 	downlinkCounter stats.Counter
+	dnsHijacker     *dnsHijacker
 }
 
 // ConnectionHandler interface with the only method that stack is going to push new connections to
@@ -62,6 +63,14 @@ func (t *Handler) Init(ctx context.Context, pm policy.Manager, dispatcher routin
 	t.policyManager = pm
 	t.dispatcher = dispatcher
 
+	if t.config.DnsHijack != nil {
+		hijacker, err := newDNSHijacker(ctx, t.config.DnsHijack, t.config.UserLevel, dispatcher)
+		if err != nil {
+			return err
+		}
+		t.dnsHijacker = hijacker
+	}
+
 	if len(t.tag) > 0 && pm.ForSystem().Stats.InboundUplink {
 		statsManager := core.MustFromContext(ctx).GetFeature(stats.ManagerType()).(stats.Manager)
 		name := "inbound>>>" + t.tag + ">>>traffic>>>uplink"
@@ -207,6 +216,22 @@ func (t *Handler) HandleConnection(conn net.Conn, destination net.Destination) {
 		Reader: &buf.TimeoutWrapperReader{Reader: buf.NewReader(conn)},
 		Writer: buf.NewWriter(conn),
 	}
+	if t.dnsHijacker != nil {
+		switch hijack, block := t.dnsHijacker.Match(destination); {
+		case block:
+			errors.LogInfo(ctx, "[tun] blocked DNS over TLS to ", destination)
+			return
+		case hijack:
+			if accessMessage := log.AccessMessageFromContext(ctx); accessMessage != nil {
+				accessMessage.Detour = t.tag + " >> dns hijack"
+				log.Record(accessMessage)
+			}
+			if err := t.dnsHijacker.Handle(ctx, destination, link); err != nil {
+				errors.LogInfoInner(ctx, err, "[tun] dns hijack connection closed")
+			}
+			return
+		}
+	}
 	if err := t.dispatcher.DispatchLink(ctx, destination, link); err != nil {
 		errors.LogError(ctx, errors.New("connection closed").Base(err))
 	}
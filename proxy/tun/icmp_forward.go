@@ -0,0 +1,196 @@
+package tun
+
+import (
+	"context"
+	"io"
+	"net/netip"
+	"sync"
+	"time"
+
+	"github.com/xtls/xray-core/common/buf"
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/protocol/icmp"
+)
+
+// icmp echo sessions are identified the same way as the ping sockets of the OS do
+type icmpSessionKey struct {
+	src   netip.Addr
+	dst   netip.Addr
+	ident uint16
+}
+
+// sub-handler specifically for icmp echo under main handler, when it is forwarded to the outbounds
+type icmpConnectionHandler struct {
+	sync.RWMutex
+
+	icmpConns map[icmpSessionKey]*icmpConn
+
+	handleConnection func(conn net.Conn, dest net.Destination)
+	writePacket      func(packet []byte) error
+}
+
+func newIcmpConnectionHandler(handleConnection func(conn net.Conn, dest net.Destination), writePacket func(packet []byte) error) *icmpConnectionHandler {
+	handler := &icmpConnectionHandler{
+		icmpConns:        make(map[icmpSessionKey]*icmpConn),
+		handleConnection: handleConnection,
+		writePacket:      writePacket,
+	}
+
+	return handler
+}
+
+// HandlePacket handles ICMP echo requests coming from tun, to forward to the dispatcher
+// every request is passed on as the whole IP packet, for the TTL to reach the outbound
+func (u *icmpConnectionHandler) HandlePacket(p *icmp.Packet) {
+	src, _ := netip.AddrFromSlice(p.Source)
+	dst, _ := netip.AddrFromSlice(p.Destination)
+	key := icmpSessionKey{src: src.Unmap(), dst: dst.Unmap(), ident: p.Ident()}
+	data := p.Marshal()
+
+	u.RLock()
+	conn, found := u.icmpConns[key]
+	if found {
+		select {
+		case conn.egress <- data:
+		default:
+			errors.LogDebug(context.Background(), "drop icmp with size ", len(data), " to ", dst, " > queue full")
+		}
+		u.RUnlock()
+		return
+	}
+	u.RUnlock()
+
+	u.Lock()
+	defer u.Unlock()
+
+	conn, found = u.icmpConns[key]
+	if !found {
+		conn = &icmpConn{handler: u, egress: make(chan []byte, 64), key: key}
+		u.icmpConns[key] = conn
+
+		go u.handleConnection(conn, net.ICMPDestination(net.IPAddress(key.dst.AsSlice())))
+	}
+
+	select {
+	case conn.egress <- data:
+	default:
+		errors.LogDebug(context.Background(), "drop icmp with size ", len(data), " to ", dst, " > queue full 2")
+	}
+}
+
+func (u *icmpConnectionHandler) connectionFinished(key icmpSessionKey) {
+	u.Lock()
+	conn, found := u.icmpConns[key]
+	if found {
+		delete(u.icmpConns, key)
+		close(conn.egress)
+	}
+	u.Unlock()
+}
+
+// icmp echo session abstraction
+type icmpConn struct {
+	handler *icmpConnectionHandler
+
+	egress chan []byte
+	key    icmpSessionKey
+}
+
+func (c *icmpConn) ReadMultiBuffer() (buf.MultiBuffer, error) {
+	for {
+		data, ok := <-c.egress
+		if !ok {
+			return nil, io.EOF
+		}
+
+		b := buf.New()
+		if _, err := b.Write(data); err != nil {
+			errors.LogErrorInner(context.Background(), err, "drop icmp packet to ", c.key.dst, " with size ", len(data))
+			b.Release()
+			continue
+		}
+
+		return buf.MultiBuffer{b}, nil
+	}
+}
+
+// Read packets from the connection
+func (c *icmpConn) Read(p []byte) (int, error) {
+	data, ok := <-c.egress
+	if !ok {
+		return 0, io.EOF
+	}
+	n := copy(p, data)
+	if n != len(data) {
+		return 0, io.ErrShortBuffer
+	}
+	return n, nil
+}
+
+func (c *icmpConn) WriteMultiBuffer(mb buf.MultiBuffer) error {
+	for i, b := range mb {
+		if _, err := c.Write(b.Bytes()); err != nil {
+			buf.ReleaseMulti(mb[i:])
+			return err
+		}
+		b.Release()
+	}
+	return nil
+}
+
+// Write returning echo replies and errors back, addressed to the source of the session
+func (c *icmpConn) Write(p []byte) (int, error) {
+	packet, err := icmp.ParsePacket(p)
+	if err != nil {
+		errors.LogDebugInner(context.Background(), err, "drop invalid icmp packet from ", c.key.dst)
+		return len(p), nil
+	}
+	if packet.IsIPv6() != c.key.src.Is6() {
+		return len(p), nil
+	}
+	src := c.key.src.AsSlice()
+	if packet.IsError() {
+		// the quoted request has been sent by the outbound, it must look like the one sent by the source
+		quoted, err := packet.Quoted()
+		if err != nil {
+			return len(p), nil
+		}
+		quoted.Source = src
+		packet.SetQuoted(quoted)
+	} else if !packet.IsEchoReply() {
+		return len(p), nil
+	}
+	packet.Destination = src
+
+	if err := c.handler.writePacket(packet.Marshal()); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (c *icmpConn) Close() error {
+	c.handler.connectionFinished(c.key)
+
+	return nil
+}
+
+func (c *icmpConn) LocalAddr() net.Addr {
+	return &net.IPAddr{IP: c.key.dst.AsSlice()}
+}
+
+func (c *icmpConn) RemoteAddr() net.Addr {
+	return &net.IPAddr{IP: c.key.src.AsSlice()}
+}
+
+func (c *icmpConn) SetDeadline(t time.Time) error {
+	return nil
+}
+
+func (c *icmpConn) SetReadDeadline(t time.Time) error {
+	return nil
+}
+
+func (c *icmpConn) SetWriteDeadline(t time.Time) error {
+	return nil
+}
@@ -0,0 +1,93 @@
+package tun
+
+import (
+	"net"
+	"testing"
+
+	"github.com/xtls/xray-core/common"
+	xnet "github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/protocol/icmp"
+)
+
+type testIcmpSession struct {
+	conn xnet.Conn
+	dest xnet.Destination
+}
+
+func testEchoRequest(src, dst net.IP, ident uint16, ttl uint8) *icmp.Packet {
+	p := &icmp.Packet{
+		Source:      src,
+		Destination: dst,
+		TTL:         ttl,
+		Message:     []byte{8, 0, 0, 0, 0, 0, 0, 1, 'p', 'i', 'n', 'g'},
+	}
+	p.SetIdent(ident)
+	return p
+}
+
+func TestIcmpConnectionHandlerForwardsEcho(t *testing.T) {
+	client := net.IP{10, 0, 0, 2}
+	remote := net.IP{1, 1, 1, 1}
+	outboundLocal := net.IP{192, 168, 0, 5}
+	router := net.IP{192, 168, 0, 1}
+
+	sessions := make(chan testIcmpSession, 4)
+	written := make(chan []byte, 4)
+	handler := newIcmpConnectionHandler(func(conn xnet.Conn, dest xnet.Destination) {
+		sessions <- testIcmpSession{conn: conn, dest: dest}
+	}, func(packet []byte) error {
+		written <- packet
+		return nil
+	})
+
+	handler.HandlePacket(testEchoRequest(client, remote, 0x1234, 1))
+	handler.HandlePacket(testEchoRequest(client, remote, 0x1234, 2))
+	session := <-sessions
+	if session.dest != xnet.ICMPDestination(xnet.IPAddress(remote)) {
+		t.Fatalf("unexpected destination: %v", session.dest)
+	}
+	if len(sessions) != 0 {
+		t.Fatal("requests of one ident must share a session")
+	}
+
+	conn := session.conn.(*icmpConn)
+	for _, ttl := range []uint8{1, 2} {
+		mb, err := conn.ReadMultiBuffer()
+		common.Must(err)
+		request, err := icmp.ParsePacket(mb[0].Bytes())
+		common.Must(err)
+		if !request.IsEchoRequest() || request.Ident() != 0x1234 || request.TTL != ttl {
+			t.Fatalf("unexpected request: %+v", request)
+		}
+	}
+
+	// the outbound sent the request from its own address, the error must quote the request of the client
+	sent := testEchoRequest(outboundLocal, remote, 0x1234, 1)
+	if _, err := conn.Write(icmp.NewError(router, outboundLocal, 11, 0, sent).Marshal()); err != nil {
+		t.Fatal(err)
+	}
+	reply, err := icmp.ParsePacket(<-written)
+	common.Must(err)
+	if !reply.Source.Equal(router) || !reply.Destination.Equal(client) {
+		t.Fatalf("unexpected error header: %v -> %v", reply.Source, reply.Destination)
+	}
+	quoted, err := reply.Quoted()
+	common.Must(err)
+	if !quoted.Source.Equal(client) || !quoted.Destination.Equal(remote) || quoted.Ident() != 0x1234 {
+		t.Fatalf("unexpected quoted request: %+v", quoted)
+	}
+
+	// echo requests are never sent back to the client
+	if _, err := conn.Write(sent.Marshal()); err != nil {
+		t.Fatal(err)
+	}
+	if len(written) != 0 {
+		t.Fatal("echo request written back to the stack")
+	}
+
+	common.Must(conn.Close())
+	handler.HandlePacket(testEchoRequest(client, remote, 0x1234, 3))
+	if session := <-sessions; session.conn == xnet.Conn(conn) {
+		t.Fatal("closed session reused")
+	}
+}
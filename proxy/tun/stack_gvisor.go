@@ -40,6 +40,8 @@ type stackGVisor struct {
 	handler     *Handler
 	stack       *stack.Stack
 	endpoint    stack.LinkEndpoint
+
+	icmpForwarder *icmpConnectionHandler
 }
 
 // NewStack builds new ip stack (using gVisor)
@@ -118,6 +120,9 @@ func (t *stackGVisor) Start() error {
 		udpForwarder.HandlePacket(src, dst, data)
 		return true
 	})
+	if t.handler.config.ForwardIcmp {
+		t.icmpForwarder = newIcmpConnectionHandler(t.handler.HandleConnection, t.writeRawIPPacket)
+	}
 	ipStack.SetTransportProtocolHandler(icmp.ProtocolNumber4, t.handleICMPv4Packet)
 	ipStack.SetTransportProtocolHandler(icmp.ProtocolNumber6, t.handleICMPv6Packet)
 
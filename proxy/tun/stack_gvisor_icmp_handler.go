@@ -2,6 +2,7 @@ package tun
 
 import (
 	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/protocol/icmp"
 	tunicmp "github.com/xtls/xray-core/proxy/tun/icmp"
 	"gvisor.dev/gvisor/pkg/buffer"
 	"gvisor.dev/gvisor/pkg/tcpip"
@@ -30,6 +31,17 @@ func (t *stackGVisor) handleICMPEchoPacket(netProto tcpip.NetworkProtocolNumber,
 		return true
 	}
 
+	if t.icmpForwarder != nil {
+		errors.LogDebug(t.ctx, "[tun][icmp] ", tunicmp.ProtocolLabel(netProto), " forward echo request ", srcIP, " -> ", dstIP, " id=", ident, " seq=", sequence)
+		t.icmpForwarder.HandlePacket(&icmp.Packet{
+			Source:      srcIP.AsSlice(),
+			Destination: dstIP.AsSlice(),
+			TTL:         networkPacketTTL(netProto, pkt),
+			Message:     message,
+		})
+		return true
+	}
+
 	reply, err := tunicmp.BuildLocalEchoReply(netProto, message, dstIP, srcIP)
 	if err != nil {
 		errors.LogInfoInner(t.ctx, err, "[tun] failed to build local icmp echo reply")
@@ -88,6 +100,31 @@ func (t *stackGVisor) writeRawICMPPacket(netProto tcpip.NetworkProtocolNumber, m
 	return nil
 }
 
+// writeRawIPPacket writes a whole IP packet, as sent back by the outbounds, back to the stack
+func (t *stackGVisor) writeRawIPPacket(packet []byte) error {
+	ipProtocol := header.IPv6ProtocolNumber
+	if header.IPVersion(packet) == header.IPv4Version {
+		ipProtocol = header.IPv4ProtocolNumber
+	}
+
+	if err := t.stack.WriteRawPacket(defaultNIC, ipProtocol, buffer.MakeWithData(packet)); err != nil {
+		return errors.New("failed to write raw ip packet back to stack", err)
+	}
+
+	return nil
+}
+
+func networkPacketTTL(netProto tcpip.NetworkProtocolNumber, pkt *stack.PacketBuffer) uint8 {
+	networkHeader := pkt.NetworkHeader().Slice()
+	if netProto == header.IPv4ProtocolNumber && len(networkHeader) >= header.IPv4MinimumSize {
+		return header.IPv4(networkHeader).TTL()
+	}
+	if netProto == header.IPv6ProtocolNumber && len(networkHeader) >= header.IPv6MinimumSize {
+		return header.IPv6(networkHeader).HopLimit()
+	}
+	return 64
+}
+
 func transportPacketBytes(pkt *stack.PacketBuffer) []byte {
 	headerBytes := pkt.TransportHeader().Slice()
 	payloadBytes := pkt.Data().AsRange().ToSlice()
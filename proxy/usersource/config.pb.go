@@ -0,0 +1,175 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        v6.33.5
+// source: proxy/usersource/config.proto
+
+package usersource
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Config is an external source of the users of an inbound, exactly one of file, url and socket is set.
+type Config struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Path of a JSON or CSV list of users, loaded again when it changes.
+	File string `protobuf:"bytes,1,opt,name=file,proto3" json:"file,omitempty"`
+	// HTTP URL of a JSON or CSV list of users, polled with ETag.
+	Url string `protobuf:"bytes,2,opt,name=url,proto3" json:"url,omitempty"`
+	// Path of a unix socket, queried for the users not known yet.
+	Socket string `protobuf:"bytes,3,opt,name=socket,proto3" json:"socket,omitempty"`
+	// Seconds between checks of the file or polls of the URL.
+	Interval uint32 `protobuf:"varint,4,opt,name=interval,proto3" json:"interval,omitempty"`
+	// Seconds to keep the users returned by the socket.
+	CacheTtl uint32 `protobuf:"varint,5,opt,name=cache_ttl,json=cacheTtl,proto3" json:"cache_ttl,omitempty"`
+	// Seconds to remember the users not found by the socket.
+	NegativeTtl   uint32 `protobuf:"varint,6,opt,name=negative_ttl,json=negativeTtl,proto3" json:"negative_ttl,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Config) Reset() {
+	*x = Config{}
+	mi := &file_proxy_usersource_config_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Config) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Config) ProtoMessage() {}
+
+func (x *Config) ProtoReflect() protoreflect.Message {
+	mi := &file_proxy_usersource_config_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Config.ProtoReflect.Descriptor instead.
+func (*Config) Descriptor() ([]byte, []int) {
+	return file_proxy_usersource_config_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Config) GetFile() string {
+	if x != nil {
+		return x.File
+	}
+	return ""
+}
+
+func (x *Config) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *Config) GetSocket() string {
+	if x != nil {
+		return x.Socket
+	}
+	return ""
+}
+
+func (x *Config) GetInterval() uint32 {
+	if x != nil {
+		return x.Interval
+	}
+	return 0
+}
+
+func (x *Config) GetCacheTtl() uint32 {
+	if x != nil {
+		return x.CacheTtl
+	}
+	return 0
+}
+
+func (x *Config) GetNegativeTtl() uint32 {
+	if x != nil {
+		return x.NegativeTtl
+	}
+	return 0
+}
+
+var File_proxy_usersource_config_proto protoreflect.FileDescriptor
+
+const file_proxy_usersource_config_proto_rawDesc = "" +
+	"\n" +
+	"\x1dproxy/usersource/config.proto\x12\x15xray.proxy.usersource\"\xa2\x01\n" +
+	"\x06Config\x12\x12\n" +
+	"\x04file\x18\x01 \x01(\tR\x04file\x12\x10\n" +
+	"\x03url\x18\x02 \x01(\tR\x03url\x12\x16\n" +
+	"\x06socket\x18\x03 \x01(\tR\x06socket\x12\x1a\n" +
+	"\binterval\x18\x04 \x01(\rR\binterval\x12\x1b\n" +
+	"\tcache_ttl\x18\x05 \x01(\rR\bcacheTtl\x12!\n" +
+	"\fnegative_ttl\x18\x06 \x01(\rR\vnegativeTtlBa\n" +
+	"\x19com.xray.proxy.usersourceP\x01Z*github.com/xtls/xray-core/proxy/usersource\xaa\x02\x15Xray.Proxy.UserSourceb\x06proto3"
+
+var (
+	file_proxy_usersource_config_proto_rawDescOnce sync.Once
+	file_proxy_usersource_config_proto_rawDescData []byte
+)
+
+func file_proxy_usersource_config_proto_rawDescGZIP() []byte {
+	file_proxy_usersource_config_proto_rawDescOnce.Do(func() {
+		file_proxy_usersource_config_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_proxy_usersource_config_proto_rawDesc), len(file_proxy_usersource_config_proto_rawDesc)))
+	})
+	return file_proxy_usersource_config_proto_rawDescData
+}
+
+var file_proxy_usersource_config_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_proxy_usersource_config_proto_goTypes = []any{
+	(*Config)(nil), // 0: xray.proxy.usersource.Config
+}
+var file_proxy_usersource_config_proto_depIdxs = []int32{
+	0, // [0:0] is the sub-list for method output_type
+	0, // [0:0] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_proxy_usersource_config_proto_init() }
+func file_proxy_usersource_config_proto_init() {
+	if File_proxy_usersource_config_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proxy_usersource_config_proto_rawDesc), len(file_proxy_usersource_config_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   1,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_proxy_usersource_config_proto_goTypes,
+		DependencyIndexes: file_proxy_usersource_config_proto_depIdxs,
+		MessageInfos:      file_proxy_usersource_config_proto_msgTypes,
+	}.Build()
+	File_proxy_usersource_config_proto = out.File
+	file_proxy_usersource_config_proto_goTypes = nil
+	file_proxy_usersource_config_proto_depIdxs = nil
+}
@@ -0,0 +1,173 @@
+package usersource
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xtls/xray-core/common/errors"
+)
+
+const (
+	httpTimeout   = 30 * time.Second
+	socketTimeout = 3 * time.Second
+)
+
+var httpClient = &http.Client{Timeout: httpTimeout}
+
+// loadFile syncs the users with the file if it has changed. A file being rewritten or briefly invalid keeps
+// the current users and is tried again at the next interval, so errors are logged and not returned.
+func (s *Source) loadFile() error {
+	info, err := os.Stat(s.config.File)
+	if err != nil {
+		errors.LogWarningInner(s.ctx, err, "user source: failed to read ", s.config.File)
+		return nil
+	}
+	if info.ModTime().Equal(s.modTime) && info.Size() == s.size {
+		return nil
+	}
+	data, err := os.ReadFile(s.config.File)
+	if err != nil {
+		errors.LogWarningInner(s.ctx, err, "user source: failed to read ", s.config.File)
+		return nil
+	}
+	records, err := parseRecords(data, strings.EqualFold(filepath.Ext(s.config.File), ".csv"))
+	if err != nil {
+		errors.LogWarningInner(s.ctx, err, "user source: failed to parse ", s.config.File)
+		return nil
+	}
+	s.modTime, s.size = info.ModTime(), info.Size()
+	s.sync(records)
+	return nil
+}
+
+// loadURL syncs the users with the URL unless it answers 304 Not Modified to the ETag of the last response.
+// An unreachable server keeps the current users until it is polled again.
+func (s *Source) loadURL() error {
+	ctx, cancel := context.WithTimeout(s.ctx, httpTimeout)
+	defer cancel()
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, s.config.Url, nil)
+	if err != nil {
+		errors.LogWarningInner(s.ctx, err, "user source: invalid URL")
+		return nil
+	}
+	if s.etag != "" {
+		request.Header.Set("If-None-Match", s.etag)
+	}
+	response, err := httpClient.Do(request)
+	if err != nil {
+		errors.LogWarningInner(s.ctx, err, "user source: failed to get users")
+		return nil
+	}
+	defer response.Body.Close()
+	switch response.StatusCode {
+	case http.StatusNotModified:
+		return nil
+	case http.StatusOK:
+	default:
+		errors.LogWarning(s.ctx, "user source: failed to get users: unexpected status ", response.Status)
+		return nil
+	}
+	data, err := io.ReadAll(response.Body)
+	if err != nil {
+		errors.LogWarningInner(s.ctx, err, "user source: failed to get users")
+		return nil
+	}
+	isCSV := strings.HasPrefix(response.Header.Get("Content-Type"), "text/csv") ||
+		strings.HasSuffix(strings.ToLower(request.URL.Path), ".csv")
+	records, err := parseRecords(data, isCSV)
+	if err != nil {
+		errors.LogWarningInner(s.ctx, err, "user source: failed to parse users")
+		return nil
+	}
+	s.etag = response.Header.Get("ETag")
+	s.sync(records)
+	return nil
+}
+
+// query asks the socket for the user of key, with a line of JSON {"protocol": "vless", "key": "..."}.
+// The answer is a line of JSON of the record, or null if there is no such user.
+func (s *Source) query(key string) (*Record, error) {
+	conn, err := net.DialTimeout("unix", s.config.Socket, socketTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(socketTimeout))
+
+	request, _ := json.Marshal(map[string]string{
+		"protocol": s.protocol,
+		"key":      key,
+	})
+	if _, err := conn.Write(append(request, '\n')); err != nil {
+		return nil, err
+	}
+	line, err := bufio.NewReader(conn).ReadBytes('\n')
+	if err != nil && (err != io.EOF || len(line) == 0) {
+		return nil, err
+	}
+	var record *Record
+	if err := json.Unmarshal(line, &record); err != nil {
+		return nil, errors.New("invalid answer").Base(err)
+	}
+	if record == nil || record.Email == "" {
+		return nil, nil
+	}
+	return record, nil
+}
+
+// parseRecords parses a JSON array of records, or a CSV with a header of the names of the fields of Record
+func parseRecords(data []byte, isCSV bool) ([]Record, error) {
+	if !isCSV {
+		var records []Record
+		if err := json.Unmarshal(data, &records); err != nil {
+			return nil, err
+		}
+		return records, nil
+	}
+
+	rows, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, errors.New("missing CSV header")
+	}
+	header := rows[0]
+	records := make([]Record, 0, len(rows)-1)
+	for i, row := range rows[1:] {
+		var record Record
+		for j, value := range row {
+			switch strings.ToLower(strings.TrimSpace(header[j])) {
+			case "email":
+				record.Email = value
+			case "level":
+				level, err := strconv.ParseUint(value, 10, 32)
+				if value != "" && err != nil {
+					return nil, errors.New("invalid level in line ", i+2).Base(err)
+				}
+				record.Level = uint32(level)
+			case "id":
+				record.ID = value
+			case "password":
+				record.Password = value
+			case "flow":
+				record.Flow = value
+			case "method":
+				record.Method = value
+			}
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
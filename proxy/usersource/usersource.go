@@ -0,0 +1,240 @@
+package usersource
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/protocol"
+	"github.com/xtls/xray-core/common/task"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	defaultFileInterval = 10 * time.Second
+	defaultURLInterval  = 60 * time.Second
+	defaultCacheTTL     = 10 * time.Minute
+	defaultNegativeTTL  = time.Minute
+	maxNegativeCache    = 65536
+)
+
+// Record is a user of an external source. ID is the UUID of VLESS and VMess users, and Password
+// is the password of Trojan and Shadowsocks users.
+type Record struct {
+	Email    string `json:"email"`
+	Level    uint32 `json:"level"`
+	ID       string `json:"id"`
+	Password string `json:"password"`
+	Flow     string `json:"flow"`
+	Method   string `json:"method"`
+}
+
+// UserManager is the inbound whose users are managed by a Source.
+type UserManager interface {
+	AddUser(context.Context, *protocol.MemoryUser) error
+	RemoveUser(context.Context, string) error
+}
+
+// ToUser converts a record to a user of the protocol of the inbound.
+type ToUser func(*Record) (*protocol.MemoryUser, error)
+
+// Source keeps the users of an inbound in sync with a file or an HTTP endpoint, or adds the users
+// not known yet by querying a unix socket.
+type Source struct {
+	ctx      context.Context
+	config   *Config
+	protocol string
+	manager  UserManager
+	toUser   ToUser
+	monitor  *task.Periodic
+
+	access sync.Mutex
+	// users added from the file or the URL, by lowercase email
+	users   map[string]Record
+	etag    string
+	modTime time.Time
+	size    int64
+	// expiry of the users added from the socket, by email, and of the keys not found
+	cached map[string]time.Time
+	missed map[string]time.Time
+	group  singleflight.Group
+}
+
+// New creates a Source of the users of manager, an inbound of protocol.
+func New(ctx context.Context, config *Config, protocol string, manager UserManager, toUser ToUser) (*Source, error) {
+	s := &Source{
+		ctx:      ctx,
+		config:   config,
+		protocol: protocol,
+		manager:  manager,
+		toUser:   toUser,
+		users:    make(map[string]Record),
+		cached:   make(map[string]time.Time),
+		missed:   make(map[string]time.Time),
+	}
+	var interval time.Duration
+	switch {
+	case config.File != "" && config.Url == "" && config.Socket == "":
+		interval = defaultFileInterval
+		s.monitor = &task.Periodic{Execute: s.loadFile}
+	case config.Url != "" && config.File == "" && config.Socket == "":
+		if !strings.HasPrefix(config.Url, "http://") && !strings.HasPrefix(config.Url, "https://") {
+			return nil, errors.New("user source URL must be HTTP or HTTPS: ", config.Url)
+		}
+		interval = defaultURLInterval
+		s.monitor = &task.Periodic{Execute: s.loadURL}
+	case config.Socket != "" && config.File == "" && config.Url == "":
+		interval = s.cacheTTL() / 2
+		s.monitor = &task.Periodic{Execute: s.expire}
+	default:
+		return nil, errors.New("exactly one of file, url and socket of the user source must be set")
+	}
+	if config.Interval > 0 {
+		interval = time.Duration(config.Interval) * time.Second
+	}
+	s.monitor.Interval = interval
+	return s, nil
+}
+
+// IsLookup returns whether the users are queried from the socket.
+func (s *Source) IsLookup() bool {
+	return s.config.Socket != ""
+}
+
+// Start implements common.Runnable.
+func (s *Source) Start() error {
+	return s.monitor.Start()
+}
+
+// Close implements common.Closable.
+func (s *Source) Close() error {
+	return s.monitor.Close()
+}
+
+// sync adds, updates and removes the users so that the users from the source are records.
+func (s *Source) sync(records []Record) {
+	s.access.Lock()
+	defer s.access.Unlock()
+
+	next := make(map[string]Record, len(records))
+	for _, record := range records {
+		if record.Email == "" {
+			errors.LogWarning(s.ctx, "user source: ignoring a user without email")
+			continue
+		}
+		email := strings.ToLower(record.Email)
+		if _, found := next[email]; found {
+			errors.LogWarning(s.ctx, "user source: ignoring duplicated user ", record.Email)
+			continue
+		}
+		next[email] = record
+	}
+
+	var added, removed int
+	for email, record := range s.users {
+		if current, found := next[email]; !found || current != record {
+			if err := s.manager.RemoveUser(s.ctx, record.Email); err != nil {
+				errors.LogWarningInner(s.ctx, err, "user source: failed to remove user ", record.Email)
+			}
+			delete(s.users, email)
+			removed++
+		}
+	}
+	for email, record := range next {
+		if _, found := s.users[email]; found {
+			continue
+		}
+		user, err := s.toUser(&record)
+		if err != nil {
+			errors.LogWarningInner(s.ctx, err, "user source: invalid user ", record.Email)
+			continue
+		}
+		if err := s.manager.AddUser(s.ctx, user); err != nil {
+			errors.LogWarningInner(s.ctx, err, "user source: failed to add user ", record.Email)
+			continue
+		}
+		s.users[email] = record
+		added++
+	}
+	if added > 0 || removed > 0 {
+		errors.LogInfo(s.ctx, "user source: ", added, " users added and ", removed, " removed, ", len(s.users), " users in total")
+	}
+}
+
+// Lookup queries the socket for the user of key, the UUID of VLESS users or the hash of the password of Trojan
+// users, and adds it. It returns whether a user was added, the caller is to check if the user matches key.
+func (s *Source) Lookup(key string) bool {
+	s.access.Lock()
+	expiry, missed := s.missed[key]
+	s.access.Unlock()
+	if missed && time.Now().Before(expiry) {
+		return false
+	}
+
+	added, _, _ := s.group.Do(key, func() (interface{}, error) {
+		record, err := s.query(key)
+		if err != nil {
+			errors.LogWarningInner(s.ctx, err, "user source: failed to query user")
+			return false, nil
+		}
+		s.access.Lock()
+		defer s.access.Unlock()
+		if record == nil {
+			if len(s.missed) >= maxNegativeCache {
+				clear(s.missed)
+			}
+			s.missed[key] = time.Now().Add(s.negativeTTL())
+			return false, nil
+		}
+		user, err := s.toUser(record)
+		if err != nil {
+			errors.LogWarningInner(s.ctx, err, "user source: invalid user ", record.Email)
+			return false, nil
+		}
+		if err := s.manager.AddUser(s.ctx, user); err != nil {
+			errors.LogWarningInner(s.ctx, err, "user source: failed to add user ", record.Email)
+			return false, nil
+		}
+		s.cached[user.Email] = time.Now().Add(s.cacheTTL())
+		errors.LogDebug(s.ctx, "user source: added user ", user.Email)
+		return true, nil
+	})
+	return added.(bool)
+}
+
+// expire removes the users from the socket which were added too long ago, so that they are queried again
+func (s *Source) expire() error {
+	s.access.Lock()
+	defer s.access.Unlock()
+	now := time.Now()
+	for email, expiry := range s.cached {
+		if now.After(expiry) {
+			if err := s.manager.RemoveUser(s.ctx, email); err != nil {
+				errors.LogDebugInner(s.ctx, err, "user source: failed to remove user ", email)
+			}
+			delete(s.cached, email)
+		}
+	}
+	for key, expiry := range s.missed {
+		if now.After(expiry) {
+			delete(s.missed, key)
+		}
+	}
+	return nil
+}
+
+func (s *Source) cacheTTL() time.Duration {
+	if s.config.CacheTtl > 0 {
+		return time.Duration(s.config.CacheTtl) * time.Second
+	}
+	return defaultCacheTTL
+}
+
+func (s *Source) negativeTTL() time.Duration {
+	if s.config.NegativeTtl > 0 {
+		return time.Duration(s.config.NegativeTtl) * time.Second
+	}
+	return defaultNegativeTTL
+}
@@ -0,0 +1,231 @@
+package usersource
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/protocol"
+)
+
+type testManager struct {
+	sync.Mutex
+	users map[string]string
+}
+
+func (m *testManager) AddUser(ctx context.Context, u *protocol.MemoryUser) error {
+	m.Lock()
+	defer m.Unlock()
+	if _, found := m.users[u.Email]; found {
+		return errors.New("User ", u.Email, " already exists.")
+	}
+	m.users[u.Email] = u.Account.(*testAccount).secret
+	return nil
+}
+
+func (m *testManager) RemoveUser(ctx context.Context, email string) error {
+	m.Lock()
+	defer m.Unlock()
+	if _, found := m.users[email]; !found {
+		return errors.New("User ", email, " not found.")
+	}
+	delete(m.users, email)
+	return nil
+}
+
+func (m *testManager) get(email string) (string, bool) {
+	m.Lock()
+	defer m.Unlock()
+	secret, found := m.users[email]
+	return secret, found
+}
+
+type testAccount struct {
+	protocol.Account
+	secret string
+}
+
+func toTestUser(record *Record) (*protocol.MemoryUser, error) {
+	return &protocol.MemoryUser{
+		Email:   record.Email,
+		Level:   record.Level,
+		Account: &testAccount{secret: record.ID + record.Password},
+	}, nil
+}
+
+func TestParseRecords(t *testing.T) {
+	records, err := parseRecords([]byte(`[{"email": "a@example.com", "id": "27848739-7e62-4138-9fd3-098a63964b6b", "level": 1, "flow": "xtls-rprx-vision"}]`), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0].Level != 1 || records[0].Flow != "xtls-rprx-vision" {
+		t.Errorf("unexpected records %+v", records)
+	}
+
+	records, err = parseRecords([]byte("email,password,method,level\nb@example.com,secret,aes-128-gcm,2\nc@example.com,other,,\n"), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[0] != (Record{Email: "b@example.com", Password: "secret", Method: "aes-128-gcm", Level: 2}) || records[1].Password != "other" {
+		t.Errorf("unexpected records %+v", records)
+	}
+
+	if _, err := parseRecords([]byte("email,level\na@example.com,high\n"), true); err == nil {
+		t.Error("expected an error for an invalid level")
+	}
+}
+
+func TestFileSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.csv")
+	write := func(content string) {
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		// the change is detected by the time and size of the file
+		modTime := time.Now().Add(time.Duration(len(content)) * time.Second)
+		os.Chtimes(path, modTime, modTime)
+	}
+	write("email,password\na@example.com,1\nb@example.com,2\n")
+
+	manager := &testManager{users: map[string]string{"config@example.com": "0"}}
+	source, err := New(context.Background(), &Config{File: path}, "trojan", manager, toTestUser)
+	if err != nil {
+		t.Fatal(err)
+	}
+	source.loadFile()
+	if len(manager.users) != 3 {
+		t.Fatalf("unexpected users %v", manager.users)
+	}
+
+	write("email,password\na@example.com,1\nb@example.com,changed\nc@example.com,3\nconfig@example.com,4\n")
+	source.loadFile()
+	if secret, _ := manager.get("b@example.com"); secret != "changed" {
+		t.Error("expected the user to be updated, but got ", secret)
+	}
+	if _, found := manager.get("c@example.com"); !found {
+		t.Error("expected the user to be added")
+	}
+	if secret, _ := manager.get("config@example.com"); secret != "0" {
+		t.Error("expected the user of the config to be kept, but got ", secret)
+	}
+
+	write("email,password\nc@example.com,3\n")
+	source.loadFile()
+	if _, found := manager.get("a@example.com"); found {
+		t.Error("expected the user to be removed")
+	}
+	if _, found := manager.get("config@example.com"); !found {
+		t.Error("expected the user of the config not to be removed")
+	}
+	if len(manager.users) != 2 {
+		t.Errorf("unexpected users %v", manager.users)
+	}
+
+	write("not a csv \"")
+	source.loadFile()
+	if len(manager.users) != 2 {
+		t.Errorf("expected the users to be kept on errors, but got %v", manager.users)
+	}
+}
+
+func TestURLSource(t *testing.T) {
+	var requests, notModified atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(`[{"email": "a@example.com", "id": "27848739-7e62-4138-9fd3-098a63964b6b"}]`))
+	}))
+	defer server.Close()
+
+	manager := &testManager{users: map[string]string{}}
+	source, err := New(context.Background(), &Config{Url: server.URL + "/users"}, "vless", manager, toTestUser)
+	if err != nil {
+		t.Fatal(err)
+	}
+	source.loadURL()
+	source.loadURL()
+	if requests.Load() != 2 || notModified.Load() != 1 {
+		t.Errorf("expected a conditional request, got %d requests and %d not modified", requests.Load(), notModified.Load())
+	}
+	if len(manager.users) != 1 {
+		t.Errorf("unexpected users %v", manager.users)
+	}
+}
+
+func TestSocketSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.sock")
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		t.Skip("unix socket is not supported: ", err)
+	}
+	defer listener.Close()
+
+	var queries atomic.Int32
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			queries.Add(1)
+			var request map[string]string
+			line, _ := bufio.NewReader(conn).ReadBytes('\n')
+			json.Unmarshal(line, &request)
+			if request["protocol"] == "vless" && request["key"] == "known" {
+				conn.Write([]byte(`{"email": "known@example.com", "id": "known"}` + "\n"))
+			} else {
+				conn.Write([]byte("null\n"))
+			}
+			conn.Close()
+		}
+	}()
+
+	manager := &testManager{users: map[string]string{}}
+	source, err := New(context.Background(), &Config{Socket: path, CacheTtl: 1}, "vless", manager, toTestUser)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !source.IsLookup() {
+		t.Fatal("expected a lookup source")
+	}
+
+	if !source.Lookup("known") {
+		t.Fatal("expected the user to be found")
+	}
+	if _, found := manager.get("known@example.com"); !found {
+		t.Fatal("expected the user to be added")
+	}
+
+	for range 3 {
+		if source.Lookup("unknown") {
+			t.Fatal("expected the user not to be found")
+		}
+	}
+	if queries.Load() != 2 {
+		t.Error("expected the miss to be cached, but got ", queries.Load(), " queries")
+	}
+
+	time.Sleep(1100 * time.Millisecond)
+	source.expire()
+	if _, found := manager.get("known@example.com"); found {
+		t.Error("expected the user to expire")
+	}
+	if len(source.cached) != 0 {
+		t.Error("unexpected cache ", source.cached)
+	}
+}
@@ -15,15 +15,17 @@ func (a *Account) AsAccount() (protocol.Account, error) {
 		return nil, errors.New("failed to parse ID").Base(err).AtError()
 	}
 	return &MemoryAccount{
-		ID:         protocol.NewID(id),
-		Flow:       a.Flow,       // needs parser here?
-		Encryption: a.Encryption, // needs parser here?
-		XorMode:    a.XorMode,
-		Seconds:    a.Seconds,
-		Padding:    a.Padding,
-		Reverse:    a.Reverse,
-		Testpre:    a.Testpre,
-		Testseed:   a.Testseed,
+		ID:          protocol.NewID(id),
+		Flow:        a.Flow,       // needs parser here?
+		Encryption:  a.Encryption, // needs parser here?
+		XorMode:     a.XorMode,
+		Seconds:     a.Seconds,
+		Padding:     a.Padding,
+		Reverse:     a.Reverse,
+		Testpre:     a.Testpre,
+		Testseed:    a.Testseed,
+		OutboundTag: a.OutboundTag,
+		Group:       a.Group,
 	}, nil
 }
 
@@ -43,6 +45,10 @@ type MemoryAccount struct {
 
 	Testpre  uint32
 	Testseed []uint32
+
+	// OutboundTag and Group are the routing hints of the user.
+	OutboundTag string
+	Group       string
 }
 
 // Equals implements protocol.Account.Equals().
@@ -56,14 +62,26 @@ func (a *MemoryAccount) Equals(account protocol.Account) bool {
 
 func (a *MemoryAccount) ToProto() proto.Message {
 	return &Account{
-		Id:         a.ID.String(),
-		Flow:       a.Flow,
-		Encryption: a.Encryption,
-		XorMode:    a.XorMode,
-		Seconds:    a.Seconds,
-		Padding:    a.Padding,
-		Reverse:    a.Reverse,
-		Testpre:    a.Testpre,
-		Testseed:   a.Testseed,
+		Id:          a.ID.String(),
+		Flow:        a.Flow,
+		Encryption:  a.Encryption,
+		XorMode:     a.XorMode,
+		Seconds:     a.Seconds,
+		Padding:     a.Padding,
+		Reverse:     a.Reverse,
+		Testpre:     a.Testpre,
+		Testseed:    a.Testseed,
+		OutboundTag: a.OutboundTag,
+		Group:       a.Group,
 	}
 }
+
+// GetOutboundTag implements protocol.RoutingAccount.
+func (a *MemoryAccount) GetOutboundTag() string {
+	return a.OutboundTag
+}
+
+// GetGroup implements protocol.RoutingAccount.
+func (a *MemoryAccount) GetGroup() string {
+	return a.Group
+}
@@ -3,6 +3,7 @@ package encoding
 import (
 	"context"
 	"io"
+	"time"
 
 	"github.com/xtls/xray-core/common/buf"
 	"github.com/xtls/xray-core/common/errors"
@@ -19,6 +20,9 @@ const (
 	Version = byte(0)
 )
 
+// ErrInvalidUser is the cause of the error of a request whose user id is unknown.
+var ErrInvalidUser = errors.New("invalid user")
+
 var addrParser = protocol.NewAddressParser(
 	protocol.AddressFamilyByte(byte(protocol.AddressTypeIPv4), net.AddressFamilyIPv4),
 	protocol.AddressFamilyByte(byte(protocol.AddressTypeDomain), net.AddressFamilyDomain),
@@ -61,6 +65,7 @@ func EncodeRequestHeader(writer io.Writer, request *protocol.RequestHeader, requ
 }
 
 // DecodeRequestHeader decodes and returns (if successful) a RequestHeader from an input stream.
+// If the user is rejected, the RequestHeader with the user is returned with the error.
 func DecodeRequestHeader(isfb bool, first *buf.Buffer, reader io.Reader, validator vless.Validator) ([]byte, *protocol.RequestHeader, *Addons, bool, error) {
 	buffer := buf.StackNew()
 	defer buffer.Release()
@@ -93,7 +98,10 @@ func DecodeRequestHeader(isfb bool, first *buf.Buffer, reader io.Reader, validat
 
 		if request.User = validator.Get(id); request.User == nil {
 			u := uuid.UUID(id)
-			return nil, nil, nil, isfb, errors.New("invalid request user id: " + u.String())
+			return nil, nil, nil, isfb, errors.New("invalid request user id: " + u.String()).Base(ErrInvalidUser)
+		}
+		if err := request.User.Check(time.Now()); err != nil {
+			return nil, request, nil, isfb, errors.New("rejected user ", request.User.Email).Base(err)
 		}
 
 		if isfb {
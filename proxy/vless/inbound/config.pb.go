@@ -8,6 +8,7 @@ package inbound
 
 import (
 	protocol "github.com/xtls/xray-core/common/protocol"
+	usersource "github.com/xtls/xray-core/proxy/usersource"
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
 	reflect "reflect"
@@ -23,13 +24,21 @@ const (
 )
 
 type Fallback struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
-	Alpn          string                 `protobuf:"bytes,2,opt,name=alpn,proto3" json:"alpn,omitempty"`
-	Path          string                 `protobuf:"bytes,3,opt,name=path,proto3" json:"path,omitempty"`
-	Type          string                 `protobuf:"bytes,4,opt,name=type,proto3" json:"type,omitempty"`
-	Dest          string                 `protobuf:"bytes,5,opt,name=dest,proto3" json:"dest,omitempty"`
-	Xver          uint64                 `protobuf:"varint,6,opt,name=xver,proto3" json:"xver,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Name  string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Alpn  string                 `protobuf:"bytes,2,opt,name=alpn,proto3" json:"alpn,omitempty"`
+	Path  string                 `protobuf:"bytes,3,opt,name=path,proto3" json:"path,omitempty"`
+	Type  string                 `protobuf:"bytes,4,opt,name=type,proto3" json:"type,omitempty"`
+	Dest  string                 `protobuf:"bytes,5,opt,name=dest,proto3" json:"dest,omitempty"`
+	Xver  uint64                 `protobuf:"varint,6,opt,name=xver,proto3" json:"xver,omitempty"`
+	// HTTP Host header to match, without the port.
+	Host string `protobuf:"bytes,7,opt,name=host,proto3" json:"host,omitempty"`
+	// HTTP header names to the regexps their values must match.
+	Headers map[string]string `protobuf:"bytes,8,rep,name=headers,proto3" json:"headers,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// Weighted dests to choose from instead of type and dest.
+	Dests []*Fallback_Dest `protobuf:"bytes,9,rep,name=dests,proto3" json:"dests,omitempty"`
+	// Whether to send the SNI, ALPN and the user as TLVs of PROXY protocol v2.
+	Tlv           bool `protobuf:"varint,10,opt,name=tlv,proto3" json:"tlv,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -106,6 +115,34 @@ func (x *Fallback) GetXver() uint64 {
 	return 0
 }
 
+func (x *Fallback) GetHost() string {
+	if x != nil {
+		return x.Host
+	}
+	return ""
+}
+
+func (x *Fallback) GetHeaders() map[string]string {
+	if x != nil {
+		return x.Headers
+	}
+	return nil
+}
+
+func (x *Fallback) GetDests() []*Fallback_Dest {
+	if x != nil {
+		return x.Dests
+	}
+	return nil
+}
+
+func (x *Fallback) GetTlv() bool {
+	if x != nil {
+		return x.Tlv
+	}
+	return false
+}
+
 type Config struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Users         []*protocol.User       `protobuf:"bytes,1,rep,name=users,proto3" json:"users,omitempty"`
@@ -115,6 +152,7 @@ type Config struct {
 	SecondsFrom   int64                  `protobuf:"varint,5,opt,name=seconds_from,json=secondsFrom,proto3" json:"seconds_from,omitempty"`
 	SecondsTo     int64                  `protobuf:"varint,6,opt,name=seconds_to,json=secondsTo,proto3" json:"seconds_to,omitempty"`
 	Padding       string                 `protobuf:"bytes,7,opt,name=padding,proto3" json:"padding,omitempty"`
+	UserSource    *usersource.Config     `protobuf:"bytes,8,opt,name=user_source,json=userSource,proto3" json:"user_source,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -198,18 +236,98 @@ func (x *Config) GetPadding() string {
 	return ""
 }
 
+func (x *Config) GetUserSource() *usersource.Config {
+	if x != nil {
+		return x.UserSource
+	}
+	return nil
+}
+
+type Fallback_Dest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Type  string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	Dest  string                 `protobuf:"bytes,2,opt,name=dest,proto3" json:"dest,omitempty"`
+	// 1 if unset.
+	Weight        uint32 `protobuf:"varint,3,opt,name=weight,proto3" json:"weight,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Fallback_Dest) Reset() {
+	*x = Fallback_Dest{}
+	mi := &file_proxy_vless_inbound_config_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Fallback_Dest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Fallback_Dest) ProtoMessage() {}
+
+func (x *Fallback_Dest) ProtoReflect() protoreflect.Message {
+	mi := &file_proxy_vless_inbound_config_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Fallback_Dest.ProtoReflect.Descriptor instead.
+func (*Fallback_Dest) Descriptor() ([]byte, []int) {
+	return file_proxy_vless_inbound_config_proto_rawDescGZIP(), []int{0, 1}
+}
+
+func (x *Fallback_Dest) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Fallback_Dest) GetDest() string {
+	if x != nil {
+		return x.Dest
+	}
+	return ""
+}
+
+func (x *Fallback_Dest) GetWeight() uint32 {
+	if x != nil {
+		return x.Weight
+	}
+	return 0
+}
+
 var File_proxy_vless_inbound_config_proto protoreflect.FileDescriptor
 
 const file_proxy_vless_inbound_config_proto_rawDesc = "" +
 	"\n" +
-	" proxy/vless/inbound/config.proto\x12\x18xray.proxy.vless.inbound\x1a\x1acommon/protocol/user.proto\"\x82\x01\n" +
+	" proxy/vless/inbound/config.proto\x12\x18xray.proxy.vless.inbound\x1a\x1acommon/protocol/user.proto\x1a\x1dproxy/usersource/config.proto\"\xb6\x03\n" +
 	"\bFallback\x12\x12\n" +
 	"\x04name\x18\x01 \x01(\tR\x04name\x12\x12\n" +
 	"\x04alpn\x18\x02 \x01(\tR\x04alpn\x12\x12\n" +
 	"\x04path\x18\x03 \x01(\tR\x04path\x12\x12\n" +
 	"\x04type\x18\x04 \x01(\tR\x04type\x12\x12\n" +
 	"\x04dest\x18\x05 \x01(\tR\x04dest\x12\x12\n" +
-	"\x04xver\x18\x06 \x01(\x04R\x04xver\"\x92\x02\n" +
+	"\x04xver\x18\x06 \x01(\x04R\x04xver\x12\x12\n" +
+	"\x04host\x18\a \x01(\tR\x04host\x12I\n" +
+	"\aheaders\x18\b \x03(\v2/.xray.proxy.vless.inbound.Fallback.HeadersEntryR\aheaders\x12=\n" +
+	"\x05dests\x18\t \x03(\v2'.xray.proxy.vless.inbound.Fallback.DestR\x05dests\x12\x10\n" +
+	"\x03tlv\x18\n" +
+	" \x01(\bR\x03tlv\x1a:\n" +
+	"\fHeadersEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\x1aF\n" +
+	"\x04Dest\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12\x12\n" +
+	"\x04dest\x18\x02 \x01(\tR\x04dest\x12\x16\n" +
+	"\x06weight\x18\x03 \x01(\rR\x06weight\"\xd2\x02\n" +
 	"\x06Config\x120\n" +
 	"\x05users\x18\x01 \x03(\v2\x1a.xray.common.protocol.UserR\x05users\x12@\n" +
 	"\tfallbacks\x18\x02 \x03(\v2\".xray.proxy.vless.inbound.FallbackR\tfallbacks\x12\x1e\n" +
@@ -220,7 +338,9 @@ const file_proxy_vless_inbound_config_proto_rawDesc = "" +
 	"\fseconds_from\x18\x05 \x01(\x03R\vsecondsFrom\x12\x1d\n" +
 	"\n" +
 	"seconds_to\x18\x06 \x01(\x03R\tsecondsTo\x12\x18\n" +
-	"\apadding\x18\a \x01(\tR\apaddingBj\n" +
+	"\apadding\x18\a \x01(\tR\apadding\x12>\n" +
+	"\vuser_source\x18\b \x01(\v2\x1d.xray.proxy.usersource.ConfigR\n" +
+	"userSourceBj\n" +
 	"\x1ccom.xray.proxy.vless.inboundP\x01Z-github.com/xtls/xray-core/proxy/vless/inbound\xaa\x02\x18Xray.Proxy.Vless.Inboundb\x06proto3"
 
 var (
@@ -235,20 +355,26 @@ func file_proxy_vless_inbound_config_proto_rawDescGZIP() []byte {
 	return file_proxy_vless_inbound_config_proto_rawDescData
 }
 
-var file_proxy_vless_inbound_config_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_proxy_vless_inbound_config_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
 var file_proxy_vless_inbound_config_proto_goTypes = []any{
-	(*Fallback)(nil),      // 0: xray.proxy.vless.inbound.Fallback
-	(*Config)(nil),        // 1: xray.proxy.vless.inbound.Config
-	(*protocol.User)(nil), // 2: xray.common.protocol.User
+	(*Fallback)(nil),          // 0: xray.proxy.vless.inbound.Fallback
+	(*Config)(nil),            // 1: xray.proxy.vless.inbound.Config
+	nil,                       // 2: xray.proxy.vless.inbound.Fallback.HeadersEntry
+	(*Fallback_Dest)(nil),     // 3: xray.proxy.vless.inbound.Fallback.Dest
+	(*protocol.User)(nil),     // 4: xray.common.protocol.User
+	(*usersource.Config)(nil), // 5: xray.proxy.usersource.Config
 }
 var file_proxy_vless_inbound_config_proto_depIdxs = []int32{
-	2, // 0: xray.proxy.vless.inbound.Config.users:type_name -> xray.common.protocol.User
-	0, // 1: xray.proxy.vless.inbound.Config.fallbacks:type_name -> xray.proxy.vless.inbound.Fallback
-	2, // [2:2] is the sub-list for method output_type
-	2, // [2:2] is the sub-list for method input_type
-	2, // [2:2] is the sub-list for extension type_name
-	2, // [2:2] is the sub-list for extension extendee
-	0, // [0:2] is the sub-list for field type_name
+	2, // 0: xray.proxy.vless.inbound.Fallback.headers:type_name -> xray.proxy.vless.inbound.Fallback.HeadersEntry
+	3, // 1: xray.proxy.vless.inbound.Fallback.dests:type_name -> xray.proxy.vless.inbound.Fallback.Dest
+	4, // 2: xray.proxy.vless.inbound.Config.users:type_name -> xray.common.protocol.User
+	0, // 3: xray.proxy.vless.inbound.Config.fallbacks:type_name -> xray.proxy.vless.inbound.Fallback
+	5, // 4: xray.proxy.vless.inbound.Config.user_source:type_name -> xray.proxy.usersource.Config
+	5, // [5:5] is the sub-list for method output_type
+	5, // [5:5] is the sub-list for method input_type
+	5, // [5:5] is the sub-list for extension type_name
+	5, // [5:5] is the sub-list for extension extendee
+	0, // [0:5] is the sub-list for field type_name
 }
 
 func init() { file_proxy_vless_inbound_config_proto_init() }
@@ -262,7 +388,7 @@ func file_proxy_vless_inbound_config_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proxy_vless_inbound_config_proto_rawDesc), len(file_proxy_vless_inbound_config_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   2,
+			NumMessages:   4,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
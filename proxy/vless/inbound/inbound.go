@@ -7,7 +7,6 @@ import (
 	"encoding/base64"
 	"io"
 	"reflect"
-	"strconv"
 	"strings"
 	"time"
 	"unsafe"
@@ -21,7 +20,6 @@ import (
 	"github.com/xtls/xray-core/common/mux"
 	"github.com/xtls/xray-core/common/net"
 	"github.com/xtls/xray-core/common/protocol"
-	"github.com/xtls/xray-core/common/retry"
 	"github.com/xtls/xray-core/common/serial"
 	"github.com/xtls/xray-core/common/session"
 	"github.com/xtls/xray-core/common/signal"
@@ -36,6 +34,8 @@ import (
 	"github.com/xtls/xray-core/features/routing"
 	"github.com/xtls/xray-core/features/stats"
 	"github.com/xtls/xray-core/proxy"
+	"github.com/xtls/xray-core/proxy/internal/fallback"
+	"github.com/xtls/xray-core/proxy/usersource"
 	"github.com/xtls/xray-core/proxy/vless"
 	"github.com/xtls/xray-core/proxy/vless/encoding"
 	"github.com/xtls/xray-core/proxy/vless/encryption"
@@ -83,8 +83,8 @@ type Handler struct {
 	observer               features.Feature
 	defaultDispatcher      routing.Dispatcher
 	ctx                    context.Context
-	fallbacks              map[string]map[string]map[string]*Fallback // or nil
-	// regexps               map[string]*regexp.Regexp       // or nil
+	userSource             *usersource.Source
+	fallbacks              *fallback.Matcher[*Fallback] // or nil
 }
 
 // New creates a new VLess inbound handler.
@@ -115,62 +115,21 @@ func New(ctx context.Context, config *Config, dc dns.Client, validator vless.Val
 	}
 
 	if config.Fallbacks != nil {
-		handler.fallbacks = make(map[string]map[string]map[string]*Fallback)
-		// handler.regexps = make(map[string]*regexp.Regexp)
-		for _, fb := range config.Fallbacks {
-			if handler.fallbacks[fb.Name] == nil {
-				handler.fallbacks[fb.Name] = make(map[string]map[string]*Fallback)
-			}
-			if handler.fallbacks[fb.Name][fb.Alpn] == nil {
-				handler.fallbacks[fb.Name][fb.Alpn] = make(map[string]*Fallback)
-			}
-			handler.fallbacks[fb.Name][fb.Alpn][fb.Path] = fb
-			/*
-				if fb.Path != "" {
-					if r, err := regexp.Compile(fb.Path); err != nil {
-						return nil, errors.New("invalid path regexp").Base(err).AtError()
-					} else {
-						handler.regexps[fb.Path] = r
-					}
-				}
-			*/
-		}
-		if handler.fallbacks[""] != nil {
-			for name, apfb := range handler.fallbacks {
-				if name != "" {
-					for alpn := range handler.fallbacks[""] {
-						if apfb[alpn] == nil {
-							apfb[alpn] = make(map[string]*Fallback)
-						}
-					}
-				}
-			}
+		fallbacks, err := fallback.NewMatcher(config.Fallbacks)
+		if err != nil {
+			return nil, errors.New("invalid fallback").Base(err).AtError()
 		}
-		for _, apfb := range handler.fallbacks {
-			if apfb[""] != nil {
-				for alpn, pfb := range apfb {
-					if alpn != "" { // && alpn != "h2" {
-						for path, fb := range apfb[""] {
-							if pfb[path] == nil {
-								pfb[path] = fb
-							}
-						}
-					}
-				}
-			}
+		handler.fallbacks = fallbacks
+	}
+
+	if config.UserSource != nil {
+		source, err := usersource.New(ctx, config.UserSource, "vless", handler, toUser)
+		if err != nil {
+			return nil, errors.New("failed to create user source").Base(err).AtError()
 		}
-		if handler.fallbacks[""] != nil {
-			for name, apfb := range handler.fallbacks {
-				if name != "" {
-					for alpn, pfb := range handler.fallbacks[""] {
-						for path, fb := range pfb {
-							if apfb[alpn][path] == nil {
-								apfb[alpn][path] = fb
-							}
-						}
-					}
-				}
-			}
+		handler.userSource = source
+		if source.IsLookup() {
+			handler.validator = &lookupValidator{Validator: validator, source: source}
 		}
 	}
 
@@ -225,8 +184,19 @@ func (h *Handler) RemoveReverse(u *protocol.MemoryUser) {
 	}
 }
 
+// Start implements common.Runnable.Start().
+func (h *Handler) Start() error {
+	if h.userSource != nil {
+		return h.userSource.Start()
+	}
+	return nil
+}
+
 // Close implements common.Closable.Close().
 func (h *Handler) Close() error {
+	if h.userSource != nil {
+		h.userSource.Close()
+	}
 	if h.decryption != nil {
 		h.decryption.Close()
 	}
@@ -301,8 +271,7 @@ func (h *Handler) Process(ctx context.Context, network net.Network, connection s
 	var requestAddons *encoding.Addons
 	var err error
 
-	napfb := h.fallbacks
-	isfb := napfb != nil
+	isfb := h.fallbacks != nil
 
 	if isfb && firstLen < 18 {
 		err = errors.New("fallback directly")
@@ -310,6 +279,17 @@ func (h *Handler) Process(ctx context.Context, network net.Network, connection s
 		userSentID, request, requestAddons, isfb, err = encoding.DecodeRequestHeader(isfb, first, reader, h.validator)
 	}
 
+	if errors.Cause(err) == encoding.ErrInvalidUser {
+		if inbound := session.InboundFromContext(ctx); inbound != nil {
+			stats.PublishAuthFailure(h.stats, stats.AuthFailure{
+				InboundTag: inbound.Tag,
+				Protocol:   "vless",
+				Source:     inbound.Source.Address,
+				Reason:     "invalid_user",
+			})
+		}
+	}
+
 	if err != nil {
 		if isfb {
 			if err := connection.SetReadDeadline(time.Time{}); err != nil {
@@ -335,97 +315,24 @@ func (h *Handler) Process(ctx context.Context, network net.Network, connection s
 			name = strings.ToLower(name)
 			alpn = strings.ToLower(alpn)
 
-			if len(napfb) > 1 || napfb[""] == nil {
-				if name != "" && napfb[name] == nil {
-					match := ""
-					for n := range napfb {
-						if n != "" && strings.Contains(name, n) && len(n) > len(match) {
-							match = n
-						}
-					}
-					name = match
-				}
-			}
-
-			if napfb[name] == nil {
-				name = ""
-			}
-			apfb := napfb[name]
-			if apfb == nil {
-				return errors.New(`failed to find the default "name" config`).AtWarning()
-			}
-
-			if apfb[alpn] == nil {
-				alpn = ""
-			}
-			pfb := apfb[alpn]
-			if pfb == nil {
-				return errors.New(`failed to find the default "alpn" config`).AtWarning()
+			email := ""
+			if request != nil && request.User != nil {
+				email = request.User.Email // rejected
 			}
+			tlvs := fallback.TLVs(name, alpn, email)
 
-			path := ""
-			if len(pfb) > 1 || pfb[""] == nil {
-				/*
-					if lines := bytes.Split(firstBytes, []byte{'\r', '\n'}); len(lines) > 1 {
-						if s := bytes.Split(lines[0], []byte{' '}); len(s) == 3 {
-							if len(s[0]) < 8 && len(s[1]) > 0 && len(s[2]) == 8 {
-								errors.New("realPath = " + string(s[1])).AtInfo().WriteToLog(sid)
-								for _, fb := range pfb {
-									if fb.Path != "" && h.regexps[fb.Path].Match(s[1]) {
-										path = fb.Path
-										break
-									}
-								}
-							}
-						}
-					}
-				*/
-				if firstLen >= 18 && first.Byte(4) != '*' { // not h2c
-					firstBytes := first.Bytes()
-					for i := 4; i <= 8; i++ { // 5 -> 9
-						if firstBytes[i] == '/' && firstBytes[i-1] == ' ' {
-							search := len(firstBytes)
-							if search > 64 {
-								search = 64 // up to about 60
-							}
-							for j := i + 1; j < search; j++ {
-								k := firstBytes[j]
-								if k == '\r' || k == '\n' { // avoid logging \r or \n
-									break
-								}
-								if k == '?' || k == ' ' {
-									path = string(firstBytes[i:j])
-									errors.LogInfo(ctx, "realPath = "+path)
-									if pfb[path] == nil {
-										path = ""
-									}
-									break
-								}
-							}
-							break
-						}
-					}
-				}
-			}
-			fb := pfb[path]
-			if fb == nil {
-				return errors.New(`failed to find the default "path" config`).AtWarning()
+			fb, err := h.fallbacks.Find(ctx, name, alpn, first, firstLen)
+			if err != nil {
+				return err
 			}
 
 			ctx, cancel := context.WithCancel(ctx)
 			timer := signal.CancelAfterInactivity(ctx, cancel, sessionPolicy.Timeouts.ConnectionIdle)
 			ctx = policy.ContextWithBufferPolicy(ctx, sessionPolicy.Buffer)
 
-			var conn net.Conn
-			if err := retry.ExponentialBackoff(5, 100).On(func() error {
-				var dialer net.Dialer
-				conn, err = dialer.DialContext(ctx, fb.Type, fb.Dest)
-				if err != nil {
-					return err
-				}
-				return nil
-			}); err != nil {
-				return errors.New("failed to dial to " + fb.Dest).Base(err).AtWarning()
+			conn, err := fallback.Dial(ctx, fb.Type, fb.Dest, fb.Dests)
+			if err != nil {
+				return err
 			}
 			defer conn.Close()
 
@@ -435,54 +342,13 @@ func (h *Handler) Process(ctx context.Context, network net.Network, connection s
 			postRequest := func() error {
 				defer timer.SetTimeout(sessionPolicy.Timeouts.DownlinkOnly)
 				if fb.Xver != 0 {
-					ipType := 4
-					remoteAddr, remotePort, err := net.SplitHostPort(connection.RemoteAddr().String())
-					if err != nil {
-						ipType = 0
-					}
-					localAddr, localPort, err := net.SplitHostPort(connection.LocalAddr().String())
-					if err != nil {
-						ipType = 0
-					}
-					if ipType == 4 {
-						for i := 0; i < len(remoteAddr); i++ {
-							if remoteAddr[i] == ':' {
-								ipType = 6
-								break
-							}
-						}
+					if !fb.Tlv {
+						tlvs = nil
 					}
 					pro := buf.New()
 					defer pro.Release()
-					switch fb.Xver {
-					case 1:
-						if ipType == 0 {
-							pro.Write([]byte("PROXY UNKNOWN\r\n"))
-							break
-						}
-						if ipType == 4 {
-							pro.Write([]byte("PROXY TCP4 " + remoteAddr + " " + localAddr + " " + remotePort + " " + localPort + "\r\n"))
-						} else {
-							pro.Write([]byte("PROXY TCP6 " + remoteAddr + " " + localAddr + " " + remotePort + " " + localPort + "\r\n"))
-						}
-					case 2:
-						pro.Write([]byte("\x0D\x0A\x0D\x0A\x00\x0D\x0A\x51\x55\x49\x54\x0A")) // signature
-						if ipType == 0 {
-							pro.Write([]byte("\x20\x00\x00\x00")) // v2 + LOCAL + UNSPEC + UNSPEC + 0 bytes
-							break
-						}
-						if ipType == 4 {
-							pro.Write([]byte("\x21\x11\x00\x0C")) // v2 + PROXY + AF_INET + STREAM + 12 bytes
-							pro.Write(net.ParseIP(remoteAddr).To4())
-							pro.Write(net.ParseIP(localAddr).To4())
-						} else {
-							pro.Write([]byte("\x21\x21\x00\x24")) // v2 + PROXY + AF_INET6 + STREAM + 36 bytes
-							pro.Write(net.ParseIP(remoteAddr).To16())
-							pro.Write(net.ParseIP(localAddr).To16())
-						}
-						p1, _ := strconv.ParseUint(remotePort, 10, 16)
-						p2, _ := strconv.ParseUint(localPort, 10, 16)
-						pro.Write([]byte{byte(p1 >> 8), byte(p1), byte(p2 >> 8), byte(p2)})
+					if err := fallback.WriteProxyHeader(pro, fb.Xver, connection.RemoteAddr(), connection.LocalAddr(), tlvs); err != nil {
+						return errors.New("failed to set PROXY protocol v", fb.Xver).Base(err).AtWarning()
 					}
 					if err := serverWriter.WriteMultiBuffer(buf.MultiBuffer{pro}); err != nil {
 						return errors.New("failed to set PROXY protocol v", fb.Xver).Base(err).AtWarning()
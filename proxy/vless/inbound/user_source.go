@@ -0,0 +1,45 @@
+package inbound
+
+import (
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/protocol"
+	"github.com/xtls/xray-core/common/uuid"
+	"github.com/xtls/xray-core/proxy/usersource"
+	"github.com/xtls/xray-core/proxy/vless"
+)
+
+func toUser(record *usersource.Record) (*protocol.MemoryUser, error) {
+	switch record.Flow {
+	case "", vless.XRV:
+	default:
+		return nil, errors.New(`VLESS users: "flow" doesn't support "` + record.Flow + `" in this version`)
+	}
+	account, err := (&vless.Account{
+		Id:   record.ID,
+		Flow: record.Flow,
+	}).AsAccount()
+	if err != nil {
+		return nil, err
+	}
+	return &protocol.MemoryUser{
+		Email:   record.Email,
+		Level:   record.Level,
+		Account: account,
+	}, nil
+}
+
+// lookupValidator queries the user source for the users unknown to the validator.
+type lookupValidator struct {
+	vless.Validator
+	source *usersource.Source
+}
+
+func (v *lookupValidator) Get(id uuid.UUID) *protocol.MemoryUser {
+	if u := v.Validator.Get(id); u != nil {
+		return u
+	}
+	if v.source.Lookup(id.String()) {
+		return v.Validator.Get(id)
+	}
+	return nil
+}
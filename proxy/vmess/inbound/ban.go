@@ -0,0 +1,26 @@
+package inbound
+
+import (
+	"github.com/xtls/xray-core/app/fail2ban"
+	"github.com/xtls/xray-core/features/routing"
+)
+
+// newAuthFailureBan creates the jail of an inbound for the source IPs failing its authentication too often.
+// The inbound reports its failures to the jail, and rejects the connections from the banned IPs before the
+// handshake. The routing rules of the bans catch the requests of the connections accepted before.
+func newAuthFailureBan(config *AuthFailureBan, r routing.Router, inboundTag string) (*fail2ban.Fail2ban, error) {
+	jail := &fail2ban.Jail{
+		Name:     "vmess>>>" + inboundTag,
+		Protocol: []string{"vmess"},
+		MaxRetry: config.MaxRetry,
+		FindTime: config.FindTime,
+		BanTime:  config.BanTime,
+	}
+	if len(inboundTag) > 0 {
+		jail.InboundTag = []string{inboundTag}
+	}
+	return fail2ban.New(&fail2ban.Config{
+		Jail:        []*fail2ban.Jail{jail},
+		OutboundTag: config.OutboundTag,
+	}, r)
+}
@@ -8,6 +8,7 @@ package inbound
 
 import (
 	protocol "github.com/xtls/xray-core/common/protocol"
+	usersource "github.com/xtls/xray-core/proxy/usersource"
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
 	reflect "reflect"
@@ -110,17 +111,93 @@ func (x *DefaultConfig) GetLevel() uint32 {
 	return 0
 }
 
+// AuthFailureBan bans the source IPs which keep failing the authentication for a while. Their connections
+// are rejected before the handshake, and the requests of those accepted before are routed to an outbound.
+type AuthFailureBan struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Number of failures of a source IP within find_time to ban it, 5 if unset.
+	MaxRetry uint32 `protobuf:"varint,1,opt,name=max_retry,json=maxRetry,proto3" json:"max_retry,omitempty"`
+	// Seconds in which the failures are counted, 600 if unset.
+	FindTime uint32 `protobuf:"varint,2,opt,name=find_time,json=findTime,proto3" json:"find_time,omitempty"`
+	// Seconds a source IP is banned for, 3600 if unset.
+	BanTime uint32 `protobuf:"varint,3,opt,name=ban_time,json=banTime,proto3" json:"ban_time,omitempty"`
+	// Outbound which the requests from banned source IPs are routed to.
+	OutboundTag   string `protobuf:"bytes,4,opt,name=outbound_tag,json=outboundTag,proto3" json:"outbound_tag,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AuthFailureBan) Reset() {
+	*x = AuthFailureBan{}
+	mi := &file_proxy_vmess_inbound_config_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AuthFailureBan) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AuthFailureBan) ProtoMessage() {}
+
+func (x *AuthFailureBan) ProtoReflect() protoreflect.Message {
+	mi := &file_proxy_vmess_inbound_config_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AuthFailureBan.ProtoReflect.Descriptor instead.
+func (*AuthFailureBan) Descriptor() ([]byte, []int) {
+	return file_proxy_vmess_inbound_config_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *AuthFailureBan) GetMaxRetry() uint32 {
+	if x != nil {
+		return x.MaxRetry
+	}
+	return 0
+}
+
+func (x *AuthFailureBan) GetFindTime() uint32 {
+	if x != nil {
+		return x.FindTime
+	}
+	return 0
+}
+
+func (x *AuthFailureBan) GetBanTime() uint32 {
+	if x != nil {
+		return x.BanTime
+	}
+	return 0
+}
+
+func (x *AuthFailureBan) GetOutboundTag() string {
+	if x != nil {
+		return x.OutboundTag
+	}
+	return ""
+}
+
 type Config struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	User          []*protocol.User       `protobuf:"bytes,1,rep,name=user,proto3" json:"user,omitempty"`
 	Default       *DefaultConfig         `protobuf:"bytes,2,opt,name=default,proto3" json:"default,omitempty"`
+	UserSource    *usersource.Config     `protobuf:"bytes,3,opt,name=user_source,json=userSource,proto3" json:"user_source,omitempty"`
+	Ban           *AuthFailureBan        `protobuf:"bytes,4,opt,name=ban,proto3" json:"ban,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *Config) Reset() {
 	*x = Config{}
-	mi := &file_proxy_vmess_inbound_config_proto_msgTypes[2]
+	mi := &file_proxy_vmess_inbound_config_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -132,7 +209,7 @@ func (x *Config) String() string {
 func (*Config) ProtoMessage() {}
 
 func (x *Config) ProtoReflect() protoreflect.Message {
-	mi := &file_proxy_vmess_inbound_config_proto_msgTypes[2]
+	mi := &file_proxy_vmess_inbound_config_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -145,7 +222,7 @@ func (x *Config) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Config.ProtoReflect.Descriptor instead.
 func (*Config) Descriptor() ([]byte, []int) {
-	return file_proxy_vmess_inbound_config_proto_rawDescGZIP(), []int{2}
+	return file_proxy_vmess_inbound_config_proto_rawDescGZIP(), []int{3}
 }
 
 func (x *Config) GetUser() []*protocol.User {
@@ -162,18 +239,40 @@ func (x *Config) GetDefault() *DefaultConfig {
 	return nil
 }
 
+func (x *Config) GetUserSource() *usersource.Config {
+	if x != nil {
+		return x.UserSource
+	}
+	return nil
+}
+
+func (x *Config) GetBan() *AuthFailureBan {
+	if x != nil {
+		return x.Ban
+	}
+	return nil
+}
+
 var File_proxy_vmess_inbound_config_proto protoreflect.FileDescriptor
 
 const file_proxy_vmess_inbound_config_proto_rawDesc = "" +
 	"\n" +
-	" proxy/vmess/inbound/config.proto\x12\x18xray.proxy.vmess.inbound\x1a\x1acommon/protocol/user.proto\"\x1e\n" +
+	" proxy/vmess/inbound/config.proto\x12\x18xray.proxy.vmess.inbound\x1a\x1acommon/protocol/user.proto\x1a\x1dproxy/usersource/config.proto\"\x1e\n" +
 	"\fDetourConfig\x12\x0e\n" +
 	"\x02to\x18\x01 \x01(\tR\x02to\"%\n" +
 	"\rDefaultConfig\x12\x14\n" +
-	"\x05level\x18\x02 \x01(\rR\x05level\"{\n" +
+	"\x05level\x18\x02 \x01(\rR\x05level\"\x88\x01\n" +
+	"\x0eAuthFailureBan\x12\x1b\n" +
+	"\tmax_retry\x18\x01 \x01(\rR\bmaxRetry\x12\x1b\n" +
+	"\tfind_time\x18\x02 \x01(\rR\bfindTime\x12\x19\n" +
+	"\bban_time\x18\x03 \x01(\rR\abanTime\x12!\n" +
+	"\foutbound_tag\x18\x04 \x01(\tR\voutboundTag\"\xf7\x01\n" +
 	"\x06Config\x12.\n" +
 	"\x04user\x18\x01 \x03(\v2\x1a.xray.common.protocol.UserR\x04user\x12A\n" +
-	"\adefault\x18\x02 \x01(\v2'.xray.proxy.vmess.inbound.DefaultConfigR\adefaultBj\n" +
+	"\adefault\x18\x02 \x01(\v2'.xray.proxy.vmess.inbound.DefaultConfigR\adefault\x12>\n" +
+	"\vuser_source\x18\x03 \x01(\v2\x1d.xray.proxy.usersource.ConfigR\n" +
+	"userSource\x12:\n" +
+	"\x03ban\x18\x04 \x01(\v2(.xray.proxy.vmess.inbound.AuthFailureBanR\x03banBj\n" +
 	"\x1ccom.xray.proxy.vmess.inboundP\x01Z-github.com/xtls/xray-core/proxy/vmess/inbound\xaa\x02\x18Xray.Proxy.Vmess.Inboundb\x06proto3"
 
 var (
@@ -188,21 +287,25 @@ func file_proxy_vmess_inbound_config_proto_rawDescGZIP() []byte {
 	return file_proxy_vmess_inbound_config_proto_rawDescData
 }
 
-var file_proxy_vmess_inbound_config_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_proxy_vmess_inbound_config_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
 var file_proxy_vmess_inbound_config_proto_goTypes = []any{
-	(*DetourConfig)(nil),  // 0: xray.proxy.vmess.inbound.DetourConfig
-	(*DefaultConfig)(nil), // 1: xray.proxy.vmess.inbound.DefaultConfig
-	(*Config)(nil),        // 2: xray.proxy.vmess.inbound.Config
-	(*protocol.User)(nil), // 3: xray.common.protocol.User
+	(*DetourConfig)(nil),      // 0: xray.proxy.vmess.inbound.DetourConfig
+	(*DefaultConfig)(nil),     // 1: xray.proxy.vmess.inbound.DefaultConfig
+	(*AuthFailureBan)(nil),    // 2: xray.proxy.vmess.inbound.AuthFailureBan
+	(*Config)(nil),            // 3: xray.proxy.vmess.inbound.Config
+	(*protocol.User)(nil),     // 4: xray.common.protocol.User
+	(*usersource.Config)(nil), // 5: xray.proxy.usersource.Config
 }
 var file_proxy_vmess_inbound_config_proto_depIdxs = []int32{
-	3, // 0: xray.proxy.vmess.inbound.Config.user:type_name -> xray.common.protocol.User
+	4, // 0: xray.proxy.vmess.inbound.Config.user:type_name -> xray.common.protocol.User
 	1, // 1: xray.proxy.vmess.inbound.Config.default:type_name -> xray.proxy.vmess.inbound.DefaultConfig
-	2, // [2:2] is the sub-list for method output_type
-	2, // [2:2] is the sub-list for method input_type
-	2, // [2:2] is the sub-list for extension type_name
-	2, // [2:2] is the sub-list for extension extendee
-	0, // [0:2] is the sub-list for field type_name
+	5, // 2: xray.proxy.vmess.inbound.Config.user_source:type_name -> xray.proxy.usersource.Config
+	2, // 3: xray.proxy.vmess.inbound.Config.ban:type_name -> xray.proxy.vmess.inbound.AuthFailureBan
+	4, // [4:4] is the sub-list for method output_type
+	4, // [4:4] is the sub-list for method input_type
+	4, // [4:4] is the sub-list for extension type_name
+	4, // [4:4] is the sub-list for extension extendee
+	0, // [0:4] is the sub-list for field type_name
 }
 
 func init() { file_proxy_vmess_inbound_config_proto_init() }
@@ -216,7 +319,7 @@ func file_proxy_vmess_inbound_config_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proxy_vmess_inbound_config_proto_rawDesc), len(file_proxy_vmess_inbound_config_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   3,
+			NumMessages:   4,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
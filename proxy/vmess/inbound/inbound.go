@@ -7,6 +7,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/xtls/xray-core/app/fail2ban"
 	"github.com/xtls/xray-core/common"
 	"github.com/xtls/xray-core/common/buf"
 	"github.com/xtls/xray-core/common/errors"
@@ -21,7 +22,10 @@ import (
 	feature_inbound "github.com/xtls/xray-core/features/inbound"
 	"github.com/xtls/xray-core/features/policy"
 	"github.com/xtls/xray-core/features/routing"
+	"github.com/xtls/xray-core/features/stats"
+	"github.com/xtls/xray-core/proxy/usersource"
 	"github.com/xtls/xray-core/proxy/vmess"
+	"github.com/xtls/xray-core/proxy/vmess/aead"
 	"github.com/xtls/xray-core/proxy/vmess/encoding"
 	"github.com/xtls/xray-core/transport/internet/stat"
 )
@@ -107,6 +111,10 @@ type Handler struct {
 	clients               *vmess.TimedUserValidator
 	usersByEmail          *userByEmail
 	sessionHistory        *encoding.SessionHistory
+	userSource            *usersource.Source
+	stats                 stats.Manager
+	authFailures          authFailureCounters // or nil
+	ban                   *fail2ban.Fail2ban  // or nil
 }
 
 // New creates a new VMess inbound handler.
@@ -118,6 +126,7 @@ func New(ctx context.Context, config *Config) (*Handler, error) {
 		clients:               vmess.NewTimedUserValidator(),
 		usersByEmail:          newUserByEmail(config.GetDefaultValue()),
 		sessionHistory:        encoding.NewSessionHistory(),
+		stats:                 v.GetFeature(stats.ManagerType()).(stats.Manager),
 	}
 
 	for _, user := range config.User {
@@ -131,11 +140,74 @@ func New(ctx context.Context, config *Config) (*Handler, error) {
 		}
 	}
 
+	if config.UserSource != nil {
+		if config.UserSource.Socket != "" {
+			return nil, errors.New("VMess users can't be queried from a socket, as they are identified by trying all of them")
+		}
+		source, err := usersource.New(ctx, config.UserSource, "vmess", handler, toUser)
+		if err != nil {
+			return nil, errors.New("failed to create user source").Base(err)
+		}
+		handler.userSource = source
+	}
+
+	var tag string
+	if inbound := session.InboundFromContext(ctx); inbound != nil {
+		tag = inbound.Tag
+	}
+	if len(tag) > 0 {
+		pm := handler.policyManager
+		if pm.ForSystem().Stats.InboundUplink || pm.ForSystem().Stats.InboundDownlink {
+			handler.authFailures = newAuthFailureCounters(handler.stats, tag)
+		}
+	}
+
+	if config.Ban != nil {
+		if err := core.RequireFeatures(ctx, func(r routing.Router) error {
+			ban, err := newAuthFailureBan(config.Ban, r, tag)
+			handler.ban = ban
+			return err
+		}); err != nil {
+			return nil, err
+		}
+	}
+
 	return handler, nil
 }
 
+func toUser(record *usersource.Record) (*protocol.MemoryUser, error) {
+	account, err := (&vmess.Account{
+		Id: record.ID,
+		SecuritySettings: &protocol.SecurityConfig{
+			Type: protocol.SecurityType_AUTO,
+		},
+	}).AsAccount()
+	if err != nil {
+		return nil, err
+	}
+	return &protocol.MemoryUser{
+		Email:   record.Email,
+		Level:   record.Level,
+		Account: account,
+	}, nil
+}
+
+// Start implements common.Runnable.
+func (h *Handler) Start() error {
+	if h.userSource != nil {
+		return h.userSource.Start()
+	}
+	return nil
+}
+
 // Close implements common.Closable.
 func (h *Handler) Close() error {
+	if h.userSource != nil {
+		h.userSource.Close()
+	}
+	if h.ban != nil {
+		h.ban.Close()
+	}
 	return errors.Combine(
 		h.sessionHistory.Close(),
 		common.Close(h.usersByEmail),
@@ -225,6 +297,10 @@ func transferResponse(timer signal.ActivityUpdater, session *encoding.ServerSess
 
 // Process implements proxy.Inbound.Process().
 func (h *Handler) Process(ctx context.Context, network net.Network, connection stat.Connection, dispatcher routing.Dispatcher) error {
+	if h.banned(ctx) {
+		return errors.New("rejected banned source ", connection.RemoteAddr()).AtInfo()
+	}
+
 	sessionPolicy := h.policyManager.ForLevel(0)
 	if err := connection.SetReadDeadline(time.Now().Add(sessionPolicy.Timeouts.Handshake)); err != nil {
 		return errors.New("unable to set read deadline").Base(err).AtWarning()
@@ -247,6 +323,7 @@ func (h *Handler) Process(ctx context.Context, network net.Network, connection s
 				Status: log.AccessRejected,
 				Reason: err,
 			})
+			h.authFailed(ctx, err)
 			err = errors.New("invalid request from ", connection.RemoteAddr()).Base(err).AtInfo()
 		}
 		return err
@@ -319,6 +396,60 @@ func (h *Handler) Process(ctx context.Context, network net.Network, connection s
 	return nil
 }
 
+// authFailures are the reasons of authentication failures which are counted, by their causes.
+var authFailures = map[error]string{
+	aead.ErrReplay:       "replay",
+	aead.ErrInvalidTime:  "time_drift",
+	aead.ErrNeagtiveTime: "time_drift",
+	aead.ErrNotFound:     "unknown_user",
+}
+
+// authFailureCounters counts the authentication failures of an inbound by reason.
+type authFailureCounters map[string]stats.Counter
+
+func newAuthFailureCounters(sm stats.Manager, tag string) authFailureCounters {
+	counters := make(authFailureCounters)
+	for _, reason := range authFailures {
+		if counters[reason] == nil {
+			counters[reason], _ = stats.GetOrRegisterCounter(sm, "inbound>>>"+tag+">>>vmess>>>"+reason)
+		}
+	}
+	return counters
+}
+
+// authFailed counts the authentication failure of err if it is one, bans its source if it fails too often,
+// and publishes it for fail2ban.
+func (h *Handler) authFailed(ctx context.Context, err error) {
+	reason, found := authFailures[errors.Cause(err)]
+	if !found {
+		return
+	}
+	inbound := session.InboundFromContext(ctx)
+	errors.LogInfo(ctx, "vmess authentication failure: reason=", reason, " source=", inbound.Source, " inbound=", inbound.Tag)
+	if h.authFailures != nil {
+		h.authFailures[reason].Add(1)
+	}
+	failure := stats.AuthFailure{
+		InboundTag: inbound.Tag,
+		Protocol:   "vmess",
+		Source:     inbound.Source.Address,
+		Reason:     reason,
+	}
+	if h.ban != nil {
+		h.ban.Fail(&failure)
+	}
+	stats.PublishAuthFailure(h.stats, failure)
+}
+
+// banned returns whether the source of the connection is banned from the inbound.
+func (h *Handler) banned(ctx context.Context) bool {
+	if h.ban == nil {
+		return false
+	}
+	inbound := session.InboundFromContext(ctx)
+	return inbound != nil && inbound.Source.Address != nil && h.ban.Banned(inbound.Tag, inbound.Source.Address)
+}
+
 // Stub command generator
 func (h *Handler) generateCommand(ctx context.Context, request *protocol.RequestHeader) protocol.ResponseCommand {
 	return nil
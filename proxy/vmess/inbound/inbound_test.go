@@ -0,0 +1,72 @@
+package inbound
+
+import (
+	"context"
+	gonet "net"
+	"testing"
+	"time"
+
+	"github.com/xtls/xray-core/app/router"
+	"github.com/xtls/xray-core/app/stats"
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/session"
+	"github.com/xtls/xray-core/proxy/vmess/aead"
+)
+
+func TestAuthFailures(t *testing.T) {
+	sm := common.Must2(stats.NewManager(context.Background(), &stats.Config{}))
+	r := new(router.Router)
+	ban := common.Must2(newAuthFailureBan(&AuthFailureBan{MaxRetry: 3, BanTime: 1, OutboundTag: "blocked"}, r, "in"))
+	defer ban.Close()
+	h := &Handler{
+		stats:        sm,
+		authFailures: newAuthFailureCounters(sm, "in"),
+		ban:          ban,
+	}
+
+	source := net.ParseAddress("192.0.2.1")
+	ctx := session.ContextWithInbound(context.Background(), &session.Inbound{
+		Source: net.TCPDestination(source, 1234),
+		Tag:    "in",
+	})
+	h.authFailed(ctx, errors.New("failed to read request header").Base(aead.ErrReplay))
+	h.authFailed(ctx, aead.ErrInvalidTime)
+	if h.banned(ctx) {
+		t.Fatal("banned before max retry")
+	}
+	h.authFailed(ctx, errors.New("invalid user").Base(aead.ErrNeagtiveTime))
+	h.authFailed(ctx, aead.ErrNotFound)
+	// other errors are not authentication failures
+	h.authFailed(ctx, errors.New("unexpected EOF"))
+
+	for reason, value := range map[string]int64{"replay": 1, "time_drift": 2, "unknown_user": 1} {
+		if counter := sm.GetCounter("inbound>>>in>>>vmess>>>" + reason); counter == nil || counter.Value() != value {
+			t.Error("unexpected ", reason, " counter ", counter)
+		}
+	}
+
+	if !h.banned(ctx) {
+		t.Fatal("expected the source to be banned after max retry")
+	}
+	other := session.ContextWithInbound(context.Background(), &session.Inbound{
+		Source: net.TCPDestination(net.ParseAddress("192.0.2.2"), 1234),
+		Tag:    "in",
+	})
+	if h.banned(other) {
+		t.Error("unexpected banned source")
+	}
+	// the connections from the banned source are rejected before the handshake
+	client, server := gonet.Pipe()
+	defer client.Close()
+	if err := h.Process(ctx, net.Network_TCP, server, nil); err == nil {
+		t.Error("expected the connection from the banned source to be rejected")
+	}
+
+	for deadline := time.Now().Add(3 * time.Second); h.banned(ctx); time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("expected the ban to be lifted after ban time")
+		}
+	}
+}
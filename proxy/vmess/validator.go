@@ -6,6 +6,7 @@ import (
 	"hash/crc64"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/xtls/xray-core/common/dice"
 	"github.com/xtls/xray-core/common/errors"
@@ -81,7 +82,11 @@ func (v *TimedUserValidator) GetAEAD(userHash []byte) (*protocol.MemoryUser, boo
 	if err != nil {
 		return nil, false, err
 	}
-	return userd.(*protocol.MemoryUser), true, nil
+	user := userd.(*protocol.MemoryUser)
+	if err := user.Check(time.Now()); err != nil {
+		return nil, false, errors.New("rejected user ", user.Email).Base(err)
+	}
+	return user, true, nil
 }
 
 func (v *TimedUserValidator) Remove(email string) bool {
@@ -39,10 +39,11 @@ type Handler struct {
 	uplinkCounter   stats.Counter
 	downlinkCounter stats.Counter
 
-	tun  tun.Device
-	tnet *Net
-	dev  *device.Device
-	mu   sync.Mutex
+	tun      tun.Device
+	tnet     *Net
+	dev      *device.Device
+	failover *failover
+	mu       sync.Mutex
 }
 
 func NewClient(ctx context.Context, conf *DeviceConfig) (*Handler, error) {
@@ -82,6 +83,13 @@ func NewClient(ctx context.Context, conf *DeviceConfig) (*Handler, error) {
 			return nil, errors.New("peer without endpoint")
 		}
 	}
+	var fo *failover
+	if conf.Failover != nil {
+		var err error
+		if fo, err = newFailover(conf.Failover, conf.Peers); err != nil {
+			return nil, err
+		}
+	}
 
 	localAddresses := make([]netip.Addr, 0, len(conf.Endpoint))
 	for _, localaddress := range conf.Endpoint {
@@ -124,8 +132,9 @@ func NewClient(ctx context.Context, conf *DeviceConfig) (*Handler, error) {
 		uplinkCounter:   uplinkCounter,
 		downlinkCounter: downlinkCounter,
 
-		tun:  tun,
-		tnet: tnet,
+		tun:      tun,
+		tnet:     tnet,
+		failover: fo,
 	}, nil
 }
 
@@ -217,6 +226,17 @@ func (h *Handler) Process(ctx context.Context, link *transport.Link, dialer inte
 		}
 		reader = c
 		writer = c
+	case net.Network_ICMP:
+		if h.tnet.DialICMPAddr == nil {
+			return errors.New("ICMP is not supported by kernel TUN")
+		}
+		conn, err := h.tnet.DialICMPAddr(addr)
+		if err != nil {
+			return errors.New("failed to create ICMP session").Base(err)
+		}
+		defer conn.Close()
+		reader = &buf.PacketReader{Reader: conn}
+		writer = &buf.SequentialWriter{Writer: conn}
 	default:
 		panic(ob.Target.Network)
 	}
@@ -241,10 +261,26 @@ func (h *Handler) Process(ctx context.Context, link *transport.Link, dialer inte
 	return nil
 }
 
+// Health implements proxy.HealthReporter.
+func (h *Handler) Health() (string, bool) {
+	if h.failover == nil {
+		return "", true
+	}
+	return h.failover.Health()
+}
+
+// CanForwardICMP implements proxy.ICMPForwarder.
+func (h *Handler) CanForwardICMP() bool {
+	return h.tnet != nil && h.tnet.DialICMPAddr != nil
+}
+
 func (h *Handler) Close() (err error) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 	if h.dev != nil {
+		if h.failover != nil {
+			h.failover.Close()
+		}
 		h.dev.Close()
 		h.dev = nil
 		h.tun = nil
@@ -325,11 +361,19 @@ func (h *Handler) init(ctx context.Context) error {
 			cfg.WriteString("preshared_key=" + peer.PreSharedKey + "\n")
 		}
 		cfg.WriteString("endpoint=" + peer.Endpoint + "\n")
-		for _, ip := range peer.AllowedIps {
-			cfg.WriteString("allowed_ip=" + ip + "\n")
+		// only the active peer of a failover group is given the allowed IPs
+		if h.failover == nil || h.failover.isActive(peer) {
+			for _, ip := range peer.AllowedIps {
+				cfg.WriteString("allowed_ip=" + ip + "\n")
+			}
 		}
-		if peer.KeepAlive != "" {
-			cfg.WriteString("persistent_keepalive_interval=" + peer.KeepAlive + "\n")
+		keepAlive := peer.KeepAlive
+		if keepAlive == "" && h.failover != nil {
+			// handshakes of idle peers are needed to know whether they are alive
+			keepAlive = h.failover.keepAlive
+		}
+		if keepAlive != "" {
+			cfg.WriteString("persistent_keepalive_interval=" + keepAlive + "\n")
 		}
 	}
 	err := dev.IpcSet(cfg.String())
@@ -340,6 +384,11 @@ func (h *Handler) init(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
+	if h.failover != nil {
+		if err := h.failover.Start(dev); err != nil {
+			return err
+		}
+	}
 	h.dev = dev
 	return nil
 }
@@ -0,0 +1,135 @@
+package command
+
+import (
+	"context"
+	"encoding/hex"
+
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/protocol"
+	"github.com/xtls/xray-core/core"
+	"github.com/xtls/xray-core/features/inbound"
+	"github.com/xtls/xray-core/proxy"
+	"github.com/xtls/xray-core/proxy/wireguard"
+	grpc "google.golang.org/grpc"
+)
+
+type wireGuardServer struct {
+	UnimplementedWireGuardServiceServer
+	ihm inbound.Manager
+}
+
+func (s *wireGuardServer) getServer(ctx context.Context, tag string) (*wireguard.Server, error) {
+	handler, err := s.ihm.GetHandler(ctx, tag)
+	if err != nil {
+		return nil, errors.New("failed to get handler: ", tag).Base(err)
+	}
+	gi, ok := handler.(proxy.GetInbound)
+	if !ok {
+		return nil, errors.New("can't get inbound proxy from handler.")
+	}
+	server, ok := gi.GetInbound().(*wireguard.Server)
+	if !ok {
+		return nil, errors.New("inbound ", tag, " is not a wireguard inbound")
+	}
+	return server, nil
+}
+
+func (s *wireGuardServer) ListPeers(ctx context.Context, request *ListPeersRequest) (*ListPeersResponse, error) {
+	server, err := s.getServer(ctx, request.Tag)
+	if err != nil {
+		return nil, err
+	}
+	statuses, err := server.GetPeerStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+	response := &ListPeersResponse{
+		Peers: make([]*Peer, 0, len(statuses)),
+	}
+	for _, status := range statuses {
+		peer := &Peer{
+			PublicKey:       hex.EncodeToString(status.PublicKey[:]),
+			HasPreSharedKey: status.PreSharedKey,
+			Endpoint:        status.Endpoint,
+			RxBytes:         status.RxBytes,
+			TxBytes:         status.TxBytes,
+			KeepAlive:       status.KeepAlive,
+		}
+		if status.User != nil {
+			peer.Email = status.User.Email
+			peer.Level = status.User.Level
+		}
+		if !status.LastHandshake.IsZero() {
+			peer.LastHandshake = status.LastHandshake.Unix()
+		}
+		for _, prefix := range status.AllowedIPs {
+			peer.AllowedIps = append(peer.AllowedIps, prefix.String())
+		}
+		response.Peers = append(response.Peers, peer)
+	}
+	return response, nil
+}
+
+func (s *wireGuardServer) AddPeer(ctx context.Context, request *AddPeerRequest) (*AddPeerResponse, error) {
+	if request.Peer == nil {
+		return nil, errors.New("peer is not specified")
+	}
+	server, err := s.getServer(ctx, request.Tag)
+	if err != nil {
+		return nil, err
+	}
+	account, err := request.Peer.AsAccount()
+	if err != nil {
+		return nil, errors.New("invalid peer").Base(err)
+	}
+	if request.Email != "" {
+		if user := server.GetUser(ctx, request.Email); user != nil && !user.Account.Equals(account) {
+			return nil, errors.New("email ", request.Email, " is used by another peer")
+		}
+	}
+	return &AddPeerResponse{}, server.AddUser(ctx, &protocol.MemoryUser{
+		Email:   request.Email,
+		Level:   request.Level,
+		Account: account,
+	})
+}
+
+func (s *wireGuardServer) RemovePeer(ctx context.Context, request *RemovePeerRequest) (*RemovePeerResponse, error) {
+	server, err := s.getServer(ctx, request.Tag)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case request.PublicKey != "":
+		pub, err := wireguard.ParseKey(request.PublicKey)
+		if err != nil {
+			return nil, errors.New("invalid public key").Base(err)
+		}
+		return &RemovePeerResponse{}, server.RemovePeer(ctx, *pub)
+	case request.Email != "":
+		user := server.GetUser(ctx, request.Email)
+		if user == nil {
+			return nil, errors.New("peer not found: ", request.Email)
+		}
+		return &RemovePeerResponse{}, server.RemovePeer(ctx, user.Account.(*wireguard.MemoryAccount).Pub)
+	default:
+		return nil, errors.New("either public key or email of the peer must be specified")
+	}
+}
+
+func (s *wireGuardServer) Register(server *grpc.Server) {
+	RegisterWireGuardServiceServer(server, s)
+}
+
+func init() {
+	common.Must(common.RegisterConfig((*Config)(nil), func(ctx context.Context, cfg interface{}) (interface{}, error) {
+		s := &wireGuardServer{}
+		if err := core.RequireFeatures(ctx, func(im inbound.Manager) {
+			s.ihm = im
+		}); err != nil {
+			return nil, err
+		}
+		return s, nil
+	}))
+}
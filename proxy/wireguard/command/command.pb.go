@@ -0,0 +1,574 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        v6.33.5
+// source: proxy/wireguard/command/command.proto
+
+package command
+
+import (
+	wireguard "github.com/xtls/xray-core/proxy/wireguard"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Peer struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Email string                 `protobuf:"bytes,1,opt,name=email,proto3" json:"email,omitempty"`
+	Level uint32                 `protobuf:"varint,2,opt,name=level,proto3" json:"level,omitempty"`
+	// Keys are hex encoded, as in the config.
+	PublicKey       string `protobuf:"bytes,3,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"`
+	HasPreSharedKey bool   `protobuf:"varint,4,opt,name=has_pre_shared_key,json=hasPreSharedKey,proto3" json:"has_pre_shared_key,omitempty"`
+	Endpoint        string `protobuf:"bytes,5,opt,name=endpoint,proto3" json:"endpoint,omitempty"`
+	// Unix time of the last handshake, 0 if there was none.
+	LastHandshake int64    `protobuf:"varint,6,opt,name=last_handshake,json=lastHandshake,proto3" json:"last_handshake,omitempty"`
+	RxBytes       uint64   `protobuf:"varint,7,opt,name=rx_bytes,json=rxBytes,proto3" json:"rx_bytes,omitempty"`
+	TxBytes       uint64   `protobuf:"varint,8,opt,name=tx_bytes,json=txBytes,proto3" json:"tx_bytes,omitempty"`
+	AllowedIps    []string `protobuf:"bytes,9,rep,name=allowed_ips,json=allowedIps,proto3" json:"allowed_ips,omitempty"`
+	KeepAlive     uint32   `protobuf:"varint,10,opt,name=keep_alive,json=keepAlive,proto3" json:"keep_alive,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Peer) Reset() {
+	*x = Peer{}
+	mi := &file_proxy_wireguard_command_command_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Peer) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Peer) ProtoMessage() {}
+
+func (x *Peer) ProtoReflect() protoreflect.Message {
+	mi := &file_proxy_wireguard_command_command_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Peer.ProtoReflect.Descriptor instead.
+func (*Peer) Descriptor() ([]byte, []int) {
+	return file_proxy_wireguard_command_command_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Peer) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+func (x *Peer) GetLevel() uint32 {
+	if x != nil {
+		return x.Level
+	}
+	return 0
+}
+
+func (x *Peer) GetPublicKey() string {
+	if x != nil {
+		return x.PublicKey
+	}
+	return ""
+}
+
+func (x *Peer) GetHasPreSharedKey() bool {
+	if x != nil {
+		return x.HasPreSharedKey
+	}
+	return false
+}
+
+func (x *Peer) GetEndpoint() string {
+	if x != nil {
+		return x.Endpoint
+	}
+	return ""
+}
+
+func (x *Peer) GetLastHandshake() int64 {
+	if x != nil {
+		return x.LastHandshake
+	}
+	return 0
+}
+
+func (x *Peer) GetRxBytes() uint64 {
+	if x != nil {
+		return x.RxBytes
+	}
+	return 0
+}
+
+func (x *Peer) GetTxBytes() uint64 {
+	if x != nil {
+		return x.TxBytes
+	}
+	return 0
+}
+
+func (x *Peer) GetAllowedIps() []string {
+	if x != nil {
+		return x.AllowedIps
+	}
+	return nil
+}
+
+func (x *Peer) GetKeepAlive() uint32 {
+	if x != nil {
+		return x.KeepAlive
+	}
+	return 0
+}
+
+type ListPeersRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Tag           string                 `protobuf:"bytes,1,opt,name=tag,proto3" json:"tag,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListPeersRequest) Reset() {
+	*x = ListPeersRequest{}
+	mi := &file_proxy_wireguard_command_command_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListPeersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPeersRequest) ProtoMessage() {}
+
+func (x *ListPeersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proxy_wireguard_command_command_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPeersRequest.ProtoReflect.Descriptor instead.
+func (*ListPeersRequest) Descriptor() ([]byte, []int) {
+	return file_proxy_wireguard_command_command_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ListPeersRequest) GetTag() string {
+	if x != nil {
+		return x.Tag
+	}
+	return ""
+}
+
+type ListPeersResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Peers         []*Peer                `protobuf:"bytes,1,rep,name=peers,proto3" json:"peers,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListPeersResponse) Reset() {
+	*x = ListPeersResponse{}
+	mi := &file_proxy_wireguard_command_command_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListPeersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPeersResponse) ProtoMessage() {}
+
+func (x *ListPeersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proxy_wireguard_command_command_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPeersResponse.ProtoReflect.Descriptor instead.
+func (*ListPeersResponse) Descriptor() ([]byte, []int) {
+	return file_proxy_wireguard_command_command_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ListPeersResponse) GetPeers() []*Peer {
+	if x != nil {
+		return x.Peers
+	}
+	return nil
+}
+
+type AddPeerRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Tag           string                 `protobuf:"bytes,1,opt,name=tag,proto3" json:"tag,omitempty"`
+	Email         string                 `protobuf:"bytes,2,opt,name=email,proto3" json:"email,omitempty"`
+	Level         uint32                 `protobuf:"varint,3,opt,name=level,proto3" json:"level,omitempty"`
+	Peer          *wireguard.PeerConfig  `protobuf:"bytes,4,opt,name=peer,proto3" json:"peer,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddPeerRequest) Reset() {
+	*x = AddPeerRequest{}
+	mi := &file_proxy_wireguard_command_command_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddPeerRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddPeerRequest) ProtoMessage() {}
+
+func (x *AddPeerRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proxy_wireguard_command_command_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddPeerRequest.ProtoReflect.Descriptor instead.
+func (*AddPeerRequest) Descriptor() ([]byte, []int) {
+	return file_proxy_wireguard_command_command_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *AddPeerRequest) GetTag() string {
+	if x != nil {
+		return x.Tag
+	}
+	return ""
+}
+
+func (x *AddPeerRequest) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+func (x *AddPeerRequest) GetLevel() uint32 {
+	if x != nil {
+		return x.Level
+	}
+	return 0
+}
+
+func (x *AddPeerRequest) GetPeer() *wireguard.PeerConfig {
+	if x != nil {
+		return x.Peer
+	}
+	return nil
+}
+
+type AddPeerResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddPeerResponse) Reset() {
+	*x = AddPeerResponse{}
+	mi := &file_proxy_wireguard_command_command_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddPeerResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddPeerResponse) ProtoMessage() {}
+
+func (x *AddPeerResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proxy_wireguard_command_command_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddPeerResponse.ProtoReflect.Descriptor instead.
+func (*AddPeerResponse) Descriptor() ([]byte, []int) {
+	return file_proxy_wireguard_command_command_proto_rawDescGZIP(), []int{4}
+}
+
+type RemovePeerRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Tag   string                 `protobuf:"bytes,1,opt,name=tag,proto3" json:"tag,omitempty"`
+	// Either the public key or the email of the peer.
+	PublicKey     string `protobuf:"bytes,2,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"`
+	Email         string `protobuf:"bytes,3,opt,name=email,proto3" json:"email,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RemovePeerRequest) Reset() {
+	*x = RemovePeerRequest{}
+	mi := &file_proxy_wireguard_command_command_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RemovePeerRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RemovePeerRequest) ProtoMessage() {}
+
+func (x *RemovePeerRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proxy_wireguard_command_command_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RemovePeerRequest.ProtoReflect.Descriptor instead.
+func (*RemovePeerRequest) Descriptor() ([]byte, []int) {
+	return file_proxy_wireguard_command_command_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *RemovePeerRequest) GetTag() string {
+	if x != nil {
+		return x.Tag
+	}
+	return ""
+}
+
+func (x *RemovePeerRequest) GetPublicKey() string {
+	if x != nil {
+		return x.PublicKey
+	}
+	return ""
+}
+
+func (x *RemovePeerRequest) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+type RemovePeerResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RemovePeerResponse) Reset() {
+	*x = RemovePeerResponse{}
+	mi := &file_proxy_wireguard_command_command_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RemovePeerResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RemovePeerResponse) ProtoMessage() {}
+
+func (x *RemovePeerResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proxy_wireguard_command_command_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RemovePeerResponse.ProtoReflect.Descriptor instead.
+func (*RemovePeerResponse) Descriptor() ([]byte, []int) {
+	return file_proxy_wireguard_command_command_proto_rawDescGZIP(), []int{6}
+}
+
+type Config struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Config) Reset() {
+	*x = Config{}
+	mi := &file_proxy_wireguard_command_command_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Config) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Config) ProtoMessage() {}
+
+func (x *Config) ProtoReflect() protoreflect.Message {
+	mi := &file_proxy_wireguard_command_command_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Config.ProtoReflect.Descriptor instead.
+func (*Config) Descriptor() ([]byte, []int) {
+	return file_proxy_wireguard_command_command_proto_rawDescGZIP(), []int{7}
+}
+
+var File_proxy_wireguard_command_command_proto protoreflect.FileDescriptor
+
+const file_proxy_wireguard_command_command_proto_rawDesc = "" +
+	"\n" +
+	"%proxy/wireguard/command/command.proto\x12\x1cxray.proxy.wireguard.command\x1a\x1cproxy/wireguard/config.proto\"\xb7\x02\n" +
+	"\x04Peer\x12\x14\n" +
+	"\x05email\x18\x01 \x01(\tR\x05email\x12\x14\n" +
+	"\x05level\x18\x02 \x01(\rR\x05level\x12\x1d\n" +
+	"\n" +
+	"public_key\x18\x03 \x01(\tR\tpublicKey\x12+\n" +
+	"\x12has_pre_shared_key\x18\x04 \x01(\bR\x0fhasPreSharedKey\x12\x1a\n" +
+	"\bendpoint\x18\x05 \x01(\tR\bendpoint\x12%\n" +
+	"\x0elast_handshake\x18\x06 \x01(\x03R\rlastHandshake\x12\x19\n" +
+	"\brx_bytes\x18\a \x01(\x04R\arxBytes\x12\x19\n" +
+	"\btx_bytes\x18\b \x01(\x04R\atxBytes\x12\x1f\n" +
+	"\vallowed_ips\x18\t \x03(\tR\n" +
+	"allowedIps\x12\x1d\n" +
+	"\n" +
+	"keep_alive\x18\n" +
+	" \x01(\rR\tkeepAlive\"$\n" +
+	"\x10ListPeersRequest\x12\x10\n" +
+	"\x03tag\x18\x01 \x01(\tR\x03tag\"M\n" +
+	"\x11ListPeersResponse\x128\n" +
+	"\x05peers\x18\x01 \x03(\v2\".xray.proxy.wireguard.command.PeerR\x05peers\"\x84\x01\n" +
+	"\x0eAddPeerRequest\x12\x10\n" +
+	"\x03tag\x18\x01 \x01(\tR\x03tag\x12\x14\n" +
+	"\x05email\x18\x02 \x01(\tR\x05email\x12\x14\n" +
+	"\x05level\x18\x03 \x01(\rR\x05level\x124\n" +
+	"\x04peer\x18\x04 \x01(\v2 .xray.proxy.wireguard.PeerConfigR\x04peer\"\x11\n" +
+	"\x0fAddPeerResponse\"Z\n" +
+	"\x11RemovePeerRequest\x12\x10\n" +
+	"\x03tag\x18\x01 \x01(\tR\x03tag\x12\x1d\n" +
+	"\n" +
+	"public_key\x18\x02 \x01(\tR\tpublicKey\x12\x14\n" +
+	"\x05email\x18\x03 \x01(\tR\x05email\"\x14\n" +
+	"\x12RemovePeerResponse\"\b\n" +
+	"\x06Config2\xdf\x02\n" +
+	"\x10WireGuardService\x12n\n" +
+	"\tListPeers\x12..xray.proxy.wireguard.command.ListPeersRequest\x1a/.xray.proxy.wireguard.command.ListPeersResponse\"\x00\x12h\n" +
+	"\aAddPeer\x12,.xray.proxy.wireguard.command.AddPeerRequest\x1a-.xray.proxy.wireguard.command.AddPeerResponse\"\x00\x12q\n" +
+	"\n" +
+	"RemovePeer\x12/.xray.proxy.wireguard.command.RemovePeerRequest\x1a0.xray.proxy.wireguard.command.RemovePeerResponse\"\x00Bv\n" +
+	" com.xray.proxy.wireguard.commandP\x01Z1github.com/xtls/xray-core/proxy/wireguard/command\xaa\x02\x1cXray.Proxy.WireGuard.Commandb\x06proto3"
+
+var (
+	file_proxy_wireguard_command_command_proto_rawDescOnce sync.Once
+	file_proxy_wireguard_command_command_proto_rawDescData []byte
+)
+
+func file_proxy_wireguard_command_command_proto_rawDescGZIP() []byte {
+	file_proxy_wireguard_command_command_proto_rawDescOnce.Do(func() {
+		file_proxy_wireguard_command_command_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_proxy_wireguard_command_command_proto_rawDesc), len(file_proxy_wireguard_command_command_proto_rawDesc)))
+	})
+	return file_proxy_wireguard_command_command_proto_rawDescData
+}
+
+var file_proxy_wireguard_command_command_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_proxy_wireguard_command_command_proto_goTypes = []any{
+	(*Peer)(nil),                 // 0: xray.proxy.wireguard.command.Peer
+	(*ListPeersRequest)(nil),     // 1: xray.proxy.wireguard.command.ListPeersRequest
+	(*ListPeersResponse)(nil),    // 2: xray.proxy.wireguard.command.ListPeersResponse
+	(*AddPeerRequest)(nil),       // 3: xray.proxy.wireguard.command.AddPeerRequest
+	(*AddPeerResponse)(nil),      // 4: xray.proxy.wireguard.command.AddPeerResponse
+	(*RemovePeerRequest)(nil),    // 5: xray.proxy.wireguard.command.RemovePeerRequest
+	(*RemovePeerResponse)(nil),   // 6: xray.proxy.wireguard.command.RemovePeerResponse
+	(*Config)(nil),               // 7: xray.proxy.wireguard.command.Config
+	(*wireguard.PeerConfig)(nil), // 8: xray.proxy.wireguard.PeerConfig
+}
+var file_proxy_wireguard_command_command_proto_depIdxs = []int32{
+	0, // 0: xray.proxy.wireguard.command.ListPeersResponse.peers:type_name -> xray.proxy.wireguard.command.Peer
+	8, // 1: xray.proxy.wireguard.command.AddPeerRequest.peer:type_name -> xray.proxy.wireguard.PeerConfig
+	1, // 2: xray.proxy.wireguard.command.WireGuardService.ListPeers:input_type -> xray.proxy.wireguard.command.ListPeersRequest
+	3, // 3: xray.proxy.wireguard.command.WireGuardService.AddPeer:input_type -> xray.proxy.wireguard.command.AddPeerRequest
+	5, // 4: xray.proxy.wireguard.command.WireGuardService.RemovePeer:input_type -> xray.proxy.wireguard.command.RemovePeerRequest
+	2, // 5: xray.proxy.wireguard.command.WireGuardService.ListPeers:output_type -> xray.proxy.wireguard.command.ListPeersResponse
+	4, // 6: xray.proxy.wireguard.command.WireGuardService.AddPeer:output_type -> xray.proxy.wireguard.command.AddPeerResponse
+	6, // 7: xray.proxy.wireguard.command.WireGuardService.RemovePeer:output_type -> xray.proxy.wireguard.command.RemovePeerResponse
+	5, // [5:8] is the sub-list for method output_type
+	2, // [2:5] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
+}
+
+func init() { file_proxy_wireguard_command_command_proto_init() }
+func file_proxy_wireguard_command_command_proto_init() {
+	if File_proxy_wireguard_command_command_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proxy_wireguard_command_command_proto_rawDesc), len(file_proxy_wireguard_command_command_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   8,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_proxy_wireguard_command_command_proto_goTypes,
+		DependencyIndexes: file_proxy_wireguard_command_command_proto_depIdxs,
+		MessageInfos:      file_proxy_wireguard_command_command_proto_msgTypes,
+	}.Build()
+	File_proxy_wireguard_command_command_proto = out.File
+	file_proxy_wireguard_command_command_proto_goTypes = nil
+	file_proxy_wireguard_command_command_proto_depIdxs = nil
+}
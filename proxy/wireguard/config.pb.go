@@ -74,7 +74,7 @@ func (x DeviceConfig_DomainStrategy) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use DeviceConfig_DomainStrategy.Descriptor instead.
 func (DeviceConfig_DomainStrategy) EnumDescriptor() ([]byte, []int) {
-	return file_proxy_wireguard_config_proto_rawDescGZIP(), []int{1, 0}
+	return file_proxy_wireguard_config_proto_rawDescGZIP(), []int{2, 0}
 }
 
 type PeerConfig struct {
@@ -153,6 +153,69 @@ func (x *PeerConfig) GetAllowedIps() []string {
 	return nil
 }
 
+type FailoverConfig struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Seconds without a handshake after which a peer is considered down.
+	HandshakeTimeout uint32 `protobuf:"varint,1,opt,name=handshake_timeout,json=handshakeTimeout,proto3" json:"handshake_timeout,omitempty"`
+	// Seconds between checks of the peers.
+	CheckInterval uint32 `protobuf:"varint,2,opt,name=check_interval,json=checkInterval,proto3" json:"check_interval,omitempty"`
+	// Keepalive interval in seconds given to the peers without one, so that they keep handshaking.
+	KeepAlive     uint32 `protobuf:"varint,3,opt,name=keep_alive,json=keepAlive,proto3" json:"keep_alive,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FailoverConfig) Reset() {
+	*x = FailoverConfig{}
+	mi := &file_proxy_wireguard_config_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FailoverConfig) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FailoverConfig) ProtoMessage() {}
+
+func (x *FailoverConfig) ProtoReflect() protoreflect.Message {
+	mi := &file_proxy_wireguard_config_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FailoverConfig.ProtoReflect.Descriptor instead.
+func (*FailoverConfig) Descriptor() ([]byte, []int) {
+	return file_proxy_wireguard_config_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *FailoverConfig) GetHandshakeTimeout() uint32 {
+	if x != nil {
+		return x.HandshakeTimeout
+	}
+	return 0
+}
+
+func (x *FailoverConfig) GetCheckInterval() uint32 {
+	if x != nil {
+		return x.CheckInterval
+	}
+	return 0
+}
+
+func (x *FailoverConfig) GetKeepAlive() uint32 {
+	if x != nil {
+		return x.KeepAlive
+	}
+	return 0
+}
+
 type DeviceConfig struct {
 	state          protoimpl.MessageState      `protogen:"open.v1"`
 	SecretKey      string                      `protobuf:"bytes,1,opt,name=secret_key,json=secretKey,proto3" json:"secret_key,omitempty"`
@@ -164,13 +227,15 @@ type DeviceConfig struct {
 	DomainStrategy DeviceConfig_DomainStrategy `protobuf:"varint,7,opt,name=domain_strategy,json=domainStrategy,proto3,enum=xray.proxy.wireguard.DeviceConfig_DomainStrategy" json:"domain_strategy,omitempty"`
 	IsClient       bool                        `protobuf:"varint,8,opt,name=is_client,json=isClient,proto3" json:"is_client,omitempty"`
 	NoKernelTun    bool                        `protobuf:"varint,9,opt,name=no_kernel_tun,json=noKernelTun,proto3" json:"no_kernel_tun,omitempty"`
-	unknownFields  protoimpl.UnknownFields
-	sizeCache      protoimpl.SizeCache
+	// Failover between client peers of the same allowed IPs, in their order.
+	Failover      *FailoverConfig `protobuf:"bytes,10,opt,name=failover,proto3" json:"failover,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
 func (x *DeviceConfig) Reset() {
 	*x = DeviceConfig{}
-	mi := &file_proxy_wireguard_config_proto_msgTypes[1]
+	mi := &file_proxy_wireguard_config_proto_msgTypes[2]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -182,7 +247,7 @@ func (x *DeviceConfig) String() string {
 func (*DeviceConfig) ProtoMessage() {}
 
 func (x *DeviceConfig) ProtoReflect() protoreflect.Message {
-	mi := &file_proxy_wireguard_config_proto_msgTypes[1]
+	mi := &file_proxy_wireguard_config_proto_msgTypes[2]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -195,7 +260,7 @@ func (x *DeviceConfig) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DeviceConfig.ProtoReflect.Descriptor instead.
 func (*DeviceConfig) Descriptor() ([]byte, []int) {
-	return file_proxy_wireguard_config_proto_rawDescGZIP(), []int{1}
+	return file_proxy_wireguard_config_proto_rawDescGZIP(), []int{2}
 }
 
 func (x *DeviceConfig) GetSecretKey() string {
@@ -261,6 +326,13 @@ func (x *DeviceConfig) GetNoKernelTun() bool {
 	return false
 }
 
+func (x *DeviceConfig) GetFailover() *FailoverConfig {
+	if x != nil {
+		return x.Failover
+	}
+	return nil
+}
+
 var File_proxy_wireguard_config_proto protoreflect.FileDescriptor
 
 const file_proxy_wireguard_config_proto_rawDesc = "" +
@@ -275,7 +347,12 @@ const file_proxy_wireguard_config_proto_rawDesc = "" +
 	"\n" +
 	"keep_alive\x18\x04 \x01(\tR\tkeepAlive\x12\x1f\n" +
 	"\vallowed_ips\x18\x05 \x03(\tR\n" +
-	"allowedIps\"\xdc\x03\n" +
+	"allowedIps\"\x83\x01\n" +
+	"\x0eFailoverConfig\x12+\n" +
+	"\x11handshake_timeout\x18\x01 \x01(\rR\x10handshakeTimeout\x12%\n" +
+	"\x0echeck_interval\x18\x02 \x01(\rR\rcheckInterval\x12\x1d\n" +
+	"\n" +
+	"keep_alive\x18\x03 \x01(\rR\tkeepAlive\"\x9e\x04\n" +
 	"\fDeviceConfig\x12\x1d\n" +
 	"\n" +
 	"secret_key\x18\x01 \x01(\tR\tsecretKey\x12\x1a\n" +
@@ -286,7 +363,9 @@ const file_proxy_wireguard_config_proto_rawDesc = "" +
 	"\breserved\x18\x06 \x01(\fR\breserved\x12Z\n" +
 	"\x0fdomain_strategy\x18\a \x01(\x0e21.xray.proxy.wireguard.DeviceConfig.DomainStrategyR\x0edomainStrategy\x12\x1b\n" +
 	"\tis_client\x18\b \x01(\bR\bisClient\x12\"\n" +
-	"\rno_kernel_tun\x18\t \x01(\bR\vnoKernelTun\"\\\n" +
+	"\rno_kernel_tun\x18\t \x01(\bR\vnoKernelTun\x12@\n" +
+	"\bfailover\x18\n" +
+	" \x01(\v2$.xray.proxy.wireguard.FailoverConfigR\bfailover\"\\\n" +
 	"\x0eDomainStrategy\x12\f\n" +
 	"\bFORCE_IP\x10\x00\x12\r\n" +
 	"\tFORCE_IP4\x10\x01\x12\r\n" +
@@ -310,22 +389,24 @@ func file_proxy_wireguard_config_proto_rawDescGZIP() []byte {
 }
 
 var file_proxy_wireguard_config_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_proxy_wireguard_config_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_proxy_wireguard_config_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
 var file_proxy_wireguard_config_proto_goTypes = []any{
 	(DeviceConfig_DomainStrategy)(0), // 0: xray.proxy.wireguard.DeviceConfig.DomainStrategy
 	(*PeerConfig)(nil),               // 1: xray.proxy.wireguard.PeerConfig
-	(*DeviceConfig)(nil),             // 2: xray.proxy.wireguard.DeviceConfig
-	(*protocol.User)(nil),            // 3: xray.common.protocol.User
+	(*FailoverConfig)(nil),           // 2: xray.proxy.wireguard.FailoverConfig
+	(*DeviceConfig)(nil),             // 3: xray.proxy.wireguard.DeviceConfig
+	(*protocol.User)(nil),            // 4: xray.common.protocol.User
 }
 var file_proxy_wireguard_config_proto_depIdxs = []int32{
 	1, // 0: xray.proxy.wireguard.DeviceConfig.peers:type_name -> xray.proxy.wireguard.PeerConfig
-	3, // 1: xray.proxy.wireguard.DeviceConfig.users:type_name -> xray.common.protocol.User
+	4, // 1: xray.proxy.wireguard.DeviceConfig.users:type_name -> xray.common.protocol.User
 	0, // 2: xray.proxy.wireguard.DeviceConfig.domain_strategy:type_name -> xray.proxy.wireguard.DeviceConfig.DomainStrategy
-	3, // [3:3] is the sub-list for method output_type
-	3, // [3:3] is the sub-list for method input_type
-	3, // [3:3] is the sub-list for extension type_name
-	3, // [3:3] is the sub-list for extension extendee
-	0, // [0:3] is the sub-list for field type_name
+	2, // 3: xray.proxy.wireguard.DeviceConfig.failover:type_name -> xray.proxy.wireguard.FailoverConfig
+	4, // [4:4] is the sub-list for method output_type
+	4, // [4:4] is the sub-list for method input_type
+	4, // [4:4] is the sub-list for extension type_name
+	4, // [4:4] is the sub-list for extension extendee
+	0, // [0:4] is the sub-list for field type_name
 }
 
 func init() { file_proxy_wireguard_config_proto_init() }
@@ -339,7 +420,7 @@ func file_proxy_wireguard_config_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proxy_wireguard_config_proto_rawDesc), len(file_proxy_wireguard_config_proto_rawDesc)),
 			NumEnums:      1,
-			NumMessages:   2,
+			NumMessages:   3,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
@@ -0,0 +1,213 @@
+package wireguard
+
+import (
+	"context"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/task"
+	"golang.zx2c4.com/wireguard/device"
+)
+
+const (
+	defaultHandshakeTimeout      = 180 * time.Second
+	defaultFailoverCheckInterval = 10 * time.Second
+	defaultFailoverKeepAlive     = 25
+	endpointResolveInterval      = 30 * time.Second
+)
+
+type failoverPeer struct {
+	config   *PeerConfig
+	pub      [32]byte
+	resolved time.Time
+}
+
+// peerGroup is a list of peers of the same allowed IPs, only the active one is given them
+type peerGroup struct {
+	peers      []*failoverPeer
+	allowedIPs []string
+	active     int
+}
+
+// failover monitors the handshakes of the peers of a client device. In each group, the first peer which had a handshake
+// recently is made active, and the endpoints of the peers which had none are resolved again.
+type failover struct {
+	groups    []*peerGroup
+	timeout   time.Duration
+	keepAlive string
+	started   time.Time
+
+	dev     *device.Device
+	monitor *task.Periodic
+
+	mu      sync.Mutex
+	healthy bool
+}
+
+func newFailover(config *FailoverConfig, peers []*PeerConfig) (*failover, error) {
+	f := &failover{
+		timeout:   defaultHandshakeTimeout,
+		keepAlive: strconv.Itoa(defaultFailoverKeepAlive),
+		healthy:   true,
+	}
+	if config.HandshakeTimeout > 0 {
+		f.timeout = time.Duration(config.HandshakeTimeout) * time.Second
+	}
+	if config.KeepAlive > 0 {
+		f.keepAlive = strconv.FormatUint(uint64(config.KeepAlive), 10)
+	}
+	interval := defaultFailoverCheckInterval
+	if config.CheckInterval > 0 {
+		interval = time.Duration(config.CheckInterval) * time.Second
+	}
+	f.monitor = &task.Periodic{
+		Interval: interval,
+		Execute:  f.run,
+	}
+
+	groups := make(map[string]*peerGroup)
+	for _, config := range peers {
+		pub, err := ParseKey(config.PublicKey)
+		if err != nil {
+			return nil, errors.New("invalid public key of peer ", config.Endpoint).Base(err)
+		}
+		allowedIPs := slices.Clone(config.AllowedIps)
+		slices.Sort(allowedIPs)
+		key := strings.Join(allowedIPs, ",")
+		group := groups[key]
+		if group == nil {
+			group = &peerGroup{allowedIPs: config.AllowedIps}
+			groups[key] = group
+			f.groups = append(f.groups, group)
+		}
+		group.peers = append(group.peers, &failoverPeer{config: config, pub: *pub})
+	}
+	return f, nil
+}
+
+// isActive returns whether the peer is to be given its allowed IPs
+func (f *failover) isActive(config *PeerConfig) bool {
+	for _, group := range f.groups {
+		if group.peers[group.active].config == config {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *failover) Start(dev *device.Device) error {
+	f.dev = dev
+	f.started = time.Now()
+	return f.monitor.Start()
+}
+
+func (f *failover) Close() error {
+	return f.monitor.Close()
+}
+
+// run switches the peers from their handshakes. Errors only skip a round: a task.Periodic returning
+// an error is not run again, and the failover has to outlive a device briefly failing IPC requests.
+func (f *failover) run() error {
+	ipc, err := f.dev.IpcGet()
+	if err != nil {
+		errors.LogWarningInner(context.Background(), err, "[wireguard] failed to get peer status")
+		return nil
+	}
+	statuses, err := parsePeerStatus(ipc)
+	if err != nil {
+		errors.LogWarningInner(context.Background(), err, "[wireguard] failed to get peer status")
+		return nil
+	}
+	status := make(map[[32]byte]*PeerStatus, len(statuses))
+	for _, s := range statuses {
+		status[s.PublicKey] = s
+	}
+
+	switchIpc, resolve := f.check(time.Now(), status)
+	if switchIpc != "" {
+		if err := f.dev.IpcSet(switchIpc); err != nil {
+			errors.LogWarningInner(context.Background(), err, "[wireguard] failed to switch peers")
+		}
+	}
+	for _, peer := range resolve {
+		errors.LogInfo(context.Background(), "[wireguard] resolving endpoint ", peer.Endpoint, " again")
+		if err := f.dev.IpcSet("public_key=" + peer.PublicKey + "\nendpoint=" + peer.Endpoint + "\n"); err != nil {
+			errors.LogWarningInner(context.Background(), err, "[wireguard] failed to resolve endpoint ", peer.Endpoint)
+		}
+	}
+	return nil
+}
+
+// check updates the active peers from their status, it returns the IPC operation switching them
+// and the peers whose endpoints are to be resolved again
+func (f *failover) check(now time.Time, status map[[32]byte]*PeerStatus) (string, []*PeerConfig) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var ipc strings.Builder
+	var resolve []*PeerConfig
+	f.healthy = true
+	for _, group := range f.groups {
+		next := -1
+		for i, peer := range group.peers {
+			if f.isHealthy(now, status[peer.pub]) {
+				if next == -1 {
+					next = i
+				}
+				continue
+			}
+			if hasDomainEndpoint(peer.config) && now.Sub(peer.resolved) >= endpointResolveInterval {
+				peer.resolved = now
+				resolve = append(resolve, peer.config)
+			}
+		}
+		if next == -1 {
+			// none is known to work, do not flap
+			f.healthy = false
+			continue
+		}
+		if next == group.active {
+			continue
+		}
+
+		previous := group.peers[group.active]
+		active := group.peers[next]
+		errors.LogWarning(context.Background(), "[wireguard] switching peer of ", strings.Join(group.allowedIPs, ","), " from ", previous.config.Endpoint, " to ", active.config.Endpoint)
+		ipc.WriteString("public_key=" + previous.config.PublicKey + "\nupdate_only=true\nreplace_allowed_ips=true\n")
+		ipc.WriteString("public_key=" + active.config.PublicKey + "\nupdate_only=true\nreplace_allowed_ips=true\n")
+		for _, ip := range group.allowedIPs {
+			ipc.WriteString("allowed_ip=" + ip + "\n")
+		}
+		group.active = next
+	}
+	return ipc.String(), resolve
+}
+
+func (f *failover) isHealthy(now time.Time, status *PeerStatus) bool {
+	last := f.started
+	if status != nil && status.LastHandshake.After(last) {
+		last = status.LastHandshake
+	}
+	return now.Sub(last) < f.timeout
+}
+
+// Health returns the endpoints of the active peers, and whether all of them had a handshake recently
+func (f *failover) Health() (string, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	endpoints := make([]string, 0, len(f.groups))
+	for _, group := range f.groups {
+		endpoints = append(endpoints, group.peers[group.active].config.Endpoint)
+	}
+	return strings.Join(endpoints, ","), f.healthy
+}
+
+func hasDomainEndpoint(config *PeerConfig) bool {
+	host, _, err := net.SplitHostPort(config.Endpoint)
+	return err == nil && net.ParseIP(host) == nil
+}
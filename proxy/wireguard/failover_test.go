@@ -0,0 +1,74 @@
+package wireguard
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFailover(t *testing.T) {
+	peers := []*PeerConfig{
+		{PublicKey: strings.Repeat("01", 32), Endpoint: "primary.example.com:51820", AllowedIps: []string{"0.0.0.0/0", "::/0"}},
+		{PublicKey: strings.Repeat("02", 32), Endpoint: "192.0.2.2:51820", AllowedIps: []string{"::/0", "0.0.0.0/0"}},
+		{PublicKey: strings.Repeat("03", 32), Endpoint: "192.0.2.3:51820", AllowedIps: []string{"10.0.0.0/8"}},
+	}
+	f, err := newFailover(&FailoverConfig{HandshakeTimeout: 100}, peers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(f.groups) != 2 || len(f.groups[0].peers) != 2 {
+		t.Fatal("peers of the same allowed IPs must be grouped")
+	}
+	if !f.isActive(peers[0]) || f.isActive(peers[1]) || !f.isActive(peers[2]) {
+		t.Fatal("the first peer of each group must be active")
+	}
+
+	f.started = time.Unix(1000, 0)
+	status := func(handshakes ...int64) map[[32]byte]*PeerStatus {
+		m := make(map[[32]byte]*PeerStatus)
+		for i, handshake := range handshakes {
+			pub, _ := ParseKey(peers[i].PublicKey)
+			m[*pub] = &PeerStatus{PublicKey: *pub, LastHandshake: time.Unix(handshake, 0)}
+		}
+		return m
+	}
+
+	// in the grace period nothing changes
+	if ipc, resolve := f.check(time.Unix(1050, 0), status(0, 0, 0)); ipc != "" || len(resolve) != 0 {
+		t.Fatal("unexpected switch in grace period: ", ipc)
+	}
+
+	// the primary is down, its endpoint is resolved again
+	ipc, resolve := f.check(time.Unix(1200, 0), status(0, 1150, 1150))
+	if !strings.Contains(ipc, "public_key="+peers[1].PublicKey+"\nupdate_only=true\nreplace_allowed_ips=true\nallowed_ip=0.0.0.0/0\nallowed_ip=::/0\n") {
+		t.Fatal("expected switch to the second peer, got ", ipc)
+	}
+	if len(resolve) != 1 || resolve[0] != peers[0] {
+		t.Fatal("expected the endpoint of the primary to be resolved again")
+	}
+	if upstream, alive := f.Health(); upstream != "192.0.2.2:51820,192.0.2.3:51820" || !alive {
+		t.Fatal("unexpected health: ", upstream, alive)
+	}
+
+	// resolutions are rate limited
+	if _, resolve := f.check(time.Unix(1210, 0), status(0, 1150, 1150)); len(resolve) != 0 {
+		t.Fatal("endpoint resolved again too early")
+	}
+
+	// nothing works, the active peer is kept
+	if ipc, _ := f.check(time.Unix(1400, 0), status(0, 1150, 1350)); ipc != "" {
+		t.Fatal("unexpected switch: ", ipc)
+	}
+	if _, alive := f.Health(); alive {
+		t.Fatal("expected to be down")
+	}
+
+	// the primary is back
+	ipc, _ = f.check(time.Unix(1500, 0), status(1450, 1150, 1450))
+	if !strings.HasPrefix(ipc, "public_key="+peers[1].PublicKey+"\nupdate_only=true\nreplace_allowed_ips=true\npublic_key="+peers[0].PublicKey) {
+		t.Fatal("expected switch back to the primary, got ", ipc)
+	}
+	if _, alive := f.Health(); !alive {
+		t.Fatal("expected to be alive")
+	}
+}
@@ -0,0 +1,210 @@
+package wireguard
+
+import (
+	"errors"
+	"io"
+	"net"
+	"net/netip"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/xtls/xray-core/common/protocol/icmp"
+	"gvisor.dev/gvisor/pkg/buffer"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+	"gvisor.dev/gvisor/pkg/tcpip/stack"
+)
+
+// icmpSessions tracks the Echo requests sent to the peer, one identifier per session,
+// so that replies and errors can be taken from the peer before they reach the stack.
+type icmpSessions struct {
+	sync.Mutex
+	next  uint16
+	conns map[uint16]*icmpConn
+}
+
+// DialICMPAddr opens a session sending Echo requests to raddr from the local address of its family.
+// The connection speaks the packets of common/protocol/icmp. It is not available with kernel TUN.
+func (tun *netTun) DialICMPAddr(raddr netip.Addr) (net.Conn, error) {
+	laddr := tun.localV4
+	if raddr.Is6() {
+		laddr = tun.localV6
+	}
+	if !laddr.IsValid() {
+		return nil, errors.New("no local address for " + raddr.String())
+	}
+
+	tun.icmp.Lock()
+	defer tun.icmp.Unlock()
+	if tun.icmp.conns == nil {
+		tun.icmp.conns = make(map[uint16]*icmpConn)
+	}
+	if len(tun.icmp.conns) >= 0xffff {
+		return nil, errors.New("too many ICMP sessions")
+	}
+	for {
+		tun.icmp.next++
+		if _, found := tun.icmp.conns[tun.icmp.next]; !found && tun.icmp.next != 0 {
+			break
+		}
+	}
+	c := &icmpConn{
+		tun:     tun,
+		ident:   tun.icmp.next,
+		local:   laddr,
+		remote:  raddr,
+		packets: make(chan []byte, 16),
+		done:    make(chan struct{}),
+	}
+	tun.icmp.conns[c.ident] = c
+	return c, nil
+}
+
+// deliverICMP hands a packet from the peer to its session. It returns false if the packet belongs to the stack.
+func (tun *netTun) deliverICMP(packet []byte) bool {
+	switch packet[0] >> 4 {
+	case 4:
+		if len(packet) < header.IPv4MinimumSize || header.IPv4(packet).Protocol() != uint8(header.ICMPv4ProtocolNumber) {
+			return false
+		}
+	case 6:
+		if len(packet) < header.IPv6MinimumSize || header.IPv6(packet).NextHeader() != uint8(header.ICMPv6ProtocolNumber) {
+			return false
+		}
+	default:
+		return false
+	}
+
+	tun.icmp.Lock()
+	empty := len(tun.icmp.conns) == 0
+	tun.icmp.Unlock()
+	if empty {
+		return false
+	}
+
+	// The buffer is reused by the device, the session gets its own copy.
+	p, err := icmp.ParsePacket(append([]byte(nil), packet...))
+	if err != nil {
+		return false
+	}
+	var quoted *icmp.Packet
+	var ident uint16
+	switch {
+	case p.IsEchoReply():
+		ident = p.Ident()
+	case p.IsError():
+		quoted, err = p.Quoted()
+		if err != nil || !quoted.IsEchoRequest() {
+			return false
+		}
+		ident = quoted.Ident()
+	default:
+		return false
+	}
+
+	tun.icmp.Lock()
+	c := tun.icmp.conns[ident]
+	tun.icmp.Unlock()
+	if c == nil || p.IsIPv6() != c.remote.Is6() {
+		return false
+	}
+
+	if quoted != nil {
+		quoted.SetIdent(uint16(c.origIdent.Load()))
+		p.SetQuoted(quoted)
+	} else {
+		p.SetIdent(uint16(c.origIdent.Load()))
+	}
+	select {
+	case c.packets <- p.Marshal():
+	case <-c.done:
+	default:
+		// Dropped like any packet of a full queue.
+	}
+	return true
+}
+
+type icmpConn struct {
+	tun           *netTun
+	ident         uint16
+	local, remote netip.Addr
+	origIdent     atomic.Uint32
+
+	packets   chan []byte
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func (c *icmpConn) Read(b []byte) (int, error) {
+	select {
+	case packet := <-c.packets:
+		return copy(b, packet), nil
+	case <-c.done:
+		return 0, io.EOF
+	}
+}
+
+// Write sends an Echo request to the peer, from the local address and with the identifier of the session.
+// Other messages are dropped.
+func (c *icmpConn) Write(b []byte) (int, error) {
+	select {
+	case <-c.done:
+		return 0, os.ErrClosed
+	default:
+	}
+	p, err := icmp.ParsePacket(b)
+	if err != nil {
+		return 0, err
+	}
+	if !p.IsEchoRequest() || p.IsIPv6() != c.remote.Is6() {
+		return len(b), nil
+	}
+	c.origIdent.Store(uint32(p.Ident()))
+	p = &icmp.Packet{
+		Source:      c.local.AsSlice(),
+		Destination: c.remote.AsSlice(),
+		TTL:         p.TTL,
+		Message:     append([]byte(nil), p.Message...),
+	}
+	p.SetIdent(c.ident)
+
+	pkb := stack.NewPacketBuffer(stack.PacketBufferOptions{Payload: buffer.MakeWithData(p.Marshal())})
+	defer pkb.DecRef()
+	var pkts stack.PacketBufferList
+	pkts.PushBack(pkb)
+	if _, tcpipErr := c.tun.ep.WritePackets(pkts); tcpipErr != nil {
+		return 0, errors.New(tcpipErr.String())
+	}
+	return len(b), nil
+}
+
+func (c *icmpConn) Close() error {
+	c.closeOnce.Do(func() {
+		c.tun.icmp.Lock()
+		delete(c.tun.icmp.conns, c.ident)
+		c.tun.icmp.Unlock()
+		close(c.done)
+	})
+	return nil
+}
+
+func (c *icmpConn) LocalAddr() net.Addr {
+	return &net.IPAddr{IP: c.local.AsSlice()}
+}
+
+func (c *icmpConn) RemoteAddr() net.Addr {
+	return &net.IPAddr{IP: c.remote.AsSlice()}
+}
+
+func (c *icmpConn) SetDeadline(t time.Time) error {
+	return nil
+}
+
+func (c *icmpConn) SetReadDeadline(t time.Time) error {
+	return nil
+}
+
+func (c *icmpConn) SetWriteDeadline(t time.Time) error {
+	return nil
+}
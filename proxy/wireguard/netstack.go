@@ -45,6 +45,9 @@ type netTun struct {
 	mtu            int
 	dnsServers     []netip.Addr
 	hasV4, hasV6   bool
+
+	localV4, localV6 netip.Addr
+	icmp             icmpSessions
 }
 
 func CreateNetTUN(localAddresses, dnsServers []netip.Addr, mtu int, handleLocal bool) (tun.Device, *Net, *stack.Stack, error) {
@@ -88,8 +91,14 @@ func CreateNetTUN(localAddresses, dnsServers []netip.Addr, mtu int, handleLocal
 		}
 		if ip.Is4() {
 			dev.hasV4 = true
+			if !dev.localV4.IsValid() {
+				dev.localV4 = ip
+			}
 		} else if ip.Is6() {
 			dev.hasV6 = true
+			if !dev.localV6.IsValid() {
+				dev.localV6 = ip
+			}
 		}
 	}
 	if dev.hasV4 {
@@ -102,6 +111,7 @@ func CreateNetTUN(localAddresses, dnsServers []netip.Addr, mtu int, handleLocal
 	tnet := &Net{
 		DialContextTCPAddrPort: dev.DialContextTCPAddrPort,
 		DialUDPAddrPort:        dev.DialUDPAddrPort,
+		DialICMPAddr:           dev.DialICMPAddr,
 		dnsServers:             dev.dnsServers,
 		hasV4:                  dev.hasV4,
 		hasV6:                  dev.hasV6,
@@ -143,6 +153,9 @@ func (tun *netTun) Write(buf [][]byte, offset int) (int, error) {
 		if len(packet) == 0 {
 			continue
 		}
+		if tun.deliverICMP(packet) {
+			continue
+		}
 
 		pkb := stack.NewPacketBuffer(stack.PacketBufferOptions{Payload: buffer.MakeWithData(packet)})
 		switch packet[0] >> 4 {
@@ -217,6 +230,7 @@ func (tun *netTun) DialUDPAddrPort(laddr, raddr netip.AddrPort) (net.Conn, error
 type Net struct {
 	DialContextTCPAddrPort func(ctx context.Context, addr netip.AddrPort) (net.Conn, error)
 	DialUDPAddrPort        func(laddr, raddr netip.AddrPort) (net.Conn, error)
+	DialICMPAddr           func(raddr netip.Addr) (net.Conn, error)
 	dnsServers             []netip.Addr
 	hasV4, hasV6           bool
 }
@@ -0,0 +1,131 @@
+package wireguard
+
+import (
+	"bufio"
+	"context"
+	"encoding/hex"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/protocol"
+)
+
+// PeerStatus is the runtime state of a peer of the device
+type PeerStatus struct {
+	PublicKey     [32]byte
+	User          *protocol.MemoryUser
+	Endpoint      string
+	LastHandshake time.Time // zero if there was no handshake
+	RxBytes       uint64
+	TxBytes       uint64
+	AllowedIPs    []netip.Prefix
+	KeepAlive     uint32
+	PreSharedKey  bool
+}
+
+// GetPeerStatus returns the state of all peers, as reported by the device
+func (s *Server) GetPeerStatus(ctx context.Context) ([]*PeerStatus, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.dev == nil {
+		return nil, errors.New("too early")
+	}
+	ipc, err := s.dev.IpcGet()
+	if err != nil {
+		return nil, err
+	}
+	peers, err := parsePeerStatus(ipc)
+	if err != nil {
+		return nil, err
+	}
+	for _, peer := range peers {
+		if user, found := s.users.Load(peer.PublicKey); found {
+			peer.User = user.(*protocol.MemoryUser)
+		}
+	}
+	return peers, nil
+}
+
+// RemovePeer removes the peer of the public key, which may have been added without an email
+func (s *Server) RemovePeer(ctx context.Context, pub [32]byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.dev == nil {
+		return errors.New("too early")
+	}
+	if _, found := s.users.Load(pub); !found {
+		return errors.New("peer not found")
+	}
+	if err := s.dev.IpcSet("public_key=" + hex.EncodeToString(pub[:]) + "\nremove=true\n"); err != nil {
+		return err
+	}
+	s.users.Delete(pub)
+	return nil
+}
+
+// parsePeerStatus parses the peers of a UAPI get operation
+func parsePeerStatus(ipc string) ([]*PeerStatus, error) {
+	var peers []*PeerStatus
+	var peer *PeerStatus
+	var handshakeSec, handshakeNsec int64
+	finish := func() {
+		if peer != nil && (handshakeSec != 0 || handshakeNsec != 0) {
+			peer.LastHandshake = time.Unix(handshakeSec, handshakeNsec)
+		}
+		handshakeSec, handshakeNsec = 0, 0
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(ipc))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), "=")
+		if !ok {
+			continue
+		}
+		if key == "public_key" {
+			finish()
+			pub, err := ParseKey(value)
+			if err != nil {
+				return nil, errors.New("invalid public key ", value).Base(err)
+			}
+			peer = &PeerStatus{PublicKey: *pub}
+			peers = append(peers, peer)
+			continue
+		}
+		if peer == nil {
+			// device settings
+			continue
+		}
+
+		var err error
+		switch key {
+		case "preshared_key":
+			peer.PreSharedKey = strings.Trim(value, "0") != ""
+		case "endpoint":
+			peer.Endpoint = value
+		case "last_handshake_time_sec":
+			handshakeSec, err = strconv.ParseInt(value, 10, 64)
+		case "last_handshake_time_nsec":
+			handshakeNsec, err = strconv.ParseInt(value, 10, 64)
+		case "rx_bytes":
+			peer.RxBytes, err = strconv.ParseUint(value, 10, 64)
+		case "tx_bytes":
+			peer.TxBytes, err = strconv.ParseUint(value, 10, 64)
+		case "persistent_keepalive_interval":
+			var keepAlive uint64
+			keepAlive, err = strconv.ParseUint(value, 10, 16)
+			peer.KeepAlive = uint32(keepAlive)
+		case "allowed_ip":
+			var prefix netip.Prefix
+			prefix, err = netip.ParsePrefix(value)
+			peer.AllowedIPs = append(peer.AllowedIPs, prefix)
+		}
+		if err != nil {
+			return nil, errors.New("invalid ", key, " of peer").Base(err)
+		}
+	}
+	finish()
+	return peers, scanner.Err()
+}
@@ -0,0 +1,57 @@
+package wireguard
+
+import (
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestParsePeerStatus(t *testing.T) {
+	ipc := "private_key=a8dac1d8a70a751f0f699fb14ba1cff7b79cf4fbd8f09f44c6e6a90d0369604f\n" +
+		"listen_port=51820\n" +
+		"public_key=b85996fecc9c7f1fc6d2572a76eda11d59bcd20be8e543b15ce4bd85a8e75a33\n" +
+		"preshared_key=0000000000000000000000000000000000000000000000000000000000000000\n" +
+		"protocol_version=1\n" +
+		"endpoint=192.0.2.1:51820\n" +
+		"last_handshake_time_sec=1700000000\n" +
+		"last_handshake_time_nsec=500\n" +
+		"tx_bytes=1024\n" +
+		"rx_bytes=2048\n" +
+		"persistent_keepalive_interval=25\n" +
+		"allowed_ip=10.0.0.2/32\n" +
+		"allowed_ip=fd00::2/128\n" +
+		"public_key=58402e695ba1772b1cc9309755f043251ea77fdcf10fbe63989ceb7e19321376\n" +
+		"preshared_key=188515093e952f5f22e865cef3012e72f8b5f0b598ac0309d5dacce3b70fcf52\n" +
+		"protocol_version=1\n" +
+		"last_handshake_time_sec=0\n" +
+		"last_handshake_time_nsec=0\n" +
+		"tx_bytes=0\n" +
+		"rx_bytes=0\n" +
+		"persistent_keepalive_interval=0\n" +
+		"allowed_ip=10.0.0.3/32\n" +
+		"errno=0\n"
+
+	peers, err := parsePeerStatus(ipc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(peers) != 2 {
+		t.Fatal("expected 2 peers but got ", len(peers))
+	}
+
+	peer := peers[0]
+	if peer.PublicKey[0] != 0xb8 || peer.PreSharedKey || peer.Endpoint != "192.0.2.1:51820" {
+		t.Errorf("unexpected peer: %+v", peer)
+	}
+	if !peer.LastHandshake.Equal(time.Unix(1700000000, 500)) || peer.RxBytes != 2048 || peer.TxBytes != 1024 || peer.KeepAlive != 25 {
+		t.Errorf("unexpected peer state: %+v", peer)
+	}
+	if len(peer.AllowedIPs) != 2 || peer.AllowedIPs[1] != netip.MustParsePrefix("fd00::2/128") {
+		t.Errorf("unexpected allowed ips: %v", peer.AllowedIPs)
+	}
+
+	peer = peers[1]
+	if !peer.PreSharedKey || !peer.LastHandshake.IsZero() || peer.Endpoint != "" || len(peer.AllowedIPs) != 1 {
+		t.Errorf("unexpected peer: %+v", peer)
+	}
+}
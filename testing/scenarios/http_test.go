@@ -4,7 +4,9 @@ import (
 	"bytes"
 	"context"
 	"crypto/rand"
+	"crypto/tls"
 	"io"
+	gonet "net"
 	"net/http"
 	"net/url"
 	"strings"
@@ -16,12 +18,16 @@ import (
 	"github.com/xtls/xray-core/common"
 	"github.com/xtls/xray-core/common/buf"
 	"github.com/xtls/xray-core/common/net"
+	http_proto "github.com/xtls/xray-core/common/protocol/http"
 	"github.com/xtls/xray-core/common/serial"
 	"github.com/xtls/xray-core/core"
 	"github.com/xtls/xray-core/proxy/freedom"
 	v2http "github.com/xtls/xray-core/proxy/http"
 	v2httptest "github.com/xtls/xray-core/testing/servers/http"
 	"github.com/xtls/xray-core/testing/servers/tcp"
+	"github.com/xtls/xray-core/testing/servers/udp"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
 )
 
 func TestHttpConformance(t *testing.T) {
@@ -377,3 +383,145 @@ func TestHttpBasicAuth(t *testing.T) {
 		}
 	}
 }
+
+func TestHTTP2ConnectMethod(t *testing.T) {
+	tcpServer := tcp.Server{
+		MsgProcessor: xor,
+	}
+	tcpDest, err := tcpServer.Start()
+	common.Must(err)
+	defer tcpServer.Close()
+
+	udpServer := udp.Server{
+		MsgProcessor: xor,
+	}
+	udpDest, err := udpServer.Start()
+	common.Must(err)
+	defer udpServer.Close()
+
+	serverPort := tcp.PickPort()
+	serverConfig := &core.Config{
+		Inbound: []*core.InboundHandlerConfig{
+			{
+				ReceiverSettings: serial.ToTypedMessage(&proxyman.ReceiverConfig{
+					PortList: &net.PortList{Range: []*net.PortRange{net.SinglePortRange(serverPort)}},
+					Listen:   net.NewIPOrDomain(net.LocalHostIP),
+				}),
+				ProxySettings: serial.ToTypedMessage(&v2http.ServerConfig{ExtendedConnect: true}),
+			},
+		},
+		Outbound: []*core.OutboundHandlerConfig{
+			{
+				ProxySettings: serial.ToTypedMessage(&freedom.Config{
+					FinalRules: []*freedom.FinalRuleConfig{{Action: freedom.RuleAction_Allow}},
+				}),
+			},
+		},
+	}
+
+	// the HTTP/2 of net/http, which serves it from Go 1.27, only enables extended CONNECT by GODEBUG
+	t.Setenv("GODEBUG", "http2xconnect=1")
+	servers, err := InitializeServerConfigs(serverConfig)
+	common.Must(err)
+	defer CloseAllServers(servers)
+
+	// h2c with prior knowledge
+	client := &http.Client{
+		Transport: &http2.Transport{
+			AllowHTTP: true,
+			DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (gonet.Conn, error) {
+				var d gonet.Dialer
+				return d.DialContext(ctx, network, addr)
+			},
+		},
+	}
+	proxyURL := "http://127.0.0.1:" + serverPort.String()
+
+	// Two CONNECT streams multiplexed on the same connection
+	for i := 0; i < 2; i++ {
+		payload := make([]byte, 1024*64)
+		common.Must2(rand.Read(payload))
+
+		reader, writer := io.Pipe()
+		req, err := http.NewRequest(http.MethodConnect, proxyURL, reader)
+		common.Must(err)
+		req.Host = tcpDest.NetAddr()
+
+		resp, err := client.Do(req)
+		common.Must(err)
+		if resp.StatusCode != 200 {
+			t.Fatal("status: ", resp.StatusCode)
+		}
+		go writer.Write(payload)
+
+		content := make([]byte, len(payload))
+		common.Must2(io.ReadFull(resp.Body, content))
+		if r := cmp.Diff(content, xor(payload)); r != "" {
+			t.Fatal(r)
+		}
+		writer.Close()
+		resp.Body.Close()
+	}
+
+	// CONNECT-UDP, with a raw framer as net/http rejects the :protocol pseudo-header
+	{
+		conn, err := gonet.Dial("tcp", "127.0.0.1:"+serverPort.String())
+		common.Must(err)
+		defer conn.Close()
+		common.Must2(conn.Write([]byte(http2.ClientPreface)))
+		framer := http2.NewFramer(conn, conn)
+		framer.ReadMetaHeaders = hpack.NewDecoder(4096, nil)
+		common.Must(framer.WriteSettings())
+
+		var block bytes.Buffer
+		encoder := hpack.NewEncoder(&block)
+		for _, field := range [][2]string{
+			{":method", http.MethodConnect},
+			{":protocol", http_proto.ProtocolConnectUDP},
+			{":scheme", "https"},
+			{":authority", "127.0.0.1:" + serverPort.String()},
+			{":path", http_proto.ConnectUDPPath(udpDest)},
+			{"capsule-protocol", "?1"},
+		} {
+			common.Must(encoder.WriteField(hpack.HeaderField{Name: field[0], Value: field[1]}))
+		}
+		common.Must(framer.WriteHeaders(http2.HeadersFrameParam{StreamID: 1, BlockFragment: block.Bytes(), EndHeaders: true}))
+
+		payload := make([]byte, 1024)
+		common.Must2(rand.Read(payload))
+		var response bytes.Buffer
+		expected := 1 + 2 + 1 + len(payload) // type, length, context ID and payload
+		for response.Len() < expected {
+			conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+			frame, err := framer.ReadFrame()
+			common.Must(err)
+			switch frame := frame.(type) {
+			case *http2.SettingsFrame:
+				if !frame.IsAck() {
+					common.Must(framer.WriteSettingsAck())
+				}
+			case *http2.MetaHeadersFrame:
+				if status := frame.PseudoValue("status"); status != "200" {
+					t.Fatal("status: ", status)
+				}
+				var capsule bytes.Buffer
+				common.Must(http_proto.WriteUDPDatagramCapsule(&capsule, payload))
+				common.Must(framer.WriteData(1, false, capsule.Bytes()))
+			case *http2.DataFrame:
+				response.Write(frame.Data())
+			case *http2.RSTStreamFrame, *http2.GoAwayFrame:
+				t.Fatal("unexpected frame: ", frame)
+			}
+		}
+
+		b, err := http_proto.ReadDatagramCapsule(bytes.NewReader(response.Bytes()))
+		common.Must(err)
+		if !http_proto.ReadContextID(b) {
+			t.Fatal("unexpected context ID")
+		}
+		if r := cmp.Diff(b.Bytes(), xor(payload)); r != "" {
+			t.Fatal(r)
+		}
+		b.Release()
+	}
+}
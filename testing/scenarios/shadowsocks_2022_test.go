@@ -35,19 +35,25 @@ func TestShadowsocks2022Tcp(t *testing.T) {
 func TestShadowsocks2022UdpAES128(t *testing.T) {
 	password := make([]byte, 32)
 	rand.Read(password)
-	testShadowsocks2022Udp(t, shadowaead_2022.List[0], base64.StdEncoding.EncodeToString(password))
+	testShadowsocks2022Udp(t, shadowaead_2022.List[0], base64.StdEncoding.EncodeToString(password), false)
 }
 
 func TestShadowsocks2022UdpAES256(t *testing.T) {
 	password := make([]byte, 32)
 	rand.Read(password)
-	testShadowsocks2022Udp(t, shadowaead_2022.List[1], base64.StdEncoding.EncodeToString(password))
+	testShadowsocks2022Udp(t, shadowaead_2022.List[1], base64.StdEncoding.EncodeToString(password), false)
 }
 
 func TestShadowsocks2022UdpChacha(t *testing.T) {
 	password := make([]byte, 32)
 	rand.Read(password)
-	testShadowsocks2022Udp(t, shadowaead_2022.List[2], base64.StdEncoding.EncodeToString(password))
+	testShadowsocks2022Udp(t, shadowaead_2022.List[2], base64.StdEncoding.EncodeToString(password), false)
+}
+
+func TestShadowsocks2022UoT(t *testing.T) {
+	password := make([]byte, 32)
+	rand.Read(password)
+	testShadowsocks2022Udp(t, shadowaead_2022.List[0], base64.StdEncoding.EncodeToString(password), true)
 }
 
 func testShadowsocks2022Tcp(t *testing.T, method string, password string) {
@@ -135,7 +141,7 @@ func testShadowsocks2022Tcp(t *testing.T, method string, password string) {
 	}
 }
 
-func testShadowsocks2022Udp(t *testing.T, method string, password string) {
+func testShadowsocks2022Udp(t *testing.T, method string, password string, uot bool) {
 	udpServer := udp.Server{
 		MsgProcessor: xor,
 	}
@@ -143,7 +149,13 @@ func testShadowsocks2022Udp(t *testing.T, method string, password string) {
 	common.Must(err)
 	defer udpServer.Close()
 
+	// with UDP-over-TCP the server does not need to receive UDP
+	serverNetwork := net.Network_UDP
 	serverPort := udp.PickPort()
+	if uot {
+		serverNetwork = net.Network_TCP
+		serverPort = tcp.PickPort()
+	}
 	serverConfig := &core.Config{
 		App: []*serial.TypedMessage{
 			serial.ToTypedMessage(&log.Config{
@@ -160,7 +172,7 @@ func testShadowsocks2022Udp(t *testing.T, method string, password string) {
 				ProxySettings: serial.ToTypedMessage(&shadowsocks_2022.ServerConfig{
 					Method:  method,
 					Key:     password,
-					Network: []net.Network{net.Network_UDP},
+					Network: []net.Network{serverNetwork},
 				}),
 			},
 		},
@@ -201,6 +213,7 @@ func testShadowsocks2022Udp(t *testing.T, method string, password string) {
 					Port:    uint32(serverPort),
 					Method:  method,
 					Key:     password,
+					Uot:     uot,
 				}),
 			},
 		},
@@ -1,9 +1,11 @@
 package scenarios
 
 import (
+	"io"
 	"testing"
 	"time"
 
+	"github.com/xtls/xray-core/app/policy"
 	"github.com/xtls/xray-core/app/proxyman"
 	"github.com/xtls/xray-core/app/router"
 	"github.com/xtls/xray-core/common"
@@ -485,3 +487,228 @@ func TestSocksConformanceMod(t *testing.T) {
 		}
 	}
 }
+
+func TestSocksBindAndStrictUDP(t *testing.T) {
+	serverPort := tcp.PickPort()
+	serverConfig := &core.Config{
+		App: []*serial.TypedMessage{
+			serial.ToTypedMessage(&router.Config{
+				Rule: []*router.RoutingRule{
+					{
+						TargetTag: &router.RoutingRule_Tag{
+							Tag: "blocked",
+						},
+						PortList: &net.PortList{Range: []*net.PortRange{net.SinglePortRange(9)}},
+					},
+				},
+			}),
+		},
+		Inbound: []*core.InboundHandlerConfig{
+			{
+				ReceiverSettings: serial.ToTypedMessage(&proxyman.ReceiverConfig{
+					PortList: &net.PortList{Range: []*net.PortRange{net.SinglePortRange(serverPort)}},
+					Listen:   net.NewIPOrDomain(net.LocalHostIP),
+				}),
+				ProxySettings: serial.ToTypedMessage(&socks.ServerConfig{
+					AuthType:    socks.AuthType_NO_AUTH,
+					Address:     net.NewIPOrDomain(net.LocalHostIP),
+					UdpEnabled:  true,
+					UdpStrict:   true,
+					BindEnabled: true,
+				}),
+			},
+		},
+		Outbound: []*core.OutboundHandlerConfig{
+			{
+				ProxySettings: serial.ToTypedMessage(&freedom.Config{}),
+			},
+			{
+				Tag:           "blocked",
+				ProxySettings: serial.ToTypedMessage(&blackhole.Config{}),
+			},
+		},
+	}
+
+	servers, err := InitializeServerConfigs(serverConfig)
+	common.Must(err)
+	defer CloseAllServers(servers)
+
+	request := func(cmd byte, ip []byte, port byte) (net.Conn, []byte) {
+		conn, err := net.Dial("tcp", net.TCPDestination(net.LocalHostIP, serverPort).NetAddr())
+		common.Must(err)
+		common.Must(conn.SetDeadline(time.Now().Add(5 * time.Second)))
+		common.Must2(conn.Write([]byte{0x05, 0x01, 0x00}))
+		reply := make([]byte, 10)
+		common.Must2(io.ReadFull(conn, reply[:2]))
+		common.Must2(conn.Write(append([]byte{0x05, cmd, 0x00, 0x01}, append(ip, 0x00, port)...)))
+		if _, err := io.ReadFull(conn, reply); err != nil {
+			t.Fatal(err)
+		}
+		return conn, reply
+	}
+
+	{
+		conn, reply := request(0x02, []byte{127, 0, 0, 1}, 0)
+		defer conn.Close()
+		if reply[1] != 0x00 {
+			t.Fatal("BIND rejected: ", reply[1])
+		}
+		port := int(reply[8])<<8 | int(reply[9])
+
+		peer, err := net.Dial("tcp", net.TCPDestination(net.LocalHostIP, net.Port(port)).NetAddr())
+		common.Must(err)
+		defer peer.Close()
+		common.Must(peer.SetDeadline(time.Now().Add(5 * time.Second)))
+
+		if _, err := io.ReadFull(conn, reply); err != nil {
+			t.Fatal(err)
+		}
+		if reply[1] != 0x00 || int(reply[8])<<8|int(reply[9]) != peer.LocalAddr().(*net.TCPAddr).Port {
+			t.Fatal("unexpected second BIND reply: ", reply)
+		}
+
+		common.Must2(peer.Write([]byte("ping")))
+		b := make([]byte, 4)
+		common.Must2(io.ReadFull(conn, b))
+		if string(b) != "ping" {
+			t.Error("unexpected data from peer: ", string(b))
+		}
+		common.Must2(conn.Write([]byte("pong")))
+		common.Must2(io.ReadFull(peer, b))
+		if string(b) != "pong" {
+			t.Error("unexpected data from client: ", string(b))
+		}
+	}
+
+	{
+		conn, reply := request(0x02, []byte{127, 0, 0, 1}, 9)
+		defer conn.Close()
+		if reply[1] != 0x02 {
+			t.Error("BIND routed to blackhole is not rejected: ", reply[1])
+		}
+	}
+
+	{
+		conn, reply := request(0x03, []byte{10, 0, 0, 1}, 0)
+		defer conn.Close()
+		if reply[1] != 0x02 {
+			t.Error("UDP associate for another address is not rejected: ", reply[1])
+		}
+	}
+
+	{
+		conn, reply := request(0x03, []byte{127, 0, 0, 1}, 0)
+		defer conn.Close()
+		if reply[1] != 0x00 {
+			t.Error("UDP associate rejected: ", reply[1])
+		}
+	}
+}
+
+func TestSocksStrictUDPTimeout(t *testing.T) {
+	udpServer := udp.Server{
+		MsgProcessor: xor,
+	}
+	dest, err := udpServer.Start()
+	common.Must(err)
+	defer udpServer.Close()
+
+	serverPort := tcp.PickPort()
+	serverConfig := &core.Config{
+		App: []*serial.TypedMessage{
+			serial.ToTypedMessage(&policy.Config{
+				Level: map[uint32]*policy.Policy{
+					0: {
+						Timeout: &policy.Policy_Timeout{
+							Handshake:      &policy.Second{Value: 1},
+							ConnectionIdle: &policy.Second{Value: 4},
+						},
+					},
+				},
+			}),
+		},
+		Inbound: []*core.InboundHandlerConfig{
+			{
+				ReceiverSettings: serial.ToTypedMessage(&proxyman.ReceiverConfig{
+					PortList: &net.PortList{Range: []*net.PortRange{net.SinglePortRange(serverPort)}},
+					Listen:   net.NewIPOrDomain(net.LocalHostIP),
+				}),
+				ProxySettings: serial.ToTypedMessage(&socks.ServerConfig{
+					AuthType:   socks.AuthType_NO_AUTH,
+					Address:    net.NewIPOrDomain(net.LocalHostIP),
+					UdpEnabled: true,
+					UdpStrict:  true,
+				}),
+			},
+		},
+		Outbound: []*core.OutboundHandlerConfig{
+			{
+				ProxySettings: serial.ToTypedMessage(&freedom.Config{}),
+			},
+		},
+	}
+
+	servers, err := InitializeServerConfigs(serverConfig)
+	common.Must(err)
+	defer CloseAllServers(servers)
+
+	associate := func() (net.Conn, *net.UDPConn) {
+		conn, err := net.Dial("tcp", net.TCPDestination(net.LocalHostIP, serverPort).NetAddr())
+		common.Must(err)
+		common.Must2(conn.Write([]byte{0x05, 0x01, 0x00}))
+		reply := make([]byte, 10)
+		common.Must2(io.ReadFull(conn, reply[:2]))
+		common.Must2(conn.Write([]byte{0x05, 0x03, 0x00, 0x01, 127, 0, 0, 1, 0, 0}))
+		common.Must2(io.ReadFull(conn, reply))
+		if reply[1] != 0x00 {
+			t.Fatal("UDP associate rejected: ", reply[1])
+		}
+		relay := &net.UDPAddr{IP: net.IP(reply[4:8]), Port: int(reply[8])<<8 | int(reply[9])}
+		udpConn, err := net.DialUDP("udp", nil, relay)
+		common.Must(err)
+		return conn, udpConn
+	}
+	// closed reports whether the association is ended by the server within timeout
+	closed := func(conn net.Conn, timeout time.Duration) bool {
+		common.Must(conn.SetReadDeadline(time.Now().Add(timeout)))
+		_, err := conn.Read(make([]byte, 1))
+		return err == io.EOF
+	}
+
+	{
+		// not used within the handshake timeout
+		conn, udpConn := associate()
+		defer conn.Close()
+		defer udpConn.Close()
+		if !closed(conn, 2500*time.Millisecond) {
+			t.Error("expected the unused association to be ended")
+		}
+	}
+
+	{
+		conn, udpConn := associate()
+		defer conn.Close()
+		defer udpConn.Close()
+		request := append([]byte{0x00, 0x00, 0x00, 0x01, 127, 0, 0, 1, byte(dest.Port >> 8), byte(dest.Port)}, 'a')
+		response := make([]byte, 64)
+		// used beyond the handshake timeout, but never idle for the connection idle timeout
+		for i := 0; i < 5; i++ {
+			common.Must2(udpConn.Write(request))
+			common.Must(udpConn.SetReadDeadline(time.Now().Add(time.Second)))
+			n, err := udpConn.Read(response)
+			if err != nil {
+				t.Fatal("association ended while in use: ", err)
+			}
+			if n != len(request) || response[n-1] != xor([]byte{'a'})[0] {
+				t.Error("unexpected response ", response[:n])
+			}
+			time.Sleep(500 * time.Millisecond)
+		}
+		if closed(conn, 500*time.Millisecond) {
+			t.Error("association ended while in use")
+		}
+		if !closed(conn, 10*time.Second) {
+			t.Error("expected the idle association to be ended")
+		}
+	}
+}
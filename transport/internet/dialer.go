@@ -71,6 +71,14 @@ func Dial(ctx context.Context, dest net.Destination, streamSettings *MemoryStrea
 		return udpDialer(ctx, dest, streamSettings)
 	}
 
+	if dest.Network == net.Network_ICMP {
+		var sockopt *SocketConfig
+		if streamSettings != nil {
+			sockopt = streamSettings.SocketSettings
+		}
+		return DialSystem(ctx, dest, sockopt)
+	}
+
 	return nil, errors.New("unknown network ", dest.Network)
 }
 
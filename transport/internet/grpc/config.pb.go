@@ -22,17 +22,23 @@ const (
 )
 
 type Config struct {
-	state               protoimpl.MessageState `protogen:"open.v1"`
-	Authority           string                 `protobuf:"bytes,1,opt,name=authority,proto3" json:"authority,omitempty"`
-	ServiceName         string                 `protobuf:"bytes,2,opt,name=service_name,json=serviceName,proto3" json:"service_name,omitempty"`
-	MultiMode           bool                   `protobuf:"varint,3,opt,name=multi_mode,json=multiMode,proto3" json:"multi_mode,omitempty"`
-	IdleTimeout         int32                  `protobuf:"varint,4,opt,name=idle_timeout,json=idleTimeout,proto3" json:"idle_timeout,omitempty"`
-	HealthCheckTimeout  int32                  `protobuf:"varint,5,opt,name=health_check_timeout,json=healthCheckTimeout,proto3" json:"health_check_timeout,omitempty"`
-	PermitWithoutStream bool                   `protobuf:"varint,6,opt,name=permit_without_stream,json=permitWithoutStream,proto3" json:"permit_without_stream,omitempty"`
-	InitialWindowsSize  int32                  `protobuf:"varint,7,opt,name=initial_windows_size,json=initialWindowsSize,proto3" json:"initial_windows_size,omitempty"`
-	UserAgent           string                 `protobuf:"bytes,8,opt,name=user_agent,json=userAgent,proto3" json:"user_agent,omitempty"`
-	unknownFields       protoimpl.UnknownFields
-	sizeCache           protoimpl.SizeCache
+	state                        protoimpl.MessageState `protogen:"open.v1"`
+	Authority                    string                 `protobuf:"bytes,1,opt,name=authority,proto3" json:"authority,omitempty"`
+	ServiceName                  string                 `protobuf:"bytes,2,opt,name=service_name,json=serviceName,proto3" json:"service_name,omitempty"`
+	MultiMode                    bool                   `protobuf:"varint,3,opt,name=multi_mode,json=multiMode,proto3" json:"multi_mode,omitempty"`
+	IdleTimeout                  int32                  `protobuf:"varint,4,opt,name=idle_timeout,json=idleTimeout,proto3" json:"idle_timeout,omitempty"`
+	HealthCheckTimeout           int32                  `protobuf:"varint,5,opt,name=health_check_timeout,json=healthCheckTimeout,proto3" json:"health_check_timeout,omitempty"`
+	PermitWithoutStream          bool                   `protobuf:"varint,6,opt,name=permit_without_stream,json=permitWithoutStream,proto3" json:"permit_without_stream,omitempty"`
+	InitialWindowsSize           int32                  `protobuf:"varint,7,opt,name=initial_windows_size,json=initialWindowsSize,proto3" json:"initial_windows_size,omitempty"`
+	UserAgent                    string                 `protobuf:"bytes,8,opt,name=user_agent,json=userAgent,proto3" json:"user_agent,omitempty"`
+	MaxConcurrentStreams         uint32                 `protobuf:"varint,9,opt,name=max_concurrent_streams,json=maxConcurrentStreams,proto3" json:"max_concurrent_streams,omitempty"`
+	MaxConnectionIdle            int32                  `protobuf:"varint,10,opt,name=max_connection_idle,json=maxConnectionIdle,proto3" json:"max_connection_idle,omitempty"`
+	MaxConnectionAge             int32                  `protobuf:"varint,11,opt,name=max_connection_age,json=maxConnectionAge,proto3" json:"max_connection_age,omitempty"`
+	MaxConnectionAgeGrace        int32                  `protobuf:"varint,12,opt,name=max_connection_age_grace,json=maxConnectionAgeGrace,proto3" json:"max_connection_age_grace,omitempty"`
+	KeepaliveMinTime             int32                  `protobuf:"varint,13,opt,name=keepalive_min_time,json=keepaliveMinTime,proto3" json:"keepalive_min_time,omitempty"`
+	KeepalivePermitWithoutStream bool                   `protobuf:"varint,14,opt,name=keepalive_permit_without_stream,json=keepalivePermitWithoutStream,proto3" json:"keepalive_permit_without_stream,omitempty"`
+	unknownFields                protoimpl.UnknownFields
+	sizeCache                    protoimpl.SizeCache
 }
 
 func (x *Config) Reset() {
@@ -121,11 +127,53 @@ func (x *Config) GetUserAgent() string {
 	return ""
 }
 
+func (x *Config) GetMaxConcurrentStreams() uint32 {
+	if x != nil {
+		return x.MaxConcurrentStreams
+	}
+	return 0
+}
+
+func (x *Config) GetMaxConnectionIdle() int32 {
+	if x != nil {
+		return x.MaxConnectionIdle
+	}
+	return 0
+}
+
+func (x *Config) GetMaxConnectionAge() int32 {
+	if x != nil {
+		return x.MaxConnectionAge
+	}
+	return 0
+}
+
+func (x *Config) GetMaxConnectionAgeGrace() int32 {
+	if x != nil {
+		return x.MaxConnectionAgeGrace
+	}
+	return 0
+}
+
+func (x *Config) GetKeepaliveMinTime() int32 {
+	if x != nil {
+		return x.KeepaliveMinTime
+	}
+	return 0
+}
+
+func (x *Config) GetKeepalivePermitWithoutStream() bool {
+	if x != nil {
+		return x.KeepalivePermitWithoutStream
+	}
+	return false
+}
+
 var File_transport_internet_grpc_config_proto protoreflect.FileDescriptor
 
 const file_transport_internet_grpc_config_proto_rawDesc = "" +
 	"\n" +
-	"$transport/internet/grpc/config.proto\x12%xray.transport.internet.grpc.encoding\"\xc2\x02\n" +
+	"$transport/internet/grpc/config.proto\x12%xray.transport.internet.grpc.encoding\"\x84\x05\n" +
 	"\x06Config\x12\x1c\n" +
 	"\tauthority\x18\x01 \x01(\tR\tauthority\x12!\n" +
 	"\fservice_name\x18\x02 \x01(\tR\vserviceName\x12\x1d\n" +
@@ -136,7 +184,14 @@ const file_transport_internet_grpc_config_proto_rawDesc = "" +
 	"\x15permit_without_stream\x18\x06 \x01(\bR\x13permitWithoutStream\x120\n" +
 	"\x14initial_windows_size\x18\a \x01(\x05R\x12initialWindowsSize\x12\x1d\n" +
 	"\n" +
-	"user_agent\x18\b \x01(\tR\tuserAgentB3Z1github.com/xtls/xray-core/transport/internet/grpcb\x06proto3"
+	"user_agent\x18\b \x01(\tR\tuserAgent\x124\n" +
+	"\x16max_concurrent_streams\x18\t \x01(\rR\x14maxConcurrentStreams\x12.\n" +
+	"\x13max_connection_idle\x18\n" +
+	" \x01(\x05R\x11maxConnectionIdle\x12,\n" +
+	"\x12max_connection_age\x18\v \x01(\x05R\x10maxConnectionAge\x127\n" +
+	"\x18max_connection_age_grace\x18\f \x01(\x05R\x15maxConnectionAgeGrace\x12,\n" +
+	"\x12keepalive_min_time\x18\r \x01(\x05R\x10keepaliveMinTime\x12E\n" +
+	"\x1fkeepalive_permit_without_stream\x18\x0e \x01(\bR\x1ckeepalivePermitWithoutStreamB3Z1github.com/xtls/xray-core/transport/internet/grpcb\x06proto3"
 
 var (
 	file_transport_internet_grpc_config_proto_rawDescOnce sync.Once
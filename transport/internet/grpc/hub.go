@@ -4,7 +4,6 @@ import (
 	"context"
 	"time"
 
-	goreality "github.com/xtls/reality"
 	"github.com/xtls/xray-core/common"
 	"github.com/xtls/xray-core/common/errors"
 	"github.com/xtls/xray-core/common/net"
@@ -87,12 +86,28 @@ func Listen(ctx context.Context, address net.Address, port net.Port, settings *i
 		// gRPC server may silently ignore TLS errors
 		options = append(options, grpc.Creds(credentials.NewTLS(config.GetTLSConfig(tls.WithNextProto("h2")))))
 	}
-	if grpcSettings.IdleTimeout > 0 || grpcSettings.HealthCheckTimeout > 0 {
+	if grpcSettings.IdleTimeout > 0 || grpcSettings.HealthCheckTimeout > 0 ||
+		grpcSettings.MaxConnectionIdle > 0 || grpcSettings.MaxConnectionAge > 0 {
 		options = append(options, grpc.KeepaliveParams(keepalive.ServerParameters{
-			Time:    time.Second * time.Duration(grpcSettings.IdleTimeout),
-			Timeout: time.Second * time.Duration(grpcSettings.HealthCheckTimeout),
+			Time:                  time.Second * time.Duration(grpcSettings.IdleTimeout),
+			Timeout:               time.Second * time.Duration(grpcSettings.HealthCheckTimeout),
+			MaxConnectionIdle:     time.Second * time.Duration(grpcSettings.MaxConnectionIdle),
+			MaxConnectionAge:      time.Second * time.Duration(grpcSettings.MaxConnectionAge),
+			MaxConnectionAgeGrace: time.Second * time.Duration(grpcSettings.MaxConnectionAgeGrace),
 		}))
 	}
+	if grpcSettings.KeepaliveMinTime > 0 || grpcSettings.KeepalivePermitWithoutStream {
+		options = append(options, grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             time.Second * time.Duration(grpcSettings.KeepaliveMinTime),
+			PermitWithoutStream: grpcSettings.KeepalivePermitWithoutStream,
+		}))
+	}
+	if grpcSettings.MaxConcurrentStreams > 0 {
+		options = append(options, grpc.MaxConcurrentStreams(grpcSettings.MaxConcurrentStreams))
+	}
+	if h := newStatsHandler(ctx); h != nil {
+		options = append(options, grpc.StatsHandler(h))
+	}
 
 	s = grpc.NewServer(options...)
 	listener.s = s
@@ -132,7 +147,7 @@ func Listen(ctx context.Context, address net.Address, port net.Port, settings *i
 		encoding.RegisterGRPCServiceServerX(s, listener, grpcSettings.getServiceName(), grpcSettings.getTunStreamName(), grpcSettings.getTunMultiStreamName())
 
 		if config := reality.ConfigFromStreamSettings(settings); config != nil {
-			streamListener = goreality.NewListener(streamListener, config.GetREALITYConfig())
+			streamListener = reality.NewListener(streamListener, reality.NewDispatcher(ctx, config))
 		}
 		if err = s.Serve(streamListener); err != nil {
 			errors.LogInfoInner(ctx, err, "Listener for gRPC ended")
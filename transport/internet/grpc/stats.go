@@ -0,0 +1,141 @@
+package grpc
+
+import (
+	"context"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/xtls/xray-core/common/session"
+	"github.com/xtls/xray-core/core"
+	"github.com/xtls/xray-core/features/policy"
+	"github.com/xtls/xray-core/features/stats"
+	grpcstats "google.golang.org/grpc/stats"
+)
+
+type connStatsKey struct{}
+
+// connStats holds the counters of a single gRPC connection.
+type connStats struct {
+	name     string
+	streams  stats.Counter
+	uplink   stats.Counter
+	downlink stats.Counter
+}
+
+// statsHandler reports gRPC connections, streams and wire bytes of an inbound to the stats manager.
+//
+// Counters are named "inbound>>>{tag}>>>grpc>>>connections" and "inbound>>>{tag}>>>grpc>>>streams"
+// for the whole listener, and "inbound>>>{tag}>>>grpc>>>conn>>>{id}>>>{streams|uplink|downlink}"
+// for each connection. The IDs are numbered per listener rather than named by the remote addresses,
+// which may be shared by the connections through a CDN, and the per-connection counters are
+// unregistered when the connection ends.
+type statsHandler struct {
+	manager  stats.Manager
+	prefix   string
+	uplink   bool
+	downlink bool
+
+	connections stats.Counter
+	streams     stats.Counter
+	nextID      atomic.Uint64
+}
+
+// newStatsHandler returns a handler for the inbound carried by ctx,
+// or nil if inbound traffic stats are not enabled in system policy.
+func newStatsHandler(ctx context.Context) *statsHandler {
+	inbound := session.InboundFromContext(ctx)
+	if inbound == nil || len(inbound.Tag) == 0 {
+		return nil
+	}
+	v := core.FromContext(ctx)
+	if v == nil {
+		return nil
+	}
+	pm, ok := v.GetFeature(policy.ManagerType()).(policy.Manager)
+	if !ok {
+		return nil
+	}
+	sm, ok := v.GetFeature(stats.ManagerType()).(stats.Manager)
+	if !ok {
+		return nil
+	}
+	sys := pm.ForSystem().Stats
+	if !sys.InboundUplink && !sys.InboundDownlink {
+		return nil
+	}
+
+	h := &statsHandler{
+		manager:  sm,
+		prefix:   "inbound>>>" + inbound.Tag + ">>>grpc",
+		uplink:   sys.InboundUplink,
+		downlink: sys.InboundDownlink,
+	}
+	h.connections, _ = stats.GetOrRegisterCounter(sm, h.prefix+">>>connections")
+	h.streams, _ = stats.GetOrRegisterCounter(sm, h.prefix+">>>streams")
+	if h.connections == nil || h.streams == nil {
+		return nil
+	}
+	return h
+}
+
+// TagConn implements grpcstats.Handler.
+func (h *statsHandler) TagConn(ctx context.Context, info *grpcstats.ConnTagInfo) context.Context {
+	cs := &connStats{name: h.prefix + ">>>conn>>>" + strconv.FormatUint(h.nextID.Add(1), 10)}
+	cs.streams, _ = stats.GetOrRegisterCounter(h.manager, cs.name+">>>streams")
+	if h.uplink {
+		cs.uplink, _ = stats.GetOrRegisterCounter(h.manager, cs.name+">>>uplink")
+	}
+	if h.downlink {
+		cs.downlink, _ = stats.GetOrRegisterCounter(h.manager, cs.name+">>>downlink")
+	}
+	return context.WithValue(ctx, connStatsKey{}, cs)
+}
+
+// HandleConn implements grpcstats.Handler.
+func (h *statsHandler) HandleConn(ctx context.Context, s grpcstats.ConnStats) {
+	switch s.(type) {
+	case *grpcstats.ConnBegin:
+		h.connections.Add(1)
+	case *grpcstats.ConnEnd:
+		h.connections.Add(-1)
+		if cs, ok := ctx.Value(connStatsKey{}).(*connStats); ok {
+			h.manager.UnregisterCounter(cs.name + ">>>streams")
+			if cs.uplink != nil {
+				h.manager.UnregisterCounter(cs.name + ">>>uplink")
+			}
+			if cs.downlink != nil {
+				h.manager.UnregisterCounter(cs.name + ">>>downlink")
+			}
+		}
+	}
+}
+
+// TagRPC implements grpcstats.Handler.
+func (h *statsHandler) TagRPC(ctx context.Context, _ *grpcstats.RPCTagInfo) context.Context {
+	return ctx
+}
+
+// HandleRPC implements grpcstats.Handler.
+func (h *statsHandler) HandleRPC(ctx context.Context, s grpcstats.RPCStats) {
+	cs, _ := ctx.Value(connStatsKey{}).(*connStats)
+	switch s := s.(type) {
+	case *grpcstats.Begin:
+		h.streams.Add(1)
+		if cs != nil && cs.streams != nil {
+			cs.streams.Add(1)
+		}
+	case *grpcstats.End:
+		h.streams.Add(-1)
+		if cs != nil && cs.streams != nil {
+			cs.streams.Add(-1)
+		}
+	case *grpcstats.InPayload:
+		if cs != nil && cs.uplink != nil {
+			cs.uplink.Add(int64(s.WireLength))
+		}
+	case *grpcstats.OutPayload:
+		if cs != nil && cs.downlink != nil {
+			cs.downlink.Add(int64(s.WireLength))
+		}
+	}
+}
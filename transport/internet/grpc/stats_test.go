@@ -0,0 +1,76 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/xtls/xray-core/app/stats"
+	"github.com/xtls/xray-core/common"
+	grpcstats "google.golang.org/grpc/stats"
+)
+
+func TestStatsHandler(t *testing.T) {
+	m, err := stats.NewManager(context.Background(), &stats.Config{})
+	common.Must(err)
+	h := &statsHandler{
+		manager:  m,
+		prefix:   "inbound>>>test>>>grpc",
+		uplink:   true,
+		downlink: true,
+	}
+	h.connections, _ = m.RegisterCounter(h.prefix + ">>>connections")
+	h.streams, _ = m.RegisterCounter(h.prefix + ">>>streams")
+
+	ctx := h.TagConn(context.Background(), &grpcstats.ConnTagInfo{
+		RemoteAddr: &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 1234},
+	})
+	h.HandleConn(ctx, &grpcstats.ConnBegin{})
+	h.HandleRPC(ctx, &grpcstats.Begin{})
+	h.HandleRPC(ctx, &grpcstats.Begin{})
+	h.HandleRPC(ctx, &grpcstats.InPayload{WireLength: 100})
+	h.HandleRPC(ctx, &grpcstats.OutPayload{WireLength: 200})
+	h.HandleRPC(ctx, &grpcstats.End{})
+
+	conn := h.prefix + ">>>conn>>>1"
+	for name, expected := range map[string]int64{
+		h.prefix + ">>>connections": 1,
+		h.prefix + ">>>streams":     1,
+		conn + ">>>streams":         1,
+		conn + ">>>uplink":          100,
+		conn + ">>>downlink":        200,
+	} {
+		c := m.GetCounter(name)
+		if c == nil {
+			t.Fatal("counter not registered: ", name)
+		}
+		if c.Value() != expected {
+			t.Error(name, " = ", c.Value(), ", expected ", expected)
+		}
+	}
+
+	// another connection from the same address, as through a CDN, has its own counters
+	other := h.TagConn(context.Background(), &grpcstats.ConnTagInfo{
+		RemoteAddr: &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 1234},
+	})
+	h.HandleConn(other, &grpcstats.ConnBegin{})
+	h.HandleRPC(other, &grpcstats.InPayload{WireLength: 10})
+	if c := m.GetCounter(h.prefix + ">>>conn>>>2>>>uplink"); c == nil || c.Value() != 10 {
+		t.Error("unexpected counter of the other connection ", c)
+	}
+
+	h.HandleConn(ctx, &grpcstats.ConnEnd{})
+	if v := h.connections.Value(); v != 1 {
+		t.Error("connections = ", v)
+	}
+	if m.GetCounter(conn+">>>uplink") != nil {
+		t.Error("per-connection counters are not unregistered")
+	}
+	if m.GetCounter(h.prefix+">>>conn>>>2>>>uplink") == nil {
+		t.Error("unexpected unregistered counters of the other connection")
+	}
+	h.HandleConn(other, &grpcstats.ConnEnd{})
+	if v := h.connections.Value(); v != 0 {
+		t.Error("connections = ", v)
+	}
+}
@@ -0,0 +1,144 @@
+package brutal
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/apernet/quic-go/congestion"
+	"github.com/apernet/quic-go/monotime"
+)
+
+const (
+	adaptiveInitialBps    = 1 << 20 // 1 MiB/s, the rate probing starts from
+	adaptiveMinBps        = 65536
+	adaptiveInterval      = time.Second
+	adaptiveMinSamples    = 20
+	adaptiveProbeGain     = 2
+	adaptiveIncreaseGain  = 1.05
+	adaptiveDecreaseGain  = 0.85
+	adaptiveLossHigh      = 0.1
+	adaptiveLossLow       = 0.02
+	adaptiveRTTInflation  = 1.5
+	adaptiveAppLimitRatio = 0.5
+)
+
+var _ congestion.CongestionControlEx = &AdaptiveBrutalSender{}
+
+// AdaptiveBrutalSender is a BrutalSender whose target rate follows the link.
+//
+// It starts by probing: the rate doubles every interval until loss or RTT
+// inflation shows up, and the delivered rate of that interval becomes the
+// estimate. Afterwards the rate grows slowly while the link stays clean and
+// backs off when loss or queueing delay exceed their thresholds. The rate
+// never exceeds maxBps if it is positive.
+type AdaptiveBrutalSender struct {
+	*BrutalSender
+
+	maxBps       congestion.ByteCount
+	target       atomic.Uint64
+	onTargetRate func(bps uint64) // or nil
+	probing      bool
+
+	intervalStart monotime.Time
+	ackedBytes    congestion.ByteCount
+	ackedCount    uint64
+	lostCount     uint64
+}
+
+func NewAdaptiveBrutalSender(maxBps uint64) *AdaptiveBrutalSender {
+	initial := uint64(adaptiveInitialBps)
+	if maxBps > 0 && initial > maxBps {
+		initial = maxBps
+	}
+	s := &AdaptiveBrutalSender{
+		BrutalSender: NewBrutalSender(initial),
+		maxBps:       congestion.ByteCount(maxBps),
+		probing:      true,
+	}
+	s.target.Store(initial)
+	return s
+}
+
+// TargetRate returns the current target rate in bytes per second. It is safe for concurrent use.
+func (s *AdaptiveBrutalSender) TargetRate() uint64 {
+	return s.target.Load()
+}
+
+// OnTargetRate calls f with the target rate in bytes per second now and whenever it changes. It must be called
+// before the sender is used.
+func (s *AdaptiveBrutalSender) OnTargetRate(f func(bps uint64)) {
+	s.onTargetRate = f
+	f(s.target.Load())
+}
+
+func (s *AdaptiveBrutalSender) OnCongestionEventEx(priorInFlight congestion.ByteCount, eventTime monotime.Time, ackedPackets []congestion.AckedPacketInfo, lostPackets []congestion.LostPacketInfo) {
+	s.BrutalSender.OnCongestionEventEx(priorInFlight, eventTime, ackedPackets, lostPackets)
+
+	for _, p := range ackedPackets {
+		s.ackedBytes += p.BytesAcked
+	}
+	s.ackedCount += uint64(len(ackedPackets))
+	s.lostCount += uint64(len(lostPackets))
+
+	if s.intervalStart == 0 {
+		s.intervalStart = eventTime
+		return
+	}
+	elapsed := eventTime.Sub(s.intervalStart)
+	if elapsed < adaptiveInterval {
+		return
+	}
+	s.adjust(elapsed)
+	s.intervalStart = eventTime
+	s.ackedBytes = 0
+	s.ackedCount = 0
+	s.lostCount = 0
+}
+
+func (s *AdaptiveBrutalSender) adjust(elapsed time.Duration) {
+	total := s.ackedCount + s.lostCount
+	if total < adaptiveMinSamples {
+		// idle or nearly idle, nothing to learn from
+		return
+	}
+	loss := float64(s.lostCount) / float64(total)
+	delivered := congestion.ByteCount(float64(s.ackedBytes) / elapsed.Seconds())
+	appLimited := float64(delivered) < float64(s.bps)*adaptiveAppLimitRatio
+
+	inflated := false
+	if s.rttStats != nil {
+		if minRTT := s.rttStats.MinRTT(); minRTT > 0 {
+			inflated = float64(s.rttStats.SmoothedRTT()) > float64(minRTT)*adaptiveRTTInflation
+		}
+	}
+
+	bps := s.bps
+	switch {
+	case s.probing && (loss > adaptiveLossLow || inflated):
+		s.probing = false
+		bps = max(delivered, bps/adaptiveProbeGain)
+	case s.probing:
+		if !appLimited {
+			bps *= adaptiveProbeGain
+		}
+	case loss > adaptiveLossHigh || inflated:
+		bps = congestion.ByteCount(float64(bps) * adaptiveDecreaseGain)
+	case loss < adaptiveLossLow && !appLimited:
+		bps = congestion.ByteCount(float64(bps) * adaptiveIncreaseGain)
+	}
+	if s.maxBps > 0 && bps >= s.maxBps {
+		bps = s.maxBps
+		s.probing = false
+	}
+	bps = max(bps, adaptiveMinBps)
+
+	if bps != s.bps && s.debug {
+		s.debugPrint("Target rate: %d -> %d (delivered=%d, loss=%.2f, rtt=%d, minRtt=%d, probing=%t)",
+			s.bps, bps, delivered, loss, s.rttStats.SmoothedRTT().Milliseconds(), s.rttStats.MinRTT().Milliseconds(), s.probing)
+	}
+	if bps != s.bps && s.onTargetRate != nil {
+		s.onTargetRate(uint64(bps))
+	}
+	s.bps = bps
+	s.target.Store(uint64(bps))
+}
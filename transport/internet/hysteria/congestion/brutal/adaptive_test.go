@@ -0,0 +1,93 @@
+package brutal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/apernet/quic-go/congestion"
+	"github.com/apernet/quic-go/monotime"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeRTTStats struct {
+	congestion.RTTStatsProvider
+	minRTT      time.Duration
+	smoothedRTT time.Duration
+}
+
+func (r *fakeRTTStats) MinRTT() time.Duration      { return r.minRTT }
+func (r *fakeRTTStats) SmoothedRTT() time.Duration { return r.smoothedRTT }
+
+// runInterval feeds one second of traffic at the sender's current target rate.
+func runInterval(s *AdaptiveBrutalSender, now monotime.Time, lossRate float64) monotime.Time {
+	const packetSize = 1000
+	packets := int(s.bps / packetSize)
+	lost := int(float64(packets) * lossRate)
+	acked := make([]congestion.AckedPacketInfo, packets-lost)
+	for i := range acked {
+		acked[i].BytesAcked = packetSize
+	}
+	s.OnCongestionEventEx(0, now, acked, make([]congestion.LostPacketInfo, lost))
+	return now.Add(adaptiveInterval)
+}
+
+func TestAdaptiveBrutalProbeAndBackOff(t *testing.T) {
+	rtt := &fakeRTTStats{minRTT: 50 * time.Millisecond, smoothedRTT: 50 * time.Millisecond}
+	s := NewAdaptiveBrutalSender(0)
+	s.SetRTTStatsProvider(rtt)
+
+	now := monotime.Time(time.Second)
+	now = runInterval(s, now, 0)
+	for i := 0; i < 3; i++ {
+		now = runInterval(s, now, 0)
+	}
+	require.True(t, s.probing)
+	require.Equal(t, uint64(adaptiveInitialBps*8), s.TargetRate())
+
+	// loss ends probing at the delivered rate
+	now = runInterval(s, now, 0.2)
+	require.False(t, s.probing)
+	probed := s.TargetRate()
+	require.Less(t, probed, uint64(adaptiveInitialBps*8))
+
+	// clean link grows slowly
+	now = runInterval(s, now, 0)
+	require.Greater(t, s.TargetRate(), probed)
+
+	// queueing delay backs off
+	rtt.smoothedRTT = 200 * time.Millisecond
+	grown := s.TargetRate()
+	runInterval(s, now, 0)
+	require.Less(t, s.TargetRate(), grown)
+}
+
+func TestAdaptiveBrutalMaxRate(t *testing.T) {
+	rtt := &fakeRTTStats{minRTT: 50 * time.Millisecond, smoothedRTT: 50 * time.Millisecond}
+	s := NewAdaptiveBrutalSender(3 << 20)
+	s.SetRTTStatsProvider(rtt)
+
+	now := monotime.Time(time.Second)
+	for i := 0; i < 10; i++ {
+		now = runInterval(s, now, 0)
+	}
+	require.Equal(t, uint64(3<<20), s.TargetRate())
+	require.False(t, s.probing)
+}
+
+func TestAdaptiveBrutalOnTargetRate(t *testing.T) {
+	s := NewAdaptiveBrutalSender(0)
+	s.SetRTTStatsProvider(&fakeRTTStats{minRTT: 50 * time.Millisecond, smoothedRTT: 50 * time.Millisecond})
+
+	var rates []uint64
+	s.OnTargetRate(func(bps uint64) {
+		rates = append(rates, bps)
+	})
+	require.Equal(t, []uint64{s.TargetRate()}, rates)
+
+	now := monotime.Time(time.Second)
+	for i := 0; i < 4; i++ {
+		now = runInterval(s, now, 0)
+	}
+	require.Greater(t, len(rates), 1)
+	require.Equal(t, s.TargetRate(), rates[len(rates)-1])
+}
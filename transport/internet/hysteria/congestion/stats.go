@@ -0,0 +1,71 @@
+package congestion
+
+import (
+	"context"
+
+	"github.com/apernet/quic-go"
+	"github.com/xtls/xray-core/common/session"
+	"github.com/xtls/xray-core/core"
+	"github.com/xtls/xray-core/features/policy"
+	"github.com/xtls/xray-core/features/stats"
+)
+
+// TargetRateStats reports the target rates of the adaptive Brutal connections of an inbound or an outbound.
+//
+// The counter of a connection is named "{inbound|outbound}>>>{tag}>>>brutal>>>conn>>>{local}->{remote}>>>target_rate",
+// and it is unregistered when the connection ends.
+type TargetRateStats struct {
+	manager stats.Manager
+	prefix  string
+}
+
+// NewTargetRateStats returns the TargetRateStats of the outbound in ctx, or of the inbound if there is no
+// outbound, as in the contexts of listeners. It returns nil if the traffic stats of the handler are not enabled.
+func NewTargetRateStats(ctx context.Context) *TargetRateStats {
+	v := core.FromContext(ctx)
+	if v == nil {
+		return nil
+	}
+	pm, ok := v.GetFeature(policy.ManagerType()).(policy.Manager)
+	if !ok {
+		return nil
+	}
+	sm, ok := v.GetFeature(stats.ManagerType()).(stats.Manager)
+	if !ok {
+		return nil
+	}
+	sys := pm.ForSystem().Stats
+	var prefix string
+	if outbounds := session.OutboundsFromContext(ctx); len(outbounds) > 0 {
+		if tag := outbounds[len(outbounds)-1].Tag; len(tag) > 0 && (sys.OutboundUplink || sys.OutboundDownlink) {
+			prefix = "outbound>>>" + tag
+		}
+	} else if inbound := session.InboundFromContext(ctx); inbound != nil {
+		if len(inbound.Tag) > 0 && (sys.InboundUplink || sys.InboundDownlink) {
+			prefix = "inbound>>>" + inbound.Tag
+		}
+	}
+	if len(prefix) == 0 {
+		return nil
+	}
+	return &TargetRateStats{
+		manager: sm,
+		prefix:  prefix + ">>>brutal>>>conn>>>",
+	}
+}
+
+// counter registers the counter of conn, which is unregistered when conn ends.
+func (s *TargetRateStats) counter(conn *quic.Conn) stats.Counter {
+	if s == nil {
+		return nil
+	}
+	name := s.prefix + conn.LocalAddr().String() + "->" + conn.RemoteAddr().String() + ">>>target_rate"
+	c, err := stats.GetOrRegisterCounter(s.manager, name)
+	if err != nil {
+		return nil
+	}
+	context.AfterFunc(conn.Context(), func() {
+		s.manager.UnregisterCounter(name)
+	})
+	return c
+}
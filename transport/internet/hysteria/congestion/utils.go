@@ -45,6 +45,18 @@ func UseBrutal(conn *quic.Conn, tx uint64) {
 	conn.SetCongestionControl(brutal.NewBrutalSender(tx))
 }
 
+// UseAdaptiveBrutal uses Brutal with a target rate adjusted to the observed link, capped at maxTx if positive.
+// The target rate is reported to rates if it is not nil.
+func UseAdaptiveBrutal(conn *quic.Conn, maxTx uint64, rates *TargetRateStats) {
+	sender := brutal.NewAdaptiveBrutalSender(maxTx)
+	if counter := rates.counter(conn); counter != nil {
+		sender.OnTargetRate(func(bps uint64) {
+			counter.Set(int64(bps))
+		})
+	}
+	conn.SetCongestionControl(sender)
+}
+
 func UseConfigured(conn *quic.Conn, congestionType, bbrProfile string) {
 	switch congestionType {
 	case TypeReno:
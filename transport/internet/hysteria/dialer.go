@@ -232,6 +232,12 @@ func (c *client) dial(ctx context.Context) error {
 		}
 	case "force-brutal":
 		congestion.UseBrutal(conn, quicParams.BrutalUp)
+	case "adaptive-brutal":
+		if quicParams.BrutalUp == 0 || down == 0 {
+			congestion.UseAdaptiveBrutal(conn, max(quicParams.BrutalUp, down), congestion.NewTargetRateStats(ctx))
+		} else {
+			congestion.UseAdaptiveBrutal(conn, min(quicParams.BrutalUp, down), congestion.NewTargetRateStats(ctx))
+		}
 	default:
 		panic(quicParams.Congestion)
 	}
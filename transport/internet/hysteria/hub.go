@@ -23,6 +23,7 @@ import (
 	"github.com/xtls/xray-core/transport/internet"
 	"github.com/xtls/xray-core/transport/internet/hysteria/congestion"
 	"github.com/xtls/xray-core/transport/internet/hysteria/congestion/bbr"
+	"github.com/xtls/xray-core/transport/internet/hysteria/udphop"
 	"github.com/xtls/xray-core/transport/internet/tls"
 )
 
@@ -34,6 +35,7 @@ type httpHandler struct {
 	masqHandler http.Handler
 	quicParams  *internet.QuicParams
 	addConn     internet.ConnHandler
+	targetRates *congestion.TargetRateStats
 	conn        *quic.Conn
 
 	auth bool
@@ -82,6 +84,12 @@ func (h *httpHandler) AuthHTTP(w http.ResponseWriter, r *http.Request) bool {
 				}
 			case "force-brutal":
 				congestion.UseBrutal(conn, quicParams.BrutalUp)
+			case "adaptive-brutal":
+				if quicParams.BrutalUp == 0 || down == 0 {
+					congestion.UseAdaptiveBrutal(conn, max(quicParams.BrutalUp, down), h.targetRates)
+				} else {
+					congestion.UseAdaptiveBrutal(conn, min(quicParams.BrutalUp, down), h.targetRates)
+				}
 			default:
 				panic(quicParams.Congestion)
 			}
@@ -146,6 +154,7 @@ type Listener struct {
 	masqHandler http.Handler
 	quicParams  *internet.QuicParams
 	addConn     internet.ConnHandler
+	targetRates *congestion.TargetRateStats
 
 	pktConn  net.PacketConn
 	tr       *quic.Transport
@@ -159,6 +168,7 @@ func (l *Listener) handleClient(conn *quic.Conn) {
 		masqHandler: l.masqHandler,
 		quicParams:  l.quicParams,
 		addConn:     l.addConn,
+		targetRates: l.targetRates,
 		conn:        conn,
 	}
 	h3s := http3.Server{
@@ -298,6 +308,16 @@ func Listen(ctx context.Context, address net.Address, port net.Port, streamSetti
 		return nil, err
 	}
 
+	if ports := quicParams.HopListenPorts; len(ports) > 0 {
+		pktConn, err = udphop.ListenUDPHop(pktConn, ports, func(addr *net.UDPAddr) (net.PacketConn, error) {
+			return internet.ListenSystemPacket(context.Background(), addr, streamSettings.SocketSettings)
+		})
+		if err != nil {
+			return nil, errors.New("failed to listen on hop ports").Base(err)
+		}
+		errors.LogInfo(ctx, "accepting hops on ", len(ports), " ports")
+	}
+
 	if streamSettings.UdpmaskManager != nil {
 		newConn, err := streamSettings.UdpmaskManager.WrapPacketConnServer(pktConn)
 		if err != nil {
@@ -322,6 +342,7 @@ func Listen(ctx context.Context, address net.Address, port net.Port, streamSetti
 		masqHandler: masqHandler,
 		quicParams:  quicParams,
 		addConn:     handler,
+		targetRates: congestion.NewTargetRateStats(ctx),
 
 		pktConn:  pktConn,
 		tr:       tr,
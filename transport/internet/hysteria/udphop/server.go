@@ -0,0 +1,238 @@
+package udphop
+
+import (
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/xtls/xray-core/common/errors"
+)
+
+const (
+	peerIdleTimeout = 5 * time.Minute
+
+	// maxListenPorts is the most hop ports ListenUDPHop listens on, as each of them takes a socket.
+	maxListenPorts = 1024
+)
+
+type hopPeer struct {
+	conn     net.PacketConn
+	lastSeen time.Time
+}
+
+// UdpHopServerConn merges the sockets of all hop ports into a single net.PacketConn,
+// so one QUIC transport serves clients hopping between them without any firewall
+// redirection. Packets to a client leave through the socket it was last seen on.
+type UdpHopServerConn struct {
+	conns []net.PacketConn
+
+	peersMutex sync.Mutex
+	peers      map[string]*hopPeer
+
+	deadlineMutex   sync.Mutex
+	readDeadline    time.Time
+	deadlineChanged chan struct{}
+
+	recvQueue chan *udpPacket
+	closeChan chan struct{}
+	closeOnce sync.Once
+
+	bufPool sync.Pool
+}
+
+func NewUDPHopServerConn(conns []net.PacketConn) net.PacketConn {
+	if len(conns) == 0 {
+		panic("len(conns) == 0")
+	}
+	s := &UdpHopServerConn{
+		conns:           conns,
+		peers:           make(map[string]*hopPeer),
+		deadlineChanged: make(chan struct{}),
+		recvQueue:       make(chan *udpPacket, packetQueueSize),
+		closeChan:       make(chan struct{}),
+		bufPool: sync.Pool{
+			New: func() interface{} {
+				return make([]byte, udpBufferSize)
+			},
+		},
+	}
+	for _, conn := range conns {
+		go s.recvLoop(conn)
+	}
+	go s.cleanLoop()
+	return s
+}
+
+func (s *UdpHopServerConn) recvLoop(conn net.PacketConn) {
+	for {
+		buf := s.bufPool.Get().([]byte)
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			s.bufPool.Put(buf)
+			if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+				continue
+			}
+			return
+		}
+
+		key := addr.String()
+		s.peersMutex.Lock()
+		if p, found := s.peers[key]; found {
+			p.conn = conn
+			p.lastSeen = time.Now()
+		} else {
+			s.peers[key] = &hopPeer{conn: conn, lastSeen: time.Now()}
+		}
+		s.peersMutex.Unlock()
+
+		select {
+		case s.recvQueue <- &udpPacket{buf, n, addr, nil}:
+		default:
+			s.bufPool.Put(buf)
+		}
+	}
+}
+
+func (s *UdpHopServerConn) cleanLoop() {
+	ticker := time.NewTicker(peerIdleTimeout)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			now := time.Now()
+			s.peersMutex.Lock()
+			for key, p := range s.peers {
+				if now.Sub(p.lastSeen) > peerIdleTimeout {
+					delete(s.peers, key)
+				}
+			}
+			s.peersMutex.Unlock()
+		case <-s.closeChan:
+			return
+		}
+	}
+}
+
+func (s *UdpHopServerConn) ReadFrom(b []byte) (n int, addr net.Addr, err error) {
+	for {
+		s.deadlineMutex.Lock()
+		deadline := s.readDeadline
+		changed := s.deadlineChanged
+		s.deadlineMutex.Unlock()
+
+		var timer *time.Timer
+		var timeout <-chan time.Time
+		if !deadline.IsZero() {
+			d := time.Until(deadline)
+			if d <= 0 {
+				return 0, nil, os.ErrDeadlineExceeded
+			}
+			timer = time.NewTimer(d)
+			timeout = timer.C
+		}
+
+		select {
+		case p := <-s.recvQueue:
+			if timer != nil {
+				timer.Stop()
+			}
+			n := copy(b, p.Buf[:p.N])
+			s.bufPool.Put(p.Buf)
+			return n, p.Addr, nil
+		case <-timeout:
+			return 0, nil, os.ErrDeadlineExceeded
+		case <-changed:
+			if timer != nil {
+				timer.Stop()
+			}
+		case <-s.closeChan:
+			if timer != nil {
+				timer.Stop()
+			}
+			return 0, nil, net.ErrClosed
+		}
+	}
+}
+
+func (s *UdpHopServerConn) WriteTo(b []byte, addr net.Addr) (n int, err error) {
+	conn := s.conns[0]
+	s.peersMutex.Lock()
+	if p, found := s.peers[addr.String()]; found {
+		conn = p.conn
+	}
+	s.peersMutex.Unlock()
+	return conn.WriteTo(b, addr)
+}
+
+func (s *UdpHopServerConn) Close() error {
+	var err error
+	s.closeOnce.Do(func() {
+		close(s.closeChan)
+		for _, conn := range s.conns {
+			if e := conn.Close(); e != nil && err == nil {
+				err = e
+			}
+		}
+	})
+	return err
+}
+
+func (s *UdpHopServerConn) LocalAddr() net.Addr {
+	return s.conns[0].LocalAddr()
+}
+
+func (s *UdpHopServerConn) SetDeadline(t time.Time) error {
+	if err := s.SetReadDeadline(t); err != nil {
+		return err
+	}
+	return s.SetWriteDeadline(t)
+}
+
+func (s *UdpHopServerConn) SetReadDeadline(t time.Time) error {
+	s.deadlineMutex.Lock()
+	s.readDeadline = t
+	close(s.deadlineChanged)
+	s.deadlineChanged = make(chan struct{})
+	s.deadlineMutex.Unlock()
+	return nil
+}
+
+func (s *UdpHopServerConn) SetWriteDeadline(t time.Time) error {
+	for _, conn := range s.conns {
+		_ = conn.SetWriteDeadline(t)
+	}
+	return nil
+}
+
+// ListenUDPHop additionally listens on every hop port at the address of mainConn,
+// and merges all sockets into one UdpHopServerConn. The port of mainConn is skipped if listed.
+//
+// A UDP socket is bound to a single port, so receiving a whole range on one socket takes a
+// firewall redirect (nftables/iptables REDIRECT or TPROXY) or an eBPF sk_lookup program, both
+// needing CAP_NET_ADMIN and state outside of the process. Instead there is a socket per port,
+// demultiplexed here, so QUIC still sees one PacketConn and runs one transport. Servers which
+// prefer a single socket can redirect the range themselves and leave hopListenPorts unset, which
+// is required for more than maxListenPorts ports.
+func ListenUDPHop(mainConn net.PacketConn, ports []uint32, listenUDPFunc func(addr *net.UDPAddr) (net.PacketConn, error)) (net.PacketConn, error) {
+	if len(ports) > maxListenPorts {
+		mainConn.Close()
+		return nil, errors.New("can't listen on ", len(ports), " hop ports, more than ", maxListenPorts, ", redirect them to the listening port with the firewall instead")
+	}
+	mainAddr := mainConn.LocalAddr().(*net.UDPAddr)
+	conns := []net.PacketConn{mainConn}
+	for _, port := range ports {
+		if int(port) == mainAddr.Port {
+			continue
+		}
+		conn, err := listenUDPFunc(&net.UDPAddr{IP: mainAddr.IP, Port: int(port), Zone: mainAddr.Zone})
+		if err != nil {
+			for _, c := range conns {
+				_ = c.Close()
+			}
+			return nil, err
+		}
+		conns = append(conns, conn)
+	}
+	return NewUDPHopServerConn(conns), nil
+}
@@ -0,0 +1,93 @@
+package udphop
+
+import (
+	"net"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestUDPHopServerConn(t *testing.T) {
+	listen := func(addr *net.UDPAddr) (net.PacketConn, error) {
+		return net.ListenUDP("udp", addr)
+	}
+	mainConn, err := listen(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hopConn, err := listen(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hopPort := hopConn.LocalAddr().(*net.UDPAddr).Port
+	hopConn.Close()
+
+	server, err := ListenUDPHop(mainConn, []uint32{uint32(mainConn.LocalAddr().(*net.UDPAddr).Port), uint32(hopPort)}, listen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	client, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	for _, port := range []int{mainConn.LocalAddr().(*net.UDPAddr).Port, hopPort} {
+		if _, err := client.WriteTo([]byte("ping"), &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: port}); err != nil {
+			t.Fatal(err)
+		}
+		b := make([]byte, 16)
+		server.SetReadDeadline(time.Now().Add(5 * time.Second))
+		n, addr, err := server.ReadFrom(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b[:n]) != "ping" {
+			t.Fatal("unexpected payload: ", string(b[:n]))
+		}
+
+		if _, err := server.WriteTo([]byte("pong"), addr); err != nil {
+			t.Fatal(err)
+		}
+		client.SetReadDeadline(time.Now().Add(5 * time.Second))
+		n, from, err := client.ReadFrom(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b[:n]) != "pong" {
+			t.Fatal("unexpected payload: ", string(b[:n]))
+		}
+		if from.(*net.UDPAddr).Port != port {
+			t.Error("reply from port ", from.(*net.UDPAddr).Port, ", expected ", port)
+		}
+	}
+
+	server.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+	if _, _, err := server.ReadFrom(make([]byte, 16)); err == nil {
+		t.Error("expected deadline error")
+	}
+}
+
+func TestListenUDPHopTooManyPorts(t *testing.T) {
+	mainConn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mainConn.Close()
+	ports := make([]uint32, 0, 65535)
+	for port := uint32(1); port <= 65535; port++ {
+		ports = append(ports, port)
+	}
+	listened := 0
+	if _, err := ListenUDPHop(mainConn, ports, func(addr *net.UDPAddr) (net.PacketConn, error) {
+		listened++
+		return nil, os.ErrPermission
+	}); err == nil {
+		t.Error("listened on the whole port range")
+	}
+	if listened > 0 {
+		t.Error("listened on ", listened, " ports")
+	}
+}
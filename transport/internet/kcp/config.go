@@ -25,6 +25,11 @@ func (c *Config) GetReceivingInFlightSize() uint32 {
 	return size
 }
 
+// FECEnabled returns true if Reed-Solomon FEC is configured.
+func (c *Config) FECEnabled() bool {
+	return c.DataShards > 0 && c.ParityShards > 0
+}
+
 func init() {
 	common.Must(internet.RegisterProtocolConfigCreator(ProtocolName, func() interface{} {
 		return &Config{
@@ -173,6 +173,8 @@ type ConnMetadata struct {
 	LocalAddr    net.Addr
 	RemoteAddr   net.Addr
 	Conversation uint16
+	// FECRequested is set on the server enabling FEC when the client requests it, see FECOutput.
+	FECRequested bool
 }
 
 // Connection is a KCP connection over UDP.
@@ -197,7 +199,9 @@ type Connection struct {
 	receivingWorker *ReceivingWorker
 	sendingWorker   *SendingWorker
 
-	output SegmentWriter
+	output    SegmentWriter
+	fecOutput *FECOutput // or nil
+	fecReader *FECReader
 
 	dataUpdater *Updater
 	pingUpdater *Updater
@@ -222,6 +226,16 @@ func NewConnection(meta ConnMetadata, writer io.Writer, closer io.Closer, config
 		},
 	}
 
+	if config.FECEnabled() {
+		if output, err := NewFECOutput(conn.output, meta.Conversation, config.DataShards, config.ParityShards, meta.FECRequested); err != nil {
+			errors.LogWarningInner(context.Background(), err, "#", meta.Conversation, " FEC disabled")
+		} else {
+			conn.fecOutput = output
+			conn.output = output
+			conn.mss -= FECSegmentOverhead
+		}
+	}
+
 	conn.receivingWorker = NewReceivingWorker(conn)
 	conn.sendingWorker = NewSendingWorker(conn)
 
@@ -539,6 +553,9 @@ func (c *Connection) Terminate() {
 	c.dataOutput.Signal()
 
 	c.closer.Close()
+	if c.fecOutput != nil {
+		c.fecOutput.Close()
+	}
 	c.sendingWorker.Release()
 	c.receivingWorker.Release()
 }
@@ -567,6 +584,9 @@ func (c *Connection) Input(segments []Segment) {
 		if seg.Conversation() != c.meta.Conversation {
 			break
 		}
+		if c.fecOutput != nil {
+			c.fecOutput.Input(seg)
+		}
 
 		switch seg := seg.(type) {
 		case *DataSegment:
@@ -593,7 +613,7 @@ func (c *Connection) Input(segments []Segment) {
 					c.SetState(StateTerminated)
 				}
 			}
-			if seg.Option == SegmentOptionClose || seg.Command() == CommandTerminate {
+			if seg.Option&SegmentOptionClose != 0 || seg.Command() == CommandTerminate {
 				c.dataInput.Signal()
 				c.dataOutput.Signal()
 			}
@@ -601,6 +621,19 @@ func (c *Connection) Input(segments []Segment) {
 			c.receivingWorker.ProcessSendingNext(seg.SendingNext)
 			c.roundTrip.UpdatePeerRTO(seg.PeerRTO, current)
 			seg.Release()
+		case *FECSegment:
+			if c.fecOutput == nil {
+				seg.Release()
+				break
+			}
+			if c.fecReader == nil {
+				c.fecReader = NewFECReader(c.Config.DataShards, c.Config.ParityShards)
+			}
+			recovered := c.fecReader.Read(seg)
+			seg.Release()
+			if len(recovered) > 0 {
+				c.Input(recovered)
+			}
 		default:
 		}
 	}
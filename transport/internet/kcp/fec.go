@@ -0,0 +1,395 @@
+package kcp
+
+import (
+	"context"
+	"encoding/binary"
+	"sync"
+	"time"
+
+	"github.com/klauspost/reedsolomon"
+	"github.com/xtls/xray-core/common/errors"
+)
+
+// fecGroupWindow is the number of recent groups kept by FECReader for recovery.
+const fecGroupWindow = 32
+
+// fecFlushTimeout is how long FECWriter waits for a group to fill up before sending its parity shards.
+// It is shorter than the minimum RTO, so lost segments are recovered before they are retransmitted.
+const fecFlushTimeout = 20 * time.Millisecond
+
+// FECWriter sends every segment as a data shard right away, and appends the
+// parity shards of a group once DataShards segments have been written, or
+// after fecFlushTimeout if they aren't, as with interactive traffic.
+type FECWriter struct {
+	sync.Mutex
+	conv         uint16
+	writer       SegmentWriter
+	codec        reedsolomon.Encoder
+	dataShards   int
+	parityShards int
+	timer        *time.Timer
+
+	group   uint32
+	shards  [][]byte
+	count   int
+	maxSize int
+}
+
+func NewFECWriter(writer SegmentWriter, conv uint16, dataShards uint32, parityShards uint32) (*FECWriter, error) {
+	codec, err := reedsolomon.New(int(dataShards), int(parityShards))
+	if err != nil {
+		return nil, errors.New("invalid FEC shards").Base(err)
+	}
+	w := &FECWriter{
+		conv:         conv,
+		writer:       writer,
+		codec:        codec,
+		dataShards:   int(dataShards),
+		parityShards: int(parityShards),
+		shards:       make([][]byte, dataShards+parityShards),
+	}
+	w.timer = time.AfterFunc(fecFlushTimeout, w.flush)
+	w.timer.Stop()
+	return w, nil
+}
+
+func (w *FECWriter) Write(seg Segment) error {
+	w.Lock()
+	defer w.Unlock()
+
+	size := int(seg.ByteSize()) + 2
+	shard := w.shards[w.count]
+	if cap(shard) < size {
+		shard = make([]byte, size)
+	}
+	shard = shard[:size]
+	binary.BigEndian.PutUint16(shard, uint16(size-2))
+	seg.Serialize(shard[2:])
+	w.shards[w.count] = shard
+	if size > w.maxSize {
+		w.maxSize = size
+	}
+
+	err := w.writeShard(w.count, shard, 0)
+	w.count++
+	if w.count == 1 && w.dataShards > 1 {
+		w.timer.Reset(fecFlushTimeout)
+	}
+	if w.count < w.dataShards {
+		return err
+	}
+	w.timer.Stop()
+	if pErr := w.writeParity(); pErr != nil && err == nil {
+		err = pErr
+	}
+	return err
+}
+
+// flush sends the parity shards of the group being written, if any.
+func (w *FECWriter) flush() {
+	w.Lock()
+	defer w.Unlock()
+	if w.count > 0 {
+		w.writeParity()
+	}
+}
+
+// writeParity sends the parity shards of the count data shards of the group, and starts the next group.
+// The missing data shards of a group which isn't full are encoded as empty.
+func (w *FECWriter) writeParity() error {
+	for i, shard := range w.shards {
+		if cap(shard) < w.maxSize {
+			w.shards[i] = make([]byte, w.maxSize)
+			if i < w.count {
+				copy(w.shards[i], shard)
+			}
+			continue
+		}
+		l := len(shard)
+		if i >= w.count {
+			l = 0
+		}
+		w.shards[i] = shard[:w.maxSize]
+		clear(w.shards[i][l:])
+	}
+	var err error
+	if encErr := w.codec.Encode(w.shards); encErr == nil {
+		for i := w.dataShards; i < len(w.shards); i++ {
+			if wErr := w.writeShard(i, w.shards[i], w.count); wErr != nil && err == nil {
+				err = wErr
+			}
+		}
+	}
+
+	w.group++
+	w.count = 0
+	w.maxSize = 0
+	return err
+}
+
+// Close stops sending the parity shards of partial groups.
+func (w *FECWriter) Close() error {
+	w.timer.Stop()
+	return nil
+}
+
+func (w *FECWriter) writeShard(index int, shard []byte, groupSize int) error {
+	seg := NewFECSegment()
+	seg.Conv = w.conv
+	seg.Group = w.group
+	seg.Index = uint8(index)
+	seg.DataShards = uint8(w.dataShards)
+	seg.ParityShards = uint8(w.parityShards)
+	seg.GroupSize = uint8(groupSize)
+	seg.Data().Write(shard)
+	err := w.writer.Write(seg)
+	seg.Release()
+	return err
+}
+
+// FECOutput negotiates FEC with the peer, and writes the segments as FEC shards once it is agreed.
+//
+// The client requests FEC by setting SegmentOptionFEC on its segments. A server enabling FEC sets it on its
+// segments too, as the acknowledgement, and the client starts sending FECSegments. The server follows the shard
+// counts of the first one it receives, if they don't exceed its own. Older servers ignore the option, so the
+// client sees segments without it and goes on without FEC, instead of sending segments they can't read.
+//
+// The option is never set on the segments closing the connection, as older peers only take them as closing if
+// SegmentOptionClose is their only option.
+type FECOutput struct {
+	sync.Mutex
+	writer       SegmentWriter
+	conv         uint16
+	server       bool
+	dataShards   uint32 // the most the client may choose on the server
+	parityShards uint32
+	option       SegmentOption // set on the segments until FEC is agreed or declined
+	fec          *FECWriter    // or nil
+}
+
+// NewFECOutput creates the FECOutput of a client requesting FEC with the shard counts, or of a server
+// accepting the request of a client with at most the shard counts.
+func NewFECOutput(writer SegmentWriter, conv uint16, dataShards uint32, parityShards uint32, server bool) (*FECOutput, error) {
+	if _, err := reedsolomon.New(int(dataShards), int(parityShards)); err != nil {
+		return nil, errors.New("invalid FEC shards").Base(err)
+	}
+	return &FECOutput{
+		writer:       writer,
+		conv:         conv,
+		server:       server,
+		dataShards:   dataShards,
+		parityShards: parityShards,
+		option:       SegmentOptionFEC,
+	}, nil
+}
+
+// Write implements SegmentWriter.
+func (o *FECOutput) Write(seg Segment) error {
+	o.Lock()
+	fec, option := o.fec, o.option
+	o.Unlock()
+	if fec != nil {
+		return fec.Write(seg)
+	}
+	if segmentOption(seg)&SegmentOptionClose == 0 {
+		switch seg := seg.(type) {
+		case *DataSegment:
+			seg.Option |= option
+		case *AckSegment:
+			seg.Option |= option
+		case *CmdOnlySegment:
+			seg.Option |= option
+		}
+	}
+	return o.writer.Write(seg)
+}
+
+// Input goes on with the negotiation with a segment received from the peer.
+func (o *FECOutput) Input(seg Segment) {
+	o.Lock()
+	defer o.Unlock()
+	if o.fec != nil || o.option == 0 {
+		return
+	}
+	if fec, ok := seg.(*FECSegment); ok {
+		if !o.server {
+			o.start(o.dataShards, o.parityShards)
+		} else if uint32(fec.DataShards) <= o.dataShards && uint32(fec.ParityShards) <= o.parityShards {
+			o.start(uint32(fec.DataShards), uint32(fec.ParityShards))
+		} else {
+			o.option = 0
+			errors.LogWarning(context.Background(), "#", o.conv, " rejected FEC with ", fec.DataShards, " data and ", fec.ParityShards,
+				" parity shards, more than the ", o.dataShards, " and ", o.parityShards, " configured")
+		}
+		return
+	}
+	option := segmentOption(seg)
+	if o.server || option&SegmentOptionClose != 0 {
+		return
+	}
+	if option&SegmentOptionFEC != 0 {
+		o.start(o.dataShards, o.parityShards)
+	} else {
+		o.option = 0
+		errors.LogWarning(context.Background(), "#", o.conv, " the server doesn't support FEC, continuing without it")
+	}
+}
+
+func (o *FECOutput) start(dataShards uint32, parityShards uint32) {
+	fec, err := NewFECWriter(o.writer, o.conv, dataShards, parityShards)
+	if err != nil {
+		o.option = 0
+		errors.LogWarningInner(context.Background(), err, "#", o.conv, " FEC disabled")
+		return
+	}
+	o.fec = fec
+	errors.LogDebug(context.Background(), "#", o.conv, " FEC enabled with ", dataShards, " data and ", parityShards, " parity shards")
+}
+
+// Close implements common.Closable.
+func (o *FECOutput) Close() error {
+	o.Lock()
+	defer o.Unlock()
+	if o.fec != nil {
+		return o.fec.Close()
+	}
+	return nil
+}
+
+type fecGroup struct {
+	shards   [][]byte
+	received int
+	done     bool
+}
+
+// FECReader unwraps FECSegments and recovers lost data shards from parity.
+// Shard counts are taken from the incoming segments, up to the configured ones.
+type FECReader struct {
+	codec           reedsolomon.Encoder
+	dataShards      uint8
+	parityShards    uint8
+	maxDataShards   uint32
+	maxParityShards uint32
+
+	groups map[uint32]*fecGroup
+	latest uint32
+}
+
+// NewFECReader creates a FECReader of segments with at most the shard counts.
+func NewFECReader(maxDataShards uint32, maxParityShards uint32) *FECReader {
+	return &FECReader{
+		maxDataShards:   maxDataShards,
+		maxParityShards: maxParityShards,
+		groups:          make(map[uint32]*fecGroup),
+	}
+}
+
+// Read returns the segments carried by seg, plus any segments recovered with its help.
+func (r *FECReader) Read(seg *FECSegment) []Segment {
+	if uint32(seg.DataShards) > r.maxDataShards || uint32(seg.ParityShards) > r.maxParityShards {
+		return nil
+	}
+	if seg.DataShards != r.dataShards || seg.ParityShards != r.parityShards || r.codec == nil {
+		codec, err := reedsolomon.New(int(seg.DataShards), int(seg.ParityShards))
+		if err != nil {
+			return nil
+		}
+		r.codec = codec
+		r.dataShards = seg.DataShards
+		r.parityShards = seg.ParityShards
+		clear(r.groups)
+	}
+
+	var result []Segment
+	if !seg.IsParity() {
+		result = readShard(seg.Data().Bytes())
+	}
+
+	if int32(seg.Group-r.latest) > 0 {
+		r.latest = seg.Group
+		for id := range r.groups {
+			if int32(r.latest-id) >= fecGroupWindow {
+				delete(r.groups, id)
+			}
+		}
+	} else if int32(r.latest-seg.Group) >= fecGroupWindow {
+		return result
+	}
+
+	group, found := r.groups[seg.Group]
+	if !found {
+		group = &fecGroup{
+			shards: make([][]byte, int(r.dataShards)+int(r.parityShards)),
+		}
+		r.groups[seg.Group] = group
+	}
+	if group.done || group.shards[seg.Index] != nil {
+		return result
+	}
+	group.shards[seg.Index] = append([]byte(nil), seg.Data().Bytes()...)
+	group.received++
+	if seg.IsParity() {
+		// the data shards missing from a partial group are empty
+		for i := int(seg.GroupSize); i < int(r.dataShards); i++ {
+			if group.shards[i] == nil {
+				group.shards[i] = []byte{}
+				group.received++
+			}
+		}
+	}
+	if group.received < int(r.dataShards) {
+		return result
+	}
+
+	group.done = true
+	var missing []int
+	maxSize := 0
+	for i, shard := range group.shards {
+		if i < int(r.dataShards) && shard == nil {
+			missing = append(missing, i)
+		}
+		if len(shard) > maxSize {
+			maxSize = len(shard)
+		}
+	}
+	if len(missing) > 0 {
+		for i, shard := range group.shards {
+			if shard != nil && len(shard) < maxSize {
+				group.shards[i] = append(shard, make([]byte, maxSize-len(shard))...)
+			}
+		}
+		if err := r.codec.ReconstructData(group.shards); err == nil {
+			for _, i := range missing {
+				result = append(result, readShard(group.shards[i])...)
+			}
+		}
+	}
+	group.shards = nil
+	return result
+}
+
+func readShard(b []byte) []Segment {
+	if len(b) < 2 {
+		return nil
+	}
+	size := int(binary.BigEndian.Uint16(b))
+	if size > len(b)-2 {
+		return nil
+	}
+	b = b[2 : 2+size]
+
+	var result []Segment
+	for len(b) > 0 {
+		seg, x := ReadSegment(b)
+		if seg == nil {
+			break
+		}
+		if fec, ok := seg.(*FECSegment); ok {
+			fec.Release()
+			break
+		}
+		result = append(result, seg)
+		b = x
+	}
+	return result
+}
@@ -0,0 +1,312 @@
+package kcp_test
+
+import (
+	"crypto/rand"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/xtls/xray-core/common"
+	. "github.com/xtls/xray-core/transport/internet/kcp"
+	"google.golang.org/protobuf/proto"
+)
+
+type segmentCollector struct {
+	sync.Mutex
+	segments []*FECSegment
+}
+
+func (c *segmentCollector) Write(seg Segment) error {
+	c.Lock()
+	defer c.Unlock()
+	b := make([]byte, seg.ByteSize())
+	seg.Serialize(b)
+	s, _ := ReadSegment(b)
+	c.segments = append(c.segments, s.(*FECSegment))
+	return nil
+}
+
+func TestFECRecovery(t *testing.T) {
+	collector := &segmentCollector{}
+	writer, err := NewFECWriter(collector, 1, 4, 2)
+	common.Must(err)
+
+	for i := 0; i < 8; i++ {
+		seg := NewDataSegment()
+		seg.Conv = 1
+		seg.Number = uint32(i)
+		seg.Data().Write(make([]byte, 10+i*50))
+		common.Must(writer.Write(seg))
+		seg.Release()
+	}
+	if len(collector.segments) != 12 {
+		t.Fatal("unexpected number of shards: ", len(collector.segments))
+	}
+
+	reader := NewFECReader(4, 2)
+	received := make(map[uint32]bool)
+	for i, seg := range collector.segments {
+		// lose two data shards of the first group and one of the second
+		if i == 1 || i == 3 || i == 8 {
+			continue
+		}
+		for _, s := range reader.Read(seg) {
+			data := s.(*DataSegment)
+			if data.Data().Len() != int32(10+data.Number*50) {
+				t.Error("unexpected payload size of #", data.Number, ": ", data.Data().Len())
+			}
+			received[data.Number] = true
+		}
+	}
+	for i := uint32(0); i < 8; i++ {
+		if !received[i] {
+			t.Error("segment #", i, " is not recovered")
+		}
+	}
+}
+
+func TestFECPartialGroup(t *testing.T) {
+	collector := &segmentCollector{}
+	writer, err := NewFECWriter(collector, 1, 4, 2)
+	common.Must(err)
+	defer writer.Close()
+
+	for i := 0; i < 2; i++ {
+		seg := NewDataSegment()
+		seg.Conv = 1
+		seg.Number = uint32(i)
+		seg.Data().Write(make([]byte, 10+i*50))
+		common.Must(writer.Write(seg))
+		seg.Release()
+	}
+	time.Sleep(100 * time.Millisecond)
+	collector.Lock()
+	segments := collector.segments
+	collector.Unlock()
+	if len(segments) != 4 {
+		t.Fatal("expected the parity shards of the partial group, got ", len(segments), " shards")
+	}
+	if segments[2].GroupSize != 2 || segments[3].GroupSize != 2 {
+		t.Error("unexpected group size: ", segments[2].GroupSize)
+	}
+
+	// lose the first data shard
+	reader := NewFECReader(4, 2)
+	var recovered []Segment
+	for _, seg := range segments[1:] {
+		recovered = append(recovered, reader.Read(seg)...)
+	}
+	found := false
+	for _, s := range recovered {
+		found = found || s.(*DataSegment).Number == 0
+	}
+	if !found {
+		t.Error("segment #0 is not recovered")
+	}
+}
+
+// lossyConn delivers packets to a peer Connection in-process, dropping every n-th packet.
+type lossyConn struct {
+	sync.Mutex
+	reader  KCPPacketReader
+	packets chan []byte
+	n       int
+	counter int
+	fec     int
+}
+
+func newLossyConn(n int) *lossyConn {
+	return &lossyConn{
+		packets: make(chan []byte, 1024),
+		n:       n,
+	}
+}
+
+func (c *lossyConn) Write(b []byte) (int, error) {
+	c.Lock()
+	defer c.Unlock()
+	c.counter++
+	if len(b) > 2 && Command(b[2]) == CommandFEC {
+		c.fec++
+	}
+	if c.counter%c.n != 0 {
+		select {
+		case c.packets <- append([]byte(nil), b...):
+		default:
+		}
+	}
+	return len(b), nil
+}
+
+func (*lossyConn) Close() error {
+	return nil
+}
+
+func (c *lossyConn) deliver(peer *Connection, done <-chan struct{}) {
+	for {
+		select {
+		case b := <-c.packets:
+			if segments := c.reader.Read(b); len(segments) > 0 {
+				peer.Input(segments)
+			}
+		case <-done:
+			return
+		}
+	}
+}
+
+func TestFECLossyConnection(t *testing.T) {
+	config := &Config{
+		Mtu:              1350,
+		Tti:              20,
+		UplinkCapacity:   5,
+		DownlinkCapacity: 20,
+		CwndMultiplier:   1,
+		MaxSendingWindow: 2 * 1024 * 1024,
+		DataShards:       10,
+		ParityShards:     3,
+	}
+	toServer := newLossyConn(10)
+	toClient := newLossyConn(10)
+	client := NewConnection(ConnMetadata{Conversation: 1}, toServer, toServer, config)
+	server := NewConnection(ConnMetadata{Conversation: 1, FECRequested: true}, toClient, toClient, config)
+	done := make(chan struct{})
+	defer close(done)
+	go toServer.deliver(server, done)
+	go toClient.deliver(client, done)
+
+	payload := make([]byte, 256*1024)
+	rand.Read(payload)
+	go client.Write(payload)
+
+	server.SetReadDeadline(time.Now().Add(20 * time.Second))
+	received := make([]byte, len(payload))
+	common.Must2(io.ReadFull(server, received))
+	if r := cmp.Diff(received, payload); r != "" {
+		t.Error(r)
+	}
+	toServer.Lock()
+	if toServer.fec == 0 {
+		t.Error("the client doesn't use FEC")
+	}
+	toServer.Unlock()
+
+	client.Terminate()
+	server.Terminate()
+}
+
+func TestFECWithoutServerSupport(t *testing.T) {
+	config := &Config{
+		Mtu:              1350,
+		Tti:              20,
+		UplinkCapacity:   5,
+		DownlinkCapacity: 20,
+		CwndMultiplier:   1,
+		MaxSendingWindow: 2 * 1024 * 1024,
+		DataShards:       10,
+		ParityShards:     3,
+	}
+	serverConfig := proto.Clone(config).(*Config)
+	serverConfig.DataShards = 0
+	serverConfig.ParityShards = 0
+	// the server ignores the request of the client, as those not supporting FEC
+	toServer := newLossyConn(1 << 30)
+	toClient := newLossyConn(1 << 30)
+	client := NewConnection(ConnMetadata{Conversation: 1}, toServer, toServer, config)
+	server := NewConnection(ConnMetadata{Conversation: 1}, toClient, toClient, serverConfig)
+	done := make(chan struct{})
+	defer close(done)
+	go toServer.deliver(server, done)
+	go toClient.deliver(client, done)
+
+	payload := make([]byte, 64*1024)
+	rand.Read(payload)
+	go client.Write(payload)
+
+	server.SetReadDeadline(time.Now().Add(10 * time.Second))
+	received := make([]byte, len(payload))
+	common.Must2(io.ReadFull(server, received))
+	if r := cmp.Diff(received, payload); r != "" {
+		t.Error(r)
+	}
+	toServer.Lock()
+	if toServer.fec != 0 {
+		t.Error("the client sent ", toServer.fec, " FEC segments to a server without FEC")
+	}
+	toServer.Unlock()
+
+	client.Terminate()
+	server.Terminate()
+}
+
+type lastSegment struct {
+	segment Segment
+}
+
+func (w *lastSegment) Write(seg Segment) error {
+	w.segment = seg
+	return nil
+}
+
+func TestFECOutputClose(t *testing.T) {
+	w := &lastSegment{}
+	output, err := NewFECOutput(w, 1, 4, 2, false)
+	common.Must(err)
+
+	seg := NewDataSegment()
+	seg.Conv = 1
+	seg.Data().Write([]byte{1})
+	defer seg.Release()
+	common.Must(output.Write(seg))
+	if seg.Option != SegmentOptionFEC {
+		t.Error("FEC is not requested: ", seg.Option)
+	}
+	closing := NewCmdOnlySegment()
+	closing.Conv = 1
+	closing.Option = SegmentOptionClose
+	common.Must(output.Write(closing))
+	if closing.Option != SegmentOptionClose {
+		t.Error("unexpected option of a closing segment: ", closing.Option)
+	}
+}
+
+func TestFECOutputServerLimits(t *testing.T) {
+	for _, tc := range []struct {
+		dataShards   uint8
+		parityShards uint8
+		fec          bool
+	}{
+		{4, 2, true},
+		{3, 1, true},
+		{10, 2, false},
+		{4, 3, false},
+	} {
+		w := &lastSegment{}
+		output, err := NewFECOutput(w, 1, 4, 2, true)
+		common.Must(err)
+		output.Input(&FECSegment{Conv: 1, DataShards: tc.dataShards, ParityShards: tc.parityShards})
+
+		seg := NewDataSegment()
+		seg.Conv = 1
+		seg.Data().Write([]byte{1})
+		common.Must(output.Write(seg))
+		seg.Release()
+		if _, fec := w.segment.(*FECSegment); fec != tc.fec {
+			t.Error("FEC with ", tc.dataShards, " data and ", tc.parityShards, " parity shards: ", fec)
+		}
+		output.Close()
+	}
+
+	reader := NewFECReader(4, 2)
+	seg := NewFECSegment()
+	seg.Conv = 1
+	seg.DataShards = 10
+	seg.ParityShards = 2
+	seg.Data().Write([]byte{0, 0})
+	if segments := reader.Read(seg); len(segments) != 0 {
+		t.Error("read a segment with too many shards")
+	}
+	seg.Release()
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/xtls/xray-core/transport/internet/stat"
 	"github.com/xtls/xray-core/transport/internet/tls"
 	"github.com/xtls/xray-core/transport/internet/udp"
+	"google.golang.org/protobuf/proto"
 )
 
 type ConnectionID struct {
@@ -105,11 +106,19 @@ func (l *Listener) OnReceive(payload *buf.Buffer, src net.Destination) {
 			Port: int(src.Port),
 		}
 		localAddr := l.hub.Addr()
+		config := l.config
+		fecRequested := config.FECEnabled() && segmentOption(segments[0])&SegmentOptionFEC != 0
+		if config.FECEnabled() && !fecRequested {
+			config = proto.Clone(l.config).(*Config)
+			config.DataShards = 0
+			config.ParityShards = 0
+		}
 		conn = NewConnection(ConnMetadata{
 			LocalAddr:    localAddr,
 			RemoteAddr:   remoteAddr,
 			Conversation: conv,
-		}, writer, writer, l.config)
+			FECRequested: fecRequested,
+		}, writer, writer, config)
 		var netConn stat.Connection = conn
 		if l.tlsConfig != nil {
 			netConn = tls.Server(conn, l.tlsConfig)
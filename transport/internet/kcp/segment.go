@@ -18,12 +18,16 @@ const (
 	CommandTerminate Command = 2
 	// CommandPing indicates a ping.
 	CommandPing Command = 3
+	// CommandFEC indicates a FECSegment.
+	CommandFEC Command = 4
 )
 
 type SegmentOption byte
 
 const (
 	SegmentOptionClose SegmentOption = 1
+	// SegmentOptionFEC requests FEC from the server, or acknowledges the request, see FECOutput.
+	SegmentOptionFEC SegmentOption = 2
 )
 
 type Segment interface {
@@ -35,6 +39,21 @@ type Segment interface {
 	parse(conv uint16, cmd Command, opt SegmentOption, buf []byte) (bool, []byte)
 }
 
+// segmentOption returns the option of seg.
+func segmentOption(seg Segment) SegmentOption {
+	switch seg := seg.(type) {
+	case *DataSegment:
+		return seg.Option
+	case *AckSegment:
+		return seg.Option
+	case *CmdOnlySegment:
+		return seg.Option
+	case *FECSegment:
+		return seg.Option
+	}
+	return 0
+}
+
 const (
 	DataSegmentOverhead = 18
 )
@@ -283,6 +302,102 @@ func (s *CmdOnlySegment) Serialize(b []byte) {
 
 func (*CmdOnlySegment) Release() {}
 
+const (
+	// FECSegmentOverhead is the size of a FECSegment header plus the length prefix of a data shard.
+	FECSegmentOverhead = 14 + 2
+)
+
+// FECSegment carries one Reed-Solomon shard. Data shards contain a serialized segment
+// prefixed by its length, parity shards are computed over the data shards of the same group.
+type FECSegment struct {
+	Conv         uint16
+	Option       SegmentOption
+	Group        uint32
+	Index        uint8
+	DataShards   uint8
+	ParityShards uint8
+	// GroupSize is the number of data shards in the group of a parity shard, less than DataShards if the
+	// group was flushed before it filled up. It is 0 in data shards.
+	GroupSize uint8
+
+	payload *buf.Buffer
+}
+
+func NewFECSegment() *FECSegment {
+	return new(FECSegment)
+}
+
+func (s *FECSegment) parse(conv uint16, cmd Command, opt SegmentOption, buf []byte) (bool, []byte) {
+	s.Conv = conv
+	s.Option = opt
+	if len(buf) < 10 {
+		return false, nil
+	}
+
+	s.Group = binary.BigEndian.Uint32(buf)
+	buf = buf[4:]
+
+	s.Index = buf[0]
+	s.DataShards = buf[1]
+	s.ParityShards = buf[2]
+	s.GroupSize = buf[3]
+	buf = buf[4:]
+
+	dataLen := int(binary.BigEndian.Uint16(buf))
+	buf = buf[2:]
+
+	if len(buf) < dataLen || s.DataShards == 0 || s.ParityShards == 0 || s.Index >= s.DataShards+s.ParityShards || s.GroupSize > s.DataShards {
+		return false, nil
+	}
+	s.Data().Clear()
+	s.Data().Write(buf[:dataLen])
+	buf = buf[dataLen:]
+
+	return true, buf
+}
+
+func (s *FECSegment) Conversation() uint16 {
+	return s.Conv
+}
+
+func (*FECSegment) Command() Command {
+	return CommandFEC
+}
+
+// IsParity returns true if the segment carries a parity shard.
+func (s *FECSegment) IsParity() bool {
+	return s.Index >= s.DataShards
+}
+
+func (s *FECSegment) Data() *buf.Buffer {
+	if s.payload == nil {
+		s.payload = buf.New()
+	}
+	return s.payload
+}
+
+func (s *FECSegment) Serialize(b []byte) {
+	binary.BigEndian.PutUint16(b, s.Conv)
+	b[2] = byte(CommandFEC)
+	b[3] = byte(s.Option)
+	binary.BigEndian.PutUint32(b[4:], s.Group)
+	b[8] = s.Index
+	b[9] = s.DataShards
+	b[10] = s.ParityShards
+	b[11] = s.GroupSize
+	binary.BigEndian.PutUint16(b[12:], uint16(s.payload.Len()))
+	copy(b[14:], s.payload.Bytes())
+}
+
+func (s *FECSegment) ByteSize() int32 {
+	return 2 + 1 + 1 + 4 + 1 + 1 + 1 + 1 + 2 + s.payload.Len()
+}
+
+func (s *FECSegment) Release() {
+	s.payload.Release()
+	s.payload = nil
+}
+
 func ReadSegment(buf []byte) (Segment, []byte) {
 	if len(buf) < 4 {
 		return nil, nil
@@ -301,6 +416,8 @@ func ReadSegment(buf []byte) (Segment, []byte) {
 		seg = NewDataSegment()
 	case CommandACK:
 		seg = NewAckSegment(128)
+	case CommandFEC:
+		seg = NewFECSegment()
 	default:
 		seg = NewCmdOnlySegment()
 	}
@@ -105,3 +105,28 @@ func TestCmdSegment(t *testing.T) {
 		t.Error(r)
 	}
 }
+
+func TestFECSegment(t *testing.T) {
+	seg := &FECSegment{
+		Conv:         1,
+		Group:        2,
+		Index:        3,
+		DataShards:   4,
+		ParityShards: 2,
+		GroupSize:    3,
+	}
+	seg.Data().Write([]byte{'a', 'b', 'c', 'd'})
+
+	nBytes := seg.ByteSize()
+	bytes := make([]byte, nBytes)
+	seg.Serialize(bytes)
+
+	iseg, _ := ReadSegment(bytes)
+	seg2 := iseg.(*FECSegment)
+	if r := cmp.Diff(seg2, seg, cmpopts.IgnoreUnexported(FECSegment{})); r != "" {
+		t.Error(r)
+	}
+	if r := cmp.Diff(seg2.Data().Bytes(), seg.Data().Bytes()); r != "" {
+		t.Error(r)
+	}
+}
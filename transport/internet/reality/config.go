@@ -14,13 +14,17 @@ import (
 )
 
 func (c *Config) GetREALITYConfig() *reality.Config {
+	return c.newREALITYConfig(c.Type, c.Dest, c.ServerNames)
+}
+
+func (c *Config) newREALITYConfig(network, dest string, serverNames []string) *reality.Config {
 	var dialer net.Dialer
 	config := &reality.Config{
 		DialContext: dialer.DialContext,
 
 		Show: c.Show,
-		Type: c.Type,
-		Dest: c.Dest,
+		Type: network,
+		Dest: dest,
 		Xver: byte(c.Xver),
 
 		PrivateKey:   c.PrivateKey,
@@ -48,7 +52,7 @@ func (c *Config) GetREALITYConfig() *reality.Config {
 		config.LimitFallbackDownload.BurstBytesPerSec = c.LimitFallbackDownload.BurstBytesPerSec
 	}
 	config.ServerNames = make(map[string]bool)
-	for _, serverName := range c.ServerNames {
+	for _, serverName := range serverNames {
 		config.ServerNames[serverName] = true
 	}
 	config.ShortIds = make(map[[8]byte]bool)
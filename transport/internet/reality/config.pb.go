@@ -36,16 +36,22 @@ type Config struct {
 	Mldsa65Seed           []byte                 `protobuf:"bytes,11,opt,name=mldsa65_seed,json=mldsa65Seed,proto3" json:"mldsa65_seed,omitempty"`
 	LimitFallbackUpload   *LimitFallback         `protobuf:"bytes,12,opt,name=limit_fallback_upload,json=limitFallbackUpload,proto3" json:"limit_fallback_upload,omitempty"`
 	LimitFallbackDownload *LimitFallback         `protobuf:"bytes,13,opt,name=limit_fallback_download,json=limitFallbackDownload,proto3" json:"limit_fallback_download,omitempty"`
-	Fingerprint           string                 `protobuf:"bytes,21,opt,name=Fingerprint,proto3" json:"Fingerprint,omitempty"`
-	ServerName            string                 `protobuf:"bytes,22,opt,name=server_name,json=serverName,proto3" json:"server_name,omitempty"`
-	PublicKey             []byte                 `protobuf:"bytes,23,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"`
-	ShortId               []byte                 `protobuf:"bytes,24,opt,name=short_id,json=shortId,proto3" json:"short_id,omitempty"`
-	Mldsa65Verify         []byte                 `protobuf:"bytes,25,opt,name=mldsa65_verify,json=mldsa65Verify,proto3" json:"mldsa65_verify,omitempty"`
-	SpiderX               string                 `protobuf:"bytes,26,opt,name=spider_x,json=spiderX,proto3" json:"spider_x,omitempty"`
-	SpiderY               []int64                `protobuf:"varint,27,rep,packed,name=spider_y,json=spiderY,proto3" json:"spider_y,omitempty"`
-	MasterKeyLog          string                 `protobuf:"bytes,31,opt,name=master_key_log,json=masterKeyLog,proto3" json:"master_key_log,omitempty"`
-	unknownFields         protoimpl.UnknownFields
-	sizeCache             protoimpl.SizeCache
+	// Candidates tried in order after dest when it is unreachable.
+	Targets []*Target `protobuf:"bytes,14,rep,name=targets,proto3" json:"targets,omitempty"`
+	// Server names dispatched to their own dests instead of the ones above.
+	ServerNameTargets map[string]*TargetList `protobuf:"bytes,15,rep,name=server_name_targets,json=serverNameTargets,proto3" json:"server_name_targets,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// Seconds between health checks of the candidates, 0 for the default.
+	HealthCheckInterval uint32  `protobuf:"varint,16,opt,name=health_check_interval,json=healthCheckInterval,proto3" json:"health_check_interval,omitempty"`
+	Fingerprint         string  `protobuf:"bytes,21,opt,name=Fingerprint,proto3" json:"Fingerprint,omitempty"`
+	ServerName          string  `protobuf:"bytes,22,opt,name=server_name,json=serverName,proto3" json:"server_name,omitempty"`
+	PublicKey           []byte  `protobuf:"bytes,23,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"`
+	ShortId             []byte  `protobuf:"bytes,24,opt,name=short_id,json=shortId,proto3" json:"short_id,omitempty"`
+	Mldsa65Verify       []byte  `protobuf:"bytes,25,opt,name=mldsa65_verify,json=mldsa65Verify,proto3" json:"mldsa65_verify,omitempty"`
+	SpiderX             string  `protobuf:"bytes,26,opt,name=spider_x,json=spiderX,proto3" json:"spider_x,omitempty"`
+	SpiderY             []int64 `protobuf:"varint,27,rep,packed,name=spider_y,json=spiderY,proto3" json:"spider_y,omitempty"`
+	MasterKeyLog        string  `protobuf:"bytes,31,opt,name=master_key_log,json=masterKeyLog,proto3" json:"master_key_log,omitempty"`
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
 }
 
 func (x *Config) Reset() {
@@ -169,6 +175,27 @@ func (x *Config) GetLimitFallbackDownload() *LimitFallback {
 	return nil
 }
 
+func (x *Config) GetTargets() []*Target {
+	if x != nil {
+		return x.Targets
+	}
+	return nil
+}
+
+func (x *Config) GetServerNameTargets() map[string]*TargetList {
+	if x != nil {
+		return x.ServerNameTargets
+	}
+	return nil
+}
+
+func (x *Config) GetHealthCheckInterval() uint32 {
+	if x != nil {
+		return x.HealthCheckInterval
+	}
+	return 0
+}
+
 func (x *Config) GetFingerprint() string {
 	if x != nil {
 		return x.Fingerprint
@@ -285,11 +312,107 @@ func (x *LimitFallback) GetBurstBytesPerSec() uint64 {
 	return 0
 }
 
+type Target struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	Dest          string                 `protobuf:"bytes,2,opt,name=dest,proto3" json:"dest,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Target) Reset() {
+	*x = Target{}
+	mi := &file_transport_internet_reality_config_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Target) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Target) ProtoMessage() {}
+
+func (x *Target) ProtoReflect() protoreflect.Message {
+	mi := &file_transport_internet_reality_config_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Target.ProtoReflect.Descriptor instead.
+func (*Target) Descriptor() ([]byte, []int) {
+	return file_transport_internet_reality_config_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Target) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Target) GetDest() string {
+	if x != nil {
+		return x.Dest
+	}
+	return ""
+}
+
+type TargetList struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Targets       []*Target              `protobuf:"bytes,1,rep,name=targets,proto3" json:"targets,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TargetList) Reset() {
+	*x = TargetList{}
+	mi := &file_transport_internet_reality_config_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TargetList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TargetList) ProtoMessage() {}
+
+func (x *TargetList) ProtoReflect() protoreflect.Message {
+	mi := &file_transport_internet_reality_config_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TargetList.ProtoReflect.Descriptor instead.
+func (*TargetList) Descriptor() ([]byte, []int) {
+	return file_transport_internet_reality_config_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *TargetList) GetTargets() []*Target {
+	if x != nil {
+		return x.Targets
+	}
+	return nil
+}
+
 var File_transport_internet_reality_config_proto protoreflect.FileDescriptor
 
 const file_transport_internet_reality_config_proto_rawDesc = "" +
 	"\n" +
-	"'transport/internet/reality/config.proto\x12\x1fxray.transport.internet.reality\"\x98\x06\n" +
+	"'transport/internet/reality/config.proto\x12\x1fxray.transport.internet.reality\"\xf2\b\n" +
 	"\x06Config\x12\x12\n" +
 	"\x04show\x18\x01 \x01(\bR\x04show\x12\x12\n" +
 	"\x04dest\x18\x02 \x01(\tR\x04dest\x12\x12\n" +
@@ -305,7 +428,10 @@ const file_transport_internet_reality_config_proto_rawDesc = "" +
 	" \x03(\fR\bshortIds\x12!\n" +
 	"\fmldsa65_seed\x18\v \x01(\fR\vmldsa65Seed\x12b\n" +
 	"\x15limit_fallback_upload\x18\f \x01(\v2..xray.transport.internet.reality.LimitFallbackR\x13limitFallbackUpload\x12f\n" +
-	"\x17limit_fallback_download\x18\r \x01(\v2..xray.transport.internet.reality.LimitFallbackR\x15limitFallbackDownload\x12 \n" +
+	"\x17limit_fallback_download\x18\r \x01(\v2..xray.transport.internet.reality.LimitFallbackR\x15limitFallbackDownload\x12A\n" +
+	"\atargets\x18\x0e \x03(\v2'.xray.transport.internet.reality.TargetR\atargets\x12n\n" +
+	"\x13server_name_targets\x18\x0f \x03(\v2>.xray.transport.internet.reality.Config.ServerNameTargetsEntryR\x11serverNameTargets\x122\n" +
+	"\x15health_check_interval\x18\x10 \x01(\rR\x13healthCheckInterval\x12 \n" +
 	"\vFingerprint\x18\x15 \x01(\tR\vFingerprint\x12\x1f\n" +
 	"\vserver_name\x18\x16 \x01(\tR\n" +
 	"serverName\x12\x1d\n" +
@@ -315,12 +441,21 @@ const file_transport_internet_reality_config_proto_rawDesc = "" +
 	"\x0emldsa65_verify\x18\x19 \x01(\fR\rmldsa65Verify\x12\x19\n" +
 	"\bspider_x\x18\x1a \x01(\tR\aspiderX\x12\x19\n" +
 	"\bspider_y\x18\x1b \x03(\x03R\aspiderY\x12$\n" +
-	"\x0emaster_key_log\x18\x1f \x01(\tR\fmasterKeyLog\"\x83\x01\n" +
+	"\x0emaster_key_log\x18\x1f \x01(\tR\fmasterKeyLog\x1aq\n" +
+	"\x16ServerNameTargetsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12A\n" +
+	"\x05value\x18\x02 \x01(\v2+.xray.transport.internet.reality.TargetListR\x05value:\x028\x01\"\x83\x01\n" +
 	"\rLimitFallback\x12\x1f\n" +
 	"\vafter_bytes\x18\x01 \x01(\x04R\n" +
 	"afterBytes\x12\"\n" +
 	"\rbytes_per_sec\x18\x02 \x01(\x04R\vbytesPerSec\x12-\n" +
-	"\x13burst_bytes_per_sec\x18\x03 \x01(\x04R\x10burstBytesPerSecB\x7f\n" +
+	"\x13burst_bytes_per_sec\x18\x03 \x01(\x04R\x10burstBytesPerSec\"0\n" +
+	"\x06Target\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12\x12\n" +
+	"\x04dest\x18\x02 \x01(\tR\x04dest\"O\n" +
+	"\n" +
+	"TargetList\x12A\n" +
+	"\atargets\x18\x01 \x03(\v2'.xray.transport.internet.reality.TargetR\atargetsB\x7f\n" +
 	"#com.xray.transport.internet.realityP\x01Z4github.com/xtls/xray-core/transport/internet/reality\xaa\x02\x1fXray.Transport.Internet.Realityb\x06proto3"
 
 var (
@@ -335,19 +470,26 @@ func file_transport_internet_reality_config_proto_rawDescGZIP() []byte {
 	return file_transport_internet_reality_config_proto_rawDescData
 }
 
-var file_transport_internet_reality_config_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_transport_internet_reality_config_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
 var file_transport_internet_reality_config_proto_goTypes = []any{
 	(*Config)(nil),        // 0: xray.transport.internet.reality.Config
 	(*LimitFallback)(nil), // 1: xray.transport.internet.reality.LimitFallback
+	(*Target)(nil),        // 2: xray.transport.internet.reality.Target
+	(*TargetList)(nil),    // 3: xray.transport.internet.reality.TargetList
+	nil,                   // 4: xray.transport.internet.reality.Config.ServerNameTargetsEntry
 }
 var file_transport_internet_reality_config_proto_depIdxs = []int32{
 	1, // 0: xray.transport.internet.reality.Config.limit_fallback_upload:type_name -> xray.transport.internet.reality.LimitFallback
 	1, // 1: xray.transport.internet.reality.Config.limit_fallback_download:type_name -> xray.transport.internet.reality.LimitFallback
-	2, // [2:2] is the sub-list for method output_type
-	2, // [2:2] is the sub-list for method input_type
-	2, // [2:2] is the sub-list for extension type_name
-	2, // [2:2] is the sub-list for extension extendee
-	0, // [0:2] is the sub-list for field type_name
+	2, // 2: xray.transport.internet.reality.Config.targets:type_name -> xray.transport.internet.reality.Target
+	4, // 3: xray.transport.internet.reality.Config.server_name_targets:type_name -> xray.transport.internet.reality.Config.ServerNameTargetsEntry
+	2, // 4: xray.transport.internet.reality.TargetList.targets:type_name -> xray.transport.internet.reality.Target
+	3, // 5: xray.transport.internet.reality.Config.ServerNameTargetsEntry.value:type_name -> xray.transport.internet.reality.TargetList
+	6, // [6:6] is the sub-list for method output_type
+	6, // [6:6] is the sub-list for method input_type
+	6, // [6:6] is the sub-list for extension type_name
+	6, // [6:6] is the sub-list for extension extendee
+	0, // [0:6] is the sub-list for field type_name
 }
 
 func init() { file_transport_internet_reality_config_proto_init() }
@@ -361,7 +503,7 @@ func file_transport_internet_reality_config_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_transport_internet_reality_config_proto_rawDesc), len(file_transport_internet_reality_config_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   2,
+			NumMessages:   5,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
@@ -0,0 +1,377 @@
+package reality
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/pires/go-proxyproto"
+	"github.com/xtls/reality"
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/net"
+	ctls "github.com/xtls/xray-core/common/protocol/tls"
+	"github.com/xtls/xray-core/common/session"
+	"github.com/xtls/xray-core/core"
+	"github.com/xtls/xray-core/features/extension"
+	"github.com/xtls/xray-core/features/policy"
+	"github.com/xtls/xray-core/features/stats"
+)
+
+const (
+	defaultHealthCheckInterval = 30 * time.Second
+	healthCheckTimeout         = 5 * time.Second
+	clientHelloTimeout         = 8 * time.Second
+	maxPlaintextRecordLen      = 16384
+)
+
+// target is a dest candidate of a REALITY server.
+type target struct {
+	network string
+	address string
+	healthy atomic.Bool
+
+	fallback stats.Counter
+}
+
+func (t *target) setHealthy(ctx context.Context, healthy bool, err error) {
+	if t.healthy.CompareAndSwap(!healthy, healthy) {
+		if healthy {
+			errors.LogInfo(ctx, "REALITY: target ", t.address, " is up")
+		} else {
+			errors.LogWarningInner(ctx, err, "REALITY: target ", t.address, " is down")
+		}
+	}
+}
+
+func (t *target) check(ctx context.Context) {
+	dialCtx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(dialCtx, t.network, t.address)
+	if err != nil {
+		if ctx.Err() == nil {
+			t.setHealthy(ctx, false, err)
+		}
+		return
+	}
+	conn.Close()
+	t.setHealthy(ctx, true, nil)
+}
+
+type dialRecordKey struct{}
+
+// dialRecord remembers the candidate that a connection was forwarded to.
+type dialRecord struct {
+	target *target
+}
+
+// targetGroup is the REALITY config for a set of server names,
+// whose dest fails over between the candidates in order.
+type targetGroup struct {
+	config  *reality.Config
+	targets []*target
+}
+
+func (g *targetGroup) dialContext(ctx context.Context, _, _ string) (net.Conn, error) {
+	candidates := make([]*target, 0, len(g.targets))
+	for _, t := range g.targets {
+		if t.healthy.Load() {
+			candidates = append(candidates, t)
+		}
+	}
+	for _, t := range g.targets {
+		if !t.healthy.Load() {
+			candidates = append(candidates, t)
+		}
+	}
+
+	var dialer net.Dialer
+	var lastErr error
+	for _, t := range candidates {
+		conn, err := dialer.DialContext(ctx, t.network, t.address)
+		if err != nil {
+			t.setHealthy(ctx, false, err)
+			lastErr = err
+			continue
+		}
+		t.setHealthy(ctx, true, nil)
+		if record, ok := ctx.Value(dialRecordKey{}).(*dialRecord); ok {
+			record.target = t
+		}
+		return conn, nil
+	}
+	return nil, lastErr
+}
+
+// Dispatcher performs the REALITY handshake of incoming connections. The dest of
+// each connection is chosen by the server name in its ClientHello, and fails
+// over to the next healthy candidate when the preferred one is unreachable.
+type Dispatcher struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	defaultGroup *targetGroup
+	groups       map[string]*targetGroup
+
+	authenticated stats.Counter
+	fallback      stats.Counter
+
+	inboundTag string
+	stats      stats.Manager // or nil
+}
+
+// banned returns whether the source of conn is banned from the inbound by fail2ban.
+func (d *Dispatcher) banned(conn net.Conn) bool {
+	if len(d.inboundTag) == 0 {
+		return false
+	}
+	v := core.FromContext(d.ctx)
+	if v == nil {
+		return false
+	}
+	f, _ := v.GetFeature(extension.Fail2banType()).(extension.Fail2ban)
+	return f != nil && f.Banned(d.inboundTag, net.DestinationFromAddr(conn.RemoteAddr()).Address)
+}
+
+// NewDispatcher creates a Dispatcher for c. Health checks of the candidates run
+// until the Dispatcher is closed. If ctx carries an inbound tag and inbound stats
+// are enabled, "inbound>>>{tag}>>>reality>>>{authenticated|fallback}" count the
+// connections, and "inbound>>>{tag}>>>reality>>>target>>>{dest}>>>fallback"
+// count the forwarded ones of each candidate. Fallbacks are also published as
+// authentication failures of the inbound.
+func NewDispatcher(ctx context.Context, c *Config) *Dispatcher {
+	d := &Dispatcher{
+		groups: make(map[string]*targetGroup),
+	}
+	d.ctx, d.cancel = context.WithCancel(ctx)
+
+	var sm stats.Manager
+	var prefix string
+	if inbound := session.InboundFromContext(ctx); inbound != nil && len(inbound.Tag) > 0 {
+		if v := core.FromContext(ctx); v != nil {
+			pm, _ := v.GetFeature(policy.ManagerType()).(policy.Manager)
+			sm, _ = v.GetFeature(stats.ManagerType()).(stats.Manager)
+			d.inboundTag = inbound.Tag
+			d.stats = sm
+			if pm != nil && sm != nil && (pm.ForSystem().Stats.InboundUplink || pm.ForSystem().Stats.InboundDownlink) {
+				prefix = "inbound>>>" + inbound.Tag + ">>>reality"
+				d.authenticated, _ = stats.GetOrRegisterCounter(sm, prefix+">>>authenticated")
+				d.fallback, _ = stats.GetOrRegisterCounter(sm, prefix+">>>fallback")
+			} else {
+				sm = nil
+			}
+		}
+	}
+
+	targets := make(map[string]*target)
+	newGroup := func(candidates []*Target, serverNames []string) *targetGroup {
+		g := &targetGroup{}
+		for _, candidate := range candidates {
+			key := candidate.Type + " " + candidate.Dest
+			t, found := targets[key]
+			if !found {
+				t = &target{
+					network: candidate.Type,
+					address: candidate.Dest,
+				}
+				t.healthy.Store(true)
+				if sm != nil {
+					t.fallback, _ = stats.GetOrRegisterCounter(sm, prefix+">>>target>>>"+strings.TrimRight(candidate.Dest, "\x00")+">>>fallback")
+				}
+				targets[key] = t
+			}
+			g.targets = append(g.targets, t)
+		}
+		g.config = c.newREALITYConfig(candidates[0].Type, candidates[0].Dest, serverNames)
+		g.config.DialContext = g.dialContext
+		go reality.DetectPostHandshakeRecordsLens(g.config)
+		return g
+	}
+
+	serverNames := make([]string, 0, len(c.ServerNames))
+	for _, serverName := range c.ServerNames {
+		if _, found := c.ServerNameTargets[serverName]; !found {
+			serverNames = append(serverNames, serverName)
+		}
+	}
+	d.defaultGroup = newGroup(append([]*Target{{Type: c.Type, Dest: c.Dest}}, c.Targets...), serverNames)
+	for serverName, list := range c.ServerNameTargets {
+		if len(list.GetTargets()) > 0 {
+			d.groups[serverName] = newGroup(list.Targets, []string{serverName})
+		}
+	}
+
+	if len(targets) > 1 {
+		interval := time.Duration(c.HealthCheckInterval) * time.Second
+		if interval <= 0 {
+			interval = defaultHealthCheckInterval
+		}
+		go d.healthCheck(targets, interval)
+	}
+	return d
+}
+
+func (d *Dispatcher) healthCheck(targets map[string]*target, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			for _, t := range targets {
+				go t.check(d.ctx)
+			}
+		case <-d.ctx.Done():
+			return
+		}
+	}
+}
+
+// Handshake performs the REALITY handshake on conn. Connections that fail
+// authentication are forwarded to the dest and an error is returned. Those from
+// sources banned by fail2ban are closed before the handshake.
+func (d *Dispatcher) Handshake(conn net.Conn) (net.Conn, error) {
+	if d.banned(conn) {
+		conn.Close()
+		return nil, errors.New("REALITY: rejected banned source ", conn.RemoteAddr())
+	}
+	group := d.defaultGroup
+	if len(d.groups) > 0 {
+		helloConn, serverName, err := peekServerName(conn)
+		if err != nil {
+			conn.Close()
+			return nil, errors.New("REALITY: failed to read the ClientHello from ", conn.RemoteAddr()).Base(err)
+		}
+		conn = helloConn
+		if g, found := d.groups[serverName]; found {
+			group = g
+		}
+	}
+	record := &dialRecord{}
+	realityConn, err := reality.Server(context.WithValue(d.ctx, dialRecordKey{}, record), conn, group.config)
+	if err == nil {
+		if d.authenticated != nil {
+			d.authenticated.Add(1)
+		}
+	} else if record.target != nil {
+		if d.fallback != nil {
+			d.fallback.Add(1)
+		}
+		if record.target.fallback != nil {
+			record.target.fallback.Add(1)
+		}
+		if d.stats != nil {
+			stats.PublishAuthFailure(d.stats, stats.AuthFailure{
+				InboundTag: d.inboundTag,
+				Protocol:   "reality",
+				Source:     net.DestinationFromAddr(conn.RemoteAddr()).Address,
+				Reason:     "fallback",
+			})
+		}
+	}
+	return &Conn{Conn: realityConn}, err
+}
+
+// Close stops the health checks.
+func (d *Dispatcher) Close() error {
+	d.cancel()
+	return nil
+}
+
+// HelloConn replays the peeked ClientHello before reading from Conn.
+type HelloConn struct {
+	net.Conn
+	reader io.Reader
+}
+
+func (c *HelloConn) Read(b []byte) (int, error) {
+	return c.reader.Read(b)
+}
+
+func (c *HelloConn) CloseWrite() error {
+	raw := c.Conn
+	if pc, ok := raw.(*proxyproto.Conn); ok {
+		raw = pc.Raw()
+	}
+	if cw, ok := raw.(interface{ CloseWrite() error }); ok {
+		return cw.CloseWrite()
+	}
+	return nil
+}
+
+// peekServerName reads the first TLS record of conn and returns the server name in it,
+// along with a conn that replays the record. The record must come within clientHelloTimeout.
+func peekServerName(conn net.Conn) (net.Conn, string, error) {
+	conn.SetReadDeadline(time.Now().Add(clientHelloTimeout))
+	defer conn.SetReadDeadline(time.Time{})
+	header := make([]byte, 5)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return nil, "", err
+	}
+	b := header
+	if header[0] == 0x16 {
+		if length := int(binary.BigEndian.Uint16(header[3:])); length <= maxPlaintextRecordLen {
+			b = append(b, make([]byte, length)...)
+			if _, err := io.ReadFull(conn, b[5:]); err != nil {
+				return nil, "", err
+			}
+		}
+	}
+	helloConn := &HelloConn{
+		Conn:   conn,
+		reader: io.MultiReader(bytes.NewReader(b), conn),
+	}
+	if h, err := ctls.SniffTLS(b); err == nil {
+		return helloConn, h.Domain(), nil
+	}
+	return helloConn, "", nil
+}
+
+type listener struct {
+	net.Listener
+	dispatcher *Dispatcher
+	conns      chan net.Conn
+	err        error
+}
+
+// NewListener creates a listener which accepts connections from inner and
+// hands out the ones that pass the REALITY handshake of dispatcher.
+func NewListener(inner net.Listener, dispatcher *Dispatcher) net.Listener {
+	l := &listener{
+		Listener:   inner,
+		dispatcher: dispatcher,
+		conns:      make(chan net.Conn),
+	}
+	go func() {
+		for {
+			c, err := l.Listener.Accept()
+			if err != nil {
+				l.err = err
+				close(l.conns)
+				return
+			}
+			go func() {
+				defer func() { recover() }()
+				if c, err := dispatcher.Handshake(c); err == nil {
+					l.conns <- c
+				}
+			}()
+		}
+	}()
+	return l
+}
+
+func (l *listener) Accept() (net.Conn, error) {
+	if c, ok := <-l.conns; ok {
+		return c, nil
+	}
+	return nil, l.err
+}
+
+func (l *listener) Close() error {
+	l.dispatcher.Close()
+	return l.Listener.Close()
+}
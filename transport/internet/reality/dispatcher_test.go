@@ -0,0 +1,127 @@
+package reality
+
+import (
+	"context"
+	"crypto/rand"
+	gotls "crypto/tls"
+	"net"
+	"testing"
+	"time"
+)
+
+func listenDest(t *testing.T) (net.Listener, chan []byte) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	received := make(chan []byte, 1)
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			b := make([]byte, 5)
+			conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+			n, _ := conn.Read(b)
+			received <- b[:n]
+			conn.Close()
+		}
+	}()
+	return l, received
+}
+
+func TestDispatcherServerNameAndFailover(t *testing.T) {
+	destA, receivedA := listenDest(t)
+	defer destA.Close()
+	destB, receivedB := listenDest(t)
+	defer destB.Close()
+	dead, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	deadAddr := dead.Addr().String()
+	dead.Close()
+
+	privateKey := make([]byte, 32)
+	rand.Read(privateKey)
+	d := NewDispatcher(context.Background(), &Config{
+		Type:        "tcp",
+		Dest:        deadAddr,
+		Targets:     []*Target{{Type: "tcp", Dest: destA.Addr().String()}},
+		ServerNames: []string{"a.example"},
+		ServerNameTargets: map[string]*TargetList{
+			"b.example": {Targets: []*Target{{Type: "tcp", Dest: destB.Addr().String()}}},
+		},
+		PrivateKey: privateKey,
+	})
+	defer d.Close()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	for _, tc := range []struct {
+		serverName string
+		received   chan []byte
+	}{
+		{"a.example", receivedA},
+		{"b.example", receivedB},
+	} {
+		client, err := net.Dial("tcp", l.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		server, err := l.Accept()
+		if err != nil {
+			t.Fatal(err)
+		}
+		go d.Handshake(server)
+		go gotls.Client(client, &gotls.Config{ServerName: tc.serverName}).Handshake()
+
+		select {
+		case b := <-tc.received:
+			if len(b) == 0 || b[0] != 0x16 {
+				t.Error("dest of ", tc.serverName, " received ", b, ", expected a ClientHello")
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("dest of ", tc.serverName, " received nothing")
+		}
+		client.Close()
+	}
+
+	if d.defaultGroup.targets[0].healthy.Load() {
+		t.Error("unreachable target is still healthy")
+	}
+	if !d.defaultGroup.targets[1].healthy.Load() {
+		t.Error("reachable target is not healthy")
+	}
+}
+
+type deadlineConn struct {
+	net.Conn
+	deadlines []time.Time
+}
+
+func (c *deadlineConn) SetReadDeadline(t time.Time) error {
+	c.deadlines = append(c.deadlines, t)
+	return c.Conn.SetReadDeadline(t)
+}
+
+func TestPeekServerNameDeadline(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	go func() {
+		client.Write([]byte{0x16, 0x03, 0x01})
+		client.Close()
+	}()
+	conn := &deadlineConn{Conn: server}
+	if _, _, err := peekServerName(conn); err == nil {
+		t.Error("peeked a truncated record")
+	}
+	if len(conn.deadlines) != 2 || conn.deadlines[0].IsZero() || !conn.deadlines[1].IsZero() {
+		t.Error("unexpected read deadlines: ", conn.deadlines)
+	}
+}
@@ -71,8 +71,9 @@ func getHTTPClient(ctx context.Context, dest net.Destination, streamSettings *in
 			xmuxConfig = *transportConfig.Xmux
 		}
 
+		targetRates := congestion.NewTargetRateStats(ctx)
 		xmuxManager = NewXmuxManager(xmuxConfig, func() XmuxConn {
-			return createHTTPClient(dest, streamSettings)
+			return createHTTPClient(dest, streamSettings, targetRates)
 		})
 		globalDialerMap[key] = xmuxManager
 	}
@@ -100,7 +101,7 @@ func decideHTTPVersion(tlsConfig *tls.Config, realityConfig *reality.Config) str
 	return "2"
 }
 
-func createHTTPClient(dest net.Destination, streamSettings *internet.MemoryStreamConfig) DialerClient {
+func createHTTPClient(dest net.Destination, streamSettings *internet.MemoryStreamConfig, targetRates *congestion.TargetRateStats) DialerClient {
 	tlsConfig := tls.ConfigFromStreamSettings(streamSettings)
 	realityConfig := reality.ConfigFromStreamSettings(streamSettings)
 
@@ -267,6 +268,8 @@ func createHTTPClient(dest net.Destination, streamSettings *internet.MemoryStrea
 					congestion.UseBBR(conn, bbr.Profile(quicParams.BbrProfile))
 				case "force-brutal":
 					congestion.UseBrutal(conn, quicParams.BrutalUp)
+				case "adaptive-brutal":
+					congestion.UseAdaptiveBrutal(conn, quicParams.BrutalUp, targetRates)
 				default:
 					panic(quicParams.Congestion)
 				}
@@ -17,7 +17,6 @@ import (
 
 	"github.com/apernet/quic-go"
 	"github.com/apernet/quic-go/http3"
-	goreality "github.com/xtls/reality"
 	"github.com/xtls/xray-core/common"
 	"github.com/xtls/xray-core/common/buf"
 	"github.com/xtls/xray-core/common/errors"
@@ -27,6 +26,7 @@ import (
 	"github.com/xtls/xray-core/transport/internet"
 	"github.com/xtls/xray-core/transport/internet/hysteria/congestion"
 	"github.com/xtls/xray-core/transport/internet/hysteria/congestion/bbr"
+	"github.com/xtls/xray-core/transport/internet/hysteria/udphop"
 	"github.com/xtls/xray-core/transport/internet/reality"
 	"github.com/xtls/xray-core/transport/internet/stat"
 	"github.com/xtls/xray-core/transport/internet/tls"
@@ -475,6 +475,15 @@ func ListenXH(ctx context.Context, address net.Address, port net.Port, streamSet
 		if err != nil {
 			return nil, errors.New("failed to listen UDP for XHTTP/3 on ", address, ":", port).Base(err)
 		}
+		if ports := streamSettings.QuicParams.GetHopListenPorts(); len(ports) > 0 {
+			Conn, err = udphop.ListenUDPHop(Conn, ports, func(addr *net.UDPAddr) (net.PacketConn, error) {
+				return internet.ListenSystemPacket(context.Background(), addr, streamSettings.SocketSettings)
+			})
+			if err != nil {
+				return nil, errors.New("failed to listen hop ports for XHTTP/3 on ", address).Base(err)
+			}
+			errors.LogInfo(ctx, "accepting hops for XHTTP/3 on ", len(ports), " ports")
+		}
 		if streamSettings.UdpmaskManager != nil {
 			newConn, err := streamSettings.UdpmaskManager.WrapPacketConnServer(Conn)
 			if err != nil {
@@ -509,6 +518,7 @@ func ListenXH(ctx context.Context, address net.Address, port net.Port, streamSet
 		l.h3listener = &QListener{
 			QUICListener: l.h3listener,
 			quicParams:   quicParams,
+			targetRates:  congestion.NewTargetRateStats(ctx),
 		}
 		errors.LogInfo(ctx, "listening QUIC for XHTTP/3 on ", address, ":", port)
 
@@ -545,7 +555,7 @@ func ListenXH(ctx context.Context, address net.Address, port net.Port, streamSet
 			}
 		}
 		if config := reality.ConfigFromStreamSettings(streamSettings); config != nil {
-			l.listener = goreality.NewListener(l.listener, config.GetREALITYConfig())
+			l.listener = reality.NewListener(l.listener, reality.NewDispatcher(ctx, config))
 		}
 
 		handler.localAddr = l.listener.Addr()
@@ -605,7 +615,8 @@ func init() {
 
 type QListener struct {
 	http3.QUICListener
-	quicParams *internet.QuicParams
+	quicParams  *internet.QuicParams
+	targetRates *congestion.TargetRateStats
 }
 
 func (l *QListener) Accept(ctx context.Context) (*quic.Conn, error) {
@@ -619,6 +630,8 @@ func (l *QListener) Accept(ctx context.Context) (*quic.Conn, error) {
 		congestion.UseBBR(conn, bbr.Profile(l.quicParams.BbrProfile))
 	case "force-brutal":
 		congestion.UseBrutal(conn, l.quicParams.BrutalUp)
+	case "adaptive-brutal":
+		congestion.UseAdaptiveBrutal(conn, l.quicParams.BrutalUp, l.targetRates)
 	default:
 		panic(l.quicParams.Congestion)
 	}
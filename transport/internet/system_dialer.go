@@ -49,6 +49,10 @@ func resolveSrcAddr(network net.Network, src net.Address) net.Addr {
 func (d *DefaultSystemDialer) Dial(ctx context.Context, src net.Address, dest net.Destination, sockopt *SocketConfig) (net.Conn, error) {
 	errors.LogDebug(ctx, "dialing to "+dest.String())
 
+	if dest.Network == net.Network_ICMP {
+		return dialICMP(ctx, src, dest, sockopt)
+	}
+
 	if dest.Network == net.Network_UDP {
 		srcAddr := resolveSrcAddr(net.Network_UDP, src)
 		if srcAddr == nil {
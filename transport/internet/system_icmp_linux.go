@@ -0,0 +1,300 @@
+//go:build linux
+
+package internet
+
+import (
+	"context"
+	"encoding/binary"
+	"os"
+	"sync/atomic"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/protocol/icmp"
+	"golang.org/x/sys/unix"
+)
+
+// icmpConn is an unprivileged ICMP socket, a.k.a. ping socket, speaking the packets of common/protocol/icmp.
+// Write sends Echo requests, and Read returns Echo replies as well as the errors they caused,
+// which the kernel only reports in the error queue of the socket.
+type icmpConn struct {
+	conn    *net.UDPConn
+	rawConn syscall.RawConn
+	ipv6    bool
+	local   net.IP
+	dest    net.IP
+
+	// The kernel replaces the identifier with its own one, the original one is restored in replies.
+	ident atomic.Uint32
+	ttl   atomic.Uint32
+
+	buffer []byte
+	oob    []byte
+}
+
+func dialICMP(ctx context.Context, src net.Address, dest net.Destination, sockopt *SocketConfig) (net.Conn, error) {
+	if !dest.Address.Family().IsIP() {
+		return nil, errors.New("ICMP destination must be an IP address: ", dest)
+	}
+	ipv6 := dest.Address.Family().IsIPv6()
+	network, family, proto := "ip4", unix.AF_INET, unix.IPPROTO_ICMP
+	if ipv6 {
+		network, family, proto = "ip6", unix.AF_INET6, unix.IPPROTO_ICMPV6
+	}
+
+	fd, err := unix.Socket(family, unix.SOCK_DGRAM|unix.SOCK_NONBLOCK|unix.SOCK_CLOEXEC, proto)
+	if err != nil {
+		return nil, errors.New("failed to open ICMP socket, check net.ipv4.ping_group_range").Base(err)
+	}
+	file := os.NewFile(uintptr(fd), "icmp")
+	defer file.Close()
+
+	if ipv6 {
+		err = errors.Combine(
+			unix.SetsockoptInt(fd, unix.IPPROTO_IPV6, unix.IPV6_RECVERR, 1),
+			unix.SetsockoptInt(fd, unix.IPPROTO_IPV6, unix.IPV6_RECVHOPLIMIT, 1),
+		)
+	} else {
+		err = errors.Combine(
+			unix.SetsockoptInt(fd, unix.IPPROTO_IP, unix.IP_RECVERR, 1),
+			unix.SetsockoptInt(fd, unix.IPPROTO_IP, unix.IP_RECVTTL, 1),
+		)
+	}
+	if err != nil {
+		return nil, errors.New("failed to set ICMP socket options").Base(err)
+	}
+	if src != nil && src.Family().IsIP() {
+		var sa unix.Sockaddr
+		if ipv6 {
+			sa = &unix.SockaddrInet6{Addr: [16]byte(src.IP().To16())}
+		} else {
+			sa = &unix.SockaddrInet4{Addr: [4]byte(src.IP().To4())}
+		}
+		if err := unix.Bind(fd, sa); err != nil {
+			return nil, errors.New("failed to bind ICMP socket to ", src).Base(err)
+		}
+	}
+
+	pc, err := net.FilePacketConn(file)
+	if err != nil {
+		return nil, err
+	}
+	conn, ok := pc.(*net.UDPConn)
+	if !ok {
+		pc.Close()
+		return nil, errors.New("unexpected ICMP socket")
+	}
+	rawConn, err := conn.SyscallConn()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	for _, ctl := range Controllers {
+		if err := ctl(network, dest.Address.String(), rawConn); err != nil {
+			errors.LogInfoInner(ctx, err, "failed to apply external controller")
+		}
+	}
+	if sockopt != nil {
+		rawConn.Control(func(fd uintptr) {
+			if err := applyOutboundSocketOptions(network, dest.Address.String(), fd, sockopt); err != nil {
+				errors.LogInfoInner(ctx, err, "failed to apply socket options")
+			}
+		})
+	}
+
+	local := net.IP(make([]byte, net.IPv4len))
+	if ipv6 {
+		local = net.IP(make([]byte, net.IPv6len))
+	}
+	if addr, ok := conn.LocalAddr().(*net.UDPAddr); ok && !addr.IP.IsUnspecified() {
+		local = addr.IP
+	}
+	c := &icmpConn{
+		conn:    conn,
+		rawConn: rawConn,
+		ipv6:    ipv6,
+		local:   local,
+		dest:    dest.Address.IP(),
+		buffer:  make([]byte, 65536),
+		oob:     make([]byte, 512),
+	}
+	c.ttl.Store(64)
+	return c, nil
+}
+
+func (c *icmpConn) Write(b []byte) (int, error) {
+	p, err := icmp.ParsePacket(b)
+	if err != nil {
+		return 0, err
+	}
+	if p.IsIPv6() != c.ipv6 || !p.IsEchoRequest() {
+		return len(b), nil
+	}
+	c.ident.Store(uint32(p.Ident()))
+	if ttl := uint32(p.TTL); ttl != 0 && c.ttl.Swap(ttl) != ttl {
+		var err error
+		c.rawConn.Control(func(fd uintptr) {
+			if c.ipv6 {
+				err = unix.SetsockoptInt(int(fd), unix.IPPROTO_IPV6, unix.IPV6_UNICAST_HOPS, int(ttl))
+			} else {
+				err = unix.SetsockoptInt(int(fd), unix.IPPROTO_IP, unix.IP_TTL, int(ttl))
+			}
+		})
+		if err != nil {
+			return 0, errors.New("failed to set TTL").Base(err)
+		}
+	}
+	if _, err := c.conn.WriteTo(p.Message, &net.UDPAddr{IP: c.dest}); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+func (c *icmpConn) Read(b []byte) (int, error) {
+	for {
+		var p *icmp.Packet
+		var err error
+		if readErr := c.rawConn.Read(func(fd uintptr) bool {
+			p, err = c.receive(int(fd))
+			return err != unix.EAGAIN
+		}); readErr != nil {
+			return 0, readErr
+		}
+		if err != nil {
+			return 0, err
+		}
+		if p != nil {
+			return copy(b, p.Marshal()), nil
+		}
+	}
+}
+
+// receive reads the error queue first, as errors are never returned by a normal read of a ping socket.
+func (c *icmpConn) receive(fd int) (*icmp.Packet, error) {
+	n, oobn, _, from, err := unix.Recvmsg(fd, c.buffer, c.oob, unix.MSG_ERRQUEUE)
+	if err == nil {
+		return c.parseError(c.buffer[:n], c.oob[:oobn], from), nil
+	}
+	if err != unix.EAGAIN {
+		return nil, err
+	}
+	n, oobn, _, from, err = unix.Recvmsg(fd, c.buffer, c.oob, 0)
+	if err != nil {
+		return nil, err
+	}
+	return c.parseReply(c.buffer[:n], c.oob[:oobn], from), nil
+}
+
+func (c *icmpConn) parseReply(message []byte, oob []byte, from unix.Sockaddr) *icmp.Packet {
+	p := &icmp.Packet{
+		Source:      sockaddrIP(from),
+		Destination: c.local,
+		TTL:         64,
+		Message:     append([]byte(nil), message...),
+	}
+	if p.Source == nil || len(p.Message) < 8 || !p.IsEchoReply() {
+		return nil
+	}
+	p.SetIdent(uint16(c.ident.Load()))
+	cmsgs, _ := unix.ParseSocketControlMessage(oob)
+	for _, cmsg := range cmsgs {
+		if (cmsg.Header.Level == unix.IPPROTO_IP && cmsg.Header.Type == unix.IP_TTL) ||
+			(cmsg.Header.Level == unix.IPPROTO_IPV6 && cmsg.Header.Type == unix.IPV6_HOPLIMIT) {
+			if len(cmsg.Data) >= 4 {
+				p.TTL = uint8(binary.NativeEndian.Uint32(cmsg.Data))
+			}
+		}
+	}
+	return p
+}
+
+func (c *icmpConn) parseError(message []byte, oob []byte, from unix.Sockaddr) *icmp.Packet {
+	dest := sockaddrIP(from)
+	if dest == nil || len(message) < 8 {
+		return nil
+	}
+	cmsgs, _ := unix.ParseSocketControlMessage(oob)
+	for _, cmsg := range cmsgs {
+		if !(cmsg.Header.Level == unix.IPPROTO_IP && cmsg.Header.Type == unix.IP_RECVERR) &&
+			!(cmsg.Header.Level == unix.IPPROTO_IPV6 && cmsg.Header.Type == unix.IPV6_RECVERR) {
+			continue
+		}
+		const size = int(unsafe.Sizeof(unix.SockExtendedErr{}))
+		if len(cmsg.Data) < size {
+			continue
+		}
+		ee := (*unix.SockExtendedErr)(unsafe.Pointer(&cmsg.Data[0]))
+		if ee.Origin != unix.SO_EE_ORIGIN_ICMP && ee.Origin != unix.SO_EE_ORIGIN_ICMP6 {
+			// Local errors, e.g. EMSGSIZE, are not ICMP messages.
+			continue
+		}
+		offender := offenderIP(cmsg.Data[size:])
+		if offender == nil {
+			continue
+		}
+		quoted := &icmp.Packet{
+			Source:      c.local,
+			Destination: dest,
+			TTL:         uint8(c.ttl.Load()),
+			Message:     append([]byte(nil), message...),
+		}
+		quoted.SetIdent(uint16(c.ident.Load()))
+		return icmp.NewError(offender, c.local, ee.Type, ee.Code, quoted)
+	}
+	return nil
+}
+
+// offenderIP parses the address following sock_extended_err, see SO_EE_OFFENDER.
+func offenderIP(b []byte) net.IP {
+	if len(b) < 2 {
+		return nil
+	}
+	switch binary.NativeEndian.Uint16(b) {
+	case unix.AF_INET:
+		if len(b) >= 8 {
+			return net.IP(append([]byte(nil), b[4:8]...))
+		}
+	case unix.AF_INET6:
+		if len(b) >= 24 {
+			return net.IP(append([]byte(nil), b[8:24]...))
+		}
+	}
+	return nil
+}
+
+func sockaddrIP(sa unix.Sockaddr) net.IP {
+	switch sa := sa.(type) {
+	case *unix.SockaddrInet4:
+		return net.IP(sa.Addr[:])
+	case *unix.SockaddrInet6:
+		return net.IP(sa.Addr[:])
+	}
+	return nil
+}
+
+func (c *icmpConn) Close() error {
+	return c.conn.Close()
+}
+
+func (c *icmpConn) LocalAddr() net.Addr {
+	return &net.IPAddr{IP: c.local}
+}
+
+func (c *icmpConn) RemoteAddr() net.Addr {
+	return &net.IPAddr{IP: c.dest}
+}
+
+func (c *icmpConn) SetDeadline(t time.Time) error {
+	return c.conn.SetDeadline(t)
+}
+
+func (c *icmpConn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+func (c *icmpConn) SetWriteDeadline(t time.Time) error {
+	return c.conn.SetWriteDeadline(t)
+}
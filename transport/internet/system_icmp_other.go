@@ -0,0 +1,15 @@
+//go:build !linux
+
+package internet
+
+import (
+	"context"
+	"runtime"
+
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/net"
+)
+
+func dialICMP(ctx context.Context, src net.Address, dest net.Destination, sockopt *SocketConfig) (net.Conn, error) {
+	return nil, errors.New("ICMP is not supported on ", runtime.GOOS)
+}
@@ -6,7 +6,6 @@ import (
 	"strings"
 	"time"
 
-	goreality "github.com/xtls/reality"
 	"github.com/xtls/xray-core/common"
 	"github.com/xtls/xray-core/common/errors"
 	"github.com/xtls/xray-core/common/net"
@@ -18,12 +17,12 @@ import (
 
 // Listener is an internet.Listener that listens for TCP connections.
 type Listener struct {
-	listener      net.Listener
-	tlsConfig     *gotls.Config
-	realityConfig *goreality.Config
-	authConfig    internet.ConnectionAuthenticator
-	config        *Config
-	addConn       internet.ConnHandler
+	listener          net.Listener
+	tlsConfig         *gotls.Config
+	realityDispatcher *reality.Dispatcher
+	authConfig        internet.ConnectionAuthenticator
+	config            *Config
+	addConn           internet.ConnHandler
 }
 
 // ListenTCP creates a new Listener based on configurations.
@@ -78,8 +77,7 @@ func ListenTCP(ctx context.Context, address net.Address, port net.Port, streamSe
 		l.tlsConfig = config.GetTLSConfig()
 	}
 	if config := reality.ConfigFromStreamSettings(streamSettings); config != nil {
-		l.realityConfig = config.GetREALITYConfig()
-		go goreality.DetectPostHandshakeRecordsLens(l.realityConfig)
+		l.realityDispatcher = reality.NewDispatcher(ctx, config)
 	}
 
 	if tcpSettings.HeaderSettings != nil {
@@ -116,8 +114,8 @@ func (v *Listener) keepAccepting() {
 		go func() {
 			if v.tlsConfig != nil {
 				conn = tls.Server(conn, v.tlsConfig)
-			} else if v.realityConfig != nil {
-				if conn, err = reality.Server(conn, v.realityConfig); err != nil {
+			} else if v.realityDispatcher != nil {
+				if conn, err = v.realityDispatcher.Handshake(conn); err != nil {
 					errors.LogInfo(context.Background(), err.Error())
 					return
 				}
@@ -137,6 +135,9 @@ func (v *Listener) Addr() net.Addr {
 
 // Close implements internet.Listener.Close.
 func (v *Listener) Close() error {
+	if v.realityDispatcher != nil {
+		v.realityDispatcher.Close()
+	}
 	return v.listener.Close()
 }
 
@@ -23,6 +23,7 @@ const (
 type pipeOption struct {
 	limit           int32 // maximum buffer size in bytes
 	discardOverflow bool
+	onDone          func() // or nil
 }
 
 func (o *pipeOption) isFull(curSize int32) bool {
@@ -175,22 +176,21 @@ func (p *pipe) WriteMultiBuffer(mb buf.MultiBuffer) error {
 
 func (p *pipe) Close() error {
 	p.Lock()
-	defer p.Unlock()
-
 	if p.state == closed || p.state == errord {
+		p.Unlock()
 		return nil
 	}
 
 	p.state = closed
 	common.Must(p.done.Close())
+	p.Unlock()
+	p.notifyDone()
 	return nil
 }
 
 // Interrupt implements common.Interruptible.
 func (p *pipe) Interrupt() {
 	p.Lock()
-	defer p.Unlock()
-
 	if !p.data.IsEmpty() {
 		buf.ReleaseMulti(p.data)
 		p.data = nil
@@ -200,10 +200,20 @@ func (p *pipe) Interrupt() {
 	}
 
 	if p.state == closed || p.state == errord {
+		p.Unlock()
 		return
 	}
 
 	p.state = errord
 
 	common.Must(p.done.Close())
+	p.Unlock()
+	p.notifyDone()
+}
+
+// notifyDone calls the OnDone function, once the pipe is done and not locked.
+func (p *pipe) notifyDone() {
+	if p.option.onDone != nil {
+		p.option.onDone()
+	}
 }
@@ -32,6 +32,13 @@ func DiscardOverflow() Option {
 	}
 }
 
+// OnDone returns an Option for Pipe to call f once it is closed or interrupted.
+func OnDone(f func()) Option {
+	return func(opt *pipeOption) {
+		opt.onDone = f
+	}
+}
+
 // OptionsFromContext returns a list of Options from context.
 func OptionsFromContext(ctx context.Context) []Option {
 	var opt []Option